@@ -0,0 +1,107 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+// bypassIDPrefix marks alerts built from secret scanning alerts.
+const bypassIDPrefix = "push-bypass-"
+
+// bypasses returns an alert for every secret scanning alert whose push
+// protection was bypassed in the configured time period.
+func (p *Provider) bypasses(ctx context.Context) ([]schema.Alert, error) {
+	opts := &github.SecretScanningAlertListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	cutoff := p.cutoff()
+
+	var alerts []schema.Alert
+	for {
+		found, resp, err := p.api.SecretScanning.ListAlertsForRepo(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		for _, a := range found {
+			if a.GetPushProtectionBypassed() && a.GetPushProtectionBypassedAt().After(cutoff) {
+				alerts = append(alerts, p.convertBypass(a))
+			}
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return alerts, nil
+		}
+		opts.ListOptions.Page = resp.NextPage
+	}
+}
+
+// getBypass fetches a push protection bypass by secret scanning alert number.
+func (p *Provider) getBypass(ctx context.Context, id, rest string) (schema.Alert, error) {
+	number, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil {
+		return schema.Alert{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("invalid alert ID: %s", id),
+		}
+	}
+
+	a, _, err := p.api.SecretScanning.GetAlert(ctx, p.config.Owner, p.config.Repo, number)
+	if err != nil {
+		return schema.Alert{}, p.wrapError(err)
+	}
+	// Secret scanning alerts that were not pushed past protection are not ours to report
+	if !a.GetPushProtectionBypassed() {
+		return schema.Alert{}, &orcherr.OpsOrchError{
+			Code:    "not_found",
+			Message: fmt.Sprintf("secret scanning alert %d was not a push protection bypass", number),
+		}
+	}
+	return p.convertBypass(a), nil
+}
+
+// convertBypass converts a bypassed secret scanning alert to a normalized
+// Alert. The secret itself is never copied.
+func (p *Provider) convertBypass(a *github.SecretScanningAlert) schema.Alert {
+	secretType := a.GetSecretTypeDisplayName()
+	if secretType == "" {
+		secretType = a.GetSecretType()
+	}
+	by := a.GetPushProtectionBypassedBy().GetLogin()
+
+	status := "open"
+	if a.GetState() == "resolved" {
+		status = "resolved"
+	}
+
+	updated := a.GetUpdatedAt().Time
+	if updated.IsZero() {
+		updated = a.GetPushProtectionBypassedAt().Time
+	}
+
+	alert := schema.Alert{
+		ID:          fmt.Sprintf("%s%d", bypassIDPrefix, a.GetNumber()),
+		Title:       fmt.Sprintf("Push protection bypassed for %s by %s", secretType, by),
+		Description: fmt.Sprintf("%s pushed a %s to %s past secret scanning push protection", by, secretType, p.repository()),
+		Status:      status,
+		Severity:    "critical",
+		Service:     p.repository(),
+		URL:         a.GetHTMLURL(),
+		CreatedAt:   a.GetPushProtectionBypassedAt().Time,
+		UpdatedAt:   updated,
+		Fields: map[string]any{
+			"source":       SourcePushProtectionBypass,
+			"repository":   p.repository(),
+			"alert_number": a.GetNumber(),
+			"secret_type":  a.GetSecretType(),
+			"bypassed_by":  by,
+			"state":        a.GetState(),
+		},
+	}
+	if resolution := a.GetResolution(); resolution != "" {
+		alert.Fields["resolution"] = resolution
+		alert.Fields["resolved_by"] = a.GetResolvedBy().GetLogin()
+	}
+	return alert
+}
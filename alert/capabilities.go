@@ -0,0 +1,12 @@
+package alert
+
+import "github.com/opsorch/opsorch-github-adapter/capability"
+
+// Capabilities describes what the provider supports. Queries read the whole
+// configured time period, so any limit is honored.
+func (p *Provider) Capabilities() capability.Capabilities {
+	return capability.Capabilities{
+		Provider:         "alert",
+		SupportedFilters: []string{"statuses", "severities", "query", "metadata.source"},
+	}
+}
@@ -0,0 +1,143 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+// forcePushIDPrefix marks alerts built from repository activity entries.
+const forcePushIDPrefix = "force-push-"
+
+// forcePushes returns an alert for every force push to a protected branch in
+// the configured time period.
+func (p *Provider) forcePushes(ctx context.Context) ([]schema.Alert, error) {
+	protected, err := p.protectedBranches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Without protected branches there is nothing to report
+	if len(protected) == 0 {
+		return nil, nil
+	}
+
+	activity, err := p.listForcePushes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []schema.Alert
+	for _, a := range activity {
+		if protected[branchName(a.Ref)] {
+			alerts = append(alerts, p.convertForcePush(a))
+		}
+	}
+	return alerts, nil
+}
+
+// getForcePush finds a force push by activity ID. The activity API has no
+// single-entry endpoint, so it scans the configured time period.
+func (p *Provider) getForcePush(ctx context.Context, id, rest string) (schema.Alert, error) {
+	activity, err := p.listForcePushes(ctx)
+	if err != nil {
+		return schema.Alert{}, err
+	}
+	for _, a := range activity {
+		if fmt.Sprint(a.ID) == rest {
+			return p.convertForcePush(a), nil
+		}
+	}
+	return schema.Alert{}, &orcherr.OpsOrchError{
+		Code:    "not_found",
+		Message: fmt.Sprintf("force push %s not found in the last %s", id, p.config.TimePeriod),
+	}
+}
+
+// listForcePushes pages through the force pushes of the configured time period.
+func (p *Provider) listForcePushes(ctx context.Context) ([]*ghapi.RepositoryActivity, error) {
+	opts := &ghapi.ActivityListOptions{
+		ActivityType: "force_push",
+		TimePeriod:   p.config.TimePeriod,
+		PerPage:      100,
+	}
+
+	var all []*ghapi.RepositoryActivity
+	for {
+		activity, resp, err := p.api.Activity.ListRepositoryActivity(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		all = append(all, activity...)
+		if resp == nil || resp.After == "" {
+			return all, nil
+		}
+		opts.After = resp.After
+	}
+}
+
+// protectedBranches returns the names of the repository's protected branches.
+func (p *Provider) protectedBranches(ctx context.Context) (map[string]bool, error) {
+	protected := true
+	opts := &github.BranchListOptions{
+		Protected:   &protected,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	names := map[string]bool{}
+	for {
+		branches, resp, err := p.api.Repositories.ListBranches(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		for _, b := range branches {
+			names[b.GetName()] = true
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return names, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// convertForcePush converts a force push activity entry to a normalized Alert.
+func (p *Provider) convertForcePush(a *ghapi.RepositoryActivity) schema.Alert {
+	branch := branchName(a.Ref)
+	actor := a.Actor.GetLogin()
+	return schema.Alert{
+		ID:          fmt.Sprintf("%s%d", forcePushIDPrefix, a.ID),
+		Title:       fmt.Sprintf("Force push to %s by %s", branch, actor),
+		Description: fmt.Sprintf("%s force-pushed protected branch %s from %s to %s", actor, branch, shortSHA(a.Before), shortSHA(a.After)),
+		Status:      "open",
+		Severity:    "warning",
+		Service:     p.repository(),
+		URL:         fmt.Sprintf("https://github.com/%s/compare/%s...%s", p.repository(), a.Before, a.After),
+		CreatedAt:   a.Timestamp.Time,
+		UpdatedAt:   a.Timestamp.Time,
+		Fields: map[string]any{
+			"source":     SourceForcePush,
+			"repository": p.repository(),
+			"branch":     branch,
+			"actor":      actor,
+			"before":     a.Before,
+			"after":      a.After,
+		},
+	}
+}
+
+// branchName strips the refs/heads/ prefix from a ref.
+func branchName(ref string) string {
+	return strings.TrimPrefix(ref, "refs/heads/")
+}
+
+// shortSHA abbreviates a commit SHA the way GitHub displays it.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
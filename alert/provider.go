@@ -0,0 +1,234 @@
+// Package alert surfaces risky repository operations — force pushes to
+// protected branches and secret scanning push protection bypasses — as
+// OpsOrch alerts, so they show up in incident timelines.
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/alert"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/gherr"
+)
+
+// Alert sources, selected with the "sources" config key and narrowed per query
+// with metadata "source".
+const (
+	SourceForcePush            = "force_push"
+	SourcePushProtectionBypass = "push_protection_bypass"
+)
+
+// Time periods accepted by the "timePeriod" config key, matching the
+// repository activity API.
+var timePeriods = map[string]time.Duration{
+	"day":     24 * time.Hour,
+	"week":    7 * 24 * time.Hour,
+	"month":   30 * 24 * time.Hour,
+	"quarter": 90 * 24 * time.Hour,
+	"year":    365 * 24 * time.Hour,
+}
+
+// Provider implements the alert.Provider interface for GitHub repository activity.
+type Provider struct {
+	api    ghapi.Services
+	config Config
+}
+
+// Config holds the configuration for the GitHub alert provider.
+type Config struct {
+	Token      string   `json:"token"`      // GitHub personal access token
+	Owner      string   `json:"owner"`      // Repository owner (user or organization)
+	Repo       string   `json:"repo"`       // Repository name
+	Sources    []string `json:"sources"`    // Alert sources to read (default: all)
+	TimePeriod string   `json:"timePeriod"` // How far back to look: day, week (default), month, quarter, or year
+}
+
+// New creates a new GitHub alert provider.
+func New(cfg map[string]any) (alert.Provider, error) {
+	// Build the API services: GitHub authenticated with the token (falling back to
+	// GITHUB_TOKEN), or local fixtures when mode is "fixtures"
+	api, err := ghconfig.Services(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := NewWithServices(cfg, api)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewWithServices creates a GitHub alert provider backed by the given API
+// implementations instead of a token-authenticated client. The force_push
+// source requires the Activity and Repositories services, and the
+// push_protection_bypass source the SecretScanning service.
+func NewWithServices(cfg map[string]any, api ghapi.Services) (*Provider, error) {
+	var config Config
+	config.Token = ghconfig.Token(cfg)
+
+	// Parse owner/repo, accepting the "repository" shorthand and GITHUB_REPOSITORY
+	owner, repo, err := ghconfig.Repository(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if owner == "" {
+		return nil, fmt.Errorf("owner is required")
+	}
+	if repo == "" {
+		return nil, fmt.Errorf("repo is required")
+	}
+	config.Owner = owner
+	config.Repo = repo
+
+	// Parse alert sources (optional)
+	config.Sources = ghconfig.StringSlice(cfg, "sources")
+	if len(config.Sources) == 0 {
+		config.Sources = []string{SourceForcePush, SourcePushProtectionBypass}
+	}
+	for i, source := range config.Sources {
+		config.Sources[i] = strings.ToLower(source)
+		switch config.Sources[i] {
+		case SourceForcePush:
+			if api.Activity == nil || api.Repositories == nil {
+				return nil, fmt.Errorf("activity and repositories services are required for the %s source", SourceForcePush)
+			}
+		case SourcePushProtectionBypass:
+			if api.SecretScanning == nil {
+				return nil, fmt.Errorf("secret scanning service is required for the %s source", SourcePushProtectionBypass)
+			}
+		default:
+			return nil, fmt.Errorf("unknown source %q (expected %s or %s)", source, SourceForcePush, SourcePushProtectionBypass)
+		}
+	}
+
+	// Parse the lookback period (optional)
+	config.TimePeriod = strings.ToLower(ghconfig.String(cfg, "timePeriod"))
+	switch _, ok := timePeriods[config.TimePeriod]; {
+	case config.TimePeriod == "":
+		config.TimePeriod = "week"
+	case !ok:
+		return nil, fmt.Errorf("unknown timePeriod %q (expected day, week, month, quarter, or year)", config.TimePeriod)
+	}
+
+	return &Provider{
+		api:    api,
+		config: config,
+	}, nil
+}
+
+// Query returns the force pushes and push protection bypasses of the configured
+// time period, newest first. Metadata "source" limits the query to one source;
+// statuses, severities, and the free-text query filter the results.
+func (p *Provider) Query(ctx context.Context, query schema.AlertQuery) ([]schema.Alert, error) {
+	sources := p.config.Sources
+	if source, ok := query.Metadata["source"].(string); ok && source != "" {
+		if !p.hasSource(source) {
+			return nil, &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: fmt.Sprintf("source %q is not enabled for this provider", source),
+			}
+		}
+		sources = []string{strings.ToLower(source)}
+	}
+
+	var alerts []schema.Alert
+	for _, source := range sources {
+		var found []schema.Alert
+		var err error
+		switch source {
+		case SourceForcePush:
+			found, err = p.forcePushes(ctx)
+		case SourcePushProtectionBypass:
+			found, err = p.bypasses(ctx)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range found {
+			if matches(a, query) {
+				alerts = append(alerts, a)
+			}
+		}
+	}
+
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].CreatedAt.After(alerts[j].CreatedAt) })
+	if query.Limit > 0 && len(alerts) > query.Limit {
+		alerts = alerts[:query.Limit]
+	}
+	return alerts, nil
+}
+
+// Get returns a single alert by its ID: "force-push-<activity ID>" or
+// "push-bypass-<secret scanning alert number>".
+func (p *Provider) Get(ctx context.Context, id string) (schema.Alert, error) {
+	if rest, ok := strings.CutPrefix(id, forcePushIDPrefix); ok && p.hasSource(SourceForcePush) {
+		return p.getForcePush(ctx, id, rest)
+	}
+	if rest, ok := strings.CutPrefix(id, bypassIDPrefix); ok && p.hasSource(SourcePushProtectionBypass) {
+		return p.getBypass(ctx, id, rest)
+	}
+	return schema.Alert{}, &orcherr.OpsOrchError{
+		Code:    "bad_request",
+		Message: fmt.Sprintf("invalid alert ID: %s", id),
+	}
+}
+
+// hasSource reports whether source is enabled.
+func (p *Provider) hasSource(source string) bool {
+	for _, s := range p.config.Sources {
+		if strings.EqualFold(s, source) {
+			return true
+		}
+	}
+	return false
+}
+
+// cutoff returns the start of the configured time period.
+func (p *Provider) cutoff() time.Time {
+	return time.Now().Add(-timePeriods[p.config.TimePeriod])
+}
+
+// repository returns the configured repository as owner/name.
+func (p *Provider) repository() string {
+	return p.config.Owner + "/" + p.config.Repo
+}
+
+// matches reports whether a satisfies the status, severity, and text filters of query.
+func matches(a schema.Alert, query schema.AlertQuery) bool {
+	if len(query.Statuses) > 0 && !containsFold(query.Statuses, a.Status) {
+		return false
+	}
+	if len(query.Severities) > 0 && !containsFold(query.Severities, a.Severity) {
+		return false
+	}
+	if text := strings.ToLower(strings.TrimSpace(query.Query)); text != "" {
+		return strings.Contains(strings.ToLower(a.Title), text) || strings.Contains(strings.ToLower(a.Description), text)
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// wrapError wraps GitHub API errors into OpsOrch errors.
+func (p *Provider) wrapError(err error) error {
+	return gherr.Wrap(err, "repository or alert")
+}
+
+func init() {
+	alert.RegisterProvider("github", New)
+}
@@ -0,0 +1,223 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+// newFakeProvider returns a provider wired to a fake GitHub server that has
+// force pushes and push protection bypasses on acme/api.
+func newFakeProvider(t *testing.T) (*Provider, *fakegithub.Server) {
+	srv := fakegithub.New(t)
+	ago := func(d time.Duration) string { return time.Now().Add(-d).UTC().Format(time.RFC3339) }
+
+	srv.Handle(http.MethodGet, "/repos/acme/api/branches", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("protected") != "true" {
+			t.Errorf("branches requested without protected=true: %s", r.URL.RawQuery)
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"name": "main", "protected": true}})
+	})
+	// Two pages of force pushes, linked by an after cursor
+	srv.Handle(http.MethodGet, "/repos/acme/api/activity", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("after") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/repos/acme/api/activity?after=c1>; rel="next"`, r.Host))
+			fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+				{"id": 71, "ref": "refs/heads/main", "before": "1111111aaaa", "after": "2222222bbbb", "timestamp": ago(time.Hour), "activity_type": "force_push", "actor": map[string]any{"login": "alice"}},
+				{"id": 72, "ref": "refs/heads/feature", "before": "3333333", "after": "4444444", "timestamp": ago(2 * time.Hour), "activity_type": "force_push", "actor": map[string]any{"login": "bob"}},
+			})
+			return
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"id": 70, "ref": "refs/heads/main", "before": "5555555", "after": "6666666", "timestamp": ago(72 * time.Hour), "activity_type": "force_push", "actor": map[string]any{"login": "carol"}},
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/secret-scanning/alerts", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"number": 2, "state": "open", "secret_type": "npm_access_token", "secret_type_display_name": "npm Access Token", "secret": "npm_leakedvalue",
+				"html_url": "https://github.com/acme/api/security/secret-scanning/2", "push_protection_bypassed": true,
+				"push_protection_bypassed_by": map[string]any{"login": "dave"}, "push_protection_bypassed_at": ago(30 * time.Minute)},
+			{"number": 3, "state": "open", "secret_type": "github_personal_access_token", "secret": "ghp_leakedvalue", "push_protection_bypassed": false},
+			{"number": 1, "state": "resolved", "resolution": "revoked", "secret_type": "aws_access_key_id", "push_protection_bypassed": true,
+				"push_protection_bypassed_by": map[string]any{"login": "erin"}, "push_protection_bypassed_at": ago(30 * 24 * time.Hour)},
+		})
+	})
+
+	return &Provider{
+		api: ghapi.FromClient(srv.Client()),
+		config: Config{
+			Owner:      fakegithub.Owner,
+			Repo:       fakegithub.Repo,
+			Sources:    []string{SourceForcePush, SourcePushProtectionBypass},
+			TimePeriod: "week",
+		},
+	}, srv
+}
+
+func hasCode(err error, code string) bool {
+	var opsErr *orcherr.OpsOrchError
+	return errors.As(err, &opsErr) && opsErr.Code == code
+}
+
+func ids(alerts []schema.Alert) []string {
+	var out []string
+	for _, a := range alerts {
+		out = append(out, a.ID)
+	}
+	return out
+}
+
+func TestNewWithServices(t *testing.T) {
+	full := ghapi.FromClient(fakegithub.New(t).Client())
+	tests := []struct {
+		name    string
+		config  map[string]any
+		api     ghapi.Services
+		wantErr bool
+	}{
+		{"all sources", map[string]any{"repository": "acme/api"}, full, false},
+		{"missing repository", map[string]any{"owner": "acme"}, full, true},
+		{"unknown source", map[string]any{"repository": "acme/api", "sources": []any{"deploy_key"}}, full, true},
+		{"unknown time period", map[string]any{"repository": "acme/api", "timePeriod": "fortnight"}, full, true},
+		{"force pushes without activity", map[string]any{"repository": "acme/api"}, ghapi.Services{SecretScanning: full.SecretScanning}, true},
+		{"bypasses only", map[string]any{"repository": "acme/api", "sources": []any{"push_protection_bypass"}}, ghapi.Services{SecretScanning: full.SecretScanning}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWithServices(tt.config, tt.api)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewWithServices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	p, srv := newFakeProvider(t)
+
+	alerts, err := p.Query(context.Background(), schema.AlertQuery{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	// Newest first; the unprotected branch, the unbypassed alert, and the bypass
+	// outside the time period are left out
+	if want := []string{"push-bypass-2", "force-push-71", "force-push-70"}; !reflect.DeepEqual(ids(alerts), want) {
+		t.Fatalf("Query() = %v, want %v", ids(alerts), want)
+	}
+
+	push := alerts[1]
+	if push.Title != "Force push to main by alice" || push.Severity != "warning" || push.Service != "acme/api" {
+		t.Errorf("force push = %+v", push)
+	}
+	if push.URL != "https://github.com/acme/api/compare/1111111aaaa...2222222bbbb" {
+		t.Errorf("force push URL = %s", push.URL)
+	}
+
+	bypass := alerts[0]
+	if bypass.Status != "open" || bypass.Severity != "critical" || bypass.Fields["bypassed_by"] != "dave" {
+		t.Errorf("bypass = %+v", bypass)
+	}
+	if data, _ := json.Marshal(alerts); bytes.Contains(data, []byte("leakedvalue")) {
+		t.Errorf("alerts leak a secret: %s", data)
+	}
+
+	for _, r := range srv.Requests() {
+		if r.Path == "/repos/acme/api/activity" && (r.Query.Get("activity_type") != "force_push" || r.Query.Get("time_period") != "week") {
+			t.Errorf("activity query = %v", r.Query)
+		}
+	}
+}
+
+func TestQueryFilters(t *testing.T) {
+	p, _ := newFakeProvider(t)
+	ctx := context.Background()
+
+	alerts, err := p.Query(ctx, schema.AlertQuery{Metadata: map[string]any{"source": "force_push"}, Limit: 1})
+	if err != nil || !reflect.DeepEqual(ids(alerts), []string{"force-push-71"}) {
+		t.Errorf("source query = %v, %v", ids(alerts), err)
+	}
+
+	alerts, err = p.Query(ctx, schema.AlertQuery{Severities: []string{"critical"}})
+	if err != nil || !reflect.DeepEqual(ids(alerts), []string{"push-bypass-2"}) {
+		t.Errorf("severity query = %v, %v", ids(alerts), err)
+	}
+
+	alerts, err = p.Query(ctx, schema.AlertQuery{Query: "carol"})
+	if err != nil || !reflect.DeepEqual(ids(alerts), []string{"force-push-70"}) {
+		t.Errorf("text query = %v, %v", ids(alerts), err)
+	}
+
+	p.config.Sources = []string{SourceForcePush}
+	if _, err := p.Query(ctx, schema.AlertQuery{Metadata: map[string]any{"source": "push_protection_bypass"}}); !hasCode(err, "bad_request") {
+		t.Errorf("disabled source error = %v, want bad_request", err)
+	}
+}
+
+func TestGet(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api/secret-scanning/alerts/2", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"number": 2, "state": "open", "secret_type": "npm_access_token",
+			"secret": "npm_leakedvalue", "push_protection_bypassed": true, "push_protection_bypassed_by": map[string]any{"login": "dave"}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/secret-scanning/alerts/3", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"number": 3, "state": "open", "push_protection_bypassed": false})
+	})
+	ctx := context.Background()
+
+	got, err := p.Get(ctx, "push-bypass-2")
+	if err != nil || got.Fields["secret_type"] != "npm_access_token" {
+		t.Errorf("Get(push-bypass-2) = %+v, %v", got, err)
+	}
+	got, err = p.Get(ctx, "force-push-70")
+	if err != nil || got.Fields["actor"] != "carol" {
+		t.Errorf("Get(force-push-70) = %+v, %v", got, err)
+	}
+
+	for id, code := range map[string]string{
+		"push-bypass-3": "not_found",
+		"push-bypass-9": "not_found",
+		"force-push-99": "not_found",
+		"push-bypass-x": "bad_request",
+		"dependabot-1":  "bad_request",
+	} {
+		if _, err := p.Get(ctx, id); !hasCode(err, code) {
+			t.Errorf("Get(%s) error = %v, want %s", id, err, code)
+		}
+	}
+}
+
+func TestErrorWrapping(t *testing.T) {
+	tests := []struct {
+		status int
+		code   string
+	}{
+		{http.StatusUnauthorized, "unauthorized"},
+		{http.StatusForbidden, "forbidden"},
+		{http.StatusNotFound, "not_found"},
+		{http.StatusUnprocessableEntity, "bad_request"},
+		{http.StatusBadGateway, "provider_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			p, srv := newFakeProvider(t)
+			srv.Error(http.MethodGet, "/repos/acme/api/secret-scanning/alerts", tt.status, "boom")
+
+			_, err := p.Query(context.Background(), schema.AlertQuery{})
+			if !hasCode(err, tt.code) {
+				t.Errorf("Query() error = %v, want code %s", err, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+// Package analytics derives reliability metrics that need both halves of the
+// adapter's data. TimeToRestore matches incident issues to the deployment that
+// shipped their fix, by the SHA of the commit or merged pull request that closed
+// them, and reports how long each took to restore.
+package analytics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/ticket"
+)
+
+// Tickets is the subset of the ticket provider TimeToRestore uses.
+type Tickets interface {
+	Resolved(ctx context.Context, input ticket.ResolvedInput) (ticket.ResolvedIssues, error)
+}
+
+// Deployments is the subset of the deployment provider TimeToRestore uses.
+type Deployments interface {
+	DeploymentOf(ctx context.Context, owner, repo, sha, environment string) (schema.Deployment, bool, error)
+}
+
+// Input selects the incidents TimeToRestore reports on.
+type Input struct {
+	Since       time.Time `json:"since,omitempty"`       // Start of the window incidents were closed in; defaults to 30 days before Until
+	Until       time.Time `json:"until,omitempty"`       // End of the window; defaults to now
+	Labels      []string  `json:"labels,omitempty"`      // Labels marking incident issues; defaults to "incident"
+	Environment string    `json:"environment,omitempty"` // Only count deployments to this environment
+}
+
+// Restore is one incident and the deployment that restored it. Deployment is
+// empty when the issue was not closed by a commit, or the commit was never
+// successfully deployed.
+type Restore struct {
+	Ticket               string     `json:"ticket"`
+	URL                  string     `json:"url"`
+	OpenedAt             time.Time  `json:"openedAt"`
+	ClosedAt             time.Time  `json:"closedAt"`
+	PullRequest          string     `json:"pullRequest,omitempty"`
+	Commit               string     `json:"commit,omitempty"`
+	Deployment           string     `json:"deployment,omitempty"`
+	DeploymentURL        string     `json:"deploymentUrl,omitempty"`
+	RestoredAt           *time.Time `json:"restoredAt,omitempty"`           // When the deployment finished
+	TimeToRestoreSeconds *int64     `json:"timeToRestoreSeconds,omitempty"` // From the issue opening to RestoredAt
+}
+
+// MTTR is the time to restore of the incidents closed in a window. The mean and
+// median cover the Matched incidents, those with a deployment, and are nil when
+// there are none. Sampled and Truncated are as for ticket.ResolvedIssues.
+type MTTR struct {
+	Since                      time.Time `json:"since"`
+	Until                      time.Time `json:"until"`
+	Incidents                  []Restore `json:"incidents"`
+	Matched                    int       `json:"matched"`
+	MeanTimeToRestoreSeconds   *int64    `json:"meanTimeToRestoreSeconds,omitempty"`
+	MedianTimeToRestoreSeconds *int64    `json:"medianTimeToRestoreSeconds,omitempty"`
+	Sampled                    bool      `json:"sampled,omitempty"`
+	Truncated                  bool      `json:"truncated,omitempty"`
+}
+
+// TimeToRestore lists the incidents closed in a window and matches each to the
+// first successful deployment of the commit that closed it. A deployment that
+// cannot be read, such as one in a repository the token cannot see, is logged
+// and leaves its incident unmatched.
+func TimeToRestore(ctx context.Context, tickets Tickets, deployments Deployments, input Input) (MTTR, error) {
+	resolved, err := tickets.Resolved(ctx, ticket.ResolvedInput{Since: input.Since, Until: input.Until, Labels: input.Labels})
+	if err != nil {
+		return MTTR{}, err
+	}
+	result := MTTR{
+		Since:     resolved.Since,
+		Until:     resolved.Until,
+		Incidents: make([]Restore, 0, len(resolved.Issues)),
+		Sampled:   resolved.Sampled,
+		Truncated: resolved.Truncated,
+	}
+
+	// Incidents fixed by the same commit share its deployment
+	type lookup struct {
+		deployment schema.Deployment
+		ok         bool
+	}
+	seen := map[string]lookup{}
+	var durations []int64
+	for _, issue := range resolved.Issues {
+		r := Restore{
+			Ticket:      issue.Ticket,
+			URL:         issue.URL,
+			OpenedAt:    issue.OpenedAt,
+			ClosedAt:    issue.ClosedAt,
+			PullRequest: issue.PullRequest,
+			Commit:      issue.Commit,
+		}
+		owner, repo, _ := strings.Cut(issue.CommitRepository, "/")
+		if issue.Commit == "" || owner == "" || result.Truncated {
+			result.Incidents = append(result.Incidents, r)
+			continue
+		}
+
+		key := issue.CommitRepository + "@" + issue.Commit
+		found, cached := seen[key]
+		if !cached {
+			d, ok, err := deployments.DeploymentOf(ctx, owner, repo, issue.Commit, input.Environment)
+			switch {
+			case errors.Is(err, budget.ErrExhausted):
+				result.Truncated = true
+			case unreadable(err):
+				log.Printf("[analytics] %s: deployment of %s: %v", issue.Ticket, key, err)
+			case err != nil:
+				return MTTR{}, err
+			}
+			found = lookup{deployment: d, ok: ok}
+			seen[key] = found
+		}
+
+		if found.ok {
+			restored := found.deployment.FinishedAt.UTC()
+			seconds := int64(restored.Sub(issue.OpenedAt).Seconds())
+			r.Deployment, r.DeploymentURL = found.deployment.ID, found.deployment.URL
+			r.RestoredAt, r.TimeToRestoreSeconds = &restored, &seconds
+			durations = append(durations, seconds)
+		}
+		result.Incidents = append(result.Incidents, r)
+	}
+
+	result.Matched = len(durations)
+	if len(durations) > 0 {
+		var sum int64
+		for _, d := range durations {
+			sum += d
+		}
+		mean := sum / int64(len(durations))
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		median := durations[len(durations)/2]
+		if len(durations)%2 == 0 {
+			median = (durations[len(durations)/2-1] + median) / 2
+		}
+		result.MeanTimeToRestoreSeconds, result.MedianTimeToRestoreSeconds = &mean, &median
+	}
+	return result, nil
+}
+
+// unreadable reports whether err means a deployment's repository cannot be read
+// with this token, rather than that GitHub failed.
+func unreadable(err error) bool {
+	var oe *orcherr.OpsOrchError
+	return errors.As(err, &oe) && (oe.Code == "not_found" || oe.Code == "forbidden")
+}
@@ -0,0 +1,83 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ticket"
+)
+
+type fakeTickets struct {
+	resolved ticket.ResolvedIssues
+}
+
+func (f *fakeTickets) Resolved(context.Context, ticket.ResolvedInput) (ticket.ResolvedIssues, error) {
+	return f.resolved, nil
+}
+
+type fakeDeployments struct {
+	bySHA map[string]schema.Deployment
+	errs  map[string]error
+	calls []string
+}
+
+func (f *fakeDeployments) DeploymentOf(_ context.Context, owner, repo, sha, _ string) (schema.Deployment, bool, error) {
+	f.calls = append(f.calls, owner+"/"+repo+"@"+sha)
+	if err := f.errs[sha]; err != nil {
+		return schema.Deployment{}, false, err
+	}
+	d, ok := f.bySHA[sha]
+	return d, ok, nil
+}
+
+func TestTimeToRestore(t *testing.T) {
+	opened := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tickets := &fakeTickets{resolved: ticket.ResolvedIssues{Issues: []ticket.Resolution{
+		{Ticket: "1", OpenedAt: opened, Commit: "aaa", CommitRepository: "acme/api", PullRequest: "7"},
+		{Ticket: "2", OpenedAt: opened.Add(time.Hour), Commit: "aaa", CommitRepository: "acme/api"},
+		{Ticket: "3", OpenedAt: opened},                                                  // Closed by hand
+		{Ticket: "4", OpenedAt: opened, Commit: "bbb", CommitRepository: "acme/api"},     // Never deployed
+		{Ticket: "5", OpenedAt: opened, Commit: "ccc", CommitRepository: "acme/private"}, // Unreadable
+		{Ticket: "6", OpenedAt: opened, Commit: "ddd", CommitRepository: "acme/api"},
+	}}}
+	deployments := &fakeDeployments{
+		bySHA: map[string]schema.Deployment{
+			"aaa": {ID: "101", FinishedAt: opened.Add(3 * time.Hour)},
+			"ddd": {ID: "102", FinishedAt: opened.Add(5 * time.Hour)},
+		},
+		errs: map[string]error{"ccc": &orcherr.OpsOrchError{Code: "not_found", Message: "Not Found"}},
+	}
+
+	result, err := TimeToRestore(context.Background(), tickets, deployments, Input{})
+	if err != nil {
+		t.Fatalf("TimeToRestore() error = %v", err)
+	}
+	if result.Matched != 3 || len(result.Incidents) != 6 {
+		t.Fatalf("result = %+v", result)
+	}
+	// 3h, 2h, and 5h
+	if *result.MeanTimeToRestoreSeconds != 12000 || *result.MedianTimeToRestoreSeconds != 10800 {
+		t.Errorf("mean = %d, median = %d", *result.MeanTimeToRestoreSeconds, *result.MedianTimeToRestoreSeconds)
+	}
+	if r := result.Incidents[0]; r.Deployment != "101" || r.PullRequest != "7" || *r.TimeToRestoreSeconds != 10800 || !r.RestoredAt.Equal(opened.Add(3*time.Hour)) {
+		t.Errorf("incident 1 = %+v", r)
+	}
+	for _, i := range []int{2, 3, 4} {
+		if r := result.Incidents[i]; r.Deployment != "" || r.TimeToRestoreSeconds != nil {
+			t.Errorf("incident %s = %+v, want unmatched", r.Ticket, r)
+		}
+	}
+	// Incidents fixed by the same commit share one lookup
+	if len(deployments.calls) != 4 {
+		t.Errorf("calls = %v", deployments.calls)
+	}
+
+	deployments.errs["ddd"] = errors.New("boom")
+	if _, err := TimeToRestore(context.Background(), tickets, deployments, Input{}); err == nil {
+		t.Error("expected error for a failed deployment lookup")
+	}
+}
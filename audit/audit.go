@@ -0,0 +1,123 @@
+// Package audit keeps an append-only record of the writes providers make to
+// GitHub — issues created and edited, comments, labels, workflow dispatches, and
+// deletions — so changes made through OpsOrch can be traced for compliance.
+// Dry runs write nothing to GitHub and are not recorded.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// Entry records one write. Failed writes are recorded too, with Error set.
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Operation  string    `json:"operation"`        // What was written, e.g. "ticket.create" or "deployment.trigger"
+	Actor      string    `json:"actor,omitempty"`  // Who the config acts as, from "auditActor"
+	Token      string    `json:"token,omitempty"`  // Fingerprint of the token the write was made with
+	Repository string    `json:"repository"`       // owner/repo written to
+	Target     string    `json:"target,omitempty"` // Issue number, workflow, or other object written
+	Input      any       `json:"input,omitempty"`  // What was sent to GitHub
+	URL        string    `json:"url,omitempty"`    // GitHub URL of the result
+	Error      string    `json:"error,omitempty"`
+}
+
+// Func receives entries in process, for embedders that forward them to their
+// own audit trail. It is passed in config under "auditFunc".
+type Func func(Entry)
+
+// Log records entries to a JSON Lines file, a Func, or both. A nil Log records
+// nothing, so providers without audit config need no checks.
+type Log struct {
+	mu    sync.Mutex
+	path  string
+	fn    Func
+	actor string
+	token string
+	now   func() time.Time
+}
+
+// Parse builds the log from the "auditLog" file and "auditFunc" config keys,
+// or returns nil when neither is set. The file is created if needed, so a path
+// that cannot be written fails provider construction instead of the first write.
+func Parse(cfg map[string]any) (*Log, error) {
+	l := &Log{path: ghconfig.String(cfg, "auditLog"), actor: ghconfig.String(cfg, "auditActor"), now: time.Now}
+	if fn, ok := cfg["auditFunc"].(Func); ok {
+		l.fn = fn
+	} else if fn, ok := cfg["auditFunc"].(func(Entry)); ok {
+		l.fn = fn
+	}
+	if l.path == "" && l.fn == nil {
+		return nil, nil
+	}
+	if l.path != "" {
+		f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: fmt.Sprintf("auditLog: %v", err),
+			}
+		}
+		f.Close()
+	}
+	if token := ghconfig.Token(cfg); token != "" {
+		l.token = Fingerprint(token)
+	}
+	return l, nil
+}
+
+// Fingerprint identifies a token without revealing it: "sha256:" and the first
+// 12 hex digits of its hash.
+func Fingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// Record stamps e with the time and the config's identity, then appends it.
+// Failures are logged, since the write it describes has already happened.
+func (l *Log) Record(e Entry) {
+	if l == nil {
+		return
+	}
+	e.Time = l.now().UTC()
+	e.Actor, e.Token = l.actor, l.token
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.fn != nil {
+		l.fn(e)
+	}
+	if l.path == "" {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err == nil {
+		err = appendLine(l.path, data)
+	}
+	if err != nil {
+		log.Printf("[audit] recording %s on %s: %v", e.Operation, e.Repository, err)
+	}
+}
+
+// appendLine appends data and a newline to path in a single write, so
+// concurrent writers never interleave within an entry.
+func appendLine(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	if l, err := Parse(map[string]any{"auditActor": "opsorch"}); l != nil || err != nil {
+		t.Errorf("Parse() without a destination = %v, %v, want nil", l, err)
+	}
+	if _, err := Parse(map[string]any{"auditLog": filepath.Join(t.TempDir(), "missing", "audit.jsonl")}); err == nil {
+		t.Error("Parse() with an unwritable auditLog succeeded")
+	}
+
+	// A nil log records nothing
+	var l *Log
+	l.Record(Entry{Operation: "ticket.create"})
+}
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	var got []Entry
+	l, err := Parse(map[string]any{
+		"auditLog":   path,
+		"auditActor": "opsorch-prod",
+		"token":      "ghp_secret",
+		"auditFunc":  Func(func(e Entry) { got = append(got, e) }),
+	})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	l.Record(Entry{Operation: "ticket.create", Repository: "acme/api", Target: "7", URL: "https://github.com/acme/api/issues/7"})
+	l.Record(Entry{Operation: "ticket.comment", Repository: "acme/api", Target: "7", Error: "forbidden: denied"})
+
+	if len(got) != 2 || got[0].Actor != "opsorch-prod" || !got[0].Time.Equal(now) {
+		t.Errorf("callback entries = %+v", got)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, e)
+	}
+	if len(lines) != 2 || lines[0].Target != "7" || lines[1].Error != "forbidden: denied" {
+		t.Errorf("audit log = %+v", lines)
+	}
+	if lines[0].Token != Fingerprint("ghp_secret") || lines[0].Token == "ghp_secret" {
+		t.Errorf("token = %q, want a fingerprint", lines[0].Token)
+	}
+}
@@ -0,0 +1,19 @@
+// Package capability describes what each GitHub provider supports, so OpsOrch
+// can adapt its UI and flows to this adapter instead of discovering gaps from
+// failed calls. Every provider has a Capabilities method, and the plugins
+// answer "<kind>.capabilities" with the same descriptor.
+package capability
+
+// Capabilities describes one provider as configured.
+type Capabilities struct {
+	Provider         string   `json:"provider"`         // Provider kind: ticket, deployment, team, alert, change, or service
+	SupportsCreate   bool     `json:"supportsCreate"`   // Records can be created
+	SupportsUpdate   bool     `json:"supportsUpdate"`   // Records can be updated
+	SupportsComments bool     `json:"supportsComments"` // Records have comments that can be read and added
+	SupportsTrigger  bool     `json:"supportsTrigger"`  // Runs can be started
+	SupportsWatch    bool     `json:"supportsWatch"`    // A record can be polled until it changes or finishes
+	SupportsPaging   bool     `json:"supportsPaging"`   // Queries return continuation tokens
+	DryRun           bool     `json:"dryRun"`           // Writes are simulated and never reach GitHub
+	MaxLimit         int      `json:"maxLimit"`         // Most results one query returns; 0 means no cap
+	SupportedFilters []string `json:"supportedFilters"` // Query fields and metadata keys the provider honors
+}
@@ -0,0 +1,178 @@
+package change
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+// DefaultApprovalCheck is the name of the approval check run when
+// "approvalCheck" is true rather than a name.
+const DefaultApprovalCheck = "opsorch/approval"
+
+// Approval states of a change request, as published on the approval check.
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+// ApprovalInput is the state of the OpsOrch change request a pull request
+// implements.
+type ApprovalInput struct {
+	ChangeRequest string         `json:"changeRequest"`        // OpsOrch change request ID, kept as the check run's external ID
+	State         string         `json:"state"`                // pending, approved, or rejected
+	Approver      string         `json:"approver,omitempty"`   // Who approved or rejected the change
+	Reason        string         `json:"reason,omitempty"`     // Shown in the check run's summary
+	DetailsURL    string         `json:"detailsUrl,omitempty"` // Link to the change request in OpsOrch
+	Metadata      map[string]any `json:"metadata,omitempty"`
+}
+
+// Approval is the approval check as published on a pull request's head commit.
+type Approval struct {
+	PullRequest   string `json:"pullRequest"`
+	ChangeRequest string `json:"changeRequest"`
+	State         string `json:"state"`
+	Check         string `json:"check"`
+	CheckRunID    int64  `json:"checkRunId,omitempty"`
+	HeadSHA       string `json:"headSha"`
+	URL           string `json:"url,omitempty"`
+	DryRun        bool   `json:"dryRun,omitempty"`
+}
+
+// SetApproval publishes the state of a pull request's change request as the
+// approval check run on its head commit, updating the run already there.
+// Only approved concludes the check with success, so a branch protection rule
+// requiring the check holds the pull request until OpsOrch approves it.
+// Check runs belong to commits, so commits pushed after an approval need it
+// published again.
+func (p *Provider) SetApproval(ctx context.Context, id string, input ApprovalInput) (Approval, error) {
+	if p.api.Checks == nil {
+		return Approval{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "check runs are not available for this provider",
+		}
+	}
+	if input.ChangeRequest == "" {
+		return Approval{}, requestError("a change request is required")
+	}
+	switch input.State {
+	case ApprovalPending, ApprovalApproved, ApprovalRejected:
+	default:
+		return Approval{}, requestError("state must be pending, approved, or rejected, got %q", input.State)
+	}
+	pr, err := p.getPullRequest(ctx, id)
+	if err != nil {
+		return Approval{}, err
+	}
+	return p.publishApproval(ctx, p.pullRequestID(pr.GetNumber()), pr.GetHead().GetSHA(), input, p.isDryRun(input.Metadata))
+}
+
+// publishApproval creates or updates the approval check run on sha, the head
+// of pull request id.
+func (p *Provider) publishApproval(ctx context.Context, id, sha string, input ApprovalInput, dryRun bool) (Approval, error) {
+	approval := Approval{
+		PullRequest:   id,
+		ChangeRequest: input.ChangeRequest,
+		State:         input.State,
+		Check:         p.approvalCheck(),
+		HeadSHA:       sha,
+	}
+
+	runs, _, err := p.api.Checks.ListCheckRunsForRef(ctx, p.config.Owner, p.config.Repo, sha, &github.ListCheckRunsOptions{
+		CheckName: github.String(approval.Check),
+	})
+	if err != nil {
+		return Approval{}, p.wrapError(err)
+	}
+	var existing *github.CheckRun
+	if len(runs.CheckRuns) > 0 {
+		existing = runs.CheckRuns[0]
+	}
+
+	status, conclusion, output := approvalCheckRun(input)
+	var completedAt *github.Timestamp
+	if conclusion != nil {
+		completedAt = &github.Timestamp{Time: time.Now()}
+	}
+	var detailsURL *string
+	if input.DetailsURL != "" {
+		detailsURL = github.String(input.DetailsURL)
+	}
+
+	if dryRun {
+		log.Printf("[dry-run] check run %s on %s: status=%s change=%s", approval.Check, sha, status, input.ChangeRequest)
+		approval.CheckRunID, approval.DryRun = existing.GetID(), true
+		return approval, nil
+	}
+
+	var run *github.CheckRun
+	if existing != nil {
+		run, _, err = p.api.Checks.UpdateCheckRun(ctx, p.config.Owner, p.config.Repo, existing.GetID(), github.UpdateCheckRunOptions{
+			Name:        approval.Check,
+			DetailsURL:  detailsURL,
+			ExternalID:  github.String(input.ChangeRequest),
+			Status:      github.String(status),
+			Conclusion:  conclusion,
+			CompletedAt: completedAt,
+			Output:      output,
+		})
+	} else {
+		run, _, err = p.api.Checks.CreateCheckRun(ctx, p.config.Owner, p.config.Repo, github.CreateCheckRunOptions{
+			Name:        approval.Check,
+			HeadSHA:     sha,
+			DetailsURL:  detailsURL,
+			ExternalID:  github.String(input.ChangeRequest),
+			Status:      github.String(status),
+			Conclusion:  conclusion,
+			CompletedAt: completedAt,
+			Output:      output,
+		})
+	}
+	p.audit("change.setApproval", id, map[string]any{"changeRequest": input.ChangeRequest, "state": input.State, "approver": input.Approver, "sha": sha}, run.GetHTMLURL(), err)
+	if err != nil {
+		return Approval{}, p.wrapError(err)
+	}
+	approval.CheckRunID, approval.URL = run.GetID(), run.GetHTMLURL()
+	return approval, nil
+}
+
+// approvalCheck returns the name of the approval check run.
+func (p *Provider) approvalCheck() string {
+	if p.config.ApprovalCheck != "" {
+		return p.config.ApprovalCheck
+	}
+	return DefaultApprovalCheck
+}
+
+// approvalCheckRun returns the check run status, conclusion, and output that
+// show an approval state: in progress while pending, then success or failure.
+func approvalCheckRun(input ApprovalInput) (string, *string, *github.CheckRunOutput) {
+	var title, conclusion string
+	switch input.State {
+	case ApprovalApproved:
+		title, conclusion = "Change approved", "success"
+	case ApprovalRejected:
+		title, conclusion = "Change rejected", "failure"
+	default:
+		title = "Waiting for change approval"
+	}
+
+	summary := fmt.Sprintf("OpsOrch change request %s is %s.", input.ChangeRequest, input.State)
+	if input.Approver != "" && input.State != ApprovalPending {
+		summary = fmt.Sprintf("OpsOrch change request %s was %s by %s.", input.ChangeRequest, input.State, input.Approver)
+	}
+	if input.Reason != "" {
+		summary += "\n\n" + input.Reason
+	}
+	output := &github.CheckRunOutput{Title: github.String(title), Summary: github.String(summary)}
+
+	if conclusion == "" {
+		return "in_progress", nil, output
+	}
+	return "completed", github.String(conclusion), output
+}
@@ -0,0 +1,14 @@
+package change
+
+import "github.com/opsorch/opsorch-github-adapter/capability"
+
+// Capabilities describes what the provider supports. Files are created and
+// updated by the same write, which reports which it did.
+func (p *Provider) Capabilities() capability.Capabilities {
+	return capability.Capabilities{
+		Provider:       "change",
+		SupportsCreate: true,
+		SupportsUpdate: true,
+		DryRun:         p.config.ReadOnly,
+	}
+}
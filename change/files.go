@@ -0,0 +1,390 @@
+package change
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// Actions a commit takes on a file, as reported in Commit.Action and passed to
+// commit message templates as {{ action }}.
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionUnchanged = "unchanged"
+)
+
+// commitSHAPattern matches a full commit SHA, which refs can be created at.
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// placeholderPattern matches {{ name }} placeholders in commit message
+// templates, as in issue templates.
+var placeholderPattern = regexp.MustCompile(`{{\s*([\w.-]+)\s*}}`)
+
+// File is a repository file read at a ref.
+type File struct {
+	Path    string `json:"path"`
+	Ref     string `json:"ref"`
+	SHA     string `json:"sha"` // Blob SHA, passed back on write to detect concurrent changes
+	Size    int    `json:"size"`
+	Content string `json:"content"`
+	URL     string `json:"url"`
+}
+
+// WriteInput is a change to one file. Branch is created from Base when it does
+// not exist, so a change can be staged away from the default branch.
+type WriteInput struct {
+	Path     string         `json:"path"`
+	Content  string         `json:"content"`
+	Branch   string         `json:"branch,omitempty"`  // Branch to commit to; defaults to the repository's default branch
+	Base     string         `json:"base,omitempty"`    // Branch or tag a new Branch starts from; defaults to the default branch
+	SHA      string         `json:"sha,omitempty"`     // Blob SHA the file must still have, from ReadFile; empty skips the check
+	Message  string         `json:"message,omitempty"` // Commit message template; defaults to "commitMessage"
+	Values   map[string]any `json:"values,omitempty"`  // Values for the message template's placeholders
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// Commit is the result of a file write. Action is ActionUnchanged, with no
+// commit made, when the file already had the content.
+type Commit struct {
+	Path          string `json:"path"`
+	Branch        string `json:"branch"`
+	Action        string `json:"action"`
+	Message       string `json:"message,omitempty"`
+	SHA           string `json:"sha,omitempty"`     // Commit SHA
+	FileSHA       string `json:"fileSha,omitempty"` // Blob SHA of the written file
+	URL           string `json:"url,omitempty"`
+	BranchCreated bool   `json:"branchCreated,omitempty"`
+	DryRun        bool   `json:"dryRun,omitempty"`
+}
+
+// ReadFile returns the file at path as of ref, a branch, tag, or commit SHA;
+// an empty ref reads the default branch.
+func (p *Provider) ReadFile(ctx context.Context, path, ref string) (File, error) {
+	path, err := cleanPath(path)
+	if err != nil {
+		return File{}, err
+	}
+	var opts *github.RepositoryContentGetOptions
+	if ref != "" {
+		opts = &github.RepositoryContentGetOptions{Ref: ref}
+	}
+	file, dir, _, err := p.api.Repositories.GetContents(ctx, p.config.Owner, p.config.Repo, path, opts)
+	if err != nil {
+		return File{}, p.wrapError(err)
+	}
+	if file == nil || dir != nil {
+		return File{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("%s is a directory", path),
+		}
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		// Files over a megabyte come without content
+		return File{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("%s cannot be read through the contents API: %v", path, err),
+		}
+	}
+	return File{
+		Path:    file.GetPath(),
+		Ref:     ref,
+		SHA:     file.GetSHA(),
+		Size:    file.GetSize(),
+		Content: content,
+		URL:     file.GetHTMLURL(),
+	}, nil
+}
+
+// WriteFile commits the content to the file through the contents API, creating
+// the file if needed. Writing the content a file already has makes no commit.
+func (p *Provider) WriteFile(ctx context.Context, input WriteInput) (Commit, error) {
+	path, err := cleanPath(input.Path)
+	if err != nil {
+		return Commit{}, err
+	}
+	input.Path = path
+	dryRun := p.isDryRun(input.Metadata)
+
+	// The default branch always exists; another is created from Base if needed
+	branch, readRef, created := input.Branch, input.Branch, false
+	if branch == "" {
+		if branch, err = p.defaultBranch(ctx); err != nil {
+			return Commit{}, err
+		}
+		readRef = branch
+	} else if created, readRef, err = p.ensureBranch(ctx, branch, input.Base, dryRun); err != nil {
+		return Commit{}, err
+	}
+
+	commit, err := p.commitFile(ctx, branch, readRef, input, dryRun)
+	commit.BranchCreated = created
+	return commit, err
+}
+
+// commitFile commits input's content to its path on an existing branch,
+// comparing it with the file at readRef, and checking the file still has blob
+// SHA input.SHA when it is set.
+func (p *Provider) commitFile(ctx context.Context, branch, readRef string, input WriteInput, dryRun bool) (Commit, error) {
+	path, content := input.Path, input.Content
+	commit := Commit{Path: path, Branch: branch, DryRun: dryRun}
+
+	action, currentSHA, err := p.planFile(ctx, readRef, input)
+	if err != nil {
+		return Commit{}, err
+	}
+	commit.Action = action
+	if action == ActionUnchanged {
+		commit.FileSHA = currentSHA
+		return commit, nil
+	}
+
+	message := input.Message
+	if message == "" {
+		message = p.config.CommitMessage
+	}
+	builtins := map[string]any{"action": commit.Action, "path": path, "branch": branch}
+	if commit.Message, err = renderMessage(message, builtins, input.Values); err != nil {
+		return Commit{}, err
+	}
+	if dryRun {
+		log.Printf("[dry-run] PUT /repos/%s/%s/contents/%s branch=%s message=%q", p.config.Owner, p.config.Repo, path, branch, commit.Message)
+		return commit, nil
+	}
+
+	opts := &github.RepositoryContentFileOptions{
+		Message: github.String(commit.Message),
+		Content: []byte(content),
+		Branch:  github.String(branch),
+	}
+	write := p.api.Repositories.CreateFile
+	if currentSHA != "" {
+		opts.SHA = github.String(currentSHA)
+		write = p.api.Repositories.UpdateFile
+	}
+	resp, _, err := write(ctx, p.config.Owner, p.config.Repo, path, opts)
+	if resp != nil {
+		commit.SHA = resp.GetSHA()
+		commit.FileSHA = resp.GetContent().GetSHA()
+		commit.URL = resp.GetHTMLURL()
+	}
+	p.audit("change.writeFile", path+"@"+branch, map[string]any{"path": path, "branch": branch, "message": commit.Message}, commit.URL, err)
+	if err != nil {
+		return Commit{}, p.wrapError(err)
+	}
+	return commit, nil
+}
+
+// planFile compares input's content with the file at ref, returning the action
+// writing it takes and the file's current blob SHA. It fails with conflict when
+// input.SHA is set and the file no longer has it.
+func (p *Provider) planFile(ctx context.Context, ref string, input WriteInput) (action, currentSHA string, err error) {
+	current, err := p.currentFile(ctx, input.Path, ref)
+	if err != nil {
+		return "", "", err
+	}
+	action = ActionCreate
+	if current != nil {
+		currentSHA = current.GetSHA()
+		action = ActionUpdate
+		if existing, err := current.GetContent(); err == nil && existing == input.Content {
+			return ActionUnchanged, currentSHA, nil
+		}
+	}
+	if input.SHA != "" && input.SHA != currentSHA {
+		return "", "", &orcherr.OpsOrchError{
+			Code:    "conflict",
+			Message: fmt.Sprintf("%s at %s has changed since it was read", input.Path, ref),
+		}
+	}
+	return action, currentSHA, nil
+}
+
+// currentFile returns the file at path as of ref, or nil when there is none.
+func (p *Provider) currentFile(ctx context.Context, path, ref string) (*github.RepositoryContent, error) {
+	file, dir, _, err := p.api.Repositories.GetContents(ctx, p.config.Owner, p.config.Repo, path, &github.RepositoryContentGetOptions{Ref: ref})
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+	if file == nil || dir != nil {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("%s is a directory", path),
+		}
+	}
+	return file, nil
+}
+
+// defaultBranch returns the name of the repository's default branch.
+func (p *Provider) defaultBranch(ctx context.Context) (string, error) {
+	repo, _, err := p.api.Repositories.Get(ctx, p.config.Owner, p.config.Repo)
+	if err != nil {
+		return "", p.wrapError(err)
+	}
+	return repo.GetDefaultBranch(), nil
+}
+
+// ensureBranch creates branch from base, a branch or tag defaulting to the
+// default branch, unless it already exists. It reports whether the branch was
+// created and the ref its files are read at: the branch, or in a dry run, which
+// creates nothing, the commit it would start from. Without the Git service the
+// branch must already exist.
+func (p *Provider) ensureBranch(ctx context.Context, branch, base string, dryRun bool) (created bool, readRef string, err error) {
+	exists, sha, err := p.resolveBranch(ctx, branch, base)
+	if err != nil || exists {
+		return false, branch, err
+	}
+	if dryRun {
+		log.Printf("[dry-run] POST /repos/%s/%s/git/refs ref=refs/heads/%s sha=%s", p.config.Owner, p.config.Repo, branch, sha)
+		return true, sha, nil
+	}
+	if err := p.createBranch(ctx, branch, base, sha); err != nil {
+		return false, "", err
+	}
+	return true, branch, nil
+}
+
+// resolveBranch reports whether branch exists and, when it does not, the
+// commit SHA of base, a branch, tag, or commit SHA defaulting to the default
+// branch, that it would start from. Without the Git service branches are assumed to exist.
+func (p *Provider) resolveBranch(ctx context.Context, branch, base string) (exists bool, sha string, err error) {
+	if p.api.Git == nil {
+		return true, "", nil
+	}
+	_, _, err = p.api.Git.GetRef(ctx, p.config.Owner, p.config.Repo, "heads/"+branch)
+	if err == nil {
+		return true, "", nil
+	}
+	if !isNotFound(err) {
+		return false, "", p.wrapError(err)
+	}
+
+	if base == "" {
+		if base, err = p.defaultBranch(ctx); err != nil {
+			return false, "", err
+		}
+	}
+	sha, err = p.resolveRef(ctx, base)
+	return false, sha, err
+}
+
+// resolveRef returns the commit SHA of ref, a branch, a tag, or a full commit
+// SHA. Annotated tags are followed to the commit they tag.
+func (p *Provider) resolveRef(ctx context.Context, ref string) (string, error) {
+	var from *github.Reference
+	var err error
+	for _, prefix := range []string{"heads/", "tags/"} {
+		if from, _, err = p.api.Git.GetRef(ctx, p.config.Owner, p.config.Repo, prefix+ref); !isNotFound(err) {
+			break
+		}
+	}
+	if isNotFound(err) {
+		if commitSHAPattern.MatchString(ref) {
+			return ref, nil
+		}
+		return "", &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("%q is not a branch, tag, or commit SHA", ref),
+		}
+	}
+	if err != nil {
+		return "", p.wrapError(err)
+	}
+	return p.peel(ctx, from)
+}
+
+// peel returns the commit SHA ref points at, following an annotated tag to the
+// commit it tags.
+func (p *Provider) peel(ctx context.Context, ref *github.Reference) (string, error) {
+	if ref.GetObject().GetType() != "tag" {
+		return ref.GetObject().GetSHA(), nil
+	}
+	tag, _, err := p.api.Git.GetTag(ctx, p.config.Owner, p.config.Repo, ref.GetObject().GetSHA())
+	if err != nil {
+		return "", p.wrapError(err)
+	}
+	return tag.GetObject().GetSHA(), nil
+}
+
+// createBranch creates branch at commit sha, which base resolved to.
+func (p *Provider) createBranch(ctx context.Context, branch, base, sha string) error {
+	_, _, err := p.api.Git.CreateRef(ctx, p.config.Owner, p.config.Repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	})
+	p.audit("change.createBranch", branch, map[string]any{"base": base, "sha": sha}, "", err)
+	if err != nil {
+		return p.wrapError(err)
+	}
+	return nil
+}
+
+// isDryRun reports whether a write should be simulated, either because the
+// provider is read-only or because the request asked for a dry run.
+func (p *Provider) isDryRun(metadata map[string]any) bool {
+	return p.config.ReadOnly || ghconfig.Bool(metadata, "dryRun")
+}
+
+// renderMessage fills in the {{ name }} placeholders of a commit message
+// template from values, then builtins. A placeholder with no value is an
+// error, so a typo never reaches the commit history.
+func renderMessage(template string, builtins, values map[string]any) (string, error) {
+	var missing []string
+	message := placeholderPattern.ReplaceAllStringFunc(template, func(m string) string {
+		name := placeholderPattern.FindStringSubmatch(m)[1]
+		if v, ok := values[name]; ok {
+			return fmt.Sprint(v)
+		}
+		if v, ok := builtins[name]; ok {
+			return fmt.Sprint(v)
+		}
+		missing = append(missing, name)
+		return m
+	})
+	if len(missing) > 0 {
+		return "", &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("commit message: no value for %s", strings.Join(missing, ", ")),
+		}
+	}
+	if strings.TrimSpace(message) == "" {
+		return "", &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "commit message is empty",
+		}
+	}
+	return message, nil
+}
+
+// cleanPath trims slashes from a repository path and rejects paths that are
+// empty or leave the repository root.
+func cleanPath(path string) (string, error) {
+	path = strings.Trim(path, "/")
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: fmt.Sprintf("invalid file path %q", path),
+			}
+		}
+	}
+	return path, nil
+}
+
+// isNotFound reports whether err is GitHub's 404.
+func isNotFound(err error) bool {
+	var ghErr *github.ErrorResponse
+	return errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound
+}
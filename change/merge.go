@@ -0,0 +1,404 @@
+package change
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+// Merge methods, as configured with "mergeMethod" or given per request.
+const (
+	MergeMethodMerge  = "merge"
+	MergeMethodSquash = "squash"
+	MergeMethodRebase = "rebase"
+)
+
+// enableAutoMergeMutation turns on auto-merge, which the REST API cannot do.
+const enableAutoMergeMutation = `mutation($id: ID!, $method: PullRequestMergeMethod!, $headline: String, $body: String, $head: GitObjectID) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method, commitHeadline: $headline, commitBody: $body, expectedHeadOid: $head}) {
+    pullRequest { number }
+  }
+}`
+
+// MergeInput controls how a pull request is merged, now or by auto-merge.
+type MergeInput struct {
+	Method        string         `json:"method,omitempty"`        // merge, squash, or rebase; defaults to "mergeMethod"
+	CommitTitle   string         `json:"commitTitle,omitempty"`   // Title of the merge or squash commit; GitHub's default when empty
+	CommitMessage string         `json:"commitMessage,omitempty"` // Body of the merge or squash commit; GitHub's default when empty
+	SHA           string         `json:"sha,omitempty"`           // Head SHA the pull request must still have
+	Metadata      map[string]any `json:"metadata,omitempty"`
+}
+
+// CloseInput closes a pull request without merging it.
+type CloseInput struct {
+	Comment  string         `json:"comment,omitempty"` // Posted on the pull request before it is closed
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// PullRequestState is the outcome of merging, enabling auto-merge on, or
+// closing a pull request.
+type PullRequestState struct {
+	ID        string `json:"id"`
+	Number    int    `json:"number"`
+	URL       string `json:"url,omitempty"`
+	State     string `json:"state"` // open, closed, or merged
+	Method    string `json:"method,omitempty"`
+	MergeSHA  string `json:"mergeSha,omitempty"`
+	AutoMerge bool   `json:"autoMerge,omitempty"` // Auto-merge is enabled and GitHub merges once requirements are met
+	DryRun    bool   `json:"dryRun,omitempty"`
+}
+
+// MergePullRequest merges a pull request, given as N or owner/repo#N. A pull
+// request that is already merged is reported as such. One that cannot be
+// merged yet fails before the merge is attempted: with checks_pending while
+// checks run, checks_failed when they failed, merge_blocked for other branch
+// protection rules, and conflict when it conflicts with its base.
+func (p *Provider) MergePullRequest(ctx context.Context, id string, input MergeInput) (PullRequestState, error) {
+	pr, method, err := p.mergeablePullRequest(ctx, id, input.Method)
+	if err != nil || pr.GetMerged() {
+		return p.pullRequestState(pr, ""), err
+	}
+	state := p.pullRequestState(pr, method)
+
+	switch pr.GetMergeableState() {
+	case "dirty":
+		return PullRequestState{}, &orcherr.OpsOrchError{
+			Code:    "conflict",
+			Message: fmt.Sprintf("pull request %s has conflicts with %s", state.ID, pr.GetBase().GetRef()),
+		}
+	case "behind":
+		return PullRequestState{}, &orcherr.OpsOrchError{
+			Code:    "merge_blocked",
+			Message: fmt.Sprintf("pull request %s must be brought up to date with %s before it can merge", state.ID, pr.GetBase().GetRef()),
+		}
+	case "blocked":
+		return PullRequestState{}, p.blockedError(ctx, state.ID, pr.GetHead().GetSHA())
+	}
+
+	if p.isDryRun(input.Metadata) {
+		log.Printf("[dry-run] PUT /repos/%s/%s/pulls/%d/merge method=%s", p.config.Owner, p.config.Repo, state.Number, method)
+		state.State, state.DryRun = "merged", true
+		return state, nil
+	}
+
+	result, _, err := p.api.PullRequests.Merge(ctx, p.config.Owner, p.config.Repo, state.Number, input.CommitMessage, &github.PullRequestOptions{
+		CommitTitle: input.CommitTitle,
+		SHA:         input.SHA,
+		MergeMethod: method,
+	})
+	p.audit("change.mergePR", state.ID, map[string]any{"method": method, "sha": input.SHA}, state.URL, err)
+	if err != nil {
+		return PullRequestState{}, p.wrapError(err)
+	}
+	state.State, state.MergeSHA = "merged", result.GetSHA()
+	return state, nil
+}
+
+// EnableAutoMerge has GitHub merge a pull request once its branch protection
+// requirements, such as checks and reviews, are met. A pull request that can
+// already merge is rejected, so the caller merges it instead.
+func (p *Provider) EnableAutoMerge(ctx context.Context, id string, input MergeInput) (PullRequestState, error) {
+	if p.api.GraphQL == nil {
+		return PullRequestState{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "auto-merge is not available for this provider",
+		}
+	}
+	pr, method, err := p.mergeablePullRequest(ctx, id, input.Method)
+	if err != nil || pr.GetMerged() {
+		return p.pullRequestState(pr, ""), err
+	}
+	state := p.pullRequestState(pr, method)
+	switch pr.GetMergeableState() {
+	case "clean", "unstable", "has_hooks":
+		return PullRequestState{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("pull request %s can merge now; merge it instead of enabling auto-merge", state.ID),
+		}
+	}
+	state.AutoMerge = true
+
+	if p.isDryRun(input.Metadata) {
+		log.Printf("[dry-run] POST /graphql enablePullRequestAutoMerge number=%d method=%s", state.Number, method)
+		state.DryRun = true
+		return state, nil
+	}
+
+	vars := map[string]any{"id": pr.GetNodeID(), "method": strings.ToUpper(method)}
+	if input.CommitTitle != "" {
+		vars["headline"] = input.CommitTitle
+	}
+	if input.CommitMessage != "" {
+		vars["body"] = input.CommitMessage
+	}
+	if input.SHA != "" {
+		vars["head"] = input.SHA
+	}
+	var data struct{}
+	_, err = p.api.GraphQL.Query(ctx, enableAutoMergeMutation, vars, &data)
+	p.audit("change.enableAutoMerge", state.ID, map[string]any{"method": method, "sha": input.SHA}, state.URL, err)
+	if err != nil {
+		return PullRequestState{}, p.wrapGraphQLError(err)
+	}
+	return state, nil
+}
+
+// ClosePullRequest closes a pull request without merging it, after posting the
+// comment if one is given. Closing a closed pull request changes nothing.
+func (p *Provider) ClosePullRequest(ctx context.Context, id string, input CloseInput) (PullRequestState, error) {
+	if input.Comment != "" && p.api.Issues == nil {
+		return PullRequestState{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "comments are not available for this provider",
+		}
+	}
+	pr, err := p.getPullRequest(ctx, id)
+	if err != nil {
+		return PullRequestState{}, err
+	}
+	state := p.pullRequestState(pr, "")
+	if pr.GetMerged() {
+		return PullRequestState{}, &orcherr.OpsOrchError{
+			Code:    "conflict",
+			Message: fmt.Sprintf("pull request %s is already merged", state.ID),
+		}
+	}
+	if pr.GetState() == "closed" {
+		return state, nil
+	}
+
+	if p.isDryRun(input.Metadata) {
+		log.Printf("[dry-run] PATCH /repos/%s/%s/pulls/%d state=closed comment=%t", p.config.Owner, p.config.Repo, state.Number, input.Comment != "")
+		state.State, state.DryRun = "closed", true
+		return state, nil
+	}
+
+	if input.Comment != "" {
+		comment, _, err := p.api.Issues.CreateComment(ctx, p.config.Owner, p.config.Repo, state.Number, &github.IssueComment{Body: github.String(input.Comment)})
+		p.audit("change.comment", state.ID, map[string]any{"body": input.Comment}, comment.GetHTMLURL(), err)
+		if err != nil {
+			return PullRequestState{}, p.wrapError(err)
+		}
+	}
+	_, _, err = p.api.PullRequests.Edit(ctx, p.config.Owner, p.config.Repo, state.Number, &github.PullRequest{State: github.String("closed")})
+	p.audit("change.closePR", state.ID, map[string]any{"state": "closed"}, state.URL, err)
+	if err != nil {
+		return PullRequestState{}, p.wrapError(err)
+	}
+	state.State = "closed"
+	return state, nil
+}
+
+// mergeablePullRequest reads a pull request to merge and resolves the merge
+// method. It fails with conflict for a closed pull request; a merged one is
+// returned for the caller to report.
+func (p *Provider) mergeablePullRequest(ctx context.Context, id, method string) (*github.PullRequest, string, error) {
+	if method == "" {
+		method = p.config.MergeMethod
+	}
+	if !validMergeMethod(method) {
+		return nil, "", requestError("method must be merge, squash, or rebase, got %q", method)
+	}
+	pr, err := p.getPullRequest(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if pr.GetMerged() {
+		return pr, method, nil
+	}
+	if pr.GetState() == "closed" {
+		return nil, "", &orcherr.OpsOrchError{
+			Code:    "conflict",
+			Message: fmt.Sprintf("pull request %s is closed", p.pullRequestID(pr.GetNumber())),
+		}
+	}
+	if pr.GetDraft() {
+		return nil, "", &orcherr.OpsOrchError{
+			Code:    "merge_blocked",
+			Message: fmt.Sprintf("pull request %s is a draft", p.pullRequestID(pr.GetNumber())),
+		}
+	}
+	return pr, method, nil
+}
+
+// getPullRequest reads a pull request of the configured repository.
+func (p *Provider) getPullRequest(ctx context.Context, id string) (*github.PullRequest, error) {
+	if p.api.PullRequests == nil {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "pull requests are not available for this provider",
+		}
+	}
+	number, err := p.parsePullRequestID(id)
+	if err != nil {
+		return nil, err
+	}
+	pr, _, err := p.api.PullRequests.Get(ctx, p.config.Owner, p.config.Repo, number)
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+	return pr, nil
+}
+
+// blockedError explains why branch protection blocks a pull request whose head
+// is sha, from the checks and commit statuses on it. Rules it cannot see, such
+// as required reviews, are reported together as merge_blocked.
+func (p *Provider) blockedError(ctx context.Context, id, sha string) error {
+	pending, failed, err := p.headChecks(ctx, sha)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(failed) > 0:
+		return &orcherr.OpsOrchError{
+			Code:    "checks_failed",
+			Message: fmt.Sprintf("pull request %s cannot merge: checks failed: %s", id, strings.Join(failed, ", ")),
+		}
+	case len(pending) > 0:
+		return &orcherr.OpsOrchError{
+			Code:    "checks_pending",
+			Message: fmt.Sprintf("pull request %s cannot merge yet: checks pending: %s; enable auto-merge to merge once they pass", id, strings.Join(pending, ", ")),
+		}
+	}
+	return &orcherr.OpsOrchError{
+		Code:    "merge_blocked",
+		Message: fmt.Sprintf("pull request %s is blocked by branch protection, such as required reviews", id),
+	}
+}
+
+// headChecks returns the names of the check runs and commit statuses on sha
+// that are still pending and that failed, sorted.
+func (p *Provider) headChecks(ctx context.Context, sha string) (pending, failed []string, err error) {
+	_, pending, failed, err = p.checksAt(ctx, sha, time.Time{})
+	return pending, failed, err
+}
+
+// checksAt returns the names of the check runs and commit statuses on sha
+// that had passed, were pending, and had failed at a time, or now for the zero
+// time, sorted. Check runs started after it are left out. GitHub keeps only
+// the latest state of a commit status, so one updated after it counts as
+// pending. Without the Checks service only commit statuses are read.
+func (p *Provider) checksAt(ctx context.Context, sha string, at time.Time) (passed, pending, failed []string, err error) {
+	after := func(t github.Timestamp) bool { return !at.IsZero() && t.After(at) }
+	if p.api.Checks != nil {
+		runs, _, err := p.api.Checks.ListCheckRunsForRef(ctx, p.config.Owner, p.config.Repo, sha, &github.ListCheckRunsOptions{
+			ListOptions: github.ListOptions{PerPage: 100},
+		})
+		if err != nil {
+			return nil, nil, nil, p.wrapError(err)
+		}
+		for _, run := range runs.CheckRuns {
+			switch {
+			case after(run.GetStartedAt()):
+			case run.GetStatus() != "completed", after(run.GetCompletedAt()):
+				pending = append(pending, run.GetName())
+			case run.GetConclusion() == "failure", run.GetConclusion() == "timed_out", run.GetConclusion() == "cancelled", run.GetConclusion() == "action_required":
+				failed = append(failed, run.GetName())
+			case run.GetConclusion() == "success", run.GetConclusion() == "neutral", run.GetConclusion() == "skipped":
+				passed = append(passed, run.GetName())
+			}
+		}
+	}
+	combined, _, err := p.api.Repositories.GetCombinedStatus(ctx, p.config.Owner, p.config.Repo, sha, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, nil, nil, p.wrapError(err)
+	}
+	for _, status := range combined.Statuses {
+		switch {
+		case status.GetState() == "pending", after(status.GetUpdatedAt()):
+			pending = append(pending, status.GetContext())
+		case status.GetState() == "failure", status.GetState() == "error":
+			failed = append(failed, status.GetContext())
+		case status.GetState() == "success":
+			passed = append(passed, status.GetContext())
+		}
+	}
+	sort.Strings(passed)
+	sort.Strings(pending)
+	sort.Strings(failed)
+	return passed, pending, failed, nil
+}
+
+// pullRequestState describes pr, which may be nil after an error.
+func (p *Provider) pullRequestState(pr *github.PullRequest, method string) PullRequestState {
+	if pr == nil {
+		return PullRequestState{}
+	}
+	state := PullRequestState{
+		ID:     p.pullRequestID(pr.GetNumber()),
+		Number: pr.GetNumber(),
+		URL:    pr.GetHTMLURL(),
+		State:  pr.GetState(),
+		Method: method,
+	}
+	if pr.GetMerged() {
+		state.State, state.MergeSHA = "merged", pr.GetMergeCommitSHA()
+	}
+	state.AutoMerge = pr.AutoMerge != nil
+	return state
+}
+
+// pullRequestID returns the composite ID of pull request number.
+func (p *Provider) pullRequestID(number int) string {
+	return fmt.Sprintf("%s/%s#%d", p.config.Owner, p.config.Repo, number)
+}
+
+// parsePullRequestID parses N, #N, or owner/repo#N, which must name the
+// configured repository.
+func (p *Provider) parsePullRequestID(id string) (int, error) {
+	repo, n, found := strings.Cut(id, "#")
+	if !found {
+		n = repo
+	} else if repo != "" && !strings.EqualFold(repo, p.config.Owner+"/"+p.config.Repo) {
+		return 0, requestError("pull request %s is not in %s/%s", id, p.config.Owner, p.config.Repo)
+	}
+	number, err := strconv.Atoi(n)
+	if err != nil || number <= 0 {
+		return 0, requestError("invalid pull request ID %q", id)
+	}
+	return number, nil
+}
+
+// wrapGraphQLError maps GraphQL error types to OpsOrch errors, deferring HTTP
+// errors to wrapError.
+func (p *Provider) wrapGraphQLError(err error) error {
+	var gqlErr *ghapi.GraphQLError
+	if !errors.As(err, &gqlErr) {
+		return p.wrapError(err)
+	}
+
+	code := "provider_error"
+	for _, entry := range gqlErr.Errors {
+		switch entry.Type {
+		case "NOT_FOUND":
+			code = "not_found"
+		case "FORBIDDEN", "INSUFFICIENT_SCOPES":
+			code = "forbidden"
+		case "UNPROCESSABLE":
+			// Such as auto-merge not being allowed in the repository
+			code = "bad_request"
+		}
+	}
+	return &orcherr.OpsOrchError{
+		Code:    code,
+		Message: "GitHub GraphQL error: " + err.Error(),
+		Err:     err,
+	}
+}
+
+func validMergeMethod(method string) bool {
+	switch method {
+	case MergeMethodMerge, MergeMethodSquash, MergeMethodRebase:
+		return true
+	}
+	return false
+}
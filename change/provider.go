@@ -0,0 +1,151 @@
+// Package change makes GitOps changes in a GitHub repository: it reads files at
+// a ref and commits edits to them, so OpsOrch automations can bump a version
+// file or toggle a feature flag stored in Git. OpsOrch has no change provider
+// interface, so this package is used directly or through the change plugin.
+package change
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/audit"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/gherr"
+)
+
+// defaultCommitMessage is the commit message template used when neither the
+// request nor the config gives one.
+const defaultCommitMessage = "{{ action }} {{ path }}"
+
+// Provider reads and commits repository files.
+type Provider struct {
+	api    ghapi.Services
+	config Config
+}
+
+// Config holds the configuration for the GitHub change provider.
+type Config struct {
+	Token         string     `json:"token"`         // GitHub personal access token
+	Owner         string     `json:"owner"`         // Repository owner (user or organization)
+	Repo          string     `json:"repo"`          // Repository name
+	CommitMessage string     `json:"commitMessage"` // Template for commit messages a request does not give
+	MergeMethod   string     `json:"mergeMethod"`   // How pull requests are merged: merge, squash, or rebase
+	ApprovalCheck string     `json:"approvalCheck"` // Check run published pending on new pull requests until their change request is approved
+	ReadOnly      bool       `json:"readOnly"`      // Simulate writes instead of calling GitHub
+	Audit         *audit.Log `json:"-"`             // Record of writes to GitHub, from "auditLog" and "auditFunc"
+}
+
+// New creates a new GitHub change provider.
+func New(cfg map[string]any) (*Provider, error) {
+	// Build the API services: GitHub authenticated with the token (falling back to
+	// GITHUB_TOKEN), or local fixtures when mode is "fixtures"
+	api, err := ghconfig.Services(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithServices(cfg, api)
+}
+
+// NewWithServices creates a GitHub change provider backed by the given API
+// implementations instead of a token-authenticated client. The Repositories
+// service is required; without Git, writes cannot create branches.
+func NewWithServices(cfg map[string]any, api ghapi.Services) (*Provider, error) {
+	if api.Repositories == nil {
+		return nil, fmt.Errorf("repositories service is required")
+	}
+
+	var config Config
+	config.Token = ghconfig.Token(cfg)
+
+	// Parse owner/repo, accepting the "repository" shorthand and GITHUB_REPOSITORY
+	owner, repo, err := ghconfig.Repository(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if owner == "" {
+		return nil, fmt.Errorf("owner is required")
+	}
+	if repo == "" {
+		return nil, fmt.Errorf("repo is required")
+	}
+	config.Owner = owner
+	config.Repo = repo
+
+	// Parse the commit message template (optional)
+	config.CommitMessage = ghconfig.String(cfg, "commitMessage")
+	if config.CommitMessage == "" {
+		config.CommitMessage = defaultCommitMessage
+	}
+
+	// Parse the merge method (optional)
+	config.MergeMethod = ghconfig.String(cfg, "mergeMethod")
+	if config.MergeMethod == "" {
+		config.MergeMethod = MergeMethodMerge
+	}
+	if !validMergeMethod(config.MergeMethod) {
+		return nil, fmt.Errorf("mergeMethod must be merge, squash, or rebase, got %q", config.MergeMethod)
+	}
+
+	// Parse the approval check (optional): true selects the default name
+	switch name := ghconfig.String(cfg, "approvalCheck"); {
+	case ghconfig.Bool(cfg, "approvalCheck"):
+		config.ApprovalCheck = DefaultApprovalCheck
+	case name != "" && name != "false":
+		config.ApprovalCheck = name
+	}
+
+	// Parse read-only mode (optional)
+	config.ReadOnly = ghconfig.Bool(cfg, "readOnly")
+
+	// Open the audit log (optional)
+	if config.Audit, err = audit.Parse(cfg); err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		api:    api,
+		config: config,
+	}, nil
+}
+
+// audit records a write to the provider's repository in the audit log. target
+// is the file or branch written, and url the GitHub URL of the result.
+func (p *Provider) audit(operation, target string, input any, url string, err error) {
+	entry := audit.Entry{
+		Operation:  operation,
+		Repository: p.config.Owner + "/" + p.config.Repo,
+		Target:     target,
+		Input:      input,
+		URL:        url,
+	}
+	if err != nil {
+		entry.Error = p.wrapError(err).Error()
+	}
+	p.config.Audit.Record(entry)
+}
+
+// wrapError wraps GitHub API errors into OpsOrch errors.
+func (p *Provider) wrapError(err error) error {
+	var ghErr *github.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil {
+		switch ghErr.Response.StatusCode {
+		case http.StatusMethodNotAllowed:
+			// Branch protection or the repository's settings refused the merge
+			return &orcherr.OpsOrchError{
+				Code:    "merge_blocked",
+				Message: fmt.Sprintf("GitHub refused the merge: %s%s", ghErr.Message, gherr.Details(ghErr)),
+			}
+		case http.StatusConflict:
+			// The file or pull request head changed since its SHA was read
+			return &orcherr.OpsOrchError{
+				Code:    "conflict",
+				Message: fmt.Sprintf("GitHub API conflict: %s%s", ghErr.Message, gherr.Details(ghErr)),
+			}
+		}
+	}
+	return gherr.Wrap(err, "repository, ref, or file")
+}
@@ -0,0 +1,795 @@
+package change
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+const versionPath = "/repos/acme/api/contents/deploy/version.txt"
+
+// newFakeProvider returns a provider wired to a fake GitHub server whose
+// repository has deploy/version.txt on main and a v1 tag, both at commit
+// c0ffee. Branches created through the server have the file too.
+func newFakeProvider(t *testing.T, cfg map[string]any) (*Provider, *fakegithub.Server) {
+	t.Helper()
+	srv := fakegithub.New(t)
+	refs := map[string]bool{"": true, "main": true, "c0ffee": true}
+
+	srv.Handle(http.MethodGet, "/repos/acme/api", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"name": "api", "default_branch": "main"})
+	})
+	srv.Handle(http.MethodGet, versionPath, func(w http.ResponseWriter, r *http.Request) {
+		if !refs[r.URL.Query().Get("ref")] {
+			fakegithub.WriteError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+			"type":     "file",
+			"path":     "deploy/version.txt",
+			"sha":      "blob1",
+			"size":     6,
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString([]byte("1.4.2\n")),
+			"html_url": "https://github.com/acme/api/blob/main/deploy/version.txt",
+		})
+	})
+	srv.Handle(http.MethodPut, versionPath, func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+			"content": map[string]any{"path": "deploy/version.txt", "sha": "blob2"},
+			"commit":  map[string]any{"sha": "commit2", "html_url": "https://github.com/acme/api/commit/commit2"},
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/git/ref/heads/main", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"ref": "refs/heads/main", "object": map[string]any{"sha": "c0ffee", "type": "commit"}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/git/ref/tags/v1", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"ref": "refs/tags/v1", "object": map[string]any{"sha": "c0ffee"}})
+	})
+	srv.Handle(http.MethodPost, "/repos/acme/api/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		var ref struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		}
+		json.NewDecoder(r.Body).Decode(&ref)
+		refs[strings.TrimPrefix(ref.Ref, "refs/heads/")] = true
+		fakegithub.WriteJSON(w, http.StatusCreated, map[string]any{"ref": ref.Ref, "object": map[string]any{"sha": ref.SHA}})
+	})
+
+	config := map[string]any{"repository": "acme/api"}
+	for k, v := range cfg {
+		config[k] = v
+	}
+	p, err := NewWithServices(config, ghapi.FromClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("NewWithServices() error = %v", err)
+	}
+	return p, srv
+}
+
+func hasCode(err error, code string) bool {
+	var opsErr *orcherr.OpsOrchError
+	return errors.As(err, &opsErr) && opsErr.Code == code
+}
+
+// writes returns the bodies of the requests that wrote to GitHub.
+func writes(srv *fakegithub.Server) []map[string]any {
+	var out []map[string]any
+	for _, r := range srv.Requests() {
+		if r.Method == http.MethodGet {
+			continue
+		}
+		body := map[string]any{"path": r.Path}
+		json.Unmarshal(r.Body, &body)
+		out = append(out, body)
+	}
+	return out
+}
+
+func TestNewWithServices(t *testing.T) {
+	full := ghapi.FromClient(fakegithub.New(t).Client())
+	tests := []struct {
+		name    string
+		config  map[string]any
+		api     ghapi.Services
+		wantErr bool
+	}{
+		{"repository", map[string]any{"repository": "acme/api"}, full, false},
+		{"missing repo", map[string]any{"owner": "acme"}, full, true},
+		{"without repositories", map[string]any{"repository": "acme/api"}, ghapi.Services{Git: full.Git}, true},
+		{"without git", map[string]any{"repository": "acme/api"}, ghapi.Services{Repositories: full.Repositories}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWithServices(tt.config, tt.api)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewWithServices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	p, _ := newFakeProvider(t, nil)
+	ctx := context.Background()
+
+	file, err := p.ReadFile(ctx, "/deploy/version.txt", "main")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if file.Content != "1.4.2\n" || file.SHA != "blob1" || file.Ref != "main" || file.Path != "deploy/version.txt" {
+		t.Errorf("ReadFile() = %+v", file)
+	}
+
+	if _, err := p.ReadFile(ctx, "deploy/version.txt", "nope"); !hasCode(err, "not_found") {
+		t.Errorf("missing ref error = %v, want not_found", err)
+	}
+	if _, err := p.ReadFile(ctx, "deploy/../secrets", ""); !hasCode(err, "bad_request") {
+		t.Errorf("escaping path error = %v, want bad_request", err)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("update on default branch", func(t *testing.T) {
+		p, srv := newFakeProvider(t, map[string]any{"commitMessage": "chore: {{ action }} {{ path }} to {{ version }}"})
+		commit, err := p.WriteFile(ctx, WriteInput{
+			Path:    "deploy/version.txt",
+			Content: "1.5.0\n",
+			SHA:     "blob1",
+			Values:  map[string]any{"version": "1.5.0"},
+		})
+		if err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		if commit.Action != ActionUpdate || commit.Branch != "main" || commit.SHA != "commit2" || commit.FileSHA != "blob2" || commit.BranchCreated {
+			t.Errorf("WriteFile() = %+v", commit)
+		}
+		w := writes(srv)
+		if len(w) != 1 || w[0]["sha"] != "blob1" || w[0]["branch"] != "main" || w[0]["message"] != "chore: update deploy/version.txt to 1.5.0" {
+			t.Fatalf("writes = %v", w)
+		}
+		if w[0]["content"] != base64.StdEncoding.EncodeToString([]byte("1.5.0\n")) {
+			t.Errorf("content = %v", w[0]["content"])
+		}
+	})
+
+	t.Run("new branch from tag", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		commit, err := p.WriteFile(ctx, WriteInput{Path: "deploy/version.txt", Content: "1.4.3\n", Branch: "release", Base: "v1"})
+		if err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		if !commit.BranchCreated || commit.Branch != "release" || commit.Message != "update deploy/version.txt" {
+			t.Errorf("WriteFile() = %+v", commit)
+		}
+		w := writes(srv)
+		if len(w) != 2 || w[0]["ref"] != "refs/heads/release" || w[0]["sha"] != "c0ffee" || w[1]["branch"] != "release" {
+			t.Errorf("writes = %v", w)
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		commit, err := p.WriteFile(ctx, WriteInput{Path: "deploy/version.txt", Content: "1.4.2\n"})
+		if err != nil || commit.Action != ActionUnchanged || commit.FileSHA != "blob1" {
+			t.Fatalf("WriteFile() = %+v, %v", commit, err)
+		}
+		if w := writes(srv); len(w) != 0 {
+			t.Errorf("writes = %v", w)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		commit, err := p.WriteFile(ctx, WriteInput{
+			Path:     "deploy/version.txt",
+			Content:  "1.4.3\n",
+			Branch:   "release",
+			Base:     "v1",
+			Metadata: map[string]any{"dryRun": true},
+		})
+		if err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		// The file is compared with the tag the branch would start from
+		if !commit.DryRun || !commit.BranchCreated || commit.Action != ActionUpdate {
+			t.Errorf("WriteFile() = %+v", commit)
+		}
+		if w := writes(srv); len(w) != 0 {
+			t.Errorf("writes = %v", w)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		p, _ := newFakeProvider(t, nil)
+		tests := []struct {
+			name  string
+			input WriteInput
+			code  string
+		}{
+			{"stale sha", WriteInput{Path: "deploy/version.txt", Content: "1.5.0\n", SHA: "blob0"}, "conflict"},
+			{"unknown placeholder", WriteInput{Path: "deploy/version.txt", Content: "1.5.0\n", Message: "bump to {{ version }}"}, "bad_request"},
+			{"unknown base", WriteInput{Path: "deploy/version.txt", Content: "1.5.0\n", Branch: "release", Base: "v9"}, "bad_request"},
+			{"empty path", WriteInput{Content: "1.5.0\n"}, "bad_request"},
+		}
+		for _, tt := range tests {
+			if _, err := p.WriteFile(ctx, tt.input); !hasCode(err, tt.code) {
+				t.Errorf("%s: error = %v, want %s", tt.name, err, tt.code)
+			}
+		}
+	})
+}
+
+func TestCreatePullRequest(t *testing.T) {
+	ctx := context.Background()
+	input := PullRequestInput{
+		Title:         "Roll back to 1.4.1",
+		Branch:        "rollback-1.4.1",
+		Files:         []FileEdit{{Path: "deploy/version.txt", Content: "1.4.1\n", SHA: "blob1"}},
+		Message:       "Roll back {{ path }} to {{ version }}",
+		Values:        map[string]any{"version": "1.4.1"},
+		Labels:        []string{"rollback"},
+		Reviewers:     []string{"alice"},
+		TeamReviewers: []string{"sre"},
+	}
+	withPulls := func(srv *fakegithub.Server) {
+		srv.Handle(http.MethodPost, "/repos/acme/api/pulls", func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusCreated, map[string]any{"number": 12, "html_url": "https://github.com/acme/api/pull/12", "head": map[string]any{"sha": "head12"}})
+		})
+		srv.Handle(http.MethodPost, "/repos/acme/api/issues/12/labels", func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"name": "rollback"}})
+		})
+		srv.Handle(http.MethodPost, "/repos/acme/api/pulls/12/requested_reviewers", func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusCreated, map[string]any{"number": 12})
+		})
+	}
+
+	t.Run("opens", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		withPulls(srv)
+		pr, err := p.CreatePullRequest(ctx, input)
+		if err != nil {
+			t.Fatalf("CreatePullRequest() error = %v", err)
+		}
+		if pr.ID != "acme/api#12" || pr.Base != "main" || !pr.BranchCreated || len(pr.Commits) != 1 || pr.Commits[0].Message != "Roll back deploy/version.txt to 1.4.1" {
+			t.Errorf("CreatePullRequest() = %+v", pr)
+		}
+		w := writes(srv)
+		if len(w) != 5 {
+			t.Fatalf("writes = %v", w)
+		}
+		if w[0]["ref"] != "refs/heads/rollback-1.4.1" || w[1]["branch"] != "rollback-1.4.1" || w[1]["sha"] != "blob1" {
+			t.Errorf("branch and commit = %v", w[:2])
+		}
+		if w[2]["head"] != "rollback-1.4.1" || w[2]["base"] != "main" || w[2]["title"] != "Roll back to 1.4.1" {
+			t.Errorf("pull request = %v", w[2])
+		}
+		if w[4]["reviewers"].([]any)[0] != "alice" || w[4]["team_reviewers"].([]any)[0] != "sre" {
+			t.Errorf("reviewers = %v", w[4])
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		p, srv := newFakeProvider(t, map[string]any{"readOnly": true})
+		withPulls(srv)
+		pr, err := p.CreatePullRequest(ctx, input)
+		if err != nil || !pr.DryRun || pr.ID != "" || len(pr.Commits) != 1 || pr.Commits[0].Action != ActionUpdate {
+			t.Fatalf("CreatePullRequest() = %+v, %v", pr, err)
+		}
+		if w := writes(srv); len(w) != 0 {
+			t.Errorf("writes = %v", w)
+		}
+	})
+
+	t.Run("reviewers fail", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		withPulls(srv)
+		srv.Error(http.MethodPost, "/repos/acme/api/pulls/12/requested_reviewers", http.StatusUnprocessableEntity, "Reviews may only be requested from collaborators")
+		pr, err := p.CreatePullRequest(ctx, input)
+		if !hasCode(err, "bad_request") || pr.ID != "acme/api#12" {
+			t.Errorf("CreatePullRequest() = %+v, %v", pr, err)
+		}
+	})
+
+	t.Run("gates approval", func(t *testing.T) {
+		p, srv := newFakeProvider(t, map[string]any{"approvalCheck": true})
+		withPulls(srv)
+		withCheckRuns(srv, nil)
+		gated := input
+		gated.ChangeRequest = "CHG-42"
+		pr, err := p.CreatePullRequest(ctx, gated)
+		if err != nil || pr.Approval != DefaultApprovalCheck {
+			t.Fatalf("CreatePullRequest() = %+v, %v", pr, err)
+		}
+		w := writes(srv)
+		if len(w) != 6 || w[5]["name"] != DefaultApprovalCheck || w[5]["head_sha"] != "head12" || w[5]["status"] != "in_progress" || w[5]["external_id"] != "CHG-42" {
+			t.Errorf("writes = %v", w)
+		}
+	})
+
+	t.Run("rejected before writing", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		withPulls(srv)
+		unchanged := input
+		unchanged.Files = []FileEdit{{Path: "deploy/version.txt", Content: "1.4.2\n"}}
+		stale := input
+		stale.Files = []FileEdit{{Path: "deploy/version.txt", Content: "1.4.1\n", SHA: "blob0"}}
+		badMessage := input
+		badMessage.Values = nil
+		sameBranch := input
+		sameBranch.Branch = "main"
+		tests := []struct {
+			name  string
+			input PullRequestInput
+			code  string
+		}{
+			{"unchanged", unchanged, "bad_request"},
+			{"stale sha", stale, "conflict"},
+			{"unknown placeholder", badMessage, "bad_request"},
+			{"branch is base", sameBranch, "bad_request"},
+			{"no files", PullRequestInput{Title: "t", Branch: "b"}, "bad_request"},
+		}
+		for _, tt := range tests {
+			if _, err := p.CreatePullRequest(ctx, tt.input); !hasCode(err, tt.code) {
+				t.Errorf("%s: error = %v, want %s", tt.name, err, tt.code)
+			}
+		}
+		if w := writes(srv); len(w) != 0 {
+			t.Errorf("writes = %v", w)
+		}
+	})
+}
+
+// withPullRequest serves pull request 12 of acme/api with the given fields,
+// and check runs and statuses on its head.
+func withPullRequest(srv *fakegithub.Server, fields map[string]any, checkRuns, statuses []map[string]any) {
+	pr := map[string]any{
+		"number":          12,
+		"node_id":         "PR_kwDO12",
+		"state":           "open",
+		"html_url":        "https://github.com/acme/api/pull/12",
+		"mergeable_state": "clean",
+		"head":            map[string]any{"ref": "rollback-1.4.1", "sha": "head12"},
+		"base":            map[string]any{"ref": "main"},
+	}
+	for k, v := range fields {
+		pr[k] = v
+	}
+	srv.Handle(http.MethodGet, "/repos/acme/api/pulls/12", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, pr)
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/commits/head12/check-runs", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": len(checkRuns), "check_runs": checkRuns})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/commits/head12/status", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"state": "pending", "statuses": statuses})
+	})
+	srv.Handle(http.MethodPut, "/repos/acme/api/pulls/12/merge", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"merged": true, "sha": "merge12"})
+	})
+	srv.Handle(http.MethodPatch, "/repos/acme/api/pulls/12", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"number": 12, "state": "closed"})
+	})
+	srv.Handle(http.MethodPost, "/repos/acme/api/issues/12/comments", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusCreated, map[string]any{"id": 1})
+	})
+	srv.Handle(http.MethodPost, "/graphql", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"enablePullRequestAutoMerge": map[string]any{"pullRequest": map[string]any{"number": 12}}}})
+	})
+}
+
+func TestMergePullRequest(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("merges", func(t *testing.T) {
+		p, srv := newFakeProvider(t, map[string]any{"mergeMethod": "squash"})
+		withPullRequest(srv, nil, nil, nil)
+		state, err := p.MergePullRequest(ctx, "acme/api#12", MergeInput{SHA: "head12"})
+		if err != nil {
+			t.Fatalf("MergePullRequest() error = %v", err)
+		}
+		if state.State != "merged" || state.MergeSHA != "merge12" || state.Method != "squash" || state.ID != "acme/api#12" {
+			t.Errorf("MergePullRequest() = %+v", state)
+		}
+		w := writes(srv)
+		if len(w) != 1 || w[0]["merge_method"] != "squash" || w[0]["sha"] != "head12" {
+			t.Errorf("writes = %v", w)
+		}
+	})
+
+	t.Run("already merged", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		withPullRequest(srv, map[string]any{"state": "closed", "merged": true, "merge_commit_sha": "merge11"}, nil, nil)
+		state, err := p.MergePullRequest(ctx, "12", MergeInput{})
+		if err != nil || state.State != "merged" || state.MergeSHA != "merge11" {
+			t.Fatalf("MergePullRequest() = %+v, %v", state, err)
+		}
+		if w := writes(srv); len(w) != 0 {
+			t.Errorf("writes = %v", w)
+		}
+	})
+
+	t.Run("blocked", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			fields    map[string]any
+			checkRuns []map[string]any
+			statuses  []map[string]any
+			code      string
+			message   string
+		}{
+			{"pending", map[string]any{"mergeable_state": "blocked"}, []map[string]any{{"name": "test", "status": "in_progress"}, {"name": "lint", "status": "completed", "conclusion": "success"}}, []map[string]any{{"context": "ci/build", "state": "pending"}}, "checks_pending", "ci/build, test"},
+			{"failed", map[string]any{"mergeable_state": "blocked"}, []map[string]any{{"name": "test", "status": "completed", "conclusion": "failure"}}, nil, "checks_failed", "test"},
+			{"reviews", map[string]any{"mergeable_state": "blocked"}, nil, nil, "merge_blocked", "required reviews"},
+			{"conflicts", map[string]any{"mergeable_state": "dirty"}, nil, nil, "conflict", "conflicts with main"},
+			{"draft", map[string]any{"draft": true}, nil, nil, "merge_blocked", "draft"},
+			{"closed", map[string]any{"state": "closed"}, nil, nil, "conflict", "closed"},
+		}
+		for _, tt := range tests {
+			p, srv := newFakeProvider(t, nil)
+			withPullRequest(srv, tt.fields, tt.checkRuns, tt.statuses)
+			_, err := p.MergePullRequest(ctx, "12", MergeInput{})
+			if !hasCode(err, tt.code) || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("%s: error = %v, want %s mentioning %q", tt.name, err, tt.code, tt.message)
+			}
+			if w := writes(srv); len(w) != 0 {
+				t.Errorf("%s: writes = %v", tt.name, w)
+			}
+		}
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		withPullRequest(srv, map[string]any{"mergeable_state": "unknown"}, nil, nil)
+		srv.Error(http.MethodPut, "/repos/acme/api/pulls/12/merge", http.StatusMethodNotAllowed, "Pull Request is not mergeable")
+		if _, err := p.MergePullRequest(ctx, "12", MergeInput{}); !hasCode(err, "merge_blocked") {
+			t.Errorf("error = %v, want merge_blocked", err)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		p, _ := newFakeProvider(t, nil)
+		for _, id := range []string{"other/repo#12", "twelve", "0"} {
+			if _, err := p.MergePullRequest(ctx, id, MergeInput{}); !hasCode(err, "bad_request") {
+				t.Errorf("%s: error = %v, want bad_request", id, err)
+			}
+		}
+		if _, err := p.MergePullRequest(ctx, "12", MergeInput{Method: "octopus"}); !hasCode(err, "bad_request") {
+			t.Errorf("method error = %v, want bad_request", err)
+		}
+	})
+}
+
+func TestEnableAutoMerge(t *testing.T) {
+	ctx := context.Background()
+
+	p, srv := newFakeProvider(t, nil)
+	withPullRequest(srv, map[string]any{"mergeable_state": "blocked"}, nil, nil)
+	state, err := p.EnableAutoMerge(ctx, "acme/api#12", MergeInput{Method: "rebase"})
+	if err != nil || !state.AutoMerge || state.State != "open" || state.Method != "rebase" {
+		t.Fatalf("EnableAutoMerge() = %+v, %v", state, err)
+	}
+	w := writes(srv)
+	if len(w) != 1 {
+		t.Fatalf("writes = %v", w)
+	}
+	if vars := w[0]["variables"].(map[string]any); vars["id"] != "PR_kwDO12" || vars["method"] != "REBASE" {
+		t.Errorf("variables = %v", vars)
+	}
+
+	p, srv = newFakeProvider(t, nil)
+	withPullRequest(srv, nil, nil, nil)
+	if _, err := p.EnableAutoMerge(ctx, "12", MergeInput{}); !hasCode(err, "bad_request") {
+		t.Errorf("mergeable pull request error = %v, want bad_request", err)
+	}
+}
+
+func TestClosePullRequest(t *testing.T) {
+	ctx := context.Background()
+
+	p, srv := newFakeProvider(t, nil)
+	withPullRequest(srv, nil, nil, nil)
+	state, err := p.ClosePullRequest(ctx, "12", CloseInput{Comment: "Superseded by #13"})
+	if err != nil || state.State != "closed" {
+		t.Fatalf("ClosePullRequest() = %+v, %v", state, err)
+	}
+	w := writes(srv)
+	if len(w) != 2 || w[0]["body"] != "Superseded by #13" || w[1]["state"] != "closed" {
+		t.Errorf("writes = %v", w)
+	}
+
+	p, srv = newFakeProvider(t, nil)
+	withPullRequest(srv, map[string]any{"state": "closed", "merged": true}, nil, nil)
+	if _, err := p.ClosePullRequest(ctx, "12", CloseInput{}); !hasCode(err, "conflict") {
+		t.Errorf("merged pull request error = %v, want conflict", err)
+	}
+}
+
+// withCheckRuns serves check run creation and updates for acme/api, and lists
+// runs on the head of pull request 12.
+func withCheckRuns(srv *fakegithub.Server, runs []map[string]any) {
+	srv.Handle(http.MethodGet, "/repos/acme/api/commits/head12/check-runs", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": len(runs), "check_runs": runs})
+	})
+	srv.Handle(http.MethodPost, "/repos/acme/api/check-runs", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusCreated, map[string]any{"id": 8, "html_url": "https://github.com/acme/api/runs/8"})
+	})
+	srv.Handle(http.MethodPatch, "/repos/acme/api/check-runs/7", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"id": 7, "html_url": "https://github.com/acme/api/runs/7"})
+	})
+}
+
+func TestSetApproval(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates", func(t *testing.T) {
+		p, srv := newFakeProvider(t, map[string]any{"approvalCheck": "change/approved"})
+		withPullRequest(srv, nil, nil, nil)
+		withCheckRuns(srv, nil)
+		approval, err := p.SetApproval(ctx, "12", ApprovalInput{ChangeRequest: "CHG-42", State: ApprovalPending, DetailsURL: "https://opsorch.example/changes/CHG-42"})
+		if err != nil || approval.CheckRunID != 8 || approval.Check != "change/approved" || approval.HeadSHA != "head12" {
+			t.Fatalf("SetApproval() = %+v, %v", approval, err)
+		}
+		w := writes(srv)
+		if len(w) != 1 || w[0]["name"] != "change/approved" || w[0]["status"] != "in_progress" || w[0]["details_url"] != "https://opsorch.example/changes/CHG-42" || w[0]["conclusion"] != nil {
+			t.Errorf("writes = %v", w)
+		}
+	})
+
+	t.Run("updates", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		withPullRequest(srv, nil, nil, nil)
+		withCheckRuns(srv, []map[string]any{{"id": 7, "name": DefaultApprovalCheck, "status": "in_progress"}})
+		approval, err := p.SetApproval(ctx, "12", ApprovalInput{ChangeRequest: "CHG-42", State: ApprovalApproved, Approver: "alice"})
+		if err != nil || approval.CheckRunID != 7 || approval.State != ApprovalApproved {
+			t.Fatalf("SetApproval() = %+v, %v", approval, err)
+		}
+		w := writes(srv)
+		if len(w) != 1 || w[0]["path"] != "/repos/acme/api/check-runs/7" || w[0]["status"] != "completed" || w[0]["conclusion"] != "success" {
+			t.Fatalf("writes = %v", w)
+		}
+		if summary := w[0]["output"].(map[string]any)["summary"]; !strings.Contains(summary.(string), "approved by alice") {
+			t.Errorf("summary = %v", summary)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		p, srv := newFakeProvider(t, map[string]any{"readOnly": true})
+		withPullRequest(srv, nil, nil, nil)
+		withCheckRuns(srv, nil)
+		approval, err := p.SetApproval(ctx, "12", ApprovalInput{ChangeRequest: "CHG-42", State: ApprovalRejected})
+		if err != nil || !approval.DryRun {
+			t.Fatalf("SetApproval() = %+v, %v", approval, err)
+		}
+		if w := writes(srv); len(w) != 0 {
+			t.Errorf("writes = %v", w)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		p, _ := newFakeProvider(t, nil)
+		if _, err := p.SetApproval(ctx, "12", ApprovalInput{ChangeRequest: "CHG-42", State: "maybe"}); !hasCode(err, "bad_request") {
+			t.Errorf("unknown state error = %v, want bad_request", err)
+		}
+		if _, err := p.SetApproval(ctx, "12", ApprovalInput{State: ApprovalApproved}); !hasCode(err, "bad_request") {
+			t.Errorf("missing change request error = %v, want bad_request", err)
+		}
+	})
+}
+
+func TestGetReview(t *testing.T) {
+	ctx := context.Background()
+	reviews := []map[string]any{
+		{"user": map[string]any{"login": "alice"}, "state": "CHANGES_REQUESTED", "submitted_at": "2026-03-01T09:00:00Z"},
+		{"user": map[string]any{"login": "bob"}, "state": "APPROVED", "submitted_at": "2026-03-01T09:30:00Z"},
+		{"user": map[string]any{"login": "alice"}, "state": "COMMENTED", "submitted_at": "2026-03-01T09:45:00Z"},
+		{"user": map[string]any{"login": "alice"}, "state": "APPROVED", "submitted_at": "2026-03-01T10:00:00Z"},
+		{"user": map[string]any{"login": "carol"}, "state": "DISMISSED", "submitted_at": "2026-03-01T10:15:00Z"},
+		{"user": map[string]any{"login": "dave"}, "state": "CHANGES_REQUESTED", "submitted_at": "2026-03-01T13:00:00Z"},
+	}
+	withReviews := func(srv *fakegithub.Server) {
+		srv.Handle(http.MethodGet, "/repos/acme/api/pulls/12/reviews", func(w http.ResponseWriter, r *http.Request) {
+			// Two pages, so reviews on the second still replace those on the first
+			if r.URL.Query().Get("page") == "2" {
+				fakegithub.WriteJSON(w, http.StatusOK, reviews[3:])
+				return
+			}
+			w.Header().Set("Link", `<https://api.github.com/repos/acme/api/pulls/12/reviews?page=2>; rel="next"`)
+			fakegithub.WriteJSON(w, http.StatusOK, reviews[:3])
+		})
+	}
+	checkRuns := []map[string]any{
+		{"name": "test", "status": "completed", "conclusion": "success", "started_at": "2026-03-01T08:00:00Z", "completed_at": "2026-03-01T08:10:00Z"},
+		{"name": "e2e", "status": "completed", "conclusion": "failure", "started_at": "2026-03-01T11:00:00Z", "completed_at": "2026-03-01T12:30:00Z"},
+		{"name": "deploy-preview", "status": "completed", "conclusion": "success", "started_at": "2026-03-01T12:30:00Z", "completed_at": "2026-03-01T12:40:00Z"},
+	}
+	statuses := []map[string]any{{"context": "ci/build", "state": "success", "updated_at": "2026-03-01T08:05:00Z"}}
+
+	t.Run("merged", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		withPullRequest(srv, map[string]any{
+			"state":               "closed",
+			"merged":              true,
+			"merged_at":           "2026-03-01T12:00:00Z",
+			"merged_by":           map[string]any{"login": "alice"},
+			"requested_reviewers": []map[string]any{{"login": "erin"}},
+			"requested_teams":     []map[string]any{{"slug": "sre"}},
+		}, checkRuns, statuses)
+		withReviews(srv)
+		review, err := p.GetReview(ctx, "acme/api#12")
+		if err != nil {
+			t.Fatalf("GetReview() error = %v", err)
+		}
+		if review.State != "merged" || review.MergedBy != "alice" || review.MergedAt == nil || review.HeadSHA != "head12" {
+			t.Errorf("GetReview() = %+v", review)
+		}
+		// dave requested changes after the merge
+		if review.Decision != ReviewApproved || review.MergedWithoutApproval || strings.Join(review.Approvers, ",") != "alice,bob" || len(review.ChangesRequestedBy) != 0 {
+			t.Errorf("decision = %s, approvers = %v, changes requested by %v", review.Decision, review.Approvers, review.ChangesRequestedBy)
+		}
+		if strings.Join(review.RequestedReviewers, ",") != "erin" || strings.Join(review.RequestedTeams, ",") != "sre" {
+			t.Errorf("requested = %v, %v", review.RequestedReviewers, review.RequestedTeams)
+		}
+		// e2e was still running at the merge, and deploy-preview started after it
+		if review.Checks != ChecksPending || strings.Join(review.PassedChecks, ",") != "ci/build,test" || strings.Join(review.PendingChecks, ",") != "e2e" || len(review.FailedChecks) != 0 {
+			t.Errorf("checks = %s, passed %v, pending %v, failed %v", review.Checks, review.PassedChecks, review.PendingChecks, review.FailedChecks)
+		}
+	})
+
+	t.Run("open", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		withPullRequest(srv, nil, checkRuns, statuses)
+		withReviews(srv)
+		review, err := p.GetReview(ctx, "12")
+		if err != nil {
+			t.Fatalf("GetReview() error = %v", err)
+		}
+		if review.State != "open" || review.Decision != ReviewChangesRequested || strings.Join(review.ChangesRequestedBy, ",") != "dave" || review.MergedWithoutApproval {
+			t.Errorf("GetReview() = %+v", review)
+		}
+		if review.Checks != ChecksFailed || strings.Join(review.FailedChecks, ",") != "e2e" {
+			t.Errorf("checks = %s, failed %v", review.Checks, review.FailedChecks)
+		}
+	})
+
+	t.Run("merged without approval", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		withPullRequest(srv, map[string]any{"state": "closed", "merged": true, "merged_at": "2026-03-01T09:10:00Z"}, nil, nil)
+		withReviews(srv)
+		review, err := p.GetReview(ctx, "12")
+		if err != nil {
+			t.Fatalf("GetReview() error = %v", err)
+		}
+		if review.Decision != ReviewChangesRequested || !review.MergedWithoutApproval || review.Checks != ChecksNone {
+			t.Errorf("GetReview() = %+v", review)
+		}
+		if review.Approvers == nil || review.RequestedReviewers == nil || review.RequestedTeams == nil {
+			t.Errorf("empty lists must not be null: %+v", review)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		p, _ := newFakeProvider(t, nil)
+		if _, err := p.GetReview(ctx, "13"); !hasCode(err, "not_found") {
+			t.Errorf("error = %v, want not_found", err)
+		}
+	})
+}
+
+func TestBranches(t *testing.T) {
+	ctx := context.Background()
+
+	p, srv := newFakeProvider(t, nil)
+	ref, err := p.CreateBranch(ctx, BranchInput{Name: "hotfix/1.4.3", From: "v1"})
+	if err != nil || ref.SHA != "c0ffee" || ref.Kind != RefBranch {
+		t.Fatalf("CreateBranch() = %+v, %v", ref, err)
+	}
+	if w := writes(srv); len(w) != 1 || w[0]["ref"] != "refs/heads/hotfix/1.4.3" {
+		t.Errorf("writes = %v", w)
+	}
+	if _, err := p.CreateBranch(ctx, BranchInput{Name: "main"}); !hasCode(err, "conflict") {
+		t.Errorf("existing branch error = %v, want conflict", err)
+	}
+	if _, err := p.CreateBranch(ctx, BranchInput{Name: "bad..name"}); !hasCode(err, "bad_request") {
+		t.Errorf("invalid name error = %v, want bad_request", err)
+	}
+
+	p, srv = newFakeProvider(t, nil)
+	srv.Handle(http.MethodGet, "/repos/acme/api/branches/hotfix", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"name": "hotfix", "protected": false, "commit": map[string]any{"sha": "f00d"}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/branches/release", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"name": "release", "protected": true, "commit": map[string]any{"sha": "beef"}})
+	})
+	srv.Handle(http.MethodDelete, "/repos/acme/api/git/refs/heads/hotfix", fakegithub.NoContent)
+
+	tests := []struct {
+		name, branch, confirm, code string
+	}{
+		{"unconfirmed", "hotfix", "", "bad_request"},
+		{"mistyped", "hotfix", "hotfx", "bad_request"},
+		{"default branch", "main", "main", "forbidden"},
+		{"protected", "release", "release", "forbidden"},
+	}
+	for _, tt := range tests {
+		if _, err := p.DeleteBranch(ctx, tt.branch, DeleteBranchInput{Confirm: tt.confirm}); !hasCode(err, tt.code) {
+			t.Errorf("%s: error = %v, want %s", tt.name, err, tt.code)
+		}
+	}
+	if w := writes(srv); len(w) != 0 {
+		t.Fatalf("writes = %v", w)
+	}
+	ref, err = p.DeleteBranch(ctx, "hotfix", DeleteBranchInput{Confirm: "hotfix"})
+	if err != nil || ref.SHA != "f00d" {
+		t.Fatalf("DeleteBranch() = %+v, %v", ref, err)
+	}
+	if w := writes(srv); len(w) != 1 || w[0]["path"] != "/repos/acme/api/git/refs/heads/hotfix" {
+		t.Errorf("writes = %v", w)
+	}
+}
+
+func TestTags(t *testing.T) {
+	ctx := context.Background()
+	newTagProvider := func(t *testing.T) (*Provider, *fakegithub.Server) {
+		p, srv := newFakeProvider(t, nil)
+		// v2 is an annotated tag
+		srv.Handle(http.MethodGet, "/repos/acme/api/git/ref/tags/v2", func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"ref": "refs/tags/v2", "object": map[string]any{"sha": "tag2", "type": "tag"}})
+		})
+		srv.Handle(http.MethodGet, "/repos/acme/api/git/tags/tag2", func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"sha": "tag2", "object": map[string]any{"sha": "d00d", "type": "commit"}})
+		})
+		srv.Handle(http.MethodPost, "/repos/acme/api/git/tags", func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusCreated, map[string]any{"sha": "tag3"})
+		})
+		srv.Handle(http.MethodPost, "/repos/acme/api/releases", func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusCreated, map[string]any{"id": 77, "html_url": "https://github.com/acme/api/releases/tag/v3"})
+		})
+		return p, srv
+	}
+
+	t.Run("annotated tag", func(t *testing.T) {
+		p, srv := newTagProvider(t)
+		ref, err := p.CreateTag(ctx, TagInput{Name: "v3", From: "v2", Message: "Hotfix release"})
+		if err != nil || ref.SHA != "d00d" || ref.TagSHA != "tag3" {
+			t.Fatalf("CreateTag() = %+v, %v", ref, err)
+		}
+		w := writes(srv)
+		if len(w) != 2 || w[0]["object"] != "d00d" || w[1]["ref"] != "refs/tags/v3" || w[1]["sha"] != "tag3" {
+			t.Errorf("writes = %v", w)
+		}
+		if _, err := p.CreateTag(ctx, TagInput{Name: "v1"}); !hasCode(err, "conflict") {
+			t.Errorf("existing tag error = %v, want conflict", err)
+		}
+	})
+
+	t.Run("release", func(t *testing.T) {
+		p, srv := newTagProvider(t)
+		ref, err := p.CreateRelease(ctx, ReleaseInput{Tag: "v3", GenerateNotes: true})
+		if err != nil || ref.SHA != "c0ffee" || ref.ReleaseID != 77 {
+			t.Fatalf("CreateRelease() = %+v, %v", ref, err)
+		}
+		w := writes(srv)
+		if len(w) != 1 || w[0]["tag_name"] != "v3" || w[0]["target_commitish"] != "c0ffee" || w[0]["name"] != "v3" || w[0]["generate_release_notes"] != true {
+			t.Errorf("writes = %v", w)
+		}
+
+		// An existing tag is released where it points
+		if ref, err := p.CreateRelease(ctx, ReleaseInput{Tag: "v2"}); err != nil || ref.SHA != "d00d" {
+			t.Errorf("existing tag release = %+v, %v", ref, err)
+		}
+		if _, err := p.CreateRelease(ctx, ReleaseInput{Tag: "v2", From: "main"}); !hasCode(err, "conflict") {
+			t.Errorf("moved tag error = %v, want conflict", err)
+		}
+	})
+}
@@ -0,0 +1,269 @@
+package change
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+// FileEdit is the new content of one file in a pull request. SHA, when set, is
+// the blob SHA the file must still have on the base, as for WriteInput.
+type FileEdit struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	SHA     string `json:"sha,omitempty"`
+}
+
+// PullRequestInput proposes file edits for review: they are committed to
+// Branch, created from Base when it does not exist, and a pull request is
+// opened from it.
+type PullRequestInput struct {
+	Title         string         `json:"title"`
+	Body          string         `json:"body,omitempty"`
+	Branch        string         `json:"branch"`                  // Head branch the edits are committed to
+	Base          string         `json:"base,omitempty"`          // Branch the pull request merges into; defaults to the default branch
+	Files         []FileEdit     `json:"files"`                   // Committed in order, one commit each
+	Message       string         `json:"message,omitempty"`       // Commit message template; defaults to "commitMessage"
+	Values        map[string]any `json:"values,omitempty"`        // Values for the message template's placeholders
+	Labels        []string       `json:"labels,omitempty"`        // Labels added to the pull request
+	Reviewers     []string       `json:"reviewers,omitempty"`     // Logins asked to review
+	TeamReviewers []string       `json:"teamReviewers,omitempty"` // Team slugs asked to review
+	Draft         bool           `json:"draft,omitempty"`
+	ChangeRequest string         `json:"changeRequest,omitempty"` // OpsOrch change request; publishes a pending approval check when "approvalCheck" is set
+	Metadata      map[string]any `json:"metadata,omitempty"`
+}
+
+// PullRequest is a pull request opened for file edits. ID has the form
+// owner/repo#N, like tickets outside the configured repository.
+type PullRequest struct {
+	ID            string   `json:"id,omitempty"`
+	Number        int      `json:"number,omitempty"`
+	URL           string   `json:"url,omitempty"`
+	Title         string   `json:"title"`
+	Branch        string   `json:"branch"`
+	Base          string   `json:"base"`
+	Commits       []Commit `json:"commits"`
+	Labels        []string `json:"labels,omitempty"`
+	Reviewers     []string `json:"reviewers,omitempty"`
+	TeamReviewers []string `json:"teamReviewers,omitempty"`
+	BranchCreated bool     `json:"branchCreated,omitempty"`
+	Draft         bool     `json:"draft,omitempty"`
+	Approval      string   `json:"approval,omitempty"` // Approval check published for the change request
+	DryRun        bool     `json:"dryRun,omitempty"`
+}
+
+// CreatePullRequest commits the edits to a branch and opens a pull request
+// from it, with the given labels and reviewers. Every edit is checked before
+// anything is written, so a stale SHA or a bad commit message leaves no stray
+// branch behind, and edits that would change nothing on a new branch are
+// rejected.
+func (p *Provider) CreatePullRequest(ctx context.Context, input PullRequestInput) (PullRequest, error) {
+	if err := p.checkPullRequests(input); err != nil {
+		return PullRequest{}, err
+	}
+	edits, err := p.pullRequestEdits(input)
+	if err != nil {
+		return PullRequest{}, err
+	}
+	dryRun := p.isDryRun(input.Metadata)
+
+	base := input.Base
+	if base == "" {
+		if base, err = p.defaultBranch(ctx); err != nil {
+			return PullRequest{}, err
+		}
+	}
+	if input.Branch == base {
+		return PullRequest{}, requestError("branch must differ from base %q", base)
+	}
+	pr := PullRequest{
+		Title:         input.Title,
+		Branch:        input.Branch,
+		Base:          base,
+		Labels:        input.Labels,
+		Reviewers:     input.Reviewers,
+		TeamReviewers: input.TeamReviewers,
+		Draft:         input.Draft,
+		DryRun:        dryRun,
+	}
+
+	exists, sha, err := p.resolveBranch(ctx, input.Branch, base)
+	if err != nil {
+		return PullRequest{}, err
+	}
+	readRef := input.Branch
+	if !exists {
+		readRef = sha
+	}
+	changed := false
+	for _, edit := range edits {
+		action, _, err := p.planFile(ctx, readRef, edit)
+		if err != nil {
+			return PullRequest{}, err
+		}
+		builtins := map[string]any{"action": action, "path": edit.Path, "branch": input.Branch}
+		if _, err := renderMessage(edit.Message, builtins, edit.Values); err != nil {
+			return PullRequest{}, err
+		}
+		changed = changed || action != ActionUnchanged
+	}
+	if !exists && !changed {
+		return PullRequest{}, requestError("the edits leave every file as it is on %s", base)
+	}
+
+	if !exists {
+		pr.BranchCreated = true
+		if dryRun {
+			log.Printf("[dry-run] POST /repos/%s/%s/git/refs ref=refs/heads/%s sha=%s", p.config.Owner, p.config.Repo, input.Branch, sha)
+		} else if err := p.createBranch(ctx, input.Branch, base, sha); err != nil {
+			return PullRequest{}, err
+		} else {
+			readRef = input.Branch
+		}
+	}
+	for _, edit := range edits {
+		commit, err := p.commitFile(ctx, input.Branch, readRef, edit, dryRun)
+		if err != nil {
+			return PullRequest{}, err
+		}
+		pr.Commits = append(pr.Commits, commit)
+	}
+
+	if dryRun {
+		if p.gatesApproval(input) {
+			pr.Approval = p.approvalCheck()
+		}
+		log.Printf("[dry-run] POST /repos/%s/%s/pulls head=%s base=%s labels=%v reviewers=%v teamReviewers=%v", p.config.Owner, p.config.Repo, input.Branch, base, input.Labels, input.Reviewers, input.TeamReviewers)
+		return pr, nil
+	}
+
+	created, _, err := p.api.PullRequests.Create(ctx, p.config.Owner, p.config.Repo, &github.NewPullRequest{
+		Title: github.String(input.Title),
+		Head:  github.String(input.Branch),
+		Base:  github.String(base),
+		Body:  github.String(input.Body),
+		Draft: github.Bool(input.Draft),
+	})
+	auditInput := map[string]any{"title": input.Title, "head": input.Branch, "base": base, "labels": input.Labels, "reviewers": input.Reviewers, "teamReviewers": input.TeamReviewers}
+	p.audit("change.createPR", input.Branch, auditInput, created.GetHTMLURL(), err)
+	if err != nil {
+		return PullRequest{}, p.wrapError(err)
+	}
+	pr.Number = created.GetNumber()
+	pr.ID = p.pullRequestID(pr.Number)
+	pr.URL = created.GetHTMLURL()
+
+	// The pull request exists from here on, so failures name it rather than
+	// leave the caller to open a duplicate
+	if len(input.Labels) > 0 {
+		if _, _, err := p.api.Issues.AddLabelsToIssue(ctx, p.config.Owner, p.config.Repo, pr.Number, input.Labels); err != nil {
+			return pr, p.followUpError(pr, "adding labels", err)
+		}
+	}
+	if len(input.Reviewers) > 0 || len(input.TeamReviewers) > 0 {
+		if _, _, err := p.api.PullRequests.RequestReviewers(ctx, p.config.Owner, p.config.Repo, pr.Number, github.ReviewersRequest{
+			Reviewers:     input.Reviewers,
+			TeamReviewers: input.TeamReviewers,
+		}); err != nil {
+			return pr, p.followUpError(pr, "requesting reviewers", err)
+		}
+	}
+	if p.gatesApproval(input) {
+		approval, err := p.publishApproval(ctx, pr.ID, created.GetHead().GetSHA(), ApprovalInput{
+			ChangeRequest: input.ChangeRequest,
+			State:         ApprovalPending,
+		}, false)
+		if err != nil {
+			return pr, p.followUpError(pr, "publishing the approval check", err)
+		}
+		pr.Approval = approval.Check
+	}
+	return pr, nil
+}
+
+// gatesApproval reports whether a pull request opened for input is held by a
+// pending approval check.
+func (p *Provider) gatesApproval(input PullRequestInput) bool {
+	return p.config.ApprovalCheck != "" && input.ChangeRequest != ""
+}
+
+// checkPullRequests checks the services a pull request needs are configured.
+func (p *Provider) checkPullRequests(input PullRequestInput) error {
+	if p.api.PullRequests == nil || p.api.Git == nil {
+		return &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "pull requests are not available for this provider",
+		}
+	}
+	if input.ChangeRequest != "" && p.config.ApprovalCheck != "" && p.api.Checks == nil {
+		return &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "check runs are not available for this provider",
+		}
+	}
+	if len(input.Labels) > 0 && p.api.Issues == nil {
+		return &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "labels are not available for this provider",
+		}
+	}
+	return nil
+}
+
+// pullRequestEdits validates the input and returns its edits as writes.
+func (p *Provider) pullRequestEdits(input PullRequestInput) ([]WriteInput, error) {
+	if strings.TrimSpace(input.Title) == "" {
+		return nil, requestError("a title is required")
+	}
+	if input.Branch == "" {
+		return nil, requestError("a branch is required")
+	}
+	if len(input.Files) == 0 {
+		return nil, requestError("at least one file is required")
+	}
+	message := input.Message
+	if message == "" {
+		message = p.config.CommitMessage
+	}
+	edits := make([]WriteInput, len(input.Files))
+	seen := make(map[string]bool, len(input.Files))
+	for i, f := range input.Files {
+		path, err := cleanPath(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		if seen[path] {
+			return nil, requestError("files[%d]: %s is edited twice", i, path)
+		}
+		seen[path] = true
+		edits[i] = WriteInput{Path: path, Content: f.Content, SHA: f.SHA, Message: message, Values: input.Values}
+	}
+	return edits, nil
+}
+
+// followUpError reports a failed step after pr was opened, keeping the code of
+// the underlying error.
+func (p *Provider) followUpError(pr PullRequest, step string, err error) error {
+	err = p.wrapError(err)
+	code := "provider_error"
+	var opsErr *orcherr.OpsOrchError
+	if errors.As(err, &opsErr) {
+		code = opsErr.Code
+	}
+	return &orcherr.OpsOrchError{
+		Code:    code,
+		Message: fmt.Sprintf("pull request %s was opened, but %s failed: %v", pr.ID, step, err),
+	}
+}
+
+func requestError(format string, args ...any) error {
+	return &orcherr.OpsOrchError{
+		Code:    "bad_request",
+		Message: fmt.Sprintf(format, args...),
+	}
+}
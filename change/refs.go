@@ -0,0 +1,294 @@
+package change
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+// Kinds of ref, as reported in Ref.Kind.
+const (
+	RefBranch = "branch"
+	RefTag    = "tag"
+)
+
+// BranchInput creates a branch, such as a hotfix branch from a release tag.
+type BranchInput struct {
+	Name     string         `json:"name"`
+	From     string         `json:"from,omitempty"` // Branch, tag, or commit SHA; defaults to the default branch
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// DeleteBranchInput confirms a branch deletion. Confirm must repeat the branch
+// name, so a mistyped or templated name never deletes the wrong branch.
+type DeleteBranchInput struct {
+	Confirm  string         `json:"confirm"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// TagInput creates a tag. A Message makes it an annotated tag; without one the
+// tag is lightweight.
+type TagInput struct {
+	Name     string         `json:"name"`
+	From     string         `json:"from,omitempty"` // Branch, tag, or commit SHA; defaults to the default branch
+	Message  string         `json:"message,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// ReleaseInput creates a GitHub release and, unless it exists, its tag.
+type ReleaseInput struct {
+	Tag           string         `json:"tag"`
+	From          string         `json:"from,omitempty"` // Branch, tag, or commit SHA a new tag is created at; defaults to the default branch
+	Name          string         `json:"name,omitempty"` // Release title; defaults to the tag
+	Body          string         `json:"body,omitempty"`
+	GenerateNotes bool           `json:"generateNotes,omitempty"` // Have GitHub write release notes, after Body
+	Prerelease    bool           `json:"prerelease,omitempty"`
+	Draft         bool           `json:"draft,omitempty"`
+	Metadata      map[string]any `json:"metadata,omitempty"`
+}
+
+// Ref is a branch or tag that was created or deleted. SHA is the commit it
+// points at, so a deleted branch can be recreated.
+type Ref struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	SHA        string `json:"sha"`
+	TagSHA     string `json:"tagSha,omitempty"`     // Tag object of an annotated tag
+	ReleaseID  int64  `json:"releaseId,omitempty"`  // Release created for the tag
+	ReleaseURL string `json:"releaseUrl,omitempty"` // Its page
+	DryRun     bool   `json:"dryRun,omitempty"`
+}
+
+// CreateBranch creates a branch. An existing branch fails with conflict rather
+// than being moved.
+func (p *Provider) CreateBranch(ctx context.Context, input BranchInput) (Ref, error) {
+	if err := p.checkRefs(input.Name, "branch"); err != nil {
+		return Ref{}, err
+	}
+	exists, sha, err := p.resolveBranch(ctx, input.Name, input.From)
+	if err != nil {
+		return Ref{}, err
+	}
+	if exists {
+		return Ref{}, refExistsError(RefBranch, input.Name)
+	}
+
+	ref := Ref{Name: input.Name, Kind: RefBranch, SHA: sha}
+	if p.isDryRun(input.Metadata) {
+		log.Printf("[dry-run] POST /repos/%s/%s/git/refs ref=refs/heads/%s sha=%s", p.config.Owner, p.config.Repo, input.Name, sha)
+		ref.DryRun = true
+		return ref, nil
+	}
+	if err := p.createBranch(ctx, input.Name, input.From, sha); err != nil {
+		return Ref{}, err
+	}
+	return ref, nil
+}
+
+// DeleteBranch deletes a branch once input confirms its name. The default
+// branch and protected branches are refused with forbidden.
+func (p *Provider) DeleteBranch(ctx context.Context, name string, input DeleteBranchInput) (Ref, error) {
+	if err := p.checkRefs(name, "branch"); err != nil {
+		return Ref{}, err
+	}
+	if input.Confirm != name {
+		return Ref{}, requestError("confirm must repeat the branch name %q to delete it", name)
+	}
+	defaultBranch, err := p.defaultBranch(ctx)
+	if err != nil {
+		return Ref{}, err
+	}
+	if name == defaultBranch {
+		return Ref{}, &orcherr.OpsOrchError{
+			Code:    "forbidden",
+			Message: fmt.Sprintf("%s is the default branch and cannot be deleted", name),
+		}
+	}
+	branch, _, err := p.api.Repositories.GetBranch(ctx, p.config.Owner, p.config.Repo, name, 0)
+	if err != nil {
+		return Ref{}, p.wrapError(err)
+	}
+	if branch.GetProtected() {
+		return Ref{}, &orcherr.OpsOrchError{
+			Code:    "forbidden",
+			Message: fmt.Sprintf("branch %s is protected and cannot be deleted", name),
+		}
+	}
+
+	ref := Ref{Name: name, Kind: RefBranch, SHA: branch.GetCommit().GetSHA()}
+	if p.isDryRun(input.Metadata) {
+		log.Printf("[dry-run] DELETE /repos/%s/%s/git/refs/heads/%s sha=%s", p.config.Owner, p.config.Repo, name, ref.SHA)
+		ref.DryRun = true
+		return ref, nil
+	}
+	_, err = p.api.Git.DeleteRef(ctx, p.config.Owner, p.config.Repo, "heads/"+name)
+	p.audit("change.deleteBranch", name, map[string]any{"sha": ref.SHA}, "", err)
+	if err != nil {
+		return Ref{}, p.wrapError(err)
+	}
+	return ref, nil
+}
+
+// CreateTag tags a commit. Tags are never moved: an existing tag fails with
+// conflict.
+func (p *Provider) CreateTag(ctx context.Context, input TagInput) (Ref, error) {
+	if err := p.checkRefs(input.Name, "tag"); err != nil {
+		return Ref{}, err
+	}
+	ref, err := p.planTag(ctx, input.Name, input.From)
+	if err != nil {
+		return Ref{}, err
+	}
+
+	if p.isDryRun(input.Metadata) {
+		log.Printf("[dry-run] POST /repos/%s/%s/git/refs ref=refs/tags/%s sha=%s annotated=%t", p.config.Owner, p.config.Repo, input.Name, ref.SHA, input.Message != "")
+		ref.DryRun = true
+		return ref, nil
+	}
+
+	target := ref.SHA
+	if input.Message != "" {
+		tag, _, err := p.api.Git.CreateTag(ctx, p.config.Owner, p.config.Repo, &github.Tag{
+			Tag:     github.String(input.Name),
+			Message: github.String(input.Message),
+			Object:  &github.GitObject{SHA: github.String(ref.SHA), Type: github.String("commit")},
+		})
+		if err != nil {
+			p.audit("change.createTag", input.Name, map[string]any{"sha": ref.SHA, "message": input.Message}, "", err)
+			return Ref{}, p.wrapError(err)
+		}
+		target, ref.TagSHA = tag.GetSHA(), tag.GetSHA()
+	}
+	_, _, err = p.api.Git.CreateRef(ctx, p.config.Owner, p.config.Repo, &github.Reference{
+		Ref:    github.String("refs/tags/" + input.Name),
+		Object: &github.GitObject{SHA: github.String(target)},
+	})
+	p.audit("change.createTag", input.Name, map[string]any{"sha": ref.SHA, "message": input.Message}, "", err)
+	if err != nil {
+		return Ref{}, p.wrapError(err)
+	}
+	return ref, nil
+}
+
+// CreateRelease publishes a GitHub release for a tag, which GitHub creates at
+// From unless it exists. An existing tag is released where it points; From
+// must then be empty or resolve to the same commit.
+func (p *Provider) CreateRelease(ctx context.Context, input ReleaseInput) (Ref, error) {
+	if err := p.checkRefs(input.Tag, "tag"); err != nil {
+		return Ref{}, err
+	}
+	ref, err := p.planRelease(ctx, input)
+	if err != nil {
+		return Ref{}, err
+	}
+	name := input.Name
+	if name == "" {
+		name = input.Tag
+	}
+
+	if p.isDryRun(input.Metadata) {
+		log.Printf("[dry-run] POST /repos/%s/%s/releases tag=%s sha=%s draft=%t prerelease=%t", p.config.Owner, p.config.Repo, input.Tag, ref.SHA, input.Draft, input.Prerelease)
+		ref.DryRun = true
+		return ref, nil
+	}
+
+	release, _, err := p.api.Repositories.CreateRelease(ctx, p.config.Owner, p.config.Repo, &github.RepositoryRelease{
+		TagName:              github.String(input.Tag),
+		TargetCommitish:      github.String(ref.SHA),
+		Name:                 github.String(name),
+		Body:                 github.String(input.Body),
+		GenerateReleaseNotes: github.Bool(input.GenerateNotes),
+		Prerelease:           github.Bool(input.Prerelease),
+		Draft:                github.Bool(input.Draft),
+	})
+	p.audit("change.createRelease", input.Tag, map[string]any{"sha": ref.SHA, "name": name, "draft": input.Draft, "prerelease": input.Prerelease}, release.GetHTMLURL(), err)
+	if err != nil {
+		return Ref{}, p.wrapError(err)
+	}
+	ref.ReleaseID, ref.ReleaseURL = release.GetID(), release.GetHTMLURL()
+	return ref, nil
+}
+
+// planTag returns the tag name would be created as, at the commit from
+// resolves to, failing with conflict if it exists.
+func (p *Provider) planTag(ctx context.Context, name, from string) (Ref, error) {
+	existing, err := p.tagCommit(ctx, name)
+	if err != nil {
+		return Ref{}, err
+	}
+	if existing != "" {
+		return Ref{}, refExistsError(RefTag, name)
+	}
+	if from == "" {
+		if from, err = p.defaultBranch(ctx); err != nil {
+			return Ref{}, err
+		}
+	}
+	sha, err := p.resolveRef(ctx, from)
+	if err != nil {
+		return Ref{}, err
+	}
+	return Ref{Name: name, Kind: RefTag, SHA: sha}, nil
+}
+
+// planRelease resolves the commit a release's tag points at, or will.
+func (p *Provider) planRelease(ctx context.Context, input ReleaseInput) (Ref, error) {
+	existing, err := p.tagCommit(ctx, input.Tag)
+	if err != nil {
+		return Ref{}, err
+	}
+	if existing == "" {
+		return p.planTag(ctx, input.Tag, input.From)
+	}
+	if input.From != "" {
+		sha, err := p.resolveRef(ctx, input.From)
+		if err != nil {
+			return Ref{}, err
+		}
+		if sha != existing {
+			return Ref{}, &orcherr.OpsOrchError{
+				Code:    "conflict",
+				Message: fmt.Sprintf("tag %s already exists at %s, not at %s", input.Tag, existing, input.From),
+			}
+		}
+	}
+	return Ref{Name: input.Tag, Kind: RefTag, SHA: existing}, nil
+}
+
+// tagCommit returns the commit SHA of tag, or "" when there is no such tag.
+func (p *Provider) tagCommit(ctx context.Context, tag string) (string, error) {
+	ref, _, err := p.api.Git.GetRef(ctx, p.config.Owner, p.config.Repo, "tags/"+tag)
+	if isNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", p.wrapError(err)
+	}
+	return p.peel(ctx, ref)
+}
+
+// checkRefs checks the Git service is configured and name is a usable branch
+// or tag name.
+func (p *Provider) checkRefs(name, kind string) error {
+	if p.api.Git == nil {
+		return &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "branches and tags are not available for this provider",
+		}
+	}
+	if name == "" || strings.HasPrefix(name, "refs/") || strings.ContainsAny(name, " ~^:?*[\\") || strings.Contains(name, "..") {
+		return requestError("invalid %s name %q", kind, name)
+	}
+	return nil
+}
+
+func refExistsError(kind, name string) error {
+	return &orcherr.OpsOrchError{
+		Code:    "conflict",
+		Message: fmt.Sprintf("%s %s already exists", kind, name),
+	}
+}
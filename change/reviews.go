@@ -0,0 +1,159 @@
+package change
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-github-adapter/internal/paging"
+)
+
+// Review decisions of a pull request.
+const (
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes_requested"
+	ReviewRequired         = "review_required"
+)
+
+// Checks states of a pull request's head commit.
+const (
+	ChecksPassed  = "passed"
+	ChecksFailed  = "failed"
+	ChecksPending = "pending"
+	ChecksNone    = "none"
+)
+
+// Review is the review state of a pull request, as it stood when the pull
+// request merged, or as it stands now for one not merged.
+type Review struct {
+	PullRequest           string     `json:"pullRequest"`
+	URL                   string     `json:"url"`
+	State                 string     `json:"state"` // open, closed, or merged
+	HeadSHA               string     `json:"headSha"`
+	MergedAt              *time.Time `json:"mergedAt,omitempty"`
+	MergedBy              string     `json:"mergedBy,omitempty"`
+	Decision              string     `json:"reviewDecision"` // approved, changes_requested, or review_required
+	Approvers             []string   `json:"approvers"`
+	ChangesRequestedBy    []string   `json:"changesRequestedBy,omitempty"`
+	RequestedReviewers    []string   `json:"requestedReviewers"`
+	RequestedTeams        []string   `json:"requestedTeams"`
+	Checks                string     `json:"checks"` // passed, failed, pending, or none
+	PassedChecks          []string   `json:"passedChecks,omitempty"`
+	PendingChecks         []string   `json:"pendingChecks,omitempty"`
+	FailedChecks          []string   `json:"failedChecks,omitempty"`
+	MergedWithoutApproval bool       `json:"mergedWithoutApproval,omitempty"`
+}
+
+// GetReview reads the reviews and checks of a pull request. A reviewer's
+// latest approval or request for changes counts, until dismissed; comments do
+// not. For a merged pull request only reviews submitted before the merge
+// count, and the checks are those on its head commit at the merge. The
+// decision is derived from the reviews alone, so review_required means no one
+// approved, whether or not branch protection requires it.
+func (p *Provider) GetReview(ctx context.Context, id string) (Review, error) {
+	pr, err := p.getPullRequest(ctx, id)
+	if err != nil {
+		return Review{}, err
+	}
+	review := Review{
+		PullRequest:        p.pullRequestID(pr.GetNumber()),
+		URL:                pr.GetHTMLURL(),
+		State:              pr.GetState(),
+		HeadSHA:            pr.GetHead().GetSHA(),
+		Approvers:          []string{},
+		RequestedReviewers: []string{},
+		RequestedTeams:     []string{},
+	}
+	var mergedAt time.Time
+	if pr.GetMerged() {
+		mergedAt = pr.GetMergedAt().Time
+		review.State, review.MergedAt, review.MergedBy = "merged", &mergedAt, pr.GetMergedBy().GetLogin()
+	}
+	for _, user := range pr.RequestedReviewers {
+		review.RequestedReviewers = append(review.RequestedReviewers, user.GetLogin())
+	}
+	for _, team := range pr.RequestedTeams {
+		review.RequestedTeams = append(review.RequestedTeams, team.GetSlug())
+	}
+
+	latest, err := p.latestReviews(ctx, pr.GetNumber(), mergedAt)
+	if err != nil {
+		return Review{}, err
+	}
+	for login, state := range latest {
+		switch state {
+		case "APPROVED":
+			review.Approvers = append(review.Approvers, login)
+		case "CHANGES_REQUESTED":
+			review.ChangesRequestedBy = append(review.ChangesRequestedBy, login)
+		}
+	}
+	sort.Strings(review.Approvers)
+	sort.Strings(review.ChangesRequestedBy)
+	switch {
+	case len(review.ChangesRequestedBy) > 0:
+		review.Decision = ReviewChangesRequested
+	case len(review.Approvers) > 0:
+		review.Decision = ReviewApproved
+	default:
+		review.Decision = ReviewRequired
+	}
+	review.MergedWithoutApproval = pr.GetMerged() && review.Decision != ReviewApproved
+
+	review.PassedChecks, review.PendingChecks, review.FailedChecks, err = p.checksAt(ctx, review.HeadSHA, mergedAt)
+	if err != nil {
+		return Review{}, err
+	}
+	switch {
+	case len(review.FailedChecks) > 0:
+		review.Checks = ChecksFailed
+	case len(review.PendingChecks) > 0:
+		review.Checks = ChecksPending
+	case len(review.PassedChecks) > 0:
+		review.Checks = ChecksPassed
+	default:
+		review.Checks = ChecksNone
+	}
+	return review, nil
+}
+
+// latestReviews returns the state of each reviewer's latest approval or
+// request for changes submitted before a time, or ever for the zero time.
+// Dismissed reviews are dropped.
+func (p *Provider) latestReviews(ctx context.Context, number int, before time.Time) (map[string]string, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	list := func() ([]*github.PullRequestReview, *github.Response, error) {
+		reviews, resp, err := p.api.PullRequests.ListReviews(ctx, p.config.Owner, p.config.Repo, number, opts)
+		if err != nil {
+			return nil, nil, p.wrapError(err)
+		}
+		return reviews, resp, nil
+	}
+
+	// Reviews are listed oldest first, so a later review replaces an earlier one
+	latest := map[string]string{}
+	visit := func(r *github.PullRequestReview) (bool, error) {
+		if !before.IsZero() && r.GetSubmittedAt().After(before) {
+			return false, nil
+		}
+		switch r.GetState() {
+		case "APPROVED", "CHANGES_REQUESTED":
+			latest[r.GetUser().GetLogin()] = r.GetState()
+		case "DISMISSED":
+			delete(latest, r.GetUser().GetLogin())
+		}
+		return true, nil
+	}
+	// Walk stops every paging.MaxResults reviews; resume until the pages run out
+	for token := ""; ; {
+		next, err := paging.Walk(token, paging.MaxResults, opts, list, visit)
+		if err != nil {
+			return nil, err
+		}
+		if next == "" {
+			return latest, nil
+		}
+		token = next
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/opsorch/opsorch-github-adapter/change"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/limits"
+	"github.com/opsorch/opsorch-github-adapter/internal/pluginrpc"
+	"github.com/opsorch/opsorch-github-adapter/internal/redact"
+)
+
+// stdout receives responses; tests redirect it.
+var stdout io.Writer = os.Stdout
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "--version" {
+		fmt.Println("opsorch-github-change-plugin v1.0.0")
+		return
+	}
+
+	// Secrets the providers register are masked in everything logged
+	log.SetOutput(redact.Default.Writer(os.Stderr))
+
+	serve(context.Background(), os.Stdin)
+}
+
+// serve answers newline-delimited requests read from in until EOF or malformed input.
+func serve(ctx context.Context, in io.Reader) {
+	providers := map[string]*change.Provider{}
+
+	dec := json.NewDecoder(in)
+	for {
+		var req pluginrpc.Request
+		if err := dec.Decode(&req); err != nil {
+			if err.Error() == "EOF" {
+				return
+			}
+			writeErr(err)
+			return
+		}
+
+		if pluginrpc.Gate(stdout, req) {
+			continue
+		}
+
+		// Initialize the request's profile provider if not already done
+		provider := providers[req.Profile]
+		if provider == nil {
+			cfg, err := ghconfig.Profile(req.Config, req.Profile)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			p, err := change.New(cfg)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			provider = p
+			providers[req.Profile] = provider
+		}
+
+		switch req.Method {
+		case "change.readFile":
+			var payload struct {
+				Path string `json:"path"`
+				Ref  string `json:"ref"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.ReadFile(ctx, payload.Path, payload.Ref)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "change.writeFile":
+			var input change.WriteInput
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.WriteFile(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "change.createPR":
+			var input change.PullRequestInput
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			if err := limits.Issue(input.Title, input.Body, input.Labels); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.CreatePullRequest(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "change.mergePR", "change.enableAutoMerge":
+			var payload struct {
+				ID string `json:"id"`
+				change.MergeInput
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			merge := provider.MergePullRequest
+			if req.Method == "change.enableAutoMerge" {
+				merge = provider.EnableAutoMerge
+			}
+			result, err := merge(ctx, payload.ID, payload.MergeInput)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "change.closePR":
+			var payload struct {
+				ID string `json:"id"`
+				change.CloseInput
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.ClosePullRequest(ctx, payload.ID, payload.CloseInput)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "change.setApproval":
+			var payload struct {
+				ID string `json:"id"`
+				change.ApprovalInput
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.SetApproval(ctx, payload.ID, payload.ApprovalInput)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "change.getReview":
+			var payload struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.GetReview(ctx, payload.ID)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "change.createBranch":
+			var input change.BranchInput
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.CreateBranch(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "change.deleteBranch":
+			var payload struct {
+				Name string `json:"name"`
+				change.DeleteBranchInput
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.DeleteBranch(ctx, payload.Name, payload.DeleteBranchInput)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "change.createTag":
+			var input change.TagInput
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.CreateTag(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "change.createRelease":
+			var input change.ReleaseInput
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			if err := limits.Issue(input.Name, input.Body, nil); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.CreateRelease(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "change.capabilities":
+			writeOK(provider.Capabilities())
+
+		default:
+			writeErr(fmt.Errorf("unknown method: %s", req.Method))
+		}
+	}
+}
+
+func writeOK(result any) { pluginrpc.WriteOK(stdout, result) }
+
+func writeErr(err error) { pluginrpc.WriteError(stdout, err) }
@@ -4,22 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"time"
 
 	"github.com/opsorch/opsorch-core/schema"
 	"github.com/opsorch/opsorch-github-adapter/deployment"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/pluginrpc"
+	"github.com/opsorch/opsorch-github-adapter/internal/redact"
+	"github.com/opsorch/opsorch-github-adapter/webhook"
 )
 
-type rpcRequest struct {
-	Method  string          `json:"method"`
-	Config  map[string]any  `json:"config"`
-	Payload json.RawMessage `json:"payload"`
-}
-
-type rpcResponse struct {
-	Result any    `json:"result,omitempty"`
-	Error  string `json:"error,omitempty"`
-}
+// stdout receives responses; tests redirect it.
+var stdout io.Writer = os.Stdout
 
 func main() {
 	if len(os.Args) > 1 && os.Args[1] == "--version" {
@@ -27,11 +26,20 @@ func main() {
 		return
 	}
 
-	var provider *deployment.Provider
+	// Secrets the providers register are masked in everything logged
+	log.SetOutput(redact.Default.Writer(os.Stderr))
+
+	serve(context.Background(), os.Stdin)
+}
 
-	dec := json.NewDecoder(os.Stdin)
+// serve answers newline-delimited requests read from in until EOF or malformed input.
+func serve(ctx context.Context, in io.Reader) {
+	providers := map[string]*deployment.Provider{}
+	defer reportPending(providers)
+
+	dec := json.NewDecoder(in)
 	for {
-		var req rpcRequest
+		var req pluginrpc.Request
 		if err := dec.Decode(&req); err != nil {
 			if err.Error() == "EOF" {
 				return
@@ -40,23 +48,37 @@ func main() {
 			return
 		}
 
-		// Initialize provider if not already done
+		if pluginrpc.Gate(stdout, req) {
+			continue
+		}
+
+		// Initialize the request's profile provider if not already done
+		provider := providers[req.Profile]
 		if provider == nil {
-			p, err := deployment.New(req.Config)
+			cfg, err := ghconfig.Profile(req.Config, req.Profile)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			p, err := deployment.New(cfg)
 			if err != nil {
 				writeErr(err)
 				continue
 			}
+			// A receiver in this process refreshes the cache its providers read
+			if _, err := webhook.Host(cfg); err != nil {
+				writeErr(err)
+				continue
+			}
 			if githubProvider, ok := p.(*deployment.Provider); ok {
 				provider = githubProvider
+				providers[req.Profile] = provider
 			} else {
 				writeErr(fmt.Errorf("failed to create GitHub deployment provider"))
 				continue
 			}
 		}
 
-		ctx := context.Background()
-
 		switch req.Method {
 		case "deployment.query":
 			var query schema.DeploymentQuery
@@ -64,12 +86,12 @@ func main() {
 				writeErr(err)
 				continue
 			}
-			result, err := provider.Query(ctx, query)
+			page, err := provider.QueryPage(ctx, query)
 			if err != nil {
 				writeErr(err)
 				continue
 			}
-			writeOK(result)
+			writePage(page.Deployments, page.NextPageToken, page.TotalCount, page.Truncated)
 
 		case "deployment.get":
 			var payload struct {
@@ -86,18 +108,220 @@ func main() {
 			}
 			writeOK(result)
 
+		case "deployment.watch":
+			var payload struct {
+				ID       string `json:"id"`
+				Interval string `json:"interval"`
+				Timeout  string `json:"timeout"`
+				Stream   bool   `json:"stream"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			opts, err := watchOptions(payload.Interval, payload.Timeout)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			var onUpdate func(schema.Deployment)
+			if payload.Stream {
+				// Intermediate updates are written as partial responses ahead of the final result
+				onUpdate = func(d schema.Deployment) { writePartial(d) }
+			}
+			result, err := provider.Watch(ctx, payload.ID, opts, onUpdate)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "deployment.trigger":
+			var input deployment.TriggerInput
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.Trigger(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "deployment.cleanup":
+			var payload struct {
+				Artifacts bool   `json:"artifacts"`
+				Caches    bool   `json:"caches"`
+				OlderThan string `json:"olderThan"`
+				DryRun    bool   `json:"dryRun"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			input := deployment.CleanupInput{Artifacts: payload.Artifacts, Caches: payload.Caches, DryRun: payload.DryRun}
+			if payload.OlderThan != "" {
+				var err error
+				if input.OlderThan, err = time.ParseDuration(payload.OlderThan); err != nil {
+					writeErr(fmt.Errorf("invalid olderThan: %w", err))
+					continue
+				}
+			}
+			result, err := provider.Cleanup(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "deployment.inventory":
+			var payload struct {
+				Environment string `json:"environment"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.Inventory(ctx, payload.Environment)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "deployment.freezes":
+			var payload struct {
+				Environment string `json:"environment"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.Freezes(ctx, payload.Environment)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "deployment.stats":
+			var input deployment.StatsInput
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.Stats(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "deployment.failures":
+			var input deployment.FailureInput
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.Failures(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "deployment.flakiness":
+			var input deployment.FlakinessInput
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.Flakiness(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "deployment.status":
+			var input deployment.StatusInput
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.Status(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "deployment.capabilities":
+			writeOK(provider.Capabilities())
+
+		case "github.raw":
+			var payload struct {
+				Path string `json:"path"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.Raw(ctx, payload.Path)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
 		default:
 			writeErr(fmt.Errorf("unknown method: %s", req.Method))
 		}
 	}
 }
 
-func writeOK(result any) {
-	enc := json.NewEncoder(os.Stdout)
-	_ = enc.Encode(rpcResponse{Result: result})
+func writeOK(result any) { pluginrpc.WriteOK(stdout, result) }
+
+// writePage answers a query with its results, continuation token, and total,
+// and whether the API call budget cut it short.
+func writePage(result any, nextPageToken string, totalCount *int, truncated bool) {
+	pluginrpc.WritePage(stdout, result, nextPageToken, totalCount, truncated)
 }
 
-func writeErr(err error) {
-	enc := json.NewEncoder(os.Stdout)
-	_ = enc.Encode(rpcResponse{Error: err.Error()})
+func writePartial(result any) { pluginrpc.WritePartial(stdout, result) }
+
+// watchOptions parses the optional interval/timeout durations of a watch payload.
+func watchOptions(interval, timeout string) (deployment.WatchOptions, error) {
+	var opts deployment.WatchOptions
+	var err error
+	if interval != "" {
+		if opts.Interval, err = time.ParseDuration(interval); err != nil {
+			return opts, fmt.Errorf("invalid interval: %w", err)
+		}
+	}
+	if timeout != "" {
+		if opts.Timeout, err = time.ParseDuration(timeout); err != nil {
+			return opts, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+func writeErr(err error) { pluginrpc.WriteError(stdout, err) }
+
+// reportPending logs the scheduled dispatches that have not fired as the
+// plugin exits. Those in scheduleDir fire once the plugin runs again; the
+// others are lost.
+func reportPending(providers map[string]*deployment.Provider) {
+	for _, provider := range providers {
+		for _, s := range provider.Pending() {
+			at := s.Input.At.Format(time.RFC3339)
+			if s.Persisted {
+				log.Printf("Scheduled dispatch %s of %s on %s at %s is kept in scheduleDir", s.ID, s.Input.Workflow, s.Input.Ref, at)
+			} else {
+				log.Printf("Scheduled dispatch %s of %s on %s at %s is lost: set scheduleDir to keep schedules across restarts", s.ID, s.Input.Workflow, s.Input.Ref, at)
+			}
+		}
+	}
 }
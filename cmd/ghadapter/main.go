@@ -0,0 +1,375 @@
+// Command ghadapter runs adapter operations by hand against GitHub, reusing the
+// providers, so operators can verify config and inspect mappings without wiring
+// up the orchestrator.
+//
+// Usage:
+//
+//	ghadapter [global flags] <resource> <command> [flags]
+//
+// Resources and commands:
+//
+//	tickets list          List issues mapped to tickets
+//	tickets create        Create an issue
+//	deployments list      List workflow runs mapped to deployments
+//	deployments trigger   Dispatch a workflow
+//	teams members <team>  List a team's members
+//
+// Config is read from the JSON file given with -config (the same keys the plugins
+// accept), falls back to the GITHUB_* environment variables, and can be overridden
+// per run with -repository, -organization, and -mode. Results are printed as JSON.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/deployment"
+	"github.com/opsorch/opsorch-github-adapter/internal/redact"
+	"github.com/opsorch/opsorch-github-adapter/team"
+	"github.com/opsorch/opsorch-github-adapter/ticket"
+)
+
+const usage = `usage: ghadapter [global flags] <resource> <command> [flags]
+
+Commands:
+  tickets list          List issues mapped to tickets
+  tickets create        Create an issue
+  deployments list      List workflow runs mapped to deployments
+  deployments trigger   Dispatch a workflow
+  teams members <team>  List a team's members
+
+Global flags:
+`
+
+// errUsage signals that usage has already been printed.
+var errUsage = errors.New("usage")
+
+func main() {
+	// Secrets the providers register are masked in everything logged
+	log.SetOutput(redact.Default.Writer(os.Stderr))
+
+	if err := run(context.Background(), os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "ghadapter:", redact.Default.Error(err))
+		}
+		os.Exit(1)
+	}
+}
+
+// run executes one command, writing results to stdout and usage to stderr.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	global := flag.NewFlagSet("ghadapter", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		global.PrintDefaults()
+	}
+	configPath := global.String("config", "", "JSON file with provider config")
+	repository := global.String("repository", "", "repository as owner/name (overrides config)")
+	organization := global.String("organization", "", "organization for team commands (overrides config)")
+	mode := global.String("mode", "", "api or fixtures (overrides config)")
+	if err := global.Parse(args); err != nil {
+		return errUsage
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	for key, value := range map[string]string{"repository": *repository, "organization": *organization, "mode": *mode} {
+		if value != "" {
+			cfg[key] = value
+		}
+	}
+
+	rest := global.Args()
+	if len(rest) < 2 {
+		global.Usage()
+		return errUsage
+	}
+
+	c := command{ctx: ctx, cfg: cfg, args: rest[2:], stdout: stdout, stderr: stderr}
+	switch rest[0] + " " + rest[1] {
+	case "tickets list":
+		return c.ticketsList()
+	case "tickets create":
+		return c.ticketsCreate()
+	case "deployments list":
+		return c.deploymentsList()
+	case "deployments trigger":
+		return c.deploymentsTrigger()
+	case "teams members":
+		return c.teamsMembers()
+	default:
+		fmt.Fprintf(stderr, "unknown command: %s %s\n\n", rest[0], rest[1])
+		global.Usage()
+		return errUsage
+	}
+}
+
+// loadConfig reads the provider config file, or returns an empty config when path is "".
+func loadConfig(path string) (map[string]any, error) {
+	cfg := map[string]any{}
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// command carries the shared state of one subcommand invocation.
+type command struct {
+	ctx    context.Context
+	cfg    map[string]any
+	args   []string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// flags returns a flag set for the subcommand that reports errors to stderr.
+func (c command) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// print writes v as indented JSON.
+func (c command) print(v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (c command) ticketsList() error {
+	fs := c.flags("tickets list")
+	status := fs.String("status", "", "open or closed (default open)")
+	assignee := fs.String("assignee", "", "only issues assigned to this login")
+	limit := fs.Int("limit", 0, "maximum number of issues per page")
+	saved := fs.String("saved", "", "start from this preset in the queries config")
+	sortBy := fs.String("sort", "", "created (default), updated, or comments")
+	direction := fs.String("direction", "", "desc (default) or asc")
+	var labels listFlag
+	fs.Var(&labels, "label", "only issues with this label (repeatable)")
+	if err := fs.Parse(c.args); err != nil {
+		return errUsage
+	}
+
+	p, err := ticket.New(c.cfg)
+	if err != nil {
+		return err
+	}
+	query := schema.TicketQuery{Limit: *limit, Metadata: map[string]any{}}
+	if *status != "" {
+		query.Statuses = []string{*status}
+	}
+	// The provider filters by assignee through the query scope
+	query.Scope.Team = *assignee
+	if len(labels) > 0 {
+		query.Metadata["labels"] = []string(labels)
+	}
+	if *saved != "" {
+		query.Metadata["savedQuery"] = *saved
+	}
+	if *sortBy != "" {
+		query.Metadata["sort"] = *sortBy
+	}
+	if *direction != "" {
+		query.Metadata["direction"] = *direction
+	}
+
+	tickets, err := p.Query(c.ctx, query)
+	if err != nil {
+		return err
+	}
+	return c.print(tickets)
+}
+
+func (c command) ticketsCreate() error {
+	fs := c.flags("tickets create")
+	title := fs.String("title", "", "issue title (required)")
+	description := fs.String("description", "", "issue body")
+	dryRun := fs.Bool("dry-run", false, "print the issue that would be created without creating it")
+	var labels, assignees listFlag
+	fs.Var(&labels, "label", "label to apply (repeatable)")
+	fs.Var(&assignees, "assignee", "login to assign (repeatable)")
+	if err := fs.Parse(c.args); err != nil {
+		return errUsage
+	}
+
+	p, err := ticket.New(c.cfg)
+	if err != nil {
+		return err
+	}
+	input := schema.CreateTicketInput{
+		Title:       *title,
+		Description: *description,
+		Fields:      map[string]any{},
+		Metadata:    map[string]any{"dryRun": *dryRun},
+	}
+	if len(assignees) > 0 {
+		input.Fields["assignees"] = []string(assignees)
+	}
+	if len(labels) > 0 {
+		input.Metadata["labels"] = []string(labels)
+	}
+
+	created, err := p.Create(c.ctx, input)
+	if err != nil {
+		return err
+	}
+	return c.print(created)
+}
+
+func (c command) deploymentsList() error {
+	fs := c.flags("deployments list")
+	status := fs.String("status", "", "queued, running, success, failed, or cancelled")
+	environment := fs.String("environment", "", "only deployments to this environment")
+	branch := fs.String("branch", "", "only runs on this branch")
+	tag := fs.String("tag", "", "only deployments of this tag, or tags matching this glob (e.g. v2.3.*)")
+	event := fs.String("event", "", "only runs triggered by this event, e.g. workflow_dispatch")
+	limit := fs.Int("limit", 0, "maximum number of runs per page")
+	saved := fs.String("saved", "", "start from this preset in the queries config")
+	sortBy := fs.String("sort", "", "started (default) or finished")
+	direction := fs.String("direction", "", "desc (default) or asc")
+	if err := fs.Parse(c.args); err != nil {
+		return errUsage
+	}
+
+	p, err := deployment.New(c.cfg)
+	if err != nil {
+		return err
+	}
+	query := schema.DeploymentQuery{Limit: *limit, Metadata: map[string]any{}}
+	if *status != "" {
+		query.Statuses = []string{*status}
+	}
+	query.Scope.Environment = *environment
+	if *branch != "" {
+		query.Metadata["branch"] = *branch
+	}
+	if *tag != "" {
+		query.Metadata["tag"] = *tag
+	}
+	if *event != "" {
+		query.Metadata["event"] = *event
+	}
+	if *saved != "" {
+		query.Metadata["savedQuery"] = *saved
+	}
+	if *sortBy != "" {
+		query.Metadata["sort"] = *sortBy
+	}
+	if *direction != "" {
+		query.Metadata["direction"] = *direction
+	}
+
+	deployments, err := p.Query(c.ctx, query)
+	if err != nil {
+		return err
+	}
+	return c.print(deployments)
+}
+
+func (c command) deploymentsTrigger() error {
+	fs := c.flags("deployments trigger")
+	workflow := fs.String("workflow", "", "workflow file name or ID (required)")
+	ref := fs.String("ref", "", "branch or tag to run on (required)")
+	var inputs listFlag
+	fs.Var(&inputs, "input", "workflow input as key=value (repeatable)")
+	at := fs.String("at", "", "dispatch at this RFC 3339 time instead of now")
+	overrideFreeze := fs.Bool("override-freeze", false, "dispatch during a change freeze that allows overrides")
+	dryRun := fs.Bool("dry-run", false, "check the change freeze and log the dispatch without sending it")
+	if err := fs.Parse(c.args); err != nil {
+		return errUsage
+	}
+
+	input := deployment.TriggerInput{Workflow: *workflow, Ref: *ref, OverrideFreeze: *overrideFreeze}
+	if *dryRun {
+		input.Metadata = map[string]any{"dryRun": true}
+	}
+	for _, kv := range inputs {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid input %q (expected key=value)", kv)
+		}
+		if input.Inputs == nil {
+			input.Inputs = map[string]any{}
+		}
+		input.Inputs[key] = value
+	}
+
+	p, err := deployment.New(c.cfg)
+	if err != nil {
+		return err
+	}
+	provider := p.(*deployment.Provider)
+
+	// The CLI exits once it returns, so it waits for a scheduled time itself
+	// rather than leaving the dispatch to the provider
+	if *at != "" {
+		when, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return fmt.Errorf("invalid -at %q (expected RFC 3339)", *at)
+		}
+		if err := provider.CheckChangeFreeze(c.ctx, input); err != nil {
+			return err
+		}
+		select {
+		case <-time.After(time.Until(when)):
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		}
+	}
+
+	result, err := provider.Trigger(c.ctx, input)
+	if err != nil {
+		return err
+	}
+	return c.print(result)
+}
+
+func (c command) teamsMembers() error {
+	fs := c.flags("teams members")
+	if err := fs.Parse(c.args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(c.stderr, "usage: ghadapter teams members <team slug or ID>")
+		return errUsage
+	}
+
+	p, err := team.New(c.cfg)
+	if err != nil {
+		return err
+	}
+	members, err := p.Members(c.ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return c.print(members)
+}
+
+// listFlag collects the values of a repeatable flag.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
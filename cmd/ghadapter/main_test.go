@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixturesDir gives each test its own fixture store, since writes are shared by
+// every provider opened on the same directory.
+func fixturesDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	data, err := os.ReadFile(filepath.Join("..", "..", "internal", "fixtures", "data", "workflow_runs.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "workflow_runs.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), args, &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestTicketsList(t *testing.T) {
+	out, err := runCLI(t, "-mode", "fixtures", "-repository", "opsorch/demo", "tickets", "list", "-label", "sev1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tickets []map[string]any
+	if err := json.Unmarshal([]byte(out), &tickets); err != nil {
+		t.Fatalf("output is not a ticket list: %v\n%s", err, out)
+	}
+	if len(tickets) != 1 || tickets[0]["id"] != "1" {
+		t.Errorf("tickets = %s", out)
+	}
+}
+
+func TestTicketsCreateDryRun(t *testing.T) {
+	out, err := runCLI(t, "-mode", "fixtures", "-repository", "opsorch/demo",
+		"tickets", "create", "-title", "Checkout errors", "-label", "incident", "-dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"title": "Checkout errors"`) || !strings.Contains(out, `"dry_run": true`) {
+		t.Errorf("output = %s", out)
+	}
+
+	if _, err := runCLI(t, "-mode", "fixtures", "-repository", "opsorch/demo", "tickets", "create"); err == nil {
+		t.Error("expected an error without a title")
+	}
+}
+
+func TestDeploymentsTrigger(t *testing.T) {
+	dir := fixturesDir(t)
+	base := []string{"-mode", "fixtures", "-repository", "opsorch/demo", "-config", writeConfig(t, map[string]any{"fixturesDir": dir})}
+
+	if _, err := runCLI(t, append(base, "deployments", "trigger", "-workflow", "deploy.yml", "-ref", "main", "-input", "env=production")...); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCLI(t, append(base, "deployments", "list", "-event", "workflow_dispatch", "-status", "queued")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deployments []map[string]any
+	if err := json.Unmarshal([]byte(out), &deployments); err != nil {
+		t.Fatalf("output is not a deployment list: %v\n%s", err, out)
+	}
+	if len(deployments) != 1 || deployments[0]["id"] != "7004" {
+		t.Errorf("deployments = %s", out)
+	}
+
+	if _, err := runCLI(t, append(base, "deployments", "trigger", "-workflow", "deploy.yml", "-ref", "main", "-input", "novalue")...); err == nil {
+		t.Error("expected an error for a malformed input")
+	}
+}
+
+func TestTeamsMembers(t *testing.T) {
+	out, err := runCLI(t, "-mode", "fixtures", "-organization", "opsorch", "teams", "members", "payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"handle": "`) {
+		t.Errorf("output = %s", out)
+	}
+
+	if _, err := runCLI(t, "-mode", "fixtures", "-organization", "opsorch", "teams", "members"); !errors.Is(err, errUsage) {
+		t.Errorf("missing team error = %v, want usage", err)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	for _, args := range [][]string{nil, {"tickets"}, {"tickets", "delete"}, {"-unknown"}} {
+		if _, err := runCLI(t, args...); !errors.Is(err, errUsage) {
+			t.Errorf("run(%q) error = %v, want usage", args, err)
+		}
+	}
+}
+
+func writeConfig(t *testing.T, cfg map[string]any) string {
+	t.Helper()
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,285 @@
+// Command loadtest replays a mix of provider queries against the fake GitHub
+// server and reports, per query, throughput, latency, allocations, and the API
+// calls one logical query makes, so performance regressions in the mapping and
+// pagination code are measurable without network access or credentials.
+//
+// Usage:
+//
+//	loadtest [-mix file] [-n iterations] [-page-size n] [-v]
+//
+// A mix is a JSON array of queries:
+//
+//	[{"name": "open issues", "provider": "ticket", "method": "query",
+//	  "payload": {"statuses": ["open"], "limit": 50}, "weight": 5}]
+//
+// provider is ticket, deployment, or team. method is query or get for every
+// provider, plus members for team; get and members take {"id": "..."}. payload
+// is the query as the plugins accept it. Each query runs n times its share of
+// the total weight. Without -mix a built-in mix over the default fixtures runs.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/deployment"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+	"github.com/opsorch/opsorch-github-adapter/team"
+	"github.com/opsorch/opsorch-github-adapter/ticket"
+)
+
+// defaultMix exercises listing, filtering, paging, and enrichment over the
+// default fixtures.
+const defaultMix = `[
+	{"name": "ticket query", "provider": "ticket", "method": "query", "payload": {"limit": 50}, "weight": 4},
+	{"name": "ticket query open", "provider": "ticket", "method": "query", "payload": {"statuses": ["open"], "limit": 10}, "weight": 2},
+	{"name": "ticket get", "provider": "ticket", "method": "get", "payload": {"id": "1"}, "weight": 4},
+	{"name": "deployment query", "provider": "deployment", "method": "query", "payload": {"limit": 20}, "weight": 4},
+	{"name": "deployment query failed", "provider": "deployment", "method": "query", "payload": {"statuses": ["failed"], "limit": 5}, "weight": 2},
+	{"name": "deployment get", "provider": "deployment", "method": "get", "payload": {"id": "1001"}, "weight": 2},
+	{"name": "team query", "provider": "team", "method": "query", "payload": {}, "weight": 1},
+	{"name": "team members", "provider": "team", "method": "members", "payload": {"id": "sre"}, "weight": 1}
+]`
+
+// entry is one query of a mix.
+type entry struct {
+	Name     string          `json:"name"`
+	Provider string          `json:"provider"`
+	Method   string          `json:"method"`
+	Payload  json.RawMessage `json:"payload"`
+	Weight   int             `json:"weight"`
+}
+
+// result is what one query of a mix measured.
+type result struct {
+	name     string
+	runs     int
+	elapsed  time.Duration
+	allocs   uint64
+	bytes    uint64
+	apiCalls int
+}
+
+// providers are the providers a mix runs against, all on one fake server.
+type providers struct {
+	ticket     *ticket.Provider
+	deployment *deployment.Provider
+	team       *team.Provider
+}
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "loadtest:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run replays the mix selected by args and writes the report to stdout.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	mixPath := flags.String("mix", "", "JSON file with the query mix (built-in mix when unset)")
+	iterations := flags.Int("n", 1000, "total queries to run, shared by weight")
+	pageSize := flags.Int("page-size", 0, "cap the fake server's page size to force pagination (0 leaves it uncapped)")
+	verbose := flags.Bool("v", false, "print the providers' log messages")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !*verbose {
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(os.Stderr)
+	}
+
+	mix, err := loadMix(*mixPath)
+	if err != nil {
+		return err
+	}
+
+	srv := fakegithub.Start()
+	defer srv.Close()
+	srv.PageSize = *pageSize
+	p, err := newProviders(ghapi.FromClient(srv.Client()))
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, e := range mix {
+		total += e.Weight
+	}
+	var results []result
+	start := time.Now()
+	for _, e := range mix {
+		runs := max(*iterations*e.Weight/total, 1)
+		r, err := measure(ctx, p, srv, e, runs)
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.Name, err)
+		}
+		results = append(results, r)
+	}
+	report(stdout, results, time.Since(start))
+	return nil
+}
+
+// loadMix reads the mix at path, or the built-in mix when path is empty.
+func loadMix(path string) ([]entry, error) {
+	data := []byte(defaultMix)
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	var mix []entry
+	if err := json.Unmarshal(data, &mix); err != nil {
+		return nil, fmt.Errorf("parsing mix: %w", err)
+	}
+	if len(mix) == 0 {
+		return nil, errors.New("mix is empty")
+	}
+	for i := range mix {
+		if mix[i].Name == "" {
+			mix[i].Name = mix[i].Provider + " " + mix[i].Method
+		}
+		if mix[i].Weight <= 0 {
+			mix[i].Weight = 1
+		}
+	}
+	return mix, nil
+}
+
+func newProviders(api ghapi.Services) (*providers, error) {
+	repo := map[string]any{"owner": fakegithub.Owner, "repo": fakegithub.Repo}
+	t, err := ticket.NewWithServices(repo, api)
+	if err != nil {
+		return nil, err
+	}
+	d, err := deployment.NewWithServices(map[string]any{"owner": fakegithub.Owner, "repo": fakegithub.Repo, "terminalRunTTL": "0s"}, api)
+	if err != nil {
+		return nil, err
+	}
+	tm, err := team.NewWithServices(map[string]any{"organization": fakegithub.Organization}, api)
+	if err != nil {
+		return nil, err
+	}
+	return &providers{ticket: t, deployment: d, team: tm}, nil
+}
+
+// measure runs e runs times in a row, reading allocations and API calls
+// around the whole block so measuring does not distort the runs.
+func measure(ctx context.Context, p *providers, srv *fakegithub.Server, e entry, runs int) (result, error) {
+	call, err := p.call(e)
+	if err != nil {
+		return result{}, err
+	}
+	// One untimed run warms caches and surfaces errors before measuring
+	if err := call(ctx); err != nil {
+		return result{}, err
+	}
+
+	var before, after runtime.MemStats
+	calls := len(srv.Requests())
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := 0; i < runs; i++ {
+		if err := call(ctx); err != nil {
+			return result{}, err
+		}
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	return result{
+		name:     e.Name,
+		runs:     runs,
+		elapsed:  elapsed,
+		allocs:   after.Mallocs - before.Mallocs,
+		bytes:    after.TotalAlloc - before.TotalAlloc,
+		apiCalls: len(srv.Requests()) - calls,
+	}, nil
+}
+
+// call returns a function making the query e describes.
+func (p *providers) call(e entry) (func(context.Context) error, error) {
+	var target struct {
+		ID string `json:"id"`
+	}
+	decode := func(v any) error {
+		if len(e.Payload) == 0 {
+			return nil
+		}
+		return json.Unmarshal(e.Payload, v)
+	}
+
+	switch e.Provider + "." + e.Method {
+	case "ticket.query":
+		var q schema.TicketQuery
+		if err := decode(&q); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error { _, err := p.ticket.Query(ctx, q); return err }, nil
+	case "ticket.get":
+		if err := decode(&target); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error { _, err := p.ticket.Get(ctx, target.ID); return err }, nil
+	case "deployment.query":
+		var q schema.DeploymentQuery
+		if err := decode(&q); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error { _, err := p.deployment.Query(ctx, q); return err }, nil
+	case "deployment.get":
+		if err := decode(&target); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error { _, err := p.deployment.Get(ctx, target.ID); return err }, nil
+	case "team.query":
+		var q schema.TeamQuery
+		if err := decode(&q); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error { _, err := p.team.Query(ctx, q); return err }, nil
+	case "team.get":
+		if err := decode(&target); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error { _, err := p.team.Get(ctx, target.ID); return err }, nil
+	case "team.members":
+		if err := decode(&target); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error { _, err := p.team.Members(ctx, target.ID); return err }, nil
+	}
+	return nil, fmt.Errorf("unknown method %s.%s", e.Provider, e.Method)
+}
+
+// report writes one row per query and a total line.
+func report(w io.Writer, results []result, elapsed time.Duration) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "query\truns\tqueries/s\tus/query\tallocs/query\tbytes/query\tAPI calls/query\t")
+	runs := 0
+	for _, r := range results {
+		n := float64(r.runs)
+		fmt.Fprintf(tw, "%s\t%d\t%.0f\t%.1f\t%.0f\t%.0f\t%.2f\t\n",
+			r.name, r.runs, n/r.elapsed.Seconds(), float64(r.elapsed.Microseconds())/n,
+			float64(r.allocs)/n, float64(r.bytes)/n, float64(r.apiCalls)/n)
+		runs += r.runs
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\n%d queries in %s (%.0f queries/s)\n", runs, elapsed.Round(time.Millisecond), float64(runs)/elapsed.Seconds())
+}
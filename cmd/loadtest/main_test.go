@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runLoadtest(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), args, &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func TestDefaultMix(t *testing.T) {
+	out, err := runLoadtest(t, "-n", "20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"API calls/query", "ticket query", "deployment get", "team members", "queries in"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report is missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestMixFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mix.json")
+	mix := `[{"provider": "ticket", "method": "query", "payload": {"limit": 3}}]`
+	if err := os.WriteFile(path, []byte(mix), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// One issue per page takes three calls to find the two issues
+	out, err := runLoadtest(t, "-mix", path, "-n", "5", "-page-size", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var row string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "ticket query") {
+			row = line
+		}
+	}
+	if fields := strings.Fields(row); len(fields) == 0 || fields[len(fields)-1] != "3.00" {
+		t.Errorf("ticket query row = %q, want 3.00 API calls per query", row)
+	}
+}
+
+func TestUnknownMethod(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mix.json")
+	if err := os.WriteFile(path, []byte(`[{"provider": "ticket", "method": "delete"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runLoadtest(t, "-mix", path); err == nil || !strings.Contains(err.Error(), "unknown method ticket.delete") {
+		t.Errorf("run() error = %v", err)
+	}
+}
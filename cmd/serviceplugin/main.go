@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/pluginrpc"
+	"github.com/opsorch/opsorch-github-adapter/internal/redact"
+	"github.com/opsorch/opsorch-github-adapter/service"
+)
+
+// stdout receives responses; tests redirect it.
+var stdout io.Writer = os.Stdout
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "--version" {
+		fmt.Println("opsorch-github-service-plugin v1.0.0")
+		return
+	}
+
+	// Secrets the providers register are masked in everything logged
+	log.SetOutput(redact.Default.Writer(os.Stderr))
+
+	serve(context.Background(), os.Stdin)
+}
+
+// serve answers newline-delimited requests read from in until EOF or malformed input.
+func serve(ctx context.Context, in io.Reader) {
+	providers := map[string]*service.Provider{}
+
+	dec := json.NewDecoder(in)
+	for {
+		var req pluginrpc.Request
+		if err := dec.Decode(&req); err != nil {
+			if err.Error() == "EOF" {
+				return
+			}
+			writeErr(err)
+			return
+		}
+
+		if pluginrpc.Gate(stdout, req) {
+			continue
+		}
+
+		// Initialize the request's profile provider if not already done
+		provider := providers[req.Profile]
+		if provider == nil {
+			cfg, err := ghconfig.Profile(req.Config, req.Profile)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			p, err := service.New(cfg)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			if githubProvider, ok := p.(*service.Provider); ok {
+				provider = githubProvider
+				providers[req.Profile] = provider
+			} else {
+				writeErr(fmt.Errorf("failed to create GitHub service provider"))
+				continue
+			}
+		}
+
+		switch req.Method {
+		case "service.query":
+			var query schema.ServiceQuery
+			if err := json.Unmarshal(req.Payload, &query); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.Query(ctx, query)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "service.get":
+			var payload struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.Get(ctx, payload.ID)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "service.setTags":
+			var payload struct {
+				ID string `json:"id"`
+				service.TagsInput
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.SetTags(ctx, payload.ID, payload.TagsInput)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "service.capabilities":
+			writeOK(provider.Capabilities())
+
+		default:
+			writeErr(fmt.Errorf("unknown method: %s", req.Method))
+		}
+	}
+}
+
+func writeOK(result any) { pluginrpc.WriteOK(stdout, result) }
+
+func writeErr(err error) { pluginrpc.WriteError(stdout, err) }
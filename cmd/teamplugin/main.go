@@ -3,19 +3,27 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 
+	"github.com/opsorch/opsorch-core/orcherr"
 	"github.com/opsorch/opsorch-core/schema"
 	coreteam "github.com/opsorch/opsorch-core/team"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/pluginrpc"
+	"github.com/opsorch/opsorch-github-adapter/internal/redact"
 	"github.com/opsorch/opsorch-github-adapter/team"
+	"github.com/opsorch/opsorch-github-adapter/webhook"
 )
 
 // PluginRequest represents an incoming RPC request.
 type PluginRequest struct {
-	Method string          `json:"method"`
-	Params json.RawMessage `json:"params"`
+	Method  string          `json:"method"`
+	Profile string          `json:"profile,omitempty"` // Entry of the config's "profiles" map to serve the request with
+	Params  json.RawMessage `json:"params"`
 }
 
 // PluginResponse represents an outgoing RPC response.
@@ -28,18 +36,40 @@ type PluginResponse struct {
 type PluginError struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
+	Details string `json:"details,omitempty"` // Underlying cause of a provider error, when it has one
 }
 
-func main() {
-	// Read configuration from environment
-	configJSON := os.Getenv("OPSORCH_TEAM_CONFIG")
-	if configJSON == "" {
-		log.Fatal("OPSORCH_TEAM_CONFIG environment variable is required")
+// errorResponse reports a provider error under its OpsOrch code, so callers can
+// tell a missing team from a bad token or a rate limit. Errors without a code
+// are reported as provider_error.
+func errorResponse(err error) PluginResponse {
+	var opsErr *orcherr.OpsOrchError
+	if errors.As(err, &opsErr) {
+		pluginErr := &PluginError{Code: opsErr.Code, Message: opsErr.Message}
+		if opsErr.Err != nil {
+			pluginErr.Details = opsErr.Err.Error()
+		}
+		return PluginResponse{Error: pluginErr}
+	}
+	return PluginResponse{
+		Error: &PluginError{
+			Code:    "provider_error",
+			Message: err.Error(),
+		},
 	}
+}
+
+func main() {
+	// Secrets the provider registers are masked in everything logged
+	log.SetOutput(redact.Default.Writer(os.Stderr))
 
-	var config map[string]any
-	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
-		log.Fatalf("Failed to parse config: %v", err)
+	// Read configuration from environment; when unset the provider falls back
+	// to the GITHUB_* variables GitHub Actions provides.
+	config := map[string]any{}
+	if configJSON := os.Getenv("OPSORCH_TEAM_CONFIG"); configJSON != "" {
+		if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+			log.Fatalf("Failed to parse config: %v", err)
+		}
 	}
 
 	// Create the GitHub team provider
@@ -47,28 +77,122 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create GitHub team provider: %v", err)
 	}
+	// A receiver in this process refreshes the cache its providers read
+	if _, err := webhook.Host(config); err != nil {
+		log.Fatalf("Failed to start GitHub webhook receiver: %v", err)
+	}
 
 	// Process RPC requests from stdin
-	decoder := json.NewDecoder(os.Stdin)
-	encoder := json.NewEncoder(os.Stdout)
+	serve(newProfiles(config, provider), os.Stdin, os.Stdout)
+}
+
+// profiles holds a provider per entry of the config's "profiles" map, created
+// on first use, next to the provider of the base config.
+type profiles struct {
+	config    map[string]any
+	providers map[string]coreteam.Provider
+}
+
+func newProfiles(config map[string]any, provider coreteam.Provider) *profiles {
+	return &profiles{config: config, providers: map[string]coreteam.Provider{"": provider}}
+}
+
+// get returns the provider of the named profile, "" being the base config.
+func (p *profiles) get(name string) (coreteam.Provider, error) {
+	if provider, ok := p.providers[name]; ok {
+		return provider, nil
+	}
+	cfg, err := ghconfig.Profile(p.config, name)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := team.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := webhook.Host(cfg); err != nil {
+		return nil, err
+	}
+	p.providers[name] = provider
+	return provider, nil
+}
+
+// serve answers newline-delimited requests read from in until EOF or malformed input.
+func serve(providers *profiles, in io.Reader, out io.Writer) {
+	decoder := json.NewDecoder(in)
+	encoder := json.NewEncoder(out)
 
 	for {
 		var req PluginRequest
 		if err := decoder.Decode(&req); err != nil {
 			if err.Error() == "EOF" {
-				break
+				return
 			}
-			log.Printf("Failed to decode request: %v", err)
+			// The decoder cannot resynchronize after malformed input, so report
+			// the error and exit instead of spinning on it
+			_ = encoder.Encode(PluginResponse{
+				Error: &PluginError{
+					Code:    "bad_request",
+					Message: fmt.Sprintf("Failed to decode request: %v", err),
+				},
+			})
+			return
+		}
+
+		if err := pluginrpc.Check(providers.config, req.Profile, req.Method, len(req.Params)); err != nil {
+			response := errorResponse(err)
+			response.Error.Message = redact.Default.String(response.Error.Message)
+			_ = encoder.Encode(response)
 			continue
 		}
 
-		response := handleRequest(provider, req)
+		response, ok := globalResponse(req)
+		if !ok {
+			provider, err := providers.get(req.Profile)
+			if err != nil {
+				_ = encoder.Encode(PluginResponse{
+					Error: &PluginError{
+						Code:    "bad_request",
+						Message: redact.Default.String(err.Error()),
+					},
+				})
+				continue
+			}
+			response = handleRequest(provider, req)
+		}
+		if response.Error != nil {
+			response.Error.Message = redact.Default.String(response.Error.Message)
+			response.Error.Details = redact.Default.String(response.Error.Details)
+		}
 		if err := encoder.Encode(response); err != nil {
 			log.Printf("Failed to encode response: %v", err)
 		}
 	}
 }
 
+// globalResponse answers the methods the process serves rather than a provider.
+// ok is false for other methods.
+func globalResponse(req PluginRequest) (response PluginResponse, ok bool) {
+	result, ok, err := pluginrpc.Global(req.Method, req.Params)
+	if !ok {
+		return PluginResponse{}, false
+	}
+	var payloadErr *pluginrpc.PayloadError
+	if errors.As(err, &payloadErr) {
+		return PluginResponse{
+			Error: &PluginError{
+				Code:    "bad_request",
+				Message: fmt.Sprintf("Invalid parameters: %v", err),
+			},
+		}, true
+	}
+	if err != nil {
+		return errorResponse(err), true
+	}
+	data, _ := json.Marshal(result)
+	return PluginResponse{Result: data}, true
+}
+
 func handleRequest(provider coreteam.Provider, req PluginRequest) PluginResponse {
 	ctx := context.Background()
 
@@ -86,12 +210,7 @@ func handleRequest(provider coreteam.Provider, req PluginRequest) PluginResponse
 
 		teams, err := provider.Query(ctx, query)
 		if err != nil {
-			return PluginResponse{
-				Error: &PluginError{
-					Code:    "provider_error",
-					Message: err.Error(),
-				},
-			}
+			return errorResponse(err)
 		}
 
 		result, _ := json.Marshal(teams)
@@ -112,12 +231,7 @@ func handleRequest(provider coreteam.Provider, req PluginRequest) PluginResponse
 
 		team, err := provider.Get(ctx, params.ID)
 		if err != nil {
-			return PluginResponse{
-				Error: &PluginError{
-					Code:    "provider_error",
-					Message: err.Error(),
-				},
-			}
+			return errorResponse(err)
 		}
 
 		result, _ := json.Marshal(team)
@@ -138,17 +252,75 @@ func handleRequest(provider coreteam.Provider, req PluginRequest) PluginResponse
 
 		members, err := provider.Members(ctx, params.TeamID)
 		if err != nil {
+			return errorResponse(err)
+		}
+
+		result, _ := json.Marshal(members)
+		return PluginResponse{Result: result}
+
+	case "team.snapshot":
+		githubProvider, ok := provider.(*team.Provider)
+		if !ok {
 			return PluginResponse{
 				Error: &PluginError{
-					Code:    "provider_error",
-					Message: err.Error(),
+					Code:    "method_not_found",
+					Message: "team.snapshot is not supported by this provider",
 				},
 			}
 		}
 
-		result, _ := json.Marshal(members)
+		snapshot, err := githubProvider.Snapshot(ctx)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		result, _ := json.Marshal(snapshot)
+		return PluginResponse{Result: result}
+
+	case "team.capabilities":
+		githubProvider, ok := provider.(*team.Provider)
+		if !ok {
+			return PluginResponse{
+				Error: &PluginError{
+					Code:    "method_not_found",
+					Message: "team.capabilities is not supported by this provider",
+				},
+			}
+		}
+
+		result, _ := json.Marshal(githubProvider.Capabilities())
 		return PluginResponse{Result: result}
 
+	case "github.raw":
+		var params struct {
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return PluginResponse{
+				Error: &PluginError{
+					Code:    "bad_request",
+					Message: fmt.Sprintf("Invalid parameters: %v", err),
+				},
+			}
+		}
+
+		githubProvider, ok := provider.(*team.Provider)
+		if !ok {
+			return PluginResponse{
+				Error: &PluginError{
+					Code:    "method_not_found",
+					Message: "github.raw is not supported by this provider",
+				},
+			}
+		}
+
+		body, err := githubProvider.Raw(ctx, params.Path)
+		if err != nil {
+			return errorResponse(err)
+		}
+
+		return PluginResponse{Result: body}
+
 	default:
 		return PluginResponse{
 			Error: &PluginError{
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/team"
+)
+
+// FuzzServe feeds arbitrary request streams to the plugin loop against a
+// fixtures-mode provider. Seeds come from the conformance cases.
+func FuzzServe(f *testing.F) {
+	config := map[string]any{"mode": "fixtures", "organization": "opsorch"}
+	provider, err := team.New(config)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	seeds, _ := filepath.Glob("../../conformance/testdata/team/*.in")
+	for _, path := range seeds {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte(`{"method":"team.query","params":{"tags":{"privacy":1}}}`))
+	f.Add([]byte(`{"method":"team.members","params":{"teamID":"-1"}}`))
+	f.Add([]byte(`{"method":"github.raw","params":{"path":"/../orgs"}}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		serve(newProfiles(config, provider), bytes.NewReader(data), io.Discard)
+	})
+}
+
+// failingProvider fails every call with err.
+type failingProvider struct{ err error }
+
+func (p failingProvider) Query(context.Context, schema.TeamQuery) ([]schema.Team, error) {
+	return nil, p.err
+}
+
+func (p failingProvider) Get(context.Context, string) (schema.Team, error) {
+	return schema.Team{}, p.err
+}
+
+func (p failingProvider) Members(context.Context, string) ([]schema.TeamMember, error) {
+	return nil, p.err
+}
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want PluginError
+	}{
+		{
+			name: "coded",
+			err:  &orcherr.OpsOrchError{Code: "not_found", Message: "GitHub resource not found"},
+			want: PluginError{Code: "not_found", Message: "GitHub resource not found"},
+		},
+		{
+			name: "coded with cause",
+			err:  fmt.Errorf("listing members: %w", &orcherr.OpsOrchError{Code: "rate_limited", Message: "GitHub API rate limit exceeded", Err: errors.New("403 API rate limit exceeded")}),
+			want: PluginError{Code: "rate_limited", Message: "GitHub API rate limit exceeded", Details: "403 API rate limit exceeded"},
+		},
+		{
+			name: "uncoded",
+			err:  errors.New("boom"),
+			want: PluginError{Code: "provider_error", Message: "boom"},
+		},
+	}
+
+	requests := `{"method":"team.query","params":{}}
+{"method":"team.get","params":{"id":"platform"}}
+{"method":"team.members","params":{"teamID":"platform"}}
+`
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			serve(newProfiles(nil, failingProvider{tt.err}), strings.NewReader(requests), &out)
+
+			dec := json.NewDecoder(&out)
+			for i := 0; i < 3; i++ {
+				var resp PluginResponse
+				if err := dec.Decode(&resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.Error == nil || *resp.Error != tt.want {
+					t.Errorf("response %d error = %+v, want %+v", i, resp.Error, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAllowedMethods(t *testing.T) {
+	config := map[string]any{"allowedMethods": []any{"team.get"}}
+	requests := `{"method":"team.get","params":{"id":"platform"}}
+{"method":"team.members","params":{"teamID":"platform"}}
+`
+	var out bytes.Buffer
+	serve(newProfiles(config, failingProvider{errors.New("boom")}), strings.NewReader(requests), &out)
+
+	dec := json.NewDecoder(&out)
+	for _, want := range []string{"provider_error", "forbidden"} {
+		var resp PluginResponse
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error == nil || resp.Error.Code != want {
+			t.Errorf("error = %+v, want code %s", resp.Error, want)
+		}
+	}
+}
+
+func TestPayloadLimit(t *testing.T) {
+	config := map[string]any{"maxPayloadBytes": 16}
+	requests := `{"method":"team.get","params":{"id":"platform-engineering"}}
+`
+	var out bytes.Buffer
+	serve(newProfiles(config, failingProvider{errors.New("boom")}), strings.NewReader(requests), &out)
+
+	var resp PluginResponse
+	if err := json.NewDecoder(&out).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error == nil || resp.Error.Code != "bad_request" || !strings.Contains(resp.Error.Message, "maxPayloadBytes") {
+		t.Errorf("error = %+v, want a bad_request naming maxPayloadBytes", resp.Error)
+	}
+}
@@ -4,22 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"time"
 
 	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/analytics"
+	"github.com/opsorch/opsorch-github-adapter/change"
+	"github.com/opsorch/opsorch-github-adapter/deployment"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/pluginrpc"
+	"github.com/opsorch/opsorch-github-adapter/internal/redact"
+	"github.com/opsorch/opsorch-github-adapter/postmortem"
 	"github.com/opsorch/opsorch-github-adapter/ticket"
+	"github.com/opsorch/opsorch-github-adapter/webhook"
 )
 
-type rpcRequest struct {
-	Method  string          `json:"method"`
-	Config  map[string]any  `json:"config"`
-	Payload json.RawMessage `json:"payload"`
-}
-
-type rpcResponse struct {
-	Result any    `json:"result,omitempty"`
-	Error  string `json:"error,omitempty"`
-}
+// stdout receives responses; tests redirect it.
+var stdout io.Writer = os.Stdout
 
 func main() {
 	if len(os.Args) > 1 && os.Args[1] == "--version" {
@@ -27,11 +30,21 @@ func main() {
 		return
 	}
 
-	var provider *ticket.Provider
+	// Secrets the providers register are masked in everything logged
+	log.SetOutput(redact.Default.Writer(os.Stderr))
+
+	serve(context.Background(), os.Stdin)
+}
+
+// serve answers newline-delimited requests read from in until EOF or malformed input.
+func serve(ctx context.Context, in io.Reader) {
+	providers := map[string]*ticket.Provider{}
+	deploymentProviders := map[string]*deployment.Provider{}
+	postmortems := map[string]*postmortem.Generator{}
 
-	dec := json.NewDecoder(os.Stdin)
+	dec := json.NewDecoder(in)
 	for {
-		var req rpcRequest
+		var req pluginrpc.Request
 		if err := dec.Decode(&req); err != nil {
 			if err.Error() == "EOF" {
 				return
@@ -40,23 +53,37 @@ func main() {
 			return
 		}
 
-		// Initialize provider if not already done
+		if pluginrpc.Gate(stdout, req) {
+			continue
+		}
+
+		// Initialize the request's profile provider if not already done
+		provider := providers[req.Profile]
 		if provider == nil {
-			p, err := ticket.New(req.Config)
+			cfg, err := ghconfig.Profile(req.Config, req.Profile)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			p, err := ticket.New(cfg)
 			if err != nil {
 				writeErr(err)
 				continue
 			}
+			// A receiver in this process refreshes the cache its providers read
+			if _, err := webhook.Host(cfg); err != nil {
+				writeErr(err)
+				continue
+			}
 			if githubProvider, ok := p.(*ticket.Provider); ok {
 				provider = githubProvider
+				providers[req.Profile] = provider
 			} else {
 				writeErr(fmt.Errorf("failed to create GitHub ticket provider"))
 				continue
 			}
 		}
 
-		ctx := context.Background()
-
 		switch req.Method {
 		case "ticket.query":
 			var query schema.TicketQuery
@@ -64,12 +91,12 @@ func main() {
 				writeErr(err)
 				continue
 			}
-			result, err := provider.Query(ctx, query)
+			page, err := provider.QueryPage(ctx, query)
 			if err != nil {
 				writeErr(err)
 				continue
 			}
-			writeOK(result)
+			writePage(page.Tickets, page.NextPageToken, page.TotalCount, page.Truncated)
 
 		case "ticket.get":
 			var payload struct {
@@ -115,18 +142,283 @@ func main() {
 			}
 			writeOK(result)
 
+		case "ticket.watch":
+			var payload struct {
+				ID          string `json:"id"`
+				Interval    string `json:"interval"`
+				Timeout     string `json:"timeout"`
+				StopOnClose bool   `json:"stopOnClose"`
+				Stream      bool   `json:"stream"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			opts, err := watchOptions(payload.Interval, payload.Timeout)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			opts.StopOnClose = payload.StopOnClose
+			var onChange func(ticket.Change)
+			if payload.Stream {
+				// Changes are written as partial responses ahead of the final result
+				onChange = func(c ticket.Change) { writePartial(c) }
+			}
+			result, err := provider.Watch(ctx, payload.ID, opts, onChange)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "ticket.linkDeployment":
+			var payload struct {
+				ID           string `json:"id"`
+				DeploymentID string `json:"deploymentId"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			deployments, err := deploymentsFor(deploymentProviders, req)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			d, err := deployments.Get(ctx, payload.DeploymentID)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.LinkDeployment(ctx, payload.ID, d)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "ticket.related":
+			var payload struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.Related(ctx, payload.ID)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "ticket.stats":
+			var input ticket.StatsInput
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.Stats(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "ticket.sweepStale":
+			var input ticket.SweepInput
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.SweepStale(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "gist.create":
+			var input ticket.GistInput
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.CreateGist(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "gist.update":
+			var payload struct {
+				ID string `json:"id"`
+				ticket.GistInput
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.UpdateGist(ctx, payload.ID, payload.GistInput)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "gist.get":
+			var payload struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.GetGist(ctx, payload.ID)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "analytics.mttr":
+			var input analytics.Input
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			deployments, err := deploymentsFor(deploymentProviders, req)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := analytics.TimeToRestore(ctx, provider, deployments, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "postmortem.generate":
+			var input postmortem.Input
+			if err := json.Unmarshal(req.Payload, &input); err != nil {
+				writeErr(err)
+				continue
+			}
+			generator, err := postmortemsFor(postmortems, req, provider)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := generator.Generate(ctx, input)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
+		case "ticket.capabilities":
+			writeOK(provider.Capabilities())
+
+		case "github.raw":
+			var payload struct {
+				Path string `json:"path"`
+			}
+			if err := json.Unmarshal(req.Payload, &payload); err != nil {
+				writeErr(err)
+				continue
+			}
+			result, err := provider.Raw(ctx, payload.Path)
+			if err != nil {
+				writeErr(err)
+				continue
+			}
+			writeOK(result)
+
 		default:
 			writeErr(fmt.Errorf("unknown method: %s", req.Method))
 		}
 	}
 }
 
-func writeOK(result any) {
-	enc := json.NewEncoder(os.Stdout)
-	_ = enc.Encode(rpcResponse{Result: result})
+// deploymentsFor returns the deployment provider of the request's profile.
+// Deployments live in the same repository, so it reuses the ticket config.
+func deploymentsFor(providers map[string]*deployment.Provider, req pluginrpc.Request) (*deployment.Provider, error) {
+	if p := providers[req.Profile]; p != nil {
+		return p, nil
+	}
+	cfg, err := ghconfig.Profile(req.Config, req.Profile)
+	if err != nil {
+		return nil, err
+	}
+	p, err := deployment.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	providers[req.Profile] = p.(*deployment.Provider)
+	return providers[req.Profile], nil
 }
 
-func writeErr(err error) {
-	enc := json.NewEncoder(os.Stdout)
-	_ = enc.Encode(rpcResponse{Error: err.Error()})
+// postmortemsFor returns the postmortem generator of the request's profile. It
+// writes through a change provider for the "postmortem" config's repository,
+// which defaults to the ticket repository, with the rest of the ticket config.
+func postmortemsFor(generators map[string]*postmortem.Generator, req pluginrpc.Request, tickets *ticket.Provider) (*postmortem.Generator, error) {
+	if g := generators[req.Profile]; g != nil {
+		return g, nil
+	}
+	cfg, err := ghconfig.Profile(req.Config, req.Profile)
+	if err != nil {
+		return nil, err
+	}
+	pmCfg, _ := cfg["postmortem"].(map[string]any)
+	docsCfg := make(map[string]any, len(cfg))
+	for k, v := range cfg {
+		docsCfg[k] = v
+	}
+	if repository := ghconfig.String(pmCfg, "repository"); repository != "" {
+		delete(docsCfg, "owner")
+		delete(docsCfg, "repo")
+		docsCfg["repository"] = repository
+	}
+	changes, err := change.New(docsCfg)
+	if err != nil {
+		return nil, err
+	}
+	g, err := postmortem.New(pmCfg, tickets, changes)
+	if err != nil {
+		return nil, err
+	}
+	generators[req.Profile] = g
+	return g, nil
 }
+
+func writeOK(result any) { pluginrpc.WriteOK(stdout, result) }
+
+// writePage answers a query with its results, continuation token, and total,
+// and whether the API call budget cut it short.
+func writePage(result any, nextPageToken string, totalCount *int, truncated bool) {
+	pluginrpc.WritePage(stdout, result, nextPageToken, totalCount, truncated)
+}
+
+func writePartial(result any) { pluginrpc.WritePartial(stdout, result) }
+
+// watchOptions parses the optional interval/timeout durations of a watch payload.
+func watchOptions(interval, timeout string) (ticket.WatchOptions, error) {
+	var opts ticket.WatchOptions
+	var err error
+	if interval != "" {
+		if opts.Interval, err = time.ParseDuration(interval); err != nil {
+			return opts, fmt.Errorf("invalid interval: %w", err)
+		}
+	}
+	if timeout != "" {
+		if opts.Timeout, err = time.ParseDuration(timeout); err != nil {
+			return opts, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+func writeErr(err error) { pluginrpc.WriteError(stdout, err) }
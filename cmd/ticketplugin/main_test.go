@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// FuzzServe feeds arbitrary request streams to the plugin loop. Seeds come from
+// the conformance cases, which run in fixtures mode; the network is disabled so
+// mutated configs that select the real API fail fast instead of calling GitHub.
+func FuzzServe(f *testing.F) {
+	stdout = io.Discard
+	http.DefaultTransport = roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("network disabled while fuzzing")
+	})
+
+	seeds, _ := filepath.Glob("../../conformance/testdata/ticket/*.in")
+	for _, path := range seeds {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte(`{"method":"ticket.query","config":{"mode":"fixtures","repository":"opsorch/demo"},"payload":{"metadata":{"labels":"incident"}}}`))
+	f.Add([]byte(`{"method":"ticket.create","config":{"mode":"fixtures","repository":"opsorch/demo"},"payload":{"title":"t","fields":{"assignees":[1,null]},"metadata":{"labels":{"a":1}}}}`))
+	f.Add([]byte(`{"method":"ticket.watch","config":{"mode":"fixtures","repository":"opsorch/demo"},"payload":{"id":"1","interval":"-1s","timeout":"1ns"}}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Bound watch calls and anything else that waits
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		serve(ctx, bytes.NewReader(data))
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/redact"
+	"github.com/opsorch/opsorch-github-adapter/webhook"
+)
+
+func main() {
+	// Secrets the receiver registers are masked in everything logged
+	log.SetOutput(redact.Default.Writer(os.Stderr))
+
+	// Read configuration from environment; when unset the receiver falls back
+	// to the GITHUB_* variables.
+	config := map[string]any{}
+	if configJSON := os.Getenv("OPSORCH_WEBHOOK_CONFIG"); configJSON != "" {
+		if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+			log.Fatalf("Failed to parse config: %v", err)
+		}
+	}
+
+	addr := ghconfig.String(config, "addr")
+	if addr == "" {
+		addr = ":8090"
+	}
+	path := ghconfig.String(config, "path")
+	if path == "" {
+		path = "/webhook"
+	}
+
+	// Emit each normalized event as one JSON line on stdout
+	var mu sync.Mutex
+	encoder := json.NewEncoder(os.Stdout)
+	sink := func(event webhook.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := encoder.Encode(event); err != nil {
+			log.Printf("Failed to encode event: %v", err)
+		}
+	}
+
+	receiver, err := webhook.New(config, sink)
+	if err != nil {
+		log.Fatalf("Failed to create GitHub webhook receiver: %v", err)
+	}
+
+	// Catch up on deliveries missed while the receiver was down
+	if window := ghconfig.Duration(config, "replayOnStart", 0); window > 0 {
+		go func() {
+			n, err := receiver.Replay(context.Background(), time.Now().Add(-window))
+			if err != nil {
+				log.Printf("Failed to replay webhook deliveries: %v", err)
+				return
+			}
+			log.Printf("Replayed %d missed webhook deliveries", n)
+		}()
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, receiver)
+
+	log.Printf("Listening for GitHub webhooks on %s%s", addr, path)
+	log.Fatal(http.ListenAndServe(addr, mux))
+}
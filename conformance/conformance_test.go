@@ -0,0 +1,254 @@
+// Package conformance drives the built plugin binaries over stdin/stdout and
+// checks their protocol behavior against golden files.
+//
+// Each testdata/<plugin>/<case>.in file is fed to the plugin verbatim and the
+// JSON lines it writes are compared with <case>.golden. The plugins run in
+// fixtures mode, so no token or network is needed. Regenerate golden files with:
+//
+//	go test ./conformance -update
+package conformance
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current plugin output")
+
+// plugins maps each stdin/stdout plugin to its environment. The ticket and
+// deployment plugins take config on every request; the team plugin reads it once
+// from OPSORCH_TEAM_CONFIG.
+var plugins = map[string][]string{
+	"ticket":     nil,
+	"deployment": nil,
+	"team":       {`OPSORCH_TEAM_CONFIG={"mode":"fixtures","organization":"opsorch"}`},
+}
+
+// fixturesConfig is the request config used by programmatic cases.
+var fixturesConfig = map[string]any{"mode": "fixtures", "repository": "opsorch/demo"}
+
+// runTimeout bounds a single plugin run; a plugin that does not exit after its
+// input closes is a protocol failure.
+const runTimeout = 30 * time.Second
+
+var binDir string
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	dir, err := os.MkdirTemp("", "opsorch-conformance")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	binDir = dir
+
+	for name := range plugins {
+		cmd := exec.Command("go", "build", "-o", filepath.Join(dir, name+"plugin"), "../cmd/"+name+"plugin")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			fmt.Fprintf(os.Stderr, "building %s plugin: %v\n%s", name, err, out)
+			os.RemoveAll(dir)
+			os.Exit(1)
+		}
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestGolden(t *testing.T) {
+	for name := range plugins {
+		inputs, err := filepath.Glob(filepath.Join("testdata", name, "*.in"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(inputs) == 0 {
+			t.Errorf("no conformance cases for the %s plugin", name)
+		}
+
+		for _, in := range inputs {
+			name, in := name, in
+			t.Run(name+"/"+strings.TrimSuffix(filepath.Base(in), ".in"), func(t *testing.T) {
+				t.Parallel()
+
+				input, err := os.ReadFile(in)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := run(t, name, input)
+
+				golden := strings.TrimSuffix(in, ".in") + ".golden"
+				if *update {
+					var out []byte
+					for _, line := range got {
+						out = append(append(out, line...), '\n')
+					}
+					if err := os.WriteFile(golden, out, 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+
+				data, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("reading golden file (run with -update to create it): %v", err)
+				}
+				compare(t, got, splitLines(data))
+			})
+		}
+	}
+}
+
+func TestHugePayload(t *testing.T) {
+	pad := strings.Repeat("x", 8<<20)
+	unlimited := map[string]any{"maxPayloadBytes": 0}
+	for k, v := range fixturesConfig {
+		unlimited[k] = v
+	}
+
+	for _, tc := range []struct {
+		plugin string
+		method string
+		id     string
+	}{
+		{"ticket", "ticket.get", "1"},
+		{"deployment", "deployment.get", "7001"},
+	} {
+		t.Run(tc.plugin, func(t *testing.T) {
+			// Without a size limit the padded request is decoded and served;
+			// under the default limit it is refused, and the stream stays in sync
+			// for the next request either way
+			for _, limit := range []struct {
+				config    map[string]any
+				wantError string
+			}{
+				{unlimited, ""},
+				{fixturesConfig, "maxPayloadBytes"},
+			} {
+				var input bytes.Buffer
+				enc := json.NewEncoder(&input)
+				enc.Encode(map[string]any{
+					"method":  tc.method,
+					"config":  limit.config,
+					"payload": map[string]any{"id": tc.id, "padding": pad},
+				})
+				enc.Encode(map[string]any{
+					"method":  tc.method,
+					"config":  limit.config,
+					"payload": map[string]any{"id": tc.id},
+				})
+
+				lines := run(t, tc.plugin, input.Bytes())
+				if len(lines) != 2 {
+					t.Fatalf("got %d responses, want 2", len(lines))
+				}
+				for i, line := range lines {
+					var resp struct {
+						Result map[string]any `json:"result"`
+						Error  string         `json:"error"`
+					}
+					if err := json.Unmarshal(line, &resp); err != nil {
+						t.Fatalf("response %d is not JSON: %v", i, err)
+					}
+					if i == 0 && limit.wantError != "" {
+						if !strings.Contains(resp.Error, limit.wantError) {
+							t.Errorf("response %d = %s, want an error naming %s", i, line, limit.wantError)
+						}
+						continue
+					}
+					if resp.Error != "" || resp.Result["id"] != tc.id {
+						t.Errorf("response %d = %s", i, line)
+					}
+				}
+			}
+		})
+	}
+}
+
+// run feeds input to a plugin and returns the lines it writes before exiting.
+func run(t *testing.T, plugin string, input []byte) [][]byte {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, filepath.Join(binDir, plugin+"plugin"))
+	cmd.Env = append(cleanEnv(), plugins[plugin]...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Fatalf("%s plugin did not exit within %s\nstderr: %s", plugin, runTimeout, stderr.String())
+	}
+	if err != nil {
+		t.Fatalf("%s plugin exited with %v\nstderr: %s", plugin, err, stderr.String())
+	}
+
+	lines := splitLines(stdout.Bytes())
+	for i, line := range lines {
+		if !json.Valid(line) {
+			t.Fatalf("%s plugin wrote a non-JSON line %d: %q", plugin, i, line)
+		}
+	}
+	return lines
+}
+
+// compare checks responses line by line, ignoring formatting and key order.
+func compare(t *testing.T, got, want [][]byte) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d responses, want %d\ngot:\n%s", len(got), len(want), bytes.Join(got, []byte("\n")))
+	}
+	for i := range got {
+		var g, w any
+		json.Unmarshal(got[i], &g)
+		if err := json.Unmarshal(want[i], &w); err != nil {
+			t.Fatalf("golden line %d is not JSON: %v", i, err)
+		}
+		if !reflect.DeepEqual(g, w) {
+			t.Errorf("response %d mismatch\ngot:  %s\nwant: %s", i, got[i], want[i])
+		}
+	}
+}
+
+// cleanEnv strips credentials and Actions variables so results only depend on
+// the case input.
+func cleanEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "GITHUB_") || strings.HasPrefix(kv, "OPSORCH_") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+func splitLines(data []byte) [][]byte {
+	var lines [][]byte
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	for sc.Scan() {
+		if line := bytes.TrimSpace(sc.Bytes()); len(line) > 0 {
+			lines = append(lines, append([]byte(nil), line...))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,97 @@
+package deployment
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// maxAnnotations caps the annotations kept on a deployment. Errors are kept
+// ahead of warnings when the cap is reached.
+const maxAnnotations = 50
+
+// Annotation is an error or warning a job reported against a file, such as a
+// failed test or a linter finding.
+type Annotation struct {
+	Job       string `json:"job"`
+	Level     string `json:"level"` // "error" or "warning"
+	Path      string `json:"path,omitempty"`
+	StartLine int    `json:"startLine,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Message   string `json:"message"`
+}
+
+// AnnotationSummary counts a run's annotations before the cap is applied.
+type AnnotationSummary struct {
+	Errors    int  `json:"errors"`
+	Warnings  int  `json:"warnings"`
+	Truncated bool `json:"truncated"` // Whether annotations were dropped to stay under the cap
+}
+
+// addAnnotations sets fields["annotations"] to the error and warning annotations
+// of the run's jobs, errors first and capped at maxAnnotations, and
+// fields["annotation_summary"] to their counts. Each job is a check run with the
+// same ID. Reading annotations is best effort; a failure is logged and leaves
+// the fields unset.
+func (p *Provider) addAnnotations(ctx context.Context, jobs []Job, fields map[string]any) {
+	if p.api.Checks == nil || jobs == nil {
+		return
+	}
+
+	var errs, warnings []Annotation
+	for _, job := range jobs {
+		checkRunID, err := strconv.ParseInt(job.ID, 10, 64)
+		if err != nil {
+			continue
+		}
+		opts := &github.ListOptions{PerPage: 100}
+		for {
+			page, resp, err := p.api.Checks.ListCheckRunAnnotations(ctx, p.config.Owner, p.config.Repo, checkRunID, opts)
+			if err != nil {
+				log.Printf("[annotations] job %s: %v", job.ID, err)
+				return
+			}
+			for _, a := range page {
+				switch a.GetAnnotationLevel() {
+				case "failure":
+					errs = append(errs, convertAnnotation(job, "error", a))
+				case "warning":
+					warnings = append(warnings, convertAnnotation(job, "warning", a))
+				}
+			}
+			if resp == nil || resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+
+	summary := AnnotationSummary{Errors: len(errs), Warnings: len(warnings)}
+	annotations := append(errs, warnings...)
+	if len(annotations) > maxAnnotations {
+		annotations = annotations[:maxAnnotations]
+		summary.Truncated = true
+	}
+	if annotations == nil {
+		annotations = []Annotation{}
+	}
+
+	fields["annotations"] = annotations
+	fields["annotation_summary"] = summary
+}
+
+// convertAnnotation converts a GitHub check run annotation to an Annotation.
+func convertAnnotation(job Job, level string, a *github.CheckRunAnnotation) Annotation {
+	return Annotation{
+		Job:       job.Name,
+		Level:     level,
+		Path:      a.GetPath(),
+		StartLine: a.GetStartLine(),
+		EndLine:   a.GetEndLine(),
+		Title:     a.GetTitle(),
+		Message:   a.GetMessage(),
+	}
+}
@@ -0,0 +1,111 @@
+package deployment
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+// Approver kinds.
+const (
+	ApproverUser = "user"
+	ApproverTeam = "team"
+)
+
+// Approver is a user or team that can approve a pending deployment. Team
+// approvers list their members' logins, resolved through the team provider.
+type Approver struct {
+	Type    string   `json:"type"` // "user" or "team"
+	ID      string   `json:"id"`   // Login for users, slug for teams
+	Name    string   `json:"name,omitempty"`
+	Members []string `json:"members,omitempty"`
+}
+
+// PendingApproval is an environment a waiting run needs approval to deploy to.
+type PendingApproval struct {
+	Environment string     `json:"environment"`
+	WaitTimer   int        `json:"waitTimer,omitempty"` // Minutes to wait after approval
+	Approvers   []Approver `json:"approvers"`
+}
+
+// addApprovers sets fields["pending_approvals"] to the environments a waiting
+// run needs approval for, and fields["approver_logins"] to every login that can
+// approve one of them, so the right person can be paged. The lookup is best
+// effort; a failure is logged and leaves the fields unset, and a team whose
+// members cannot be read is listed without them.
+func (p *Provider) addApprovers(ctx context.Context, runID int64, status string, fields map[string]any) {
+	if p.api.Approvals == nil || status != "waiting" {
+		return
+	}
+
+	pending, _, err := p.api.Approvals.ListPendingDeployments(ctx, p.config.Owner, p.config.Repo, runID)
+	if err != nil {
+		log.Printf("[approvers] run %d: %v", runID, err)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	// Teams often guard several environments, so each is resolved once
+	members := map[string][]string{}
+	logins := map[string]bool{}
+	var approvals []PendingApproval
+	for _, pd := range pending {
+		approval := PendingApproval{Environment: pd.Environment.Name, WaitTimer: pd.WaitTimer}
+		for _, r := range pd.Reviewers {
+			// Bot users cannot be paged; bot team members are handled by the team provider
+			if !strings.EqualFold(r.Type, "Team") && p.config.BotPolicy.Exclude && p.config.BotPolicy.IsBot(r.Reviewer.Login, "") {
+				continue
+			}
+			approver := p.convertApprover(ctx, r, members)
+			if approver.Type == ApproverUser {
+				logins[approver.ID] = true
+			}
+			for _, login := range approver.Members {
+				logins[login] = true
+			}
+			approval.Approvers = append(approval.Approvers, approver)
+		}
+		approvals = append(approvals, approval)
+	}
+
+	fields["pending_approvals"] = approvals
+	all := make([]string, 0, len(logins))
+	for login := range logins {
+		all = append(all, login)
+	}
+	sort.Strings(all)
+	fields["approver_logins"] = all
+}
+
+// convertApprover converts a pending deployment reviewer, looking up team
+// members through the team provider and caching them in members by slug.
+func (p *Provider) convertApprover(ctx context.Context, r *ghapi.PendingDeploymentReviewer, members map[string][]string) Approver {
+	if !strings.EqualFold(r.Type, "Team") {
+		return Approver{Type: ApproverUser, ID: r.Reviewer.Login, Name: r.Reviewer.Name}
+	}
+
+	approver := Approver{Type: ApproverTeam, ID: r.Reviewer.Slug, Name: r.Reviewer.Name}
+	if p.teams == nil {
+		return approver
+	}
+	if cached, ok := members[approver.ID]; ok {
+		approver.Members = cached
+		return approver
+	}
+
+	teamMembers, err := p.teams.Members(ctx, approver.ID)
+	if err != nil {
+		log.Printf("[approvers] team %s: %v", approver.ID, err)
+		return approver
+	}
+	for _, m := range teamMembers {
+		approver.Members = append(approver.Members, m.Handle)
+	}
+	members[approver.ID] = approver.Members
+	return approver
+}
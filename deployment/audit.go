@@ -0,0 +1,22 @@
+package deployment
+
+import (
+	"github.com/opsorch/opsorch-github-adapter/audit"
+)
+
+// audit records a write to the provider's repository in the audit log. target
+// is the workflow, artifact, or cache written, and url the GitHub URL of the
+// result when there is one.
+func (p *Provider) audit(operation, target string, input any, url string, err error) {
+	entry := audit.Entry{
+		Operation:  operation,
+		Repository: p.config.Owner + "/" + p.config.Repo,
+		Target:     target,
+		Input:      input,
+		URL:        url,
+	}
+	if err != nil {
+		entry.Error = p.wrapError(err).Error()
+	}
+	p.config.Audit.Record(entry)
+}
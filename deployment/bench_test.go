@@ -0,0 +1,80 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+	"github.com/opsorch/opsorch-github-adapter/internal/fieldset"
+)
+
+// reportCalls reports the API calls srv received per benchmark iteration.
+func reportCalls(b *testing.B, srv *fakegithub.Server) {
+	b.ReportMetric(float64(len(srv.Requests()))/float64(b.N), "calls/op")
+}
+
+func BenchmarkConvertWorkflowRun(b *testing.B) {
+	p, _ := newFakeProvider(b)
+	var run github.WorkflowRun
+	if err := json.Unmarshal(fakegithub.Fixture("workflow_run.json"), &run); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.convertWorkflowRunToDeployment(&run)
+	}
+}
+
+func BenchmarkConvertWorkflowRunLean(b *testing.B) {
+	p, _ := newFakeProvider(b)
+	var run github.WorkflowRun
+	if err := json.Unmarshal(fakegithub.Fixture("workflow_run.json"), &run); err != nil {
+		b.Fatal(err)
+	}
+	want := fieldset.Parse(map[string]any{"fields": []string{"branch"}})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.convertRun(&run, want)
+	}
+}
+
+func BenchmarkQuery(b *testing.B) {
+	p, srv := newFakeProvider(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Query(context.Background(), schema.DeploymentQuery{Limit: 20}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportCalls(b, srv)
+}
+
+func BenchmarkQueryFiltered(b *testing.B) {
+	p, srv := newFakeProvider(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Query(context.Background(), schema.DeploymentQuery{Statuses: []string{"failed"}, Limit: 5}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportCalls(b, srv)
+}
+
+func BenchmarkGet(b *testing.B) {
+	p, srv := newFakeProvider(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Get(context.Background(), "1001"); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportCalls(b, srv)
+}
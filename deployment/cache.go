@@ -0,0 +1,159 @@
+package deployment
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/cache"
+	"github.com/opsorch/opsorch-github-adapter/internal/lastgood"
+	"github.com/opsorch/opsorch-github-adapter/internal/timefmt"
+)
+
+// defaultTerminalRunTTL is how long Get answers a finished run from memory
+// when "terminalRunTTL" is unset.
+const defaultTerminalRunTTL = time.Minute
+
+// cacheScopes numbers providers, so each caches the deployments it shapes apart
+// from providers configured differently.
+var cacheScopes atomic.Uint64
+
+// recordKey identifies a deployment across providers. Watch callers listen on it
+// and Invalidate drops every provider's copy under it.
+func (p *Provider) recordKey(id string) string {
+	return "deployment/" + p.config.Owner + "/" + p.config.Repo + "/" + id
+}
+
+// cacheKey identifies this provider's copy of a deployment in the shared response cache.
+func (p *Provider) cacheKey(id string) string {
+	return p.recordKey(id) + "/" + strconv.FormatUint(p.cacheScope, 10)
+}
+
+// cachedDeployment returns the cached deployment for id when caching is enabled.
+func (p *Provider) cachedDeployment(id string) (schema.Deployment, bool) {
+	if p.config.CacheTTL <= 0 {
+		return schema.Deployment{}, false
+	}
+	if v, ok := cache.Default.Get(p.cacheKey(id)); ok {
+		return cloneDeployment(v.(schema.Deployment)), true
+	}
+	return schema.Deployment{}, false
+}
+
+// Prime stores a copy of a deployment in the response cache so later Get calls
+// are served without an API request. It is a no-op unless cacheTTL is
+// configured. Only Get primes, once it has added jobs, commit details, and the
+// other enrichment; Publish keeps those copies up to date. A run that is active
+// again, after a re-run, leaves the terminal-run memo.
+func (p *Provider) Prime(deployment schema.Deployment) {
+	if deployment.ID == "" {
+		return
+	}
+	cache.Default.Set(p.cacheKey(deployment.ID), cloneDeployment(deployment), p.config.CacheTTL)
+	if !isTerminalStatus(deployment.Status) {
+		cache.Default.DeletePrefix(p.terminalPrefix(deployment.ID))
+	}
+}
+
+// Publish merges a changed deployment into every provider's cached copy, drops
+// it from the terminal-run memo, and hands it to Watch callers. Webhook
+// deliveries and Watch polls publish instead of priming, as their deployments
+// lack what Get adds. A copy the change cannot be merged into, as
+// mergeDeployment decides, is dropped and fetched again by the next Get.
+func (p *Provider) Publish(deployment schema.Deployment) {
+	if deployment.ID == "" {
+		return
+	}
+	cache.Default.UpdatePrefix(p.recordKey(deployment.ID)+"/", func(v any) (any, bool) {
+		return mergeDeployment(v.(schema.Deployment), deployment)
+	})
+	cache.Default.DeletePrefix(p.terminalPrefix(deployment.ID))
+	cache.Default.Notify(p.recordKey(deployment.ID), cloneDeployment(deployment))
+}
+
+// mergeDeployment brings a cached deployment up to date with a changed one: its
+// status, log URL, and finish time. ok is false when the merge is
+// ambiguous: the change is older than the copy, is of another commit, or starts
+// a finished run again, whose jobs and approvals Get looks up afresh.
+func mergeDeployment(cached, changed schema.Deployment) (schema.Deployment, bool) {
+	if changed.Version != cached.Version ||
+		(!changed.FinishedAt.IsZero() && changed.FinishedAt.Before(cached.FinishedAt)) ||
+		(isTerminalStatus(cached.Status) && !isTerminalStatus(changed.Status)) {
+		return cached, false
+	}
+
+	merged := cloneDeployment(cached)
+	if merged.Fields == nil {
+		merged.Fields = map[string]any{}
+	}
+	merged.Status = changed.Status
+	merged.FinishedAt = changed.FinishedAt
+	if changed.URL != "" {
+		merged.URL = changed.URL
+	}
+	for _, f := range []string{"state", "environment_url", "finished_at" + timefmt.MillisSuffix} {
+		if v, ok := changed.Fields[f]; ok {
+			merged.Fields[f] = v
+		}
+	}
+	return merged, true
+}
+
+// Invalidate drops every provider's copy of a deployment from the response
+// cache and the terminal-run memo.
+func (p *Provider) Invalidate(id string) {
+	cache.Default.DeletePrefix(p.recordKey(id) + "/")
+	cache.Default.DeletePrefix(p.terminalPrefix(id))
+}
+
+// terminalPrefix identifies every provider's memo of a finished workflow run.
+func (p *Provider) terminalPrefix(id string) string {
+	return "deployment-terminal/" + p.config.Owner + "/" + p.config.Repo + "/" + id + "/"
+}
+
+// terminalKey identifies this provider's memo of a finished workflow run.
+func (p *Provider) terminalKey(id string) string {
+	return p.terminalPrefix(id) + strconv.FormatUint(p.cacheScope, 10)
+}
+
+// terminalRun returns the memoized deployment of a finished run, which Get
+// answers without calling the API.
+func (p *Provider) terminalRun(id string) (schema.Deployment, bool) {
+	if p.config.TerminalRunTTL <= 0 {
+		return schema.Deployment{}, false
+	}
+	if v, ok := cache.Default.Get(p.terminalKey(id)); ok {
+		return cloneDeployment(v.(schema.Deployment)), true
+	}
+	return schema.Deployment{}, false
+}
+
+// rememberTerminal memoizes a deployment for terminalRunTTL once its run has
+// finished. Finished runs only change when re-run, so the memo is short-lived.
+func (p *Provider) rememberTerminal(deployment schema.Deployment) {
+	if p.config.TerminalRunTTL <= 0 || !isTerminalStatus(deployment.Status) {
+		return
+	}
+	cache.Default.Set(p.terminalKey(deployment.ID), cloneDeployment(deployment), p.config.TerminalRunTTL)
+}
+
+// cloneDeployment copies the Fields and Metadata of a deployment, which the
+// cache and its callers must not share.
+func cloneDeployment(deployment schema.Deployment) schema.Deployment {
+	deployment.Fields = cache.Clone(deployment.Fields)
+	deployment.Metadata = cache.Clone(deployment.Metadata)
+	return deployment
+}
+
+// markStale flags a last-known-good deployment served during an outage.
+func markStale(deployment *schema.Deployment, savedAt time.Time) {
+	deployment.Metadata = lastgood.Mark(deployment.Metadata, savedAt)
+}
+
+// markStalePage flags every deployment of a last-known-good page.
+func markStalePage(page *Page, savedAt time.Time) {
+	for i := range page.Deployments {
+		markStale(&page.Deployments[i], savedAt)
+	}
+}
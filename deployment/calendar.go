@@ -0,0 +1,193 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/miniyaml"
+)
+
+// FreezeWindow is a scheduled change freeze from the freeze calendar.
+type FreezeWindow struct {
+	Name         string    `json:"name"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	Environments []string  `json:"environments,omitempty"` // Environments the window covers; empty means those of the changeFreeze config
+	Reason       string    `json:"reason,omitempty"`
+	Active       bool      `json:"active"` // The window has started and not yet ended
+}
+
+// activeAt reports whether t falls within the window.
+func (w FreezeWindow) activeAt(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// covers reports whether the window applies to environment. Windows without
+// environments cover the freeze's, and when neither lists any, every dispatch.
+func (w FreezeWindow) covers(environment string, defaults []string) bool {
+	environments := w.Environments
+	if len(environments) == 0 {
+		environments = defaults
+	}
+	if len(environments) == 0 {
+		return true
+	}
+	for _, e := range environments {
+		if strings.EqualFold(e, environment) {
+			return true
+		}
+	}
+	return false
+}
+
+// Freezes returns the calendar's freeze windows that have not ended, active
+// ones first and the rest by start time, so schedulers can plan around them.
+// With environment set, only windows covering it are returned. Without a
+// calendar configured there are none.
+func (p *Provider) Freezes(ctx context.Context, environment string) ([]FreezeWindow, error) {
+	freeze := p.config.ChangeFreeze
+	if freeze.Calendar == "" {
+		return []FreezeWindow{}, nil
+	}
+	windows, err := p.calendarWindows(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := []FreezeWindow{}
+	for _, w := range windows {
+		if !now.Before(w.End) || (environment != "" && !w.covers(environment, freeze.Environments)) {
+			continue
+		}
+		w.Active = w.activeAt(now)
+		result = append(result, w)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Active != result[j].Active {
+			return result[i].Active
+		}
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result, nil
+}
+
+// calendarWindows reads the freeze calendar from the default branch of its
+// repository. A missing calendar has no windows.
+func (p *Provider) calendarWindows(ctx context.Context) ([]FreezeWindow, error) {
+	freeze := p.config.ChangeFreeze
+	if p.api.Repositories == nil {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "change freeze calendars are not available for this provider",
+		}
+	}
+	owner, repo := p.config.Owner, p.config.Repo
+	if freeze.CalendarRepository != "" {
+		owner, repo, _ = strings.Cut(freeze.CalendarRepository, "/")
+	}
+
+	file, _, _, err := p.api.Repositories.GetContents(ctx, owner, repo, freeze.Calendar, nil)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+	content, err := file.GetContent()
+	if err == nil {
+		var windows []FreezeWindow
+		if windows, err = parseCalendar([]byte(content)); err == nil {
+			return windows, nil
+		}
+	}
+	return nil, &orcherr.OpsOrchError{
+		Code:    "provider_error",
+		Message: fmt.Sprintf("reading freeze calendar %s/%s/%s: %v", owner, repo, freeze.Calendar, err),
+	}
+}
+
+// parseCalendar decodes a freeze calendar: a list of windows, either at the top
+// level or under "freezes", each with a name, start, end, and optional
+// environments and reason. Times are RFC 3339 or dates; a date start begins at
+// midnight UTC and a date end covers that whole day.
+func parseCalendar(data []byte) ([]FreezeWindow, error) {
+	doc, err := miniyaml.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	var entries []any
+	switch v := doc.(type) {
+	case nil:
+	case []any:
+		entries = v
+	case map[string]any:
+		var ok bool
+		if entries, ok = v["freezes"].([]any); !ok && v["freezes"] != nil {
+			return nil, fmt.Errorf("freezes: expected a list")
+		}
+	default:
+		return nil, fmt.Errorf("expected a list of freezes")
+	}
+
+	windows := make([]FreezeWindow, 0, len(entries))
+	for i, entry := range entries {
+		m, ok := entry.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("freeze %d: expected a mapping", i+1)
+		}
+		w := FreezeWindow{}
+		w.Name, _ = m["name"].(string)
+		w.Reason, _ = m["reason"].(string)
+		if w.Name == "" {
+			w.Name = fmt.Sprintf("freeze %d", i+1)
+		}
+		start, _ := m["start"].(string)
+		end, _ := m["end"].(string)
+		if w.Start, err = calendarTime(start, false); err != nil {
+			return nil, fmt.Errorf("%s: start: %w", w.Name, err)
+		}
+		if w.End, err = calendarTime(end, true); err != nil {
+			return nil, fmt.Errorf("%s: end: %w", w.Name, err)
+		}
+		if !w.End.After(w.Start) {
+			return nil, fmt.Errorf("%s: end must be after start", w.Name)
+		}
+		switch envs := m["environments"].(type) {
+		case string:
+			w.Environments = []string{envs}
+		case []any:
+			for _, e := range envs {
+				if s, ok := e.(string); ok && s != "" {
+					w.Environments = append(w.Environments, s)
+				}
+			}
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// calendarTime parses an RFC 3339 time or a date. A date that ends a window
+// means the end of that day.
+func calendarTime(value string, end bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("required")
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (expected RFC 3339 or YYYY-MM-DD)", value)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
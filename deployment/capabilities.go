@@ -0,0 +1,25 @@
+package deployment
+
+import (
+	"github.com/opsorch/opsorch-github-adapter/capability"
+	"github.com/opsorch/opsorch-github-adapter/internal/paging"
+)
+
+// Capabilities describes what the provider supports as configured.
+func (p *Provider) Capabilities() capability.Capabilities {
+	return capability.Capabilities{
+		Provider:        "deployment",
+		SupportsTrigger: true,
+		SupportsWatch:   true,
+		SupportsPaging:  true,
+		DryRun:          p.config.ReadOnly,
+		MaxLimit:        paging.MaxResults,
+		SupportedFilters: []string{
+			"statuses", "scope.service", "scope.environment",
+			"metadata.branch", "metadata.ref", "metadata.tag", "metadata.actor", "metadata.event",
+			"metadata.org", "metadata.topic", "metadata.perRepo",
+			"metadata.savedQuery", "metadata.fields", "metadata.pageToken",
+			"metadata.owner", "metadata.repo", "metadata.repository",
+		},
+	}
+}
@@ -0,0 +1,164 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+// CleanupInput selects the Actions storage Cleanup deletes. When neither
+// Artifacts nor Caches is set, both are cleaned.
+type CleanupInput struct {
+	Artifacts bool          // Delete workflow run artifacts created before OlderThan ago
+	Caches    bool          // Delete Actions caches last used before OlderThan ago
+	OlderThan time.Duration // Required age threshold
+	DryRun    bool          // Report what would be deleted without deleting it; always set under readOnly
+}
+
+// CleanupItem is an artifact or cache that was, or in a dry run would be, deleted.
+type CleanupItem struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"` // Artifact name or cache key
+	SizeBytes int64     `json:"sizeBytes"`
+	At        time.Time `json:"at"` // When the artifact was created or the cache last used
+}
+
+// CleanupResult lists what Cleanup deleted. Errors holds deletions that failed;
+// the others still went ahead.
+type CleanupResult struct {
+	DryRun     bool          `json:"dryRun"`
+	Artifacts  []CleanupItem `json:"artifacts"`
+	Caches     []CleanupItem `json:"caches"`
+	FreedBytes int64         `json:"freedBytes"`
+	Errors     []string      `json:"errors,omitempty"`
+}
+
+// Cleanup deletes old artifacts and Actions caches from the repository to keep
+// storage under quota. Expired artifacts no longer count against storage and are
+// skipped.
+func (p *Provider) Cleanup(ctx context.Context, input CleanupInput) (CleanupResult, error) {
+	if input.OlderThan <= 0 {
+		return CleanupResult{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "olderThan is required",
+		}
+	}
+	if !input.Artifacts && !input.Caches {
+		input.Artifacts, input.Caches = true, true
+	}
+	input.DryRun = input.DryRun || p.isDryRun(nil)
+
+	cutoff := time.Now().Add(-input.OlderThan)
+	result := CleanupResult{DryRun: input.DryRun, Artifacts: []CleanupItem{}, Caches: []CleanupItem{}}
+
+	if input.Artifacts {
+		artifacts, err := p.oldArtifacts(ctx, cutoff)
+		if err != nil {
+			return result, err
+		}
+		for _, item := range artifacts {
+			if !input.DryRun {
+				id, _ := strconv.ParseInt(item.ID, 10, 64)
+				_, err := p.api.Actions.DeleteArtifact(ctx, p.config.Owner, p.config.Repo, id)
+				p.audit("deployment.deleteArtifact", item.ID, item, "", err)
+				if err != nil && !isNotFound(err) {
+					result.Errors = append(result.Errors, fmt.Sprintf("artifact %s: %v", item.ID, p.wrapError(err)))
+					continue
+				}
+				log.Printf("[cleanup] deleted artifact %s (%s, %d bytes)", item.ID, item.Name, item.SizeBytes)
+			}
+			result.Artifacts = append(result.Artifacts, item)
+			result.FreedBytes += item.SizeBytes
+		}
+	}
+
+	if input.Caches {
+		caches, err := p.oldCaches(ctx, cutoff)
+		if err != nil {
+			return result, err
+		}
+		for _, item := range caches {
+			if !input.DryRun {
+				id, _ := strconv.ParseInt(item.ID, 10, 64)
+				_, err := p.api.Actions.DeleteCachesByID(ctx, p.config.Owner, p.config.Repo, id)
+				p.audit("deployment.deleteCache", item.ID, item, "", err)
+				if err != nil && !isNotFound(err) {
+					result.Errors = append(result.Errors, fmt.Sprintf("cache %s: %v", item.ID, p.wrapError(err)))
+					continue
+				}
+				log.Printf("[cleanup] deleted cache %s (%s, %d bytes)", item.ID, item.Name, item.SizeBytes)
+			}
+			result.Caches = append(result.Caches, item)
+			result.FreedBytes += item.SizeBytes
+		}
+	}
+
+	return result, nil
+}
+
+// oldArtifacts lists the unexpired artifacts created before cutoff.
+func (p *Provider) oldArtifacts(ctx context.Context, cutoff time.Time) ([]CleanupItem, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	var items []CleanupItem
+	for {
+		page, resp, err := p.api.Actions.ListArtifacts(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		for _, a := range page.Artifacts {
+			if a.GetExpired() || !a.GetCreatedAt().Before(cutoff) {
+				continue
+			}
+			items = append(items, CleanupItem{
+				ID:        strconv.FormatInt(a.GetID(), 10),
+				Name:      a.GetName(),
+				SizeBytes: a.GetSizeInBytes(),
+				At:        a.GetCreatedAt().Time,
+			})
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return items, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// oldCaches lists the caches last used before cutoff.
+func (p *Provider) oldCaches(ctx context.Context, cutoff time.Time) ([]CleanupItem, error) {
+	opts := &github.ActionsCacheListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	var items []CleanupItem
+	for {
+		page, resp, err := p.api.Actions.ListCaches(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		for _, c := range page.ActionsCaches {
+			if !c.GetLastAccessedAt().Before(cutoff) {
+				continue
+			}
+			items = append(items, CleanupItem{
+				ID:        strconv.FormatInt(c.GetID(), 10),
+				Name:      c.GetKey(),
+				SizeBytes: c.GetSizeInBytes(),
+				At:        c.GetLastAccessedAt().Time,
+			})
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return items, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// isNotFound reports whether err is a GitHub 404, such as for an artifact or
+// cache that is already gone.
+func isNotFound(err error) bool {
+	ghErr, ok := err.(*github.ErrorResponse)
+	return ok && ghErr.Response.StatusCode == http.StatusNotFound
+}
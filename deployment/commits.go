@@ -0,0 +1,152 @@
+package deployment
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/timefmt"
+)
+
+// CommitPerson is the author or committer of a deployment's head commit, so
+// notifications can reach whoever wrote the change rather than whoever started
+// the run.
+type CommitPerson struct {
+	Name      string    `json:"name"`
+	Login     string    `json:"login,omitempty"` // GitHub login, when the commit email maps to an account
+	Timestamp time.Time `json:"timestamp,omitempty"`
+}
+
+// CommitVerification is GitHub's check of the head commit's signature, so
+// policies can flag production deploys of unverified commits. Reason is GitHub's
+// verdict, such as "valid", "unsigned", "unknown_key", or "bad_email".
+type CommitVerification struct {
+	Verified bool   `json:"verified"`
+	Reason   string `json:"reason"`
+}
+
+// commitPerson converts a head commit author or committer. Workflow run payloads
+// carry no login; webhook payloads do.
+func commitPerson(person *github.CommitAuthor, commitTime *github.Timestamp) (CommitPerson, bool) {
+	if person == nil {
+		return CommitPerson{}, false
+	}
+	cp := CommitPerson{
+		Name:      person.GetName(),
+		Login:     person.GetLogin(),
+		Timestamp: timefmt.UTC(person.GetDate().Time),
+	}
+	if cp.Timestamp.IsZero() && commitTime != nil {
+		cp.Timestamp = timefmt.UTC(commitTime.Time)
+	}
+	return cp, true
+}
+
+// addCommitDetails reads the head commit to add its signature verification as
+// Fields["commit_verification"] and to fill in the login of its author and
+// committer from the commit's linked GitHub accounts. The lookup is best effort;
+// a failure is logged and leaves both unset.
+func (p *Provider) addCommitDetails(ctx context.Context, sha string, fields map[string]any) {
+	if p.api.Repositories == nil || sha == "" {
+		return
+	}
+	author, hasAuthor := fields["commit_author"].(CommitPerson)
+	committer, hasCommitter := fields["commit_committer"].(CommitPerson)
+
+	commit, _, err := p.api.Repositories.GetCommit(ctx, p.config.Owner, p.config.Repo, sha, nil)
+	if err != nil {
+		log.Printf("[commits] %s: %v", sha, err)
+		return
+	}
+	if v := commit.GetCommit().GetVerification(); v != nil {
+		fields["commit_verification"] = CommitVerification{Verified: v.GetVerified(), Reason: v.GetReason()}
+	}
+	if hasAuthor && author.Login == "" {
+		author.Login = commit.GetAuthor().GetLogin()
+		fields["commit_author"] = author
+	}
+	if hasCommitter && committer.Login == "" {
+		committer.Login = commit.GetCommitter().GetLogin()
+		fields["commit_committer"] = committer
+	}
+}
+
+// DeploymentOf returns the first successful deployment of commit sha in
+// owner/repo, the one that finished earliest, and whether there is one. With
+// environment set, only deployments to it count. The configured repository is
+// read from the Deployments API when environmentSource allows, as environment
+// queries are; otherwise, and for other repositories, from workflow runs.
+func (p *Provider) DeploymentOf(ctx context.Context, owner, repo, sha, environment string) (schema.Deployment, bool, error) {
+	if owner == p.config.Owner && repo == p.config.Repo && p.useDeploymentsAPI() && p.api.Repositories != nil {
+		d, ok, found, err := p.deploymentRecordOf(ctx, sha, environment)
+		if err != nil || found || p.config.EnvironmentSource == EnvironmentSourceDeployments {
+			return d, ok, err
+		}
+	}
+
+	if !budget.Call(ctx) {
+		return schema.Deployment{}, false, budget.ErrExhausted
+	}
+	runs, _, err := p.api.Actions.ListRepositoryWorkflowRuns(ctx, owner, repo, &github.ListWorkflowRunsOptions{
+		HeadSHA:     sha,
+		Status:      "success",
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return schema.Deployment{}, false, p.wrapError(err)
+	}
+	var candidates []schema.Deployment
+	for _, run := range runs.WorkflowRuns {
+		candidates = append(candidates, p.convertRunIn(run, owner, repo, nil))
+	}
+	d, ok := firstSuccess(candidates, environment)
+	return d, ok, nil
+}
+
+// deploymentRecordOf returns the first successful Deployments API record of
+// commit sha. found is false when the commit has no records at all.
+func (p *Provider) deploymentRecordOf(ctx context.Context, sha, environment string) (d schema.Deployment, ok, found bool, err error) {
+	if !budget.Call(ctx) {
+		return schema.Deployment{}, false, false, budget.ErrExhausted
+	}
+	records, _, err := p.api.Repositories.ListDeployments(ctx, p.config.Owner, p.config.Repo, &github.DeploymentsListOptions{
+		SHA:         sha,
+		Environment: environment,
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return schema.Deployment{}, false, false, p.wrapError(err)
+	}
+	var candidates []schema.Deployment
+	for _, record := range records {
+		if !budget.Call(ctx) {
+			return schema.Deployment{}, false, true, budget.ErrExhausted
+		}
+		status, err := p.latestDeploymentStatus(ctx, record.GetID())
+		if err != nil {
+			return schema.Deployment{}, false, true, err
+		}
+		candidates = append(candidates, p.convertDeploymentToSchema(record, status))
+	}
+	d, ok = firstSuccess(candidates, environment)
+	return d, ok, len(records) > 0, nil
+}
+
+// firstSuccess returns the successful deployment to environment, or to any
+// environment when it is empty, that finished earliest.
+func firstSuccess(deployments []schema.Deployment, environment string) (schema.Deployment, bool) {
+	var first schema.Deployment
+	ok := false
+	for _, d := range deployments {
+		if d.Status != "success" || d.FinishedAt.IsZero() || (environment != "" && d.Environment != environment) {
+			continue
+		}
+		if !ok || d.FinishedAt.Before(first.FinishedAt) {
+			first, ok = d, true
+		}
+	}
+	return first, ok
+}
@@ -0,0 +1,290 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-github-adapter/internal/miniyaml"
+)
+
+// expressionPattern matches a ${{ ... }} expression in a workflow value.
+var expressionPattern = regexp.MustCompile(`\$\{\{\s*(.*?)\s*\}\}`)
+
+// concurrency is a workflow's top-level concurrency setting.
+type concurrency struct {
+	Group            string // Group name, possibly with ${{ }} expressions
+	CancelInProgress bool
+}
+
+// addConcurrency sets fields["concurrency_group"] and fields["cancel_in_progress"]
+// for an active run whose workflow declares top-level concurrency. When the run is
+// waiting to start and an earlier in-progress run of any workflow in the
+// repository shares its group, it also sets fields["queued_behind"] and
+// fields["queued_behind_url"] to that run, or fields["cancels"] when
+// cancel-in-progress means the earlier run is cancelled instead. Reading the workflow is best effort; a failure is logged and leaves the
+// fields unset.
+func (p *Provider) addConcurrency(ctx context.Context, run *github.WorkflowRun, fields map[string]any) {
+	if p.api.Repositories == nil || run.GetStatus() == "completed" || run.GetWorkflowID() == 0 {
+		return
+	}
+
+	c, err := p.workflowConcurrency(ctx, run)
+	if err != nil {
+		log.Printf("[concurrency] run %d: %v", run.GetID(), err)
+		return
+	}
+	if c == nil {
+		return
+	}
+
+	refs := p.newRunRefs(ctx)
+	group, resolved := c.evaluate(p.config.Owner+"/"+p.config.Repo, run, refs.of(run))
+	fields["concurrency_group"] = group
+	fields["cancel_in_progress"] = c.CancelInProgress
+	// A group with expressions that cannot be evaluated here cannot be compared
+	if !resolved || !isWaiting(run.GetStatus()) {
+		return
+	}
+
+	blocker, err := p.concurrencyBlocker(ctx, run, group, refs)
+	if err != nil {
+		log.Printf("[concurrency] run %d: %v", run.GetID(), err)
+		return
+	}
+	if blocker == nil {
+		return
+	}
+	blockerID := strconv.FormatInt(blocker.GetID(), 10)
+	if c.CancelInProgress {
+		fields["cancels"] = blockerID
+		return
+	}
+	fields["queued_behind"] = blockerID
+	fields["queued_behind_url"] = blocker.GetHTMLURL()
+}
+
+// workflowConcurrency reads the concurrency setting from the workflow file at the
+// run's commit. It returns nil when the workflow declares none.
+func (p *Provider) workflowConcurrency(ctx context.Context, run *github.WorkflowRun) (*concurrency, error) {
+	workflow, _, err := p.api.Actions.GetWorkflowByID(ctx, p.config.Owner, p.config.Repo, run.GetWorkflowID())
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+	path := workflow.GetPath()
+
+	opts := &github.RepositoryContentGetOptions{Ref: run.GetHeadSHA()}
+	file, _, _, err := p.api.Repositories.GetContents(ctx, p.config.Owner, p.config.Repo, path, opts)
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	doc, err := miniyaml.Parse([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	definition, _ := doc.(map[string]any)
+
+	// concurrency is either the group name or a mapping with group and
+	// cancel-in-progress
+	switch v := definition["concurrency"].(type) {
+	case string:
+		return &concurrency{Group: v}, nil
+	case map[string]any:
+		c := &concurrency{}
+		c.Group, _ = v["group"].(string)
+		switch cancel := v["cancel-in-progress"].(type) {
+		case bool:
+			c.CancelInProgress = cancel
+		case string:
+			c.CancelInProgress = strings.EqualFold(cancel, "true")
+		}
+		if c.Group == "" {
+			return nil, nil
+		}
+		return c, nil
+	}
+	return nil, nil
+}
+
+// concurrencyBlocker returns the earlier in-progress run, of any workflow in the
+// repository, whose workflow puts it in the same concurrency group, or nil if
+// there is none. Groups are shared across workflows, so each run's group is
+// evaluated with its own workflow's setting; runs whose group cannot be read or
+// evaluated are skipped.
+func (p *Provider) concurrencyBlocker(ctx context.Context, run *github.WorkflowRun, group string, refs *runRefs) (*github.WorkflowRun, error) {
+	settings := map[string]*concurrency{}
+	opts := &github.ListWorkflowRunsOptions{Status: "in_progress", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		runs, resp, err := p.api.Actions.ListRepositoryWorkflowRuns(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		for _, other := range runs.WorkflowRuns {
+			if other.GetID() == run.GetID() || other.GetStatus() != "in_progress" || other.GetWorkflowID() == 0 {
+				continue
+			}
+			if other.GetCreatedAt().After(run.GetCreatedAt().Time) {
+				continue
+			}
+
+			// Runs of one workflow at one commit share its setting
+			key := strconv.FormatInt(other.GetWorkflowID(), 10) + "@" + other.GetHeadSHA()
+			c, ok := settings[key]
+			if !ok {
+				if c, err = p.workflowConcurrency(ctx, other); err != nil {
+					log.Printf("[concurrency] run %d: %v", other.GetID(), err)
+				}
+				settings[key] = c
+			}
+			if c == nil {
+				continue
+			}
+			if otherGroup, resolved := c.evaluate(p.config.Owner+"/"+p.config.Repo, other, refs.of(other)); resolved && otherGroup == group {
+				return other, nil
+			}
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// runRefs derives the full ref of workflow runs, which the runs API does not
+// give: head_branch holds a branch or a tag name alike. Names looked up are
+// remembered for the runs of one addConcurrency call.
+type runRefs struct {
+	p    *Provider
+	ctx  context.Context
+	tags map[string]bool
+}
+
+func (p *Provider) newRunRefs(ctx context.Context) *runRefs {
+	return &runRefs{p: p, ctx: ctx, tags: map[string]bool{}}
+}
+
+// of returns a lazy github.ref of run, so the lookup is only made for groups
+// that use it.
+func (r *runRefs) of(run *github.WorkflowRun) func() (string, bool) {
+	return func() (string, bool) { return r.ref(run) }
+}
+
+// ref returns the ref run ran on: the merge ref of its pull request for pull
+// request events, refs/tags/ for releases and for other events whose
+// head_branch names a tag, and refs/heads/ otherwise. It is not resolved when
+// the pull request or the tag lookup is unavailable.
+func (r *runRefs) ref(run *github.WorkflowRun) (string, bool) {
+	name := run.GetHeadBranch()
+	switch run.GetEvent() {
+	case "pull_request":
+		if len(run.PullRequests) == 0 {
+			return "", false
+		}
+		return "refs/pull/" + strconv.Itoa(run.PullRequests[0].GetNumber()) + "/merge", true
+	case "release":
+		return "refs/tags/" + name, true
+	case "schedule":
+		// Scheduled runs use the default branch
+		return "refs/heads/" + name, true
+	}
+
+	tag, ok := r.tags[name]
+	if !ok && r.p.api.Git != nil {
+		_, _, err := r.p.api.Git.GetRef(r.ctx, r.p.config.Owner, r.p.config.Repo, "tags/"+name)
+		switch {
+		case err == nil:
+			tag = true
+		case isNotFound(err):
+			tag = false
+		default:
+			log.Printf("[concurrency] tag %s: %v", name, err)
+			return "", false
+		}
+		r.tags[name] = tag
+	}
+	if tag {
+		return "refs/tags/" + name, true
+	}
+	return "refs/heads/" + name, true
+}
+
+// evaluate substitutes the github context values known for run into the group's
+// expressions. resolved is false when an expression refers to anything else, in
+// which case that expression is left as written.
+func (c *concurrency) evaluate(repository string, run *github.WorkflowRun, ref func() (string, bool)) (group string, resolved bool) {
+	resolved = true
+	group = expressionPattern.ReplaceAllStringFunc(c.Group, func(expr string) string {
+		inner := expressionPattern.FindStringSubmatch(expr)[1]
+		// a || b evaluates to the first non-empty operand
+		for _, operand := range strings.Split(inner, "||") {
+			value, ok := contextValue(repository, run, ref, strings.TrimSpace(operand))
+			if !ok {
+				resolved = false
+				return expr
+			}
+			if value != "" {
+				return value
+			}
+		}
+		return ""
+	})
+	return group, resolved
+}
+
+// contextValue returns the value of a string literal or github context property
+// as it was for run. ref returns the run's github.ref.
+func contextValue(repository string, run *github.WorkflowRun, ref func() (string, bool), operand string) (string, bool) {
+	if len(operand) >= 2 && operand[0] == '\'' && operand[len(operand)-1] == '\'' {
+		return strings.ReplaceAll(operand[1:len(operand)-1], "''", "'"), true
+	}
+
+	pullRequest := run.GetEvent() == "pull_request"
+	switch operand {
+	case "github.workflow":
+		return run.GetName(), true
+	case "github.repository":
+		return repository, true
+	case "github.event_name":
+		return run.GetEvent(), true
+	case "github.sha":
+		return run.GetHeadSHA(), true
+	case "github.run_id":
+		return strconv.FormatInt(run.GetID(), 10), true
+	case "github.actor":
+		return run.GetActor().GetLogin(), true
+	case "github.head_ref":
+		if pullRequest {
+			return run.GetHeadBranch(), true
+		}
+		return "", true
+	case "github.ref":
+		return ref()
+	case "github.ref_name":
+		if !pullRequest {
+			return run.GetHeadBranch(), true
+		}
+		// Pull request runs use the merge ref of the pull request
+		if len(run.PullRequests) == 0 {
+			return "", false
+		}
+		return strconv.Itoa(run.PullRequests[0].GetNumber()) + "/merge", true
+	}
+	return "", false
+}
+
+// isWaiting reports whether a run status means it has not started yet.
+func isWaiting(status string) bool {
+	switch status {
+	case "queued", "pending", "waiting", "requested":
+		return true
+	}
+	return false
+}
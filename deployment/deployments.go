@@ -0,0 +1,96 @@
+package deployment
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/timefmt"
+)
+
+// deploymentIDPrefix distinguishes GitHub Deployments API records from workflow runs,
+// whose numeric IDs live in a separate ID space.
+const deploymentIDPrefix = "deployment-"
+
+// ConvertWorkflowRun converts a GitHub workflow run using this provider's configuration.
+func (p *Provider) ConvertWorkflowRun(run *github.WorkflowRun) schema.Deployment {
+	return p.convertWorkflowRunToDeployment(run)
+}
+
+// ConvertDeployment converts a GitHub deployment and its latest status (which may be nil)
+// using this provider's configuration.
+func (p *Provider) ConvertDeployment(d *github.Deployment, status *github.DeploymentStatus) schema.Deployment {
+	return p.convertDeploymentToSchema(d, status)
+}
+
+// convertDeploymentToSchema converts a GitHub Deployments API record to a normalized Deployment.
+func (p *Provider) convertDeploymentToSchema(d *github.Deployment, status *github.DeploymentStatus) schema.Deployment {
+	deployment := schema.Deployment{
+		ID:          deploymentIDPrefix + strconv.FormatInt(d.GetID(), 10),
+		Service:     p.config.Repo,
+		Environment: d.GetEnvironment(),
+		Status:      "queued",
+		Fields: map[string]any{
+			"deployment_id": d.GetID(),
+			"ref":           d.GetRef(),
+			"commit":        d.GetSHA(),
+			"task":          d.GetTask(),
+			"description":   d.GetDescription(),
+		},
+	}
+
+	if nodeID := d.GetNodeID(); nodeID != "" {
+		deployment.Fields["node_id"] = nodeID
+	}
+	if sha := d.GetSHA(); len(sha) >= 7 {
+		deployment.Version = sha[:7]
+	}
+	if createdAt := d.GetCreatedAt(); !createdAt.IsZero() {
+		deployment.StartedAt = timefmt.UTC(createdAt.Time)
+	}
+	if creator := d.GetCreator(); creator != nil {
+		deployment.Actor = p.convertActor(creator)
+	}
+
+	if status != nil {
+		deployment.Status = p.normalizeDeploymentState(status.GetState())
+		deployment.URL = status.GetLogURL()
+		if deployment.URL == "" {
+			deployment.URL = status.GetTargetURL()
+		}
+		if environmentURL := status.GetEnvironmentURL(); environmentURL != "" {
+			deployment.Fields["environment_url"] = environmentURL
+		}
+		if updatedAt := status.GetUpdatedAt(); !updatedAt.IsZero() && deployment.Status != "queued" && deployment.Status != "running" {
+			deployment.FinishedAt = timefmt.UTC(updatedAt.Time)
+		}
+		deployment.Fields["state"] = status.GetState()
+	}
+
+	if p.config.EpochMillis {
+		timefmt.SetMillis(deployment.Fields, "started_at", deployment.StartedAt)
+		timefmt.SetMillis(deployment.Fields, "finished_at", deployment.FinishedAt)
+	}
+	p.config.InstanceLabels.Stamp(deployment.Fields)
+	return deployment
+}
+
+// normalizeDeploymentState converts a GitHub deployment status state to normalized status.
+func (p *Provider) normalizeDeploymentState(state string) string {
+	switch strings.ToLower(state) {
+	case "pending", "queued", "waiting":
+		return "queued"
+	case "in_progress":
+		return "running"
+	case "success":
+		return "success"
+	case "inactive":
+		// Inactive deployments succeeded and were later superseded.
+		return "success"
+	case "failure", "error":
+		return "failed"
+	default:
+		return state
+	}
+}
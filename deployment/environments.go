@@ -0,0 +1,196 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/fieldset"
+	"github.com/opsorch/opsorch-github-adapter/internal/paging"
+	"github.com/opsorch/opsorch-github-adapter/internal/throttle"
+)
+
+// Values accepted for the "environmentSource" config key.
+const (
+	// EnvironmentSourceAuto reads the Deployments API and falls back to matching
+	// workflow runs when the environment has no deployment records.
+	EnvironmentSourceAuto = "auto"
+	// EnvironmentSourceDeployments reads only the Deployments API.
+	EnvironmentSourceDeployments = "deployments"
+	// EnvironmentSourceRuns matches workflow runs by name and branch.
+	EnvironmentSourceRuns = "runs"
+)
+
+// queryEnvironment lists the deployment history of query.Scope.Environment from
+// the Deployments API, newest first, each with its latest status. found is false
+// when the environment has no deployment records at all.
+func (p *Provider) queryEnvironment(ctx context.Context, query schema.DeploymentQuery, refs *refFilter) (page Page, found bool, err error) {
+	if p.api.Repositories == nil {
+		return Page{}, false, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "environment deployment history is not available for this provider",
+		}
+	}
+
+	opts := &github.DeploymentsListOptions{
+		Environment: query.Scope.Environment,
+		ListOptions: github.ListOptions{
+			PerPage: 100, // GitHub's max per page
+		},
+	}
+
+	// Apply limit; filters matched here fetch full pages and trim afterwards
+	filtered := len(query.Statuses) > 0 || refs.isGlob() || query.Scope.Service != ""
+	if query.Limit > 0 && query.Limit < 100 && !filtered {
+		opts.PerPage = query.Limit
+	}
+
+	// Apply ref filter from the branch metadata used for workflow runs, or an
+	// exact ref or tag
+	if branch, ok := query.Metadata["branch"].(string); ok {
+		opts.Ref = branch
+	} else if name := refs.exact(); name != "" {
+		opts.Ref = name
+	}
+
+	want := fieldset.Parse(query.Metadata)
+	deployments := make([]schema.Deployment, 0, paging.Capacity(query.Limit, opts.PerPage, nil))
+	token := paging.FromMetadata(query.Metadata)
+	list := func() ([]*github.Deployment, *github.Response, error) {
+		if !budget.Call(ctx) {
+			return nil, nil, budget.ErrExhausted
+		}
+		records, resp, err := p.api.Repositories.ListDeployments(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return nil, nil, p.wrapError(err)
+		}
+		found = found || len(records) > 0
+		return records, resp, nil
+	}
+	next, err := paging.Walk(token, query.Limit, &opts.ListOptions, list, func(d *github.Deployment) (bool, error) {
+		deployment, ok, err := p.matchDeployment(ctx, d, query, refs)
+		if ok {
+			// Records have few Fields, so they are converted whole and pruned
+			want.Prune(deployment.Fields)
+			deployments = append(deployments, deployment)
+		}
+		return ok, err
+	})
+	if err != nil {
+		return Page{}, found, err
+	}
+	return Page{
+		Deployments:   deployments,
+		NextPageToken: next,
+		HasMore:       next != "",
+		TotalCount:    paging.Total(token, next, len(deployments)),
+	}, found, nil
+}
+
+// matchDeployment converts a deployment record with its latest status,
+// reporting whether it passes the query's ref, status, and service filters.
+func (p *Provider) matchDeployment(ctx context.Context, d *github.Deployment, query schema.DeploymentQuery, refs *refFilter) (schema.Deployment, bool, error) {
+	// Apply ref or tag filter before reading the status
+	if !refs.matches(d.GetRef()) {
+		return schema.Deployment{}, false, nil
+	}
+
+	// Without its status a record can be neither matched nor shown, so the walk
+	// stops here when the enrichment budget is spent
+	if !budget.Enrich(ctx) {
+		return schema.Deployment{}, false, budget.ErrExhausted
+	}
+	status, err := p.latestDeploymentStatus(throttle.WithPriority(ctx, throttle.Enrichment), d.GetID())
+	if err != nil {
+		return schema.Deployment{}, false, err
+	}
+	deployment := p.convertDeploymentToSchema(d, status)
+
+	// Apply status filter
+	if len(query.Statuses) > 0 {
+		matched := false
+		for _, s := range query.Statuses {
+			if strings.EqualFold(deployment.Status, s) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return schema.Deployment{}, false, nil
+		}
+	}
+
+	// Apply service filter from scope
+	if query.Scope.Service != "" && deployment.Service != query.Scope.Service {
+		return schema.Deployment{}, false, nil
+	}
+
+	return deployment, true, nil
+}
+
+// useDeploymentsAPI reports whether environment queries read the Deployments API.
+func (p *Provider) useDeploymentsAPI() bool {
+	switch p.config.EnvironmentSource {
+	case EnvironmentSourceDeployments:
+		return true
+	case EnvironmentSourceAuto:
+		return p.api.Repositories != nil
+	}
+	return false
+}
+
+// latestDeploymentStatus returns the most recent status of a deployment, or nil if
+// it has none yet. GitHub lists statuses newest first.
+func (p *Provider) latestDeploymentStatus(ctx context.Context, deploymentID int64) (*github.DeploymentStatus, error) {
+	return p.latestDeploymentStatusIn(ctx, p.config.Owner, p.config.Repo, deploymentID)
+}
+
+// latestDeploymentStatusIn is latestDeploymentStatus for a deployment of owner/repo.
+func (p *Provider) latestDeploymentStatusIn(ctx context.Context, owner, repo string, deploymentID int64) (*github.DeploymentStatus, error) {
+	statuses, _, err := p.api.Repositories.ListDeploymentStatuses(ctx, owner, repo, deploymentID, &github.ListOptions{PerPage: 1})
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+	return statuses[0], nil
+}
+
+// fetchDeployment retrieves a Deployments API record of owner/repo by its
+// prefixed ID, bypassing and then refreshing the cache.
+func (p *Provider) fetchDeployment(ctx context.Context, owner, repo, id string) (schema.Deployment, error) {
+	deploymentID, err := strconv.ParseInt(strings.TrimPrefix(id, deploymentIDPrefix), 10, 64)
+	if err != nil {
+		return schema.Deployment{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("invalid deployment ID: %s", id),
+		}
+	}
+	if p.api.Repositories == nil {
+		return schema.Deployment{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "environment deployment history is not available for this provider",
+		}
+	}
+
+	d, _, err := p.api.Repositories.GetDeployment(ctx, owner, repo, deploymentID)
+	if err != nil {
+		return schema.Deployment{}, p.wrapError(err)
+	}
+	status, err := p.latestDeploymentStatusIn(ctx, owner, repo, deploymentID)
+	if err != nil {
+		return schema.Deployment{}, err
+	}
+
+	deployment := p.convertDeploymentIn(d, status, owner, repo)
+	p.addCommitDetails(ctx, d.GetSHA(), deployment.Fields)
+	p.addServiceTags(ctx, owner, repo, []schema.Deployment{deployment})
+	p.Prime(deployment)
+	return deployment, nil
+}
@@ -0,0 +1,255 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+)
+
+// defaultFailureWindow is the window Failures covers when Since is unset.
+const defaultFailureWindow = 7 * 24 * time.Hour
+
+// defaultFailureRuns caps the failed runs Failures analyzes when Limit is unset.
+const defaultFailureRuns = 100
+
+// maxFailureExamples caps the example runs kept per cluster.
+const maxFailureExamples = 5
+
+// maxSignatureLength caps error signatures, which are compared whole.
+const maxSignatureLength = 120
+
+// Patterns of the parts of error messages that differ between occurrences of
+// the same failure, replaced so the occurrences share a signature.
+var (
+	signatureHex    = regexp.MustCompile(`\b[0-9a-f]{7,}\b`)
+	signatureNumber = regexp.MustCompile(`\d+`)
+	signatureQuoted = regexp.MustCompile(`"[^"]*"|'[^']*'`)
+)
+
+// FailureInput selects the failed runs Failures groups.
+type FailureInput struct {
+	Since    time.Time `json:"since,omitempty"`    // Start of the window runs were created in; defaults to 7 days before Until
+	Until    time.Time `json:"until,omitempty"`    // End of the window; defaults to now
+	Workflow string    `json:"workflow,omitempty"` // Only runs of the workflow with this name
+	Branch   string    `json:"branch,omitempty"`   // Only runs on this branch
+	Limit    int       `json:"limit,omitempty"`    // Most failed runs analyzed, newest first; defaults to 100
+}
+
+// FailureCluster is one failure mode: runs of a workflow that failed at the
+// same job and step with the same error signature.
+type FailureCluster struct {
+	Workflow  string    `json:"workflow"`
+	Job       string    `json:"job,omitempty"`       // Empty when the run failed without a failed job
+	Step      string    `json:"step,omitempty"`      // First failed step of the job
+	Signature string    `json:"signature,omitempty"` // First error annotation, normalized
+	Message   string    `json:"message,omitempty"`   // The error annotation as first seen
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Runs      []string  `json:"runs"`     // IDs of the newest runs in the cluster
+	Examples  []string  `json:"examples"` // URLs of the newest failed jobs, or runs
+}
+
+// FailureClusters are the failure modes of the failed runs in a window, most
+// frequent first.
+type FailureClusters struct {
+	Since     time.Time        `json:"since"`
+	Until     time.Time        `json:"until"`
+	Runs      int              `json:"runs"` // Failed runs analyzed
+	Clusters  []FailureCluster `json:"clusters"`
+	Sampled   bool             `json:"sampled,omitempty"`   // More runs failed than were analyzed
+	Truncated bool             `json:"truncated,omitempty"` // The API call budget ran out, leaving the clusters partial
+}
+
+// failure is where one run failed.
+type failure struct {
+	job, step, signature, message, url string
+}
+
+// Failures groups the failed workflow runs in a window by failure mode, so one
+// ticket can be opened per mode instead of per run. A run's failure mode is the
+// workflow, its first failed job and step, and the signature of the job's
+// first error annotation: the message with numbers, hashes, and quoted strings
+// masked. Each run costs a jobs listing and each failed job an annotations
+// listing.
+func (p *Provider) Failures(ctx context.Context, input FailureInput) (FailureClusters, error) {
+	until := input.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	since := input.Since
+	if since.IsZero() {
+		since = until.Add(-defaultFailureWindow)
+	}
+	if !since.Before(until) {
+		return FailureClusters{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "since must be before until",
+		}
+	}
+	since, until = since.UTC(), until.UTC()
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultFailureRuns
+	}
+
+	ctx = budget.Start(ctx, p.config.Budget)
+	result := FailureClusters{Since: since, Until: until, Clusters: []FailureCluster{}}
+
+	runs, sampled, err := p.failedRuns(ctx, since, until, input, limit)
+	if err != nil && !errors.Is(err, budget.ErrExhausted) {
+		return FailureClusters{}, err
+	}
+	result.Sampled = sampled
+
+	clusters := map[string]*FailureCluster{}
+	var order []string
+	for _, run := range runs {
+		f, ok := p.runFailure(ctx, run)
+		if !ok {
+			break
+		}
+		result.Runs++
+
+		key := strings.Join([]string{run.GetName(), f.job, f.step, f.signature}, "\x00")
+		c := clusters[key]
+		if c == nil {
+			c = &FailureCluster{Workflow: run.GetName(), Job: f.job, Step: f.step, Signature: f.signature, Message: f.message, Runs: []string{}, Examples: []string{}}
+			clusters[key] = c
+			order = append(order, key)
+		}
+		// Runs come newest first
+		created := run.GetCreatedAt().Time
+		c.Count++
+		c.FirstSeen = created
+		if c.LastSeen.IsZero() {
+			c.LastSeen = created
+		}
+		if len(c.Runs) < maxFailureExamples {
+			c.Runs = append(c.Runs, strconv.FormatInt(run.GetID(), 10))
+			c.Examples = append(c.Examples, f.url)
+		}
+	}
+
+	for _, key := range order {
+		result.Clusters = append(result.Clusters, *clusters[key])
+	}
+	sort.SliceStable(result.Clusters, func(i, j int) bool {
+		return result.Clusters[i].Count > result.Clusters[j].Count
+	})
+	result.Truncated = budget.Truncated(ctx)
+	return result, nil
+}
+
+// failedRuns returns up to limit failed runs created in the window, newest
+// first, and whether more failed.
+func (p *Provider) failedRuns(ctx context.Context, since, until time.Time, input FailureInput, limit int) ([]*github.WorkflowRun, bool, error) {
+	opts := &github.ListWorkflowRunsOptions{
+		Status:      "failure",
+		Branch:      input.Branch,
+		Created:     since.Format(time.RFC3339) + ".." + until.Format(time.RFC3339),
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var runs []*github.WorkflowRun
+	for {
+		if !budget.Call(ctx) {
+			return runs, false, budget.ErrExhausted
+		}
+		page, resp, err := p.api.Actions.ListRepositoryWorkflowRuns(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return nil, false, p.wrapError(err)
+		}
+		for _, run := range page.WorkflowRuns {
+			if input.Workflow != "" && !strings.EqualFold(run.GetName(), input.Workflow) {
+				continue
+			}
+			if len(runs) == limit {
+				return runs, true, nil
+			}
+			runs = append(runs, run)
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return runs, false, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// runFailure finds where a run failed: its first failed job, that job's first
+// failed step, and its first error annotation. ok is false when the API call
+// budget ran out.
+func (p *Provider) runFailure(ctx context.Context, run *github.WorkflowRun) (failure, bool) {
+	f := failure{url: run.GetHTMLURL()}
+	if !budget.Enrich(ctx) {
+		return failure{}, false
+	}
+	jobs, _, err := p.api.Actions.ListWorkflowJobs(ctx, p.config.Owner, p.config.Repo, run.GetID(), &github.ListWorkflowJobsOptions{
+		Filter:      "latest",
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		// The run still counts, without a job
+		log.Printf("[failures] run %d jobs: %v", run.GetID(), err)
+		return f, true
+	}
+
+	var job *github.WorkflowJob
+	for _, j := range jobs.Jobs {
+		if j.GetConclusion() == "failure" {
+			job = j
+			break
+		}
+	}
+	if job == nil {
+		return f, true
+	}
+	f.job, f.url = job.GetName(), job.GetHTMLURL()
+	for _, step := range job.Steps {
+		if step.GetConclusion() == "failure" {
+			f.step = step.GetName()
+			break
+		}
+	}
+
+	// Jobs are check runs with the same ID
+	if p.api.Checks == nil || !budget.Enrich(ctx) {
+		return f, true
+	}
+	annotations, _, err := p.api.Checks.ListCheckRunAnnotations(ctx, p.config.Owner, p.config.Repo, job.GetID(), &github.ListOptions{PerPage: 100})
+	if err != nil {
+		log.Printf("[failures] job %d annotations: %v", job.GetID(), err)
+		return f, true
+	}
+	for _, a := range annotations {
+		if a.GetAnnotationLevel() == "failure" {
+			f.message = a.GetMessage()
+			f.signature = signature(f.message)
+			break
+		}
+	}
+	return f, true
+}
+
+// signature normalizes an error message so occurrences of the same error
+// compare equal: its first line, with quoted strings, hashes, and numbers
+// masked and whitespace collapsed.
+func signature(message string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	line = signatureQuoted.ReplaceAllString(line, `"…"`)
+	line = signatureHex.ReplaceAllString(strings.ToLower(line), "<hex>")
+	line = signatureNumber.ReplaceAllString(line, "<n>")
+	line = strings.Join(strings.Fields(line), " ")
+	if runes := []rune(line); len(runes) > maxSignatureLength {
+		line = string(runes[:maxSignatureLength])
+	}
+	return line
+}
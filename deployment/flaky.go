@@ -0,0 +1,190 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/paging"
+)
+
+// Defaults of a flakiness report.
+const (
+	defaultFlakyWindow    = 30 * 24 * time.Hour
+	defaultFlakyThreshold = 0.1
+	defaultFlakyMinRuns   = 5
+)
+
+// FlakinessInput selects the runs Flakiness scores and when a workflow counts
+// as flaky.
+type FlakinessInput struct {
+	Since     time.Time `json:"since,omitempty"`     // Start of the window runs were created in; defaults to 30 days before Until
+	Until     time.Time `json:"until,omitempty"`     // End of the window; defaults to now
+	Workflow  string    `json:"workflow,omitempty"`  // Only the workflow with this name
+	Branch    string    `json:"branch,omitempty"`    // Only runs on this branch
+	Threshold float64   `json:"threshold,omitempty"` // Score at which a workflow is flaky, 0 to 1; defaults to 0.1
+	MinRuns   int       `json:"minRuns,omitempty"`   // Runs a workflow needs in the window to be flagged; defaults to 5
+}
+
+// WorkflowFlakiness is how often a workflow's runs only passed when re-run.
+type WorkflowFlakiness struct {
+	Workflow string   `json:"workflow"`
+	Runs     int      `json:"runs"`     // Finished runs in the window
+	Retried  int      `json:"retried"`  // Runs with more than one attempt
+	Flips    int      `json:"flips"`    // Runs that succeeded after their previous attempt failed
+	Score    float64  `json:"score"`    // Flips over Runs, 0 to 1
+	Flaky    bool     `json:"flaky"`    // Score reached the threshold with at least minRuns runs
+	Examples []string `json:"examples"` // URLs of the newest flipped runs
+}
+
+// Flakiness scores the workflows with runs in a window, flaky ones first and
+// then by score.
+type Flakiness struct {
+	Since     time.Time           `json:"since"`
+	Until     time.Time           `json:"until"`
+	Threshold float64             `json:"threshold"`
+	MinRuns   int                 `json:"minRuns"`
+	Workflows []WorkflowFlakiness `json:"workflows"`
+	Sampled   bool                `json:"sampled,omitempty"`   // More runs finished than were read
+	Truncated bool                `json:"truncated,omitempty"` // The API call budget ran out, leaving the scores partial
+}
+
+// Flakiness flags workflows whose runs often fail and then pass when re-run.
+// A flip is a run whose latest attempt succeeded while the attempt before it
+// failed; a workflow's score is its flips over its finished runs. Only re-run
+// successes cost an API call, to read the previous attempt. An attempt that
+// cannot be read is logged and not counted as a flip.
+func (p *Provider) Flakiness(ctx context.Context, input FlakinessInput) (Flakiness, error) {
+	until := input.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	since := input.Since
+	if since.IsZero() {
+		since = until.Add(-defaultFlakyWindow)
+	}
+	if !since.Before(until) {
+		return Flakiness{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "since must be before until",
+		}
+	}
+	if input.Threshold < 0 || input.Threshold > 1 || input.MinRuns < 0 {
+		return Flakiness{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "threshold must be between 0 and 1 and minRuns must not be negative",
+		}
+	}
+	result := Flakiness{
+		Since:     since.UTC(),
+		Until:     until.UTC(),
+		Threshold: input.Threshold,
+		MinRuns:   input.MinRuns,
+		Workflows: []WorkflowFlakiness{},
+	}
+	if result.Threshold == 0 {
+		result.Threshold = defaultFlakyThreshold
+	}
+	if result.MinRuns == 0 {
+		result.MinRuns = defaultFlakyMinRuns
+	}
+
+	ctx = budget.Start(ctx, p.config.Budget)
+	runs, sampled, err := p.finishedRuns(ctx, result.Since, result.Until, input.Branch)
+	if err != nil && !errors.Is(err, budget.ErrExhausted) {
+		return Flakiness{}, err
+	}
+	result.Sampled = sampled
+
+	byWorkflow := map[string]*WorkflowFlakiness{}
+	for _, run := range runs {
+		if input.Workflow != "" && !strings.EqualFold(run.GetName(), input.Workflow) {
+			continue
+		}
+		w := byWorkflow[run.GetName()]
+		if w == nil {
+			w = &WorkflowFlakiness{Workflow: run.GetName(), Examples: []string{}}
+			byWorkflow[run.GetName()] = w
+		}
+		w.Runs++
+		if run.GetRunAttempt() <= 1 {
+			continue
+		}
+		w.Retried++
+		if run.GetConclusion() == "success" && p.previousAttemptFailed(ctx, run) {
+			w.Flips++
+			if len(w.Examples) < maxFailureExamples {
+				w.Examples = append(w.Examples, run.GetHTMLURL())
+			}
+		}
+	}
+
+	for _, w := range byWorkflow {
+		w.Score = float64(w.Flips) / float64(w.Runs)
+		w.Flaky = w.Runs >= result.MinRuns && w.Score >= result.Threshold
+		result.Workflows = append(result.Workflows, *w)
+	}
+	sort.Slice(result.Workflows, func(i, j int) bool {
+		a, b := result.Workflows[i], result.Workflows[j]
+		if a.Flaky != b.Flaky {
+			return a.Flaky
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Workflow < b.Workflow
+	})
+	result.Truncated = budget.Truncated(ctx)
+	return result, nil
+}
+
+// finishedRuns returns the completed runs created in the window, up to
+// paging.MaxPages pages of them, and whether there were more.
+func (p *Provider) finishedRuns(ctx context.Context, since, until time.Time, branch string) ([]*github.WorkflowRun, bool, error) {
+	opts := &github.ListWorkflowRunsOptions{
+		Status:      "completed",
+		Branch:      branch,
+		Created:     since.Format(time.RFC3339) + ".." + until.Format(time.RFC3339),
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var runs []*github.WorkflowRun
+	total := 0
+	for page := 0; page < paging.MaxPages; page++ {
+		if !budget.Call(ctx) {
+			return runs, false, budget.ErrExhausted
+		}
+		list, resp, err := p.api.Actions.ListRepositoryWorkflowRuns(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return nil, false, p.wrapError(err)
+		}
+		total = list.GetTotalCount()
+		runs = append(runs, list.WorkflowRuns...)
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return runs, len(runs) < total, nil
+}
+
+// previousAttemptFailed reports whether the attempt before a run's latest
+// one failed.
+func (p *Provider) previousAttemptFailed(ctx context.Context, run *github.WorkflowRun) bool {
+	if !budget.Enrich(ctx) {
+		return false
+	}
+	attempt, _, err := p.api.Actions.GetWorkflowRunAttempt(ctx, p.config.Owner, p.config.Repo, run.GetID(), run.GetRunAttempt()-1, &github.WorkflowRunAttemptOptions{
+		ExcludePullRequests: github.Bool(true),
+	})
+	if err != nil {
+		log.Printf("[flakiness] run %d attempt %d: %v", run.GetID(), run.GetRunAttempt()-1, err)
+		return false
+	}
+	return attempt.GetConclusion() == "failure"
+}
@@ -0,0 +1,275 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// ChangeFreeze configures the signals that block Trigger during a change freeze.
+// A freeze is active when any configured signal is present.
+type ChangeFreeze struct {
+	Label              string   `json:"label"`                 // Active while an open issue carries this label
+	File               string   `json:"file"`                  // Active while this path exists on the default branch
+	Calendar           string   `json:"calendar"`              // YAML file of scheduled freeze windows, e.g. .opsorch/freezes.yml
+	CalendarRepository string   `json:"calendarRepository"`    // owner/repo holding Calendar; defaults to the provider's repository
+	Environments       []string `json:"environments"`          // Environments (the "environment" workflow input) the freeze covers; empty covers every dispatch
+	Protection         bool     `json:"environmentProtection"` // Active while the target environment's deployment branch policy does not admit the ref
+	AllowOverride      bool     `json:"allowOverride"`         // Permit dispatches with overrideFreeze set; otherwise they are refused
+}
+
+// ChangeFreezeError lists why a change freeze is active. It is wrapped in a
+// change_freeze OpsOrchError.
+type ChangeFreezeError struct {
+	Reasons     []string
+	Overridable bool // Whether retrying with overrideFreeze would be allowed
+}
+
+func (e *ChangeFreezeError) Error() string {
+	return "change freeze: " + strings.Join(e.Reasons, "; ")
+}
+
+// parseChangeFreeze reads the "changeFreeze" config object. A calendarRepository
+// without an owner is taken to be one of owner's.
+func parseChangeFreeze(cfg map[string]any, owner string) (ChangeFreeze, error) {
+	var freeze ChangeFreeze
+	raw, ok := cfg["changeFreeze"]
+	if !ok || raw == nil {
+		return freeze, nil
+	}
+
+	// Round-trip through JSON so decoded config and a typed ChangeFreeze passed
+	// in-process are handled the same way
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return freeze, fmt.Errorf("changeFreeze: %w", err)
+	}
+	if err := json.Unmarshal(data, &freeze); err != nil {
+		return freeze, fmt.Errorf("changeFreeze must be an object with label, file, calendar, calendarRepository, environments, environmentProtection, and allowOverride: %w", err)
+	}
+	if repository := strings.TrimSpace(freeze.CalendarRepository); repository != "" {
+		if !strings.Contains(repository, "/") {
+			repository = owner + "/" + repository
+		}
+		if _, _, err := ghconfig.SplitRepository(repository); err != nil {
+			return freeze, fmt.Errorf("changeFreeze.calendarRepository: %w", err)
+		}
+		freeze.CalendarRepository = repository
+	}
+	return freeze, nil
+}
+
+// CheckChangeFreeze returns a change_freeze error when a configured freeze covers
+// the dispatch described by input, unless the freeze allows overrides and
+// input.OverrideFreeze is set.
+//
+// Calendar windows are checked at input.At when the dispatch is scheduled for
+// later, so a dispatch scheduled into a freeze window is refused up front.
+func (p *Provider) CheckChangeFreeze(ctx context.Context, input TriggerInput) error {
+	freeze := p.config.ChangeFreeze
+	var reasons []string
+	if p.freezeCovers(input) {
+		var err error
+		if reasons, err = p.freezeReasons(ctx); err != nil {
+			return err
+		}
+	}
+	if environment, _ := input.Inputs["environment"].(string); freeze.Protection && environment != "" && p.coversEnvironment(environment) {
+		reason, err := p.protectionReason(ctx, environment, input.Ref)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	if freeze.Calendar != "" {
+		at := time.Now()
+		if input.At.After(at) {
+			at = input.At
+		}
+		windows, err := p.calendarWindows(ctx)
+		if err != nil {
+			return err
+		}
+		environment, _ := input.Inputs["environment"].(string)
+		for _, w := range windows {
+			if w.activeAt(at) && w.covers(environment, freeze.Environments) {
+				reasons = append(reasons, fmt.Sprintf("freeze window %q runs until %s", w.Name, w.End.Format(time.RFC3339)))
+			}
+		}
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	if input.OverrideFreeze && freeze.AllowOverride {
+		log.Printf("[trigger] overriding change freeze for %s on %s: %s", input.Workflow, input.Ref, strings.Join(reasons, "; "))
+		return nil
+	}
+	message := "change freeze is active"
+	if freeze.AllowOverride {
+		message += " (set overrideFreeze to dispatch anyway)"
+	}
+	return &orcherr.OpsOrchError{
+		Code:    "change_freeze",
+		Message: fmt.Sprintf("%s: %s", message, strings.Join(reasons, "; ")),
+		Err:     &ChangeFreezeError{Reasons: reasons, Overridable: freeze.AllowOverride},
+	}
+}
+
+// freezeCovers reports whether the configured label and file signals apply to
+// input's target environment.
+func (p *Provider) freezeCovers(input TriggerInput) bool {
+	freeze := p.config.ChangeFreeze
+	if freeze.Label == "" && freeze.File == "" {
+		return false
+	}
+	environment, _ := input.Inputs["environment"].(string)
+	return p.coversEnvironment(environment)
+}
+
+// coversEnvironment reports whether the freeze's environments include
+// environment, as they all do when none are listed.
+func (p *Provider) coversEnvironment(environment string) bool {
+	freeze := p.config.ChangeFreeze
+	if len(freeze.Environments) == 0 {
+		return true
+	}
+	for _, e := range freeze.Environments {
+		if strings.EqualFold(e, environment) {
+			return true
+		}
+	}
+	return false
+}
+
+// freezeReasons checks each configured freeze signal and describes those present.
+func (p *Provider) freezeReasons(ctx context.Context) ([]string, error) {
+	freeze := p.config.ChangeFreeze
+	var reasons []string
+
+	if freeze.Label != "" {
+		if p.api.Issues == nil {
+			return nil, &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: "change freeze labels are not available for this provider",
+			}
+		}
+		opts := &github.IssueListByRepoOptions{
+			State:       "open",
+			Labels:      []string{freeze.Label},
+			ListOptions: github.ListOptions{PerPage: 1},
+		}
+		issues, _, err := p.api.Issues.ListByRepo(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		if len(issues) > 0 {
+			reasons = append(reasons, fmt.Sprintf("#%d is labeled %q", issues[0].GetNumber(), freeze.Label))
+		}
+	}
+
+	if freeze.File != "" {
+		if p.api.Repositories == nil {
+			return nil, &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: "change freeze files are not available for this provider",
+			}
+		}
+		_, _, _, err := p.api.Repositories.GetContents(ctx, p.config.Owner, p.config.Repo, freeze.File, nil)
+		if isNotFound(err) {
+			err = nil
+		} else if err == nil {
+			reasons = append(reasons, fmt.Sprintf("%s exists", freeze.File))
+		}
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+	}
+
+	return reasons, nil
+}
+
+// protectionReason describes why the deployment branch policy of environment
+// does not admit ref, or returns "" when it does. Teams freeze an environment
+// by narrowing its policy, for example to protected branches or to a branch
+// that does not exist, so GitHub would hold the run's deployment job anyway.
+// An environment without a policy, or one that does not exist, admits every ref.
+func (p *Provider) protectionReason(ctx context.Context, environment, ref string) (string, error) {
+	if p.api.Repositories == nil {
+		return "", &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "environment protection is not available for this provider",
+		}
+	}
+	env, _, err := p.api.Repositories.GetEnvironment(ctx, p.config.Owner, p.config.Repo, environment)
+	if isNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", p.wrapError(err)
+	}
+	policy := env.GetDeploymentBranchPolicy()
+	name, kind := refName(ref), refKind(ref)
+
+	switch {
+	case policy.GetProtectedBranches():
+		if kind == "tag" {
+			return fmt.Sprintf("environment %q only admits protected branches, not tag %s", environment, name), nil
+		}
+		branch, _, err := p.api.Repositories.GetBranch(ctx, p.config.Owner, p.config.Repo, name, 1)
+		if isNotFound(err) {
+			return fmt.Sprintf("environment %q only admits protected branches, and %s is not a branch", environment, name), nil
+		}
+		if err != nil {
+			return "", p.wrapError(err)
+		}
+		if !branch.GetProtected() {
+			return fmt.Sprintf("environment %q only admits protected branches, and %s is not protected", environment, name), nil
+		}
+
+	case policy.GetCustomBranchPolicies():
+		policies, _, err := p.api.Repositories.ListDeploymentBranchPolicies(ctx, p.config.Owner, p.config.Repo, environment)
+		if err != nil {
+			return "", p.wrapError(err)
+		}
+		for _, bp := range policies.BranchPolicies {
+			policyKind := bp.GetType()
+			if policyKind == "" {
+				policyKind = "branch"
+			}
+			if kind != "" && kind != policyKind {
+				continue
+			}
+			if ok, _ := path.Match(bp.GetName(), name); ok {
+				return "", nil
+			}
+		}
+		if len(policies.BranchPolicies) == 0 {
+			return fmt.Sprintf("environment %q admits no branches or tags", environment), nil
+		}
+		return fmt.Sprintf("environment %q does not admit %s", environment, name), nil
+	}
+	return "", nil
+}
+
+// refKind returns the kind of ref refName strips: "branch", "tag", or "" for
+// a bare name that can be either.
+func refKind(ref string) string {
+	switch {
+	case strings.HasPrefix(ref, "refs/heads/"):
+		return "branch"
+	case strings.HasPrefix(ref, "refs/tags/"):
+		return "tag"
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package deployment
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+// Kinds of InventoryEntry.
+const (
+	KindSecret   = "secret"
+	KindVariable = "variable"
+)
+
+// InventoryEntry names an Actions secret or variable and when it last changed.
+// Values are never returned; variable values GitHub sends are dropped.
+type InventoryEntry struct {
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind"`                  // "secret" or "variable"
+	Environment string    `json:"environment,omitempty"` // Empty for repository-level entries
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Inventory lists the repository's Actions secrets and variables by name and,
+// when environment is set, that environment's too, most recently updated first,
+// so a change made shortly before an incident stands out.
+func (p *Provider) Inventory(ctx context.Context, environment string) ([]InventoryEntry, error) {
+	entries, err := p.repoInventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if environment != "" {
+		// The environment endpoints address the repository by ID
+		if p.api.Repositories == nil {
+			return nil, &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: "environment secrets and variables are not available for this provider",
+			}
+		}
+		repo, _, err := p.api.Repositories.Get(ctx, p.config.Owner, p.config.Repo)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		envEntries, err := p.envInventory(ctx, int(repo.GetID()), environment)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, envEntries...)
+	}
+
+	slices.SortStableFunc(entries, func(a, b InventoryEntry) int {
+		return b.UpdatedAt.Compare(a.UpdatedAt)
+	})
+	return entries, nil
+}
+
+// repoInventory lists the repository-level secrets and variables.
+func (p *Provider) repoInventory(ctx context.Context) ([]InventoryEntry, error) {
+	return p.inventory("",
+		func(opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+			return p.api.Actions.ListRepoSecrets(ctx, p.config.Owner, p.config.Repo, opts)
+		},
+		func(opts *github.ListOptions) (*github.ActionsVariables, *github.Response, error) {
+			return p.api.Actions.ListRepoVariables(ctx, p.config.Owner, p.config.Repo, opts)
+		})
+}
+
+// envInventory lists the secrets and variables of an environment.
+func (p *Provider) envInventory(ctx context.Context, repoID int, environment string) ([]InventoryEntry, error) {
+	return p.inventory(environment,
+		func(opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+			return p.api.Actions.ListEnvSecrets(ctx, repoID, environment, opts)
+		},
+		func(opts *github.ListOptions) (*github.ActionsVariables, *github.Response, error) {
+			return p.api.Actions.ListEnvVariables(ctx, repoID, environment, opts)
+		})
+}
+
+// inventory pages through a secrets and a variables listing.
+func (p *Provider) inventory(
+	environment string,
+	listSecrets func(*github.ListOptions) (*github.Secrets, *github.Response, error),
+	listVariables func(*github.ListOptions) (*github.ActionsVariables, *github.Response, error),
+) ([]InventoryEntry, error) {
+	entries := []InventoryEntry{}
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := listSecrets(opts)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		for _, s := range page.Secrets {
+			entries = append(entries, InventoryEntry{
+				Name:        s.Name,
+				Kind:        KindSecret,
+				Environment: environment,
+				CreatedAt:   s.CreatedAt.Time,
+				UpdatedAt:   s.UpdatedAt.Time,
+			})
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	opts = &github.ListOptions{PerPage: 30} // GitHub's max per page for variables
+	for {
+		page, resp, err := listVariables(opts)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		for _, v := range page.Variables {
+			entries = append(entries, InventoryEntry{
+				Name:        v.Name,
+				Kind:        KindVariable,
+				Environment: environment,
+				CreatedAt:   v.GetCreatedAt().Time,
+				UpdatedAt:   v.GetUpdatedAt().Time,
+			})
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,87 @@
+package deployment
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-github-adapter/internal/timefmt"
+)
+
+// Job is a job of a workflow run and the runner that executed it, so a failed
+// deploy can be traced to a specific self-hosted runner or pool.
+type Job struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	URL         string    `json:"url,omitempty"`
+	RunnerName  string    `json:"runnerName,omitempty"`
+	RunnerGroup string    `json:"runnerGroup,omitempty"`
+	Labels      []string  `json:"labels,omitempty"`
+	SelfHosted  bool      `json:"selfHosted"`
+	StartedAt   time.Time `json:"startedAt,omitempty"`
+	CompletedAt time.Time `json:"completedAt,omitempty"`
+}
+
+// addJobs sets fields["jobs"] to the jobs of the run's latest attempt and, when
+// any failed, fields["failed_runners"] to the runners they ran on, and returns
+// the jobs. Listing jobs is best effort; a failure is logged and leaves the
+// fields unset.
+func (p *Provider) addJobs(ctx context.Context, runID int64, fields map[string]any) []Job {
+	opts := &github.ListWorkflowJobsOptions{
+		Filter:      "latest",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	jobs := []Job{}
+	var failedRunners []string
+	for {
+		page, resp, err := p.api.Actions.ListWorkflowJobs(ctx, p.config.Owner, p.config.Repo, runID, opts)
+		if err != nil {
+			log.Printf("[jobs] run %d: %v", runID, err)
+			return nil
+		}
+		for _, j := range page.Jobs {
+			job := p.convertJob(j)
+			jobs = append(jobs, job)
+			if job.Status == "failed" && job.RunnerName != "" && !slices.Contains(failedRunners, job.RunnerName) {
+				failedRunners = append(failedRunners, job.RunnerName)
+			}
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	fields["jobs"] = jobs
+	if len(failedRunners) > 0 {
+		fields["failed_runners"] = failedRunners
+	}
+	return jobs
+}
+
+// convertJob converts a GitHub workflow job to a Job.
+func (p *Provider) convertJob(j *github.WorkflowJob) Job {
+	job := Job{
+		ID:          strconv.FormatInt(j.GetID(), 10),
+		Name:        j.GetName(),
+		Status:      p.normalizeStatus(j.GetStatus(), j.GetConclusion()),
+		URL:         j.GetHTMLURL(),
+		RunnerName:  j.GetRunnerName(),
+		RunnerGroup: j.GetRunnerGroupName(),
+		Labels:      j.Labels,
+		StartedAt:   timefmt.UTC(j.GetStartedAt().Time),
+		CompletedAt: timefmt.UTC(j.GetCompletedAt().Time),
+	}
+	for _, label := range j.Labels {
+		if strings.EqualFold(label, "self-hosted") {
+			job.SelfHosted = true
+		}
+	}
+	return job
+}
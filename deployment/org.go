@@ -0,0 +1,224 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/fieldset"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/override"
+)
+
+// Values accepted for the "queryScope" config key.
+const (
+	QueryScopeRepo = "repo"
+	QueryScopeOrg  = "org"
+)
+
+const (
+	// orgConcurrency bounds the repositories queried at once by org-scope queries.
+	orgConcurrency = 8
+	// defaultRunsPerRepo is how many of each repository's latest runs an
+	// org-scope query reads when metadata "perRepo" is unset.
+	defaultRunsPerRepo = 5
+)
+
+// queryOrganization returns the organization a query aggregates, or "" for a
+// query of the configured repository. Metadata "org" selects an organization for
+// one query, which must be the configured one or match the
+// organizationAllowlist; otherwise queryScope "org" reads the configured
+// organization.
+func (p *Provider) queryOrganization(query schema.DeploymentQuery) (string, error) {
+	if org := ghconfig.String(query.Metadata, "org"); org != "" {
+		return org, p.config.OrganizationAllowlist.Check(org, p.config.Organization)
+	}
+	if p.config.QueryScope == QueryScopeOrg {
+		return p.config.Organization, nil
+	}
+	return "", nil
+}
+
+// reach returns forbidden unless owner/repo is the configured repository, one
+// the repositoryAllowlist permits, or one of an organization queries may read.
+func (p *Provider) reach(owner, repo string) error {
+	if strings.EqualFold(owner, p.config.Owner) && strings.EqualFold(repo, p.config.Repo) {
+		return nil
+	}
+	return override.Reach(p.config.RepositoryAllowlist, p.config.OrganizationAllowlist, p.config.Organization, owner, repo)
+}
+
+// queryOrganizationRuns reads the latest matching runs of every repository in
+// org, optionally only those with a topic, and merges them newest first. A
+// repository whose runs cannot be read is logged and skipped, so one failure
+// does not hide the rest of the feed.
+func (p *Provider) queryOrganizationRuns(ctx context.Context, org string, query schema.DeploymentQuery, refs *refFilter) ([]schema.Deployment, error) {
+	want := fieldset.Parse(query.Metadata)
+	topic := ghconfig.String(query.Metadata, "topic")
+	if topic == "" {
+		topic = p.config.Topic
+	}
+	repos, err := p.organizationRepositories(ctx, org, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each repository contributes its latest runs; the overall limit applies
+	// after merging
+	perRepo := ghconfig.Int(query.Metadata, "perRepo", defaultRunsPerRepo)
+	repoQuery := query
+	repoQuery.Limit = perRepo
+
+	results := make([][]schema.Deployment, len(repos))
+	sem := make(chan struct{}, orgConcurrency)
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(i int, repo *github.Repository) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			page, err := p.queryRuns(ctx, org, repo.GetName(), repoQuery, refs)
+			if err != nil {
+				log.Printf("[org] %s: %v", repo.GetFullName(), err)
+				return
+			}
+			results[i] = page.Deployments
+		}(i, repo)
+	}
+	wg.Wait()
+
+	n := 0
+	for _, r := range results {
+		n += len(r)
+	}
+	deployments := make([]schema.Deployment, 0, n)
+	for i, r := range results {
+		for _, d := range r {
+			if want.Has("repository") {
+				d.Fields["repository"] = org + "/" + repos[i].GetName()
+			}
+			deployments = append(deployments, d)
+		}
+	}
+	// Limit after sorting so the merged result keeps the wanted end
+	order, _ := parseOrdering(query.Metadata)
+	order.sortDeployments(deployments)
+	if query.Limit > 0 && len(deployments) > query.Limit {
+		deployments = deployments[:query.Limit]
+	}
+	return deployments, nil
+}
+
+// organizationRepositories lists the unarchived repositories of org, keeping
+// only those tagged with topic when it is set.
+func (p *Provider) organizationRepositories(ctx context.Context, org, topic string) ([]*github.Repository, error) {
+	if p.api.Repositories == nil {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "organization deployment queries are not available for this provider",
+		}
+	}
+
+	opts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	var repos []*github.Repository
+	for {
+		// A spent budget keeps the repositories listed so far
+		if !budget.Call(ctx) {
+			return repos, nil
+		}
+		page, resp, err := p.api.Repositories.ListByOrg(ctx, org, opts)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		for _, repo := range page {
+			if repo.GetArchived() || (topic != "" && !slices.Contains(repo.Topics, strings.ToLower(topic))) {
+				continue
+			}
+			repos = append(repos, repo)
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return repos, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// convertRunIn converts a workflow run of owner/repo. Runs outside the configured
+// repository get owner/repo#ID IDs, which Get accepts, the repository name as
+// their service, and the repository in Fields["repository"]. want selects
+// Fields entries as for convertRun.
+func (p *Provider) convertRunIn(run *github.WorkflowRun, owner, repo string, want fieldset.Set) schema.Deployment {
+	deployment := p.convertRun(run, want)
+	if owner != p.config.Owner || repo != p.config.Repo {
+		deployment.ID = fmt.Sprintf("%s/%s#%d", owner, repo, run.GetID())
+		deployment.Service = repo
+		if want.Has("repository") {
+			deployment.Fields["repository"] = owner + "/" + repo
+		}
+	}
+	return deployment
+}
+
+// convertDeploymentIn converts a Deployments API record of owner/repo. Records
+// outside the configured repository get owner/repo#deployment-ID IDs, which Get
+// accepts, and the repository as for convertRunIn.
+func (p *Provider) convertDeploymentIn(d *github.Deployment, status *github.DeploymentStatus, owner, repo string) schema.Deployment {
+	deployment := p.convertDeploymentToSchema(d, status)
+	if owner != p.config.Owner || repo != p.config.Repo {
+		deployment.ID = fmt.Sprintf("%s/%s#%s", owner, repo, deployment.ID)
+		deployment.Service = repo
+		deployment.Fields["repository"] = owner + "/" + repo
+	}
+	return deployment
+}
+
+// fetchIn retrieves a workflow run of another repository, bypassing the cache.
+func (p *Provider) fetchIn(ctx context.Context, owner, repo string, runID int64) (schema.Deployment, error) {
+	run, _, err := p.api.Actions.GetWorkflowRunByID(ctx, owner, repo, runID)
+	if err != nil {
+		return schema.Deployment{}, p.wrapError(err)
+	}
+
+	return p.convertRunIn(run, owner, repo, nil), nil
+}
+
+// splitDeploymentID parses an owner/repo#deployment-ID Deployments API record ID,
+// returning the prefixed ID within the repository.
+func splitDeploymentID(id string) (owner, repo, deploymentID string, ok bool) {
+	fullName, deploymentID, found := strings.Cut(id, "#")
+	if !found || !strings.HasPrefix(deploymentID, deploymentIDPrefix) {
+		return "", "", "", false
+	}
+	owner, repo, found = strings.Cut(fullName, "/")
+	if !found || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", "", false
+	}
+	return owner, repo, deploymentID, true
+}
+
+// splitRunID parses an owner/repo#ID run ID.
+func splitRunID(id string) (owner, repo string, runID int64, ok bool) {
+	fullName, n, found := strings.Cut(id, "#")
+	if !found {
+		return "", "", 0, false
+	}
+	owner, repo, found = strings.Cut(fullName, "/")
+	if !found || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", 0, false
+	}
+	runID, err := strconv.ParseInt(n, 10, 64)
+	if err != nil || runID <= 0 {
+		return "", "", 0, false
+	}
+	return owner, repo, runID, true
+}
@@ -0,0 +1,114 @@
+package deployment
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+	"github.com/opsorch/opsorch-github-adapter/internal/override"
+)
+
+func TestQueryOrganization(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	p.config.QueryScope = QueryScopeOrg
+	p.config.Organization = "acme"
+	p.config.Topic = "deploy"
+	srv.Handle(http.MethodGet, "/orgs/acme/repos", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"name": "api", "topics": []string{"deploy"}},
+			{"name": "web", "topics": []string{"deploy", "frontend"}},
+			{"name": "broken", "topics": []string{"deploy"}},
+			{"name": "lib", "topics": []string{"library"}},
+			{"name": "legacy", "topics": []string{"deploy"}, "archived": true},
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/web/actions/runs", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 1, "workflow_runs": []map[string]any{
+			{"id": 77, "name": "Deploy", "status": "completed", "conclusion": "success", "created_at": "2030-01-05T09:30:00Z"},
+		}})
+	})
+	srv.Error(http.MethodGet, "/repos/acme/broken/actions/runs", http.StatusInternalServerError, "boom")
+	srv.Handle(http.MethodGet, "/repos/acme/web/actions/runs/77", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"id": 77, "name": "Deploy", "status": "completed", "conclusion": "success"})
+	})
+
+	deployments, err := p.Query(context.Background(), schema.DeploymentQuery{Limit: 3})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	var ids []string
+	for _, d := range deployments {
+		ids = append(ids, d.ID)
+	}
+	if len(ids) != 3 || ids[0] != "1001" || ids[1] != "acme/web#77" || ids[2] != "1002" {
+		t.Fatalf("ids = %v, want newest first across repositories", ids)
+	}
+	if deployments[1].Service != "web" || deployments[1].Fields["repository"] != "acme/web" || deployments[0].Fields["repository"] != "acme/api" {
+		t.Errorf("web deployment = %+v", deployments[1])
+	}
+
+	for _, r := range srv.Requests() {
+		switch r.Path {
+		case "/repos/acme/lib/actions/runs", "/repos/acme/legacy/actions/runs":
+			t.Errorf("queried %s", r.Path)
+		case "/repos/acme/web/actions/runs":
+			if r.Query.Get("per_page") != "5" {
+				t.Errorf("per_page = %q, want the per-repository default", r.Query.Get("per_page"))
+			}
+		}
+	}
+
+	got, err := p.Get(context.Background(), "acme/web#77")
+	if err != nil || got.ID != "acme/web#77" || got.Service != "web" {
+		t.Errorf("Get() = %+v, %v", got, err)
+	}
+	if got, err := p.Get(context.Background(), "acme/api#1001"); err != nil || got.ID != "1001" {
+		t.Errorf("Get() of the configured repository = %+v, %v", got, err)
+	}
+	if _, err := p.Get(context.Background(), "globex/app#77"); !hasCode(err, "forbidden") {
+		t.Errorf("Get() outside the allowlists error = %v, want forbidden", err)
+	}
+}
+
+func TestQueryOrganizationFromMetadata(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/orgs/globex/repos", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"name": "app"}})
+	})
+	srv.Handle(http.MethodGet, "/repos/globex/app/actions/runs", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 0, "workflow_runs": []any{}})
+	})
+
+	// Organizations other than the configured one must be allowlisted
+	if _, err := p.Query(context.Background(), schema.DeploymentQuery{Metadata: map[string]any{"org": "globex"}}); !hasCode(err, "forbidden") {
+		t.Fatalf("Query() outside the organizationAllowlist error = %v, want forbidden", err)
+	}
+	p.config.OrganizationAllowlist = override.Organizations{"globex"}
+
+	deployments, err := p.Query(context.Background(), schema.DeploymentQuery{Metadata: map[string]any{"org": "globex", "perRepo": 2}})
+	if err != nil || len(deployments) != 0 {
+		t.Fatalf("Query() = %v, %v", deployments, err)
+	}
+	reqs := srv.Requests()
+	if last := reqs[len(reqs)-1]; last.Path != "/repos/globex/app/actions/runs" || last.Query.Get("per_page") != "2" {
+		t.Errorf("last request = %s %v", last.Path, last.Query)
+	}
+
+	noRepos, err := NewWithServices(map[string]any{"repository": "acme/api", "queryScope": "org"}, ghapi.Services{Actions: p.api.Actions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if noRepos.config.Organization != "acme" {
+		t.Errorf("Organization = %q, want owner", noRepos.config.Organization)
+	}
+	if _, err := noRepos.Query(context.Background(), schema.DeploymentQuery{}); !hasCode(err, "bad_request") {
+		t.Errorf("Query() without a repositories service error = %v, want bad_request", err)
+	}
+
+	if _, err := NewWithServices(map[string]any{"repository": "acme/api", "queryScope": "enterprise"}, p.api); err == nil {
+		t.Error("expected error for unknown queryScope")
+	}
+}
@@ -3,72 +3,367 @@ package deployment
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v57/github"
 	"github.com/opsorch/opsorch-core/deployment"
 	"github.com/opsorch/opsorch-core/orcherr"
 	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/audit"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/botpolicy"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/fieldset"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/gherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/identity"
+	"github.com/opsorch/opsorch-github-adapter/internal/instance"
+	"github.com/opsorch/opsorch-github-adapter/internal/lastgood"
+	"github.com/opsorch/opsorch-github-adapter/internal/nodeid"
+	"github.com/opsorch/opsorch-github-adapter/internal/override"
+	"github.com/opsorch/opsorch-github-adapter/internal/paging"
+	"github.com/opsorch/opsorch-github-adapter/internal/throttle"
+	"github.com/opsorch/opsorch-github-adapter/internal/timefmt"
+	"github.com/opsorch/opsorch-github-adapter/team"
 )
 
 // Provider implements the deployment.Provider interface for GitHub Actions.
 type Provider struct {
-	client *github.Client
-	config Config
+	api        ghapi.Services
+	config     Config
+	teams      *team.Provider // Resolves team approvers; nil without the team services
+	schedules  schedules      // Dispatches Trigger scheduled for later
+	cacheScope uint64         // Separates this provider's response cache entries, see cacheKey
 }
 
 // Config holds the configuration for the GitHub deployment provider.
 type Config struct {
-	Token string `json:"token"` // GitHub personal access token
-	Owner string `json:"owner"` // Repository owner (user or organization)
-	Repo  string `json:"repo"`  // Repository name
+	Token                 string                            `json:"token"`                 // GitHub personal access token
+	Owner                 string                            `json:"owner"`                 // Repository owner (user or organization)
+	Repo                  string                            `json:"repo"`                  // Repository name
+	RawAPIAllowlist       []string                          `json:"rawAPIAllowlist"`       // Path patterns permitted for raw GET passthrough
+	RepositoryAllowlist   override.Allowlist                `json:"repositoryAllowlist"`   // owner/repo patterns queries may target through metadata
+	OrganizationAllowlist override.Organizations            `json:"organizationAllowlist"` // Organization patterns org-scope queries may name in metadata "org"
+	CacheTTL              time.Duration                     `json:"cacheTTL"`              // How long Get results stay in the response cache (0 disables)
+	TerminalRunTTL        time.Duration                     `json:"terminalRunTTL"`        // How long Get answers finished runs from memory (0 disables)
+	StatusTTL             time.Duration                     `json:"statusTTL"`             // How long Status boards stay in memory (0 disables)
+	EnvironmentSource     string                            `json:"environmentSource"`     // "auto" (default), "deployments", or "runs": where environment-scoped queries read history
+	Queries               map[string]schema.DeploymentQuery `json:"queries"`               // Named query presets selected with metadata "savedQuery"
+	ChangeFreeze          ChangeFreeze                      `json:"changeFreeze"`          // Signals that block Trigger during a change freeze
+	ScheduleDir           string                            `json:"scheduleDir"`           // Directory scheduled dispatches are persisted in, so they survive a restart
+	ReadOnly              bool                              `json:"readOnly"`              // Simulate dispatches and cleanups instead of calling GitHub
+	QueryScope            string                            `json:"queryScope"`            // "repo" (default) or "org" to aggregate runs across Organization
+	Organization          string                            `json:"organization"`          // Organization read by org-scope queries (defaults to Owner)
+	Topic                 string                            `json:"topic"`                 // Only aggregate org repositories with this topic
+	ServiceTags           bool                              `json:"serviceTags"`           // Add the repository's topics and custom properties to Fields["service_tags"]
+	BotPolicy             botpolicy.Policy                  `json:"botPolicy"`             // Which actors and approvers are bots, and whether bot approvers are left out
+	Identities            *identity.Map                     `json:"-"`                     // Canonical identities by login, from "identities" and "identitiesURL"
+	InstanceLabels        instance.Labels                   `json:"instanceLabels"`        // Labels of this adapter instance added to every deployment's Fields["instance_labels"]
+	EpochMillis           bool                              `json:"epochMillis"`           // Also add StartedAt and FinishedAt to Fields in epoch milliseconds, as "started_at_ms" and "finished_at_ms"
+	Budget                budget.Limits                     `json:"-"`                     // Per-query API call caps, from "maxAPICallsPerQuery" and "maxEnrichmentCalls"
+	LastGood              *lastgood.Store                   `json:"-"`                     // Last-known-good results served during outages, from "staleCacheDir"
+	Audit                 *audit.Log                        `json:"-"`                     // Record of writes to GitHub, from "auditLog" and "auditFunc"
 }
 
 // New creates a new GitHub deployment provider.
 func New(cfg map[string]any) (deployment.Provider, error) {
-	var config Config
+	// Build the API services: GitHub authenticated with the token (falling back to
+	// GITHUB_TOKEN), or local fixtures when mode is "fixtures"
+	api, err := ghconfig.Services(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	// Parse token
-	if token, ok := cfg["token"].(string); ok {
-		config.Token = token
-	} else {
-		return nil, fmt.Errorf("token is required")
+	p, err := NewWithServices(cfg, api)
+	if err != nil {
+		return nil, err
 	}
+	return p, nil
+}
 
-	// Parse owner
-	if owner, ok := cfg["owner"].(string); ok {
-		config.Owner = owner
-	} else {
-		return nil, fmt.Errorf("owner is required")
+// NewWithServices creates a GitHub deployment provider backed by the given API
+// implementations instead of a token-authenticated client. Only the Actions
+// service is required; raw passthrough is unavailable without a Requester, and
+// team approvers are listed without members unless the Teams, Organizations and
+// Users services are given.
+func NewWithServices(cfg map[string]any, api ghapi.Services) (*Provider, error) {
+	if api.Actions == nil {
+		return nil, fmt.Errorf("actions service is required")
 	}
 
-	// Parse repo
-	if repo, ok := cfg["repo"].(string); ok {
-		config.Repo = repo
-	} else {
+	var config Config
+	config.Token = ghconfig.Token(cfg)
+
+	// Parse owner/repo, accepting the "repository" shorthand and GITHUB_REPOSITORY
+	owner, repo, err := ghconfig.Repository(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if owner == "" {
+		return nil, fmt.Errorf("owner is required")
+	}
+	if repo == "" {
 		return nil, fmt.Errorf("repo is required")
 	}
+	config.Owner = owner
+	config.Repo = repo
 
-	// Create GitHub client
-	client := github.NewTokenClient(context.Background(), config.Token)
+	// Parse raw API allowlist (optional)
+	config.RawAPIAllowlist = ghconfig.StringSlice(cfg, "rawAPIAllowlist")
 
-	return &Provider{
-		client: client,
-		config: config,
-	}, nil
+	// Parse repository override allowlist (optional)
+	if config.RepositoryAllowlist, err = override.Parse(cfg); err != nil {
+		return nil, err
+	}
+	if config.OrganizationAllowlist, err = override.ParseOrganizations(cfg); err != nil {
+		return nil, err
+	}
+
+	// Parse response cache TTL (optional)
+	config.CacheTTL = ghconfig.Duration(cfg, "cacheTTL", 0)
+
+	// Parse the terminal-run memo TTL (optional)
+	config.TerminalRunTTL = ghconfig.Duration(cfg, "terminalRunTTL", defaultTerminalRunTTL)
+
+	// Parse the status board TTL (optional)
+	config.StatusTTL = ghconfig.Duration(cfg, "statusTTL", defaultStatusTTL)
+
+	// Parse environment history source (optional)
+	config.EnvironmentSource = strings.ToLower(ghconfig.String(cfg, "environmentSource"))
+	switch config.EnvironmentSource {
+	case "":
+		config.EnvironmentSource = EnvironmentSourceAuto
+	case EnvironmentSourceAuto, EnvironmentSourceDeployments, EnvironmentSourceRuns:
+	default:
+		return nil, fmt.Errorf("unknown environmentSource %q (expected %s, %s, or %s)", config.EnvironmentSource, EnvironmentSourceAuto, EnvironmentSourceDeployments, EnvironmentSourceRuns)
+	}
+
+	// Parse saved query presets (optional)
+	if config.Queries, err = parseSavedQueries(cfg); err != nil {
+		return nil, err
+	}
+
+	// Parse query scope and the organization it reads (optional)
+	config.QueryScope = strings.ToLower(ghconfig.String(cfg, "queryScope"))
+	switch config.QueryScope {
+	case "":
+		config.QueryScope = QueryScopeRepo
+	case QueryScopeRepo, QueryScopeOrg:
+	default:
+		return nil, fmt.Errorf("unknown queryScope %q (expected %s or %s)", config.QueryScope, QueryScopeRepo, QueryScopeOrg)
+	}
+	config.Organization = ghconfig.String(cfg, "organization")
+	if config.Organization == "" {
+		config.Organization = owner
+	}
+	config.Topic = ghconfig.String(cfg, "topic")
+
+	// Parse service tag lookups (optional)
+	config.ServiceTags = ghconfig.Bool(cfg, "serviceTags")
+
+	// Parse change freeze signals (optional)
+	if config.ChangeFreeze, err = parseChangeFreeze(cfg, owner); err != nil {
+		return nil, err
+	}
+
+	// Parse read-only flag (optional)
+	config.ReadOnly = ghconfig.Bool(cfg, "readOnly")
+
+	// Parse the scheduled dispatch directory (optional)
+	if config.ScheduleDir = ghconfig.String(cfg, "scheduleDir"); config.ScheduleDir != "" {
+		if err := os.MkdirAll(config.ScheduleDir, 0o700); err != nil {
+			return nil, fmt.Errorf("scheduleDir: %w", err)
+		}
+	}
+
+	// Parse bot policy (optional)
+	if config.BotPolicy, err = botpolicy.Parse(cfg); err != nil {
+		return nil, err
+	}
+
+	// Parse identity mapping (optional)
+	if config.Identities, err = identity.Parse(cfg); err != nil {
+		return nil, err
+	}
+
+	// Parse API call budgets (optional)
+	config.Budget = budget.Parse(cfg)
+
+	// Parse epoch millisecond timestamps (optional)
+	config.EpochMillis = ghconfig.Bool(cfg, "epochMillis")
+
+	// Parse instance labels (optional)
+	if config.InstanceLabels, err = instance.Parse(cfg); err != nil {
+		return nil, err
+	}
+
+	// Open the last-known-good store (optional)
+	if config.LastGood, err = lastgood.Parse(cfg, "deployment/"+owner+"/"+repo); err != nil {
+		return nil, err
+	}
+
+	// Open the audit log (optional)
+	if config.Audit, err = audit.Parse(cfg); err != nil {
+		return nil, err
+	}
+
+	// Team approvers are resolved in the organization that owns the repository,
+	// under the same bot policy
+	var teams *team.Provider
+	if api.Teams != nil && api.Organizations != nil && api.Users != nil {
+		teamCfg := map[string]any{"organization": config.Organization, "botPolicy": config.BotPolicy}
+		if teams, err = team.NewWithServices(teamCfg, api); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &Provider{
+		api:        api,
+		config:     config,
+		teams:      teams,
+		cacheScope: cacheScopes.Add(1),
+	}
+
+	// Pick up the dispatches a previous process scheduled
+	if config.ScheduleDir != "" {
+		if err := p.recoverSchedules(); err != nil {
+			return nil, fmt.Errorf("scheduleDir: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// Page is one page of query results. NextPageToken, when set, is passed back
+// in the query's metadata "pageToken" to read the results that follow.
+// TotalCount is the size of the whole result set when GitHub reports it or the
+// query read every result, and nil when it is unknown. Truncated is set when
+// the API call budget ran out, leaving the page short.
+type Page struct {
+	Deployments   []schema.Deployment `json:"deployments"`
+	NextPageToken string              `json:"nextPageToken,omitempty"`
+	HasMore       bool                `json:"hasMore"`
+	TotalCount    *int                `json:"totalCount,omitempty"`
+	Truncated     bool                `json:"truncated,omitempty"`
 }
 
-// Query returns deployments (GitHub Actions workflow runs) matching the given filters.
+// Query returns deployments (GitHub Actions workflow runs) matching the given
+// filters. Statuses, ref globs, and service and environment scopes are matched
+// after listing, so with a limit pages are read until that many deployments
+// match; without one, a single page is returned.
 func (p *Provider) Query(ctx context.Context, query schema.DeploymentQuery) ([]schema.Deployment, error) {
+	page, err := p.QueryPage(ctx, query)
+	return page.Deployments, err
+}
+
+// QueryPage is Query that also returns a continuation token, so large result
+// sets can be read one query at a time. A query resumes from metadata
+// "pageToken"; the token is only meaningful for the query that produced it.
+// Org-scope queries merge many repositories and return no token.
+func (p *Provider) QueryPage(ctx context.Context, query schema.DeploymentQuery) (Page, error) {
+	ctx = budget.Start(ctx, p.config.Budget)
+	page, err := p.queryPage(ctx, query)
+	page.Truncated = budget.Truncated(ctx)
+	p.finishPage(&page, query)
+	return lastgood.Serve(p.config.LastGood, lastgood.Key("query", query), page, err, markStalePage)
+}
+
+// finishPage sorts a page's records as the query asks, or its saved query, and
+// then leaves out the top-level values its "fields" do not name.
+func (p *Provider) finishPage(page *Page, query schema.DeploymentQuery) {
+	query, err := p.applySavedQuery(query)
+	if err != nil {
+		return
+	}
+	if order, err := parseOrdering(query.Metadata); err == nil {
+		order.sortDeployments(page.Deployments)
+	}
+	want := fieldset.Parse(query.Metadata)
+	for i := range page.Deployments {
+		want.Deployment(&page.Deployments[i])
+	}
+}
+
+func (p *Provider) queryPage(ctx context.Context, query schema.DeploymentQuery) (Page, error) {
+	query, err := p.applySavedQuery(query)
+	if err != nil {
+		return Page{}, err
+	}
+	order, err := parseOrdering(query.Metadata)
+	if err != nil {
+		return Page{}, err
+	}
+	refs, err := parseRefFilter(query.Metadata)
+	if err != nil {
+		return Page{}, err
+	}
+
+	// Org-scope queries aggregate the workflow runs of every repository
+	org, err := p.queryOrganization(query)
+	if err != nil {
+		return Page{}, err
+	}
+	if org != "" {
+		if paging.FromMetadata(query.Metadata) != "" {
+			return Page{}, &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: "page tokens are not supported for organization queries",
+			}
+		}
+		deployments, err := p.queryOrganizationRuns(ctx, org, query, refs)
+		return Page{Deployments: deployments}, err
+	}
+
+	// Repository queries are read in GitHub's order, newest created first, and
+	// stop at the limit, so a page cut by a limit or resumed from a token holds
+	// the right records only in that order. Sorting it afterwards would reorder
+	// the wrong records, so other orders are refused there.
+	if order != defaultOrdering && (query.Limit > 0 || paging.FromMetadata(query.Metadata) != "") {
+		return Page{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("sort %s %s is only supported for org-scope queries or queries without a limit or pageToken", order.Sort, order.Direction),
+		}
+	}
+
+	// A repository named in metadata is read from its workflow runs; the
+	// Deployments API history below is only read for the configured repository
+	owner, repo, overridden, err := p.config.RepositoryAllowlist.Repository(query.Metadata, p.config.Owner, p.config.Repo)
+	if err != nil {
+		return Page{}, err
+	}
+	if overridden {
+		return p.queryRuns(ctx, owner, repo, query, refs)
+	}
+
+	// Environment history comes from the Deployments API when available, which
+	// is exact, instead of guessing the environment from workflow run names
+	if query.Scope.Environment != "" && p.useDeploymentsAPI() {
+		page, found, err := p.queryEnvironment(ctx, query, refs)
+		if err != nil || found || p.config.EnvironmentSource == EnvironmentSourceDeployments {
+			if fieldset.Parse(query.Metadata).Has("service_tags") {
+				p.addServiceTags(ctx, p.config.Owner, p.config.Repo, page.Deployments)
+			}
+			return page, err
+		}
+	}
+
+	return p.queryRuns(ctx, p.config.Owner, p.config.Repo, query, refs)
+}
+
+// queryRuns lists the workflow runs of owner/repo matching the query.
+func (p *Provider) queryRuns(ctx context.Context, owner, repo string, query schema.DeploymentQuery, refs *refFilter) (Page, error) {
 	opts := &github.ListWorkflowRunsOptions{
 		ListOptions: github.ListOptions{
 			PerPage: 100, // GitHub's max per page
 		},
 	}
 
-	// Apply limit
-	if query.Limit > 0 && query.Limit < 100 {
+	// Apply limit; filters matched here fetch full pages and trim afterwards
+	filtered := len(query.Statuses) > 0 || refs.isGlob() || query.Scope.Service != "" || query.Scope.Environment != ""
+	if query.Limit > 0 && query.Limit < 100 && !filtered {
 		opts.PerPage = query.Limit
 	}
 
@@ -96,6 +391,11 @@ func (p *Provider) Query(ctx context.Context, query schema.DeploymentQuery) ([]s
 		opts.Branch = branch
 	}
 
+	// Apply exact ref or tag filter; GitHub's branch filter also matches tags
+	if name := refs.exact(); name != "" && opts.Branch == "" {
+		opts.Branch = name
+	}
+
 	// Apply actor filter from metadata
 	if actor, ok := query.Metadata["actor"].(string); ok {
 		opts.Actor = actor
@@ -106,48 +406,159 @@ func (p *Provider) Query(ctx context.Context, query schema.DeploymentQuery) ([]s
 		opts.Event = event
 	}
 
-	runs, _, err := p.client.Actions.ListRepositoryWorkflowRuns(ctx, p.config.Owner, p.config.Repo, opts)
+	want := fieldset.Parse(query.Metadata)
+	deployments := []schema.Deployment{}
+	var total *int
+	token := paging.FromMetadata(query.Metadata)
+	list := func() ([]*github.WorkflowRun, *github.Response, error) {
+		if !budget.Call(ctx) {
+			return nil, nil, budget.ErrExhausted
+		}
+		runs, resp, err := p.api.Actions.ListRepositoryWorkflowRuns(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, nil, p.wrapError(err)
+		}
+		if total == nil && runs.TotalCount != nil {
+			deployments = slices.Grow(deployments, paging.Capacity(query.Limit, opts.PerPage, runs.TotalCount))
+		}
+		total = runs.TotalCount
+		return runs.WorkflowRuns, resp, nil
+	}
+	next, err := paging.Walk(token, query.Limit, &opts.ListOptions, list, func(run *github.WorkflowRun) (bool, error) {
+		deployment, ok := p.matchRun(run, owner, repo, query, refs, want)
+		if ok {
+			deployments = append(deployments, deployment)
+		}
+		return ok, nil
+	})
 	if err != nil {
-		return nil, p.wrapError(err)
-	}
-
-	deployments := make([]schema.Deployment, 0, len(runs.WorkflowRuns))
-	for _, run := range runs.WorkflowRuns {
-		// Apply conclusion filter if status filter was specified
-		if len(query.Statuses) > 0 {
-			normalizedStatus := p.normalizeStatus(run.GetStatus(), run.GetConclusion())
-			found := false
-			for _, status := range query.Statuses {
-				if strings.EqualFold(normalizedStatus, status) {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
+		return Page{}, err
+	}
+	if want.Has("service_tags") {
+		p.addServiceTags(ctx, owner, repo, deployments)
+	}
+	// GitHub's total counts the runs its own filters matched, which is only the
+	// result count when nothing was filtered here
+	if filtered || total == nil {
+		total = paging.Total(token, next, len(deployments))
+	}
+	return Page{
+		Deployments:   deployments,
+		NextPageToken: next,
+		HasMore:       next != "",
+		TotalCount:    total,
+	}, nil
+}
+
+// matchRun converts a run of owner/repo, reporting whether it passes the
+// query's status, ref, service, and environment filters.
+func (p *Provider) matchRun(run *github.WorkflowRun, owner, repo string, query schema.DeploymentQuery, refs *refFilter, want fieldset.Set) (schema.Deployment, bool) {
+	// Apply conclusion filter if status filter was specified
+	if len(query.Statuses) > 0 {
+		normalizedStatus := p.normalizeStatus(run.GetStatus(), run.GetConclusion())
+		found := false
+		for _, status := range query.Statuses {
+			if strings.EqualFold(normalizedStatus, status) {
+				found = true
+				break
 			}
 		}
+		if !found {
+			return schema.Deployment{}, false
+		}
+	}
 
-		deployment := p.convertWorkflowRunToDeployment(run)
+	// Apply ref or tag filter
+	if !refs.matches(run.GetHeadBranch()) {
+		return schema.Deployment{}, false
+	}
 
-		// Apply service filter from scope
-		if query.Scope.Service != "" && deployment.Service != query.Scope.Service {
-			continue
-		}
+	deployment := p.convertRunIn(run, owner, repo, want)
 
-		// Apply environment filter from scope
-		if query.Scope.Environment != "" && deployment.Environment != query.Scope.Environment {
-			continue
-		}
+	// Apply service filter from scope
+	if query.Scope.Service != "" && deployment.Service != query.Scope.Service {
+		return schema.Deployment{}, false
+	}
 
-		deployments = append(deployments, deployment)
+	// Apply environment filter from scope
+	if query.Scope.Environment != "" && deployment.Environment != query.Scope.Environment {
+		return schema.Deployment{}, false
 	}
 
-	return deployments, nil
+	return deployment, true
 }
 
-// Get returns a single deployment by its ID (workflow run ID).
+// Get returns a single deployment by its ID: a workflow run ID, a Deployments
+// API record ID with the "deployment-" prefix, owner/repo#ID or
+// owner/repo#deployment-ID for one in another repository, or a GraphQL node ID.
 func (p *Provider) Get(ctx context.Context, id string) (schema.Deployment, error) {
+	deployment, err := p.get(throttle.WithPriority(ctx, throttle.Get), id)
+	return lastgood.Serve(p.config.LastGood, "get/"+id, deployment, err, markStale)
+}
+
+func (p *Provider) get(ctx context.Context, id string) (schema.Deployment, error) {
+	// Node IDs are resolved to the repository the run or deployment lives in
+	// now, which may have been renamed or transferred since the ID was recorded.
+	if nodeid.Is(id) {
+		node, err := nodeid.Resolve(ctx, p.api.GraphQL, id, p.wrapError)
+		if err != nil {
+			return schema.Deployment{}, err
+		}
+		if err := nodeid.Expect(id, node, nodeid.TypeWorkflowRun, nodeid.TypeDeployment); err != nil {
+			return schema.Deployment{}, err
+		}
+		if node.Type == nodeid.TypeDeployment {
+			id = fmt.Sprintf("%s/%s#%s%d", node.Owner, node.Repo, deploymentIDPrefix, node.DatabaseID)
+		} else {
+			id = fmt.Sprintf("%s/%s#%d", node.Owner, node.Repo, node.DatabaseID)
+		}
+	}
+
+	// Deployments API records of other repositories have owner/repo#deployment-ID IDs
+	if owner, repo, deploymentID, ok := splitDeploymentID(id); ok {
+		if owner == p.config.Owner && repo == p.config.Repo {
+			id = deploymentID
+		} else {
+			if err := p.reach(owner, repo); err != nil {
+				return schema.Deployment{}, err
+			}
+			if cached, ok := p.cachedDeployment(id); ok {
+				return cached, nil
+			}
+			return p.fetchDeployment(ctx, owner, repo, deploymentID)
+		}
+	}
+
+	if strings.HasPrefix(id, deploymentIDPrefix) {
+		if cached, ok := p.cachedDeployment(id); ok {
+			return cached, nil
+		}
+		return p.fetchDeployment(ctx, p.config.Owner, p.config.Repo, id)
+	}
+
+	// Runs in other repositories of the organization have owner/repo#ID IDs
+	if owner, repo, runID, ok := splitRunID(id); ok {
+		if owner != p.config.Owner || repo != p.config.Repo {
+			if err := p.reach(owner, repo); err != nil {
+				return schema.Deployment{}, err
+			}
+			if cached, ok := p.cachedDeployment(id); ok {
+				return cached, nil
+			}
+			if memo, ok := p.terminalRun(id); ok {
+				return memo, nil
+			}
+			deployment, err := p.fetchIn(ctx, owner, repo, runID)
+			if err == nil {
+				p.addServiceTags(ctx, owner, repo, []schema.Deployment{deployment})
+				p.Prime(deployment)
+				p.rememberTerminal(deployment)
+			}
+			return deployment, err
+		}
+		id = strconv.FormatInt(runID, 10)
+	}
+
 	runID, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
 		return schema.Deployment{}, &orcherr.OpsOrchError{
@@ -156,33 +567,100 @@ func (p *Provider) Get(ctx context.Context, id string) (schema.Deployment, error
 		}
 	}
 
-	run, _, err := p.client.Actions.GetWorkflowRunByID(ctx, p.config.Owner, p.config.Repo, runID)
+	if cached, ok := p.cachedDeployment(id); ok {
+		return cached, nil
+	}
+	if memo, ok := p.terminalRun(id); ok {
+		return memo, nil
+	}
+
+	run, deployment, err := p.fetchRun(ctx, runID)
+	if err != nil {
+		return deployment, err
+	}
+	jobs := p.addJobs(ctx, runID, deployment.Fields)
+	p.addAnnotations(ctx, jobs, deployment.Fields)
+	p.addConcurrency(ctx, run, deployment.Fields)
+	p.addCommitDetails(ctx, run.GetHeadSHA(), deployment.Fields)
+	p.addApprovers(ctx, runID, run.GetStatus(), deployment.Fields)
+	p.addServiceTags(ctx, p.config.Owner, p.config.Repo, []schema.Deployment{deployment})
+	p.Prime(deployment)
+	p.rememberTerminal(deployment)
+	return deployment, nil
+}
+
+// fetch retrieves a workflow run from the API, bypassing the cache and
+// publishing the deployment to Watch callers.
+func (p *Provider) fetch(ctx context.Context, runID int64) (schema.Deployment, error) {
+	_, deployment, err := p.fetchRun(ctx, runID)
+	if err == nil {
+		p.Publish(deployment)
+	}
+	return deployment, err
+}
+
+// fetchRun retrieves and converts a workflow run, leaving it to the caller to
+// prime the cache once the deployment is complete.
+func (p *Provider) fetchRun(ctx context.Context, runID int64) (*github.WorkflowRun, schema.Deployment, error) {
+	run, _, err := p.api.Actions.GetWorkflowRunByID(ctx, p.config.Owner, p.config.Repo, runID)
 	if err != nil {
-		return schema.Deployment{}, p.wrapError(err)
+		return nil, schema.Deployment{}, p.wrapError(err)
 	}
 
-	return p.convertWorkflowRunToDeployment(run), nil
+	return run, p.convertWorkflowRunToDeployment(run), nil
 }
 
+// deploymentFieldsHint sizes a deployment's Fields for the entries most runs
+// have, so filling it rarely grows the map.
+const deploymentFieldsHint = 8
+
 // convertWorkflowRunToDeployment converts a GitHub workflow run to a normalized Deployment.
 func (p *Provider) convertWorkflowRunToDeployment(run *github.WorkflowRun) schema.Deployment {
+	return p.convertRun(run, nil)
+}
+
+// convertRun converts a GitHub workflow run, building only the Fields entries
+// in want. Queries pass the set from their "fields" metadata, so large result
+// sets skip the entries nobody reads.
+func (p *Provider) convertRun(run *github.WorkflowRun, want fieldset.Set) schema.Deployment {
 	deployment := schema.Deployment{
 		ID:     strconv.FormatInt(run.GetID(), 10),
 		Status: p.normalizeStatus(run.GetStatus(), run.GetConclusion()),
 		URL:    run.GetHTMLURL(),
-		Fields: map[string]any{
-			"workflow_name": run.GetName(),
-			"branch":        run.GetHeadBranch(),
-			"commit":        run.GetHeadSHA(),
-		},
+		Fields: make(map[string]any, want.Size(deploymentFieldsHint)),
+	}
+	if want.Has("workflow_name") {
+		deployment.Fields["workflow_name"] = run.GetName()
+	}
+	if want.Has("branch") {
+		deployment.Fields["branch"] = run.GetHeadBranch()
+	}
+	if want.Has("commit") {
+		deployment.Fields["commit"] = run.GetHeadSHA()
+	}
+
+	// Node IDs stay valid across repository renames and transfers, and Get accepts them
+	if nodeID := run.GetNodeID(); nodeID != "" && want.Has("node_id") {
+		deployment.Fields["node_id"] = nodeID
+	}
+	if repoNodeID := run.GetRepository().GetNodeID(); repoNodeID != "" && want.Has("repository_node_id") {
+		deployment.Fields["repository_node_id"] = repoNodeID
 	}
 
 	// Set timestamps
 	if createdAt := run.GetCreatedAt(); !createdAt.IsZero() {
-		deployment.StartedAt = createdAt.Time
+		deployment.StartedAt = timefmt.UTC(createdAt.Time)
 	}
 	if updatedAt := run.GetUpdatedAt(); !updatedAt.IsZero() {
-		deployment.FinishedAt = updatedAt.Time
+		deployment.FinishedAt = timefmt.UTC(updatedAt.Time)
+	}
+	if p.config.EpochMillis {
+		if want.Has("started_at_ms") {
+			timefmt.SetMillis(deployment.Fields, "started_at", deployment.StartedAt)
+		}
+		if want.Has("finished_at_ms") {
+			timefmt.SetMillis(deployment.Fields, "finished_at", deployment.FinishedAt)
+		}
 	}
 
 	// Extract service name from repository name
@@ -198,19 +676,49 @@ func (p *Provider) convertWorkflowRunToDeployment(run *github.WorkflowRun) schem
 
 	// Add actor information (just the login name)
 	if actor := run.GetActor(); actor != nil {
-		deployment.Actor = map[string]any{
-			"login": actor.GetLogin(),
-		}
+		deployment.Actor = p.convertActor(actor)
 	}
 
-	// Add commit message if available
+	// Add commit message and people if available
 	if headCommit := run.GetHeadCommit(); headCommit != nil {
-		deployment.Fields["commit_message"] = headCommit.GetMessage()
+		if want.Has("commit_message") {
+			deployment.Fields["commit_message"] = headCommit.GetMessage()
+		}
+		if want.Has("commit_author") {
+			if author, ok := commitPerson(headCommit.Author, headCommit.Timestamp); ok {
+				deployment.Fields["commit_author"] = author
+			}
+		}
+		if want.Has("commit_committer") {
+			if committer, ok := commitPerson(headCommit.Committer, headCommit.Timestamp); ok {
+				deployment.Fields["commit_committer"] = committer
+			}
+		}
 	}
 
+	if want.Has(instance.Field) {
+		p.config.InstanceLabels.Stamp(deployment.Fields)
+	}
 	return deployment
 }
 
+// convertActor builds a deployment actor: the login, whether it is a bot, and
+// the canonical identity it maps to.
+func (p *Provider) convertActor(user *github.User) map[string]any {
+	actor := map[string]any{
+		"login": user.GetLogin(),
+	}
+	if p.config.BotPolicy.IsBot(user.GetLogin(), user.GetType()) {
+		actor["bot"] = true
+	}
+	if id, ok := p.config.Identities.Lookup(user.GetLogin()); ok {
+		for key, value := range id.Fields() {
+			actor[key] = value
+		}
+	}
+	return actor
+}
+
 // normalizeStatus converts GitHub workflow run status and conclusion to normalized status.
 func (p *Provider) normalizeStatus(status, conclusion string) string {
 	switch strings.ToLower(status) {
@@ -274,40 +782,7 @@ func (p *Provider) extractEnvironment(workflowName, branch string) string {
 
 // wrapError wraps GitHub API errors into OpsOrch errors.
 func (p *Provider) wrapError(err error) error {
-	if ghErr, ok := err.(*github.ErrorResponse); ok {
-		switch ghErr.Response.StatusCode {
-		case 401:
-			return &orcherr.OpsOrchError{
-				Code:    "unauthorized",
-				Message: "GitHub API authentication failed",
-			}
-		case 403:
-			return &orcherr.OpsOrchError{
-				Code:    "forbidden",
-				Message: "GitHub API access forbidden",
-			}
-		case 404:
-			return &orcherr.OpsOrchError{
-				Code:    "not_found",
-				Message: "GitHub repository or workflow run not found",
-			}
-		case 422:
-			return &orcherr.OpsOrchError{
-				Code:    "bad_request",
-				Message: fmt.Sprintf("GitHub API validation error: %s", ghErr.Message),
-			}
-		default:
-			return &orcherr.OpsOrchError{
-				Code:    "provider_error",
-				Message: fmt.Sprintf("GitHub API error: %s", ghErr.Message),
-			}
-		}
-	}
-
-	return &orcherr.OpsOrchError{
-		Code:    "provider_error",
-		Message: fmt.Sprintf("GitHub API error: %v", err),
-	}
+	return gherr.Wrap(err, "repository or workflow run")
 }
 
 func init() {
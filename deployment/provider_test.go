@@ -1,11 +1,43 @@
 package deployment
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-github/v57/github"
 	"github.com/opsorch/opsorch-core/deployment"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/audit"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/cache"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+	"github.com/opsorch/opsorch-github-adapter/internal/instance"
+	"github.com/opsorch/opsorch-github-adapter/internal/override"
+	"github.com/opsorch/opsorch-github-adapter/team"
 )
 
+// newFakeProvider returns a provider wired to a fake GitHub server.
+func newFakeProvider(t testing.TB) (*Provider, *fakegithub.Server) {
+	srv := fakegithub.New(t)
+	return &Provider{
+		api:    ghapi.FromClient(srv.Client()),
+		config: Config{Owner: fakegithub.Owner, Repo: fakegithub.Repo, EnvironmentSource: EnvironmentSourceAuto},
+	}, srv
+}
+
 func TestNew(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -45,8 +77,28 @@ func TestNew(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "repository shorthand",
+			config: map[string]any{
+				"token":      "ghp_test_token",
+				"repository": "testorg/testrepo",
+			},
+			wantErr: false,
+		},
+		{
+			name: "malformed repository shorthand",
+			config: map[string]any{
+				"token":      "ghp_test_token",
+				"repository": "testrepo",
+			},
+			wantErr: true,
+		},
 	}
 
+	// Keep Actions environment from leaking into the config under test
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_REPOSITORY", "")
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			provider, err := New(tt.config)
@@ -61,6 +113,20 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewEnvFallback(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_env_token")
+	t.Setenv("GITHUB_REPOSITORY", "envorg/envrepo")
+
+	p, err := New(map[string]any{"repo": "override"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	cfg := p.(*Provider).config
+	if cfg.Token != "ghp_env_token" || cfg.Owner != "envorg" || cfg.Repo != "override" {
+		t.Errorf("unexpected config from env fallback: %+v", cfg)
+	}
+}
+
 func TestProviderRegistration(t *testing.T) {
 	// Test that the provider is registered
 	constructor, ok := deployment.LookupProvider("github")
@@ -129,3 +195,1798 @@ func TestExtractEnvironment(t *testing.T) {
 		})
 	}
 }
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   schema.DeploymentQuery
+		wantIDs []string
+	}{
+		{
+			name:    "all runs",
+			query:   schema.DeploymentQuery{},
+			wantIDs: []string{"1001", "1002", "1003"},
+		},
+		{
+			name:    "status filter by conclusion",
+			query:   schema.DeploymentQuery{Statuses: []string{"failed"}},
+			wantIDs: []string{"1002"},
+		},
+		{
+			name:    "environment scope",
+			query:   schema.DeploymentQuery{Scope: schema.QueryScope{Environment: "staging"}},
+			wantIDs: []string{"1002"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newFakeProvider(t)
+
+			deployments, err := p.Query(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			var ids []string
+			for _, d := range deployments {
+				ids = append(ids, d.ID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("got %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Errorf("got %v, want %v", ids, tt.wantIDs)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestQueryFillsLimitAcrossPages(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	runs := []map[string]any{
+		{"id": 3003, "head_branch": "main", "status": "completed", "conclusion": "success"},
+		{"id": 3002, "head_branch": "main", "status": "completed", "conclusion": "failure"},
+		{"id": 3001, "head_branch": "main", "status": "completed", "conclusion": "failure"},
+	}
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs", func(w http.ResponseWriter, r *http.Request) {
+		page := 1
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		if page < len(runs) {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?page=%d>; rel="next"`, r.Host, r.URL.Path, page+1))
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": len(runs), "workflow_runs": runs[page-1 : page]})
+	})
+
+	// Failures are told apart from successes after listing, so the first page
+	// alone does not fill the limit
+	deployments, err := p.Query(context.Background(), schema.DeploymentQuery{Statuses: []string{"failed"}, Limit: 2})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(deployments) != 2 || deployments[0].ID != "3002" || deployments[1].ID != "3001" {
+		t.Fatalf("deployments = %+v", deployments)
+	}
+	reqs := srv.Requests()
+	if len(reqs) != 3 || reqs[0].Query.Get("per_page") != "100" {
+		t.Errorf("made %d requests, first %v", len(reqs), reqs[0].Query)
+	}
+
+	// Unfiltered queries ask GitHub for exactly the limit and stop once it is met
+	deployments, _ = p.Query(context.Background(), schema.DeploymentQuery{Limit: 1})
+	reqs = srv.Requests()
+	if len(deployments) != 1 || len(reqs) != 4 || reqs[3].Query.Get("per_page") != "1" {
+		t.Errorf("limit 1 returned %d deployments after %d requests", len(deployments), len(reqs))
+	}
+
+	// A call budget stops the query early with what it found
+	p.config.Budget = budget.Limits{Calls: 2}
+	page, err := p.QueryPage(context.Background(), schema.DeploymentQuery{Statuses: []string{"failed"}, Limit: 2})
+	if err != nil {
+		t.Fatalf("QueryPage() error = %v", err)
+	}
+	if len(page.Deployments) != 1 || !page.Truncated || page.NextPageToken == "" {
+		t.Errorf("budgeted page = %+v", page)
+	}
+}
+
+func TestQueryPageToken(t *testing.T) {
+	p, srv := newFakeProvider(t)
+
+	// Completed runs are told apart by conclusion after listing
+	first, err := p.QueryPage(context.Background(), schema.DeploymentQuery{Statuses: []string{"success", "failed"}, Limit: 1})
+	if err != nil {
+		t.Fatalf("QueryPage() error = %v", err)
+	}
+	if len(first.Deployments) != 1 || first.Deployments[0].ID != "1001" || first.NextPageToken == "" {
+		t.Fatalf("first page = %+v", first)
+	}
+	if first.TotalCount != nil {
+		t.Errorf("filtered TotalCount = %d, want unknown", *first.TotalCount)
+	}
+	second, err := p.QueryPage(context.Background(), schema.DeploymentQuery{Statuses: []string{"success", "failed"}, Limit: 1, Metadata: map[string]any{"pageToken": first.NextPageToken}})
+	if err != nil {
+		t.Fatalf("QueryPage() error = %v", err)
+	}
+	if len(second.Deployments) != 1 || second.Deployments[0].ID != "1002" {
+		t.Errorf("second page = %+v", second)
+	}
+	reqs := srv.Requests()
+	if q := reqs[len(reqs)-1].Query; q.Get("page") != "1" || q.Get("per_page") != "100" {
+		t.Errorf("resumed query = %v, want the first page again", q)
+	}
+
+	// Unfiltered queries report GitHub's total
+	unfiltered, err := p.QueryPage(context.Background(), schema.DeploymentQuery{Limit: 1})
+	if err != nil || unfiltered.TotalCount == nil || *unfiltered.TotalCount != 3 {
+		t.Errorf("unfiltered QueryPage() = %+v, %v, want a total of 3", unfiltered, err)
+	}
+
+	p.config.QueryScope = QueryScopeOrg
+	p.config.Organization = "acme"
+	if _, err := p.QueryPage(context.Background(), schema.DeploymentQuery{Metadata: map[string]any{"pageToken": first.NextPageToken}}); !hasCode(err, "bad_request") {
+		t.Errorf("org QueryPage() with a token error = %v, want bad_request", err)
+	}
+}
+
+func TestQueryFields(t *testing.T) {
+	p, _ := newFakeProvider(t)
+
+	deployments, err := p.Query(context.Background(), schema.DeploymentQuery{
+		Metadata: map[string]any{"fields": "branch, commit"},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(deployments) == 0 {
+		t.Fatal("no deployments")
+	}
+	for _, d := range deployments {
+		if len(d.Fields) != 2 || d.Fields["branch"] == nil || d.Fields["commit"] == nil {
+			t.Errorf("deployment %s fields = %v, want branch and commit", d.ID, d.Fields)
+		}
+		if d.Status == "" || d.Environment == "" {
+			t.Errorf("top-level fields missing: %+v", d)
+		}
+	}
+
+	// Naming top-level values leaves out the others
+	deployments, err = p.Query(context.Background(), schema.DeploymentQuery{
+		Metadata: map[string]any{"fields": []string{"id", "status", "url"}},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	for _, d := range deployments {
+		if d.ID == "" || d.Status == "" || d.URL == "" || d.Environment != "" || d.Actor != nil || !d.StartedAt.IsZero() || d.Fields != nil {
+			t.Errorf("projected deployment = %+v", d)
+		}
+	}
+}
+
+func TestQuerySort(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs", func(w http.ResponseWriter, _ *http.Request) {
+		// Re-runs keep their creation time, so GitHub's order is not by start
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 4, "workflow_runs": []map[string]any{
+			{"id": 3, "status": "completed", "conclusion": "success", "created_at": "2024-03-02T10:00:00Z", "updated_at": "2024-03-02T10:05:00Z"},
+			{"id": 4, "status": "completed", "conclusion": "failure", "created_at": "2024-03-03T10:00:00Z", "updated_at": "2024-03-03T10:01:00Z"},
+			{"id": 10, "status": "completed", "conclusion": "success", "created_at": "2024-03-01T10:00:00Z", "updated_at": "2024-03-04T10:00:00Z"},
+			{"id": 9, "status": "completed", "conclusion": "success", "created_at": "2024-03-01T10:00:00Z", "updated_at": "2024-03-01T10:05:00Z"},
+		}})
+	})
+	ids := func(metadata map[string]any) string {
+		t.Helper()
+		deployments, err := p.Query(context.Background(), schema.DeploymentQuery{Statuses: []string{"success", "failed"}, Metadata: metadata})
+		if err != nil {
+			t.Fatalf("Query(%v) error = %v", metadata, err)
+		}
+		var ids []string
+		for _, d := range deployments {
+			ids = append(ids, d.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	for _, tt := range []struct {
+		metadata map[string]any
+		want     string
+	}{
+		{nil, "4,3,10,9"},
+		{map[string]any{"direction": "asc"}, "9,10,3,4"},
+		{map[string]any{"sort": "finished"}, "10,4,3,9"},
+	} {
+		if got := ids(tt.metadata); got != tt.want {
+			t.Errorf("Query(%v) order = %s, want %s", tt.metadata, got, tt.want)
+		}
+	}
+	for _, metadata := range []map[string]any{{"sort": "name"}, {"direction": "up"}} {
+		if _, err := p.Query(context.Background(), schema.DeploymentQuery{Metadata: metadata}); !hasCode(err, "bad_request") {
+			t.Errorf("Query(%v) error = %v, want bad_request", metadata, err)
+		}
+	}
+
+	// A limit would cut GitHub's order before the sort, so only the default
+	// order is accepted with one
+	if _, err := p.Query(context.Background(), schema.DeploymentQuery{Limit: 2, Metadata: map[string]any{"direction": "asc"}}); !hasCode(err, "bad_request") {
+		t.Errorf("Query() with a limit and direction asc error = %v, want bad_request", err)
+	}
+	if _, err := p.Query(context.Background(), schema.DeploymentQuery{Limit: 2, Metadata: map[string]any{"direction": "desc"}}); err != nil {
+		t.Errorf("Query() with a limit in the default order error = %v", err)
+	}
+}
+
+func TestInstanceLabels(t *testing.T) {
+	p, _ := newFakeProvider(t)
+	p.config.InstanceLabels = instance.Labels{"region": "eu-west-1"}
+
+	deployments, err := p.Query(context.Background(), schema.DeploymentQuery{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(deployments) == 0 {
+		t.Fatal("no deployments")
+	}
+	for _, d := range deployments {
+		if !reflect.DeepEqual(d.Fields[instance.Field], map[string]string{"region": "eu-west-1"}) {
+			t.Errorf("deployment %s fields = %v", d.ID, d.Fields)
+		}
+	}
+	d := p.convertDeploymentToSchema(&github.Deployment{ID: github.Int64(7)}, nil)
+	if d.Fields[instance.Field] == nil {
+		t.Errorf("Deployments API record fields = %v", d.Fields)
+	}
+}
+
+func TestEpochMillis(t *testing.T) {
+	p, _ := newFakeProvider(t)
+	p.config.EpochMillis = true
+
+	started := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("PST", -8*3600))
+	d := p.convertRun(&github.WorkflowRun{
+		ID:        github.Int64(1),
+		CreatedAt: &github.Timestamp{Time: started},
+		UpdatedAt: &github.Timestamp{Time: started.Add(time.Minute)},
+		HeadCommit: &github.HeadCommit{
+			Timestamp: &github.Timestamp{Time: started},
+			Author:    &github.CommitAuthor{Name: github.String("Alice")},
+		},
+	}, nil)
+	if d.StartedAt.Location() != time.UTC || !d.StartedAt.Equal(started) {
+		t.Errorf("StartedAt = %v", d.StartedAt)
+	}
+	if d.Fields["started_at_ms"] != started.UnixMilli() || d.Fields["finished_at_ms"] != started.Add(time.Minute).UnixMilli() {
+		t.Errorf("fields = %v", d.Fields)
+	}
+	if author, _ := d.Fields["commit_author"].(CommitPerson); author.Timestamp.Location() != time.UTC {
+		t.Errorf("commit_author = %+v", d.Fields["commit_author"])
+	}
+
+	p.config.EpochMillis = false
+	if d := p.convertRun(&github.WorkflowRun{ID: github.Int64(1), CreatedAt: &github.Timestamp{Time: started}}, nil); d.Fields["started_at_ms"] != nil {
+		t.Errorf("fields without epochMillis = %v", d.Fields)
+	}
+}
+
+func TestQueryServiceTags(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	p.config.ServiceTags = true
+	srv.Handle(http.MethodGet, "/repos/acme/api", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"name": "api", "owner": map[string]any{"login": "acme", "type": "Organization"}, "topics": []string{"payments"}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/properties/values", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"property_name": "tier", "value": "1"}})
+	})
+
+	deployments, err := p.Query(context.Background(), schema.DeploymentQuery{})
+	if err != nil || len(deployments) == 0 {
+		t.Fatalf("Query() = %v, %v", deployments, err)
+	}
+	want := map[string]string{"topic:payments": "true", "tier": "1"}
+	for _, d := range deployments {
+		if !reflect.DeepEqual(d.Fields["service_tags"], want) {
+			t.Errorf("deployment %s service_tags = %v, want %v", d.ID, d.Fields["service_tags"], want)
+		}
+	}
+	reads := func() int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if r.Path == "/repos/acme/api" {
+				n++
+			}
+		}
+		return n
+	}
+	if n := reads(); n != 1 {
+		t.Errorf("repository read %d times, want once per query", n)
+	}
+
+	// Queries not asking for the tags skip the lookups
+	deployments, err = p.Query(context.Background(), schema.DeploymentQuery{Metadata: map[string]any{"fields": "branch"}})
+	if err != nil || deployments[0].Fields["service_tags"] != nil {
+		t.Errorf("Query() with fields = %v, %v", deployments, err)
+	}
+	if n := reads(); n != 1 {
+		t.Errorf("repository read %d times, want no read for a query without service_tags", n)
+	}
+}
+
+func TestQueryRefFilter(t *testing.T) {
+	p, srv := newFakeProvider(t)
+
+	deployments, err := p.Query(context.Background(), schema.DeploymentQuery{Metadata: map[string]any{"ref": "refs/heads/release/*"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(deployments) != 1 || deployments[0].ID != "1002" {
+		t.Errorf("ref glob deployments = %+v", deployments)
+	}
+	if q := srv.Requests()[0].Query; q.Has("branch") || q.Get("per_page") != "100" {
+		t.Errorf("glob query = %v, want no server-side branch", q)
+	}
+
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 4, "workflow_runs": []map[string]any{
+			{"id": 2004, "head_branch": "v2.4.0", "status": "completed", "conclusion": "success"},
+			{"id": 2003, "head_branch": "v2.3.1", "status": "completed", "conclusion": "success"},
+			{"id": 2002, "head_branch": "v2.3.0", "status": "completed", "conclusion": "failure"},
+			{"id": 2001, "head_branch": "v2.2.0", "status": "completed", "conclusion": "success"},
+		}})
+	})
+	deployments, _ = p.Query(context.Background(), schema.DeploymentQuery{Limit: 5, Metadata: map[string]any{"tag": "v2.3.*"}})
+	if len(deployments) != 2 || deployments[0].ID != "2003" || deployments[1].ID != "2002" {
+		t.Errorf("tag glob deployments = %+v", deployments)
+	}
+	deployments, _ = p.Query(context.Background(), schema.DeploymentQuery{Limit: 1, Metadata: map[string]any{"tag": "v2.3.*"}})
+	if len(deployments) != 1 || deployments[0].ID != "2003" {
+		t.Errorf("tag glob deployments with limit = %+v", deployments)
+	}
+
+	// Exact names are filtered by GitHub
+	if _, err := p.Query(context.Background(), schema.DeploymentQuery{Metadata: map[string]any{"tag": "refs/tags/v2.3.1"}}); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	reqs := srv.Requests()
+	if q := reqs[len(reqs)-1].Query; q.Get("branch") != "v2.3.1" {
+		t.Errorf("exact tag query = %v", q)
+	}
+	if _, err := p.Query(context.Background(), schema.DeploymentQuery{Scope: schema.QueryScope{Environment: "production"}, Metadata: map[string]any{"ref": "main"}}); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	for _, r := range srv.Requests() {
+		if r.Path == "/repos/acme/api/deployments" && r.Query.Get("ref") != "main" {
+			t.Errorf("deployments query = %v, want ref=main", r.Query)
+		}
+	}
+
+	for _, metadata := range []map[string]any{{"ref": "main", "tag": "v1"}, {"tag": "v2.["}} {
+		if _, err := p.Query(context.Background(), schema.DeploymentQuery{Metadata: metadata}); !hasCode(err, "bad_request") {
+			t.Errorf("Query(%v) error = %v, want bad_request", metadata, err)
+		}
+	}
+}
+
+func TestQueryRepositoryOverride(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/web/actions/runs", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 1, "workflow_runs": []map[string]any{
+			{"id": 3001, "head_branch": "main", "status": "completed", "conclusion": "success"},
+		}})
+	})
+
+	if _, err := p.Query(context.Background(), schema.DeploymentQuery{Metadata: map[string]any{"repo": "web"}}); !hasCode(err, "forbidden") {
+		t.Fatalf("Query() error = %v, want forbidden", err)
+	}
+
+	p.config.RepositoryAllowlist = override.Allowlist{"acme/*"}
+	deployments, err := p.Query(context.Background(), schema.DeploymentQuery{
+		Scope:    schema.QueryScope{Environment: "production"},
+		Metadata: map[string]any{"repository": "acme/web"},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(deployments) != 1 || deployments[0].ID != "acme/web#3001" {
+		t.Errorf("Query() = %+v", deployments)
+	}
+	for _, r := range srv.Requests() {
+		if strings.HasPrefix(r.Path, "/repos/acme/api/") {
+			t.Errorf("override query requested %s", r.Path)
+		}
+	}
+
+	if _, err := p.Query(context.Background(), schema.DeploymentQuery{Metadata: map[string]any{"owner": "acme"}}); !hasCode(err, "bad_request") {
+		t.Errorf("Query() with owner only error = %v, want bad_request", err)
+	}
+}
+
+func TestQueryEnvironment(t *testing.T) {
+	ids := func(deployments []schema.Deployment) []string {
+		var out []string
+		for _, d := range deployments {
+			out = append(out, d.ID)
+		}
+		return out
+	}
+
+	p, srv := newFakeProvider(t)
+	deployments, err := p.Query(context.Background(), schema.DeploymentQuery{Scope: schema.QueryScope{Environment: "production"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if got := ids(deployments); !reflect.DeepEqual(got, []string{"deployment-501", "deployment-500"}) {
+		t.Fatalf("ids = %v", got)
+	}
+	if d := deployments[0]; d.Status != "success" || d.Environment != "production" || d.URL != "https://github.com/acme/api/actions/runs/1001/job/1" || d.Fields["environment_url"] != "https://api.acme.example" {
+		t.Errorf("latest deployment = %+v", d)
+	}
+	if d := deployments[1]; d.Status != "queued" {
+		t.Errorf("deployment without statuses = %+v", d)
+	}
+	if q := srv.Requests()[0].Query; q.Get("environment") != "production" {
+		t.Errorf("environment = %q", q.Get("environment"))
+	}
+
+	deployments, _ = p.Query(context.Background(), schema.DeploymentQuery{Statuses: []string{"success"}, Scope: schema.QueryScope{Environment: "production"}})
+	if got := ids(deployments); !reflect.DeepEqual(got, []string{"deployment-501"}) {
+		t.Errorf("success ids = %v", got)
+	}
+
+	// Without deployment records, auto falls back to matching workflow runs
+	deployments, _ = p.Query(context.Background(), schema.DeploymentQuery{Scope: schema.QueryScope{Environment: "staging"}})
+	if got := ids(deployments); !reflect.DeepEqual(got, []string{"1002"}) {
+		t.Errorf("staging ids = %v", got)
+	}
+	p.config.EnvironmentSource = EnvironmentSourceDeployments
+	deployments, _ = p.Query(context.Background(), schema.DeploymentQuery{Scope: schema.QueryScope{Environment: "staging"}})
+	if len(deployments) != 0 {
+		t.Errorf("staging ids with deployments source = %v", ids(deployments))
+	}
+	// The run heuristic names the production workflow's environment "prod"
+	p.config.EnvironmentSource = EnvironmentSourceRuns
+	deployments, _ = p.Query(context.Background(), schema.DeploymentQuery{Scope: schema.QueryScope{Environment: "prod"}})
+	if got := ids(deployments); !reflect.DeepEqual(got, []string{"1001"}) {
+		t.Errorf("production ids with runs source = %v", got)
+	}
+
+	d, err := p.Get(context.Background(), "deployment-501")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if d.Status != "success" || d.Version != "a1b2c3d" {
+		t.Errorf("Get() = %+v", d)
+	}
+	if _, err := p.Get(context.Background(), "deployment-abc"); !hasCode(err, "bad_request") {
+		t.Errorf("Get() with invalid deployment ID error = %v, want bad_request", err)
+	}
+
+	if _, err := NewWithServices(map[string]any{"repository": "acme/api", "environmentSource": "guess"}, ghapi.FromClient(srv.Client())); err == nil {
+		t.Error("expected error for unknown environmentSource")
+	}
+}
+
+func TestSavedQuery(t *testing.T) {
+	srv := fakegithub.New(t)
+	p, err := NewWithServices(map[string]any{
+		"repository": "acme/api",
+		"queries": map[string]any{
+			"failed-main": map[string]any{"statuses": []any{"failed"}, "metadata": map[string]any{"branch": "main", "event": "push"}},
+		},
+	}, ghapi.FromClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("NewWithServices() error = %v", err)
+	}
+
+	deployments, err := p.Query(context.Background(), schema.DeploymentQuery{
+		Metadata: map[string]any{"savedQuery": "failed-main", "event": "workflow_dispatch"},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(deployments) != 1 || deployments[0].ID != "1002" {
+		t.Errorf("deployments = %+v", deployments)
+	}
+	q := srv.Requests()[0].Query
+	if q.Get("status") != "completed" || q.Get("branch") != "main" || q.Get("event") != "workflow_dispatch" {
+		t.Errorf("query = %v", q)
+	}
+
+	if _, err := p.Query(context.Background(), schema.DeploymentQuery{Metadata: map[string]any{"savedQuery": "missing"}}); !hasCode(err, "bad_request") {
+		t.Errorf("Query() with unknown preset error = %v, want bad_request", err)
+	}
+	if _, err := NewWithServices(map[string]any{"repository": "acme/api", "queries": []any{"x"}}, ghapi.FromClient(srv.Client())); err == nil {
+		t.Error("expected error for malformed queries")
+	}
+}
+
+func TestGet(t *testing.T) {
+	p, _ := newFakeProvider(t)
+
+	got, err := p.Get(context.Background(), "1001")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Status != "success" || got.Service != "api" || got.Environment != "prod" || got.Version != "a1b2c3d" {
+		t.Errorf("unexpected deployment: %+v", got)
+	}
+	if got.Fields["commit_message"] != "Fix connection pool sizing" || got.Actor["login"] != "alice" {
+		t.Errorf("unexpected fields: %v actor: %v", got.Fields, got.Actor)
+	}
+
+	if _, err := p.Get(context.Background(), "42"); !hasCode(err, "not_found") {
+		t.Errorf("Get(42) error = %v, want not_found", err)
+	}
+}
+
+func TestGetTerminalRunMemo(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	p.config.TerminalRunTTL = time.Minute
+	t.Cleanup(func() {
+		cache.Default.Delete(p.terminalKey("1001"))
+		cache.Default.Delete(p.terminalKey("1003"))
+	})
+	runReads := func() int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if strings.HasPrefix(r.Path, "/repos/acme/api/actions/runs/100") && strings.Count(r.Path, "/") == 6 {
+				n++
+			}
+		}
+		return n
+	}
+
+	// A finished run is read once, then answered from memory
+	first, err := p.Get(context.Background(), "1001")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	second, err := p.Get(context.Background(), "1001")
+	if err != nil || second.Status != first.Status || runReads() != 1 {
+		t.Fatalf("second Get() = %+v, %v after %d run reads", second, err, runReads())
+	}
+
+	// A webhook reporting the run active again drops it from the memo
+	p.Prime(schema.Deployment{ID: "1001", Status: "running"})
+	if _, err := p.Get(context.Background(), "1001"); err != nil || runReads() != 2 {
+		t.Errorf("Get() after a re-run made %d run reads, want 2", runReads())
+	}
+
+	// Runs still in progress are always read
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs/1003", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"id": 1003, "status": "in_progress"})
+	})
+	p.Get(context.Background(), "1003")
+	p.Get(context.Background(), "1003")
+	if runReads() != 4 {
+		t.Errorf("in-progress run reads = %d, want 2", runReads()-2)
+	}
+}
+
+func TestGetCachesCopy(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	p.config.CacheTTL = time.Minute
+	t.Cleanup(func() { cache.Default.Delete(p.cacheKey("1001")) })
+
+	first, err := p.Get(context.Background(), "1001")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, ok := first.Fields["jobs"]; !ok {
+		t.Fatalf("Get() fields = %v, want the jobs", first.Fields)
+	}
+	first.Fields["jobs"] = "changed by the caller"
+
+	// The cached deployment holds the jobs and keeps them when callers change theirs
+	second, err := p.Get(context.Background(), "1001")
+	if err != nil {
+		t.Fatalf("second Get() error = %v", err)
+	}
+	if second.Fields["jobs"] == "changed by the caller" {
+		t.Error("changing a returned deployment changed the cached one")
+	}
+	reads := 0
+	for _, r := range srv.Requests() {
+		if r.Path == "/repos/acme/api/actions/runs/1001" {
+			reads++
+		}
+	}
+	if reads != 1 {
+		t.Errorf("run read %d times, want once", reads)
+	}
+}
+
+func TestGetJobs(t *testing.T) {
+	p, srv := newFakeProvider(t)
+
+	got, err := p.Get(context.Background(), "1001")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	jobs, _ := got.Fields["jobs"].([]Job)
+	if len(jobs) != 2 {
+		t.Fatalf("jobs = %+v", got.Fields["jobs"])
+	}
+	if j := jobs[0]; j.Name != "build" || j.Status != "success" || j.SelfHosted || j.RunnerGroup != "GitHub Actions" {
+		t.Errorf("hosted job = %+v", j)
+	}
+	if j := jobs[1]; j.RunnerName != "deploy-runner-3" || !j.SelfHosted || j.RunnerGroup != "production-deployers" || !reflect.DeepEqual(j.Labels, []string{"self-hosted", "linux", "deploy"}) {
+		t.Errorf("self-hosted job = %+v", j)
+	}
+	if got.Fields["failed_runners"] != nil {
+		t.Errorf("failed_runners = %v, want none", got.Fields["failed_runners"])
+	}
+
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs/1001/jobs", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 3, "jobs": []map[string]any{
+			{"id": 1, "status": "completed", "conclusion": "failure", "runner_name": "deploy-runner-3"},
+			{"id": 2, "status": "completed", "conclusion": "failure", "runner_name": "deploy-runner-3"},
+			{"id": 3, "status": "completed", "conclusion": "success", "runner_name": "deploy-runner-1"},
+		}})
+	})
+	got, _ = p.Get(context.Background(), "1001")
+	if runners := got.Fields["failed_runners"]; !reflect.DeepEqual(runners, []string{"deploy-runner-3"}) {
+		t.Errorf("failed_runners = %v", runners)
+	}
+
+	// Jobs are best effort
+	srv.Error(http.MethodGet, "/repos/acme/api/actions/runs/1001/jobs", http.StatusForbidden, "Resource not accessible by integration")
+	got, err = p.Get(context.Background(), "1001")
+	if err != nil || got.Fields["jobs"] != nil {
+		t.Errorf("Get() with failing jobs = %v, %v", got.Fields, err)
+	}
+}
+
+func TestGetCommitPeople(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	commitTime := time.Date(2030, 1, 5, 9, 58, 0, 0, time.UTC)
+
+	// Without a resolvable login only the names are known
+	got, err := p.Get(context.Background(), "1001")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if author := got.Fields["commit_author"]; author != (CommitPerson{Name: "Bob Lee", Timestamp: commitTime}) {
+		t.Errorf("commit_author = %+v", author)
+	}
+
+	srv.Handle(http.MethodGet, "/repos/acme/api/commits/a1b2c3d4e5f60718293a4b5c6d7e8f9012345678", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+			"sha":       "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678",
+			"author":    map[string]any{"login": "bob"},
+			"committer": map[string]any{"login": "web-flow"},
+			"commit": map[string]any{
+				"verification": map[string]any{"verified": false, "reason": "unsigned"},
+			},
+		})
+	})
+	got, _ = p.Get(context.Background(), "1001")
+	if author := got.Fields["commit_author"]; author != (CommitPerson{Name: "Bob Lee", Login: "bob", Timestamp: commitTime}) {
+		t.Errorf("commit_author = %+v", author)
+	}
+	if committer := got.Fields["commit_committer"]; committer != (CommitPerson{Name: "GitHub", Login: "web-flow", Timestamp: commitTime}) {
+		t.Errorf("commit_committer = %+v", committer)
+	}
+	if got.Actor["login"] != "alice" {
+		t.Errorf("Actor = %v, want the run actor", got.Actor)
+	}
+	if v := got.Fields["commit_verification"]; v != (CommitVerification{Verified: false, Reason: "unsigned"}) {
+		t.Errorf("commit_verification = %+v", v)
+	}
+}
+
+func TestGetApprovers(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	teams, err := team.NewWithServices(map[string]any{"organization": fakegithub.Organization}, p.api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.teams = teams
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs/1002", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"id": 1002, "name": "Deploy to Production", "status": "waiting", "head_branch": "main"})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs/1002/pending_deployments", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{
+			"environment": map[string]any{"id": 7, "name": "production"},
+			"wait_timer":  5,
+			"reviewers": []map[string]any{
+				{"type": "User", "reviewer": map[string]any{"id": 3, "login": "carol"}},
+				{"type": "Team", "reviewer": map[string]any{"id": 11, "slug": "sre", "name": "SRE"}},
+			},
+		}})
+	})
+
+	got, err := p.Get(context.Background(), "1002")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	want := []PendingApproval{{
+		Environment: "production",
+		WaitTimer:   5,
+		Approvers: []Approver{
+			{Type: ApproverUser, ID: "carol"},
+			{Type: ApproverTeam, ID: "sre", Name: "SRE", Members: []string{"alice", "ghost"}},
+		},
+	}}
+	if !reflect.DeepEqual(got.Fields["pending_approvals"], want) {
+		t.Errorf("pending_approvals = %+v, want %+v", got.Fields["pending_approvals"], want)
+	}
+	if logins := got.Fields["approver_logins"]; !reflect.DeepEqual(logins, []string{"alice", "carol", "ghost"}) {
+		t.Errorf("approver_logins = %v", logins)
+	}
+
+	// Runs that are not waiting skip the lookup
+	got, _ = p.Get(context.Background(), "1001")
+	if _, ok := got.Fields["pending_approvals"]; ok {
+		t.Errorf("completed run has pending_approvals: %v", got.Fields["pending_approvals"])
+	}
+}
+
+func TestGetAnnotations(t *testing.T) {
+	p, srv := newFakeProvider(t)
+
+	got, err := p.Get(context.Background(), "1001")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	want := []Annotation{{
+		Job:       "deploy",
+		Level:     "warning",
+		Path:      "deploy/migrate.sql",
+		StartLine: 12,
+		EndLine:   12,
+		Title:     "Slow migration",
+		Message:   "ALTER TABLE on orders locks the table for the duration of the migration",
+	}}
+	if !reflect.DeepEqual(got.Fields["annotations"], want) {
+		t.Errorf("annotations = %+v", got.Fields["annotations"])
+	}
+	if summary := got.Fields["annotation_summary"]; summary != (AnnotationSummary{Warnings: 1}) {
+		t.Errorf("annotation_summary = %+v", summary)
+	}
+
+	// Errors come first and the list is capped
+	annotations := make([]map[string]any, 0, maxAnnotations+1)
+	for i := 0; i < maxAnnotations; i++ {
+		annotations = append(annotations, map[string]any{"annotation_level": "warning", "message": "deprecated input"})
+	}
+	annotations = append(annotations, map[string]any{"annotation_level": "failure", "path": "app/orders.go", "start_line": 40, "message": "TestCheckout failed"})
+	srv.Handle(http.MethodGet, "/repos/acme/api/check-runs/3001/annotations", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, annotations)
+	})
+	got, _ = p.fetch(context.Background(), 1001)
+	p.addAnnotations(context.Background(), []Job{{ID: "3001", Name: "build"}}, got.Fields)
+	list := got.Fields["annotations"].([]Annotation)
+	if len(list) != maxAnnotations || list[0].Level != "error" || list[0].Path != "app/orders.go" {
+		t.Errorf("annotations = %d, first = %+v", len(list), list[0])
+	}
+	if summary := got.Fields["annotation_summary"]; summary != (AnnotationSummary{Errors: 1, Warnings: maxAnnotations, Truncated: true}) {
+		t.Errorf("annotation_summary = %+v", summary)
+	}
+
+	// Annotations are best effort
+	srv.Error(http.MethodGet, "/repos/acme/api/check-runs/3002/annotations", http.StatusForbidden, "Resource not accessible by integration")
+	fields := map[string]any{}
+	p.addAnnotations(context.Background(), []Job{{ID: "3002", Name: "deploy"}}, fields)
+	if len(fields) != 0 {
+		t.Errorf("fields with failing annotations = %v", fields)
+	}
+}
+
+func TestGetConcurrency(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	run := func(id, workflowID int, status, branch, created string) map[string]any {
+		return map[string]any{
+			"id": id, "workflow_id": workflowID, "name": "Deploy", "status": status, "event": "push", "head_branch": branch,
+			"head_sha": "a1b2c3d", "created_at": created, "html_url": fmt.Sprintf("https://github.com/acme/api/actions/runs/%d", id),
+		}
+	}
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs/1002", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, run(1002, 7, "pending", "main", "2030-01-05T10:10:00Z"))
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs", func(w http.ResponseWriter, r *http.Request) {
+		if status := r.URL.Query().Get("status"); status != "in_progress" {
+			t.Errorf("runs listed with status %q, want in_progress", status)
+		}
+		// The blocker is a run of another workflow sharing the group
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 4, "workflow_runs": []map[string]any{
+			run(1003, 7, "in_progress", "main", "2030-01-05T10:20:00Z"),
+			run(999, 7, "in_progress", "feature", "2030-01-05T09:55:00Z"),
+			run(998, 9, "in_progress", "main", "2030-01-05T09:50:00Z"),
+			run(1000, 8, "in_progress", "main", "2030-01-05T10:00:00Z"),
+		}})
+	})
+	for id, path := range map[int]string{7: "deploy.yml", 8: "release.yml", 9: "lint.yml"} {
+		id, path := id, path
+		srv.Handle(http.MethodGet, fmt.Sprintf("/repos/acme/api/actions/workflows/%d", id), func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"id": id, "path": ".github/workflows/" + path})
+		})
+	}
+	files := map[string]string{
+		"release.yml": "concurrency: deploy-${{ github.ref }}\n",
+		"lint.yml":    "concurrency: lint-${{ github.ref }}\n",
+	}
+	for path, content := range files {
+		content := content
+		srv.Handle(http.MethodGet, "/repos/acme/api/contents/.github/workflows/"+path, func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+				"type": "file", "encoding": "base64", "content": base64.StdEncoding.EncodeToString([]byte(content)),
+			})
+		})
+	}
+	workflow := "name: Deploy\non:\n  push:\n    branches: [main]\nconcurrency:\n  group: deploy-${{ github.ref }}\n  cancel-in-progress: false\njobs:\n  deploy:\n    runs-on: ubuntu-latest\n    steps:\n      - run: ./deploy.sh\n"
+	srv.Handle(http.MethodGet, "/repos/acme/api/contents/.github/workflows/deploy.yml", func(w http.ResponseWriter, r *http.Request) {
+		if ref := r.URL.Query().Get("ref"); ref != "a1b2c3d" {
+			t.Errorf("workflow read at ref %q, want the run's commit", ref)
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+			"type": "file", "encoding": "base64", "content": base64.StdEncoding.EncodeToString([]byte(workflow)),
+		})
+	})
+
+	got, err := p.Get(context.Background(), "1002")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Fields["concurrency_group"] != "deploy-refs/heads/main" || got.Fields["cancel_in_progress"] != false {
+		t.Errorf("concurrency = %v, %v", got.Fields["concurrency_group"], got.Fields["cancel_in_progress"])
+	}
+	if got.Fields["queued_behind"] != "1000" || got.Fields["queued_behind_url"] != "https://github.com/acme/api/actions/runs/1000" {
+		t.Errorf("queued_behind = %v (%v)", got.Fields["queued_behind"], got.Fields["queued_behind_url"])
+	}
+
+	// With cancel-in-progress the waiting run replaces the earlier one instead
+	workflow = "concurrency:\n  group: deploy-${{ github.ref }}\n  cancel-in-progress: true\n"
+	got, _ = p.Get(context.Background(), "1002")
+	if got.Fields["cancels"] != "1000" || got.Fields["queued_behind"] != nil {
+		t.Errorf("cancels = %v, queued_behind = %v", got.Fields["cancels"], got.Fields["queued_behind"])
+	}
+
+	// Completed runs are not checked
+	if got, _ := p.Get(context.Background(), "1001"); got.Fields["concurrency_group"] != nil {
+		t.Errorf("completed run concurrency_group = %v", got.Fields["concurrency_group"])
+	}
+}
+
+func TestConcurrencyEvaluate(t *testing.T) {
+	push := &github.WorkflowRun{ID: github.Int64(42), Name: github.String("Deploy"), Event: github.String("push"), HeadBranch: github.String("main")}
+	pr := &github.WorkflowRun{ID: github.Int64(43), Event: github.String("pull_request"), HeadBranch: github.String("fix-pool"),
+		PullRequests: []*github.PullRequest{{Number: github.Int(12)}}}
+
+	tests := []struct {
+		group        string
+		run          *github.WorkflowRun
+		want         string
+		wantResolved bool
+	}{
+		{"${{ github.workflow }}-${{ github.ref }}", push, "Deploy-refs/heads/main", true},
+		{"ci-${{ github.head_ref || github.run_id }}", push, "ci-42", true},
+		{"ci-${{ github.head_ref || github.run_id }}", pr, "ci-fix-pool", true},
+		{"${{ github.ref_name }}", pr, "12/merge", true},
+		{"${{ github.repository }}-${{ 'prod' }}", push, "acme/api-prod", true},
+		{"deploy-${{ inputs.environment }}", push, "deploy-${{ inputs.environment }}", false},
+	}
+	for _, tt := range tests {
+		ref := func() (string, bool) { return "refs/heads/main", true }
+		got, resolved := (&concurrency{Group: tt.group}).evaluate("acme/api", tt.run, ref)
+		if got != tt.want || resolved != tt.wantResolved {
+			t.Errorf("evaluate(%q) = %q, %v, want %q, %v", tt.group, got, resolved, tt.want, tt.wantResolved)
+		}
+	}
+}
+
+func TestRunRefs(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api/git/ref/tags/v1.2.0", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"ref": "refs/tags/v1.2.0", "object": map[string]any{"sha": "a1b2c3d"}})
+	})
+	run := func(event, branch string) *github.WorkflowRun {
+		return &github.WorkflowRun{Event: github.String(event), HeadBranch: github.String(branch)}
+	}
+
+	refs := p.newRunRefs(context.Background())
+	tests := []struct {
+		run  *github.WorkflowRun
+		want string
+	}{
+		{run("push", "v1.2.0"), "refs/tags/v1.2.0"},
+		{run("workflow_dispatch", "v1.2.0"), "refs/tags/v1.2.0"},
+		{run("push", "main"), "refs/heads/main"},
+		{run("release", "v2.0.0"), "refs/tags/v2.0.0"},
+		{&github.WorkflowRun{Event: github.String("pull_request"), PullRequests: []*github.PullRequest{{Number: github.Int(12)}}}, "refs/pull/12/merge"},
+	}
+	for _, tt := range tests {
+		if got, ok := refs.ref(tt.run); !ok || got != tt.want {
+			t.Errorf("ref(%s %s) = %q, %v, want %q", tt.run.GetEvent(), tt.run.GetHeadBranch(), got, ok, tt.want)
+		}
+	}
+	// Each name is looked up once
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestTrigger(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	path := "/repos/acme/api/actions/workflows/deploy.yml/dispatches"
+	srv.Handle(http.MethodPost, path, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	input := TriggerInput{Workflow: "deploy.yml", Ref: "main", Inputs: map[string]any{"environment": "prod"}}
+	if result, err := p.Trigger(context.Background(), input); err != nil || !result.Dispatched {
+		t.Fatalf("Trigger() = %+v, %v", result, err)
+	}
+
+	reqs := srv.Requests()
+	last := reqs[len(reqs)-1]
+	var body map[string]any
+	if err := json.Unmarshal(last.Body, &body); err != nil {
+		t.Fatalf("dispatch body: %v", err)
+	}
+	if last.Path != path || body["ref"] != "main" || body["inputs"].(map[string]any)["environment"] != "prod" {
+		t.Errorf("unexpected dispatch %s %s", last.Path, last.Body)
+	}
+
+	if _, err := p.Trigger(context.Background(), TriggerInput{Workflow: "deploy.yml"}); !hasCode(err, "bad_request") {
+		t.Errorf("Trigger() without ref error = %v, want bad_request", err)
+	}
+	srv.Error(http.MethodPost, path, http.StatusUnprocessableEntity, "Workflow does not have 'workflow_dispatch' trigger")
+	if _, err := p.Trigger(context.Background(), input); !hasCode(err, "bad_request") {
+		t.Errorf("Trigger() error = %v, want bad_request", err)
+	}
+}
+
+func TestTriggerAuditLog(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodPost, "/repos/acme/api/actions/workflows/deploy.yml/dispatches", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	var err error
+	if p.config.Audit, err = audit.Parse(map[string]any{"auditLog": path}); err != nil {
+		t.Fatalf("audit.Parse() error = %v", err)
+	}
+
+	if _, err := p.Trigger(context.Background(), TriggerInput{Workflow: "deploy.yml", Ref: "main"}); err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading audit log: %v", err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("audit log %q: %v", data, err)
+	}
+	if entry["operation"] != "deployment.trigger" || entry["target"] != "deploy.yml" || entry["url"] != "https://github.com/acme/api/actions/workflows/deploy.yml" {
+		t.Errorf("audit entry = %v", entry)
+	}
+	if input, _ := entry["input"].(map[string]any); input["ref"] != "main" {
+		t.Errorf("audit entry input = %v", entry["input"])
+	}
+}
+
+func TestTriggerScheduled(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	path := "/repos/acme/api/actions/workflows/deploy.yml/dispatches"
+	dispatched := make(chan struct{}, 1)
+	srv.Handle(http.MethodPost, path, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+		dispatched <- struct{}{}
+	})
+
+	at := time.Now().Add(50 * time.Millisecond)
+	result, err := p.Trigger(context.Background(), TriggerInput{Workflow: "deploy.yml", Ref: "main", At: at})
+	if err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	if result.Dispatched || !result.ScheduledAt.Equal(at) {
+		t.Errorf("Trigger() = %+v, want scheduled at %v", result, at)
+	}
+	if len(srv.Requests()) != 0 {
+		t.Error("scheduled trigger dispatched immediately")
+	}
+
+	select {
+	case <-dispatched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled dispatch did not fire")
+	}
+}
+
+func TestTriggerDryRun(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	input := TriggerInput{Workflow: "deploy.yml", Ref: "main", Metadata: map[string]any{"dryRun": true}}
+
+	result, err := p.Trigger(context.Background(), input)
+	if err != nil || !result.DryRun || result.Dispatched {
+		t.Errorf("Trigger() with metadata dryRun = %+v, %v", result, err)
+	}
+
+	// readOnly simulates every dispatch, scheduled ones included
+	p.config.ReadOnly = true
+	input.Metadata = nil
+	input.At = time.Now().Add(time.Hour)
+	result, err = p.Trigger(context.Background(), input)
+	if err != nil || !result.DryRun || result.ScheduleID != "" || len(p.Pending()) != 0 {
+		t.Errorf("Trigger() under readOnly = %+v, %v with %d pending", result, err, len(p.Pending()))
+	}
+	if !p.Capabilities().DryRun {
+		t.Error("Capabilities().DryRun = false under readOnly")
+	}
+	if len(srv.Requests()) != 0 {
+		t.Errorf("dry run made %d requests", len(srv.Requests()))
+	}
+
+	// The change freeze is still checked
+	p.config.ChangeFreeze = ChangeFreeze{File: ".github/FREEZE"}
+	srv.Handle(http.MethodGet, "/repos/acme/api/contents/.github/FREEZE", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"type": "file", "name": "FREEZE", "path": ".github/FREEZE"})
+	})
+	if _, err := p.Trigger(context.Background(), input); !hasCode(err, "change_freeze") {
+		t.Errorf("dry-run Trigger() during a freeze error = %v, want change_freeze", err)
+	}
+}
+
+func TestTriggerScheduledPersisted(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	p.config.ScheduleDir = t.TempDir()
+	path := "/repos/acme/api/actions/workflows/deploy.yml/dispatches"
+	dispatched := make(chan struct{}, 1)
+	srv.Handle(http.MethodPost, path, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+		dispatched <- struct{}{}
+	})
+
+	result, err := p.Trigger(context.Background(), TriggerInput{Workflow: "deploy.yml", Ref: "main", At: time.Now().Add(time.Hour)})
+	if err != nil || !result.Persisted || result.ScheduleID == "" {
+		t.Fatalf("Trigger() = %+v, %v, want a persisted schedule", result, err)
+	}
+	if _, err := os.Stat(p.schedulePath(result.ScheduleID)); err != nil {
+		t.Errorf("schedule file: %v", err)
+	}
+	if pending := p.Pending(); len(pending) != 1 || pending[0].ID != result.ScheduleID || !pending[0].Persisted {
+		t.Errorf("Pending() = %+v", pending)
+	}
+
+	// A dispatch persisted by a process that exited before it was due fires
+	// as soon as a new provider recovers it
+	missed := ScheduledDispatch{ID: "missed", Owner: "acme", Repo: "api", Input: TriggerInput{Workflow: "deploy.yml", Ref: "main", At: time.Now().Add(-time.Minute)}}
+	restarted, _ := newFakeProvider(t)
+	restarted.api = p.api
+	restarted.config.ScheduleDir = p.config.ScheduleDir
+	if err := restarted.persistSchedule(&missed); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.recoverSchedules(); err != nil {
+		t.Fatalf("recoverSchedules() error = %v", err)
+	}
+	select {
+	case <-dispatched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("recovered dispatch did not fire")
+	}
+	// The dispatch armed in this process is not armed again
+	if pending := restarted.Pending(); len(pending) != 0 {
+		t.Errorf("Pending() after recovery = %+v", pending)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(restarted.schedulePath("missed")); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("fired dispatch left its schedule file")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTriggerEnvironmentProtection(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodPost, "/repos/acme/api/actions/workflows/deploy.yml/dispatches", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/environments/production", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"name": "production", "deployment_branch_policy": map[string]any{"protected_branches": false, "custom_branch_policies": true}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/environments/production/deployment-branch-policies", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 1, "branch_policies": []map[string]any{{"name": "release/*", "type": "branch"}}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/environments/canary", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"name": "canary", "deployment_branch_policy": map[string]any{"protected_branches": true, "custom_branch_policies": false}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/branches/main", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"name": "main", "protected": false})
+	})
+	p.config.ChangeFreeze = ChangeFreeze{Protection: true}
+	trigger := func(environment, ref string) (TriggerResult, error) {
+		return p.Trigger(context.Background(), TriggerInput{Workflow: "deploy.yml", Ref: ref, Inputs: map[string]any{"environment": environment}})
+	}
+
+	_, err := trigger("production", "main")
+	var freeze *ChangeFreezeError
+	if !hasCode(err, "change_freeze") || !errors.As(err, &freeze) || freeze.Reasons[0] != `environment "production" does not admit main` {
+		t.Fatalf("Trigger() error = %v, want change_freeze", err)
+	}
+	if result, err := trigger("production", "refs/heads/release/2.1"); err != nil || !result.Dispatched {
+		t.Errorf("Trigger() of an admitted branch = %+v, %v", result, err)
+	}
+	if _, err := trigger("canary", "main"); !hasCode(err, "change_freeze") {
+		t.Errorf("Trigger() of an unprotected branch error = %v, want change_freeze", err)
+	}
+	// An environment that does not exist has no policy to freeze it
+	if result, err := trigger("staging", "main"); err != nil || !result.Dispatched {
+		t.Errorf("Trigger() for staging = %+v, %v", result, err)
+	}
+	// Environments limit the signal like the others
+	p.config.ChangeFreeze.Environments = []string{"staging"}
+	if result, err := trigger("production", "main"); err != nil || !result.Dispatched {
+		t.Errorf("Trigger() outside the freeze's environments = %+v, %v", result, err)
+	}
+}
+
+func TestTriggerChangeFreeze(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodPost, "/repos/acme/api/actions/workflows/deploy.yml/dispatches", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/issues", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("labels") != "change-freeze" || r.URL.Query().Get("state") != "open" {
+			t.Errorf("issues query = %s", r.URL.RawQuery)
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"number": 42, "title": "Holiday freeze"}})
+	})
+	p.config.ChangeFreeze = ChangeFreeze{Label: "change-freeze", File: ".github/FREEZE", Environments: []string{"production"}}
+	production := TriggerInput{Workflow: "deploy.yml", Ref: "main", Inputs: map[string]any{"environment": "production"}}
+
+	_, err := p.Trigger(context.Background(), production)
+	var freeze *ChangeFreezeError
+	if !hasCode(err, "change_freeze") || !errors.As(err, &freeze) {
+		t.Fatalf("Trigger() error = %v, want change_freeze", err)
+	}
+	if len(freeze.Reasons) != 1 || freeze.Reasons[0] != `#42 is labeled "change-freeze"` || freeze.Overridable {
+		t.Errorf("freeze = %+v", freeze)
+	}
+
+	// Overrides are refused unless the freeze allows them
+	production.OverrideFreeze = true
+	if _, err := p.Trigger(context.Background(), production); !hasCode(err, "change_freeze") {
+		t.Errorf("Trigger() with a refused override error = %v, want change_freeze", err)
+	}
+	p.config.ChangeFreeze.AllowOverride = true
+	if result, err := p.Trigger(context.Background(), production); err != nil || !result.Dispatched {
+		t.Errorf("Trigger() with an override = %+v, %v", result, err)
+	}
+
+	// Environments outside the freeze are not checked
+	staging := TriggerInput{Workflow: "deploy.yml", Ref: "main", Inputs: map[string]any{"environment": "staging"}}
+	if result, err := p.Trigger(context.Background(), staging); err != nil || !result.Dispatched {
+		t.Errorf("Trigger() for staging = %+v, %v", result, err)
+	}
+
+	// The freeze file counts when present
+	p.config.ChangeFreeze = ChangeFreeze{File: ".github/FREEZE"}
+	if _, err := p.Trigger(context.Background(), staging); err != nil {
+		t.Errorf("Trigger() without a freeze file error = %v", err)
+	}
+	srv.Handle(http.MethodGet, "/repos/acme/api/contents/.github/FREEZE", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"type": "file", "name": "FREEZE", "path": ".github/FREEZE"})
+	})
+	if err := p.CheckChangeFreeze(context.Background(), staging); !hasCode(err, "change_freeze") {
+		t.Errorf("CheckChangeFreeze() with a freeze file error = %v, want change_freeze", err)
+	}
+
+	if _, err := NewWithServices(map[string]any{"repository": "acme/api", "changeFreeze": "friday"}, p.api); err == nil {
+		t.Error("expected error for a malformed changeFreeze")
+	}
+}
+
+func TestFreezeCalendar(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodPost, "/repos/acme/api/actions/workflows/deploy.yml/dispatches", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	now := time.Now().UTC().Truncate(time.Second)
+	calendar := fmt.Sprintf(`freezes:
+  - name: Incident review
+    start: %s
+    end: %s
+    environments: [production]
+  - name: Holidays
+    start: %s
+    end: %s
+    reason: End-of-year freeze
+  - name: Last quarter
+    start: 2020-10-01
+    end: 2020-10-02
+`, now.Add(-time.Hour).Format(time.RFC3339), now.Add(time.Hour).Format(time.RFC3339),
+		now.Add(48*time.Hour).Format(time.RFC3339), now.Add(72*time.Hour).Format(time.RFC3339))
+	srv.Handle(http.MethodGet, "/repos/acme/config/contents/.opsorch/freezes.yml", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+			"type": "file", "encoding": "base64", "content": base64.StdEncoding.EncodeToString([]byte(calendar)),
+		})
+	})
+
+	var err error
+	if p.config.ChangeFreeze, err = parseChangeFreeze(map[string]any{"changeFreeze": map[string]any{
+		"calendar": ".opsorch/freezes.yml", "calendarRepository": "config",
+	}}, "acme"); err != nil {
+		t.Fatalf("parseChangeFreeze() error = %v", err)
+	}
+
+	windows, err := p.Freezes(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Freezes() error = %v", err)
+	}
+	if len(windows) != 2 || windows[0].Name != "Incident review" || !windows[0].Active || windows[1].Name != "Holidays" || windows[1].Active {
+		t.Fatalf("Freezes() = %+v", windows)
+	}
+	if windows[1].Reason != "End-of-year freeze" || !windows[1].Start.Equal(now.Add(48*time.Hour)) {
+		t.Errorf("upcoming window = %+v", windows[1])
+	}
+	if windows, _ := p.Freezes(context.Background(), "staging"); len(windows) != 1 || windows[0].Name != "Holidays" {
+		t.Errorf("Freezes(staging) = %+v", windows)
+	}
+
+	// The active window blocks production only
+	production := TriggerInput{Workflow: "deploy.yml", Ref: "main", Inputs: map[string]any{"environment": "production"}}
+	if _, err := p.Trigger(context.Background(), production); !hasCode(err, "change_freeze") {
+		t.Errorf("Trigger() during a freeze window error = %v, want change_freeze", err)
+	}
+	staging := TriggerInput{Workflow: "deploy.yml", Ref: "main", Inputs: map[string]any{"environment": "staging"}}
+	if result, err := p.Trigger(context.Background(), staging); err != nil || !result.Dispatched {
+		t.Errorf("Trigger() for staging = %+v, %v", result, err)
+	}
+
+	// A dispatch scheduled into an upcoming window is refused up front
+	staging.At = now.Add(50 * time.Hour)
+	if _, err := p.Trigger(context.Background(), staging); !hasCode(err, "change_freeze") {
+		t.Errorf("Trigger() scheduled into a freeze window error = %v, want change_freeze", err)
+	}
+
+	// A missing calendar has no windows
+	p.config.ChangeFreeze.Calendar = ".opsorch/missing.yml"
+	if windows, err := p.Freezes(context.Background(), ""); err != nil || len(windows) != 0 {
+		t.Errorf("Freezes() without a calendar file = %+v, %v", windows, err)
+	}
+}
+
+func TestParseCalendar(t *testing.T) {
+	windows, err := parseCalendar([]byte("- name: Launch\n  start: 2024-12-20\n  end: 2024-12-20\n  environments: production\n"))
+	if err != nil {
+		t.Fatalf("parseCalendar() error = %v", err)
+	}
+	want := FreezeWindow{
+		Name:         "Launch",
+		Start:        time.Date(2024, 12, 20, 0, 0, 0, 0, time.UTC),
+		End:          time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC),
+		Environments: []string{"production"},
+	}
+	if len(windows) != 1 || !reflect.DeepEqual(windows[0], want) {
+		t.Errorf("parseCalendar() = %+v, want %+v", windows, want)
+	}
+
+	for _, doc := range []string{
+		"freezes: soon\n",
+		"- name: Backwards\n  start: 2024-12-21\n  end: 2024-12-19\n",
+		"- name: Unbounded\n  start: 2024-12-21\n",
+		"- name: Vague\n  start: next week\n  end: 2024-12-19\n",
+	} {
+		if _, err := parseCalendar([]byte(doc)); err == nil {
+			t.Errorf("parseCalendar(%q) succeeded, want error", doc)
+		}
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	old := time.Now().Add(-60 * 24 * time.Hour).Format(time.RFC3339)
+	recent := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/artifacts", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 3, "artifacts": []map[string]any{
+			{"id": 11, "name": "build-output", "size_in_bytes": 1000, "created_at": old},
+			{"id": 12, "name": "coverage", "size_in_bytes": 500, "created_at": recent},
+			{"id": 13, "name": "logs", "size_in_bytes": 800, "created_at": old, "expired": true},
+		}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/caches", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 2, "actions_caches": []map[string]any{
+			{"id": 21, "key": "go-mod-abc", "size_in_bytes": 2000, "last_accessed_at": old, "created_at": old},
+			{"id": 22, "key": "go-mod-def", "size_in_bytes": 3000, "last_accessed_at": recent, "created_at": old},
+		}})
+	})
+	srv.Handle(http.MethodDelete, "/repos/acme/api/actions/artifacts/11", fakegithub.NoContent)
+	srv.Error(http.MethodDelete, "/repos/acme/api/actions/caches/21", http.StatusForbidden, "Resource not accessible by integration")
+
+	result, err := p.Cleanup(context.Background(), CleanupInput{OlderThan: 30 * 24 * time.Hour, DryRun: true})
+	if err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	if len(result.Artifacts) != 1 || result.Artifacts[0].ID != "11" || len(result.Caches) != 1 || result.Caches[0].Name != "go-mod-abc" || result.FreedBytes != 3000 {
+		t.Errorf("dry run = %+v", result)
+	}
+	for _, r := range srv.Requests() {
+		if r.Method == http.MethodDelete {
+			t.Errorf("dry run deleted %s", r.Path)
+		}
+	}
+
+	result, err = p.Cleanup(context.Background(), CleanupInput{OlderThan: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	if len(result.Artifacts) != 1 || len(result.Caches) != 0 || result.FreedBytes != 1000 || len(result.Errors) != 1 {
+		t.Errorf("cleanup = %+v", result)
+	}
+
+	result, _ = p.Cleanup(context.Background(), CleanupInput{Caches: true, OlderThan: 30 * 24 * time.Hour, DryRun: true})
+	if len(result.Artifacts) != 0 || len(result.Caches) != 1 {
+		t.Errorf("caches-only cleanup = %+v", result)
+	}
+
+	if _, err := p.Cleanup(context.Background(), CleanupInput{Artifacts: true}); !hasCode(err, "bad_request") {
+		t.Errorf("Cleanup() without olderThan error = %v, want bad_request", err)
+	}
+
+	// readOnly turns every cleanup into a dry run
+	p.config.ReadOnly = true
+	deletes := func() int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if r.Method == http.MethodDelete {
+				n++
+			}
+		}
+		return n
+	}
+	before := deletes()
+	result, err = p.Cleanup(context.Background(), CleanupInput{OlderThan: 30 * 24 * time.Hour})
+	if err != nil || !result.DryRun || len(result.Artifacts) != 1 || deletes() != before {
+		t.Errorf("Cleanup() under readOnly = %+v, %v after %d deletes", result, err, deletes()-before)
+	}
+}
+
+func TestInventory(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/secrets", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 1, "secrets": []map[string]any{
+			{"name": "NPM_TOKEN", "created_at": "2029-06-01T00:00:00Z", "updated_at": "2029-06-01T00:00:00Z"},
+		}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/variables", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 1, "variables": []map[string]any{
+			{"name": "REGION", "value": "us-east-1", "created_at": "2029-01-01T00:00:00Z", "updated_at": "2029-12-01T00:00:00Z"},
+		}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"id": 99, "name": "api"})
+	})
+	srv.Handle(http.MethodGet, "/repositories/99/environments/production/secrets", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 1, "secrets": []map[string]any{
+			{"name": "PROD_DB_URL", "created_at": "2029-01-01T00:00:00Z", "updated_at": "2030-01-05T09:45:00Z"},
+		}})
+	})
+	srv.Handle(http.MethodGet, "/repositories/99/environments/production/variables", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 0, "variables": []any{}})
+	})
+
+	entries, err := p.Inventory(context.Background(), "production")
+	if err != nil {
+		t.Fatalf("Inventory() error = %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Kind+":"+e.Environment+":"+e.Name)
+	}
+	if want := []string{"secret:production:PROD_DB_URL", "variable::REGION", "secret::NPM_TOKEN"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("entries = %v, want %v", names, want)
+	}
+	if data, _ := json.Marshal(entries); bytes.Contains(data, []byte("us-east-1")) {
+		t.Errorf("inventory leaks a variable value: %s", data)
+	}
+
+	entries, err = p.Inventory(context.Background(), "")
+	if err != nil || len(entries) != 2 {
+		t.Errorf("repository Inventory() = %v, %v", entries, err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 4, "workflow_runs": []map[string]any{
+			{"id": 1, "name": "Deploy prod", "status": "completed", "conclusion": "success", "created_at": "2024-03-01T10:00:00Z", "updated_at": "2024-03-01T10:05:00Z"},
+			{"id": 2, "name": "Deploy prod", "status": "completed", "conclusion": "failure", "created_at": "2024-03-02T10:00:00Z", "updated_at": "2024-03-02T10:15:00Z"},
+			{"id": 3, "name": "Deploy prod", "status": "completed", "conclusion": "cancelled", "created_at": "2024-03-03T10:00:00Z", "updated_at": "2024-03-03T10:01:00Z"},
+			{"id": 4, "name": "Deploy staging", "status": "completed", "conclusion": "success", "created_at": "2024-03-04T10:00:00Z", "updated_at": "2024-03-04T10:02:00Z"},
+		}})
+	})
+
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	input := StatsInput{Since: since, Until: since.AddDate(0, 0, 10)}
+	p.config.EnvironmentSource = EnvironmentSourceRuns
+	stats, err := p.Stats(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if stats.Source != EnvironmentSourceRuns || len(stats.Environments) != 2 || stats.Sampled {
+		t.Fatalf("stats = %+v", stats)
+	}
+	// Cancelled runs are not deployments
+	prod := stats.Environments[0]
+	if prod.Environment != "prod" || prod.Deployments != 2 || prod.Failed != 1 || prod.ChangeFailureRate != 0.5 || prod.DeploymentsPerDay != 0.2 {
+		t.Errorf("prod = %+v", prod)
+	}
+	if prod.MedianDurationSeconds == nil || *prod.MedianDurationSeconds != 600 {
+		t.Errorf("prod MedianDurationSeconds = %v, want 600", prod.MedianDurationSeconds)
+	}
+	if q := srv.Requests()[0].Query; q.Get("created") != "2024-03-01T00:00:00Z..2024-03-11T00:00:00Z" || q.Get("status") != "completed" {
+		t.Errorf("runs query = %v", q)
+	}
+
+	stats, _ = p.Stats(context.Background(), StatsInput{Since: since, Until: input.Until, Environment: "staging"})
+	if len(stats.Environments) != 1 || stats.Environments[0].Environment != "staging" || stats.Environments[0].ChangeFailureRate != 0 {
+		t.Errorf("staging stats = %+v", stats)
+	}
+
+	// Deployments API records are read newest first until one predates the window
+	srv.Handle(http.MethodGet, "/repos/acme/api/deployments", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"id": 12, "environment": "production", "created_at": "2024-03-05T10:00:00Z"},
+			{"id": 11, "environment": "production", "created_at": "2024-03-02T10:00:00Z"},
+			{"id": 10, "environment": "production", "created_at": "2024-02-20T10:00:00Z"},
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/deployments/12/statuses", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"state": "failure", "updated_at": "2024-03-05T10:20:00Z"}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/deployments/11/statuses", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"state": "success", "updated_at": "2024-03-02T10:10:00Z"}})
+	})
+	p.config.EnvironmentSource = EnvironmentSourceAuto
+	stats, err = p.Stats(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if stats.Source != EnvironmentSourceDeployments || len(stats.Environments) != 1 {
+		t.Fatalf("deployments stats = %+v", stats)
+	}
+	if env := stats.Environments[0]; env.Environment != "production" || env.Deployments != 2 || env.Failed != 1 || *env.MedianDurationSeconds != 900 {
+		t.Errorf("production = %+v", env)
+	}
+	for _, r := range srv.Requests() {
+		if r.Path == "/repos/acme/api/deployments/10/statuses" {
+			t.Error("read the status of a deployment before the window")
+		}
+	}
+
+	if _, err := p.Stats(context.Background(), StatsInput{Since: since, Until: since}); !hasCode(err, "bad_request") {
+		t.Errorf("Stats() with empty window error = %v, want bad_request", err)
+	}
+}
+
+func TestFailures(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("status") != "failure" || q.Get("created") != "2024-03-01T00:00:00Z..2024-03-08T00:00:00Z" {
+			t.Errorf("runs query = %v", q)
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 4, "workflow_runs": []map[string]any{
+			{"id": 4, "name": "Deploy", "html_url": "https://github.com/acme/api/actions/runs/4", "created_at": "2024-03-04T10:00:00Z"},
+			{"id": 3, "name": "Deploy", "created_at": "2024-03-03T10:00:00Z"},
+			{"id": 2, "name": "Deploy", "created_at": "2024-03-02T10:00:00Z"},
+			{"id": 1, "name": "Lint", "created_at": "2024-03-01T10:00:00Z"},
+		}})
+	})
+	failedJob := func(id int) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 2, "jobs": []map[string]any{
+				{"id": 10, "name": "build", "conclusion": "success"},
+				{"id": id, "name": "deploy", "conclusion": "failure", "html_url": fmt.Sprintf("https://github.com/acme/api/actions/runs/x/job/%d", id), "steps": []map[string]any{
+					{"name": "Checkout", "conclusion": "success"},
+					{"name": "Rollout", "conclusion": "failure"},
+				}},
+			}})
+		}
+	}
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs/3/jobs", failedJob(31))
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs/2/jobs", failedJob(21))
+	srv.Handle(http.MethodGet, "/repos/acme/api/check-runs/31/annotations", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"annotation_level": "failure", "message": "Timed out after 300s waiting for \"web-7f9c\"\nretrying"}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/check-runs/21/annotations", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"annotation_level": "warning", "message": "deprecated"},
+			{"annotation_level": "failure", "message": "Timed out after 600s waiting for \"web-a1b2\""},
+		})
+	})
+	// Run 4 failed before any job ran
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs/4/jobs", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 0, "jobs": []any{}})
+	})
+
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	result, err := p.Failures(context.Background(), FailureInput{Since: since, Until: since.AddDate(0, 0, 7), Workflow: "deploy"})
+	if err != nil {
+		t.Fatalf("Failures() error = %v", err)
+	}
+	if result.Runs != 3 || len(result.Clusters) != 2 || result.Sampled {
+		t.Fatalf("result = %+v", result)
+	}
+	c := result.Clusters[0]
+	if c.Workflow != "Deploy" || c.Job != "deploy" || c.Step != "Rollout" || c.Count != 2 || c.Signature != `timed out after <n>s waiting for "…"` {
+		t.Errorf("cluster = %+v", c)
+	}
+	if !reflect.DeepEqual(c.Runs, []string{"3", "2"}) || c.Examples[0] != "https://github.com/acme/api/actions/runs/x/job/31" || !c.LastSeen.After(c.FirstSeen) {
+		t.Errorf("cluster runs = %+v", c)
+	}
+	if c := result.Clusters[1]; c.Job != "" || c.Count != 1 || c.Examples[0] != "https://github.com/acme/api/actions/runs/4" {
+		t.Errorf("jobless cluster = %+v", c)
+	}
+
+	result, _ = p.Failures(context.Background(), FailureInput{Since: since, Until: since.AddDate(0, 0, 7), Limit: 1})
+	if result.Runs != 1 || !result.Sampled {
+		t.Errorf("limited result = %+v", result)
+	}
+	if _, err := p.Failures(context.Background(), FailureInput{Since: since, Until: since}); !hasCode(err, "bad_request") {
+		t.Errorf("Failures() with empty window error = %v, want bad_request", err)
+	}
+}
+
+func TestFlakiness(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("status") != "completed" || q.Get("created") != "2024-03-01T00:00:00Z..2024-03-31T00:00:00Z" {
+			t.Errorf("runs query = %v", q)
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 5, "workflow_runs": []map[string]any{
+			{"id": 5, "name": "Deploy", "conclusion": "success", "run_attempt": 2, "html_url": "https://github.com/acme/api/actions/runs/5"},
+			{"id": 4, "name": "Deploy", "conclusion": "success", "run_attempt": 3},
+			{"id": 3, "name": "Deploy", "conclusion": "failure", "run_attempt": 2},
+			{"id": 2, "name": "Deploy", "conclusion": "success", "run_attempt": 1},
+			{"id": 1, "name": "Lint", "conclusion": "success", "run_attempt": 1},
+		}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs/5/attempts/1", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"id": 5, "conclusion": "failure", "run_attempt": 1})
+	})
+	// Run 4 was re-run after a cancellation, which is no flip
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs/4/attempts/2", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"id": 4, "conclusion": "cancelled", "run_attempt": 2})
+	})
+
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	input := FlakinessInput{Since: since, Until: since.AddDate(0, 0, 30), MinRuns: 4}
+	result, err := p.Flakiness(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Flakiness() error = %v", err)
+	}
+	if result.Threshold != 0.1 || len(result.Workflows) != 2 || result.Sampled {
+		t.Fatalf("result = %+v", result)
+	}
+	want := WorkflowFlakiness{Workflow: "Deploy", Runs: 4, Retried: 3, Flips: 1, Score: 0.25, Flaky: true, Examples: []string{"https://github.com/acme/api/actions/runs/5"}}
+	if !reflect.DeepEqual(result.Workflows[0], want) {
+		t.Errorf("workflow = %+v, want %+v", result.Workflows[0], want)
+	}
+	if w := result.Workflows[1]; w.Workflow != "Lint" || w.Flaky || w.Score != 0 {
+		t.Errorf("workflow = %+v", w)
+	}
+
+	// Too few runs to flag, however high the score
+	input.Workflow, input.MinRuns, input.Threshold = "deploy", 5, 0.2
+	result, _ = p.Flakiness(context.Background(), input)
+	if len(result.Workflows) != 1 || result.Workflows[0].Flaky {
+		t.Errorf("filtered result = %+v", result)
+	}
+
+	for _, input := range []FlakinessInput{{Since: since, Until: since}, {Threshold: 2}, {MinRuns: -1}} {
+		if _, err := p.Flakiness(context.Background(), input); !hasCode(err, "bad_request") {
+			t.Errorf("Flakiness(%+v) error = %v, want bad_request", input, err)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	p.config.StatusTTL = time.Minute
+	p.config.Repo = "status-board"
+	srv.Handle(http.MethodGet, "/repos/acme/status-board/deployments", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("environment") == "qa" {
+			fakegithub.WriteJSON(w, http.StatusOK, []any{})
+			return
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"id": 3, "environment": "staging", "sha": "cccccccccc", "ref": "main", "creator": map[string]any{"login": "bob"}, "created_at": "2024-03-05T10:00:00Z"},
+			{"id": 2, "environment": "production", "sha": "bbbbbbbbbb", "ref": "v1.2.0", "creator": map[string]any{"login": "alice"}, "created_at": "2024-03-04T10:00:00Z"},
+			{"id": 1, "environment": "production", "sha": "aaaaaaaaaa", "created_at": "2024-03-01T10:00:00Z"},
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/status-board/deployments/2/statuses", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"state": "success", "updated_at": "2024-03-04T10:05:00Z", "log_url": "https://github.com/acme/api/actions/runs/2", "environment_url": "https://acme.example"}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/status-board/deployments/3/statuses", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []any{})
+	})
+
+	board, err := p.Status(context.Background(), StatusInput{})
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if board.Repository != "acme/status-board" || len(board.Environments) != 2 {
+		t.Fatalf("board = %+v", board)
+	}
+	want := EnvironmentStatus{
+		Environment:    "production",
+		Status:         "success",
+		Version:        "bbbbbbb",
+		Ref:            "v1.2.0",
+		Actor:          "alice",
+		At:             time.Date(2024, 3, 4, 10, 5, 0, 0, time.UTC),
+		URL:            "https://github.com/acme/api/actions/runs/2",
+		EnvironmentURL: "https://acme.example",
+		Deployment:     "deployment-2",
+	}
+	if got := board.Environments[0]; !reflect.DeepEqual(got, want) {
+		t.Errorf("production = %+v, want %+v", got, want)
+	}
+	if staging := board.Environments[1]; staging.Status != "queued" || staging.Actor != "bob" {
+		t.Errorf("staging = %+v", staging)
+	}
+
+	// A second read comes from memory
+	calls := len(srv.Requests())
+	if _, err := p.Status(context.Background(), StatusInput{}); err != nil || len(srv.Requests()) != calls {
+		t.Errorf("Status() again = %v, calls %d -> %d", err, calls, len(srv.Requests()))
+	}
+
+	board, err = p.Status(context.Background(), StatusInput{Environments: []string{"qa"}})
+	if err != nil || len(board.Environments) != 1 || board.Environments[0] != (EnvironmentStatus{Environment: "qa"}) {
+		t.Errorf("Status(qa) = %+v, %v", board, err)
+	}
+}
+
+func TestDeploymentOf(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/lib/actions/runs", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("head_sha") != "abc123" || q.Get("status") != "success" {
+			t.Errorf("runs query = %v", q)
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 2, "workflow_runs": []map[string]any{
+			{"id": 2, "name": "Deploy prod", "status": "completed", "conclusion": "success", "created_at": "2024-03-01T11:00:00Z", "updated_at": "2024-03-01T11:10:00Z"},
+			{"id": 1, "name": "Deploy prod", "status": "completed", "conclusion": "success", "created_at": "2024-03-01T10:00:00Z", "updated_at": "2024-03-01T10:10:00Z"},
+		}})
+	})
+
+	// Other repositories are read from workflow runs; the earliest finish wins
+	d, ok, err := p.DeploymentOf(context.Background(), "acme", "lib", "abc123", "")
+	if err != nil || !ok || d.ID != "acme/lib#1" {
+		t.Errorf("DeploymentOf() = %+v, %v, %v", d, ok, err)
+	}
+	if _, ok, _ := p.DeploymentOf(context.Background(), "acme", "lib", "abc123", "staging"); ok {
+		t.Error("DeploymentOf() matched a run outside the environment")
+	}
+
+	srv.Handle(http.MethodGet, "/repos/acme/api/deployments", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sha") != "def456" {
+			fakegithub.WriteJSON(w, http.StatusOK, []any{})
+			return
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"id": 21, "environment": "production", "created_at": "2024-03-02T10:00:00Z"},
+			{"id": 20, "environment": "production", "created_at": "2024-03-02T09:00:00Z"},
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/deployments/21/statuses", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"state": "success", "updated_at": "2024-03-02T10:05:00Z"}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/deployments/20/statuses", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"state": "failure", "updated_at": "2024-03-02T09:05:00Z"}})
+	})
+	d, ok, err = p.DeploymentOf(context.Background(), "acme", "api", "def456", "production")
+	if err != nil || !ok || d.ID != "deployment-21" {
+		t.Errorf("DeploymentOf() from records = %+v, %v, %v", d, ok, err)
+	}
+}
+
+func TestErrorWrapping(t *testing.T) {
+	tests := []struct {
+		status int
+		code   string
+	}{
+		{http.StatusUnauthorized, "unauthorized"},
+		{http.StatusForbidden, "forbidden"},
+		{http.StatusNotFound, "not_found"},
+		{http.StatusUnprocessableEntity, "bad_request"},
+		{http.StatusBadGateway, "provider_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			p, srv := newFakeProvider(t)
+			srv.Error(http.MethodGet, "/repos/acme/api/actions/runs", tt.status, "boom")
+
+			_, err := p.Query(context.Background(), schema.DeploymentQuery{})
+			if !hasCode(err, tt.code) {
+				t.Errorf("Query() error = %v, want code %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func hasCode(err error, code string) bool {
+	var oe *orcherr.OpsOrchError
+	return errors.As(err, &oe) && oe.Code == code
+}
+
+// FuzzNew checks that arbitrary JSON config never crashes the constructor.
+func FuzzNew(f *testing.F) {
+	f.Add(`{"token":"t","owner":"o","repo":"r"}`)
+	f.Add(`{"token":"t","repository":"o/r","cacheTTL":"5m","rawAPIAllowlist":["/a",1]}`)
+	f.Add(`{"mode":"fixtures","repository":"opsorch/demo"}`)
+
+	f.Fuzz(func(t *testing.T, data string) {
+		var cfg map[string]any
+		if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+			return
+		}
+		p, err := New(cfg)
+		if err != nil {
+			return
+		}
+		gp := p.(*Provider)
+		if gp.config.Owner == "" || gp.config.Repo == "" {
+			t.Errorf("New() accepted config without owner/repo: %s", data)
+		}
+	})
+}
+
+func TestGetByNodeID(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	repo := map[string]any{"name": "api", "owner": map[string]any{"login": "acme"}}
+	srv.Handle(http.MethodPost, "/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]any `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		node := map[string]any{"__typename": "WorkflowRun", "databaseId": 1001, "checkSuite": map[string]any{"repository": repo}}
+		switch req.Variables["id"] {
+		case "DE_kwDOABCD5M4AAAAB":
+			node = map[string]any{"__typename": "Deployment", "databaseId": 501, "repository": repo}
+		case "DE_kwDOABCD5M4AAAAC":
+			node = map[string]any{"__typename": "Deployment", "databaseId": 502, "repository": map[string]any{"name": "web", "owner": map[string]any{"login": "acme"}}}
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"node": node}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/web/deployments/502", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"id": 502, "environment": "production"})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/web/deployments/502/statuses", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"state": "success"}})
+	})
+	p.config.RepositoryAllowlist = override.Allowlist{"acme/*"}
+
+	for nodeID, want := range map[string]string{"WFR_kwLOABCD5M8AAAAB": "1001", "DE_kwDOABCD5M4AAAAB": "deployment-501", "DE_kwDOABCD5M4AAAAC": "acme/web#deployment-502"} {
+		got, err := p.Get(context.Background(), nodeID)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", nodeID, err)
+		}
+		if got.ID != want {
+			t.Errorf("Get(%s) ID = %s, want %s", nodeID, got.ID, want)
+		}
+	}
+	if got, err := p.Get(context.Background(), "acme/web#deployment-502"); err != nil || got.Service != "web" || got.Status != "success" {
+		t.Errorf("Get(acme/web#deployment-502) = %+v, %v, want web's deployment", got, err)
+	}
+
+	got := p.convertWorkflowRunToDeployment(&github.WorkflowRun{
+		ID:         github.Int64(7),
+		NodeID:     github.String("WFR_kwLOABCD5M8AAAAB"),
+		Repository: &github.Repository{NodeID: github.String("R_kgDOABCD5M")},
+	})
+	if got.Fields["node_id"] != "WFR_kwLOABCD5M8AAAAB" || got.Fields["repository_node_id"] != "R_kgDOABCD5M" {
+		t.Errorf("node ID fields = %v", got.Fields)
+	}
+}
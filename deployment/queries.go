@@ -0,0 +1,86 @@
+package deployment
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+// savedQueryKey is the query metadata key that names a preset from the "queries"
+// config map.
+const savedQueryKey = "savedQuery"
+
+// parseSavedQueries reads the "queries" config map of named query presets.
+func parseSavedQueries(cfg map[string]any) (map[string]schema.DeploymentQuery, error) {
+	raw, ok := cfg["queries"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	// Round-trip through JSON so decoded config and typed presets passed
+	// in-process are handled the same way
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("queries: %w", err)
+	}
+	var queries map[string]schema.DeploymentQuery
+	if err := json.Unmarshal(data, &queries); err != nil {
+		return nil, fmt.Errorf("queries must map names to deployment queries: %w", err)
+	}
+	if _, ok := queries[""]; ok {
+		return nil, fmt.Errorf("queries: preset name is required")
+	}
+	return queries, nil
+}
+
+// applySavedQuery expands the preset named by metadata "savedQuery". Fields set
+// on the query override the preset's, and metadata keys are merged the same way.
+func (p *Provider) applySavedQuery(query schema.DeploymentQuery) (schema.DeploymentQuery, error) {
+	name, _ := query.Metadata[savedQueryKey].(string)
+	if name == "" {
+		return query, nil
+	}
+	preset, ok := p.config.Queries[name]
+	if !ok {
+		return query, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("unknown saved query: %s", name),
+		}
+	}
+
+	if query.Query == "" {
+		query.Query = preset.Query
+	}
+	if len(query.Statuses) == 0 {
+		query.Statuses = preset.Statuses
+	}
+	if len(query.Versions) == 0 {
+		query.Versions = preset.Versions
+	}
+	if query.Scope.Service == "" {
+		query.Scope.Service = preset.Scope.Service
+	}
+	if query.Scope.Team == "" {
+		query.Scope.Team = preset.Scope.Team
+	}
+	if query.Scope.Environment == "" {
+		query.Scope.Environment = preset.Scope.Environment
+	}
+	if query.Limit == 0 {
+		query.Limit = preset.Limit
+	}
+
+	metadata := make(map[string]any, len(preset.Metadata)+len(query.Metadata))
+	for k, v := range preset.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range query.Metadata {
+		if k != savedQueryKey {
+			metadata[k] = v
+		}
+	}
+	query.Metadata = metadata
+	return query, nil
+}
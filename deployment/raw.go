@@ -0,0 +1,19 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/opsorch/opsorch-github-adapter/internal/ghraw"
+)
+
+// Raw performs a GET against an arbitrary GitHub API path, restricted to the
+// configured rawAPIAllowlist. It is an escape hatch for endpoints the adapter
+// does not model yet.
+func (p *Provider) Raw(ctx context.Context, path string) (json.RawMessage, error) {
+	allow := ghraw.Allowlist{
+		Patterns: p.config.RawAPIAllowlist,
+		Vars:     map[string]string{"owner": p.config.Owner, "repo": p.config.Repo},
+	}
+	return ghraw.Raw(ctx, p.api.Requester, allow, path, p.wrapError)
+}
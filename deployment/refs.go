@@ -0,0 +1,70 @@
+package deployment
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+// refFilter restricts a deployment query to a branch or tag name, exactly or by
+// path.Match glob, from metadata "ref" or "tag".
+type refFilter struct {
+	pattern string // Name without a refs/heads/ or refs/tags/ prefix
+	glob    bool
+}
+
+// parseRefFilter reads the "ref" or "tag" query metadata. It returns nil when
+// neither is set.
+func parseRefFilter(metadata map[string]any) (*refFilter, error) {
+	ref, _ := metadata["ref"].(string)
+	tag, _ := metadata["tag"].(string)
+	if ref != "" && tag != "" {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "set either ref or tag, not both",
+		}
+	}
+	pattern := refName(ref + tag)
+	if pattern == "" {
+		return nil, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("invalid ref pattern %q", pattern),
+		}
+	}
+	return &refFilter{pattern: pattern, glob: strings.ContainsAny(pattern, "*?[")}, nil
+}
+
+// exact returns the name to filter on server-side, or "" for a glob.
+func (f *refFilter) exact() string {
+	if f == nil || f.glob {
+		return ""
+	}
+	return f.pattern
+}
+
+// isGlob reports whether the filter is a glob, which is matched client-side.
+func (f *refFilter) isGlob() bool {
+	return f != nil && f.glob
+}
+
+// matches reports whether the ref passes the filter. A nil filter passes everything.
+func (f *refFilter) matches(ref string) bool {
+	if f == nil {
+		return true
+	}
+	ok, _ := path.Match(f.pattern, refName(ref))
+	return ok
+}
+
+// refName strips the refs/heads/ or refs/tags/ prefix from a ref.
+func refName(ref string) string {
+	if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+		return name
+	}
+	return strings.TrimPrefix(ref, "refs/tags/")
+}
@@ -0,0 +1,178 @@
+package deployment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+// ScheduledDispatch is a Trigger waiting for its time to dispatch.
+type ScheduledDispatch struct {
+	ID        string       `json:"id"`
+	Owner     string       `json:"owner"`
+	Repo      string       `json:"repo"`
+	Input     TriggerInput `json:"input"`
+	Persisted bool         `json:"persisted"` // Written to scheduleDir, so it survives a restart
+}
+
+// schedules holds a provider's pending dispatches. The zero value is ready to use.
+type schedules struct {
+	mu      sync.Mutex
+	pending map[string]*ScheduledDispatch
+}
+
+// armed holds the IDs of the dispatches armed in this process, so providers
+// of one repository created for several profiles recover each persisted
+// dispatch once.
+var armed sync.Map
+
+// schedule arms a dispatch of input at input.At, persisting it first when
+// scheduleDir is configured so a restarted process picks it up again.
+func (p *Provider) schedule(input TriggerInput) (ScheduledDispatch, error) {
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return ScheduledDispatch{}, err
+	}
+	s := ScheduledDispatch{ID: hex.EncodeToString(id[:]), Owner: p.config.Owner, Repo: p.config.Repo, Input: input}
+	if p.config.ScheduleDir != "" {
+		if err := p.persistSchedule(&s); err != nil {
+			return ScheduledDispatch{}, &orcherr.OpsOrchError{
+				Code:    "provider_error",
+				Message: fmt.Sprintf("persisting scheduled dispatch: %v", err),
+			}
+		}
+	}
+	p.arm(s)
+	return s, nil
+}
+
+// arm starts the timer of a scheduled dispatch. One already due fires at once.
+func (p *Provider) arm(s ScheduledDispatch) {
+	if _, loaded := armed.LoadOrStore(s.ID, struct{}{}); loaded {
+		return
+	}
+	p.schedules.mu.Lock()
+	if p.schedules.pending == nil {
+		p.schedules.pending = map[string]*ScheduledDispatch{}
+	}
+	p.schedules.pending[s.ID] = &s
+	p.schedules.mu.Unlock()
+	time.AfterFunc(time.Until(s.Input.At), func() { p.fire(s) })
+}
+
+// fire checks the change freeze again and dispatches. Refusals and failures
+// are logged, since the caller's request has long been answered.
+func (p *Provider) fire(s ScheduledDispatch) {
+	p.schedules.mu.Lock()
+	delete(p.schedules.pending, s.ID)
+	p.schedules.mu.Unlock()
+	armed.Delete(s.ID)
+	if s.Persisted {
+		if err := os.Remove(p.schedulePath(s.ID)); err != nil && !os.IsNotExist(err) {
+			log.Printf("[trigger] removing scheduled dispatch %s: %v", s.ID, err)
+		}
+	}
+
+	// The caller's context is gone by now, so the dispatch runs on its own
+	ctx := context.Background()
+	input := s.Input
+	if err := p.CheckChangeFreeze(ctx, input); err != nil {
+		log.Printf("[trigger] scheduled dispatch of %s on %s: %v", input.Workflow, input.Ref, err)
+		return
+	}
+	// readOnly may have been turned on since the dispatch was persisted
+	if p.isDryRun(input.Metadata) {
+		p.simulateDispatch(input)
+		return
+	}
+	if err := p.dispatch(ctx, input); err != nil {
+		log.Printf("[trigger] scheduled dispatch of %s on %s: %v", input.Workflow, input.Ref, err)
+	}
+}
+
+// Pending returns the dispatches scheduled but not yet fired, the soonest
+// first. Those not persisted are lost if the process exits.
+func (p *Provider) Pending() []ScheduledDispatch {
+	p.schedules.mu.Lock()
+	defer p.schedules.mu.Unlock()
+	pending := make([]ScheduledDispatch, 0, len(p.schedules.pending))
+	for _, s := range p.schedules.pending {
+		pending = append(pending, *s)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Input.At.Before(pending[j].Input.At) })
+	return pending
+}
+
+// schedulePath names the file of a persisted dispatch. Providers of several
+// repositories can share scheduleDir.
+func (p *Provider) schedulePath(id string) string {
+	return filepath.Join(p.config.ScheduleDir, p.config.Owner+"_"+p.config.Repo+"_"+id+".json")
+}
+
+// persistSchedule writes s to scheduleDir through a temporary file, so a
+// restart never reads half a schedule.
+func (p *Provider) persistSchedule(s *ScheduledDispatch) error {
+	s.Persisted = true
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(p.config.ScheduleDir, ".schedule-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p.schedulePath(s.ID))
+}
+
+// recoverSchedules re-arms the dispatches a previous process persisted for
+// this repository. Those whose time passed while no process was running fire
+// at once, after the change freeze check.
+func (p *Provider) recoverSchedules() error {
+	prefix := p.config.Owner + "_" + p.config.Repo + "_"
+	entries, err := os.ReadDir(p.config.ScheduleDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(p.config.ScheduleDir, name))
+		if err != nil {
+			return err
+		}
+		var s ScheduledDispatch
+		if err := json.Unmarshal(data, &s); err != nil || s.ID == "" || s.Owner != p.config.Owner || s.Repo != p.config.Repo {
+			log.Printf("[trigger] discarding unreadable scheduled dispatch %s", name)
+			continue
+		}
+		if _, ok := armed.Load(s.ID); ok {
+			continue
+		}
+		if late := time.Since(s.Input.At); late > 0 {
+			log.Printf("[trigger] scheduled dispatch of %s on %s is %s late, dispatching now", s.Input.Workflow, s.Input.Ref, late.Round(time.Second))
+		}
+		p.arm(s)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package deployment
+
+import (
+	"context"
+	"log"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/cache"
+	"github.com/opsorch/opsorch-github-adapter/internal/servicetags"
+)
+
+// addServiceTags sets Fields["service_tags"] on deployments of owner/repo to
+// the repository's topics and custom properties, tagged as the service
+// provider tags the repository's service. It is a no-op unless serviceTags is
+// configured. The tags are read once per call and kept for cacheTTL; a failed
+// read is logged and leaves them unset.
+func (p *Provider) addServiceTags(ctx context.Context, owner, repo string, deployments []schema.Deployment) {
+	if !p.config.ServiceTags || p.api.Repositories == nil || len(deployments) == 0 {
+		return
+	}
+	tags, ok := p.serviceTags(ctx, owner, repo)
+	if !ok {
+		return
+	}
+	for _, d := range deployments {
+		d.Fields["service_tags"] = tags
+	}
+}
+
+// serviceTags returns the service tags of owner/repo, from the response cache
+// when it holds them.
+func (p *Provider) serviceTags(ctx context.Context, owner, repo string) (map[string]string, bool) {
+	key := "service-tags/" + owner + "/" + repo
+	if p.config.CacheTTL > 0 {
+		if v, ok := cache.Default.Get(key); ok {
+			return v.(map[string]string), true
+		}
+	}
+	if !budget.Enrich(ctx) {
+		return nil, false
+	}
+
+	repository, _, err := p.api.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		log.Printf("[service-tags] %s/%s: %v", owner, repo, err)
+		return nil, false
+	}
+	// Custom properties only exist for organization repositories
+	var properties []*ghapi.PropertyValue
+	if p.api.Properties != nil && repository.GetOwner().GetType() != "User" {
+		if properties, _, err = p.api.Properties.ListRepositoryPropertyValues(ctx, owner, repo); err != nil {
+			log.Printf("[service-tags] %s/%s custom properties: %v", owner, repo, err)
+			return nil, false
+		}
+	}
+
+	tags := servicetags.Tags(repository.Topics, properties)
+	if p.config.CacheTTL > 0 {
+		cache.Default.Set(key, tags, p.config.CacheTTL)
+	}
+	return tags, true
+}
@@ -0,0 +1,82 @@
+package deployment
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// Values accepted for the "sort" query metadata key.
+const (
+	SortStarted  = "started"
+	SortFinished = "finished"
+)
+
+// ordering is the sort requested by query metadata "sort" and "direction". The
+// zero value is newest started first.
+type ordering struct {
+	Sort      string
+	Direction string
+}
+
+// defaultOrdering is the order of a query without "sort" or "direction", which
+// is also the order GitHub lists deployments in.
+var defaultOrdering = ordering{Sort: SortStarted, Direction: "desc"}
+
+// parseOrdering reads the "sort" and "direction" query metadata keys.
+func parseOrdering(metadata map[string]any) (ordering, error) {
+	o := ordering{
+		Sort:      strings.ToLower(ghconfig.String(metadata, "sort")),
+		Direction: strings.ToLower(ghconfig.String(metadata, "direction")),
+	}
+	switch o.Sort {
+	case "":
+		o.Sort = SortStarted
+	case SortStarted, SortFinished:
+	default:
+		return o, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("unknown sort %q (expected %s or %s)", o.Sort, SortStarted, SortFinished),
+		}
+	}
+	switch o.Direction {
+	case "":
+		o.Direction = "desc"
+	case "asc", "desc":
+	default:
+		return o, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("unknown direction %q (expected asc or desc)", o.Direction),
+		}
+	}
+	return o, nil
+}
+
+// sortDeployments orders deployments client-side, after every filter ran, since
+// GitHub's order does not survive pages trimmed by filters or merged from
+// several repositories. Ties are broken by ID, so the order is stable across
+// calls.
+func (o ordering) sortDeployments(deployments []schema.Deployment) {
+	slices.SortStableFunc(deployments, func(a, b schema.Deployment) int {
+		var c int
+		if o.Sort == SortFinished {
+			c = a.FinishedAt.Compare(b.FinishedAt)
+		} else {
+			c = a.StartedAt.Compare(b.StartedAt)
+		}
+		if c == 0 {
+			// Numeric IDs of equal prefix compare by length first
+			if c = len(a.ID) - len(b.ID); c == 0 {
+				c = strings.Compare(a.ID, b.ID)
+			}
+		}
+		if o.Direction == "asc" {
+			return c
+		}
+		return -c
+	})
+}
@@ -0,0 +1,229 @@
+package deployment
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/paging"
+)
+
+// defaultStatsWindow is the window Stats covers when Since is unset.
+const defaultStatsWindow = 30 * 24 * time.Hour
+
+// StatsInput selects the time window, and optionally the environment, Stats
+// reports on.
+type StatsInput struct {
+	Since       time.Time `json:"since,omitempty"`       // Start of the window; defaults to 30 days before Until
+	Until       time.Time `json:"until,omitempty"`       // End of the window; defaults to now
+	Environment string    `json:"environment,omitempty"` // Only report this environment
+}
+
+// EnvironmentStats are the DORA figures of one environment. Only finished
+// deployments count: successes and failures, not cancelled or active ones.
+type EnvironmentStats struct {
+	Environment           string  `json:"environment"`
+	Deployments           int     `json:"deployments"`
+	Failed                int     `json:"failed"`
+	DeploymentsPerDay     float64 `json:"deploymentsPerDay"`
+	ChangeFailureRate     float64 `json:"changeFailureRate"`               // Failed over Deployments, 0 to 1
+	MedianDurationSeconds *int64  `json:"medianDurationSeconds,omitempty"` // From start to finish; nil when no deployment has both
+}
+
+// Stats summarizes deployments over a window, per environment and sorted by
+// environment name. Source is "deployments" when the figures come from the
+// Deployments API and "runs" when they come from workflow runs. Sampled is set
+// when the window held more deployments than were read, leaving the figures
+// partial.
+type Stats struct {
+	Since        time.Time          `json:"since"`
+	Until        time.Time          `json:"until"`
+	Source       string             `json:"source"`
+	Environments []EnvironmentStats `json:"environments"`
+	Sampled      bool               `json:"sampled,omitempty"`
+	Truncated    bool               `json:"truncated,omitempty"` // The API call budget ran out, leaving the figures partial
+}
+
+// Stats computes deployment frequency, change failure rate, and median duration
+// per environment over a window. Like environment queries, it reads the
+// Deployments API when environmentSource allows, falling back to workflow runs
+// when the repository has no deployment records.
+func (p *Provider) Stats(ctx context.Context, input StatsInput) (Stats, error) {
+	until := input.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	since := input.Since
+	if since.IsZero() {
+		since = until.Add(-defaultStatsWindow)
+	}
+	if !since.Before(until) {
+		return Stats{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "since must be before until",
+		}
+	}
+	since, until = since.UTC(), until.UTC()
+
+	ctx = budget.Start(ctx, p.config.Budget)
+	stats := Stats{Since: since, Until: until, Environments: []EnvironmentStats{}}
+
+	var (
+		deployments []schema.Deployment
+		sampled     bool
+		err         error
+	)
+	if p.useDeploymentsAPI() && p.api.Repositories != nil {
+		var found bool
+		deployments, sampled, found, err = p.statsDeployments(ctx, since, until, input.Environment)
+		if err == nil && (found || p.config.EnvironmentSource == EnvironmentSourceDeployments) {
+			stats.Source = EnvironmentSourceDeployments
+		}
+	}
+	if err == nil && stats.Source == "" {
+		stats.Source = EnvironmentSourceRuns
+		deployments, sampled, err = p.statsRuns(ctx, since, until, input.Environment)
+	}
+	if errors.Is(err, budget.ErrExhausted) {
+		err = nil
+	}
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats.Environments = summarize(deployments, until.Sub(since))
+	stats.Sampled = sampled
+	stats.Truncated = budget.Truncated(ctx)
+	return stats, nil
+}
+
+// statsRuns returns the finished workflow runs created in the window, up to
+// paging.MaxResults, and whether there were more.
+func (p *Provider) statsRuns(ctx context.Context, since, until time.Time, environment string) ([]schema.Deployment, bool, error) {
+	opts := &github.ListWorkflowRunsOptions{
+		Status:      "completed",
+		Created:     since.Format(time.RFC3339) + ".." + until.Format(time.RFC3339),
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var deployments []schema.Deployment
+	listed, total := 0, 0
+	for page := 0; page < paging.MaxPages; page++ {
+		if !budget.Call(ctx) {
+			return deployments, false, budget.ErrExhausted
+		}
+		runs, resp, err := p.api.Actions.ListRepositoryWorkflowRuns(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return nil, false, p.wrapError(err)
+		}
+		total = runs.GetTotalCount()
+		for _, run := range runs.WorkflowRuns {
+			listed++
+			deployment := p.convertRun(run, nil)
+			if environment == "" || deployment.Environment == environment {
+				deployments = append(deployments, deployment)
+			}
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return deployments, listed < total, nil
+}
+
+// statsDeployments returns the Deployments API records created in the window,
+// each with its latest status. GitHub lists records newest first, so listing
+// stops at the first one older than the window. found is false when the
+// repository, or environment, has no records at all.
+func (p *Provider) statsDeployments(ctx context.Context, since, until time.Time, environment string) (deployments []schema.Deployment, sampled, found bool, err error) {
+	opts := &github.DeploymentsListOptions{
+		Environment: environment,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for page := 0; page < paging.MaxPages; page++ {
+		if !budget.Call(ctx) {
+			return deployments, false, found, budget.ErrExhausted
+		}
+		records, resp, err := p.api.Repositories.ListDeployments(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return nil, false, found, p.wrapError(err)
+		}
+		found = found || len(records) > 0
+		for _, d := range records {
+			created := d.GetCreatedAt().Time
+			if created.Before(since) {
+				return deployments, false, found, nil
+			}
+			if !created.Before(until) {
+				continue
+			}
+			if !budget.Call(ctx) {
+				return deployments, false, found, budget.ErrExhausted
+			}
+			status, err := p.latestDeploymentStatus(ctx, d.GetID())
+			if err != nil {
+				return nil, false, found, err
+			}
+			deployments = append(deployments, p.convertDeploymentToSchema(d, status))
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return deployments, false, found, nil
+		}
+		opts.Page = resp.NextPage
+	}
+	// The page cap was reached before the start of the window
+	return deployments, true, found, nil
+}
+
+// summarize groups finished deployments by environment over a window of the
+// given length.
+func summarize(deployments []schema.Deployment, window time.Duration) []EnvironmentStats {
+	byEnvironment := map[string]*EnvironmentStats{}
+	durations := map[string][]int64{}
+	for _, d := range deployments {
+		if d.Status != "success" && d.Status != "failed" {
+			continue
+		}
+		env := byEnvironment[d.Environment]
+		if env == nil {
+			env = &EnvironmentStats{Environment: d.Environment}
+			byEnvironment[d.Environment] = env
+		}
+		env.Deployments++
+		if d.Status == "failed" {
+			env.Failed++
+		}
+		if !d.StartedAt.IsZero() && !d.FinishedAt.IsZero() {
+			durations[d.Environment] = append(durations[d.Environment], int64(d.FinishedAt.Sub(d.StartedAt).Seconds()))
+		}
+	}
+
+	days := window.Hours() / 24
+	environments := make([]EnvironmentStats, 0, len(byEnvironment))
+	for name, env := range byEnvironment {
+		env.DeploymentsPerDay = float64(env.Deployments) / days
+		env.ChangeFailureRate = float64(env.Failed) / float64(env.Deployments)
+		env.MedianDurationSeconds = median(durations[name])
+		environments = append(environments, *env)
+	}
+	sort.Slice(environments, func(i, j int) bool { return environments[i].Environment < environments[j].Environment })
+	return environments
+}
+
+// median returns the median of values, or nil when there are none.
+func median(values []int64) *int64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	m := values[len(values)/2]
+	if len(values)%2 == 0 {
+		m = (values[len(values)/2-1] + m) / 2
+	}
+	return &m
+}
@@ -0,0 +1,161 @@
+package deployment
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/cache"
+)
+
+// defaultStatusTTL is how long a status board stays in memory when "statusTTL"
+// is unset.
+const defaultStatusTTL = 30 * time.Second
+
+// statusScanSize is how many recent deployments a status board without named
+// environments reads to find them.
+const statusScanSize = 100
+
+// StatusInput selects the environments of a status board.
+type StatusInput struct {
+	Environments []string `json:"environments,omitempty"` // Environments to report; defaults to those of recent deployments
+}
+
+// EnvironmentStatus is the latest deployment to one environment, kept small for
+// status widgets.
+type EnvironmentStatus struct {
+	Environment    string    `json:"environment"`
+	Status         string    `json:"status"`                   // Normalized status of the deployment; empty when the environment has none
+	Version        string    `json:"version,omitempty"`        // Short SHA deployed
+	Ref            string    `json:"ref,omitempty"`            // Branch or tag deployed
+	Actor          string    `json:"actor,omitempty"`          // Login of who deployed
+	At             time.Time `json:"at,omitempty"`             // When the latest status was set
+	URL            string    `json:"url,omitempty"`            // Log or target URL of the deployment
+	EnvironmentURL string    `json:"environmentUrl,omitempty"` // Where the environment is served
+	Deployment     string    `json:"deployment,omitempty"`     // Deployment ID, for Get
+}
+
+// StatusBoard is the latest deployment status of each environment, sorted by
+// environment name.
+type StatusBoard struct {
+	Repository   string              `json:"repository"`
+	Environments []EnvironmentStatus `json:"environments"`
+	UpdatedAt    time.Time           `json:"updatedAt"` // When the board was read from GitHub
+}
+
+// Status returns the latest deployment of each environment from the
+// Deployments API: one call lists the deployments, and one per environment
+// reads its latest status. Named environments are looked up one call each
+// instead; those never deployed are reported without a status. Boards are kept
+// in memory for statusTTL, so widgets polling it cost no API calls in between.
+func (p *Provider) Status(ctx context.Context, input StatusInput) (StatusBoard, error) {
+	if p.api.Repositories == nil {
+		return StatusBoard{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "environment deployment history is not available for this provider",
+		}
+	}
+
+	key := "deployment-status/" + p.config.Owner + "/" + p.config.Repo + "/" + strings.Join(input.Environments, ",")
+	if p.config.StatusTTL > 0 {
+		if v, ok := cache.Default.Get(key); ok {
+			return v.(StatusBoard), nil
+		}
+	}
+
+	latest, err := p.latestDeployments(ctx, input.Environments)
+	if err != nil {
+		return StatusBoard{}, err
+	}
+
+	board := StatusBoard{
+		Repository:   p.config.Owner + "/" + p.config.Repo,
+		Environments: make([]EnvironmentStatus, 0, len(latest)),
+		UpdatedAt:    time.Now().UTC(),
+	}
+	for environment, d := range latest {
+		entry := EnvironmentStatus{Environment: environment}
+		if d != nil {
+			status, err := p.latestDeploymentStatus(ctx, d.GetID())
+			if err != nil {
+				return StatusBoard{}, err
+			}
+			entry = p.convertEnvironmentStatus(d, status)
+		}
+		board.Environments = append(board.Environments, entry)
+	}
+	sort.Slice(board.Environments, func(i, j int) bool {
+		return board.Environments[i].Environment < board.Environments[j].Environment
+	})
+
+	if p.config.StatusTTL > 0 {
+		cache.Default.Set(key, board, p.config.StatusTTL)
+	}
+	return board, nil
+}
+
+// latestDeployments returns the newest deployment of each environment, nil for
+// named environments without one.
+func (p *Provider) latestDeployments(ctx context.Context, environments []string) (map[string]*github.Deployment, error) {
+	latest := map[string]*github.Deployment{}
+	if len(environments) > 0 {
+		for _, environment := range environments {
+			deployments, _, err := p.api.Repositories.ListDeployments(ctx, p.config.Owner, p.config.Repo, &github.DeploymentsListOptions{
+				Environment: environment,
+				ListOptions: github.ListOptions{PerPage: 1},
+			})
+			if err != nil {
+				return nil, p.wrapError(err)
+			}
+			latest[environment] = nil
+			if len(deployments) > 0 {
+				latest[environment] = deployments[0]
+			}
+		}
+		return latest, nil
+	}
+
+	// GitHub lists deployments newest first
+	deployments, _, err := p.api.Repositories.ListDeployments(ctx, p.config.Owner, p.config.Repo, &github.DeploymentsListOptions{
+		ListOptions: github.ListOptions{PerPage: statusScanSize},
+	})
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+	for _, d := range deployments {
+		if _, seen := latest[d.GetEnvironment()]; !seen && d.GetEnvironment() != "" {
+			latest[d.GetEnvironment()] = d
+		}
+	}
+	return latest, nil
+}
+
+// convertEnvironmentStatus picks the widget fields out of a deployment and its
+// latest status, which may be nil.
+func (p *Provider) convertEnvironmentStatus(d *github.Deployment, status *github.DeploymentStatus) EnvironmentStatus {
+	entry := EnvironmentStatus{
+		Environment: d.GetEnvironment(),
+		Status:      "queued",
+		Ref:         d.GetRef(),
+		Actor:       d.GetCreator().GetLogin(),
+		At:          d.GetCreatedAt().Time,
+		Deployment:  deploymentIDPrefix + strconv.FormatInt(d.GetID(), 10),
+	}
+	if sha := d.GetSHA(); len(sha) >= 7 {
+		entry.Version = sha[:7]
+	}
+	if status != nil {
+		entry.Status = p.normalizeDeploymentState(status.GetState())
+		entry.At = status.GetUpdatedAt().Time
+		entry.URL = status.GetLogURL()
+		if entry.URL == "" {
+			entry.URL = status.GetTargetURL()
+		}
+		entry.EnvironmentURL = status.GetEnvironmentURL()
+	}
+	return entry
+}
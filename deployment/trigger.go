@@ -0,0 +1,110 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+// TriggerInput selects the workflow to dispatch and the inputs passed to it.
+type TriggerInput struct {
+	Workflow       string         `json:"workflow"` // Workflow file name (e.g. deploy.yml) or numeric ID
+	Ref            string         `json:"ref"`      // Branch or tag to run the workflow on
+	Inputs         map[string]any `json:"inputs,omitempty"`
+	At             time.Time      `json:"at,omitempty"`             // Dispatch at this time instead of immediately
+	OverrideFreeze bool           `json:"overrideFreeze,omitempty"` // Dispatch during a change freeze that allows overrides
+	Metadata       map[string]any `json:"metadata,omitempty"`       // "dryRun" simulates the dispatch
+}
+
+// TriggerResult reports whether the workflow was dispatched or scheduled.
+type TriggerResult struct {
+	Workflow    string    `json:"workflow"`
+	Ref         string    `json:"ref"`
+	Dispatched  bool      `json:"dispatched"`
+	ScheduledAt time.Time `json:"scheduledAt,omitempty"`
+	ScheduleID  string    `json:"scheduleID,omitempty"` // ID of a scheduled dispatch, listed by Pending
+	Persisted   bool      `json:"persisted,omitempty"`  // The scheduled dispatch is in scheduleDir and survives a restart
+	DryRun      bool      `json:"dryRun,omitempty"`     // The dispatch was only logged, under readOnly or metadata "dryRun"
+}
+
+// Trigger dispatches a workflow_dispatch event. GitHub does not return the run it
+// starts, so callers find it with Query (event "workflow_dispatch") once it is queued.
+//
+// When input.At is in the future the dispatch is scheduled in-process and Trigger
+// returns at once; the change freeze is checked both now and again when the
+// dispatch fires, and a scheduled dispatch that fails is logged. With
+// scheduleDir configured the schedule is persisted there, and a provider
+// created after a restart dispatches it; otherwise it is lost when the process
+// exits, and Pending lists it.
+//
+// Under readOnly, or with metadata "dryRun", the freeze is still checked but
+// the dispatch is logged instead of sent or scheduled.
+func (p *Provider) Trigger(ctx context.Context, input TriggerInput) (TriggerResult, error) {
+	input.Workflow = strings.TrimSpace(input.Workflow)
+	input.Ref = strings.TrimSpace(input.Ref)
+	if input.Workflow == "" {
+		return TriggerResult{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "workflow is required",
+		}
+	}
+	if input.Ref == "" {
+		return TriggerResult{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "ref is required",
+		}
+	}
+	result := TriggerResult{Workflow: input.Workflow, Ref: input.Ref}
+
+	if err := p.CheckChangeFreeze(ctx, input); err != nil {
+		return result, err
+	}
+
+	if p.isDryRun(input.Metadata) {
+		p.simulateDispatch(input)
+		if input.At.After(time.Now()) {
+			result.ScheduledAt = input.At
+		}
+		result.DryRun = true
+		return result, nil
+	}
+
+	if input.At.After(time.Now()) {
+		scheduled, err := p.schedule(input)
+		if err != nil {
+			return result, err
+		}
+		result.ScheduledAt = input.At
+		result.ScheduleID = scheduled.ID
+		result.Persisted = scheduled.Persisted
+		return result, nil
+	}
+
+	if err := p.dispatch(ctx, input); err != nil {
+		return result, err
+	}
+	result.Dispatched = true
+	return result, nil
+}
+
+// dispatch sends the workflow_dispatch event for input.
+func (p *Provider) dispatch(ctx context.Context, input TriggerInput) error {
+	event := github.CreateWorkflowDispatchEventRequest{Ref: input.Ref, Inputs: input.Inputs}
+	_, err := p.api.Actions.CreateWorkflowDispatchEventByFileName(ctx, p.config.Owner, p.config.Repo, input.Workflow, event)
+	url := fmt.Sprintf("https://github.com/%s/%s/actions/workflows/%s", p.config.Owner, p.config.Repo, input.Workflow)
+	p.audit("deployment.trigger", input.Workflow, event, url, err)
+	if err != nil {
+		return p.wrapError(err)
+	}
+	return nil
+}
+
+// simulateDispatch logs the workflow_dispatch event that would have been sent.
+func (p *Provider) simulateDispatch(input TriggerInput) {
+	log.Printf("[dry-run] POST /repos/%s/%s/actions/workflows/%s/dispatches ref=%q", p.config.Owner, p.config.Repo, input.Workflow, input.Ref)
+}
@@ -0,0 +1,117 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/cache"
+)
+
+// WatchOptions controls how Watch follows a workflow run.
+type WatchOptions struct {
+	Interval time.Duration // Time between polls (default 10s, minimum 1s)
+	Timeout  time.Duration // Give up after this long (default 30m)
+}
+
+const (
+	defaultWatchInterval = 10 * time.Second
+	minWatchInterval     = time.Second
+	defaultWatchTimeout  = 30 * time.Minute
+)
+
+// Watch follows a workflow run until it reaches a terminal status (success, failed,
+// cancelled), calling onUpdate every time the normalized status changes, and returns
+// the terminal deployment. It polls the API and also wakes up immediately when a
+// webhook event for the run arrives in the same process.
+func (p *Provider) Watch(ctx context.Context, id string, opts WatchOptions, onUpdate func(schema.Deployment)) (schema.Deployment, error) {
+	runID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return schema.Deployment{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("invalid workflow run ID: %s", id),
+		}
+	}
+
+	if opts.Interval <= 0 {
+		opts.Interval = defaultWatchInterval
+	}
+	if opts.Interval < minWatchInterval {
+		opts.Interval = minWatchInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultWatchTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	pushed, stop := cache.Default.Watch(p.recordKey(id))
+	defer stop()
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	lastStatus := ""
+	observe := func(d schema.Deployment) bool {
+		if d.Status != lastStatus {
+			lastStatus = d.Status
+			if onUpdate != nil {
+				onUpdate(d)
+			}
+		}
+		return isTerminalStatus(d.Status)
+	}
+
+	current, err := p.fetch(ctx, runID)
+	drain(pushed)
+	for {
+		if err != nil {
+			if ctx.Err() != nil {
+				return schema.Deployment{}, watchTimeout(id)
+			}
+			return schema.Deployment{}, err
+		}
+		if observe(current) {
+			return current, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return schema.Deployment{}, watchTimeout(id)
+		case v := <-pushed:
+			current, err = v.(schema.Deployment), nil
+		case <-ticker.C:
+			current, err = p.fetch(ctx, runID)
+			drain(pushed)
+		}
+	}
+}
+
+// drain discards the notification our own fetch produced when it published the record.
+func drain(ch <-chan any) {
+	select {
+	case <-ch:
+	default:
+	}
+}
+
+// isTerminalStatus reports whether a normalized status will not change again.
+func isTerminalStatus(status string) bool {
+	switch status {
+	case "success", "failed", "cancelled":
+		return true
+	default:
+		return false
+	}
+}
+
+func watchTimeout(id string) error {
+	return &orcherr.OpsOrchError{
+		Code:    "timeout",
+		Message: fmt.Sprintf("workflow run %s did not reach a terminal status before the watch ended", id),
+	}
+}
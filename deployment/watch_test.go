@@ -0,0 +1,78 @@
+package deployment
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+func TestWatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"id": 99, "name": "Deploy", "status": "in_progress", "head_sha": "abcdef1234"}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	p := &Provider{api: ghapi.FromClient(client), config: Config{Owner: "acme", Repo: "watch"}}
+
+	var updates []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		result, err := p.Watch(context.Background(), "99", WatchOptions{Interval: time.Hour, Timeout: 5 * time.Second}, func(d schema.Deployment) {
+			updates = append(updates, d.Status)
+			if d.Status == "running" {
+				// Simulate a webhook delivering the completed run
+				go func() {
+					completed := d
+					completed.Status = "success"
+					p.Publish(completed)
+				}()
+			}
+		})
+		if err != nil {
+			t.Errorf("Watch() error = %v", err)
+			return
+		}
+		if result.Status != "success" {
+			t.Errorf("Watch() status = %q, want success", result.Status)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not return")
+	}
+	if len(updates) != 2 || updates[0] != "running" || updates[1] != "success" {
+		t.Errorf("updates = %v, want [running success]", updates)
+	}
+}
+
+func TestWatchTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"id": 98, "status": "queued"}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	p := &Provider{api: ghapi.FromClient(client), config: Config{Owner: "acme", Repo: "watch"}}
+
+	_, err := p.Watch(context.Background(), "98", WatchOptions{Interval: time.Hour, Timeout: 50 * time.Millisecond}, nil)
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+
+	if _, err := p.Watch(context.Background(), "not-a-number", WatchOptions{}, nil); err == nil {
+		t.Error("expected bad_request for invalid ID")
+	}
+}
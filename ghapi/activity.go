@@ -0,0 +1,76 @@
+package ghapi
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// RepositoryActivity is one entry of the repository activity feed, which
+// go-github does not model yet.
+type RepositoryActivity struct {
+	ID           int64            `json:"id"`
+	Before       string           `json:"before"`
+	After        string           `json:"after"`
+	Ref          string           `json:"ref"`
+	Timestamp    github.Timestamp `json:"timestamp"`
+	ActivityType string           `json:"activity_type"`
+	Actor        *github.User     `json:"actor,omitempty"`
+}
+
+// ActivityListOptions filters the repository activity feed. TimePeriod is one
+// of day, week, month, quarter, or year; After is the cursor from a previous
+// page's Response.After.
+type ActivityListOptions struct {
+	ActivityType string
+	Ref          string
+	Actor        string
+	TimePeriod   string
+	After        string
+	PerPage      int
+}
+
+// activityService implements ActivityService on a go-github client.
+type activityService struct {
+	client *github.Client
+}
+
+// ListRepositoryActivity lists the activity of a repository, newest first.
+func (s activityService) ListRepositoryActivity(ctx context.Context, owner, repo string, opts *ActivityListOptions) ([]*RepositoryActivity, *github.Response, error) {
+	params := url.Values{}
+	if opts != nil {
+		for key, value := range map[string]string{
+			"activity_type": opts.ActivityType,
+			"ref":           opts.Ref,
+			"actor":         opts.Actor,
+			"time_period":   opts.TimePeriod,
+			"after":         opts.After,
+		} {
+			if value != "" {
+				params.Set(key, value)
+			}
+		}
+		if opts.PerPage > 0 {
+			params.Set("per_page", strconv.Itoa(opts.PerPage))
+		}
+	}
+
+	u := fmt.Sprintf("repos/%s/%s/activity", owner, repo)
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var activity []*RepositoryActivity
+	resp, err := s.client.Do(ctx, req, &activity)
+	if err != nil {
+		return nil, resp, err
+	}
+	return activity, resp, nil
+}
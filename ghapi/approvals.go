@@ -0,0 +1,55 @@
+package ghapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// PendingDeployment is an environment a workflow run is waiting to deploy to,
+// with the reviewers who can approve it. go-github can review pending
+// deployments but not list them.
+type PendingDeployment struct {
+	Environment struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"environment"`
+	WaitTimer             int                          `json:"wait_timer"`
+	WaitTimerStartedAt    *github.Timestamp            `json:"wait_timer_started_at,omitempty"`
+	CurrentUserCanApprove bool                         `json:"current_user_can_approve"`
+	Reviewers             []*PendingDeploymentReviewer `json:"reviewers"`
+}
+
+// PendingDeploymentReviewer is a user or team that can approve a pending
+// deployment. Type is "User" or "Team"; Login is set for users and Slug for teams.
+type PendingDeploymentReviewer struct {
+	Type     string `json:"type"`
+	Reviewer struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login,omitempty"`
+		Slug  string `json:"slug,omitempty"`
+		Name  string `json:"name,omitempty"`
+	} `json:"reviewer"`
+}
+
+// approvalsService implements ApprovalsService on a go-github client.
+type approvalsService struct {
+	client *github.Client
+}
+
+// ListPendingDeployments lists the environments a workflow run is waiting on.
+func (s approvalsService) ListPendingDeployments(ctx context.Context, owner, repo string, runID int64) ([]*PendingDeployment, *github.Response, error) {
+	u := fmt.Sprintf("repos/%s/%s/actions/runs/%d/pending_deployments", owner, repo, runID)
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pending []*PendingDeployment
+	resp, err := s.client.Do(ctx, req, &pending)
+	if err != nil {
+		return nil, resp, err
+	}
+	return pending, resp, nil
+}
@@ -0,0 +1,236 @@
+// Package ghapi defines the narrow slices of the GitHub REST API the providers
+// depend on, so they can be backed by *github.Client, fakes, or another
+// implementation such as a GraphQL adapter.
+package ghapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// IssuesService is the subset of the Issues API used by the ticket provider.
+type IssuesService interface {
+	ListByRepo(ctx context.Context, owner, repo string, opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error)
+	Get(ctx context.Context, owner, repo string, number int) (*github.Issue, *github.Response, error)
+	Create(ctx context.Context, owner, repo string, issue *github.IssueRequest) (*github.Issue, *github.Response, error)
+	Edit(ctx context.Context, owner, repo string, number int, issue *github.IssueRequest) (*github.Issue, *github.Response, error)
+	RemoveMilestone(ctx context.Context, owner, repo string, number int) (*github.Issue, *github.Response, error)
+	ListMilestones(ctx context.Context, owner, repo string, opts *github.MilestoneListOptions) ([]*github.Milestone, *github.Response, error)
+	ListComments(ctx context.Context, owner, repo string, number int, opts *github.IssueListCommentsOptions) ([]*github.IssueComment, *github.Response, error)
+	CreateComment(ctx context.Context, owner, repo string, number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error)
+	AddLabelsToIssue(ctx context.Context, owner, repo string, number int, labels []string) ([]*github.Label, *github.Response, error)
+	IsAssignee(ctx context.Context, owner, repo, user string) (bool, *github.Response, error)
+	ListIssueTimeline(ctx context.Context, owner, repo string, number int, opts *github.ListOptions) ([]*github.Timeline, *github.Response, error)
+}
+
+// ActionsService is the subset of the Actions API used by the deployment provider.
+type ActionsService interface {
+	ListRepositoryWorkflowRuns(ctx context.Context, owner, repo string, opts *github.ListWorkflowRunsOptions) (*github.WorkflowRuns, *github.Response, error)
+	GetWorkflowRunByID(ctx context.Context, owner, repo string, runID int64) (*github.WorkflowRun, *github.Response, error)
+	GetWorkflowRunAttempt(ctx context.Context, owner, repo string, runID int64, attemptNumber int, opts *github.WorkflowRunAttemptOptions) (*github.WorkflowRun, *github.Response, error)
+	GetWorkflowByID(ctx context.Context, owner, repo string, workflowID int64) (*github.Workflow, *github.Response, error)
+	ListWorkflowJobs(ctx context.Context, owner, repo string, runID int64, opts *github.ListWorkflowJobsOptions) (*github.Jobs, *github.Response, error)
+	CreateWorkflowDispatchEventByFileName(ctx context.Context, owner, repo, workflowFileName string, event github.CreateWorkflowDispatchEventRequest) (*github.Response, error)
+	ListArtifacts(ctx context.Context, owner, repo string, opts *github.ListOptions) (*github.ArtifactList, *github.Response, error)
+	DeleteArtifact(ctx context.Context, owner, repo string, artifactID int64) (*github.Response, error)
+	ListRepoSecrets(ctx context.Context, owner, repo string, opts *github.ListOptions) (*github.Secrets, *github.Response, error)
+	ListEnvSecrets(ctx context.Context, repoID int, env string, opts *github.ListOptions) (*github.Secrets, *github.Response, error)
+	ListRepoVariables(ctx context.Context, owner, repo string, opts *github.ListOptions) (*github.ActionsVariables, *github.Response, error)
+	ListEnvVariables(ctx context.Context, repoID int, env string, opts *github.ListOptions) (*github.ActionsVariables, *github.Response, error)
+	ListCaches(ctx context.Context, owner, repo string, opts *github.ActionsCacheListOptions) (*github.ActionsCacheList, *github.Response, error)
+	DeleteCachesByID(ctx context.Context, owner, repo string, cacheID int64) (*github.Response, error)
+}
+
+// RepositoriesService is the subset of the Repositories API used to read issue
+// templates, environment deployment history and branch policies, commits,
+// repositories, protected branches, and commit statuses, to commit file changes
+// and create releases, and to set repository topics.
+type RepositoriesService interface {
+	Get(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error)
+	ListByOrg(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)
+	GetCommit(ctx context.Context, owner, repo, sha string, opts *github.ListOptions) (*github.RepositoryCommit, *github.Response, error)
+	GetContents(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentGetOptions) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error)
+	ListDeployments(ctx context.Context, owner, repo string, opts *github.DeploymentsListOptions) ([]*github.Deployment, *github.Response, error)
+	GetDeployment(ctx context.Context, owner, repo string, deploymentID int64) (*github.Deployment, *github.Response, error)
+	ListDeploymentStatuses(ctx context.Context, owner, repo string, deployment int64, opts *github.ListOptions) ([]*github.DeploymentStatus, *github.Response, error)
+	GetEnvironment(ctx context.Context, owner, repo, name string) (*github.Environment, *github.Response, error)
+	ListDeploymentBranchPolicies(ctx context.Context, owner, repo, environment string) (*github.DeploymentBranchPolicyResponse, *github.Response, error)
+	ListBranches(ctx context.Context, owner, repo string, opts *github.BranchListOptions) ([]*github.Branch, *github.Response, error)
+	GetBranch(ctx context.Context, owner, repo, branch string, maxRedirects int) (*github.Branch, *github.Response, error)
+	CreateRelease(ctx context.Context, owner, repo string, release *github.RepositoryRelease) (*github.RepositoryRelease, *github.Response, error)
+	GetCombinedStatus(ctx context.Context, owner, repo, ref string, opts *github.ListOptions) (*github.CombinedStatus, *github.Response, error)
+	CreateFile(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse, *github.Response, error)
+	UpdateFile(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse, *github.Response, error)
+	ReplaceAllTopics(ctx context.Context, owner, repo string, topics []string) ([]string, *github.Response, error)
+}
+
+// GitService is the subset of the Git database API used to manage branches and
+// tags.
+type GitService interface {
+	GetRef(ctx context.Context, owner, repo, ref string) (*github.Reference, *github.Response, error)
+	CreateRef(ctx context.Context, owner, repo string, ref *github.Reference) (*github.Reference, *github.Response, error)
+	DeleteRef(ctx context.Context, owner, repo, ref string) (*github.Response, error)
+	GetTag(ctx context.Context, owner, repo, sha string) (*github.Tag, *github.Response, error)
+	CreateTag(ctx context.Context, owner, repo string, tag *github.Tag) (*github.Tag, *github.Response, error)
+}
+
+// PullRequestsService is the subset of the Pull Requests API used to propose
+// file changes for review, to merge or close the pull requests, and to read
+// their reviews.
+type PullRequestsService interface {
+	Get(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error)
+	Create(ctx context.Context, owner, repo string, pull *github.NewPullRequest) (*github.PullRequest, *github.Response, error)
+	Edit(ctx context.Context, owner, repo string, number int, pull *github.PullRequest) (*github.PullRequest, *github.Response, error)
+	Merge(ctx context.Context, owner, repo string, number int, commitMessage string, opts *github.PullRequestOptions) (*github.PullRequestMergeResult, *github.Response, error)
+	RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers github.ReviewersRequest) (*github.PullRequest, *github.Response, error)
+	ListReviews(ctx context.Context, owner, repo string, number int, opts *github.ListOptions) ([]*github.PullRequestReview, *github.Response, error)
+}
+
+// ChecksService is the subset of the Checks API used to read deployment
+// annotations and the checks a pull request is waiting on, and to publish the
+// change approval check.
+type ChecksService interface {
+	ListCheckRunsForRef(ctx context.Context, owner, repo, ref string, opts *github.ListCheckRunsOptions) (*github.ListCheckRunsResults, *github.Response, error)
+	CreateCheckRun(ctx context.Context, owner, repo string, opts github.CreateCheckRunOptions) (*github.CheckRun, *github.Response, error)
+	UpdateCheckRun(ctx context.Context, owner, repo string, checkRunID int64, opts github.UpdateCheckRunOptions) (*github.CheckRun, *github.Response, error)
+	ListCheckRunAnnotations(ctx context.Context, owner, repo string, checkRunID int64, opts *github.ListOptions) ([]*github.CheckRunAnnotation, *github.Response, error)
+}
+
+// SecretScanningService is the subset of the Secret Scanning API used to
+// surface push protection bypasses.
+type SecretScanningService interface {
+	ListAlertsForRepo(ctx context.Context, owner, repo string, opts *github.SecretScanningAlertListOptions) ([]*github.SecretScanningAlert, *github.Response, error)
+	GetAlert(ctx context.Context, owner, repo string, number int64) (*github.SecretScanningAlert, *github.Response, error)
+}
+
+// ActivityService reads the repository activity feed, which records pushes,
+// force pushes, and branch changes.
+type ActivityService interface {
+	ListRepositoryActivity(ctx context.Context, owner, repo string, opts *ActivityListOptions) ([]*RepositoryActivity, *github.Response, error)
+}
+
+// ApprovalsService lists the environment approvals a workflow run is waiting on.
+type ApprovalsService interface {
+	ListPendingDeployments(ctx context.Context, owner, repo string, runID int64) ([]*PendingDeployment, *github.Response, error)
+}
+
+// PropertiesService reads and sets the custom property values of an
+// organization's repositories.
+type PropertiesService interface {
+	ListOrganizationPropertyValues(ctx context.Context, org string, opts *github.ListOptions) ([]*RepositoryPropertyValues, *github.Response, error)
+	ListRepositoryPropertyValues(ctx context.Context, owner, repo string) ([]*PropertyValue, *github.Response, error)
+	SetRepositoryPropertyValues(ctx context.Context, org, repo string, values []*PropertyValue) (*github.Response, error)
+}
+
+// GistsService is the subset of the Gists API used to host ticket attachments
+// and scratchpads.
+type GistsService interface {
+	Create(ctx context.Context, gist *github.Gist) (*github.Gist, *github.Response, error)
+	Get(ctx context.Context, id string) (*github.Gist, *github.Response, error)
+	Edit(ctx context.Context, id string, gist *github.Gist) (*github.Gist, *github.Response, error)
+	Delete(ctx context.Context, id string) (*github.Response, error)
+}
+
+// SearchService is the subset of the Search API used for organization-wide ticket queries.
+type SearchService interface {
+	Issues(ctx context.Context, query string, opts *github.SearchOptions) (*github.IssuesSearchResult, *github.Response, error)
+}
+
+// MarkdownService is the subset of the Markdown API used to render issue bodies as HTML.
+type MarkdownService interface {
+	Render(ctx context.Context, text string, opts *github.MarkdownOptions) (string, *github.Response, error)
+}
+
+// TeamsService is the subset of the Teams API used by the team provider.
+type TeamsService interface {
+	ListTeams(ctx context.Context, org string, opts *github.ListOptions) ([]*github.Team, *github.Response, error)
+	GetTeamBySlug(ctx context.Context, org, slug string) (*github.Team, *github.Response, error)
+	GetTeamByID(ctx context.Context, orgID, teamID int64) (*github.Team, *github.Response, error)
+	ListTeamMembersByID(ctx context.Context, orgID, teamID int64, opts *github.TeamListTeamMembersOptions) ([]*github.User, *github.Response, error)
+	GetTeamMembershipByID(ctx context.Context, orgID, teamID int64, user string) (*github.Membership, *github.Response, error)
+	ListTeamMembersBySlug(ctx context.Context, org, slug string, opts *github.TeamListTeamMembersOptions) ([]*github.User, *github.Response, error)
+	GetTeamMembershipBySlug(ctx context.Context, org, slug, user string) (*github.Membership, *github.Response, error)
+	ListChildTeamsByParentSlug(ctx context.Context, org, slug string, opts *github.ListOptions) ([]*github.Team, *github.Response, error)
+	ListChildTeamsByParentID(ctx context.Context, orgID, teamID int64, opts *github.ListOptions) ([]*github.Team, *github.Response, error)
+}
+
+// OrganizationsService is the subset of the Organizations API used by the team provider.
+type OrganizationsService interface {
+	Get(ctx context.Context, org string) (*github.Organization, *github.Response, error)
+	GetOrgMembership(ctx context.Context, user, org string) (*github.Membership, *github.Response, error)
+	ListMembers(ctx context.Context, org string, opts *github.ListMembersOptions) ([]*github.User, *github.Response, error)
+}
+
+// UsersService is the subset of the Users API used by the team provider.
+type UsersService interface {
+	Get(ctx context.Context, user string) (*github.User, *github.Response, error)
+}
+
+// GraphQLService runs GraphQL queries, for reads the REST API would need many
+// calls for.
+type GraphQLService interface {
+	Query(ctx context.Context, query string, variables map[string]any, out any) (*github.Response, error)
+}
+
+// Requester issues arbitrary API requests for the raw passthrough.
+type Requester interface {
+	NewRequest(method, urlStr string, body interface{}, opts ...github.RequestOption) (*http.Request, error)
+	Do(ctx context.Context, req *http.Request, v interface{}) (*github.Response, error)
+}
+
+// Services bundles the API implementations a provider calls. Providers only
+// require the services they use; Requester may be nil to disable raw access,
+// Repositories to disable issue templates and environment deployment history,
+// Checks to disable deployment annotations, Approvals to disable pending
+// deployment approvers, Gists to disable attachments and scratchpads, Search to
+// disable organization-wide ticket queries, SecretScanning and Activity to
+// disable the corresponding alert sources, GraphQL to disable team snapshots,
+// Git to disable branch and tag management, PullRequests to disable
+// proposing them as pull requests, and Properties to disable custom property
+// service tags.
+type Services struct {
+	Issues         IssuesService
+	Actions        ActionsService
+	Repositories   RepositoriesService
+	Checks         ChecksService
+	Approvals      ApprovalsService
+	Gists          GistsService
+	Search         SearchService
+	Markdown       MarkdownService
+	SecretScanning SecretScanningService
+	Activity       ActivityService
+	Teams          TeamsService
+	Organizations  OrganizationsService
+	Users          UsersService
+	GraphQL        GraphQLService
+	Git            GitService
+	PullRequests   PullRequestsService
+	Properties     PropertiesService
+	Requester      Requester
+}
+
+// FromClient returns the services backed by a go-github client.
+func FromClient(client *github.Client) Services {
+	return Services{
+		Issues:         client.Issues,
+		Actions:        client.Actions,
+		Repositories:   client.Repositories,
+		Checks:         client.Checks,
+		Approvals:      approvalsService{client},
+		Gists:          client.Gists,
+		Search:         client.Search,
+		Markdown:       client.Markdown,
+		SecretScanning: client.SecretScanning,
+		Activity:       activityService{client},
+		Teams:          client.Teams,
+		Organizations:  client.Organizations,
+		Users:          client.Users,
+		GraphQL:        graphQLService{client},
+		Git:            client.Git,
+		PullRequests:   client.PullRequests,
+		Properties:     propertiesService{client},
+		Requester:      client,
+	}
+}
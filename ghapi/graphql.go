@@ -0,0 +1,64 @@
+package ghapi
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// GraphQLError reports the errors a GraphQL response carried alongside, or
+// instead of, its data. GitHub answers such queries with status 200.
+type GraphQLError struct {
+	Errors []GraphQLErrorEntry
+}
+
+// GraphQLErrorEntry is one entry of a GraphQL response's errors list. Type is
+// GitHub's classification, such as NOT_FOUND or FORBIDDEN.
+type GraphQLErrorEntry struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+func (e *GraphQLError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, entry := range e.Errors {
+		messages[i] = entry.Message
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// graphQLService implements GraphQLService on a go-github client, posting to
+// the graphql endpoint next to the REST base URL.
+type graphQLService struct {
+	client *github.Client
+}
+
+// Query runs a GraphQL query and decodes its data into out.
+func (s graphQLService) Query(ctx context.Context, query string, variables map[string]any, out any) (*github.Response, error) {
+	// GitHub Enterprise Server serves GraphQL at /api/graphql, beside /api/v3/
+	u := "graphql"
+	if strings.HasSuffix(s.client.BaseURL.Path, "/api/v3/") {
+		u = "../graphql"
+	}
+
+	body := map[string]any{"query": query, "variables": variables}
+	req, err := s.client.NewRequest("POST", u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data   json.RawMessage     `json:"data"`
+		Errors []GraphQLErrorEntry `json:"errors"`
+	}
+	resp, err := s.client.Do(ctx, req, &result)
+	if err != nil {
+		return resp, err
+	}
+	if len(result.Errors) > 0 {
+		return resp, &GraphQLError{Errors: result.Errors}
+	}
+	return resp, json.Unmarshal(result.Data, out)
+}
@@ -0,0 +1,82 @@
+package ghapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// PropertyValue is the value of one custom property of a repository. Value is
+// a string, a []any of strings for multi-select properties, or nil when unset;
+// setting nil removes the value. go-github decodes values as strings only, so
+// multi-select properties fail to decode there.
+type PropertyValue struct {
+	Name  string `json:"property_name"`
+	Value any    `json:"value"`
+}
+
+// RepositoryPropertyValues is the custom property values of one repository of
+// an organization.
+type RepositoryPropertyValues struct {
+	RepositoryID       int64            `json:"repository_id"`
+	RepositoryName     string           `json:"repository_name"`
+	RepositoryFullName string           `json:"repository_full_name"`
+	Properties         []*PropertyValue `json:"properties"`
+}
+
+// propertiesService implements PropertiesService on a go-github client.
+type propertiesService struct {
+	client *github.Client
+}
+
+// ListOrganizationPropertyValues lists the custom property values of every
+// repository of org.
+func (s propertiesService) ListOrganizationPropertyValues(ctx context.Context, org string, opts *github.ListOptions) ([]*RepositoryPropertyValues, *github.Response, error) {
+	u := fmt.Sprintf("orgs/%s/properties/values", org)
+	if opts != nil {
+		u = fmt.Sprintf("%s?per_page=%d&page=%d", u, opts.PerPage, opts.Page)
+	}
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var values []*RepositoryPropertyValues
+	resp, err := s.client.Do(ctx, req, &values)
+	if err != nil {
+		return nil, resp, err
+	}
+	return values, resp, nil
+}
+
+// ListRepositoryPropertyValues lists the custom property values of a repository.
+func (s propertiesService) ListRepositoryPropertyValues(ctx context.Context, owner, repo string) ([]*PropertyValue, *github.Response, error) {
+	u := fmt.Sprintf("repos/%s/%s/properties/values", owner, repo)
+	req, err := s.client.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var values []*PropertyValue
+	resp, err := s.client.Do(ctx, req, &values)
+	if err != nil {
+		return nil, resp, err
+	}
+	return values, resp, nil
+}
+
+// SetRepositoryPropertyValues sets custom property values of a repository of
+// org, leaving the properties not given as they are.
+func (s propertiesService) SetRepositoryPropertyValues(ctx context.Context, org, repo string, values []*PropertyValue) (*github.Response, error) {
+	u := fmt.Sprintf("orgs/%s/properties/values", org)
+	body := struct {
+		RepositoryNames []string         `json:"repository_names"`
+		Properties      []*PropertyValue `json:"properties"`
+	}{[]string{repo}, values}
+	req, err := s.client.NewRequest("PATCH", u, body)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(ctx, req, nil)
+}
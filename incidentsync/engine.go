@@ -0,0 +1,440 @@
+// Package incidentsync keeps an OpsOrch incident and a GitHub issue in lockstep.
+// Status and severity (as issue labels), assignees, and timeline comments flow both
+// ways. Field changes are reconciled with a three-way merge against the last synced
+// state; comments carry markers so nothing is mirrored back to where it came from.
+package incidentsync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/opsorch/opsorch-core/incident"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/ticket"
+)
+
+// Conflict resolution policies applied when both sides changed the same field.
+const (
+	PolicyLatestWins   = "latest_wins"
+	PolicyIncidentWins = "incident_wins"
+	PolicyIssueWins    = "issue_wins"
+)
+
+// commentMarker tags issue comments written by the engine so they are never mirrored
+// back onto the incident timeline.
+const commentMarker = "<!-- opsorch-sync:timeline:%s -->"
+
+// timelineSource marks timeline entries the engine mirrored from GitHub comments.
+const timelineSource = "github"
+
+// Tickets is the subset of the ticket provider the engine uses.
+type Tickets interface {
+	Get(ctx context.Context, id string) (schema.Ticket, error)
+	Update(ctx context.Context, id string, in schema.UpdateTicketInput) (schema.Ticket, error)
+	Comments(ctx context.Context, id string) ([]ticket.Comment, error)
+	AddComment(ctx context.Context, id, body string, metadata map[string]any) (ticket.Comment, error)
+}
+
+// Config holds the configuration for the sync engine.
+type Config struct {
+	StatusLabelPrefix   string   `json:"statusLabelPrefix"`   // Prefix of the issue label carrying incident status
+	SeverityLabelPrefix string   `json:"severityLabelPrefix"` // Prefix of the issue label carrying incident severity
+	ClosedStatuses      []string `json:"closedStatuses"`      // Incident statuses that close the issue
+	ConflictPolicy      string   `json:"conflictPolicy"`      // latest_wins, incident_wins, or issue_wins
+}
+
+// Engine reconciles linked incident/issue pairs.
+type Engine struct {
+	incidents incident.Provider
+	tickets   Tickets
+	config    Config
+
+	mu   sync.Mutex
+	base map[string]state // last synced state per pair
+}
+
+// state is the comparable shape both sides are projected onto.
+type state struct {
+	Status    string
+	Severity  string
+	Assignees []string
+}
+
+// Result summarizes one reconciliation pass.
+type Result struct {
+	IncidentID         string   `json:"incidentId"`
+	TicketID           string   `json:"ticketId"`
+	IssueUpdated       bool     `json:"issueUpdated"`
+	IncidentUpdated    bool     `json:"incidentUpdated"`
+	Conflicts          []string `json:"conflicts,omitempty"`
+	CommentsToIssue    int      `json:"commentsToIssue"`
+	CommentsToTimeline int      `json:"commentsToTimeline"`
+}
+
+// New creates a sync engine between an incident provider and a GitHub ticket provider.
+func New(cfg map[string]any, incidents incident.Provider, tickets Tickets) (*Engine, error) {
+	if incidents == nil {
+		return nil, fmt.Errorf("incident provider is required")
+	}
+	if tickets == nil {
+		return nil, fmt.Errorf("ticket provider is required")
+	}
+
+	config := Config{
+		StatusLabelPrefix:   ghconfig.String(cfg, "statusLabelPrefix"),
+		SeverityLabelPrefix: ghconfig.String(cfg, "severityLabelPrefix"),
+		ClosedStatuses:      ghconfig.StringSlice(cfg, "closedStatuses"),
+		ConflictPolicy:      ghconfig.String(cfg, "conflictPolicy"),
+	}
+	if config.StatusLabelPrefix == "" {
+		config.StatusLabelPrefix = "status:"
+	}
+	if config.SeverityLabelPrefix == "" {
+		config.SeverityLabelPrefix = "severity:"
+	}
+	if len(config.ClosedStatuses) == 0 {
+		config.ClosedStatuses = []string{"resolved", "closed"}
+	}
+	switch config.ConflictPolicy {
+	case "":
+		config.ConflictPolicy = PolicyLatestWins
+	case PolicyLatestWins, PolicyIncidentWins, PolicyIssueWins:
+	default:
+		return nil, fmt.Errorf("unknown conflictPolicy: %s", config.ConflictPolicy)
+	}
+
+	return &Engine{
+		incidents: incidents,
+		tickets:   tickets,
+		config:    config,
+		base:      map[string]state{},
+	}, nil
+}
+
+// Sync performs one reconciliation pass between an incident and a ticket.
+func (e *Engine) Sync(ctx context.Context, incidentID, ticketID string) (Result, error) {
+	result := Result{IncidentID: incidentID, TicketID: ticketID}
+
+	inc, err := e.incidents.Get(ctx, incidentID)
+	if err != nil {
+		return result, err
+	}
+	tkt, err := e.tickets.Get(ctx, ticketID)
+	if err != nil {
+		return result, err
+	}
+
+	key := incidentID + "|" + ticketID
+	e.mu.Lock()
+	base, seen := e.base[key]
+	e.mu.Unlock()
+
+	fromIncident := e.incidentState(inc)
+	fromIssue := e.issueState(tkt, fromIncident, seen)
+	incidentIsNewer := !inc.UpdatedAt.Before(tkt.UpdatedAt)
+
+	target, conflicts := e.merge(base, seen, fromIncident, fromIssue, incidentIsNewer)
+	result.Conflicts = conflicts
+
+	if !sameState(fromIssue, target) || !e.labelsMatch(tkt, target) || !assigneesMatch(tkt, target) {
+		if err := e.updateIssue(ctx, tkt, target); err != nil {
+			return result, err
+		}
+		result.IssueUpdated = true
+	}
+	if !sameState(fromIncident, target) {
+		if err := e.updateIncident(ctx, inc, target); err != nil {
+			return result, err
+		}
+		result.IncidentUpdated = true
+	}
+
+	e.mu.Lock()
+	e.base[key] = target
+	e.mu.Unlock()
+
+	if result.CommentsToIssue, result.CommentsToTimeline, err = e.syncComments(ctx, incidentID, ticketID); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// merge computes the reconciled state field by field. A field changed on only one
+// side since the last sync takes that side's value; a field changed on both sides
+// is a conflict resolved by the configured policy. Without a base (first pass)
+// every differing field is treated as a conflict.
+func (e *Engine) merge(base state, seen bool, inc, issue state, incidentIsNewer bool) (state, []string) {
+	var conflicts []string
+
+	pick := func(field string, baseVal, incVal, issueVal string) string {
+		if incVal == issueVal {
+			return incVal
+		}
+		incChanged := !seen || incVal != baseVal
+		issueChanged := !seen || issueVal != baseVal
+		switch {
+		case incChanged && !issueChanged:
+			return incVal
+		case issueChanged && !incChanged:
+			return issueVal
+		}
+		if seen {
+			conflicts = append(conflicts, field)
+		}
+		if e.incidentWins(incidentIsNewer) {
+			return incVal
+		}
+		return issueVal
+	}
+
+	target := state{
+		Status:   pick("status", base.Status, inc.Status, issue.Status),
+		Severity: pick("severity", base.Severity, inc.Severity, issue.Severity),
+	}
+	assignees := pick("assignees", joinLogins(base.Assignees), joinLogins(inc.Assignees), joinLogins(issue.Assignees))
+	target.Assignees = splitLogins(assignees)
+
+	return target, conflicts
+}
+
+func (e *Engine) incidentWins(incidentIsNewer bool) bool {
+	switch e.config.ConflictPolicy {
+	case PolicyIncidentWins:
+		return true
+	case PolicyIssueWins:
+		return false
+	default:
+		return incidentIsNewer
+	}
+}
+
+// incidentState projects an incident onto the comparable state.
+func (e *Engine) incidentState(inc schema.Incident) state {
+	return state{
+		Status:    strings.ToLower(inc.Status),
+		Severity:  strings.ToLower(inc.Severity),
+		Assignees: sortedLogins(ghconfig.StringSlice(inc.Fields, "assignees")),
+	}
+}
+
+// issueState projects an issue onto the comparable state. Status and severity come
+// from prefixed labels; an issue closed or reopened by hand without touching the
+// status label maps to the first closed status or back to "open". Before the
+// first sync (seen false), an issue without a status or severity label, or
+// without assignees, takes the incident's, so a newer issue that never carried
+// them does not blank the incident's. Once synced, a missing value is a clear
+// made on GitHub and is merged like any other change.
+func (e *Engine) issueState(tkt schema.Ticket, inc state, seen bool) state {
+	s := state{Assignees: sortedLogins(tkt.Assignees)}
+	s.Status, s.Severity = e.labelValues(tkt)
+	if !seen {
+		if s.Status == "" {
+			s.Status = inc.Status
+		}
+		if s.Severity == "" {
+			s.Severity = inc.Severity
+		}
+		if len(s.Assignees) == 0 {
+			s.Assignees = inc.Assignees
+		}
+	}
+
+	closed := tkt.Status == "closed"
+	if closed && !e.isClosed(s.Status) {
+		s.Status = e.config.ClosedStatuses[0]
+	}
+	if !closed && e.isClosed(s.Status) {
+		s.Status = "open"
+	}
+	return s
+}
+
+// labelsMatch reports whether the issue's status and severity labels already carry
+// the target values, which can differ from the projected state after a manual close.
+func (e *Engine) labelsMatch(tkt schema.Ticket, target state) bool {
+	status, severity := e.labelValues(tkt)
+	return status == target.Status && severity == target.Severity
+}
+
+// assigneesMatch reports whether the issue already has the target assignees,
+// which can differ from the projected state when the issue had none.
+func assigneesMatch(tkt schema.Ticket, target state) bool {
+	return joinLogins(sortedLogins(tkt.Assignees)) == joinLogins(target.Assignees)
+}
+
+// labelValues returns the values of the issue's status and severity labels.
+func (e *Engine) labelValues(tkt schema.Ticket) (status, severity string) {
+	for _, label := range ticketLabels(tkt) {
+		lower := strings.ToLower(label)
+		if strings.HasPrefix(lower, e.config.StatusLabelPrefix) {
+			status = strings.TrimPrefix(lower, e.config.StatusLabelPrefix)
+		}
+		if strings.HasPrefix(lower, e.config.SeverityLabelPrefix) {
+			severity = strings.TrimPrefix(lower, e.config.SeverityLabelPrefix)
+		}
+	}
+	return status, severity
+}
+
+func (e *Engine) isClosed(status string) bool {
+	for _, closed := range e.config.ClosedStatuses {
+		if strings.EqualFold(status, closed) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateIssue writes the target state to the issue, keeping unmanaged labels.
+func (e *Engine) updateIssue(ctx context.Context, tkt schema.Ticket, target state) error {
+	var labels []string
+	for _, label := range ticketLabels(tkt) {
+		lower := strings.ToLower(label)
+		if strings.HasPrefix(lower, e.config.StatusLabelPrefix) || strings.HasPrefix(lower, e.config.SeverityLabelPrefix) {
+			continue
+		}
+		labels = append(labels, label)
+	}
+	if target.Status != "" {
+		labels = append(labels, e.config.StatusLabelPrefix+target.Status)
+	}
+	if target.Severity != "" {
+		labels = append(labels, e.config.SeverityLabelPrefix+target.Severity)
+	}
+
+	status := "open"
+	if e.isClosed(target.Status) {
+		status = "closed"
+	}
+	assignees := target.Assignees
+	if assignees == nil {
+		assignees = []string{}
+	}
+
+	_, err := e.tickets.Update(ctx, tkt.ID, schema.UpdateTicketInput{
+		Status:    &status,
+		Assignees: &assignees,
+		Metadata:  map[string]any{"labels": labels},
+	})
+	return err
+}
+
+// updateIncident writes the target state to the incident.
+func (e *Engine) updateIncident(ctx context.Context, inc schema.Incident, target state) error {
+	fields := map[string]any{}
+	for k, v := range inc.Fields {
+		fields[k] = v
+	}
+	fields["assignees"] = target.Assignees
+
+	_, err := e.incidents.Update(ctx, inc.ID, schema.UpdateIncidentInput{
+		Status:   &target.Status,
+		Severity: &target.Severity,
+		Fields:   fields,
+	})
+	return err
+}
+
+// syncComments mirrors timeline entries to issue comments and issue comments to
+// timeline entries. Entries the engine created on either side are recognized by
+// their marker and never mirrored back.
+func (e *Engine) syncComments(ctx context.Context, incidentID, ticketID string) (toIssue, toTimeline int, err error) {
+	timeline, err := e.incidents.GetTimeline(ctx, incidentID)
+	if err != nil {
+		return 0, 0, err
+	}
+	comments, err := e.tickets.Comments(ctx, ticketID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	mirroredEntries := map[string]bool{}
+	for _, c := range comments {
+		if id, ok := markerEntryID(c.Body); ok {
+			mirroredEntries[id] = true
+		}
+	}
+	mirroredComments := map[string]bool{}
+	for _, entry := range timeline {
+		if id, ok := entry.Metadata["github_comment_id"].(string); ok {
+			mirroredComments[id] = true
+		}
+	}
+
+	for _, entry := range timeline {
+		if entry.Metadata["source"] == timelineSource || mirroredEntries[entry.ID] || strings.TrimSpace(entry.Body) == "" {
+			continue
+		}
+		body := fmt.Sprintf(commentMarker, entry.ID) + "\n" + entry.Body
+		if _, err := e.tickets.AddComment(ctx, ticketID, body, nil); err != nil {
+			return toIssue, toTimeline, err
+		}
+		toIssue++
+	}
+
+	for _, c := range comments {
+		if _, ok := markerEntryID(c.Body); ok || mirroredComments[c.ID] {
+			continue
+		}
+		err := e.incidents.AppendTimeline(ctx, incidentID, schema.TimelineAppendInput{
+			At:    c.CreatedAt,
+			Kind:  "comment",
+			Body:  c.Body,
+			Actor: map[string]any{"login": c.Author},
+			Metadata: map[string]any{
+				"source":            timelineSource,
+				"github_comment_id": c.ID,
+				"url":               c.URL,
+			},
+		})
+		if err != nil {
+			return toIssue, toTimeline, err
+		}
+		toTimeline++
+	}
+
+	return toIssue, toTimeline, nil
+}
+
+// markerEntryID extracts the timeline entry ID from a comment written by the engine.
+func markerEntryID(body string) (string, bool) {
+	prefix, suffix := "<!-- opsorch-sync:timeline:", " -->"
+	if !strings.HasPrefix(body, prefix) {
+		return "", false
+	}
+	rest := strings.TrimPrefix(body, prefix)
+	end := strings.Index(rest, suffix)
+	if end < 0 {
+		return "", false
+	}
+	return rest[:end], true
+}
+
+func ticketLabels(tkt schema.Ticket) []string {
+	return ghconfig.StringSlice(tkt.Fields, "labels")
+}
+
+func sameState(a, b state) bool {
+	return a.Status == b.Status && a.Severity == b.Severity && joinLogins(a.Assignees) == joinLogins(b.Assignees)
+}
+
+func sortedLogins(logins []string) []string {
+	out := append([]string(nil), logins...)
+	sort.Strings(out)
+	return out
+}
+
+func joinLogins(logins []string) string {
+	return strings.Join(logins, ",")
+}
+
+func splitLogins(joined string) []string {
+	if joined == "" {
+		return nil
+	}
+	return strings.Split(joined, ",")
+}
@@ -0,0 +1,245 @@
+package incidentsync
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ticket"
+)
+
+type fakeIncidents struct {
+	incident schema.Incident
+	timeline []schema.TimelineEntry
+}
+
+func (f *fakeIncidents) Query(context.Context, schema.IncidentQuery) ([]schema.Incident, error) {
+	return []schema.Incident{f.incident}, nil
+}
+
+func (f *fakeIncidents) Get(context.Context, string) (schema.Incident, error) {
+	return f.incident, nil
+}
+
+func (f *fakeIncidents) Create(context.Context, schema.CreateIncidentInput) (schema.Incident, error) {
+	return f.incident, nil
+}
+
+func (f *fakeIncidents) Update(_ context.Context, _ string, in schema.UpdateIncidentInput) (schema.Incident, error) {
+	if in.Status != nil {
+		f.incident.Status = *in.Status
+	}
+	if in.Severity != nil {
+		f.incident.Severity = *in.Severity
+	}
+	if in.Fields != nil {
+		f.incident.Fields = in.Fields
+	}
+	return f.incident, nil
+}
+
+func (f *fakeIncidents) GetTimeline(context.Context, string) ([]schema.TimelineEntry, error) {
+	return f.timeline, nil
+}
+
+func (f *fakeIncidents) AppendTimeline(_ context.Context, id string, in schema.TimelineAppendInput) error {
+	f.timeline = append(f.timeline, schema.TimelineEntry{
+		ID:         "t" + strconv.Itoa(len(f.timeline)+1),
+		IncidentID: id,
+		At:         in.At,
+		Kind:       in.Kind,
+		Body:       in.Body,
+		Metadata:   in.Metadata,
+	})
+	return nil
+}
+
+type fakeTickets struct {
+	ticket   schema.Ticket
+	comments []ticket.Comment
+	updates  int
+}
+
+func (f *fakeTickets) Get(context.Context, string) (schema.Ticket, error) {
+	return f.ticket, nil
+}
+
+func (f *fakeTickets) Update(_ context.Context, _ string, in schema.UpdateTicketInput) (schema.Ticket, error) {
+	f.updates++
+	if in.Status != nil {
+		f.ticket.Status = *in.Status
+	}
+	if in.Assignees != nil {
+		f.ticket.Assignees = *in.Assignees
+	}
+	if labels, ok := in.Metadata["labels"].([]string); ok {
+		f.ticket.Fields["labels"] = labels
+	}
+	return f.ticket, nil
+}
+
+func (f *fakeTickets) Comments(context.Context, string) ([]ticket.Comment, error) {
+	return f.comments, nil
+}
+
+func (f *fakeTickets) AddComment(_ context.Context, _ string, body string, _ map[string]any) (ticket.Comment, error) {
+	c := ticket.Comment{ID: "c" + strconv.Itoa(len(f.comments)+1), Body: body}
+	f.comments = append(f.comments, c)
+	return c, nil
+}
+
+func TestSync(t *testing.T) {
+	now := time.Now()
+	incidents := &fakeIncidents{
+		incident: schema.Incident{ID: "inc-1", Status: "investigating", Severity: "sev1", UpdatedAt: now},
+		timeline: []schema.TimelineEntry{{ID: "e1", Kind: "note", Body: "Paged on-call"}},
+	}
+	tickets := &fakeTickets{
+		ticket:   schema.Ticket{ID: "42", Status: "open", UpdatedAt: now.Add(-time.Hour), Fields: map[string]any{"labels": []string{"bug"}}},
+		comments: []ticket.Comment{{ID: "100", Author: "octocat", Body: "Looking at the DB"}},
+	}
+
+	engine, err := New(map[string]any{}, incidents, tickets)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+
+	// First pass: incident is newer, so its status/severity land on the issue
+	result, err := engine.Sync(ctx, "inc-1", "42")
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if !result.IssueUpdated || result.IncidentUpdated {
+		t.Errorf("first pass result = %+v", result)
+	}
+	labels := tickets.ticket.Fields["labels"].([]string)
+	if len(labels) != 3 || labels[0] != "bug" || labels[1] != "status:investigating" || labels[2] != "severity:sev1" {
+		t.Errorf("issue labels = %v", labels)
+	}
+	if result.CommentsToIssue != 1 || result.CommentsToTimeline != 1 {
+		t.Errorf("first pass comments = %+v", result)
+	}
+
+	// Second pass: nothing changed, and mirrored comments must not loop back
+	result, err = engine.Sync(ctx, "inc-1", "42")
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.IssueUpdated || result.IncidentUpdated || result.CommentsToIssue != 0 || result.CommentsToTimeline != 0 {
+		t.Errorf("second pass should be a no-op: %+v", result)
+	}
+
+	// Third pass: the issue was closed by hand, which resolves the incident
+	tickets.ticket.Status = "closed"
+	result, err = engine.Sync(ctx, "inc-1", "42")
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if !result.IncidentUpdated || incidents.incident.Status != "resolved" {
+		t.Errorf("third pass result = %+v, incident status = %q", result, incidents.incident.Status)
+	}
+	if !result.IssueUpdated {
+		t.Errorf("status label should be rewritten to resolved: %+v", result)
+	}
+}
+
+func TestSyncIssueNewer(t *testing.T) {
+	now := time.Now()
+	incidents := &fakeIncidents{
+		incident: schema.Incident{ID: "inc-1", Status: "investigating", Severity: "sev1", UpdatedAt: now.Add(-time.Hour), Fields: map[string]any{"assignees": []string{"alice"}}},
+	}
+	// The issue is newer but carries no severity label and no assignees
+	tickets := &fakeTickets{
+		ticket: schema.Ticket{ID: "42", Status: "open", UpdatedAt: now, Fields: map[string]any{"labels": []string{"status:identified"}}},
+	}
+
+	engine, err := New(map[string]any{}, incidents, tickets)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	result, err := engine.Sync(context.Background(), "inc-1", "42")
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	// The issue's status wins, and the incident keeps its severity and assignees
+	if incidents.incident.Status != "identified" || incidents.incident.Severity != "sev1" {
+		t.Errorf("incident = %+v", incidents.incident)
+	}
+	if assignees, _ := incidents.incident.Fields["assignees"].([]string); len(assignees) != 1 || assignees[0] != "alice" {
+		t.Errorf("incident assignees = %v, want [alice]", incidents.incident.Fields["assignees"])
+	}
+	// and they are written to the issue
+	if !result.IssueUpdated || len(tickets.ticket.Assignees) != 1 || tickets.ticket.Assignees[0] != "alice" {
+		t.Errorf("result = %+v, issue assignees = %v", result, tickets.ticket.Assignees)
+	}
+	labels := tickets.ticket.Fields["labels"].([]string)
+	if len(labels) != 2 || labels[0] != "status:identified" || labels[1] != "severity:sev1" {
+		t.Errorf("issue labels = %v", labels)
+	}
+}
+
+func TestSyncIssueClear(t *testing.T) {
+	now := time.Now()
+	incidents := &fakeIncidents{
+		incident: schema.Incident{ID: "inc-1", Status: "investigating", Severity: "sev1", UpdatedAt: now, Fields: map[string]any{"assignees": []string{"alice"}}},
+	}
+	tickets := &fakeTickets{
+		ticket: schema.Ticket{ID: "42", Status: "open", UpdatedAt: now.Add(-time.Hour), Fields: map[string]any{"labels": []string{}}},
+	}
+
+	engine, err := New(map[string]any{}, incidents, tickets)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	if _, err := engine.Sync(ctx, "inc-1", "42"); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	// The severity label and assignees are removed on GitHub
+	tickets.ticket.Fields["labels"] = []string{"status:investigating"}
+	tickets.ticket.Assignees = nil
+	tickets.ticket.UpdatedAt = now.Add(time.Hour)
+	result, err := engine.Sync(ctx, "inc-1", "42")
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if !result.IncidentUpdated || incidents.incident.Severity != "" {
+		t.Errorf("result = %+v, incident severity = %q, want cleared", result, incidents.incident.Severity)
+	}
+	if assignees, _ := incidents.incident.Fields["assignees"].([]string); len(assignees) != 0 {
+		t.Errorf("incident assignees = %v, want none", assignees)
+	}
+
+	// and stay cleared on the next pass
+	result, err = engine.Sync(ctx, "inc-1", "42")
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if result.IssueUpdated || result.IncidentUpdated || incidents.incident.Severity != "" || len(tickets.ticket.Assignees) != 0 {
+		t.Errorf("third pass result = %+v, incident = %+v, issue assignees = %v", result, incidents.incident, tickets.ticket.Assignees)
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	engine := &Engine{config: Config{ConflictPolicy: PolicyIssueWins}}
+	base := state{Status: "investigating", Severity: "sev2"}
+	inc := state{Status: "identified", Severity: "sev2"}
+	issue := state{Status: "monitoring", Severity: "sev1"}
+
+	target, conflicts := engine.merge(base, true, inc, issue, true)
+	if target.Status != "monitoring" || target.Severity != "sev1" {
+		t.Errorf("merge() = %+v", target)
+	}
+	if len(conflicts) != 1 || conflicts[0] != "status" {
+		t.Errorf("conflicts = %v, want [status]", conflicts)
+	}
+
+	if _, err := New(map[string]any{"conflictPolicy": "coin_flip"}, &fakeIncidents{}, &fakeTickets{}); err == nil {
+		t.Error("expected error for unknown conflict policy")
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/opsorch/opsorch-core/schema"
 	"github.com/opsorch/opsorch-github-adapter/deployment"
+	"github.com/opsorch/opsorch-github-adapter/internal/vcr"
 )
 
 func main() {
@@ -39,6 +40,20 @@ func main() {
 	owner := os.Getenv("GITHUB_OWNER")
 	repo := os.Getenv("GITHUB_REPO")
 
+	// Record or replay GitHub traffic when GITHUB_VCR_MODE is set
+	recorder, err := vcr.FromEnv("integ/testdata/deployment.json", token)
+	if err != nil {
+		log.Fatalf("Failed to set up recorder: %v", err)
+	}
+	defer func() {
+		if err := recorder.Save(); err != nil {
+			log.Printf("Failed to save cassette: %v", err)
+		}
+	}()
+	if token == "" && recorder.Mode() == vcr.ModeReplay {
+		token = "replay-token"
+	}
+
 	if token == "" {
 		log.Fatal("GITHUB_TOKEN environment variable is required")
 	}
@@ -56,9 +71,10 @@ func main() {
 
 	// Create the GitHub deployment provider
 	config := map[string]any{
-		"token": token,
-		"owner": owner,
-		"repo":  repo,
+		"token":      token,
+		"owner":      owner,
+		"repo":       repo,
+		"httpClient": recorder.Client(),
 	}
 
 	provider, err := deployment.New(config)
@@ -7,12 +7,28 @@ import (
 	"os"
 
 	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/vcr"
 	"github.com/opsorch/opsorch-github-adapter/team"
 )
 
 func main() {
 	// Get configuration from environment
 	token := os.Getenv("GITHUB_TOKEN")
+
+	// Record or replay GitHub traffic when GITHUB_VCR_MODE is set
+	recorder, err := vcr.FromEnv("integ/testdata/team.json", token)
+	if err != nil {
+		log.Fatalf("Failed to set up recorder: %v", err)
+	}
+	defer func() {
+		if err := recorder.Save(); err != nil {
+			log.Printf("Failed to save cassette: %v", err)
+		}
+	}()
+	if token == "" && recorder.Mode() == vcr.ModeReplay {
+		token = "replay-token"
+	}
+
 	if token == "" {
 		log.Fatal("GITHUB_TOKEN environment variable is required")
 	}
@@ -28,6 +44,7 @@ func main() {
 	config := map[string]any{
 		"token":        token,
 		"organization": org,
+		"httpClient":   recorder.Client(),
 	}
 
 	provider, err := team.New(config)
@@ -0,0 +1,242 @@
+// Package apiversion pins the GitHub REST API version requests are made
+// against and keeps track of the endpoints GitHub reports as deprecated. GitHub
+// announces the removal of an endpoint with Deprecation and Sunset response
+// headers; each endpoint answering with one is logged once and listed in
+// Health until the process exits.
+//
+// Every client built by the providers routes through Default.
+package apiversion
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Header carries the API version of a request.
+const Header = "X-GitHub-Api-Version"
+
+// maxNotices bounds the endpoints a Registry remembers; notices past it are
+// counted but not listed.
+const maxNotices = 100
+
+// versionPattern matches GitHub's date-based API versions.
+var versionPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// Default is the process-wide registry wrapped around every provider client.
+var Default = New()
+
+// Validate checks that version looks like a GitHub API version, such as
+// 2022-11-28. An empty version leaves the client's default in place.
+func Validate(version string) error {
+	if version == "" {
+		return nil
+	}
+	if !versionPattern.MatchString(version) {
+		return fmt.Errorf("apiVersion must be a date such as 2022-11-28, got %q", version)
+	}
+	if _, err := time.Parse("2006-01-02", version); err != nil {
+		return fmt.Errorf("apiVersion must be a date such as 2022-11-28, got %q", version)
+	}
+	return nil
+}
+
+// Notice is an endpoint GitHub reported as deprecated.
+type Notice struct {
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`                  // Request path with numeric IDs replaced by {id}
+	Version     string    `json:"version,omitempty"`     // API version the request was made with
+	Deprecation string    `json:"deprecation,omitempty"` // Deprecation header: when the endpoint was deprecated
+	Sunset      string    `json:"sunset,omitempty"`      // Sunset header: when the endpoint stops working
+	Link        string    `json:"link,omitempty"`        // Link header pointing at the deprecation notice
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastSeen    time.Time `json:"lastSeen"`
+	Count       int       `json:"count"` // Responses carrying the notice
+}
+
+// Health summarizes the API version in use and the deprecated endpoints the
+// adapter has called.
+type Health struct {
+	Status       string   `json:"status"` // "ok", or "warning" once a deprecated endpoint was called
+	APIVersion   string   `json:"apiVersion,omitempty"`
+	Warnings     []string `json:"warnings"`
+	Deprecations []Notice `json:"deprecations"`
+	Dropped      int      `json:"dropped,omitempty"` // Deprecated endpoints not listed once the list was full
+}
+
+// Registry records deprecation notices.
+type Registry struct {
+	mu      sync.Mutex
+	version string
+	notices map[string]*Notice
+	dropped int
+	logf    func(format string, args ...any)
+	now     func() time.Time
+}
+
+// New creates an empty registry that logs through the standard logger.
+func New() *Registry {
+	return &Registry{notices: map[string]*Notice{}, logf: log.Printf, now: time.Now}
+}
+
+// Wrap returns a transport that sends version, when set, as the request's API
+// version and records the deprecation notices of the responses through base.
+func (r *Registry) Wrap(base http.RoundTripper, version string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if version != "" {
+		r.mu.Lock()
+		r.version = version
+		r.mu.Unlock()
+	}
+	return &transport{registry: r, base: base, version: version}
+}
+
+type transport struct {
+	registry *Registry
+	base     http.RoundTripper
+	version  string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.version != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(Header, t.version)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	deprecation, sunset := resp.Header.Get("Deprecation"), resp.Header.Get("Sunset")
+	if deprecation != "" || sunset != "" {
+		t.registry.record(Notice{
+			Method:      req.Method,
+			Path:        normalizePath(req.URL.Path),
+			Version:     req.Header.Get(Header),
+			Deprecation: deprecation,
+			Sunset:      sunset,
+			Link:        resp.Header.Get("Link"),
+		})
+	}
+	return resp, nil
+}
+
+// record adds or refreshes the notice of an endpoint, logging the first one.
+func (r *Registry) record(n Notice) {
+	now := r.now().UTC()
+	key := n.Method + " " + n.Path
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.notices[key]; ok {
+		existing.Deprecation, existing.Sunset = n.Deprecation, n.Sunset
+		if n.Link != "" {
+			existing.Link = n.Link
+		}
+		existing.LastSeen = now
+		existing.Count++
+		return
+	}
+	if len(r.notices) >= maxNotices {
+		r.dropped++
+		return
+	}
+	n.FirstSeen, n.LastSeen, n.Count = now, now, 1
+	r.notices[key] = &n
+	r.logf("[apiversion] %s", n.warning())
+}
+
+// Notices returns the recorded notices, the soonest sunset first.
+func (r *Registry) Notices() []Notice {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notices := make([]Notice, 0, len(r.notices))
+	for _, n := range r.notices {
+		notices = append(notices, *n)
+	}
+	sort.Slice(notices, func(i, j int) bool {
+		a, b := notices[i], notices[j]
+		at, aok := sunsetTime(a.Sunset)
+		bt, bok := sunsetTime(b.Sunset)
+		if aok != bok {
+			return aok
+		}
+		if aok && !at.Equal(bt) {
+			return at.Before(bt)
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+	return notices
+}
+
+// Health reports "warning" with a line per deprecated endpoint once any was
+// called, and "ok" otherwise.
+func (r *Registry) Health() Health {
+	notices := r.Notices()
+	r.mu.Lock()
+	health := Health{Status: "ok", APIVersion: r.version, Warnings: []string{}, Deprecations: notices, Dropped: r.dropped}
+	r.mu.Unlock()
+	for _, n := range notices {
+		health.Warnings = append(health.Warnings, n.warning())
+	}
+	if health.Dropped > 0 {
+		health.Warnings = append(health.Warnings, fmt.Sprintf("%d more deprecated endpoints were called", health.Dropped))
+	}
+	if len(health.Warnings) > 0 {
+		health.Status = "warning"
+	}
+	return health
+}
+
+// Reset forgets the recorded notices.
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notices = map[string]*Notice{}
+	r.dropped = 0
+}
+
+func (n Notice) warning() string {
+	line := fmt.Sprintf("%s %s is deprecated", n.Method, n.Path)
+	if n.Deprecation != "" {
+		line += " since " + n.Deprecation
+	}
+	if n.Sunset != "" {
+		line += " and will be removed on " + n.Sunset
+	}
+	if n.Link != "" {
+		line += " (" + n.Link + ")"
+	}
+	return line
+}
+
+// sunsetTime parses a Sunset header, an HTTP date.
+func sunsetTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := http.ParseTime(value)
+	return t, err == nil
+}
+
+// normalizePath replaces numeric path segments, such as run and issue
+// numbers, so calls of one endpoint share a notice.
+func normalizePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if _, err := strconv.ParseInt(part, 10, 64); err == nil {
+			parts[i] = "{id}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,127 @@
+package apiversion
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	for _, version := range []string{"", "2022-11-28"} {
+		if err := Validate(version); err != nil {
+			t.Errorf("Validate(%q) = %v", version, err)
+		}
+	}
+	for _, version := range []string{"v3", "2022-13-01", "2022-11-28T00:00:00Z"} {
+		if err := Validate(version); err == nil {
+			t.Errorf("Validate(%q) succeeded", version)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	var versions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		versions = append(versions, r.Header.Get(Header))
+		if strings.HasPrefix(r.URL.Path, "/old/") {
+			w.Header().Set("Deprecation", "Mon, 01 Jan 2024 00:00:00 GMT")
+			w.Header().Set("Sunset", "Wed, 01 Jan 2025 00:00:00 GMT")
+			w.Header().Set("Link", `<https://docs.github.com/changes>; rel="deprecation"`)
+		}
+		if r.URL.Path == "/soon" {
+			w.Header().Set("Sunset", "Sun, 01 Dec 2024 00:00:00 GMT")
+		}
+	}))
+	defer srv.Close()
+
+	registry := New()
+	var logged []string
+	registry.logf = func(format string, args ...any) { logged = append(logged, fmt.Sprintf(format, args...)) }
+	registry.now = func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }
+
+	client := &http.Client{Transport: registry.Wrap(nil, "2024-01-01")}
+	get := func(path string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		req.Header.Set(Header, "2022-11-28")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if health := registry.Health(); health.Status != "ok" || health.APIVersion != "2024-01-01" {
+		t.Fatalf("health before any notice = %+v", health)
+	}
+
+	get("/old/1")
+	get("/old/2")
+	get("/current")
+	get("/soon")
+	for _, version := range versions {
+		if version != "2024-01-01" {
+			t.Fatalf("sent API versions %v, want the configured one", versions)
+		}
+	}
+
+	notices := registry.Notices()
+	if len(notices) != 2 {
+		t.Fatalf("got %d notices, want 2: %+v", len(notices), notices)
+	}
+	if notices[0].Path != "/soon" {
+		t.Errorf("first notice = %s, want the soonest sunset", notices[0].Path)
+	}
+	old := notices[1]
+	if old.Path != "/old/{id}" || old.Count != 2 || old.Version != "2024-01-01" || !strings.Contains(old.Link, "docs.github.com") {
+		t.Errorf("notice = %+v", old)
+	}
+	if len(logged) != 2 {
+		t.Errorf("logged %v, want one line per endpoint", logged)
+	}
+
+	health := registry.Health()
+	if health.Status != "warning" || len(health.Warnings) != 2 || !strings.Contains(health.Warnings[1], "will be removed on Wed, 01 Jan 2025") {
+		t.Errorf("health = %+v", health)
+	}
+
+	registry.Reset()
+	if health := registry.Health(); health.Status != "ok" || len(health.Deprecations) != 0 {
+		t.Errorf("health after Reset = %+v", health)
+	}
+}
+
+func TestWrapWithoutVersion(t *testing.T) {
+	var version string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version = r.Header.Get(Header)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: New().Wrap(nil, "")}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set(Header, "2022-11-28")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if version != "2022-11-28" {
+		t.Errorf("API version = %q, want the client's own", version)
+	}
+}
+
+func TestNoticeLimit(t *testing.T) {
+	registry := New()
+	registry.logf = func(string, ...any) {}
+	for i := 0; i < maxNotices+3; i++ {
+		registry.record(Notice{Method: http.MethodGet, Path: fmt.Sprintf("/endpoint-%d", i), Deprecation: "true"})
+	}
+	health := registry.Health()
+	if len(health.Deprecations) != maxNotices || health.Dropped != 3 {
+		t.Errorf("listed %d and dropped %d notices", len(health.Deprecations), health.Dropped)
+	}
+}
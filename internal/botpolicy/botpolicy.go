@@ -0,0 +1,79 @@
+// Package botpolicy recognises automation accounts — GitHub Apps, bot users, and
+// service accounts — so the providers can tag them, or leave them out of the
+// lists OpsOrch pages from.
+package botpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultLoginSuffixes are the login suffixes treated as bots when the policy
+// names none. GitHub Apps act as <app>[bot].
+var DefaultLoginSuffixes = []string{"[bot]"}
+
+// Policy decides which accounts are bots. An account is a bot when it is on
+// Deny, or when it is not on Allow and either GitHub reports its type as Bot or
+// its login ends with one of LoginSuffixes.
+type Policy struct {
+	LoginSuffixes []string `json:"loginSuffixes"` // Login suffixes that mark bots (default "[bot]")
+	Allow         []string `json:"allow"`         // Logins never treated as bots
+	Deny          []string `json:"deny"`          // Logins always treated as bots, e.g. service accounts
+	Exclude       bool     `json:"exclude"`       // Drop bots from team members, assignees, and approvers instead of only tagging them
+}
+
+// Parse reads the "botPolicy" config object. A missing object yields the
+// default policy, which tags GitHub's bots without excluding them.
+func Parse(cfg map[string]any) (Policy, error) {
+	var policy Policy
+	if raw, ok := cfg["botPolicy"]; ok && raw != nil {
+		// Round-trip through JSON so decoded config and a typed Policy passed
+		// in-process are handled the same way
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return policy, fmt.Errorf("botPolicy: %w", err)
+		}
+		if err := json.Unmarshal(data, &policy); err != nil {
+			return policy, fmt.Errorf("botPolicy must be an object with loginSuffixes, allow, deny, and exclude: %w", err)
+		}
+	}
+	if len(policy.LoginSuffixes) == 0 {
+		policy.LoginSuffixes = DefaultLoginSuffixes
+	}
+	return policy, nil
+}
+
+// IsBot reports whether the account with login and GitHub user type (which may
+// be "" when unknown) is a bot under the policy.
+func (p Policy) IsBot(login, userType string) bool {
+	if login == "" {
+		return false
+	}
+	if contains(p.Deny, login) {
+		return true
+	}
+	if contains(p.Allow, login) {
+		return false
+	}
+	if strings.EqualFold(userType, "Bot") {
+		return true
+	}
+	lower := strings.ToLower(login)
+	for _, suffix := range p.LoginSuffixes {
+		if suffix != "" && strings.HasSuffix(lower, strings.ToLower(suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether logins holds login; GitHub logins are case-insensitive.
+func contains(logins []string, login string) bool {
+	for _, l := range logins {
+		if strings.EqualFold(l, login) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package botpolicy
+
+import "testing"
+
+func TestIsBot(t *testing.T) {
+	policy, err := Parse(map[string]any{"botPolicy": map[string]any{
+		"loginSuffixes": []any{"[bot]", "-svc"},
+		"allow":         []any{"release-svc"},
+		"deny":          []any{"deployer"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		login    string
+		userType string
+		want     bool
+	}{
+		{"alice", "User", false},
+		{"dependabot[bot]", "Bot", true},
+		{"renovate", "Bot", true},
+		{"Metrics-SVC", "User", true},
+		{"release-svc", "User", false},
+		{"Deployer", "User", true},
+		{"", "Bot", false},
+	}
+	for _, tt := range tests {
+		if got := policy.IsBot(tt.login, tt.userType); got != tt.want {
+			t.Errorf("IsBot(%q, %q) = %v, want %v", tt.login, tt.userType, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	policy, err := Parse(map[string]any{})
+	if err != nil || !policy.IsBot("github-actions[bot]", "") || policy.Exclude {
+		t.Errorf("default policy = %+v, %v", policy, err)
+	}
+	if _, err := Parse(map[string]any{"botPolicy": "exclude"}); err == nil {
+		t.Error("Parse() accepted a non-object botPolicy")
+	}
+}
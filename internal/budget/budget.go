@@ -0,0 +1,101 @@
+// Package budget caps the GitHub API calls one query may make, so an expensive
+// query degrades to partial results instead of spending the whole rate limit.
+// A budget travels in the query's context; without one, calls are unlimited.
+package budget
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// ErrExhausted is returned in place of an API call the budget did not allow.
+var ErrExhausted = errors.New("API call budget exhausted")
+
+// Limits are the per-query caps read from the "maxAPICallsPerQuery" and
+// "maxEnrichmentCalls" config keys. Zero means unlimited.
+type Limits struct {
+	Calls      int // All API calls made by one query
+	Enrichment int // Per-item lookups that add detail to results, such as member profiles
+}
+
+// Parse reads the limits from provider config.
+func Parse(cfg map[string]any) Limits {
+	return Limits{
+		Calls:      ghconfig.Int(cfg, "maxAPICallsPerQuery", 0),
+		Enrichment: ghconfig.Int(cfg, "maxEnrichmentCalls", 0),
+	}
+}
+
+type budget struct {
+	mu         sync.Mutex
+	limits     Limits
+	calls      int
+	enrichment int
+	truncated  bool
+}
+
+type contextKey struct{}
+
+// Start returns a context carrying a fresh budget with the given limits, or ctx
+// itself when neither limit is set. Queries may fan out, so the budget is safe
+// for concurrent use.
+func Start(ctx context.Context, limits Limits) context.Context {
+	if limits.Calls <= 0 && limits.Enrichment <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, &budget{limits: limits})
+}
+
+func from(ctx context.Context) *budget {
+	b, _ := ctx.Value(contextKey{}).(*budget)
+	return b
+}
+
+// Call takes one API call from the budget in ctx, reporting whether it may be made.
+func Call(ctx context.Context) bool {
+	return take(ctx, false)
+}
+
+// Enrich takes one enrichment call, which also counts as an API call, reporting
+// whether it may be made.
+func Enrich(ctx context.Context) bool {
+	return take(ctx, true)
+}
+
+func take(ctx context.Context, enrichment bool) bool {
+	b := from(ctx)
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limits.Calls > 0 && b.calls >= b.limits.Calls {
+		b.truncated = true
+		return false
+	}
+	if enrichment && b.limits.Enrichment > 0 && b.enrichment >= b.limits.Enrichment {
+		b.truncated = true
+		return false
+	}
+	b.calls++
+	if enrichment {
+		b.enrichment++
+	}
+	return true
+}
+
+// Truncated reports whether the budget in ctx refused a call, so the query's
+// results are partial.
+func Truncated(ctx context.Context) bool {
+	b := from(ctx)
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.truncated
+}
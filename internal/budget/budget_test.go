@@ -0,0 +1,62 @@
+package budget
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	got := Parse(map[string]any{"maxAPICallsPerQuery": float64(20), "maxEnrichmentCalls": 5})
+	if got != (Limits{Calls: 20, Enrichment: 5}) {
+		t.Errorf("Parse() = %+v", got)
+	}
+	if got := Parse(map[string]any{}); got != (Limits{}) {
+		t.Errorf("Parse() without keys = %+v, want unlimited", got)
+	}
+}
+
+func TestUnlimited(t *testing.T) {
+	ctx := Start(context.Background(), Limits{})
+	for i := 0; i < 100; i++ {
+		if !Call(ctx) || !Enrich(ctx) {
+			t.Fatal("call refused without a budget")
+		}
+	}
+	if Truncated(ctx) {
+		t.Error("Truncated() = true without a budget")
+	}
+}
+
+func TestCalls(t *testing.T) {
+	ctx := Start(context.Background(), Limits{Calls: 2})
+	if !Call(ctx) || !Enrich(ctx) {
+		t.Fatal("calls within the budget refused")
+	}
+	if Truncated(ctx) {
+		t.Fatal("Truncated() = true within the budget")
+	}
+	// Enrichment counts toward all calls
+	if Call(ctx) || Enrich(ctx) {
+		t.Error("calls beyond the budget allowed")
+	}
+	if !Truncated(ctx) {
+		t.Error("Truncated() = false after a refused call")
+	}
+}
+
+func TestEnrichment(t *testing.T) {
+	ctx := Start(context.Background(), Limits{Enrichment: 1})
+	if !Enrich(ctx) {
+		t.Fatal("enrichment within the budget refused")
+	}
+	if Enrich(ctx) {
+		t.Error("enrichment beyond the budget allowed")
+	}
+	// Other calls are not limited by the enrichment budget
+	if !Call(ctx) {
+		t.Error("call refused by the enrichment budget")
+	}
+	if !Truncated(ctx) {
+		t.Error("Truncated() = false after a refused enrichment")
+	}
+}
@@ -0,0 +1,165 @@
+// Package cache provides the in-memory response cache shared by the GitHub providers.
+// Providers and the webhook receiver running in the same process share Default, so a
+// webhook delivery can refresh the record a later Get call returns.
+package cache
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default is the process-wide cache used by providers with caching enabled.
+var Default = New()
+
+type entry struct {
+	value     any
+	expiresAt time.Time
+}
+
+// Cache is a concurrency-safe key/value store with per-entry expiry.
+type Cache struct {
+	mu       sync.Mutex
+	entries  map[string]entry
+	watchers map[string][]chan any
+	now      func() time.Time
+}
+
+// New creates an empty cache.
+func New() *Cache {
+	return &Cache{
+		entries:  map[string]entry{},
+		watchers: map[string][]chan any{},
+		now:      time.Now,
+	}
+}
+
+// Get returns the value stored under key if it has not expired.
+func (c *Cache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if c.now().After(e.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set stores value under key for ttl and notifies watchers of key. A non-positive
+// ttl skips storage but still notifies watchers.
+func (c *Cache) Set(key string, value any, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notify(key, value)
+	if ttl <= 0 {
+		return
+	}
+	c.entries[key] = entry{value: value, expiresAt: c.now().Add(ttl)}
+	c.evictExpired()
+}
+
+// Watch returns a channel that receives every value subsequently Set under key, and a
+// function that stops the watch. Slow receivers only see the most recent value.
+func (c *Cache) Watch(key string) (<-chan any, func()) {
+	ch := make(chan any, 1)
+
+	c.mu.Lock()
+	c.watchers[key] = append(c.watchers[key], ch)
+	c.mu.Unlock()
+
+	cancel := func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		watchers := c.watchers[key]
+		for i, w := range watchers {
+			if w == ch {
+				c.watchers[key] = append(watchers[:i], watchers[i+1:]...)
+				break
+			}
+		}
+		if len(c.watchers[key]) == 0 {
+			delete(c.watchers, key)
+		}
+	}
+	return ch, cancel
+}
+
+// notify delivers value to the watchers of key without blocking, replacing any value
+// a watcher has not consumed yet. Callers must hold c.mu.
+func (c *Cache) notify(key string, value any) {
+	for _, ch := range c.watchers[key] {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- value
+	}
+}
+
+// Notify delivers value to the watchers of key without storing it.
+func (c *Cache) Notify(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notify(key, value)
+}
+
+// Delete removes key from the cache.
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// DeletePrefix removes every key that starts with prefix.
+func (c *Cache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// UpdatePrefix passes the value of every unexpired key that starts with prefix
+// to update, storing what it returns under the key until the entry's original
+// expiry, or deleting the key when update reports false.
+func (c *Cache) UpdatePrefix(prefix string, update func(value any) (any, bool)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, e := range c.entries {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if now.After(e.expiresAt) {
+			delete(c.entries, k)
+			continue
+		}
+		if value, ok := update(e.value); ok {
+			c.entries[k] = entry{value: value, expiresAt: e.expiresAt}
+		} else {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// evictExpired drops expired entries once the map grows, so keys that are never
+// read again do not accumulate. Callers must hold c.mu.
+func (c *Cache) evictExpired() {
+	if len(c.entries) < 1024 || len(c.entries)%256 != 0 {
+		return
+	}
+	now := c.now()
+	for k, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New()
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1, time.Minute)
+	c.Set("disabled", 2, 0)
+
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a) = %v, %v", v, ok)
+	}
+	if _, ok := c.Get("disabled"); ok {
+		t.Error("zero TTL entries must not be stored")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expired entry returned")
+	}
+
+	c.Set("b", 3, time.Minute)
+	c.Delete("b")
+	if _, ok := c.Get("b"); ok {
+		t.Error("deleted entry returned")
+	}
+
+	c.Set("r/1/x", 4, time.Minute)
+	c.Set("r/1/y", 5, time.Minute)
+	c.Set("r/10/x", 6, time.Minute)
+	c.DeletePrefix("r/1/")
+	if _, ok := c.Get("r/1/x"); ok {
+		t.Error("DeletePrefix kept r/1/x")
+	}
+	if _, ok := c.Get("r/1/y"); ok {
+		t.Error("DeletePrefix kept r/1/y")
+	}
+	if _, ok := c.Get("r/10/x"); !ok {
+		t.Error("DeletePrefix removed r/10/x")
+	}
+
+	c.Set("u/1/x", 7, time.Minute)
+	c.Set("u/1/y", 8, time.Minute)
+	c.UpdatePrefix("u/1/", func(v any) (any, bool) { return v.(int) * 10, v != 8 })
+	if v, ok := c.Get("u/1/x"); !ok || v != 70 {
+		t.Errorf("Get(u/1/x) after UpdatePrefix = %v, %v, want 70", v, ok)
+	}
+	if _, ok := c.Get("u/1/y"); ok {
+		t.Error("UpdatePrefix kept u/1/y")
+	}
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("u/1/x"); ok {
+		t.Error("UpdatePrefix extended the entry's expiry")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	c := New()
+	updates, cancel := c.Watch("k")
+
+	c.Set("k", 1, 0)
+	c.Set("k", 2, 0)
+	if v := <-updates; v != 2 {
+		t.Errorf("watcher received %v, want latest value 2", v)
+	}
+	if _, ok := c.Get("k"); ok {
+		t.Error("zero TTL Set must not store the value")
+	}
+
+	c.Notify("k", 3)
+	if v := <-updates; v != 3 {
+		t.Errorf("watcher received %v, want notified value 3", v)
+	}
+
+	cancel()
+	c.Set("k", 3, 0)
+	select {
+	case v := <-updates:
+		t.Errorf("cancelled watcher received %v", v)
+	default:
+	}
+}
+
+func TestClone(t *testing.T) {
+	fields := map[string]any{
+		"labels":    []string{"bug"},
+		"nested":    map[string]any{"jobs": []any{map[string]any{"name": "build"}}},
+		"count":     1,
+		"nilSlice":  []string(nil),
+		"nilMember": nil,
+	}
+	clone := Clone(fields)
+
+	fields["count"] = 2
+	fields["labels"].([]string)[0] = "incident"
+	fields["nested"].(map[string]any)["jobs"].([]any)[0].(map[string]any)["name"] = "deploy"
+
+	if clone["count"] != 1 || clone["labels"].([]string)[0] != "bug" {
+		t.Errorf("clone changed with the original: %v", clone)
+	}
+	if name := clone["nested"].(map[string]any)["jobs"].([]any)[0].(map[string]any)["name"]; name != "build" {
+		t.Errorf("nested clone changed with the original: %v", name)
+	}
+	if clone["nilSlice"].([]string) != nil || clone["nilMember"] != nil {
+		t.Errorf("nil values not kept: %v", clone)
+	}
+	if Clone(nil) != nil {
+		t.Error("Clone(nil) != nil")
+	}
+}
@@ -0,0 +1,46 @@
+package cache
+
+import "reflect"
+
+// Clone returns a deep copy of the maps and slices of a Fields or Metadata
+// map, so a record stored in the cache does not change when the caller keeps
+// filling in the map it was built from. Other values are copied as-is.
+func Clone(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	return cloneValue(reflect.ValueOf(m)).Interface().(map[string]any)
+}
+
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(cloneValue(v.Elem()))
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), cloneValue(iter.Value()))
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,108 @@
+// Package conditional makes repeated GETs of the same GitHub resource
+// conditional. The transport remembers each matching response's ETag and
+// Last-Modified and sends them back as If-None-Match and If-Modified-Since;
+// GitHub answers an unchanged resource with 304 Not Modified, which does not
+// count against the rate limit, and the transport replays the remembered body.
+package conditional
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// maxEntries bounds the responses a transport remembers. Past it, an arbitrary
+// entry is dropped for each new one.
+const maxEntries = 256
+
+type entry struct {
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+// Wrap returns a transport that makes GETs through base conditional when match
+// accepts their URL path.
+func Wrap(base http.RoundTripper, match func(path string) bool) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, match: match, entries: map[string]entry{}}
+}
+
+type transport struct {
+	base  http.RoundTripper
+	match func(path string) bool
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || !t.match(req.URL.Path) {
+		return t.base.RoundTrip(req)
+	}
+	key := req.URL.String()
+
+	t.mu.Lock()
+	cached, ok := t.entries[key]
+	t.mu.Unlock()
+	if ok {
+		req = req.Clone(req.Context())
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case ok && resp.StatusCode == http.StatusNotModified:
+		// The 304 carries current rate limit headers; the rest come from the
+		// remembered response
+		resp.Body.Close()
+		header := cached.header.Clone()
+		for k, v := range resp.Header {
+			header[k] = v
+		}
+		resp.StatusCode, resp.Status = http.StatusOK, "200 OK"
+		resp.Header = header
+		resp.Body = io.NopCloser(bytes.NewReader(cached.body))
+		resp.ContentLength = int64(len(cached.body))
+		return resp, nil
+
+	case resp.StatusCode == http.StatusOK:
+		etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		if etag == "" && lastModified == "" {
+			return resp, nil
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		t.remember(key, entry{etag: etag, lastModified: lastModified, header: resp.Header.Clone(), body: body})
+	}
+	return resp, nil
+}
+
+func (t *transport) remember(key string, e entry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.entries[key]; !ok && len(t.entries) >= maxEntries {
+		for k := range t.entries {
+			delete(t.entries, k)
+			break
+		}
+	}
+	t.entries[key] = e
+}
@@ -0,0 +1,59 @@
+package conditional
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConditionalGet(t *testing.T) {
+	var conditional, full int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("X-RateLimit-Remaining", "5000")
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: Wrap(nil, func(path string) bool { return strings.HasPrefix(path, "/runs/") })}
+	get := func(path string) (int, string, string) {
+		t.Helper()
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), resp.Header.Get("X-RateLimit-Remaining")
+	}
+
+	if status, body, _ := get("/runs/1"); status != http.StatusOK || body != `{"id":1}` {
+		t.Fatalf("first GET = %d %q", status, body)
+	}
+	status, body, remaining := get("/runs/1")
+	if status != http.StatusOK || body != `{"id":1}` {
+		t.Fatalf("conditional GET = %d %q, want the remembered body", status, body)
+	}
+	if remaining != "4999" {
+		t.Errorf("rate limit header = %q, want the 304's", remaining)
+	}
+	if conditional != 1 || full != 1 {
+		t.Errorf("made %d conditional and %d full requests, want 1 and 1", conditional, full)
+	}
+
+	// Paths the matcher rejects are never conditional
+	get("/other")
+	get("/other")
+	if conditional != 1 || full != 3 {
+		t.Errorf("unmatched paths made %d conditional requests", conditional-1)
+	}
+}
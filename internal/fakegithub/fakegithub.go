@@ -0,0 +1,217 @@
+// Package fakegithub provides an httptest-backed fake of the GitHub REST API
+// serving canned fixtures, so provider tests can exercise real API calls
+// without network access or credentials.
+package fakegithub
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// Identifiers used by the default fixtures.
+const (
+	Owner        = "acme"
+	Repo         = "api"
+	Organization = "acme"
+	OrgID        = 500
+)
+
+//go:embed testdata/*.json
+var fixtures embed.FS
+
+// Request is a request recorded by the server.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Body   []byte
+}
+
+// Server is a fake GitHub API. Routes are matched exactly on method and path;
+// unmatched requests get GitHub's 404 response.
+type Server struct {
+	*httptest.Server
+
+	// PageSize caps per_page on paginated list fixtures when greater than zero,
+	// forcing callers through multiple pages.
+	PageSize int
+
+	mu       sync.Mutex
+	routes   map[string]http.HandlerFunc
+	requests []Request
+}
+
+// New starts a server with the default fixtures registered and closes it when
+// the test finishes.
+func New(t testing.TB) *Server {
+	t.Helper()
+
+	s := Start()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Start starts a server with the default fixtures registered, for use outside
+// tests. The caller closes it.
+func Start() *Server {
+	s := &Server{routes: make(map[string]http.HandlerFunc)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.registerDefaults()
+	return s
+}
+
+// Client returns a GitHub client pointed at the server.
+func (s *Server) Client() *github.Client {
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(s.URL + "/")
+	return client
+}
+
+// Handle registers or replaces the handler for method and path.
+func (s *Server) Handle(method, path string, h http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[method+" "+path] = h
+}
+
+// Fixture serves the named fixture for method and path.
+func (s *Server) Fixture(method, path, name string) {
+	body := Fixture(name)
+	s.Handle(method, path, func(w http.ResponseWriter, _ *http.Request) {
+		writeRaw(w, http.StatusOK, body)
+	})
+}
+
+// List serves the named fixture, which must hold a JSON array, one page at a
+// time with GitHub's Link header.
+func (s *Server) List(method, path, name string) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(Fixture(name), &items); err != nil {
+		panic(fmt.Sprintf("fakegithub: fixture %s is not a list: %v", name, err))
+	}
+	s.Handle(method, path, func(w http.ResponseWriter, r *http.Request) {
+		s.paginate(w, r, items)
+	})
+}
+
+// Error makes method and path fail with status and a GitHub error body.
+func (s *Server) Error(method, path string, status int, message string) {
+	s.Handle(method, path, func(w http.ResponseWriter, _ *http.Request) {
+		WriteError(w, status, message)
+	})
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Fixture returns the raw contents of a named fixture.
+func Fixture(name string) []byte {
+	data, err := fixtures.ReadFile("testdata/" + name)
+	if err != nil {
+		panic(fmt.Sprintf("fakegithub: unknown fixture %s", name))
+	}
+	return data
+}
+
+// WriteJSON writes v as a JSON response.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeRaw(w, status, data)
+}
+
+// WriteError writes a GitHub-style error response.
+func WriteError(w http.ResponseWriter, status int, message string) {
+	WriteJSON(w, status, map[string]string{
+		"message":           message,
+		"documentation_url": "https://docs.github.com/rest",
+	})
+}
+
+func writeRaw(w http.ResponseWriter, status int, data []byte) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Body:   body,
+	})
+	h, ok := s.routes[r.Method+" "+r.URL.Path]
+	s.mu.Unlock()
+
+	if !ok {
+		WriteError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	h(w, r)
+}
+
+// paginate writes one page of items, honouring page and per_page and setting
+// a Link header for the next and last pages.
+func (s *Server) paginate(w http.ResponseWriter, r *http.Request, items []json.RawMessage) {
+	q := r.URL.Query()
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+	if perPage <= 0 {
+		perPage = 30
+	}
+	if s.PageSize > 0 && perPage > s.PageSize {
+		perPage = s.PageSize
+	}
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+
+	start := (page - 1) * perPage
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + perPage
+	if end > len(items) {
+		end = len(items)
+	}
+
+	last := (len(items) + perPage - 1) / perPage
+	if page < last {
+		link := func(p int, rel string) string {
+			u := *r.URL
+			u.Scheme, u.Host = "http", r.Host
+			v := u.Query()
+			v.Set("page", strconv.Itoa(p))
+			v.Set("per_page", strconv.Itoa(perPage))
+			u.RawQuery = v.Encode()
+			return fmt.Sprintf("<%s>; rel=%q", u.String(), rel)
+		}
+		w.Header().Set("Link", link(page+1, "next")+", "+link(last, "last"))
+	}
+
+	WriteJSON(w, http.StatusOK, items[start:end])
+}
@@ -0,0 +1,72 @@
+package fakegithub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+)
+
+func TestServer(t *testing.T) {
+	srv := New(t)
+	client := srv.Client()
+	ctx := context.Background()
+
+	t.Run("fixture", func(t *testing.T) {
+		issue, _, err := client.Issues.Get(ctx, Owner, Repo, 1)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if issue.GetTitle() != "Database latency spike" {
+			t.Errorf("title = %q", issue.GetTitle())
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		srv.PageSize = 2
+		defer func() { srv.PageSize = 0 }()
+
+		opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+		page, resp, err := client.Issues.ListComments(ctx, Owner, Repo, 1, opts)
+		if err != nil {
+			t.Fatalf("ListComments() error = %v", err)
+		}
+		if len(page) != 2 || resp.NextPage != 2 || resp.LastPage != 2 {
+			t.Fatalf("page 1: len=%d next=%d last=%d", len(page), resp.NextPage, resp.LastPage)
+		}
+
+		opts.Page = resp.NextPage
+		page, resp, err = client.Issues.ListComments(ctx, Owner, Repo, 1, opts)
+		if err != nil {
+			t.Fatalf("ListComments() error = %v", err)
+		}
+		if len(page) != 1 || resp.NextPage != 0 {
+			t.Errorf("page 2: len=%d next=%d", len(page), resp.NextPage)
+		}
+	})
+
+	t.Run("unknown route", func(t *testing.T) {
+		_, _, err := client.Issues.Get(ctx, Owner, Repo, 99)
+		var ghErr *github.ErrorResponse
+		if !errors.As(err, &ghErr) || ghErr.Response.StatusCode != http.StatusNotFound {
+			t.Errorf("error = %v, want 404", err)
+		}
+	})
+
+	t.Run("error override", func(t *testing.T) {
+		srv.Error(http.MethodGet, "/repos/acme/api/issues/1", http.StatusForbidden, "Resource not accessible by integration")
+		defer srv.Fixture(http.MethodGet, "/repos/acme/api/issues/1", "issue.json")
+
+		_, _, err := client.Issues.Get(ctx, Owner, Repo, 1)
+		var ghErr *github.ErrorResponse
+		if !errors.As(err, &ghErr) || ghErr.Response.StatusCode != http.StatusForbidden {
+			t.Errorf("error = %v, want 403", err)
+		}
+	})
+
+	if got := len(srv.Requests()); got != 5 {
+		t.Errorf("recorded %d requests, want 5", got)
+	}
+}
@@ -0,0 +1,142 @@
+package fakegithub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// registerDefaults wires the canned fixtures for the acme/api repository and
+// the acme organization.
+func (s *Server) registerDefaults() {
+	repo := fmt.Sprintf("/repos/%s/%s", Owner, Repo)
+	org := fmt.Sprintf("/organizations/%d", OrgID)
+
+	// Issues
+	s.List(http.MethodGet, repo+"/issues", "issues.json")
+	s.Fixture(http.MethodGet, repo+"/issues/1", "issue.json")
+	s.List(http.MethodGet, repo+"/issues/1/comments", "comments.json")
+	s.Handle(http.MethodPost, repo+"/issues", createIssue)
+	s.Handle(http.MethodPatch, repo+"/issues/1", editIssue)
+	s.Handle(http.MethodGet, repo+"/assignees/alice", NoContent)
+
+	// Workflow runs
+	s.Fixture(http.MethodGet, repo+"/actions/runs", "workflow_runs.json")
+	s.Fixture(http.MethodGet, repo+"/actions/runs/1001", "workflow_run.json")
+	s.Fixture(http.MethodGet, repo+"/actions/runs/1001/jobs", "jobs.json")
+	s.Handle(http.MethodGet, repo+"/check-runs/3001/annotations", func(w http.ResponseWriter, _ *http.Request) {
+		WriteJSON(w, http.StatusOK, []any{})
+	})
+	s.List(http.MethodGet, repo+"/check-runs/3002/annotations", "annotations.json")
+
+	// Deployments: production has two records, other environments none
+	s.Handle(http.MethodGet, repo+"/deployments", listDeployments)
+	s.Handle(http.MethodGet, repo+"/deployments/501", func(w http.ResponseWriter, _ *http.Request) {
+		var deployments []json.RawMessage
+		json.Unmarshal(Fixture("deployments.json"), &deployments)
+		writeRaw(w, http.StatusOK, deployments[0])
+	})
+	s.List(http.MethodGet, repo+"/deployments/501/statuses", "deployment_statuses.json")
+	s.Handle(http.MethodGet, repo+"/deployments/500/statuses", func(w http.ResponseWriter, _ *http.Request) {
+		WriteJSON(w, http.StatusOK, []any{})
+	})
+
+	// Organization and teams
+	s.Fixture(http.MethodGet, "/orgs/"+Organization, "org.json")
+	s.List(http.MethodGet, "/orgs/"+Organization+"/teams", "teams.json")
+	s.Fixture(http.MethodGet, "/orgs/"+Organization+"/teams/sre", "team.json")
+	s.Fixture(http.MethodGet, org+"/team/11", "team.json")
+	s.List(http.MethodGet, org+"/team/11/members", "members.json")
+	s.Fixture(http.MethodGet, org+"/team/11/memberships/alice", "membership_alice.json")
+	s.List(http.MethodGet, "/orgs/"+Organization+"/teams/sre/members", "members.json")
+	s.Fixture(http.MethodGet, "/orgs/"+Organization+"/teams/sre/memberships/alice", "membership_alice.json")
+	noTeams := func(w http.ResponseWriter, _ *http.Request) { WriteJSON(w, http.StatusOK, []any{}) }
+	s.Handle(http.MethodGet, "/orgs/"+Organization+"/teams/sre/teams", noTeams)
+	s.Handle(http.MethodGet, org+"/team/11/teams", noTeams)
+	s.Fixture(http.MethodGet, "/users/alice", "user_alice.json")
+}
+
+// NoContent answers with 204, which GitHub uses for positive boolean checks such
+// as whether a login can be assigned.
+func NoContent(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listDeployments serves the deployments fixture filtered by the environment
+// and sha query parameters.
+func listDeployments(w http.ResponseWriter, r *http.Request) {
+	var deployments []map[string]any
+	json.Unmarshal(Fixture("deployments.json"), &deployments)
+
+	matched := []map[string]any{}
+	for _, d := range deployments {
+		env, sha := r.URL.Query().Get("environment"), r.URL.Query().Get("sha")
+		if (env == "" || d["environment"] == env) && (sha == "" || d["sha"] == sha) {
+			matched = append(matched, d)
+		}
+	}
+	WriteJSON(w, http.StatusOK, matched)
+}
+
+// createIssue echoes the request back as issue number 4.
+func createIssue(w http.ResponseWriter, r *http.Request) {
+	issue, err := decodeIssueRequest(r.Body)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+	if issue["title"] == nil || issue["title"] == "" {
+		WriteError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+
+	issue["number"] = 4
+	issue["state"] = "open"
+	issue["html_url"] = fmt.Sprintf("https://github.com/%s/%s/issues/4", Owner, Repo)
+	issue["user"] = map[string]any{"login": "opsorch-bot"}
+	issue["created_at"] = "2030-01-06T10:00:00Z"
+	issue["updated_at"] = "2030-01-06T10:00:00Z"
+	WriteJSON(w, http.StatusCreated, issue)
+}
+
+// editIssue merges the request into the issue fixture.
+func editIssue(w http.ResponseWriter, r *http.Request) {
+	patch, err := decodeIssueRequest(r.Body)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+
+	var issue map[string]any
+	json.Unmarshal(Fixture("issue.json"), &issue)
+	for k, v := range patch {
+		issue[k] = v
+	}
+	issue["updated_at"] = "2030-01-06T10:00:00Z"
+	WriteJSON(w, http.StatusOK, issue)
+}
+
+// decodeIssueRequest reads an IssueRequest body, expanding assignee and label
+// names into the objects GitHub returns.
+func decodeIssueRequest(body io.Reader) (map[string]any, error) {
+	var req map[string]any
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		return nil, err
+	}
+
+	expand := func(key, field string) {
+		names, ok := req[key].([]any)
+		if !ok {
+			return
+		}
+		objects := make([]any, len(names))
+		for i, name := range names {
+			objects[i] = map[string]any{field: name}
+		}
+		req[key] = objects
+	}
+	expand("assignees", "login")
+	expand("labels", "name")
+	return req, nil
+}
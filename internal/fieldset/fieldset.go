@@ -0,0 +1,66 @@
+// Package fieldset selects which entries of a result's Fields a query returns.
+// Queries for thousands of records often need only a few of them; naming those
+// in the "fields" query metadata lets the conversion skip building the rest.
+package fieldset
+
+import "github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+
+// Key is the query metadata key listing the Fields entries to return.
+const Key = "fields"
+
+// Set is the Fields entries a query asked for. A nil Set asks for all of them.
+type Set map[string]struct{}
+
+// Parse reads the set from query metadata, returning nil when "fields" is unset
+// or empty.
+func Parse(metadata map[string]any) Set {
+	keys := ghconfig.StringSlice(metadata, Key)
+	if len(keys) == 0 {
+		return nil
+	}
+	s := make(Set, len(keys))
+	for _, key := range keys {
+		s[key] = struct{}{}
+	}
+	return s
+}
+
+// Has reports whether key was asked for.
+func (s Set) Has(key string) bool {
+	if s == nil {
+		return true
+	}
+	_, ok := s[key]
+	return ok
+}
+
+// Any reports whether any of keys was asked for.
+func (s Set) Any(keys ...string) bool {
+	for _, key := range keys {
+		if s.Has(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Size returns how many entries to make room for in a Fields map that holds
+// about all entries when complete.
+func (s Set) Size(all int) int {
+	if s == nil {
+		return all
+	}
+	return min(len(s), all)
+}
+
+// Prune deletes the entries of fields that were not asked for.
+func (s Set) Prune(fields map[string]any) {
+	if s == nil {
+		return
+	}
+	for key := range fields {
+		if _, ok := s[key]; !ok {
+			delete(fields, key)
+		}
+	}
+}
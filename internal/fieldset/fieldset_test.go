@@ -0,0 +1,49 @@
+package fieldset
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	if s := Parse(map[string]any{}); s != nil {
+		t.Errorf("Parse() without fields = %v, want nil", s)
+	}
+	if s := Parse(map[string]any{"fields": []any{}}); s != nil {
+		t.Errorf("Parse() with no entries = %v, want nil", s)
+	}
+	s := Parse(map[string]any{"fields": "labels, url"})
+	if !s.Has("labels") || !s.Has("url") || s.Has("milestone") {
+		t.Errorf("Parse() = %v", s)
+	}
+}
+
+func TestNilSetHasAll(t *testing.T) {
+	var s Set
+	if !s.Has("anything") || !s.Any("a", "b") {
+		t.Error("nil set should have every key")
+	}
+	if s.Size(8) != 8 {
+		t.Errorf("Size() = %d, want 8", s.Size(8))
+	}
+	fields := map[string]any{"a": 1}
+	s.Prune(fields)
+	if len(fields) != 1 {
+		t.Errorf("nil set pruned fields: %v", fields)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s := Parse(map[string]any{"fields": []string{"team"}})
+	if !s.Any("severity", "team") || s.Any("severity", "service") {
+		t.Error("Any() mismatch")
+	}
+	if s.Size(8) != 1 {
+		t.Errorf("Size() = %d, want 1", s.Size(8))
+	}
+	fields := map[string]any{"team": "sre", "service": "api", "severity": "sev2"}
+	s.Prune(fields)
+	if !reflect.DeepEqual(fields, map[string]any{"team": "sre"}) {
+		t.Errorf("Prune() left %v", fields)
+	}
+}
@@ -0,0 +1,88 @@
+package fieldset
+
+import "github.com/opsorch/opsorch-core/schema"
+
+// The top-level values of each record, by JSON name. A set naming any of them
+// projects records to those named; the ID is always kept.
+var (
+	ticketValues     = []string{"key", "title", "description", "status", "assignees", "reporter", "url", "createdAt", "updatedAt", "metadata"}
+	deploymentValues = []string{"service", "environment", "version", "status", "startedAt", "finishedAt", "url", "actor", "metadata"}
+	teamValues       = []string{"name", "parent", "url", "tags", "metadata"}
+)
+
+// projects reports whether the set names any of values, so the top-level
+// values it does not name are left out. Sets naming only Fields entries keep
+// every top-level value.
+func (s Set) projects(values []string) bool {
+	if s == nil {
+		return false
+	}
+	for _, v := range values {
+		if _, ok := s[v]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Ticket clears the top-level values of t the set does not name, when it names
+// any. Fields is dropped once it holds no entries.
+func (s Set) Ticket(t *schema.Ticket) {
+	if !s.projects(ticketValues) {
+		return
+	}
+	omit(s, "key", &t.Key)
+	omit(s, "title", &t.Title)
+	omit(s, "description", &t.Description)
+	omit(s, "status", &t.Status)
+	omit(s, "assignees", &t.Assignees)
+	omit(s, "reporter", &t.Reporter)
+	omit(s, "url", &t.URL)
+	omit(s, "createdAt", &t.CreatedAt)
+	omit(s, "updatedAt", &t.UpdatedAt)
+	omit(s, "metadata", &t.Metadata)
+	if len(t.Fields) == 0 {
+		t.Fields = nil
+	}
+}
+
+// Deployment clears the top-level values of d the set does not name, when it
+// names any. Fields is dropped once it holds no entries.
+func (s Set) Deployment(d *schema.Deployment) {
+	if !s.projects(deploymentValues) {
+		return
+	}
+	omit(s, "service", &d.Service)
+	omit(s, "environment", &d.Environment)
+	omit(s, "version", &d.Version)
+	omit(s, "status", &d.Status)
+	omit(s, "startedAt", &d.StartedAt)
+	omit(s, "finishedAt", &d.FinishedAt)
+	omit(s, "url", &d.URL)
+	omit(s, "actor", &d.Actor)
+	omit(s, "metadata", &d.Metadata)
+	if len(d.Fields) == 0 {
+		d.Fields = nil
+	}
+}
+
+// Team clears the top-level values of t the set does not name, when it names
+// any.
+func (s Set) Team(t *schema.Team) {
+	if !s.projects(teamValues) {
+		return
+	}
+	omit(s, "name", &t.Name)
+	omit(s, "parent", &t.Parent)
+	omit(s, "url", &t.URL)
+	omit(s, "tags", &t.Tags)
+	omit(s, "metadata", &t.Metadata)
+}
+
+// omit zeroes *v unless the set names key.
+func omit[T any](s Set, key string, v *T) {
+	if !s.Has(key) {
+		var zero T
+		*v = zero
+	}
+}
@@ -0,0 +1,56 @@
+package fieldset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+func TestProjectTicket(t *testing.T) {
+	full := schema.Ticket{
+		ID:        "1",
+		Title:     "Checkout errors",
+		Status:    "open",
+		Assignees: []string{"bob"},
+		URL:       "https://github.com/acme/api/issues/1",
+		CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Fields:    map[string]any{},
+		Metadata:  map[string]any{"stale": true},
+	}
+
+	// Sets naming only Fields entries keep every top-level value
+	got := full
+	Set{"labels": {}}.Ticket(&got)
+	if !reflect.DeepEqual(got, full) {
+		t.Errorf("Ticket() = %+v, want %+v", got, full)
+	}
+	Set(nil).Ticket(&got)
+	if !reflect.DeepEqual(got, full) {
+		t.Errorf("nil Ticket() = %+v", got)
+	}
+
+	Parse(map[string]any{"fields": "id, status, url"}).Ticket(&got)
+	want := schema.Ticket{ID: "1", Status: "open", URL: "https://github.com/acme/api/issues/1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Ticket() = %+v, want %+v", got, want)
+	}
+}
+
+func TestProjectDeployment(t *testing.T) {
+	got := schema.Deployment{ID: "9", Status: "success", Environment: "production", Actor: map[string]any{"login": "alice"}, Fields: map[string]any{"branch": "main"}}
+	Set{"status": {}, "branch": {}}.Deployment(&got)
+	want := schema.Deployment{ID: "9", Status: "success", Fields: map[string]any{"branch": "main"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Deployment() = %+v, want %+v", got, want)
+	}
+}
+
+func TestProjectTeam(t *testing.T) {
+	got := schema.Team{ID: "sre", Name: "SRE", Parent: "platform", Tags: map[string]string{"privacy": "closed"}}
+	Set{"name": {}}.Team(&got)
+	if want := (schema.Team{ID: "sre", Name: "SRE"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Team() = %+v, want %+v", got, want)
+	}
+}
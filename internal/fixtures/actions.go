@@ -0,0 +1,151 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+type actionsService struct{ s *Store }
+
+func (a actionsService) ListRepositoryWorkflowRuns(_ context.Context, owner, repo string, opts *github.ListWorkflowRunsOptions) (*github.WorkflowRuns, *github.Response, error) {
+	a.s.mu.Lock()
+	defer a.s.mu.Unlock()
+
+	if opts == nil {
+		opts = &github.ListWorkflowRunsOptions{}
+	}
+
+	var matched []*github.WorkflowRun
+	for _, run := range a.s.runs {
+		// GitHub accepts either a status or a conclusion in the status filter
+		if opts.Status != "" && run.GetStatus() != opts.Status && run.GetConclusion() != opts.Status {
+			continue
+		}
+		if opts.Branch != "" && run.GetHeadBranch() != opts.Branch {
+			continue
+		}
+		if opts.Actor != "" && run.GetActor().GetLogin() != opts.Actor {
+			continue
+		}
+		if opts.Event != "" && run.GetEvent() != opts.Event {
+			continue
+		}
+		if opts.HeadSHA != "" && run.GetHeadSHA() != opts.HeadSHA {
+			continue
+		}
+		matched = append(matched, run)
+	}
+
+	items, resp := page(matched, &opts.ListOptions)
+	return &github.WorkflowRuns{
+		TotalCount:   github.Int(len(matched)),
+		WorkflowRuns: items,
+	}, resp, nil
+}
+
+func (a actionsService) GetWorkflowRunByID(_ context.Context, owner, repo string, runID int64) (*github.WorkflowRun, *github.Response, error) {
+	a.s.mu.Lock()
+	defer a.s.mu.Unlock()
+
+	for _, run := range a.s.runs {
+		if run.GetID() == runID {
+			return run, &github.Response{}, nil
+		}
+	}
+	return nil, nil, notFound(fmt.Sprintf("/repos/%s/%s/actions/runs/%d", owner, repo, runID))
+}
+
+// GetWorkflowByID returns not found; the fixture dataset does not model workflow
+// files.
+func (a actionsService) GetWorkflowByID(_ context.Context, owner, repo string, workflowID int64) (*github.Workflow, *github.Response, error) {
+	return nil, nil, notFound(fmt.Sprintf("/repos/%s/%s/actions/workflows/%d", owner, repo, workflowID))
+}
+
+// ListWorkflowJobs returns no jobs; the fixture dataset does not model jobs.
+func (a actionsService) ListWorkflowJobs(ctx context.Context, owner, repo string, runID int64, _ *github.ListWorkflowJobsOptions) (*github.Jobs, *github.Response, error) {
+	if _, _, err := a.GetWorkflowRunByID(ctx, owner, repo, runID); err != nil {
+		return nil, nil, err
+	}
+	return &github.Jobs{TotalCount: github.Int(0)}, &github.Response{}, nil
+}
+
+// CreateWorkflowDispatchEventByFileName records a queued run for the workflow, the
+// way GitHub would once it picks up the dispatch.
+func (a actionsService) CreateWorkflowDispatchEventByFileName(_ context.Context, owner, repo, workflowFileName string, event github.CreateWorkflowDispatchEventRequest) (*github.Response, error) {
+	a.s.mu.Lock()
+	defer a.s.mu.Unlock()
+
+	var id int64 = 1
+	for _, run := range a.s.runs {
+		if run.GetID() >= id {
+			id = run.GetID() + 1
+		}
+	}
+
+	now := github.Timestamp{Time: time.Now().UTC()}
+	a.s.runs = append(a.s.runs, &github.WorkflowRun{
+		ID:         github.Int64(id),
+		Name:       github.String(workflowFileName),
+		HeadBranch: github.String(event.Ref),
+		Status:     github.String("queued"),
+		Event:      github.String("workflow_dispatch"),
+		HTMLURL:    github.String(fmt.Sprintf("https://github.com/%s/%s/actions/runs/%d", owner, repo, id)),
+		Actor:      &github.User{Login: github.String(fixtureUser)},
+		CreatedAt:  &now,
+		UpdatedAt:  &now,
+	})
+	return &github.Response{}, nil
+}
+
+// GetWorkflowRunAttempt returns the latest attempt of a run; the fixture dataset
+// does not model earlier attempts, so they are not found.
+func (a actionsService) GetWorkflowRunAttempt(ctx context.Context, owner, repo string, runID int64, attemptNumber int, _ *github.WorkflowRunAttemptOptions) (*github.WorkflowRun, *github.Response, error) {
+	run, resp, err := a.GetWorkflowRunByID(ctx, owner, repo, runID)
+	if err != nil || attemptNumber == max(run.GetRunAttempt(), 1) {
+		return run, resp, err
+	}
+	return nil, nil, notFound(fmt.Sprintf("/repos/%s/%s/actions/runs/%d/attempts/%d", owner, repo, runID, attemptNumber))
+}
+
+// ListArtifacts returns no artifacts; the fixture dataset does not model storage.
+func (a actionsService) ListArtifacts(context.Context, string, string, *github.ListOptions) (*github.ArtifactList, *github.Response, error) {
+	return &github.ArtifactList{TotalCount: github.Int64(0)}, &github.Response{}, nil
+}
+
+// DeleteArtifact returns not found, as there are no artifacts.
+func (a actionsService) DeleteArtifact(_ context.Context, owner, repo string, artifactID int64) (*github.Response, error) {
+	return nil, notFound(fmt.Sprintf("/repos/%s/%s/actions/artifacts/%d", owner, repo, artifactID))
+}
+
+// ListCaches returns no caches; the fixture dataset does not model storage.
+func (a actionsService) ListCaches(context.Context, string, string, *github.ActionsCacheListOptions) (*github.ActionsCacheList, *github.Response, error) {
+	return &github.ActionsCacheList{}, &github.Response{}, nil
+}
+
+// DeleteCachesByID returns not found, as there are no caches.
+func (a actionsService) DeleteCachesByID(_ context.Context, owner, repo string, cacheID int64) (*github.Response, error) {
+	return nil, notFound(fmt.Sprintf("/repos/%s/%s/actions/caches/%d", owner, repo, cacheID))
+}
+
+// ListRepoSecrets returns no secrets; the fixture dataset does not model them.
+func (a actionsService) ListRepoSecrets(context.Context, string, string, *github.ListOptions) (*github.Secrets, *github.Response, error) {
+	return &github.Secrets{}, &github.Response{}, nil
+}
+
+// ListEnvSecrets returns no secrets; the fixture dataset does not model them.
+func (a actionsService) ListEnvSecrets(context.Context, int, string, *github.ListOptions) (*github.Secrets, *github.Response, error) {
+	return &github.Secrets{}, &github.Response{}, nil
+}
+
+// ListRepoVariables returns no variables; the fixture dataset does not model them.
+func (a actionsService) ListRepoVariables(context.Context, string, string, *github.ListOptions) (*github.ActionsVariables, *github.Response, error) {
+	return &github.ActionsVariables{}, &github.Response{}, nil
+}
+
+// ListEnvVariables returns no variables; the fixture dataset does not model them.
+func (a actionsService) ListEnvVariables(context.Context, int, string, *github.ListOptions) (*github.ActionsVariables, *github.Response, error) {
+	return &github.ActionsVariables{}, &github.Response{}, nil
+}
@@ -0,0 +1,182 @@
+// Package fixtures serves the GitHub API from local JSON files so the providers
+// can run with no token and no network, for demos and orchestration testing.
+//
+// Files use the shapes GitHub returns, so captured API responses can be dropped
+// in directly:
+//
+//	issues.json         array of issues (pull requests are included and skipped, as on GitHub)
+//	comments.json       object mapping issue number to an array of comments
+//	workflow_runs.json  workflow run list response ({"workflow_runs": [...]})
+//	org.json            the organization
+//	teams.json          array of teams
+//	members.json        object mapping team slug to an array of users
+//	memberships.json    object mapping team slug to {login: role}
+//	users.json          array of users with profile details
+//
+// Missing files are treated as empty. Writes are applied in memory only.
+package fixtures
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+//go:embed data/*.json
+var builtin embed.FS
+
+var (
+	storesMu sync.Mutex
+	stores   = map[string]*Store{}
+)
+
+// Store holds fixture data and the in-memory effect of writes.
+type Store struct {
+	mu sync.Mutex
+
+	issues      []*github.Issue
+	comments    map[string][]*github.IssueComment
+	runs        []*github.WorkflowRun
+	org         *github.Organization
+	teams       []*github.Team
+	members     map[string][]*github.User
+	memberships map[string]map[string]string
+	users       []*github.User
+}
+
+// Open returns the store for dir, loading it on first use. Providers in the same
+// process share a store, so a ticket created by one is visible to the others. An
+// empty dir selects the built-in demo dataset.
+func Open(dir string) (*Store, error) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	if s, ok := stores[dir]; ok {
+		return s, nil
+	}
+
+	var fsys fs.FS
+	if dir == "" {
+		sub, err := fs.Sub(builtin, "data")
+		if err != nil {
+			return nil, err
+		}
+		fsys = sub
+	} else {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("fixtures directory: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("fixtures directory %s is not a directory", dir)
+		}
+		fsys = os.DirFS(dir)
+	}
+
+	s, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	stores[dir] = s
+	return s, nil
+}
+
+// Services returns the API services backed by the store. Raw passthrough is not
+// available in fixtures mode.
+func (s *Store) Services() ghapi.Services {
+	return ghapi.Services{
+		Issues:        issuesService{s},
+		Markdown:      markdownService{},
+		Actions:       actionsService{s},
+		Teams:         teamsService{s},
+		Organizations: organizationsService{s},
+		Users:         usersService{s},
+	}
+}
+
+func load(fsys fs.FS) (*Store, error) {
+	s := &Store{
+		comments:    map[string][]*github.IssueComment{},
+		members:     map[string][]*github.User{},
+		memberships: map[string]map[string]string{},
+		org:         &github.Organization{},
+	}
+
+	var runs github.WorkflowRuns
+	files := []struct {
+		name string
+		v    any
+	}{
+		{"issues.json", &s.issues},
+		{"comments.json", &s.comments},
+		{"workflow_runs.json", &runs},
+		{"org.json", s.org},
+		{"teams.json", &s.teams},
+		{"members.json", &s.members},
+		{"memberships.json", &s.memberships},
+		{"users.json", &s.users},
+	}
+	for _, f := range files {
+		data, err := fs.ReadFile(fsys, f.name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, f.v); err != nil {
+			return nil, fmt.Errorf("fixtures: decoding %s: %w", f.name, err)
+		}
+	}
+	s.runs = runs.WorkflowRuns
+
+	return s, nil
+}
+
+// notFound returns the error GitHub reports for a missing resource.
+func notFound(path string) error {
+	return &github.ErrorResponse{
+		Response: &http.Response{
+			StatusCode: http.StatusNotFound,
+			Request:    &http.Request{Method: http.MethodGet, URL: &url.URL{Path: path}},
+		},
+		Message: "Not Found",
+	}
+}
+
+// page slices items according to opts, returning the response with NextPage set.
+func page[T any](items []T, opts *github.ListOptions) ([]T, *github.Response) {
+	perPage, current := 30, 1
+	if opts != nil {
+		if opts.PerPage > 0 {
+			perPage = opts.PerPage
+		}
+		if opts.Page > 0 {
+			current = opts.Page
+		}
+	}
+
+	start := (current - 1) * perPage
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + perPage
+	if end > len(items) {
+		end = len(items)
+	}
+
+	resp := &github.Response{}
+	if end < len(items) {
+		resp.NextPage = current + 1
+	}
+	return items[start:end], resp
+}
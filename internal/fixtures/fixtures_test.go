@@ -0,0 +1,175 @@
+package fixtures
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// fresh loads the built-in dataset into a store that is not shared with other tests.
+func fresh(t *testing.T) *Store {
+	t.Helper()
+	sub, _ := fs.Sub(builtin, "data")
+	s, err := load(sub)
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	return s
+}
+
+func TestIssues(t *testing.T) {
+	api := fresh(t).Services()
+	ctx := context.Background()
+
+	open, _, err := api.Issues.ListByRepo(ctx, "opsorch", "demo", &github.IssueListByRepoOptions{Labels: []string{"incident"}})
+	if err != nil {
+		t.Fatalf("ListByRepo() error = %v", err)
+	}
+	if len(open) != 1 || open[0].GetNumber() != 1 {
+		t.Errorf("open incidents = %d, want issue 1", len(open))
+	}
+
+	all, resp, _ := api.Issues.ListByRepo(ctx, "opsorch", "demo", &github.IssueListByRepoOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 3},
+	})
+	if len(all) != 3 || resp.NextPage != 2 {
+		t.Errorf("page 1: len=%d next=%d", len(all), resp.NextPage)
+	}
+
+	title := "Payments latency"
+	created, _, err := api.Issues.Create(ctx, "opsorch", "demo", &github.IssueRequest{Title: &title, Labels: &[]string{"sev2"}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.GetNumber() != 5 || created.GetState() != "open" {
+		t.Errorf("unexpected issue: %+v", created)
+	}
+
+	closed := "closed"
+	edited, _, err := api.Issues.Edit(ctx, "opsorch", "demo", 5, &github.IssueRequest{State: &closed})
+	if err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	if edited.GetState() != "closed" || edited.ClosedAt == nil || edited.GetTitle() != title {
+		t.Errorf("unexpected edit: %+v", edited)
+	}
+
+	if _, _, err := api.Issues.CreateComment(ctx, "opsorch", "demo", 1, &github.IssueComment{Body: github.String("Postmortem scheduled")}); err != nil {
+		t.Fatalf("CreateComment() error = %v", err)
+	}
+	comments, _, _ := api.Issues.ListComments(ctx, "opsorch", "demo", 1, nil)
+	if len(comments) != 3 || comments[2].GetID() != 9003 {
+		t.Errorf("comments = %d, last id %d", len(comments), comments[len(comments)-1].GetID())
+	}
+
+	timeline, _, err := api.Issues.ListIssueTimeline(ctx, "opsorch", "demo", 3, nil)
+	if err != nil || len(timeline) != 1 || timeline[0].GetEvent() != "closed" {
+		t.Errorf("timeline of closed issue = %+v, %v", timeline, err)
+	}
+
+	_, _, err = api.Issues.Get(ctx, "opsorch", "demo", 99)
+	var ghErr *github.ErrorResponse
+	if !errors.As(err, &ghErr) || ghErr.Response.StatusCode != 404 {
+		t.Errorf("Get(99) error = %v, want 404", err)
+	}
+	if ghErr != nil && ghErr.Error() == "" {
+		t.Error("expected a printable error")
+	}
+}
+
+func TestActions(t *testing.T) {
+	api := fresh(t).Services()
+	ctx := context.Background()
+
+	runs, _, err := api.Actions.ListRepositoryWorkflowRuns(ctx, "opsorch", "demo", &github.ListWorkflowRunsOptions{Status: "completed", Branch: "main"})
+	if err != nil {
+		t.Fatalf("ListRepositoryWorkflowRuns() error = %v", err)
+	}
+	if runs.GetTotalCount() != 2 {
+		t.Errorf("completed main runs = %d, want 2", runs.GetTotalCount())
+	}
+	bySHA, _, _ := api.Actions.ListRepositoryWorkflowRuns(ctx, "opsorch", "demo", &github.ListWorkflowRunsOptions{HeadSHA: "9b8a7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a09"})
+	if bySHA.GetTotalCount() != 1 || bySHA.WorkflowRuns[0].GetID() != 7002 {
+		t.Errorf("runs for head sha = %d, want run 7002", bySHA.GetTotalCount())
+	}
+
+	run, _, err := api.Actions.GetWorkflowRunByID(ctx, "opsorch", "demo", 7003)
+	if err != nil || run.GetStatus() != "in_progress" {
+		t.Errorf("GetWorkflowRunByID() = %v, %v", run, err)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	api := fresh(t).Services()
+
+	got, _, err := api.Markdown.Render(context.Background(), "Error rate <12%>\r\n\r\n\nRolled back.", nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "<p>Error rate &lt;12%&gt;</p>\n<p>Rolled back.</p>\n"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestTeams(t *testing.T) {
+	api := fresh(t).Services()
+	ctx := context.Background()
+
+	org, _, _ := api.Organizations.Get(ctx, "acme")
+	if org.GetLogin() != "acme" || org.GetID() != 1000 {
+		t.Errorf("unexpected org: %+v", org)
+	}
+
+	team, _, err := api.Teams.GetTeamBySlug(ctx, "acme", "payments")
+	if err != nil {
+		t.Fatalf("GetTeamBySlug() error = %v", err)
+	}
+	members, _, _ := api.Teams.ListTeamMembersByID(ctx, org.GetID(), team.GetID(), nil)
+	if len(members) != 2 {
+		t.Fatalf("members = %d, want 2", len(members))
+	}
+	membership, _, err := api.Teams.GetTeamMembershipByID(ctx, org.GetID(), team.GetID(), "alice")
+	if err != nil || membership.GetRole() != "maintainer" {
+		t.Errorf("membership = %v, %v", membership, err)
+	}
+	if user, _, err := api.Users.Get(ctx, "bob"); err != nil || user.GetName() != "Bob Okafor" {
+		t.Errorf("Users.Get() = %v, %v", user, err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "issues.json"), []byte(`[{"number": 42, "title": "Custom", "state": "open"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if again, _ := Open(dir); again != s {
+		t.Error("expected the store to be shared")
+	}
+
+	// Missing files are empty
+	teams, _, _ := s.Services().Teams.ListTeams(context.Background(), "acme", nil)
+	if len(teams) != 0 {
+		t.Errorf("teams = %d, want 0", len(teams))
+	}
+
+	if _, err := Open(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for missing directory")
+	}
+
+	bad := t.TempDir()
+	os.WriteFile(filepath.Join(bad, "teams.json"), []byte(`{not json`), 0o644)
+	if _, err := Open(bad); err == nil {
+		t.Error("expected error for malformed fixture")
+	}
+}
@@ -0,0 +1,305 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// fixtureUser authors issues and comments created in fixtures mode.
+const fixtureUser = "opsorch"
+
+type issuesService struct{ s *Store }
+
+func (i issuesService) ListByRepo(_ context.Context, owner, repo string, opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+	i.s.mu.Lock()
+	defer i.s.mu.Unlock()
+
+	if opts == nil {
+		opts = &github.IssueListByRepoOptions{}
+	}
+	state := opts.State
+	if state == "" {
+		state = "open"
+	}
+
+	var matched []*github.Issue
+	for _, issue := range i.s.issues {
+		if state != "all" && issue.GetState() != state {
+			continue
+		}
+		if opts.Assignee != "" && !hasAssignee(issue, opts.Assignee) {
+			continue
+		}
+		if !hasLabels(issue, opts.Labels) {
+			continue
+		}
+		matched = append(matched, issue)
+	}
+
+	items, resp := page(matched, &opts.ListOptions)
+	return items, resp, nil
+}
+
+func (i issuesService) Get(_ context.Context, owner, repo string, number int) (*github.Issue, *github.Response, error) {
+	i.s.mu.Lock()
+	defer i.s.mu.Unlock()
+
+	issue := i.s.issue(number)
+	if issue == nil {
+		return nil, nil, notFound(fmt.Sprintf("/repos/%s/%s/issues/%d", owner, repo, number))
+	}
+	return issue, &github.Response{}, nil
+}
+
+func (i issuesService) Create(_ context.Context, owner, repo string, req *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	i.s.mu.Lock()
+	defer i.s.mu.Unlock()
+
+	number := 1
+	for _, issue := range i.s.issues {
+		if issue.GetNumber() >= number {
+			number = issue.GetNumber() + 1
+		}
+	}
+
+	now := github.Timestamp{Time: time.Now().UTC()}
+	issue := &github.Issue{
+		Number:    github.Int(number),
+		State:     github.String("open"),
+		HTMLURL:   github.String(fmt.Sprintf("https://github.com/%s/%s/issues/%d", owner, repo, number)),
+		User:      &github.User{Login: github.String(fixtureUser)},
+		CreatedAt: &now,
+		UpdatedAt: &now,
+	}
+	applyIssueRequest(issue, req)
+	i.s.issues = append(i.s.issues, issue)
+	return issue, &github.Response{}, nil
+}
+
+func (i issuesService) Edit(_ context.Context, owner, repo string, number int, req *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	i.s.mu.Lock()
+	defer i.s.mu.Unlock()
+
+	issue := i.s.issue(number)
+	if issue == nil {
+		return nil, nil, notFound(fmt.Sprintf("/repos/%s/%s/issues/%d", owner, repo, number))
+	}
+
+	applyIssueRequest(issue, req)
+	now := github.Timestamp{Time: time.Now().UTC()}
+	issue.UpdatedAt = &now
+	if issue.GetState() == "closed" && issue.ClosedAt == nil {
+		issue.ClosedAt = &now
+	}
+	return issue, &github.Response{}, nil
+}
+
+func (i issuesService) RemoveMilestone(_ context.Context, owner, repo string, number int) (*github.Issue, *github.Response, error) {
+	i.s.mu.Lock()
+	defer i.s.mu.Unlock()
+
+	issue := i.s.issue(number)
+	if issue == nil {
+		return nil, nil, notFound(fmt.Sprintf("/repos/%s/%s/issues/%d", owner, repo, number))
+	}
+	issue.Milestone = nil
+	now := github.Timestamp{Time: time.Now().UTC()}
+	issue.UpdatedAt = &now
+	return issue, &github.Response{}, nil
+}
+
+// ListMilestones returns no milestones; fixtures do not model them.
+func (i issuesService) ListMilestones(_ context.Context, _, _ string, _ *github.MilestoneListOptions) ([]*github.Milestone, *github.Response, error) {
+	return []*github.Milestone{}, &github.Response{}, nil
+}
+
+func (i issuesService) ListComments(_ context.Context, owner, repo string, number int, opts *github.IssueListCommentsOptions) ([]*github.IssueComment, *github.Response, error) {
+	i.s.mu.Lock()
+	defer i.s.mu.Unlock()
+
+	if i.s.issue(number) == nil {
+		return nil, nil, notFound(fmt.Sprintf("/repos/%s/%s/issues/%d/comments", owner, repo, number))
+	}
+
+	var list *github.ListOptions
+	if opts != nil {
+		list = &opts.ListOptions
+	}
+	items, resp := page(i.s.comments[strconv.Itoa(number)], list)
+	return items, resp, nil
+}
+
+func (i issuesService) CreateComment(_ context.Context, owner, repo string, number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	i.s.mu.Lock()
+	defer i.s.mu.Unlock()
+
+	if i.s.issue(number) == nil {
+		return nil, nil, notFound(fmt.Sprintf("/repos/%s/%s/issues/%d/comments", owner, repo, number))
+	}
+
+	var id int64 = 1
+	for _, comments := range i.s.comments {
+		for _, c := range comments {
+			if c.GetID() >= id {
+				id = c.GetID() + 1
+			}
+		}
+	}
+
+	now := github.Timestamp{Time: time.Now().UTC()}
+	created := &github.IssueComment{
+		ID:        github.Int64(id),
+		Body:      github.String(comment.GetBody()),
+		HTMLURL:   github.String(fmt.Sprintf("https://github.com/%s/%s/issues/%d#issuecomment-%d", owner, repo, number, id)),
+		User:      &github.User{Login: github.String(fixtureUser)},
+		CreatedAt: &now,
+		UpdatedAt: &now,
+	}
+	key := strconv.Itoa(number)
+	i.s.comments[key] = append(i.s.comments[key], created)
+	return created, &github.Response{}, nil
+}
+
+func (i issuesService) AddLabelsToIssue(_ context.Context, owner, repo string, number int, labels []string) ([]*github.Label, *github.Response, error) {
+	i.s.mu.Lock()
+	defer i.s.mu.Unlock()
+
+	issue := i.s.issue(number)
+	if issue == nil {
+		return nil, nil, notFound(fmt.Sprintf("/repos/%s/%s/issues/%d/labels", owner, repo, number))
+	}
+
+	for _, name := range labels {
+		if !hasLabels(issue, []string{name}) {
+			issue.Labels = append(issue.Labels, &github.Label{Name: github.String(name)})
+		}
+	}
+	return issue.Labels, &github.Response{}, nil
+}
+
+// IsAssignee accepts the fixture users and team members, standing in for the
+// push access GitHub checks.
+func (i issuesService) IsAssignee(_ context.Context, owner, repo, user string) (bool, *github.Response, error) {
+	i.s.mu.Lock()
+	defer i.s.mu.Unlock()
+
+	for _, u := range i.s.users {
+		if strings.EqualFold(u.GetLogin(), user) {
+			return true, &github.Response{}, nil
+		}
+	}
+	for _, members := range i.s.members {
+		for _, u := range members {
+			if strings.EqualFold(u.GetLogin(), user) {
+				return true, &github.Response{}, nil
+			}
+		}
+	}
+	return false, &github.Response{}, nil
+}
+
+// ListIssueTimeline derives cross-referenced events from the other fixture issues
+// whose bodies mention #number, followed by a closed event if the issue is
+// closed. Other timeline events are not modelled.
+func (i issuesService) ListIssueTimeline(_ context.Context, owner, repo string, number int, opts *github.ListOptions) ([]*github.Timeline, *github.Response, error) {
+	i.s.mu.Lock()
+	defer i.s.mu.Unlock()
+
+	root := i.s.issue(number)
+	if root == nil {
+		return nil, nil, notFound(fmt.Sprintf("/repos/%s/%s/issues/%d/timeline", owner, repo, number))
+	}
+
+	mention := regexp.MustCompile(fmt.Sprintf(`(^|[^\w/])#%d\b`, number))
+	var events []*github.Timeline
+	for _, issue := range i.s.issues {
+		if issue.GetNumber() != number && mention.MatchString(issue.GetBody()) {
+			events = append(events, &github.Timeline{
+				Event:     github.String("cross-referenced"),
+				CreatedAt: issue.CreatedAt,
+				Source:    &github.Source{Type: github.String("issue"), Issue: issue},
+			})
+		}
+	}
+
+	if root.GetState() == "closed" {
+		events = append(events, &github.Timeline{
+			Event:     github.String("closed"),
+			Actor:     root.ClosedBy,
+			CreatedAt: root.ClosedAt,
+		})
+	}
+
+	items, resp := page(events, opts)
+	return items, resp, nil
+}
+
+// issue returns the issue with number, or nil. Callers hold s.mu.
+func (s *Store) issue(number int) *github.Issue {
+	for _, issue := range s.issues {
+		if issue.GetNumber() == number {
+			return issue
+		}
+	}
+	return nil
+}
+
+// applyIssueRequest copies the fields set in req onto issue.
+func applyIssueRequest(issue *github.Issue, req *github.IssueRequest) {
+	if req.Title != nil {
+		issue.Title = req.Title
+	}
+	if req.Body != nil {
+		issue.Body = req.Body
+	}
+	if req.State != nil {
+		issue.State = req.State
+	}
+	if req.Assignees != nil {
+		issue.Assignees = nil
+		for _, login := range *req.Assignees {
+			issue.Assignees = append(issue.Assignees, &github.User{Login: github.String(login)})
+		}
+	}
+	if req.Labels != nil {
+		issue.Labels = nil
+		for _, name := range *req.Labels {
+			issue.Labels = append(issue.Labels, &github.Label{Name: github.String(name)})
+		}
+	}
+	if req.Milestone != nil {
+		issue.Milestone = &github.Milestone{Number: req.Milestone}
+	}
+}
+
+func hasAssignee(issue *github.Issue, login string) bool {
+	for _, a := range issue.Assignees {
+		if strings.EqualFold(a.GetLogin(), login) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLabels reports whether issue carries every label in names.
+func hasLabels(issue *github.Issue, names []string) bool {
+	for _, name := range names {
+		found := false
+		for _, l := range issue.Labels {
+			if strings.EqualFold(l.GetName(), name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,23 @@
+package fixtures
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+type markdownService struct{}
+
+// Render stands in for GitHub's renderer offline: each paragraph is escaped
+// and wrapped in <p>, and no other Markdown is interpreted.
+func (markdownService) Render(_ context.Context, text string, _ *github.MarkdownOptions) (string, *github.Response, error) {
+	var b strings.Builder
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			b.WriteString("<p>" + html.EscapeString(paragraph) + "</p>\n")
+		}
+	}
+	return b.String(), &github.Response{}, nil
+}
@@ -0,0 +1,231 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/go-github/v57/github"
+)
+
+type teamsService struct{ s *Store }
+
+func (t teamsService) ListTeams(_ context.Context, org string, opts *github.ListOptions) ([]*github.Team, *github.Response, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+
+	items, resp := page(t.s.teams, opts)
+	return items, resp, nil
+}
+
+func (t teamsService) GetTeamBySlug(_ context.Context, org, slug string) (*github.Team, *github.Response, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+
+	for _, team := range t.s.teams {
+		if team.GetSlug() == slug {
+			return team, &github.Response{}, nil
+		}
+	}
+	return nil, nil, notFound(fmt.Sprintf("/orgs/%s/teams/%s", org, slug))
+}
+
+func (t teamsService) GetTeamByID(_ context.Context, orgID, teamID int64) (*github.Team, *github.Response, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+
+	team := t.s.team(teamID)
+	if team == nil {
+		return nil, nil, notFound(fmt.Sprintf("/organizations/%d/team/%d", orgID, teamID))
+	}
+	return team, &github.Response{}, nil
+}
+
+func (t teamsService) ListTeamMembersByID(_ context.Context, orgID, teamID int64, opts *github.TeamListTeamMembersOptions) ([]*github.User, *github.Response, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+
+	team := t.s.team(teamID)
+	if team == nil {
+		return nil, nil, notFound(fmt.Sprintf("/organizations/%d/team/%d/members", orgID, teamID))
+	}
+
+	var list *github.ListOptions
+	if opts != nil {
+		list = &opts.ListOptions
+	}
+	items, resp := page(t.s.members[team.GetSlug()], list)
+	return items, resp, nil
+}
+
+func (t teamsService) GetTeamMembershipByID(_ context.Context, orgID, teamID int64, user string) (*github.Membership, *github.Response, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+
+	path := fmt.Sprintf("/organizations/%d/team/%d/memberships/%s", orgID, teamID, user)
+	team := t.s.team(teamID)
+	if team == nil {
+		return nil, nil, notFound(path)
+	}
+	role, ok := t.s.memberships[team.GetSlug()][user]
+	if !ok {
+		return nil, nil, notFound(path)
+	}
+	return &github.Membership{State: github.String("active"), Role: github.String(role)}, &github.Response{}, nil
+}
+
+func (t teamsService) ListTeamMembersBySlug(_ context.Context, org, slug string, opts *github.TeamListTeamMembersOptions) ([]*github.User, *github.Response, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+
+	if !t.s.hasTeam(slug) {
+		return nil, nil, notFound(fmt.Sprintf("/orgs/%s/teams/%s/members", org, slug))
+	}
+
+	var list *github.ListOptions
+	if opts != nil {
+		list = &opts.ListOptions
+	}
+	items, resp := page(t.s.members[slug], list)
+	return items, resp, nil
+}
+
+func (t teamsService) GetTeamMembershipBySlug(_ context.Context, org, slug, user string) (*github.Membership, *github.Response, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+
+	role, ok := t.s.memberships[slug][user]
+	if !t.s.hasTeam(slug) || !ok {
+		return nil, nil, notFound(fmt.Sprintf("/orgs/%s/teams/%s/memberships/%s", org, slug, user))
+	}
+	return &github.Membership{State: github.String("active"), Role: github.String(role)}, &github.Response{}, nil
+}
+
+// ListChildTeamsByParentSlug returns the fixture teams whose parent is slug.
+func (t teamsService) ListChildTeamsByParentSlug(_ context.Context, org, slug string, opts *github.ListOptions) ([]*github.Team, *github.Response, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+
+	if !t.s.hasTeam(slug) {
+		return nil, nil, notFound(fmt.Sprintf("/orgs/%s/teams/%s/teams", org, slug))
+	}
+	children := []*github.Team{}
+	for _, team := range t.s.teams {
+		if team.GetParent().GetSlug() == slug {
+			children = append(children, team)
+		}
+	}
+	items, resp := page(children, opts)
+	return items, resp, nil
+}
+
+// ListChildTeamsByParentID returns the fixture teams whose parent is teamID.
+func (t teamsService) ListChildTeamsByParentID(_ context.Context, orgID, teamID int64, opts *github.ListOptions) ([]*github.Team, *github.Response, error) {
+	t.s.mu.Lock()
+	defer t.s.mu.Unlock()
+
+	if t.s.team(teamID) == nil {
+		return nil, nil, notFound(fmt.Sprintf("/organizations/%d/team/%d/teams", orgID, teamID))
+	}
+	children := []*github.Team{}
+	for _, team := range t.s.teams {
+		if team.GetParent().GetID() == teamID {
+			children = append(children, team)
+		}
+	}
+	items, resp := page(children, opts)
+	return items, resp, nil
+}
+
+// hasTeam reports whether a team with slug exists. Callers hold s.mu.
+func (s *Store) hasTeam(slug string) bool {
+	for _, team := range s.teams {
+		if team.GetSlug() == slug {
+			return true
+		}
+	}
+	return false
+}
+
+// team returns the team with id, or nil. Callers hold s.mu.
+func (s *Store) team(id int64) *github.Team {
+	for _, team := range s.teams {
+		if team.GetID() == id {
+			return team
+		}
+	}
+	return nil
+}
+
+type organizationsService struct{ s *Store }
+
+// Get returns the fixture organization under whatever name the provider is
+// configured with, so demos work without matching the fixture login.
+func (o organizationsService) Get(_ context.Context, org string) (*github.Organization, *github.Response, error) {
+	o.s.mu.Lock()
+	defer o.s.mu.Unlock()
+
+	result := *o.s.org
+	result.Login = github.String(org)
+	return &result, &github.Response{}, nil
+}
+
+// GetOrgMembership reports an active membership for any user on a fixture team.
+func (o organizationsService) GetOrgMembership(_ context.Context, user, org string) (*github.Membership, *github.Response, error) {
+	o.s.mu.Lock()
+	defer o.s.mu.Unlock()
+
+	for _, member := range o.s.orgMembers() {
+		if member.GetLogin() == user {
+			return &github.Membership{
+				State: github.String("active"),
+				Role:  github.String("member"),
+				User:  member,
+			}, &github.Response{}, nil
+		}
+	}
+	return nil, nil, notFound("/orgs/" + org + "/memberships/" + user)
+}
+
+// ListMembers returns the users on fixture teams. Fixtures record no 2FA
+// status, so the 2fa_disabled filter matches nobody.
+func (o organizationsService) ListMembers(_ context.Context, _ string, opts *github.ListMembersOptions) ([]*github.User, *github.Response, error) {
+	o.s.mu.Lock()
+	defer o.s.mu.Unlock()
+
+	if opts != nil && opts.Filter == "2fa_disabled" {
+		return []*github.User{}, &github.Response{}, nil
+	}
+	return o.s.orgMembers(), &github.Response{}, nil
+}
+
+// orgMembers returns the users on any fixture team, each once, sorted by login.
+// The caller holds s.mu.
+func (s *Store) orgMembers() []*github.User {
+	seen := map[string]bool{}
+	var users []*github.User
+	for _, members := range s.members {
+		for _, user := range members {
+			if !seen[user.GetLogin()] {
+				seen[user.GetLogin()] = true
+				users = append(users, user)
+			}
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].GetLogin() < users[j].GetLogin() })
+	return users
+}
+
+type usersService struct{ s *Store }
+
+func (u usersService) Get(_ context.Context, login string) (*github.User, *github.Response, error) {
+	u.s.mu.Lock()
+	defer u.s.mu.Unlock()
+
+	for _, user := range u.s.users {
+		if user.GetLogin() == login {
+			return user, &github.Response{}, nil
+		}
+	}
+	return nil, nil, notFound("/users/" + login)
+}
@@ -0,0 +1,417 @@
+// Package ghconfig holds configuration parsing helpers shared by the GitHub providers.
+package ghconfig
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/apiversion"
+	"github.com/opsorch/opsorch-github-adapter/internal/conditional"
+	"github.com/opsorch/opsorch-github-adapter/internal/fixtures"
+	"github.com/opsorch/opsorch-github-adapter/internal/httpdump"
+	"github.com/opsorch/opsorch-github-adapter/internal/redact"
+	"github.com/opsorch/opsorch-github-adapter/internal/throttle"
+)
+
+// Environment variables consulted when a value is missing from the provider config.
+// These are the names GitHub Actions sets for every job.
+const (
+	EnvToken           = "GITHUB_TOKEN"
+	EnvRepository      = "GITHUB_REPOSITORY"
+	EnvRepositoryOwner = "GITHUB_REPOSITORY_OWNER"
+	EnvAPIURL          = "GITHUB_API_URL"
+	EnvActions         = "GITHUB_ACTIONS"
+)
+
+// DefaultAPIURL is the API of github.com, which needs no "apiURL".
+const DefaultAPIURL = "https://api.github.com"
+
+// String returns the string value stored under key, or "" if it is missing or not a string.
+func String(cfg map[string]any, key string) string {
+	if v, ok := cfg[key].(string); ok {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+// Token returns the configured token, falling back to GITHUB_TOKEN.
+func Token(cfg map[string]any) string {
+	if token := String(cfg, "token"); token != "" {
+		return token
+	}
+	return strings.TrimSpace(os.Getenv(EnvToken))
+}
+
+// InActions reports whether the adapter runs inside a GitHub Actions job.
+func InActions() bool {
+	return Env(EnvActions) == "true"
+}
+
+// JobToken reports whether token is the GITHUB_TOKEN of the Actions job the
+// adapter runs in: an installation token ("ghs_") seen inside Actions. It can
+// only do what the workflow's permissions key grants, and never reads
+// organization teams or members.
+func JobToken(token string) bool {
+	return InActions() && strings.HasPrefix(token, "ghs_")
+}
+
+// APIURL returns the configured "apiURL", falling back to GITHUB_API_URL, which
+// Actions sets to the API of the GitHub instance running the job. It returns
+// "" for github.com.
+func APIURL(cfg map[string]any) string {
+	api := String(cfg, "apiURL")
+	if api == "" {
+		api = Env(EnvAPIURL)
+	}
+	if api = strings.TrimSuffix(api, "/"); api == DefaultAPIURL {
+		return ""
+	}
+	return api
+}
+
+// Repository resolves the owner and repository name. Explicit "owner"/"repo" keys win,
+// then the "repository" shorthand ("owner/name"), then GITHUB_REPOSITORY.
+func Repository(cfg map[string]any) (owner, repo string, err error) {
+	owner = String(cfg, "owner")
+	repo = String(cfg, "repo")
+	if owner != "" && repo != "" {
+		return owner, repo, nil
+	}
+
+	full := String(cfg, "repository")
+	if full == "" {
+		full = strings.TrimSpace(os.Getenv(EnvRepository))
+	}
+	if full != "" {
+		o, r, err := SplitRepository(full)
+		if err != nil {
+			return "", "", err
+		}
+		if owner == "" {
+			owner = o
+		}
+		if repo == "" {
+			repo = r
+		}
+	}
+	return owner, repo, nil
+}
+
+// Organization returns the configured organization, falling back to GITHUB_REPOSITORY_OWNER
+// and then the owner half of GITHUB_REPOSITORY.
+func Organization(cfg map[string]any) string {
+	if org := String(cfg, "organization"); org != "" {
+		return org
+	}
+	if owner := strings.TrimSpace(os.Getenv(EnvRepositoryOwner)); owner != "" {
+		return owner
+	}
+	if owner, _, err := SplitRepository(os.Getenv(EnvRepository)); err == nil {
+		return owner
+	}
+	return ""
+}
+
+// Profile returns the config of the named entry of the "profiles" map: the
+// base config with the profile's keys laid over it, so profiles only name what
+// differs, such as owner, repo, organization, or token. A profile naming its
+// repository in either form replaces the base repository in both. The empty
+// name selects the base config itself.
+func Profile(cfg map[string]any, name string) (map[string]any, error) {
+	if name == "" {
+		return cfg, nil
+	}
+	profiles, _ := cfg["profiles"].(map[string]any)
+	profile, ok := profiles[name].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q", name)
+	}
+
+	merged := make(map[string]any, len(cfg)+len(profile))
+	for k, v := range cfg {
+		merged[k] = v
+	}
+	delete(merged, "profiles")
+	for _, key := range []string{"owner", "repo", "repository"} {
+		if _, ok := profile[key]; ok {
+			delete(merged, "owner")
+			delete(merged, "repo")
+			delete(merged, "repository")
+			break
+		}
+	}
+	for k, v := range profile {
+		merged[k] = v
+	}
+	return merged, nil
+}
+
+// AllowMethod checks an RPC method against "allowedMethods", the methods a
+// plugin instance may serve, taken from the named profile when it resolves and
+// from cfg otherwise. Entries are method names or path.Match patterns such as
+// "ticket.get*" or "*.query". Without the key every method is allowed; with it,
+// other methods fail with forbidden before they reach GitHub. plugin.health is
+// always allowed.
+func AllowMethod(cfg map[string]any, profile, method string) error {
+	if resolved, err := Profile(cfg, profile); err == nil {
+		cfg = resolved
+	}
+	if _, ok := cfg["allowedMethods"]; !ok || method == "plugin.health" {
+		return nil
+	}
+	for _, pattern := range StringSlice(cfg, "allowedMethods") {
+		matched, err := path.Match(pattern, method)
+		if err != nil {
+			return &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: fmt.Sprintf("allowedMethods entry %q is not a valid pattern", pattern),
+			}
+		}
+		if matched {
+			return nil
+		}
+	}
+	return &orcherr.OpsOrchError{
+		Code:    "forbidden",
+		Message: fmt.Sprintf("method %s is not in this plugin's allowedMethods", method),
+	}
+}
+
+// SplitRepository splits an "owner/name" string into its parts.
+func SplitRepository(full string) (owner, repo string, err error) {
+	parts := strings.Split(strings.TrimSpace(full), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("repository must be in owner/name form, got %q", full)
+	}
+	return parts[0], parts[1], nil
+}
+
+// Bool returns the boolean stored under key. String values such as "true" or "1" are
+// accepted so flags survive env-var based config.
+func Bool(cfg map[string]any, key string) bool {
+	switch v := cfg[key].(type) {
+	case bool:
+		return v
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "1", "yes", "on":
+			return true
+		}
+	}
+	return false
+}
+
+// StringSlice returns the list stored under key. JSON-decoded config produces []any,
+// so both []string and []any of strings are accepted, as is a comma-separated string.
+func StringSlice(cfg map[string]any, key string) []string {
+	var out []string
+	switch v := cfg[key].(type) {
+	case []string:
+		out = append(out, v...)
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+	case string:
+		out = strings.Split(v, ",")
+	}
+
+	result := out[:0]
+	for _, s := range out {
+		if s = strings.TrimSpace(s); s != "" {
+			result = append(result, s)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Env returns the trimmed value of an environment variable.
+func Env(name string) string {
+	return strings.TrimSpace(os.Getenv(name))
+}
+
+// Int returns the integer stored under key, or def when it is missing or invalid.
+// JSON-decoded config produces float64, so numeric strings and floats are accepted.
+func Int(cfg map[string]any, key string, def int) int {
+	switch v := cfg[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+// Duration returns the duration stored under key ("5m", "24h"), or def when it is
+// missing or invalid. Plain numbers are interpreted as seconds.
+func Duration(cfg map[string]any, key string, def time.Duration) time.Duration {
+	switch v := cfg[key].(type) {
+	case float64:
+		return time.Duration(v * float64(time.Second))
+	case int:
+		return time.Duration(v) * time.Second
+	case string:
+		if d, err := time.ParseDuration(strings.TrimSpace(v)); err == nil {
+			return d
+		}
+	}
+	return def
+}
+
+// Client builds an authenticated GitHub client. An *http.Client supplied under
+// "httpClient" is used for transport, which lets the integration recorder and
+// tests sit between the providers and the API. Traffic always passes through
+// httpdump.Default so dumping can be switched on at runtime, and through
+// throttle.Default, which queues calls once configured, and apiversion.Default,
+// which sends "apiVersion" when set and records deprecated endpoints. Reads of
+// a single workflow run, which callers poll, are made conditional.
+func Client(cfg map[string]any, token string) *github.Client {
+	httpClient := &http.Client{}
+	if c, ok := cfg["httpClient"].(*http.Client); ok && c != nil {
+		copied := *c
+		httpClient = &copied
+	}
+	redact.Default.AddSecrets(token)
+	transport := apiversion.Default.Wrap(httpClient.Transport, String(cfg, "apiVersion"))
+	httpClient.Transport = throttle.Default.Wrap(conditional.Wrap(httpdump.Default.Wrap(transport, token), isWorkflowRunPath))
+	client := github.NewClient(httpClient).WithAuthToken(token)
+
+	// GitHub Enterprise Server serves the API under /api/v3 and uploads under
+	// /api/uploads of its host
+	if api := APIURL(cfg); api != "" {
+		host := strings.TrimSuffix(api, "/api/v3")
+		if enterprise, err := client.WithEnterpriseURLs(host+"/api/v3/", host+"/api/uploads/"); err == nil {
+			client = enterprise
+		} else {
+			log.Printf("[config] apiURL %q: %v", api, err)
+		}
+	}
+	return client
+}
+
+// isWorkflowRunPath reports whether path reads a single workflow run,
+// .../repos/{owner}/{repo}/actions/runs/{id}.
+func isWorkflowRunPath(path string) bool {
+	parts := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	n := len(parts)
+	if n < 6 || parts[n-6] != "repos" || parts[n-3] != "actions" || parts[n-2] != "runs" {
+		return false
+	}
+	_, err := strconv.ParseInt(parts[n-1], 10, 64)
+	return err == nil
+}
+
+// HTTPDump returns the dump settings under "debug" ("httpDump", "httpDumpBodies",
+// "httpDumpFile") and whether any of them were set.
+func HTTPDump(cfg map[string]any) (httpdump.Options, bool) {
+	debug, ok := cfg["debug"].(map[string]any)
+	if !ok {
+		return httpdump.Options{}, false
+	}
+	_, enabled := debug["httpDump"]
+	_, bodies := debug["httpDumpBodies"]
+	_, file := debug["httpDumpFile"]
+	if !enabled && !bodies && !file {
+		return httpdump.Options{}, false
+	}
+	return httpdump.Options{
+		Enabled: Bool(debug, "httpDump"),
+		Bodies:  Bool(debug, "httpDumpBodies"),
+		File:    String(debug, "httpDumpFile"),
+	}, true
+}
+
+// Redaction registers the configured token and "redactPatterns" with
+// redact.Default, so they are masked in logs and errors from then on. Like the
+// request queue, redaction is shared by every provider in the process; patterns
+// accumulate rather than replace each other.
+func Redaction(cfg map[string]any) error {
+	redact.Default.AddSecrets(Token(cfg))
+	return redact.Default.AddPatterns(StringSlice(cfg, "redactPatterns")...)
+}
+
+// defaultRequestQueueDepth is how many calls may wait for a slot when
+// "maxConcurrentRequests" is set without "requestQueueDepth".
+const defaultRequestQueueDepth = 50
+
+// Throttle returns the request queue settings, "maxConcurrentRequests" and
+// "requestQueueDepth", and whether the first was set. The queue is shared by
+// every provider in the process, so the last provider configured sets it.
+func Throttle(cfg map[string]any) (throttle.Options, bool) {
+	if _, ok := cfg["maxConcurrentRequests"]; !ok {
+		return throttle.Options{}, false
+	}
+	return throttle.Options{
+		Concurrency: Int(cfg, "maxConcurrentRequests", 0),
+		Depth:       Int(cfg, "requestQueueDepth", defaultRequestQueueDepth),
+	}, true
+}
+
+// Values accepted for the "mode" config key.
+const (
+	ModeAPI      = "api"
+	ModeFixtures = "fixtures"
+)
+
+// Services returns the API implementation selected by "mode": GitHub, authenticated
+// with the configured token, or local fixtures read from "fixturesDir" (the built-in
+// demo dataset when unset), which need no token and no network.
+func Services(cfg map[string]any) (ghapi.Services, error) {
+	if err := Redaction(cfg); err != nil {
+		return ghapi.Services{}, err
+	}
+
+	switch mode := strings.ToLower(String(cfg, "mode")); mode {
+	case "", ModeAPI:
+		token := Token(cfg)
+		if token == "" {
+			return ghapi.Services{}, fmt.Errorf("token is required")
+		}
+		if api := APIURL(cfg); api != "" {
+			if u, err := url.Parse(api); err != nil || u.Scheme == "" || u.Host == "" {
+				return ghapi.Services{}, fmt.Errorf("apiURL must be an absolute URL, got %q", api)
+			}
+		}
+		if err := apiversion.Validate(String(cfg, "apiVersion")); err != nil {
+			return ghapi.Services{}, err
+		}
+		if opts, ok := HTTPDump(cfg); ok {
+			if err := httpdump.Default.Configure(opts); err != nil {
+				return ghapi.Services{}, err
+			}
+		}
+		if opts, ok := Throttle(cfg); ok {
+			throttle.Default.Configure(opts)
+		}
+		return ghapi.FromClient(Client(cfg, token)), nil
+	case ModeFixtures:
+		store, err := fixtures.Open(String(cfg, "fixturesDir"))
+		if err != nil {
+			return ghapi.Services{}, err
+		}
+		return store.Services(), nil
+	default:
+		return ghapi.Services{}, fmt.Errorf("unknown mode %q (expected %s or %s)", mode, ModeAPI, ModeFixtures)
+	}
+}
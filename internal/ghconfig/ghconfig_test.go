@@ -0,0 +1,281 @@
+package ghconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/apiversion"
+)
+
+func TestRepository(t *testing.T) {
+	t.Setenv(EnvRepository, "")
+
+	tests := []struct {
+		name      string
+		config    map[string]any
+		wantOwner string
+		wantRepo  string
+		wantErr   bool
+	}{
+		{"explicit keys", map[string]any{"owner": "o", "repo": "r"}, "o", "r", false},
+		{"shorthand", map[string]any{"repository": "o/r"}, "o", "r", false},
+		{"explicit wins over shorthand", map[string]any{"owner": "x", "repository": "o/r"}, "x", "r", false},
+		{"malformed shorthand", map[string]any{"repository": "o/r/extra"}, "", "", true},
+		{"missing", map[string]any{}, "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, repo, err := Repository(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Repository() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if owner != tt.wantOwner || repo != tt.wantRepo {
+				t.Errorf("Repository() = %q, %q, want %q, %q", owner, repo, tt.wantOwner, tt.wantRepo)
+			}
+		})
+	}
+}
+
+func TestProfile(t *testing.T) {
+	cfg := map[string]any{
+		"owner":    "acme",
+		"repo":     "api",
+		"token":    "base",
+		"cacheTTL": "1m",
+		"profiles": map[string]any{
+			"web":   map[string]any{"repository": "acme/web"},
+			"other": map[string]any{"owner": "other", "repo": "svc", "token": "other-token"},
+		},
+	}
+
+	got, err := Profile(cfg, "")
+	if err != nil || got["repo"] != "api" {
+		t.Errorf("Profile(\"\") = %v, %v, want the base config", got, err)
+	}
+
+	got, err = Profile(cfg, "web")
+	if err != nil {
+		t.Fatalf("Profile(web) error = %v", err)
+	}
+	// The shorthand replaces the base owner/repo instead of losing to them
+	if owner, repo, _ := Repository(got); owner != "acme" || repo != "web" {
+		t.Errorf("Profile(web) repository = %s/%s", owner, repo)
+	}
+	if got["token"] != "base" || got["cacheTTL"] != "1m" || got["profiles"] != nil {
+		t.Errorf("Profile(web) = %v", got)
+	}
+
+	got, err = Profile(cfg, "other")
+	if err != nil || got["owner"] != "other" || got["repo"] != "svc" || got["token"] != "other-token" {
+		t.Errorf("Profile(other) = %v, %v", got, err)
+	}
+	if cfg["repo"] != "api" {
+		t.Error("Profile modified the base config")
+	}
+
+	if _, err := Profile(cfg, "missing"); err == nil {
+		t.Error("Profile(missing) succeeded")
+	}
+}
+
+func TestAllowMethod(t *testing.T) {
+	cfg := map[string]any{
+		"allowedMethods": []any{"ticket.query", "ticket.get*", "*.capabilities"},
+		"profiles": map[string]any{
+			"writer": map[string]any{"allowedMethods": "ticket.*"},
+			"broken": map[string]any{"allowedMethods": "ticket.["},
+		},
+	}
+
+	tests := []struct {
+		profile, method string
+		wantCode        string
+	}{
+		{"", "ticket.query", ""},
+		{"", "ticket.getComments", ""},
+		{"", "deployment.capabilities", ""},
+		{"", "plugin.health", ""},
+		{"", "ticket.create", "forbidden"},
+		{"", "debug.httpDump", "forbidden"},
+		{"writer", "ticket.create", ""},
+		{"writer", "deployment.query", "forbidden"},
+		{"unknown", "ticket.create", "forbidden"},
+		{"broken", "ticket.query", "bad_request"},
+	}
+	for _, tt := range tests {
+		err := AllowMethod(cfg, tt.profile, tt.method)
+		var code string
+		var opsErr *orcherr.OpsOrchError
+		if errors.As(err, &opsErr) {
+			code = opsErr.Code
+		} else if err != nil {
+			code = err.Error()
+		}
+		if code != tt.wantCode {
+			t.Errorf("AllowMethod(%q, %q) = %v, want code %q", tt.profile, tt.method, err, tt.wantCode)
+		}
+	}
+
+	if err := AllowMethod(map[string]any{}, "", "ticket.create"); err != nil {
+		t.Errorf("AllowMethod() without allowedMethods = %v", err)
+	}
+	if err := AllowMethod(map[string]any{"allowedMethods": []any{}}, "", "ticket.query"); err == nil {
+		t.Error("AllowMethod() with an empty allowedMethods allowed a method")
+	}
+}
+
+func TestEnvFallbacks(t *testing.T) {
+	t.Setenv(EnvToken, "env-token")
+	t.Setenv(EnvRepository, "env-owner/env-repo")
+	t.Setenv(EnvRepositoryOwner, "")
+
+	if got := Token(map[string]any{}); got != "env-token" {
+		t.Errorf("Token() = %q, want env-token", got)
+	}
+	if got := Token(map[string]any{"token": "cfg"}); got != "cfg" {
+		t.Errorf("Token() = %q, want cfg", got)
+	}
+	owner, repo, err := Repository(map[string]any{})
+	if err != nil || owner != "env-owner" || repo != "env-repo" {
+		t.Errorf("Repository() = %q, %q, %v", owner, repo, err)
+	}
+	if got := Organization(map[string]any{}); got != "env-owner" {
+		t.Errorf("Organization() = %q, want env-owner", got)
+	}
+}
+
+func TestActionsEnvironment(t *testing.T) {
+	t.Setenv(EnvActions, "true")
+	t.Setenv(EnvAPIURL, "https://ghes.acme.example/api/v3")
+
+	if !JobToken("ghs_abc") || JobToken("ghp_abc") {
+		t.Error("JobToken() mismatch")
+	}
+	client := Client(map[string]any{}, "ghs_abc")
+	if got := client.BaseURL.String(); got != "https://ghes.acme.example/api/v3/" {
+		t.Errorf("BaseURL = %s", got)
+	}
+	if got := client.UploadURL.String(); got != "https://ghes.acme.example/api/uploads/" {
+		t.Errorf("UploadURL = %s", got)
+	}
+	if got := APIURL(map[string]any{"apiURL": "https://api.github.com/"}); got != "" {
+		t.Errorf("APIURL() for github.com = %q", got)
+	}
+	if _, err := Services(map[string]any{"token": "x", "apiURL": "ghes"}); err == nil {
+		t.Error("Services() accepted a relative apiURL")
+	}
+
+	t.Setenv(EnvActions, "")
+	t.Setenv(EnvAPIURL, DefaultAPIURL)
+	if JobToken("ghs_abc") {
+		t.Error("JobToken() outside Actions")
+	}
+	if got := Client(map[string]any{}, "x").BaseURL.String(); got != DefaultAPIURL+"/" {
+		t.Errorf("BaseURL = %s", got)
+	}
+}
+
+func TestAPIVersion(t *testing.T) {
+	if _, err := Services(map[string]any{"token": "x", "apiVersion": "latest"}); err == nil {
+		t.Error("Services() accepted a malformed apiVersion")
+	}
+
+	var version string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version = r.Header.Get(apiversion.Header)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	client := Client(map[string]any{"apiURL": srv.URL, "apiVersion": "2026-03-10"}, "x")
+	if _, _, err := client.Repositories.Get(context.Background(), "acme", "api"); err != nil {
+		t.Fatal(err)
+	}
+	if version != "2026-03-10" {
+		t.Errorf("%s = %q, want the configured version", apiversion.Header, version)
+	}
+}
+
+// FuzzParsers checks that the tolerant parsers accept any JSON-decoded value.
+func FuzzParsers(f *testing.F) {
+	f.Add(`{"v": "a, b,,c"}`)
+	f.Add(`{"v": ["a", 1, null, {"x": 2}]}`)
+	f.Add(`{"v": 1e300}`)
+	f.Add(`{"v": -9.5}`)
+	f.Add(`{"v": "90s"}`)
+	f.Add(`{"v": true, "repository": "o/r/x", "owner": 1}`)
+
+	f.Fuzz(func(t *testing.T, data string) {
+		var cfg map[string]any
+		if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+			return
+		}
+
+		String(cfg, "v")
+		Bool(cfg, "v")
+		Int(cfg, "v", 7)
+		Duration(cfg, "v", time.Second)
+		for _, s := range StringSlice(cfg, "v") {
+			if s == "" || s != strings.TrimSpace(s) {
+				t.Errorf("StringSlice() returned untrimmed or empty element %q", s)
+			}
+		}
+		Repository(cfg)
+	})
+}
+
+func TestHTTPDump(t *testing.T) {
+	if _, ok := HTTPDump(map[string]any{}); ok {
+		t.Error("HTTPDump() reported settings for an empty config")
+	}
+	if _, ok := HTTPDump(map[string]any{"debug": map[string]any{"other": true}}); ok {
+		t.Error("HTTPDump() reported settings for unrelated debug keys")
+	}
+
+	opts, ok := HTTPDump(map[string]any{"debug": map[string]any{
+		"httpDump":       "true",
+		"httpDumpBodies": true,
+		"httpDumpFile":   " /tmp/dump.log ",
+	}})
+	if !ok || !opts.Enabled || !opts.Bodies || opts.File != "/tmp/dump.log" {
+		t.Errorf("HTTPDump() = %+v, %v", opts, ok)
+	}
+}
+
+func TestIsWorkflowRunPath(t *testing.T) {
+	tests := map[string]bool{
+		"/repos/acme/api/actions/runs/1001":        true,
+		"/api/v3/repos/acme/api/actions/runs/1001": true,
+		"/repos/acme/api/actions/runs":             false,
+		"/repos/acme/api/actions/runs/1001/jobs":   false,
+		"/repos/acme/api/actions/runs/latest":      false,
+		"/repos/acme/api/issues/1":                 false,
+	}
+	for path, want := range tests {
+		if got := isWorkflowRunPath(path); got != want {
+			t.Errorf("isWorkflowRunPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	if _, ok := Throttle(map[string]any{"requestQueueDepth": 5}); ok {
+		t.Error("Throttle() without maxConcurrentRequests reported settings")
+	}
+	opts, ok := Throttle(map[string]any{"maxConcurrentRequests": float64(4)})
+	if !ok || opts.Concurrency != 4 || opts.Depth != defaultRequestQueueDepth {
+		t.Errorf("Throttle() = %+v, %v", opts, ok)
+	}
+	opts, _ = Throttle(map[string]any{"maxConcurrentRequests": 2, "requestQueueDepth": 10})
+	if opts.Concurrency != 2 || opts.Depth != 10 {
+		t.Errorf("Throttle() with a depth = %+v", opts)
+	}
+}
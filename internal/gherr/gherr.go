@@ -0,0 +1,229 @@
+// Package gherr turns GitHub API errors into OpsOrch errors for all the
+// providers.
+package gherr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/throttle"
+)
+
+// Wrap turns err from a GitHub API call into an OpsOrch error. resource names
+// what a 404 failed to find, such as "repository or issue". Expired contexts,
+// rate limits, and the adapter's own throttling are told apart from GitHub
+// failures first.
+func Wrap(err error, resource string) error {
+	if ctxErr := Context(err); ctxErr != nil {
+		return ctxErr
+	}
+
+	// Rate limits are answered with 403 too, but call for backing off rather than
+	// fixing permissions
+	if rateErr := RateLimit(err); rateErr != nil {
+		return rateErr
+	}
+	if queueErr := Throttled(err); queueErr != nil {
+		return queueErr
+	}
+
+	var ghErr *github.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil {
+		// Field errors and the request ID tell users what GitHub rejected and
+		// what to quote to GitHub support
+		details := Details(ghErr)
+		switch ghErr.Response.StatusCode {
+		case http.StatusUnauthorized:
+			return &orcherr.OpsOrchError{
+				Code:    "unauthorized",
+				Message: "GitHub API authentication failed" + details,
+			}
+		case http.StatusForbidden:
+			return &orcherr.OpsOrchError{
+				Code:    "forbidden",
+				Message: "GitHub API access forbidden - check token permissions" + details,
+			}
+		case http.StatusNotFound:
+			return &orcherr.OpsOrchError{
+				Code:    "not_found",
+				Message: "GitHub " + resource + " not found" + details,
+			}
+		case http.StatusUnprocessableEntity:
+			return &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: fmt.Sprintf("GitHub API validation error: %s%s", ghErr.Message, details),
+			}
+		default:
+			return &orcherr.OpsOrchError{
+				Code:    "provider_error",
+				Message: fmt.Sprintf("GitHub API error: %s%s", ghErr.Message, details),
+			}
+		}
+	}
+
+	return &orcherr.OpsOrchError{
+		Code:    "provider_error",
+		Message: fmt.Sprintf("GitHub API error: %v", err),
+	}
+}
+
+// Details describes what GitHub reported beyond the top-level message: the
+// field-level validation errors and the request ID to quote to GitHub support.
+// It returns "" when there is neither, and otherwise a string starting with a
+// space, for appending to an error message.
+func Details(ghErr *github.ErrorResponse) string {
+	var parts []string
+
+	var fields []string
+	for _, e := range ghErr.Errors {
+		fields = append(fields, fieldError(e))
+	}
+	if len(fields) > 0 {
+		parts = append(parts, strings.Join(fields, "; "))
+	}
+
+	// Installation tokens, the Actions job token among them, are told which
+	// permissions the endpoint accepts
+	if ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusForbidden {
+		if accepted := ghErr.Response.Header.Get("X-Accepted-GitHub-Permissions"); accepted != "" {
+			parts = append(parts, "accepted permissions: "+accepted)
+		}
+		if ghErr.Message == "Resource not accessible by integration" && ghconfig.InActions() {
+			parts = append(parts, "grant the job's GITHUB_TOKEN the permission under the workflow's permissions key")
+		}
+	}
+
+	if requestID := RequestID(ghErr); requestID != "" {
+		parts = append(parts, "request ID "+requestID)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, "; ") + ")"
+}
+
+// RequestID returns the X-GitHub-Request-Id of the response that failed.
+func RequestID(ghErr *github.ErrorResponse) string {
+	if ghErr.Response == nil || ghErr.Response.Header == nil {
+		return ""
+	}
+	return ghErr.Response.Header.Get("X-GitHub-Request-Id")
+}
+
+// fieldError describes one validation error, such as "Issue.title: missing_field"
+// or, for custom errors, the message GitHub gave.
+func fieldError(e github.Error) string {
+	target := e.Field
+	if e.Resource != "" && e.Field != "" {
+		target = e.Resource + "." + e.Field
+	}
+
+	detail := e.Code
+	if e.Message != "" {
+		detail = e.Message
+	}
+
+	switch {
+	case target == "":
+		return detail
+	case detail == "":
+		return target
+	default:
+		return fmt.Sprintf("%s: %s", target, detail)
+	}
+}
+
+// RateLimit returns a rate_limited error when err reports an exhausted primary
+// or secondary rate limit, and nil otherwise. GitHub answers both with 403 or
+// 429, so without this they would read as permission problems. The message says
+// when to retry; the original error is kept as Err.
+func RateLimit(err error) *orcherr.OpsOrchError {
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return rateLimited(fmt.Sprintf("GitHub API rate limit exceeded, resets at %s", rateErr.Rate.Reset.UTC().Format(time.RFC3339)), err)
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return rateLimited(fmt.Sprintf("GitHub API secondary rate limit exceeded, retry after %s", *abuseErr.RetryAfter), err)
+		}
+		return rateLimited("GitHub API secondary rate limit exceeded", err)
+	}
+
+	var ghErr *github.ErrorResponse
+	if !errors.As(err, &ghErr) || ghErr.Response == nil {
+		return nil
+	}
+	status, header := ghErr.Response.StatusCode, ghErr.Response.Header
+	if status != http.StatusForbidden && status != http.StatusTooManyRequests {
+		return nil
+	}
+	if header.Get("X-RateLimit-Remaining") == "0" {
+		message := "GitHub API rate limit exceeded"
+		if reset, parseErr := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); parseErr == nil {
+			message += ", resets at " + time.Unix(reset, 0).UTC().Format(time.RFC3339)
+		}
+		return rateLimited(message, err)
+	}
+	if status == http.StatusTooManyRequests {
+		if retryAfter := header.Get("Retry-After"); retryAfter != "" {
+			return rateLimited("GitHub API rate limit exceeded, retry after "+retryAfter+"s", err)
+		}
+		return rateLimited("GitHub API rate limit exceeded", err)
+	}
+	return nil
+}
+
+func rateLimited(message string, err error) *orcherr.OpsOrchError {
+	return &orcherr.OpsOrchError{
+		Code:    "rate_limited",
+		Message: message,
+		Err:     err,
+	}
+}
+
+// Throttled returns a throttled error when err comes from the adapter's own
+// request queue turning a call away, and nil otherwise. The message suggests
+// when to retry; the original error is kept as Err.
+func Throttled(err error) *orcherr.OpsOrchError {
+	var queueErr *throttle.Error
+	if !errors.As(err, &queueErr) {
+		return nil
+	}
+	return &orcherr.OpsOrchError{
+		Code:    "throttled",
+		Message: queueErr.Error(),
+		Err:     err,
+	}
+}
+
+// Context returns a timeout error when err comes from an expired context
+// deadline and a canceled error when it comes from a canceled context, so retry
+// policies can tell them from GitHub failures. It returns nil otherwise.
+func Context(err error) *orcherr.OpsOrchError {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return &orcherr.OpsOrchError{
+			Code:    "timeout",
+			Message: "GitHub API request timed out",
+			Err:     err,
+		}
+	case errors.Is(err, context.Canceled):
+		return &orcherr.OpsOrchError{
+			Code:    "canceled",
+			Message: "GitHub API request canceled",
+			Err:     err,
+		}
+	}
+	return nil
+}
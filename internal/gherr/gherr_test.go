@@ -0,0 +1,170 @@
+package gherr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/throttle"
+)
+
+func TestDetails(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-GitHub-Request-Id", "C0DE:1234:ABCD")
+
+	tests := []struct {
+		name string
+		err  *github.ErrorResponse
+		want string
+	}{
+		{"nothing", &github.ErrorResponse{Response: &http.Response{}}, ""},
+		{"no response", &github.ErrorResponse{}, ""},
+		{"request ID", &github.ErrorResponse{Response: &http.Response{Header: header}}, " (request ID C0DE:1234:ABCD)"},
+		{
+			"field errors",
+			&github.ErrorResponse{
+				Response: &http.Response{Header: header},
+				Errors: []github.Error{
+					{Resource: "Issue", Field: "title", Code: "missing_field"},
+					{Resource: "Issue", Code: "custom", Message: "assignees must be collaborators"},
+					{Field: "labels", Code: "invalid"},
+				},
+			},
+			" (Issue.title: missing_field; assignees must be collaborators; labels: invalid; request ID C0DE:1234:ABCD)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Details(tt.err); got != tt.want {
+				t.Errorf("Details() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetailsPermissions(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-Accepted-GitHub-Permissions", "issues=write")
+	ghErr := &github.ErrorResponse{
+		Response: &http.Response{StatusCode: http.StatusForbidden, Header: header},
+		Message:  "Resource not accessible by integration",
+	}
+
+	t.Setenv("GITHUB_ACTIONS", "")
+	if got, want := Details(ghErr), " (accepted permissions: issues=write)"; got != want {
+		t.Errorf("Details() = %q, want %q", got, want)
+	}
+	t.Setenv("GITHUB_ACTIONS", "true")
+	if got := Details(ghErr); !strings.Contains(got, "grant the job's GITHUB_TOKEN") {
+		t.Errorf("Details() in Actions = %q", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	reset := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	retryAfter := 30 * time.Second
+	response := func(status int, headers map[string]string) *http.Response {
+		header := http.Header{}
+		for key, value := range headers {
+			header.Set(key, value)
+		}
+		return &http.Response{StatusCode: status, Header: header}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"primary", &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: reset}}}, "GitHub API rate limit exceeded, resets at 2026-10-15T12:00:00Z"},
+		{"secondary", &github.AbuseRateLimitError{RetryAfter: &retryAfter}, "GitHub API secondary rate limit exceeded, retry after 30s"},
+		{"secondary without retry", &github.AbuseRateLimitError{}, "GitHub API secondary rate limit exceeded"},
+		{
+			"exhausted header",
+			&github.ErrorResponse{Response: response(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(reset.Unix(), 10)})},
+			"GitHub API rate limit exceeded, resets at 2026-10-15T12:00:00Z",
+		},
+		{"429", &github.ErrorResponse{Response: response(http.StatusTooManyRequests, map[string]string{"Retry-After": "60"})}, "GitHub API rate limit exceeded, retry after 60s"},
+		{"permission 403", &github.ErrorResponse{Response: response(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "4999"})}, ""},
+		{"not found", &github.ErrorResponse{Response: response(http.StatusNotFound, nil)}, ""},
+		{"other error", errors.New("connection reset"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RateLimit(tt.err)
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("RateLimit() = %v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.Code != "rate_limited" || got.Message != tt.want {
+				t.Errorf("RateLimit() = %+v, want rate_limited %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{context.DeadlineExceeded, "timeout"},
+		{&url.Error{Op: "Get", URL: "https://api.github.com/repos/acme/api", Err: context.DeadlineExceeded}, "timeout"},
+		{context.Canceled, "canceled"},
+		{fmt.Errorf("list issues: %w", context.Canceled), "canceled"},
+		{errors.New("connection reset"), ""},
+	}
+	for _, tt := range tests {
+		got := Context(tt.err)
+		if (got == nil && tt.want != "") || (got != nil && got.Code != tt.want) {
+			t.Errorf("Context(%v) = %v, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestThrottled(t *testing.T) {
+	err := fmt.Errorf("get: %w", &throttle.Error{RetryAfter: 3 * time.Second})
+	got := Throttled(err)
+	if got == nil || got.Code != "throttled" || got.Message != "GitHub request queue is full, retry after 3s" {
+		t.Errorf("Throttled() = %+v", got)
+	}
+	if Throttled(errors.New("other")) != nil {
+		t.Error("Throttled() of another error is not nil")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	response := func(status int) *github.ErrorResponse {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: status, Header: http.Header{}}, Message: "Validation Failed"}
+	}
+	tests := []struct {
+		err         error
+		wantCode    string
+		wantMessage string
+	}{
+		{response(http.StatusUnauthorized), "unauthorized", "GitHub API authentication failed"},
+		{response(http.StatusForbidden), "forbidden", "GitHub API access forbidden - check token permissions"},
+		{response(http.StatusNotFound), "not_found", "GitHub repository or issue not found"},
+		{fmt.Errorf("get issue: %w", response(http.StatusNotFound)), "not_found", "GitHub repository or issue not found"},
+		{response(http.StatusUnprocessableEntity), "bad_request", "GitHub API validation error: Validation Failed"},
+		{response(http.StatusBadGateway), "provider_error", "GitHub API error: Validation Failed"},
+		{context.DeadlineExceeded, "timeout", "GitHub API request timed out"},
+		{errors.New("connection reset"), "provider_error", "GitHub API error: connection reset"},
+	}
+	for _, tt := range tests {
+		var got *orcherr.OpsOrchError
+		if !errors.As(Wrap(tt.err, "repository or issue"), &got) || got.Code != tt.wantCode || got.Message != tt.wantMessage {
+			t.Errorf("Wrap(%v) = %+v, want %s %q", tt.err, got, tt.wantCode, tt.wantMessage)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package ghraw implements the guarded raw GET passthrough to the GitHub REST API.
+package ghraw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+// Allowlist holds path patterns that raw requests may target. Patterns use path.Match
+// syntax and may reference {owner}, {repo}, and {org}, which are expanded from Vars.
+type Allowlist struct {
+	Patterns []string
+	Vars     map[string]string
+}
+
+// Allows reports whether the cleaned request path matches one of the patterns.
+func (a Allowlist) Allows(p string) bool {
+	for _, pattern := range a.Patterns {
+		pattern = "/" + strings.TrimPrefix(a.expand(pattern), "/")
+		if ok, err := path.Match(pattern, p); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Allowlist) expand(pattern string) string {
+	for k, v := range a.Vars {
+		pattern = strings.ReplaceAll(pattern, "{"+k+"}", v)
+	}
+	return pattern
+}
+
+// Raw is Get for the providers' Raw methods: errors from the API call are mapped
+// with wrap, typically the provider's wrapError, while refusals by the
+// allowlist are returned as they are.
+func Raw(ctx context.Context, client ghapi.Requester, allow Allowlist, rawPath string, wrap func(error) error) (json.RawMessage, error) {
+	body, err := Get(ctx, client, allow, rawPath)
+	if err != nil {
+		var opsErr *orcherr.OpsOrchError
+		if errors.As(err, &opsErr) {
+			return nil, err
+		}
+		return nil, wrap(err)
+	}
+	return body, nil
+}
+
+// Get performs a GET against rawPath (which may carry a query string) if the allowlist
+// permits it, returning the undecoded JSON body. API errors are returned unwrapped so
+// providers can map them with their own wrapError.
+func Get(ctx context.Context, client ghapi.Requester, allow Allowlist, rawPath string) (json.RawMessage, error) {
+	if len(allow.Patterns) == 0 {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "forbidden",
+			Message: "raw GitHub API access is disabled; configure rawAPIAllowlist to enable it",
+		}
+	}
+	if client == nil {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "forbidden",
+			Message: "raw GitHub API access is not available for this provider",
+		}
+	}
+
+	u, err := url.Parse(rawPath)
+	if err != nil || u.IsAbs() || u.Host != "" {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("invalid raw API path: %s", rawPath),
+		}
+	}
+	if strings.Contains(u.Path, "..") {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("raw API path must not contain '..': %s", rawPath),
+		}
+	}
+
+	cleaned := path.Clean("/" + u.Path)
+	if !allow.Allows(cleaned) {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "forbidden",
+			Message: fmt.Sprintf("raw API path not in allowlist: %s", cleaned),
+		}
+	}
+
+	target := strings.TrimPrefix(cleaned, "/")
+	if u.RawQuery != "" {
+		target += "?" + u.RawQuery
+	}
+
+	req, err := client.NewRequest("GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var body json.RawMessage
+	if _, err := client.Do(ctx, req, &body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,86 @@
+package ghraw
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+func TestAllowlist(t *testing.T) {
+	allow := Allowlist{
+		Patterns: []string{"/repos/{owner}/{repo}/releases", "repos/{owner}/{repo}/releases/*"},
+		Vars:     map[string]string{"owner": "acme", "repo": "api"},
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/repos/acme/api/releases", true},
+		{"/repos/acme/api/releases/123", true},
+		{"/repos/acme/api/releases/123/assets", false},
+		{"/repos/other/api/releases", false},
+		{"/user", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := allow.Allows(tt.path); got != tt.want {
+				t.Errorf("Allows(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRejectsBeforeCallingAPI(t *testing.T) {
+	allow := Allowlist{Patterns: []string{"/repos/*/*/releases"}}
+
+	tests := []struct {
+		name  string
+		allow Allowlist
+		path  string
+	}{
+		{"disabled", Allowlist{}, "/repos/a/b/releases"},
+		{"absolute url", allow, "https://evil.example.com/repos/a/b/releases"},
+		{"traversal", allow, "/repos/a/b/releases/../../../user"},
+		{"not allowed", allow, "/user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A nil client proves the request is rejected before any API call.
+			if _, err := Get(context.Background(), nil, tt.allow, tt.path); err == nil {
+				t.Errorf("Get(%q) expected error", tt.path)
+			}
+		})
+	}
+}
+
+func TestRaw(t *testing.T) {
+	srv := fakegithub.New(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api/releases", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"tag_name": "v1.0.0"}})
+	})
+	srv.Error(http.MethodGet, "/repos/acme/api/releases/1", http.StatusBadGateway, "Server Error")
+	allow := Allowlist{Patterns: []string{"/repos/acme/api/releases", "/repos/acme/api/releases/*"}}
+	wrapped := errors.New("wrapped")
+	wrap := func(error) error { return wrapped }
+
+	body, err := Raw(context.Background(), srv.Client(), allow, "/repos/acme/api/releases", wrap)
+	if err != nil || !strings.Contains(string(body), "v1.0.0") {
+		t.Errorf("Raw() = %s, %v", body, err)
+	}
+	if _, err := Raw(context.Background(), srv.Client(), allow, "/repos/acme/api/releases/1", wrap); err != wrapped {
+		t.Errorf("Raw() of a failing call error = %v, want it wrapped", err)
+	}
+	// Allowlist refusals are already OpsOrch errors
+	var opsErr *orcherr.OpsOrchError
+	if _, err := Raw(context.Background(), srv.Client(), allow, "/user", wrap); !errors.As(err, &opsErr) || opsErr.Code != "forbidden" {
+		t.Errorf("Raw() outside the allowlist error = %v, want forbidden", err)
+	}
+}
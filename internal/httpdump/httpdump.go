@@ -0,0 +1,182 @@
+// Package httpdump logs sanitized summaries of GitHub API traffic for diagnosing
+// mapping bugs. Every client built by the providers routes through Default, so
+// dumping can be switched on at runtime without rebuilding clients.
+package httpdump
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opsorch/opsorch-github-adapter/internal/redact"
+)
+
+// Default is the process-wide dumper wrapped around every provider client.
+var Default = New(os.Stderr)
+
+// redacted replaces secrets and sensitive query parameters.
+const redacted = "REDACTED"
+
+// sensitiveParams are query parameters whose values are never logged.
+var sensitiveParams = []string{"access_token", "token", "client_secret", "code"}
+
+// Options controls what is dumped and where.
+type Options struct {
+	Enabled bool   `json:"enabled"`
+	Bodies  bool   `json:"bodies"`         // Include request and response bodies
+	File    string `json:"file,omitempty"` // Append to this file instead of stderr
+}
+
+// Dumper writes request/response summaries when enabled.
+type Dumper struct {
+	mu       sync.Mutex
+	opts     Options
+	fallback io.Writer
+	file     *os.File
+	logger   *log.Logger
+}
+
+// New creates a disabled dumper that writes to w until a file is configured.
+func New(w io.Writer) *Dumper {
+	return &Dumper{fallback: w, logger: log.New(w, "", log.LstdFlags)}
+}
+
+// Configure applies opts, opening File for appending when it changes. An empty
+// File restores the default writer.
+func (d *Dumper) Configure(opts Options) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if opts.File != d.opts.File {
+		out := d.fallback
+		var file *os.File
+		if opts.File != "" {
+			f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+			if err != nil {
+				return fmt.Errorf("opening http dump file: %w", err)
+			}
+			file, out = f, f
+		}
+		if d.file != nil {
+			d.file.Close()
+		}
+		d.file = file
+		d.logger = log.New(out, "", log.LstdFlags)
+	}
+
+	d.opts = opts
+	return nil
+}
+
+// Options returns the current settings.
+func (d *Dumper) Options() Options {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.opts
+}
+
+// Wrap returns a transport that dumps traffic through base while the dumper is
+// enabled. Occurrences of secrets are redacted from dumped URLs and bodies.
+func (d *Dumper) Wrap(base http.RoundTripper, secrets ...string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &transport{dumper: d, base: base}
+	for _, s := range secrets {
+		if s != "" {
+			t.secrets = append(t.secrets, s)
+		}
+	}
+	return t
+}
+
+type transport struct {
+	dumper  *Dumper
+	base    http.RoundTripper
+	secrets []string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	opts := t.dumper.Options()
+	if !opts.Enabled {
+		return t.base.RoundTrip(req)
+	}
+
+	var reqBody []byte
+	if opts.Bodies && req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	latency := time.Since(start).Round(time.Millisecond)
+
+	target := t.redact(sanitizeURL(req.URL))
+	if err != nil {
+		t.dumper.printf("[http] %s %s -> error: %v (%s)", req.Method, target, err, latency)
+		return nil, err
+	}
+
+	line := fmt.Sprintf("[http] %s %s -> %d (%s)", req.Method, target, resp.StatusCode, latency)
+	if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "" {
+		line += fmt.Sprintf(" rate=%s/%s reset=%s", remaining, resp.Header.Get("X-RateLimit-Limit"), resp.Header.Get("X-RateLimit-Reset"))
+	}
+	if retry := resp.Header.Get("Retry-After"); retry != "" {
+		line += " retry-after=" + retry
+	}
+
+	if opts.Bodies {
+		respBody, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+		if readErr != nil {
+			return nil, readErr
+		}
+		if len(reqBody) > 0 {
+			line += "\n  request: " + t.redact(string(reqBody))
+		}
+		line += "\n  response: " + t.redact(string(respBody))
+	}
+
+	t.dumper.printf("%s", line)
+	return resp, nil
+}
+
+func (t *transport) redact(s string) string {
+	for _, secret := range t.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+func (d *Dumper) printf(format string, args ...any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.logger.Print(redact.Default.String(fmt.Sprintf(format, args...)))
+}
+
+// sanitizeURL returns the request path and query with sensitive parameters masked.
+func sanitizeURL(u *url.URL) string {
+	q := u.Query()
+	for _, name := range sensitiveParams {
+		if q.Has(name) {
+			q.Set(name, redacted)
+		}
+	}
+	if len(q) == 0 {
+		return u.Path
+	}
+	return u.Path + "?" + q.Encode()
+}
@@ -0,0 +1,136 @@
+package httpdump
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(`{"echo":"` + string(body) + `","token":"secret-token"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDisabledWritesNothing(t *testing.T) {
+	srv := newServer(t)
+	var buf bytes.Buffer
+	d := New(&buf)
+
+	client := &http.Client{Transport: d.Wrap(nil)}
+	resp, err := client.Get(srv.URL + "/repos/acme/api/issues")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if buf.Len() != 0 {
+		t.Errorf("disabled dumper wrote %q", buf.String())
+	}
+}
+
+func TestSummary(t *testing.T) {
+	srv := newServer(t)
+	var buf bytes.Buffer
+	d := New(&buf)
+	if err := d.Configure(Options{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: d.Wrap(nil, "secret-token")}
+	resp, err := client.Get(srv.URL + "/repos/acme/api/issues?state=open&access_token=secret-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	out := buf.String()
+	for _, want := range []string{"[http] GET /repos/acme/api/issues?", "state=open", "-> 200", "rate=4999/5000 reset=1700000000"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dump %q missing %q", out, want)
+		}
+	}
+	if strings.Contains(out, "secret-token") {
+		t.Errorf("dump leaked the token: %q", out)
+	}
+	if strings.Contains(out, "response:") {
+		t.Errorf("bodies dumped without being enabled: %q", out)
+	}
+	if !strings.Contains(string(body), "secret-token") {
+		t.Errorf("response body was altered: %s", body)
+	}
+}
+
+func TestBodies(t *testing.T) {
+	srv := newServer(t)
+	var buf bytes.Buffer
+	d := New(&buf)
+	if err := d.Configure(Options{Enabled: true, Bodies: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: d.Wrap(nil, "secret-token")}
+	resp, err := client.Post(srv.URL+"/repos/acme/api/issues", "application/json", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	out := buf.String()
+	if !strings.Contains(out, "request: hello") || !strings.Contains(out, `response: {"echo":"hello","token":"REDACTED"}`) {
+		t.Errorf("unexpected dump: %q", out)
+	}
+	if string(body) != `{"echo":"hello","token":"secret-token"}` {
+		t.Errorf("caller saw body %s", body)
+	}
+}
+
+func TestFile(t *testing.T) {
+	srv := newServer(t)
+	var buf bytes.Buffer
+	d := New(&buf)
+	path := filepath.Join(t.TempDir(), "dump.log")
+	if err := d.Configure(Options{Enabled: true, File: path}); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: d.Wrap(nil)}
+	resp, err := client.Get(srv.URL + "/orgs/acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// Switching back to the default writer closes the file
+	if err := d.Configure(Options{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "GET /orgs/acme -> 200") {
+		t.Errorf("file dump = %q", data)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("default writer received %q", buf.String())
+	}
+
+	if err := d.Configure(Options{Enabled: true, File: filepath.Join(path, "missing", "dump.log")}); err == nil {
+		t.Error("expected an error for an unwritable file")
+	}
+}
@@ -0,0 +1,168 @@
+// Package identity maps GitHub logins to the canonical identity of the person
+// behind them — email, employee ID, and chat handle — so OpsOrch can correlate
+// the same human across providers.
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// DefaultRefresh is how long a remote mapping is used before it is fetched again.
+const DefaultRefresh = time.Hour
+
+// fetchTimeout bounds a remote mapping fetch, which happens during conversion.
+const fetchTimeout = 10 * time.Second
+
+// Identity is the canonical identity of a GitHub user.
+type Identity struct {
+	Name       string `json:"name,omitempty"`
+	Email      string `json:"email,omitempty"`
+	EmployeeID string `json:"employeeId,omitempty"`
+	ChatHandle string `json:"chatHandle,omitempty"`
+}
+
+// Fields returns the identity's non-empty attributes under snake_case keys,
+// for merging into maps such as a deployment's actor.
+func (id Identity) Fields() map[string]any {
+	fields := map[string]any{}
+	for key, value := range map[string]string{
+		"name":        id.Name,
+		"email":       id.Email,
+		"employee_id": id.EmployeeID,
+		"chat_handle": id.ChatHandle,
+	} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// Map looks up identities by login. Entries come from the "identities" config
+// object and from the JSON object served at "identitiesURL", which is fetched
+// on first use and again every "identitiesRefresh"; config entries win. A nil
+// Map has no entries.
+type Map struct {
+	static  map[string]Identity
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	mu      sync.Mutex
+	remote  map[string]Identity
+	fetched time.Time
+}
+
+// Parse reads the identity mapping config. It returns nil when neither
+// "identities" nor "identitiesURL" is set.
+func Parse(cfg map[string]any) (*Map, error) {
+	m := &Map{
+		url:     ghconfig.String(cfg, "identitiesURL"),
+		refresh: ghconfig.Duration(cfg, "identitiesRefresh", DefaultRefresh),
+		client:  &http.Client{Timeout: fetchTimeout},
+	}
+
+	if raw, ok := cfg["identities"]; ok && raw != nil {
+		entries, err := decode(raw)
+		if err != nil {
+			return nil, fmt.Errorf("identities must be an object mapping logins to name, email, employeeId, and chatHandle: %w", err)
+		}
+		m.static = entries
+	}
+	if m.static == nil && m.url == "" {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// Lookup returns the identity mapped to login; logins are case-insensitive.
+func (m *Map) Lookup(login string) (Identity, bool) {
+	if m == nil || login == "" {
+		return Identity{}, false
+	}
+	key := strings.ToLower(login)
+	if id, ok := m.static[key]; ok {
+		return id, true
+	}
+	id, ok := m.remoteEntries()[key]
+	return id, ok
+}
+
+// remoteEntries returns the remote mapping, fetching it when it is missing or
+// stale. A failed fetch is logged and the last good mapping is kept.
+func (m *Map) remoteEntries() map[string]Identity {
+	if m.url == "" {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.fetched.IsZero() && time.Since(m.fetched) < m.refresh {
+		return m.remote
+	}
+
+	entries, err := m.fetch()
+	// Wait out the refresh interval before retrying, rather than fetching on every lookup
+	m.fetched = time.Now()
+	if err != nil {
+		log.Printf("[identity] %s: %v", m.url, err)
+		return m.remote
+	}
+	m.remote = entries
+	return m.remote
+}
+
+// fetch reads the remote mapping.
+func (m *Map) fetch() (map[string]Identity, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var raw map[string]Identity
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	return lowerKeys(raw), nil
+}
+
+// decode converts a config value to entries keyed by lowercase login.
+func decode(raw any) (map[string]Identity, error) {
+	// Round-trip through JSON so decoded config and typed entries passed
+	// in-process are handled the same way
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[string]Identity
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return lowerKeys(entries), nil
+}
+
+func lowerKeys(entries map[string]Identity) map[string]Identity {
+	lowered := make(map[string]Identity, len(entries))
+	for login, id := range entries {
+		lowered[strings.ToLower(login)] = id
+	}
+	return lowered
+}
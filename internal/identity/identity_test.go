@@ -0,0 +1,58 @@
+package identity
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		w.Write([]byte(`{"Alice": {"email": "alice@corp.example", "employeeId": "E100"}, "bob": {"chatHandle": "@bob"}}`))
+	}))
+	defer srv.Close()
+
+	m, err := Parse(map[string]any{
+		"identities":    map[string]any{"bob": map[string]any{"email": "bob@corp.example"}},
+		"identitiesURL": srv.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id, ok := m.Lookup("alice"); !ok || id.EmployeeID != "E100" {
+		t.Errorf("Lookup(alice) = %+v, %v", id, ok)
+	}
+	// Config entries win over the remote mapping
+	if id, _ := m.Lookup("BOB"); id != (Identity{Email: "bob@corp.example"}) {
+		t.Errorf("Lookup(BOB) = %+v", id)
+	}
+	if _, ok := m.Lookup("carol"); ok {
+		t.Error("Lookup(carol) found an unmapped login")
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("remote mapping fetched %d times, want 1", n)
+	}
+
+	m.refresh = time.Nanosecond
+	m.Lookup("alice")
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("stale remote mapping fetched %d times, want 2", n)
+	}
+}
+
+func TestParse(t *testing.T) {
+	if m, err := Parse(map[string]any{}); m != nil || err != nil {
+		t.Errorf("Parse() without mapping = %v, %v", m, err)
+	}
+	if _, ok := (*Map)(nil).Lookup("alice"); ok {
+		t.Error("nil Map found an identity")
+	}
+	if _, err := Parse(map[string]any{"identities": []any{"alice"}}); err == nil {
+		t.Error("Parse() accepted a non-object identities")
+	}
+}
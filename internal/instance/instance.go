@@ -0,0 +1,67 @@
+// Package instance stamps the labels of an adapter instance, configured as
+// "instanceLabels", into the records it returns, so OpsOrch installations
+// running several instances of a plugin can tell which one produced a record.
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Field is the Fields and Metadata key holding the labels.
+const Field = "instance_labels"
+
+// Labels are an instance's labels, such as its region or tenant.
+type Labels map[string]string
+
+// Parse returns the labels under "instanceLabels", an object of strings. It is
+// nil when the key is unset.
+func Parse(cfg map[string]any) (Labels, error) {
+	raw, ok := cfg["instanceLabels"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	// Round-trip through JSON so decoded config and typed maps passed in-process
+	// are handled the same way
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("instanceLabels: %w", err)
+	}
+	var labels Labels
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("instanceLabels must map label names to strings: %w", err)
+	}
+	for name := range labels {
+		if name == "" {
+			return nil, fmt.Errorf("instanceLabels has an empty label name")
+		}
+	}
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	return labels, nil
+}
+
+// Stamp sets the labels under Field in a record's Fields or Metadata. Each
+// record gets its own copy, so callers may edit one without touching others.
+func (l Labels) Stamp(m map[string]any) {
+	if len(l) == 0 || m == nil {
+		return
+	}
+	labels := make(map[string]string, len(l))
+	for name, value := range l {
+		labels[name] = value
+	}
+	m[Field] = labels
+}
+
+// Tag sets each label as a tag, replacing any tag of the same name.
+func (l Labels) Tag(tags map[string]string) {
+	if tags == nil {
+		return
+	}
+	for name, value := range l {
+		tags[name] = value
+	}
+}
@@ -0,0 +1,50 @@
+package instance
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	labels, err := Parse(map[string]any{"instanceLabels": map[string]any{"region": "eu-west-1", "tenant": "acme"}})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(labels, Labels{"region": "eu-west-1", "tenant": "acme"}) {
+		t.Errorf("Parse() = %v", labels)
+	}
+
+	for _, cfg := range []map[string]any{{}, {"instanceLabels": map[string]string{}}} {
+		if labels, err := Parse(cfg); labels != nil || err != nil {
+			t.Errorf("Parse(%v) = %v, %v, want nil", cfg, labels, err)
+		}
+	}
+	for _, raw := range []any{"eu", map[string]any{"region": 1}, map[string]any{"": "eu"}} {
+		if _, err := Parse(map[string]any{"instanceLabels": raw}); err == nil {
+			t.Errorf("Parse(%v) error = nil", raw)
+		}
+	}
+}
+
+func TestStamp(t *testing.T) {
+	labels := Labels{"region": "eu"}
+	a, b := map[string]any{}, map[string]any{}
+	labels.Stamp(a)
+	labels.Stamp(b)
+	a[Field].(map[string]string)["region"] = "us"
+	if !reflect.DeepEqual(b[Field], map[string]string{"region": "eu"}) {
+		t.Errorf("records share labels: %v", b)
+	}
+
+	tags := map[string]string{"provider": "github", "region": "us"}
+	labels.Tag(tags)
+	if !reflect.DeepEqual(tags, map[string]string{"provider": "github", "region": "eu"}) {
+		t.Errorf("Tag() = %v", tags)
+	}
+
+	empty := map[string]any{}
+	Labels(nil).Stamp(empty)
+	if len(empty) != 0 {
+		t.Errorf("nil labels stamped %v", empty)
+	}
+}
@@ -0,0 +1,272 @@
+// Package lastgood keeps the latest result of each provider read on disk, so
+// that during a GitHub outage Get and Query can answer with last-known-good
+// data instead of failing. Served results are flagged with metadata "stale" and
+// "stale_at", the time they were read from GitHub.
+package lastgood
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// Defaults bounding a store, so the directory does not keep growing with every
+// distinct query.
+const (
+	DefaultTTL      = 7 * 24 * time.Hour
+	DefaultMaxFiles = 1000
+)
+
+// Store is a directory of last-known-good results. A nil Store saves nothing
+// and finds nothing, so providers without "staleCacheDir" need no checks.
+type Store struct {
+	dir      string
+	scope    string
+	ttl      time.Duration // Results older than this are neither served nor kept (0 keeps them)
+	maxFiles int           // Most results kept in dir, across all scopes (0 is unbounded)
+	now      func() time.Time
+}
+
+// Parse opens the store in the "staleCacheDir" config directory, creating it
+// if needed, or returns nil when the key is unset. "staleCacheTTL" and
+// "staleCacheMaxFiles" bound what it keeps; results past them are pruned now
+// and as new ones are saved. scope separates the results of providers sharing
+// the directory, e.g. "ticket/acme/api".
+func Parse(cfg map[string]any, scope string) (*Store, error) {
+	dir := ghconfig.String(cfg, "staleCacheDir")
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("staleCacheDir: %v", err),
+		}
+	}
+	s := &Store{
+		dir:      dir,
+		scope:    scope,
+		ttl:      ghconfig.Duration(cfg, "staleCacheTTL", DefaultTTL),
+		maxFiles: ghconfig.Int(cfg, "staleCacheMaxFiles", DefaultMaxFiles),
+		now:      time.Now,
+	}
+	s.prune()
+	return s, nil
+}
+
+type record struct {
+	SavedAt time.Time       `json:"savedAt"`
+	Value   json.RawMessage `json:"value"`
+}
+
+func (s *Store) path(key string) string {
+	sum := sha256.Sum256([]byte(s.scope + "\x00" + key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Save records value as the latest result for key. A result equal to the saved
+// one is not written again; its file's modification time is moved to now
+// instead, which Load reports as the save time. Failures are logged, since a
+// read that succeeded should not fail for want of a disk copy.
+func (s *Store) Save(key string, value any) {
+	if s == nil {
+		return
+	}
+	path := s.path(key)
+	now := s.now().UTC()
+	data, err := json.Marshal(value)
+	if err == nil {
+		var existing []byte
+		existing, err = os.ReadFile(path)
+		var rec record
+		switch {
+		case err == nil && json.Unmarshal(existing, &rec) == nil && bytes.Equal(rec.Value, data):
+			err = os.Chtimes(path, now, now)
+		case err == nil || errors.Is(err, fs.ErrNotExist):
+			created := err != nil
+			data, err = json.Marshal(record{SavedAt: now, Value: data})
+			if err == nil {
+				err = writeFile(path, data, now)
+			}
+			if err == nil && created {
+				s.prune()
+			}
+		}
+	}
+	if err != nil {
+		log.Printf("[lastgood] saving %s %s: %v", s.scope, key, err)
+	}
+}
+
+// writeFile replaces path with data through a temporary file, so a reader never
+// sees half a record, and dates it savedAt.
+func writeFile(path string, data []byte, savedAt time.Time) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".lastgood-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tmp.Name(), savedAt, savedAt); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load decodes the latest result for key into value, returning when it was
+// saved. A result older than the TTL is removed rather than served.
+func (s *Store) Load(key string, value any) (time.Time, bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+	path := s.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil || json.Unmarshal(rec.Value, value) != nil {
+		log.Printf("[lastgood] discarding unreadable %s %s", s.scope, key)
+		return time.Time{}, false
+	}
+	savedAt := rec.SavedAt
+	if info.ModTime().After(savedAt) {
+		savedAt = info.ModTime().UTC()
+	}
+	if s.expired(savedAt) {
+		os.Remove(path)
+		return time.Time{}, false
+	}
+	return savedAt, true
+}
+
+func (s *Store) expired(savedAt time.Time) bool {
+	return s.ttl > 0 && s.now().Sub(savedAt) > s.ttl
+}
+
+// prune removes the results in the directory older than the TTL and then the
+// oldest ones beyond maxFiles, whichever provider saved them. Failures are
+// logged; the next save tries again.
+func (s *Store) prune() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		log.Printf("[lastgood] pruning %s: %v", s.dir, err)
+		return
+	}
+	type file struct {
+		path    string
+		savedAt time.Time
+	}
+	var kept []file
+	for _, entry := range entries {
+		name := entry.Name()
+		// Temporary files are left behind only by a process that died mid-write
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") && !strings.HasPrefix(name, ".lastgood-") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		f := file{path: filepath.Join(s.dir, name), savedAt: info.ModTime()}
+		if s.expired(f.savedAt) || strings.HasPrefix(name, ".lastgood-") && s.now().Sub(f.savedAt) > time.Hour {
+			s.remove(f.path)
+			continue
+		}
+		if strings.HasSuffix(name, ".json") {
+			kept = append(kept, f)
+		}
+	}
+	if s.maxFiles <= 0 || len(kept) <= s.maxFiles {
+		return
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].savedAt.Before(kept[j].savedAt) })
+	for _, f := range kept[:len(kept)-s.maxFiles] {
+		s.remove(f.path)
+	}
+}
+
+func (s *Store) remove(path string) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("[lastgood] pruning %s: %v", path, err)
+	}
+}
+
+// Outage reports whether err means GitHub could not be reached or answer,
+// rather than that the request was wrong: server errors, network failures,
+// timeouts, rate limits, and calls the request queue turned away. Only these
+// are answered from the store.
+func Outage(err error) bool {
+	var oe *orcherr.OpsOrchError
+	if !errors.As(err, &oe) {
+		return false
+	}
+	switch oe.Code {
+	case "provider_error", "timeout", "rate_limited", "throttled":
+		return true
+	}
+	return false
+}
+
+// Serve saves a successful result under key, or answers an outage with the
+// saved one, passed to mark with its save time to flag it stale. Other errors,
+// and outages with nothing saved, are returned as is.
+func Serve[T any](s *Store, key string, result T, err error, mark func(*T, time.Time)) (T, error) {
+	if s == nil {
+		return result, err
+	}
+	if err == nil {
+		s.Save(key, result)
+		return result, nil
+	}
+	if !Outage(err) {
+		return result, err
+	}
+	var saved T
+	savedAt, ok := s.Load(key, &saved)
+	if !ok {
+		return result, err
+	}
+	log.Printf("[lastgood] serving %s %s from %s: %v", s.scope, key, savedAt.Format(time.RFC3339), err)
+	mark(&saved, savedAt)
+	return saved, nil
+}
+
+// Mark flags metadata as stale, saved at savedAt, allocating it if needed.
+func Mark(metadata map[string]any, savedAt time.Time) map[string]any {
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	metadata["stale"] = true
+	metadata["stale_at"] = savedAt.UTC().Format(time.RFC3339)
+	return metadata
+}
+
+// Key returns a store key for a read and its parameters, such as a query.
+func Key(read string, params any) string {
+	data, _ := json.Marshal(params)
+	return read + "/" + string(data)
+}
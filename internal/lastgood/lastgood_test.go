@@ -0,0 +1,156 @@
+package lastgood
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+type item struct {
+	Name     string         `json:"name"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+func mark(it *item, savedAt time.Time) { it.Metadata = Mark(it.Metadata, savedAt) }
+
+func newStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Parse(map[string]any{"staleCacheDir": t.TempDir()}, "test/acme")
+	if err != nil || s == nil {
+		t.Fatalf("Parse() = %v, %v", s, err)
+	}
+	s.now = func() time.Time { return time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return s
+}
+
+func TestServe(t *testing.T) {
+	s := newStore(t)
+	outage := &orcherr.OpsOrchError{Code: "provider_error", Message: "GitHub API error: 502"}
+
+	// Nothing saved yet, so the outage is returned
+	if _, err := Serve(s, "get/1", item{}, outage, mark); err != outage {
+		t.Fatalf("Serve() before a save error = %v, want the outage", err)
+	}
+
+	got, err := Serve(s, "get/1", item{Name: "one"}, nil, mark)
+	if err != nil || got.Name != "one" || got.Metadata != nil {
+		t.Fatalf("Serve() of a result = %+v, %v", got, err)
+	}
+
+	got, err = Serve(s, "get/1", item{}, outage, mark)
+	if err != nil {
+		t.Fatalf("Serve() during an outage error = %v", err)
+	}
+	if got.Name != "one" || got.Metadata["stale"] != true || got.Metadata["stale_at"] != "2030-01-02T03:04:05Z" {
+		t.Errorf("Serve() during an outage = %+v", got)
+	}
+
+	// Errors that are not outages are returned even with a saved result
+	notFound := &orcherr.OpsOrchError{Code: "not_found", Message: "gone"}
+	if _, err := Serve(s, "get/1", item{}, notFound, mark); err != notFound {
+		t.Errorf("Serve() of not_found error = %v", err)
+	}
+
+	// Another scope sharing the directory does not see the result
+	other, _ := Parse(map[string]any{"staleCacheDir": s.dir}, "test/other")
+	if _, ok := other.Load("get/1", &item{}); ok {
+		t.Error("result leaked across scopes")
+	}
+}
+
+func TestNilStore(t *testing.T) {
+	s, err := Parse(map[string]any{}, "test/acme")
+	if s != nil || err != nil {
+		t.Fatalf("Parse() without staleCacheDir = %v, %v", s, err)
+	}
+	outage := &orcherr.OpsOrchError{Code: "timeout"}
+	if _, err := Serve(s, "get/1", item{}, outage, mark); err != outage {
+		t.Errorf("Serve() without a store error = %v", err)
+	}
+}
+
+func TestOutage(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&orcherr.OpsOrchError{Code: "provider_error"}, true},
+		{&orcherr.OpsOrchError{Code: "timeout"}, true},
+		{&orcherr.OpsOrchError{Code: "rate_limited"}, true},
+		{&orcherr.OpsOrchError{Code: "not_found"}, false},
+		{&orcherr.OpsOrchError{Code: "canceled"}, false},
+		{errors.New("plain"), false},
+	}
+	for _, tt := range tests {
+		if got := Outage(tt.err); got != tt.want {
+			t.Errorf("Outage(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestSaveUnchanged(t *testing.T) {
+	s := newStore(t)
+	s.Save("get/1", item{Name: "one"})
+	first, err := os.ReadFile(s.path("get/1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An equal result only moves the save time
+	s.now = func() time.Time { return time.Date(2030, 1, 3, 0, 0, 0, 0, time.UTC) }
+	s.Save("get/1", item{Name: "one"})
+	if again, _ := os.ReadFile(s.path("get/1")); string(again) != string(first) {
+		t.Errorf("unchanged result rewritten: %s", again)
+	}
+	if savedAt, ok := s.Load("get/1", &item{}); !ok || !savedAt.Equal(s.now()) {
+		t.Errorf("Load() after an unchanged save = %v, %v, want %v", savedAt, ok, s.now())
+	}
+
+	s.Save("get/1", item{Name: "two"})
+	var got item
+	if _, ok := s.Load("get/1", &got); !ok || got.Name != "two" {
+		t.Errorf("Load() after a changed save = %+v, %v", got, ok)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s := newStore(t)
+	s.ttl = 24 * time.Hour
+	s.maxFiles = 2
+	start := s.now()
+	at := func(hours int) { s.now = func() time.Time { return start.Add(time.Duration(hours) * time.Hour) } }
+
+	s.Save("get/1", item{Name: "one"})
+	at(1)
+	s.Save("get/2", item{Name: "two"})
+	at(2)
+	s.Save("get/3", item{Name: "three"})
+	if _, ok := s.Load("get/1", &item{}); ok {
+		t.Error("oldest result kept beyond maxFiles")
+	}
+	if _, ok := s.Load("get/2", &item{}); !ok {
+		t.Error("newer result pruned")
+	}
+
+	// Past the TTL a result is no longer served, and is removed
+	at(26)
+	if _, ok := s.Load("get/2", &item{}); ok {
+		t.Error("result served past the TTL")
+	}
+	if _, err := os.Stat(s.path("get/2")); !os.IsNotExist(err) {
+		t.Errorf("expired result kept: %v", err)
+	}
+
+	// Opening the store prunes what expired while no process ran
+	old := time.Now().Add(-DefaultTTL - time.Hour)
+	if err := os.Chtimes(s.path("get/3"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	reopened, _ := Parse(map[string]any{"staleCacheDir": s.dir}, "test/acme")
+	if entries, _ := os.ReadDir(reopened.dir); len(entries) != 0 {
+		t.Errorf("files after reopening = %d, want 0", len(entries))
+	}
+}
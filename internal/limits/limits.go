@@ -0,0 +1,66 @@
+// Package limits validates RPC payloads before they are dispatched, so an
+// oversized request fails with a bad_request naming the limit instead of an
+// opaque 422 from GitHub.
+package limits
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// DefaultMaxBytes is the payload size allowed when "maxPayloadBytes" is unset.
+// It leaves room for repository files written through the change plugin.
+const DefaultMaxBytes = 4 << 20
+
+// GitHub's limits on issues and pull requests.
+const (
+	MaxTitle  = 256   // Characters in a title
+	MaxBody   = 65536 // Characters in a body or comment
+	MaxLabels = 100   // Labels on one issue or pull request
+)
+
+// Size checks a payload of n bytes against "maxPayloadBytes", taken from the
+// named profile when it resolves and from cfg otherwise. Zero or less means
+// unlimited.
+func Size(cfg map[string]any, profile string, n int) error {
+	if resolved, err := ghconfig.Profile(cfg, profile); err == nil {
+		cfg = resolved
+	}
+	limit := ghconfig.Int(cfg, "maxPayloadBytes", DefaultMaxBytes)
+	if limit <= 0 || n <= limit {
+		return nil
+	}
+	return &orcherr.OpsOrchError{
+		Code:    "bad_request",
+		Message: fmt.Sprintf("payload is %d bytes, over the maxPayloadBytes limit of %d", n, limit),
+	}
+}
+
+// Issue checks the title, body, and labels of an issue, pull request, or
+// release against GitHub's limits. Lengths are counted in characters, as
+// GitHub counts them.
+func Issue(title, body string, labels []string) error {
+	if n := utf8.RuneCountInString(title); n > MaxTitle {
+		return tooLong("title", n, MaxTitle)
+	}
+	if n := utf8.RuneCountInString(body); n > MaxBody {
+		return tooLong("body", n, MaxBody)
+	}
+	if len(labels) > MaxLabels {
+		return &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("%d labels given, over GitHub's limit of %d", len(labels), MaxLabels),
+		}
+	}
+	return nil
+}
+
+func tooLong(field string, n, limit int) error {
+	return &orcherr.OpsOrchError{
+		Code:    "bad_request",
+		Message: fmt.Sprintf("%s is %d characters, over GitHub's limit of %d", field, n, limit),
+	}
+}
@@ -0,0 +1,83 @@
+package limits
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+func isBadRequest(err error) bool {
+	var opsErr *orcherr.OpsOrchError
+	return errors.As(err, &opsErr) && opsErr.Code == "bad_request"
+}
+
+func TestSize(t *testing.T) {
+	cfg := map[string]any{
+		"maxPayloadBytes": 100,
+		"profiles": map[string]any{
+			"bulk":      map[string]any{"maxPayloadBytes": 1000},
+			"unlimited": map[string]any{"maxPayloadBytes": 0},
+		},
+	}
+
+	tests := []struct {
+		profile string
+		n       int
+		wantErr bool
+	}{
+		{"", 100, false},
+		{"", 101, true},
+		{"bulk", 1000, false},
+		{"bulk", 1001, true},
+		{"unlimited", 1 << 30, false},
+		{"unknown", 101, true},
+	}
+	for _, tt := range tests {
+		err := Size(cfg, tt.profile, tt.n)
+		if (err != nil) != tt.wantErr || (err != nil && !isBadRequest(err)) {
+			t.Errorf("Size(%q, %d) = %v, wantErr %v", tt.profile, tt.n, err, tt.wantErr)
+		}
+	}
+
+	if err := Size(map[string]any{}, "", DefaultMaxBytes); err != nil {
+		t.Errorf("Size() at the default limit = %v", err)
+	}
+	if err := Size(map[string]any{}, "", DefaultMaxBytes+1); err == nil {
+		t.Error("Size() past the default limit succeeded")
+	}
+}
+
+func TestIssue(t *testing.T) {
+	labels := func(n int) []string { return make([]string, n) }
+
+	tests := []struct {
+		name    string
+		title   string
+		body    string
+		labels  []string
+		wantErr string
+	}{
+		{"empty", "", "", nil, ""},
+		{"at limits", strings.Repeat("t", MaxTitle), strings.Repeat("b", MaxBody), labels(MaxLabels), ""},
+		{"characters not bytes", strings.Repeat("é", MaxTitle), "", nil, ""},
+		{"long title", strings.Repeat("t", MaxTitle+1), "", nil, "title is 257 characters, over GitHub's limit of 256"},
+		{"long body", "", strings.Repeat("b", MaxBody+1), nil, "body is 65537 characters"},
+		{"many labels", "", "", labels(MaxLabels + 1), "101 labels given"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Issue(tt.title, tt.body, tt.labels)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Issue() = %v", err)
+				}
+				return
+			}
+			if !isBadRequest(err) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Issue() = %v, want bad_request containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
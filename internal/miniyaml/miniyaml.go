@@ -0,0 +1,341 @@
+// Package miniyaml parses the subset of YAML used by GitHub issue templates and
+// issue forms: block mappings and sequences, flow sequences, quoted and plain
+// scalars, literal (|) and folded (>) block scalars, and comments. Anchors, tags,
+// multi-document streams, and multi-line plain scalars are not supported.
+//
+// Mappings decode to map[string]any, sequences to []any, true/false to bool, and
+// every other scalar to string.
+package miniyaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse decodes a YAML document.
+func Parse(data []byte) (any, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	p := &parser{lines: strings.Split(text, "\n")}
+
+	p.skip()
+	if p.i < len(p.lines) && strings.TrimSpace(p.lines[p.i]) == "---" {
+		p.i++
+		p.skip()
+	}
+	if p.i >= len(p.lines) {
+		return nil, nil
+	}
+
+	v, err := p.node(indentOf(p.lines[p.i]))
+	if err != nil {
+		return nil, err
+	}
+	p.skip()
+	if p.i < len(p.lines) && strings.TrimSpace(p.lines[p.i]) != "---" {
+		return nil, p.errorf("unexpected content")
+	}
+	return v, nil
+}
+
+type parser struct {
+	lines []string
+	i     int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("yaml line %d: %s", p.i+1, fmt.Sprintf(format, args...))
+}
+
+// skip advances past blank and comment-only lines.
+func (p *parser) skip() {
+	for p.i < len(p.lines) {
+		t := strings.TrimSpace(p.lines[p.i])
+		if t != "" && !strings.HasPrefix(t, "#") {
+			return
+		}
+		p.i++
+	}
+}
+
+// node parses the value starting on the current line, which is indented by indent.
+func (p *parser) node(indent int) (any, error) {
+	text := strings.TrimSpace(p.lines[p.i])
+	if text == "-" || strings.HasPrefix(text, "- ") {
+		return p.sequence(indent)
+	}
+	if _, _, ok := splitKey(text); ok {
+		return p.mapping(indent)
+	}
+	p.i++
+	return scalar(text)
+}
+
+func (p *parser) sequence(indent int) ([]any, error) {
+	items := []any{}
+	for {
+		p.skip()
+		if p.i >= len(p.lines) || indentOf(p.lines[p.i]) != indent {
+			return items, nil
+		}
+		line := p.lines[p.i]
+		text := strings.TrimSpace(line)
+		if text != "-" && !strings.HasPrefix(text, "- ") {
+			return items, nil
+		}
+
+		rest := strings.TrimLeft(text[1:], " ")
+		if rest == "" {
+			p.i++
+			item, err := p.nested(indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+
+		// Re-read the item content as though it started on its own line, so
+		// "- key: value" followed by aligned keys parses as one mapping
+		itemIndent := len(line) - len(rest)
+		p.lines[p.i] = strings.Repeat(" ", itemIndent) + rest
+		item, err := p.node(itemIndent)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+}
+
+func (p *parser) mapping(indent int) (map[string]any, error) {
+	m := map[string]any{}
+	for {
+		p.skip()
+		if p.i >= len(p.lines) || indentOf(p.lines[p.i]) != indent {
+			return m, nil
+		}
+		text := strings.TrimSpace(p.lines[p.i])
+		if text == "-" || strings.HasPrefix(text, "- ") {
+			return m, nil
+		}
+
+		key, rest, ok := splitKey(text)
+		if !ok {
+			return nil, p.errorf("expected key: value")
+		}
+		p.i++
+
+		var value any
+		var err error
+		switch {
+		case rest == "":
+			value, err = p.nested(indent)
+			// A sequence may sit at the same indentation as its key
+			if err == nil && value == nil && p.i < len(p.lines) && indentOf(p.lines[p.i]) == indent {
+				if t := strings.TrimSpace(p.lines[p.i]); t == "-" || strings.HasPrefix(t, "- ") {
+					value, err = p.sequence(indent)
+				}
+			}
+		case rest[0] == '|' || rest[0] == '>':
+			value = p.block(indent, rest)
+		default:
+			value, err = scalar(rest)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+	}
+}
+
+// nested parses a value on the following lines if they are indented deeper than
+// indent, and returns nil otherwise.
+func (p *parser) nested(indent int) (any, error) {
+	p.skip()
+	if p.i >= len(p.lines) {
+		return nil, nil
+	}
+	child := indentOf(p.lines[p.i])
+	if child <= indent {
+		return nil, nil
+	}
+	return p.node(child)
+}
+
+// block reads a literal or folded block scalar whose header is style.
+func (p *parser) block(indent int, style string) string {
+	var lines []string
+	blockIndent := -1
+	for p.i < len(p.lines) {
+		line := p.lines[p.i]
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, "")
+			p.i++
+			continue
+		}
+		n := indentOf(line)
+		if n <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = n
+		}
+		if n < blockIndent {
+			break
+		}
+		lines = append(lines, line[blockIndent:])
+		p.i++
+	}
+
+	// Trailing blank lines belong to the chomping indicator, not the content
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var out string
+	if style[0] == '>' {
+		var b strings.Builder
+		for i, line := range lines {
+			switch {
+			case i == 0:
+			case line == "" || lines[i-1] == "":
+				b.WriteString("\n")
+			default:
+				b.WriteString(" ")
+			}
+			b.WriteString(line)
+		}
+		out = b.String()
+	} else {
+		out = strings.Join(lines, "\n")
+	}
+	if !strings.Contains(style, "-") && out != "" {
+		out += "\n"
+	}
+	return out
+}
+
+// splitKey splits "key: value" (or "key:") outside quotes.
+func splitKey(text string) (key, rest string, ok bool) {
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return "", "", false
+	}
+
+	quote := byte(0)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 {
+				quote = c
+			}
+		case c == ':' && (i == len(text)-1 || text[i+1] == ' '):
+			key = strings.TrimSpace(text[:i])
+			if unquoted, err := unquote(key); err == nil {
+				key = unquoted
+			}
+			return key, strings.TrimSpace(stripComment(text[i+1:])), key != ""
+		case c == '#' && i > 0 && text[i-1] == ' ':
+			return "", "", false
+		}
+	}
+	return "", "", false
+}
+
+// scalar decodes an inline value: a flow sequence, a quoted string, or a plain scalar.
+func scalar(text string) (any, error) {
+	text = strings.TrimSpace(stripComment(text))
+	switch {
+	case strings.HasPrefix(text, "["):
+		if !strings.HasSuffix(text, "]") {
+			return nil, fmt.Errorf("yaml: unterminated flow sequence %q", text)
+		}
+		items := []any{}
+		for _, part := range splitFlow(text[1 : len(text)-1]) {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			item, err := scalar(part)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'"):
+		return unquote(text)
+	case text == "true" || text == "True" || text == "TRUE":
+		return true, nil
+	case text == "false" || text == "False" || text == "FALSE":
+		return false, nil
+	case text == "~" || text == "null":
+		return nil, nil
+	}
+	return text, nil
+}
+
+func unquote(text string) (string, error) {
+	if len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'' {
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+	if len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"' {
+		s, err := strconv.Unquote(text)
+		if err != nil {
+			return "", fmt.Errorf("yaml: invalid quoted string %s", text)
+		}
+		return s, nil
+	}
+	if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") {
+		return "", fmt.Errorf("yaml: unterminated quoted string %s", text)
+	}
+	return text, nil
+}
+
+// splitFlow splits flow sequence items on commas outside quotes.
+func splitFlow(text string) []string {
+	var parts []string
+	quote := byte(0)
+	start := 0
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, text[start:])
+}
+
+// stripComment removes a trailing " # comment" outside quotes.
+func stripComment(text string) string {
+	quote := byte(0)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.ContainsRune(" \t[,", rune(text[i-1]))):
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		}
+	}
+	return text
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
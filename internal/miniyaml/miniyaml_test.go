@@ -0,0 +1,119 @@
+package miniyaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIssueForm(t *testing.T) {
+	src := `# Incident report form
+name: Incident report
+description: "File an incident: anything customer-facing"
+title: "[Incident]: "
+labels: ["incident", 'triage']
+assignees:
+- octocat
+body:
+  - type: markdown
+    attributes:
+      value: |
+        Thanks for reporting!
+
+        Please fill in every section.
+  - type: input
+    id: service   # the affected service
+    attributes:
+      label: Service
+      placeholder: e.g. checkout
+    validations:
+      required: true
+  - type: dropdown
+    id: severity
+    attributes:
+      label: Severity
+      options:
+        - sev1
+        - sev2
+  - type: textarea
+    id: summary
+    attributes:
+      label: Summary
+      description: >-
+        What happened,
+        and when?
+`
+	got, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := map[string]any{
+		"name":        "Incident report",
+		"description": "File an incident: anything customer-facing",
+		"title":       "[Incident]: ",
+		"labels":      []any{"incident", "triage"},
+		"assignees":   []any{"octocat"},
+		"body": []any{
+			map[string]any{
+				"type":       "markdown",
+				"attributes": map[string]any{"value": "Thanks for reporting!\n\nPlease fill in every section.\n"},
+			},
+			map[string]any{
+				"type":        "input",
+				"id":          "service",
+				"attributes":  map[string]any{"label": "Service", "placeholder": "e.g. checkout"},
+				"validations": map[string]any{"required": true},
+			},
+			map[string]any{
+				"type":       "dropdown",
+				"id":         "severity",
+				"attributes": map[string]any{"label": "Severity", "options": []any{"sev1", "sev2"}},
+			},
+			map[string]any{
+				"type":       "textarea",
+				"id":         "summary",
+				"attributes": map[string]any{"label": "Summary", "description": "What happened, and when?"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestParseScalars(t *testing.T) {
+	tests := []struct {
+		src  string
+		want any
+	}{
+		{"", nil},
+		{"---\nkey: value\n", map[string]any{"key": "value"}},
+		{"a: 'it''s'\nb: \"tab\\there\"\nc: ~\nd: false", map[string]any{"a": "it's", "b": "tab\there", "c": nil, "d": false}},
+		{"url: https://example.com/a#b", map[string]any{"url": "https://example.com/a#b"}},
+		{"note: don't # trailing", map[string]any{"note": "don't"}},
+		{"- a\n- [b, \"c, d\"]", []any{"a", []any{"b", "c, d"}}},
+	}
+	for _, tt := range tests {
+		got, err := Parse([]byte(tt.src))
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		"key: value\n  stray",
+		"key: [a, b",
+		"key: \"unterminated",
+		"a: 1\nnot a pair",
+	} {
+		if _, err := Parse([]byte(src)); err == nil {
+			t.Errorf("Parse(%q) expected an error", src)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package nodeid resolves GitHub GraphQL node IDs of issues, workflow runs,
+// deployments, and teams to the repository and number, or organization and
+// slug, they currently live at. Node IDs survive repository renames and
+// transfers, so references built on them stay valid when owner/repo names do
+// not, and IDs from webhook payloads and GraphQL sources work directly.
+package nodeid
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+// Node types Resolve reports.
+const (
+	TypeIssue       = "Issue"
+	TypeWorkflowRun = "WorkflowRun"
+	TypeDeployment  = "Deployment"
+	TypeTeam        = "Team"
+)
+
+// Node is what a node ID refers to. Number is set for issues and DatabaseID for
+// workflow runs, deployments, and teams. A team's Owner is its organization and
+// Repo is empty.
+type Node struct {
+	Type       string
+	Owner      string
+	Repo       string
+	Number     int
+	DatabaseID int64
+	Slug       string
+}
+
+var (
+	// Current node IDs are a type prefix and an opaque suffix, such as I_kwDO…
+	nextFormat = regexp.MustCompile(`^(I|WFR|DE|T)_[A-Za-z0-9_-]+$`)
+	// Legacy node IDs are base64 of "<length>:<Type><database ID>"
+	legacyFormat = regexp.MustCompile(`^\d+:(Issue|WorkflowRun|Deployment|Team)\d+$`)
+)
+
+// Is reports whether id looks like the node ID of an issue, workflow run,
+// deployment, or team.
+func Is(id string) bool {
+	if nextFormat.MatchString(id) {
+		return true
+	}
+	decoded, err := base64.StdEncoding.DecodeString(id)
+	return err == nil && legacyFormat.Match(decoded)
+}
+
+const nodeQuery = `query($id: ID!) {
+  node(id: $id) {
+    __typename
+    ... on Issue { number repository { name owner { login } } }
+    ... on WorkflowRun { databaseId checkSuite { repository { name owner { login } } } }
+    ... on Deployment { databaseId repository { name owner { login } } }
+    ... on Team { databaseId slug organization { login } }
+  }
+}`
+
+type gqlRepository struct {
+	Name  string `json:"name"`
+	Owner struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+// Resolve looks up a node ID. Unknown nodes are not_found errors and nodes of
+// other types bad_request errors; HTTP errors are passed to the caller's wrap.
+func Resolve(ctx context.Context, gql ghapi.GraphQLService, id string, wrap func(error) error) (Node, error) {
+	if gql == nil {
+		return Node{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "node ID lookups are not available for this provider",
+		}
+	}
+
+	var data struct {
+		Node *struct {
+			Typename   string        `json:"__typename"`
+			Number     int           `json:"number"`
+			DatabaseID int64         `json:"databaseId"`
+			Slug       string        `json:"slug"`
+			Repository gqlRepository `json:"repository"`
+			CheckSuite struct {
+				Repository gqlRepository `json:"repository"`
+			} `json:"checkSuite"`
+			Organization struct {
+				Login string `json:"login"`
+			} `json:"organization"`
+		} `json:"node"`
+	}
+	if _, err := gql.Query(ctx, nodeQuery, map[string]any{"id": id}, &data); err != nil {
+		var gqlErr *ghapi.GraphQLError
+		if !errors.As(err, &gqlErr) {
+			return Node{}, wrap(err)
+		}
+		for _, entry := range gqlErr.Errors {
+			if entry.Type == "NOT_FOUND" {
+				return Node{}, notFound(id)
+			}
+		}
+		return Node{}, &orcherr.OpsOrchError{
+			Code:    "provider_error",
+			Message: fmt.Sprintf("GitHub API error: %v", err),
+			Err:     err,
+		}
+	}
+	if data.Node == nil {
+		return Node{}, notFound(id)
+	}
+
+	n := data.Node
+	node := Node{Type: n.Typename, Number: n.Number, DatabaseID: n.DatabaseID, Slug: n.Slug}
+	repo := n.Repository
+	switch n.Typename {
+	case TypeIssue, TypeDeployment:
+	case TypeWorkflowRun:
+		repo = n.CheckSuite.Repository
+	case TypeTeam:
+		node.Owner = n.Organization.Login
+		return node, nil
+	default:
+		return Node{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("node %s is a %s, not an issue, workflow run, deployment, or team", id, n.Typename),
+		}
+	}
+	node.Owner, node.Repo = repo.Owner.Login, repo.Name
+	return node, nil
+}
+
+// Expect returns a bad_request error unless node has one of the given types.
+func Expect(id string, node Node, types ...string) error {
+	for _, t := range types {
+		if node.Type == t {
+			return nil
+		}
+	}
+	return &orcherr.OpsOrchError{
+		Code:    "bad_request",
+		Message: fmt.Sprintf("node %s is a %s, not a %s", id, node.Type, strings.Join(types, " or ")),
+	}
+}
+
+func notFound(id string) error {
+	return &orcherr.OpsOrchError{
+		Code:    "not_found",
+		Message: fmt.Sprintf("GitHub node %s not found", id),
+	}
+}
@@ -0,0 +1,99 @@
+package nodeid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+func TestIs(t *testing.T) {
+	tests := map[string]bool{
+		"I_kwDOABCD5M5abcde":   true,
+		"WFR_kwLOABCD5M8AAAAB": true,
+		"DE_kwDOABCD5M4AAAAB":  true,
+		"T_kwDOABCD5M4AAAAL":   true,
+		"MDQ6VGVhbTEx":         true,  // 04:Team11
+		"MDU6SXNzdWUxMjM0NTY=": true,  // 05:Issue123456
+		"MDQ6VXNlcjE=":         false, // 04:User1
+		"PR_kwDOABCD5M5abcde":  false,
+		"42":                   false,
+		"acme/api#42":          false,
+		"deployment-501":       false,
+	}
+	for id, want := range tests {
+		if got := Is(id); got != want {
+			t.Errorf("Is(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	srv := fakegithub.New(t)
+	api := ghapi.FromClient(srv.Client())
+	repo := map[string]any{"name": "api-renamed", "owner": map[string]any{"login": "acme"}}
+	srv.Handle(http.MethodPost, "/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]any `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.Variables["id"] {
+		case "I_issue":
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"node": map[string]any{
+				"__typename": "Issue", "number": 7, "repository": repo,
+			}}})
+		case "WFR_run":
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"node": map[string]any{
+				"__typename": "WorkflowRun", "databaseId": 1001, "checkSuite": map[string]any{"repository": repo},
+			}}})
+		case "T_team":
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"node": map[string]any{
+				"__typename": "Team", "databaseId": 11, "slug": "sre", "organization": map[string]any{"login": "acme"},
+			}}})
+		case "DE_user":
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"node": map[string]any{"__typename": "User"}}})
+		default:
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+				"data":   map[string]any{"node": nil},
+				"errors": []any{map[string]any{"type": "NOT_FOUND", "message": "Could not resolve to a node"}},
+			})
+		}
+	})
+	wrap := func(err error) error { return err }
+
+	node, err := Resolve(context.Background(), api.GraphQL, "I_issue", wrap)
+	if err != nil || node != (Node{Type: TypeIssue, Owner: "acme", Repo: "api-renamed", Number: 7}) {
+		t.Errorf("Resolve(issue) = %+v, %v", node, err)
+	}
+	node, err = Resolve(context.Background(), api.GraphQL, "WFR_run", wrap)
+	if err != nil || node != (Node{Type: TypeWorkflowRun, Owner: "acme", Repo: "api-renamed", DatabaseID: 1001}) {
+		t.Errorf("Resolve(run) = %+v, %v", node, err)
+	}
+	node, err = Resolve(context.Background(), api.GraphQL, "T_team", wrap)
+	if err != nil || node != (Node{Type: TypeTeam, Owner: "acme", DatabaseID: 11, Slug: "sre"}) {
+		t.Errorf("Resolve(team) = %+v, %v", node, err)
+	}
+	if err := Expect("T_team", node, TypeIssue); !hasCode(err, "bad_request") {
+		t.Errorf("Expect() error = %v, want bad_request", err)
+	}
+
+	for id, code := range map[string]string{"DE_user": "bad_request", "I_missing": "not_found"} {
+		if _, err := Resolve(context.Background(), api.GraphQL, id, wrap); !hasCode(err, code) {
+			t.Errorf("Resolve(%s) error = %v, want %s", id, err, code)
+		}
+	}
+	if _, err := Resolve(context.Background(), nil, "I_issue", wrap); !hasCode(err, "bad_request") {
+		t.Errorf("Resolve() without GraphQL error = %v, want bad_request", err)
+	}
+}
+
+func hasCode(err error, code string) bool {
+	var oe *orcherr.OpsOrchError
+	return errors.As(err, &oe) && oe.Code == code
+}
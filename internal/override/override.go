@@ -0,0 +1,170 @@
+// Package override lets one query or create target a repository other than the
+// provider's, named in request metadata as "owner" and "repo" or as the
+// "repository" shorthand, and lets an org-scope query name an organization in
+// metadata "org". Only repositories matching the "repositoryAllowlist" config
+// and organizations matching "organizationAllowlist" may be targeted, so ad-hoc
+// cross-repository calls need no provider of their own but cannot reach
+// anything the operator did not allow.
+package override
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// Allowlist holds owner/repo patterns, such as "acme/*", that overrides may
+// target. Patterns use path.Match syntax and match case-insensitively. An empty
+// allowlist disables overrides.
+type Allowlist []string
+
+// Parse reads the "repositoryAllowlist" config key, rejecting malformed patterns.
+func Parse(cfg map[string]any) (Allowlist, error) {
+	var allow Allowlist
+	for _, pattern := range ghconfig.StringSlice(cfg, "repositoryAllowlist") {
+		pattern = strings.ToLower(pattern)
+		owner, repo, ok := strings.Cut(pattern, "/")
+		if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+			return nil, fmt.Errorf("repositoryAllowlist: invalid pattern %q (expected owner/repo)", pattern)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("repositoryAllowlist: invalid pattern %q: %w", pattern, err)
+		}
+		allow = append(allow, pattern)
+	}
+	return allow, nil
+}
+
+// Allows reports whether owner/repo matches a pattern.
+func (a Allowlist) Allows(owner, repo string) bool {
+	full := strings.ToLower(owner + "/" + repo)
+	for _, pattern := range a {
+		if ok, _ := path.Match(pattern, full); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Repository returns the repository metadata asks for, reporting false when it
+// names none or the provider's own, owner/repo. A bare "repo" is taken to be
+// one of owner's. Repositories outside the allowlist are forbidden.
+func (a Allowlist) Repository(metadata map[string]any, owner, repo string) (string, string, bool, error) {
+	targetOwner, targetRepo := ghconfig.String(metadata, "owner"), ghconfig.String(metadata, "repo")
+	if full := ghconfig.String(metadata, "repository"); full != "" {
+		if targetOwner != "" || targetRepo != "" {
+			return "", "", false, &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: "set either repository or owner/repo, not both",
+			}
+		}
+		var err error
+		if targetOwner, targetRepo, err = ghconfig.SplitRepository(full); err != nil {
+			return "", "", false, &orcherr.OpsOrchError{Code: "bad_request", Message: err.Error()}
+		}
+	}
+	if targetOwner == "" && targetRepo == "" {
+		return "", "", false, nil
+	}
+	if targetRepo == "" {
+		return "", "", false, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "repo is required with owner",
+		}
+	}
+	if targetOwner == "" {
+		targetOwner = owner
+	}
+	if strings.EqualFold(targetOwner, owner) && strings.EqualFold(targetRepo, repo) {
+		return "", "", false, nil
+	}
+
+	if err := a.Check(targetOwner, targetRepo); err != nil {
+		return "", "", false, err
+	}
+	return targetOwner, targetRepo, true, nil
+}
+
+// Check returns forbidden unless owner/repo matches a pattern.
+func (a Allowlist) Check(owner, repo string) error {
+	if len(a) == 0 {
+		return &orcherr.OpsOrchError{
+			Code:    "forbidden",
+			Message: "repository overrides are disabled; configure repositoryAllowlist to enable them",
+		}
+	}
+	if !a.Allows(owner, repo) {
+		return &orcherr.OpsOrchError{
+			Code:    "forbidden",
+			Message: fmt.Sprintf("repository not in allowlist: %s/%s", owner, repo),
+		}
+	}
+	return nil
+}
+
+// Organizations holds organization patterns, such as "acme-*", that org-scope
+// queries may name in metadata "org". Patterns use path.Match syntax and match
+// case-insensitively.
+type Organizations []string
+
+// ParseOrganizations reads the "organizationAllowlist" config key, rejecting
+// malformed patterns.
+func ParseOrganizations(cfg map[string]any) (Organizations, error) {
+	var allow Organizations
+	for _, pattern := range ghconfig.StringSlice(cfg, "organizationAllowlist") {
+		pattern = strings.ToLower(pattern)
+		if strings.Contains(pattern, "/") {
+			return nil, fmt.Errorf("organizationAllowlist: invalid pattern %q (expected an organization)", pattern)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("organizationAllowlist: invalid pattern %q: %w", pattern, err)
+		}
+		allow = append(allow, pattern)
+	}
+	return allow, nil
+}
+
+// Allows reports whether org is own, the provider's organization, or matches
+// a pattern.
+func (o Organizations) Allows(org, own string) bool {
+	if strings.EqualFold(org, own) {
+		return true
+	}
+	org = strings.ToLower(org)
+	for _, pattern := range o {
+		if ok, _ := path.Match(pattern, org); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns forbidden unless Allows accepts org.
+func (o Organizations) Check(org, own string) error {
+	if o.Allows(org, own) {
+		return nil
+	}
+	if len(o) == 0 {
+		return &orcherr.OpsOrchError{
+			Code:    "forbidden",
+			Message: "organization overrides are disabled; configure organizationAllowlist to enable them",
+		}
+	}
+	return &orcherr.OpsOrchError{
+		Code:    "forbidden",
+		Message: fmt.Sprintf("organization not in allowlist: %s", org),
+	}
+}
+
+// Reach returns forbidden unless a provider may read owner/repo by ID: the
+// repository matches repos, or its owner is an organization orgs allows, whose
+// org-scope queries return IDs of all of its repositories.
+func Reach(repos Allowlist, orgs Organizations, own, owner, repo string) error {
+	if orgs.Allows(owner, own) || repos.Allows(owner, repo) {
+		return nil
+	}
+	return repos.Check(owner, repo)
+}
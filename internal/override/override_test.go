@@ -0,0 +1,125 @@
+package override
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+func TestParse(t *testing.T) {
+	allow, err := Parse(map[string]any{"repositoryAllowlist": []any{"Acme/*", "ops/infra"}})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	for _, tt := range []struct {
+		owner, repo string
+		want        bool
+	}{
+		{"acme", "web", true},
+		{"ACME", "Web", true},
+		{"ops", "infra", true},
+		{"ops", "web", false},
+	} {
+		if got := allow.Allows(tt.owner, tt.repo); got != tt.want {
+			t.Errorf("Allows(%q, %q) = %v, want %v", tt.owner, tt.repo, got, tt.want)
+		}
+	}
+
+	for _, pattern := range []string{"acme", "acme/", "/web", "acme/web/x", "acme/[web"} {
+		if _, err := Parse(map[string]any{"repositoryAllowlist": []any{pattern}}); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", pattern)
+		}
+	}
+}
+
+func TestRepository(t *testing.T) {
+	allow := Allowlist{"acme/*"}
+	tests := []struct {
+		name      string
+		allow     Allowlist
+		metadata  map[string]any
+		wantOwner string
+		wantRepo  string
+		wantOK    bool
+		wantCode  string
+	}{
+		{"none", allow, nil, "", "", false, ""},
+		{"own repository", allow, map[string]any{"repository": "acme/api"}, "", "", false, ""},
+		{"bare repo", allow, map[string]any{"repo": "web"}, "acme", "web", true, ""},
+		{"owner and repo", allow, map[string]any{"owner": "acme", "repo": "web"}, "acme", "web", true, ""},
+		{"shorthand", allow, map[string]any{"repository": "acme/web"}, "acme", "web", true, ""},
+		{"both forms", allow, map[string]any{"repository": "acme/web", "repo": "web"}, "", "", false, "bad_request"},
+		{"malformed shorthand", allow, map[string]any{"repository": "web"}, "", "", false, "bad_request"},
+		{"owner only", allow, map[string]any{"owner": "acme"}, "", "", false, "bad_request"},
+		{"outside allowlist", allow, map[string]any{"repository": "other/web"}, "", "", false, "forbidden"},
+		{"disabled", nil, map[string]any{"repo": "web"}, "", "", false, "forbidden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, repo, ok, err := tt.allow.Repository(tt.metadata, "acme", "api")
+			var oe *orcherr.OpsOrchError
+			if tt.wantCode != "" {
+				if !errors.As(err, &oe) || oe.Code != tt.wantCode {
+					t.Fatalf("Repository() error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Repository() error = %v", err)
+			}
+			if owner != tt.wantOwner || repo != tt.wantRepo || ok != tt.wantOK {
+				t.Errorf("Repository() = %q, %q, %v, want %q, %q, %v", owner, repo, ok, tt.wantOwner, tt.wantRepo, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestOrganizations(t *testing.T) {
+	orgs, err := ParseOrganizations(map[string]any{"organizationAllowlist": "Acme-*"})
+	if err != nil {
+		t.Fatalf("ParseOrganizations() error = %v", err)
+	}
+	for _, tt := range []struct {
+		org      string
+		wantCode string
+	}{
+		{"acme", ""},
+		{"ACME-labs", ""},
+		{"other", "forbidden"},
+	} {
+		err := orgs.Check(tt.org, "acme")
+		var oe *orcherr.OpsOrchError
+		if tt.wantCode == "" && err != nil || tt.wantCode != "" && (!errors.As(err, &oe) || oe.Code != tt.wantCode) {
+			t.Errorf("Check(%q) = %v, want code %q", tt.org, err, tt.wantCode)
+		}
+	}
+	if err := Organizations(nil).Check("other", "acme"); err == nil {
+		t.Error("Check() without an allowlist allowed another organization")
+	}
+
+	for _, pattern := range []string{"acme/web", "acme-["} {
+		if _, err := ParseOrganizations(map[string]any{"organizationAllowlist": []any{pattern}}); err == nil {
+			t.Errorf("ParseOrganizations(%q) succeeded, want error", pattern)
+		}
+	}
+}
+
+func TestReach(t *testing.T) {
+	repos, orgs := Allowlist{"partner/portal"}, Organizations{"acme-*"}
+	for _, tt := range []struct {
+		owner, repo string
+		want        bool
+	}{
+		{"acme", "web", true},
+		{"acme-labs", "web", true},
+		{"partner", "portal", true},
+		{"partner", "billing", false},
+		{"other", "web", false},
+	} {
+		if err := Reach(repos, orgs, "acme", tt.owner, tt.repo); (err == nil) != tt.want {
+			t.Errorf("Reach(%s/%s) = %v, want allowed %v", tt.owner, tt.repo, err, tt.want)
+		}
+	}
+}
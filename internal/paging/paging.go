@@ -0,0 +1,160 @@
+// Package paging reads GitHub list endpoints page by page on behalf of queries
+// that filter results after listing, and encodes where a query stopped as an
+// opaque continuation token so the next query can resume there.
+package paging
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// TokenKey is the query metadata key that carries a continuation token.
+const TokenKey = "pageToken"
+
+// MaxPages bounds how many pages one query reads while filling its limit, so a
+// filter that matches little cannot walk a repository's whole history.
+const MaxPages = 10
+
+// MaxResults is the most results one query can return: MaxPages full pages.
+const MaxResults = MaxPages * 100
+
+// position is where a query resumes: the GitHub page, its size, and how many
+// of its items the previous query already went through.
+type position struct {
+	Page    int `json:"p"`
+	PerPage int `json:"n"`
+	Skip    int `json:"s,omitempty"`
+}
+
+func (pos position) encode() string {
+	data, _ := json.Marshal(pos)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// FromMetadata returns the continuation token in metadata, or "" when there is
+// none.
+func FromMetadata(metadata map[string]any) string {
+	return ghconfig.String(metadata, TokenKey)
+}
+
+func decode(token string) (position, error) {
+	var pos position
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(data, &pos)
+	}
+	if err != nil || pos.Page < 1 || pos.PerPage < 1 || pos.PerPage > 100 || pos.Skip < 0 {
+		return position{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("invalid page token: %s", token),
+		}
+	}
+	return pos, nil
+}
+
+// Walk reads pages of a list endpoint, passing each item to visit, until limit
+// items were kept, the pages run out, or MaxPages were read. Without a limit it
+// reads a single page. fetch lists the page set in opts, which Walk fills in
+// from token when the query resumes an earlier one; otherwise opts.PerPage is
+// left as the caller set it. visit reports whether it kept the item.
+//
+// Walk returns the token that resumes after the last item visited, or "" when
+// nothing is left. When fetch or visit fails with budget.ErrExhausted, Walk
+// stops without an error and the token resumes where the budget ran out.
+func Walk[T any](token string, limit int, opts *github.ListOptions, fetch func() ([]T, *github.Response, error), visit func(T) (bool, error)) (string, error) {
+	skip := 0
+	if token != "" {
+		pos, err := decode(token)
+		if err != nil {
+			return "", err
+		}
+		opts.Page, opts.PerPage, skip = pos.Page, pos.PerPage, pos.Skip
+	}
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+
+	kept := 0
+	for pages := 1; ; pages++ {
+		items, resp, err := fetch()
+		if errors.Is(err, budget.ErrExhausted) {
+			return position{Page: opts.Page, PerPage: opts.PerPage, Skip: skip}.encode(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		for i := skip; i < len(items); i++ {
+			ok, err := visit(items[i])
+			if errors.Is(err, budget.ErrExhausted) {
+				return position{Page: opts.Page, PerPage: opts.PerPage, Skip: i}.encode(), nil
+			}
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				kept++
+			}
+			if limit > 0 && kept >= limit {
+				// Resume after this item, on this page or the next one
+				if i+1 < len(items) {
+					return position{Page: opts.Page, PerPage: opts.PerPage, Skip: i + 1}.encode(), nil
+				}
+				return next(opts, resp), nil
+			}
+		}
+		skip = 0
+
+		if limit <= 0 || pages >= MaxPages || resp == nil || resp.NextPage == 0 {
+			return next(opts, resp), nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// Total returns the number of results, n, when a query started at the beginning
+// (no token) and nothing is left after it (no next token), so it saw every
+// result. Otherwise the total is unknown and Total returns nil.
+func Total(token, next string, n int) *int {
+	if token != "" || next != "" {
+		return nil
+	}
+	return &n
+}
+
+// Capacity returns how many results to make room for up front: at most limit,
+// or the single page of perPage results Walk reads for a query without one, and
+// no more than total when GitHub reported it. Without either limit or total it
+// returns 0, leaving results to grow as they arrive.
+func Capacity(limit, perPage int, total *int) int {
+	if limit <= 0 && total == nil {
+		return 0
+	}
+	n := MaxResults
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	if limit <= 0 && perPage > 0 && perPage < n {
+		n = perPage
+	}
+	if total != nil && *total < n {
+		n = max(*total, 0)
+	}
+	return n
+}
+
+// next returns the token for the page after the one just read, or "" if it was
+// the last.
+func next(opts *github.ListOptions, resp *github.Response) string {
+	if resp == nil || resp.NextPage == 0 {
+		return ""
+	}
+	return position{Page: resp.NextPage, PerPage: opts.PerPage}.encode()
+}
@@ -0,0 +1,155 @@
+package paging
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+)
+
+// pages serves items n at a time, honouring opts.Page.
+func pages(items []int, opts *github.ListOptions) func() ([]int, *github.Response, error) {
+	return func() ([]int, *github.Response, error) {
+		start := (opts.Page - 1) * opts.PerPage
+		end := min(start+opts.PerPage, len(items))
+		resp := &github.Response{}
+		if end < len(items) {
+			resp.NextPage = opts.Page + 1
+		}
+		return items[start:end], resp, nil
+	}
+}
+
+// walk collects the even items of 1..9 from token, three per page.
+func walk(t *testing.T, token string, limit int) ([]int, string) {
+	t.Helper()
+	opts := &github.ListOptions{PerPage: 3}
+	var got []int
+	next, err := Walk(token, limit, opts, pages([]int{1, 2, 3, 4, 5, 6, 7, 8, 9}, opts), func(n int) (bool, error) {
+		if n%2 != 0 {
+			return false, nil
+		}
+		got = append(got, n)
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	return got, next
+}
+
+func TestWalk(t *testing.T) {
+	// The limit is met mid-page, so the token resumes on the same page
+	got, next := walk(t, "", 1)
+	if !reflect.DeepEqual(got, []int{2}) || next == "" {
+		t.Fatalf("first page = %v, next %q", got, next)
+	}
+	got, next = walk(t, next, 2)
+	if !reflect.DeepEqual(got, []int{4, 6}) || next == "" {
+		t.Fatalf("second page = %v, next %q", got, next)
+	}
+	got, next = walk(t, next, 5)
+	if !reflect.DeepEqual(got, []int{8}) || next != "" {
+		t.Fatalf("last page = %v, next %q", got, next)
+	}
+
+	// Without a limit a single page is read
+	got, next = walk(t, "", 0)
+	if !reflect.DeepEqual(got, []int{2}) || next == "" {
+		t.Errorf("unlimited = %v, next %q", got, next)
+	}
+}
+
+func TestWalkBudgetExhausted(t *testing.T) {
+	opts := &github.ListOptions{PerPage: 3}
+	fetch := pages([]int{1, 2, 3, 4, 5, 6, 7, 8, 9}, opts)
+	var got []int
+	next, err := Walk("", 5, opts, fetch, func(n int) (bool, error) {
+		if n == 5 {
+			return false, budget.ErrExhausted
+		}
+		got = append(got, n)
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, []int{1, 2, 3, 4}) || next == "" {
+		t.Fatalf("Walk() = %v, next %q", got, next)
+	}
+
+	// The token resumes at the item the budget stopped on
+	got = nil
+	opts = &github.ListOptions{}
+	if _, err := Walk(next, 1, opts, pages([]int{1, 2, 3, 4, 5, 6, 7, 8, 9}, opts), func(n int) (bool, error) {
+		got = append(got, n)
+		return true, nil
+	}); err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, []int{5}) {
+		t.Errorf("resumed Walk() = %v, want [5]", got)
+	}
+}
+
+func TestWalkInvalidToken(t *testing.T) {
+	for _, token := range []string{"not a token", "eyJwIjowLCJuIjozfQ"} { // {"p":0,"n":3}
+		_, err := Walk(token, 1, &github.ListOptions{}, func() ([]int, *github.Response, error) {
+			t.Fatal("fetched with an invalid token")
+			return nil, nil, nil
+		}, func(int) (bool, error) { return true, nil })
+		var oe *orcherr.OpsOrchError
+		if !errors.As(err, &oe) || oe.Code != "bad_request" {
+			t.Errorf("Walk(%q) error = %v, want bad_request", token, err)
+		}
+	}
+}
+
+func TestTotal(t *testing.T) {
+	if got := Total("", "", 4); got == nil || *got != 4 {
+		t.Errorf("Total() of a complete query = %v, want 4", got)
+	}
+	if got := Total("", "next", 4); got != nil {
+		t.Errorf("Total() with more results = %v, want nil", *got)
+	}
+	if got := Total("token", "", 4); got != nil {
+		t.Errorf("Total() of a resumed query = %v, want nil", *got)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	total := func(n int) *int { return &n }
+	tests := []struct {
+		limit   int
+		perPage int
+		total   *int
+		want    int
+	}{
+		{0, 100, nil, 0},
+		{50, 50, nil, 50},
+		{0, 100, total(30), 30},
+		{50, 50, total(30), 30},
+		{20, 20, total(30), 20},
+		{0, 100, total(1 << 20), 100},
+		{0, 0, total(1 << 20), MaxResults},
+		{1 << 20, 100, nil, MaxResults},
+		{1 << 20, 100, total(1 << 20), MaxResults},
+	}
+	for _, tt := range tests {
+		if got := Capacity(tt.limit, tt.perPage, tt.total); got != tt.want {
+			t.Errorf("Capacity(%d, %d, %v) = %d, want %d", tt.limit, tt.perPage, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestFromMetadata(t *testing.T) {
+	if got := FromMetadata(map[string]any{"pageToken": "abc"}); got != "abc" {
+		t.Errorf("FromMetadata() = %q", got)
+	}
+	if got := FromMetadata(nil); got != "" {
+		t.Errorf("FromMetadata(nil) = %q", got)
+	}
+}
@@ -0,0 +1,127 @@
+// Package pluginrpc holds what the plugin binaries share around their request
+// loops: the gate every request passes before it reaches a provider, the
+// methods the process answers itself, and the responses written to stdout.
+package pluginrpc
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/opsorch/opsorch-github-adapter/internal/apiversion"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/httpdump"
+	"github.com/opsorch/opsorch-github-adapter/internal/limits"
+	"github.com/opsorch/opsorch-github-adapter/internal/redact"
+)
+
+// Request is one newline-delimited request read from stdin.
+type Request struct {
+	Method  string          `json:"method"`
+	Profile string          `json:"profile,omitempty"` // Entry of the config's "profiles" map to serve the request with
+	Config  map[string]any  `json:"config"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Response is one newline-delimited response written to stdout.
+type Response struct {
+	Result        any    `json:"result,omitempty"`
+	Error         string `json:"error,omitempty"`
+	Partial       bool   `json:"partial,omitempty"`
+	NextPageToken string `json:"nextPageToken,omitempty"` // Continuation token of a query, passed back in metadata "pageToken"
+	HasMore       bool   `json:"hasMore,omitempty"`       // A query has results after this page
+	TotalCount    *int   `json:"totalCount,omitempty"`    // Size of a query's whole result set, when known
+	Truncated     bool   `json:"truncated,omitempty"`     // A query stopped early at its API call budget
+}
+
+// PayloadError reports a payload that does not decode into what the method
+// takes. Its message is the decoder's.
+type PayloadError struct {
+	Err error
+}
+
+func (e *PayloadError) Error() string { return e.Err.Error() }
+
+func (e *PayloadError) Unwrap() error { return e.Err }
+
+// Check refuses a request that the "allowedMethods" allowlist or the
+// "maxPayloadBytes" limit of cfg, or of the named profile, does not permit. It
+// comes first, so a refused request never reaches GitHub.
+func Check(cfg map[string]any, profile, method string, size int) error {
+	if err := ghconfig.AllowMethod(cfg, profile, method); err != nil {
+		return err
+	}
+	return limits.Size(cfg, profile, size)
+}
+
+// Global answers the methods the process serves rather than a provider. Dump
+// settings are process-wide, so they can change before a provider exists, and
+// deprecation notices outlive any one provider. ok is false for other methods.
+func Global(method string, payload json.RawMessage) (result any, ok bool, err error) {
+	switch method {
+	case "debug.httpDump":
+		var opts httpdump.Options
+		if err := json.Unmarshal(payload, &opts); err != nil {
+			return nil, true, &PayloadError{Err: err}
+		}
+		if err := httpdump.Default.Configure(opts); err != nil {
+			return nil, true, err
+		}
+		return httpdump.Default.Options(), true, nil
+	case "plugin.health":
+		return apiversion.Default.Health(), true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// Gate answers the requests a plugin does not dispatch to a provider: those
+// Check refuses and those Global serves. It reports whether it wrote a response
+// to w.
+func Gate(w io.Writer, req Request) bool {
+	if err := Check(req.Config, req.Profile, req.Method, len(req.Payload)); err != nil {
+		WriteError(w, err)
+		return true
+	}
+	result, ok, err := Global(req.Method, req.Payload)
+	if !ok {
+		return false
+	}
+	if err != nil {
+		WriteError(w, err)
+	} else {
+		WriteOK(w, result)
+	}
+	return true
+}
+
+// WriteOK answers a request with its result.
+func WriteOK(w io.Writer, result any) {
+	write(w, Response{Result: result})
+}
+
+// WritePartial writes a result ahead of the final response, as streamed
+// watches do.
+func WritePartial(w io.Writer, result any) {
+	write(w, Response{Result: result, Partial: true})
+}
+
+// WritePage answers a query with its results, continuation token, and total,
+// and whether the API call budget cut it short.
+func WritePage(w io.Writer, result any, nextPageToken string, totalCount *int, truncated bool) {
+	write(w, Response{
+		Result:        result,
+		NextPageToken: nextPageToken,
+		HasMore:       nextPageToken != "",
+		TotalCount:    totalCount,
+		Truncated:     truncated,
+	})
+}
+
+// WriteError answers a request with err, its secrets masked.
+func WriteError(w io.Writer, err error) {
+	write(w, Response{Error: redact.Default.String(err.Error())})
+}
+
+func write(w io.Writer, resp Response) {
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,58 @@
+package pluginrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGate(t *testing.T) {
+	cfg := map[string]any{"allowedMethods": []any{"ticket.get", "debug.httpDump"}, "maxPayloadBytes": 64}
+
+	tests := []struct {
+		name    string
+		req     Request
+		handled bool
+		want    string
+	}{
+		{"allowed", Request{Method: "ticket.get", Config: cfg, Payload: json.RawMessage(`{"id":"1"}`)}, false, ""},
+		{"refused", Request{Method: "ticket.create", Config: cfg}, true, `{"error":"forbidden: method ticket.create is not in this plugin's allowedMethods"}`},
+		{"too large", Request{Method: "ticket.get", Config: cfg, Payload: json.RawMessage(`"` + strings.Repeat("x", 64) + `"`)}, true, `{"error":"bad_request: payload is 66 bytes, over the maxPayloadBytes limit of 64"}`},
+		{"health", Request{Method: "plugin.health", Config: cfg}, true, `{"result":{"status":"ok","warnings":[],"deprecations":[]}}`},
+		{"dump", Request{Method: "debug.httpDump", Config: cfg, Payload: json.RawMessage(`{"enabled":false}`)}, true, `{"result":{"enabled":false,"bodies":false}}`},
+		{"bad dump", Request{Method: "debug.httpDump", Config: cfg, Payload: json.RawMessage(`{"enabled":"yes"}`)}, true, `{"error":"json: cannot unmarshal string into Go struct field Options.enabled of type bool"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if handled := Gate(&out, tt.req); handled != tt.handled {
+				t.Fatalf("Gate() = %v, want %v", handled, tt.handled)
+			}
+			if got := strings.TrimSpace(out.String()); got != tt.want {
+				t.Errorf("Gate() wrote %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlobalPayloadError(t *testing.T) {
+	_, ok, err := Global("debug.httpDump", json.RawMessage(`[]`))
+	var payloadErr *PayloadError
+	if !ok || !errors.As(err, &payloadErr) {
+		t.Errorf("Global() = %v, %v, want a PayloadError", ok, err)
+	}
+	if _, ok, _ := Global("ticket.get", nil); ok {
+		t.Error("Global() answered a provider method")
+	}
+}
+
+func TestWritePage(t *testing.T) {
+	var out bytes.Buffer
+	total := 3
+	WritePage(&out, []int{1}, "next", &total, true)
+	if got, want := strings.TrimSpace(out.String()), `{"result":[1],"nextPageToken":"next","hasMore":true,"totalCount":3,"truncated":true}`; got != want {
+		t.Errorf("WritePage() wrote %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+// Package redact scrubs secrets from text leaving the adapter: log lines,
+// HTTP dumps, and the error messages plugins answer with. GitHub tokens,
+// Authorization headers, and sensitive query parameters are always masked;
+// the configured tokens and "redactPatterns", such as email addresses or
+// internal hostnames, are masked once a provider registers them with Default.
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+// Redacted replaces every masked value.
+const Redacted = "REDACTED"
+
+// builtin are masked by every redactor. Each keeps its first group, the text
+// naming the secret, and masks the rest of the match.
+var builtin = []*regexp.Regexp{
+	// Authorization and Proxy-Authorization headers, with or without a scheme
+	regexp.MustCompile(`(?i)(\b(?:proxy-)?authorization["']?\s*[:=]\s*["']?)(?:(?:bearer|token|basic)\s+)?[^\s"',;]+`),
+	// GitHub personal access, OAuth, app, and refresh tokens
+	regexp.MustCompile(`()\b(?:gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})`),
+	// Query parameters carrying credentials
+	regexp.MustCompile(`(?i)([?&](?:access_token|token|client_secret|code)=)[^&\s"'#]+`),
+}
+
+// Default is the process-wide redactor. Providers register their secrets and
+// patterns with it as they are built, and the plugins pass their log output and
+// error responses through it.
+var Default = New()
+
+// Redactor masks secrets and pattern matches in text. It is safe for
+// concurrent use.
+type Redactor struct {
+	mu       sync.RWMutex
+	secrets  []string
+	patterns []*regexp.Regexp
+	seen     map[string]bool // Secrets and patterns already added
+}
+
+// New returns a redactor that masks only the built-in secrets.
+func New() *Redactor {
+	return &Redactor{seen: map[string]bool{}}
+}
+
+// AddSecrets masks every occurrence of the given literal values. Empty values
+// are ignored.
+func (r *Redactor) AddSecrets(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range secrets {
+		if s == "" || r.seen["secret:"+s] {
+			continue
+		}
+		r.seen["secret:"+s] = true
+		r.secrets = append(r.secrets, s)
+	}
+	// Longer secrets first, so one containing another is masked whole
+	sort.SliceStable(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+}
+
+// AddPatterns masks every match of the given regular expressions, rejecting
+// invalid ones before adding any.
+func (r *Redactor) AddPatterns(patterns ...string) error {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("redactPatterns: invalid pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, re := range compiled {
+		if r.seen["pattern:"+re.String()] {
+			continue
+		}
+		r.seen["pattern:"+re.String()] = true
+		r.patterns = append(r.patterns, re)
+	}
+	return nil
+}
+
+// String returns s with every secret and pattern match masked.
+func (r *Redactor) String(s string) string {
+	for _, re := range builtin {
+		s = re.ReplaceAllString(s, "${1}"+Redacted)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllLiteralString(s, Redacted)
+	}
+	return s
+}
+
+// Error returns err with its message masked. OpsOrch errors keep their code;
+// other errors become plain errors with the masked message. An error with
+// nothing to mask is returned as is.
+func (r *Redactor) Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := r.String(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	var oe *orcherr.OpsOrchError
+	if errors.As(err, &oe) {
+		return &orcherr.OpsOrchError{Code: oe.Code, Message: r.String(oe.Message)}
+	}
+	return errors.New(msg)
+}
+
+// Writer returns a writer that masks each write before passing it to w. Log
+// packages write one line per call, so secrets are never split across writes.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return &writer{r: r, w: w}
+}
+
+type writer struct {
+	r *Redactor
+	w io.Writer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.r.String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
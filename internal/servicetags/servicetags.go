@@ -0,0 +1,67 @@
+// Package servicetags flattens the service metadata GitHub keeps on a
+// repository — its topics and its organization's custom properties — into
+// OpsOrch service tags, so the service catalog and deployments carry the same
+// tags.
+package servicetags
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+// TopicPrefix starts the tag of each repository topic: topic "payments" is the
+// tag "topic:payments" with value "true". Custom properties are tagged with
+// their own names, which cannot contain ':'.
+const TopicPrefix = "topic:"
+
+// Tags returns the tags of a repository with the given topics and custom
+// property values. Unset properties are left out, and the values of a
+// multi-select property are joined with commas.
+func Tags(topics []string, properties []*ghapi.PropertyValue) map[string]string {
+	tags := make(map[string]string, len(topics)+len(properties))
+	for _, topic := range topics {
+		tags[TopicPrefix+topic] = "true"
+	}
+	for _, property := range properties {
+		if value, ok := Value(property.Value); ok {
+			tags[property.Name] = value
+		}
+	}
+	return tags
+}
+
+// Value returns a custom property value as a tag value, and false when it is
+// unset.
+func Value(v any) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case []string:
+		return strings.Join(v, ","), true
+	case []any:
+		values := make([]string, len(v))
+		for i, item := range v {
+			values[i] = fmt.Sprint(item)
+		}
+		return strings.Join(values, ","), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// Topics returns the topics among tags, sorted.
+func Topics(tags map[string]string) []string {
+	var topics []string
+	for key := range tags {
+		if topic, ok := strings.CutPrefix(key, TopicPrefix); ok {
+			topics = append(topics, topic)
+		}
+	}
+	sort.Strings(topics)
+	return topics
+}
@@ -0,0 +1,28 @@
+package servicetags
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+func TestTags(t *testing.T) {
+	tags := Tags([]string{"payments", "tier-1"}, []*ghapi.PropertyValue{
+		{Name: "owner", Value: "team-payments"},
+		{Name: "regions", Value: []any{"eu", "us"}},
+		{Name: "pager", Value: nil},
+	})
+	want := map[string]string{
+		"topic:payments": "true",
+		"topic:tier-1":   "true",
+		"owner":          "team-payments",
+		"regions":        "eu,us",
+	}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("Tags() = %v, want %v", tags, want)
+	}
+	if topics := Topics(tags); !reflect.DeepEqual(topics, []string{"payments", "tier-1"}) {
+		t.Errorf("Topics() = %v", topics)
+	}
+}
@@ -0,0 +1,223 @@
+// Package throttle queues GitHub API calls so bursts from the orchestrator do
+// not trip GitHub's secondary rate limits. At most a configured number of calls
+// run at once; the rest wait in a bounded queue, served by priority (Get before
+// Query before enrichment) and in arrival order within a priority. A call that
+// finds the queue full fails at once with an Error suggesting when to retry.
+//
+// Every client built by the providers routes through Default, which is
+// unlimited until configured.
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Priority orders queued calls; higher priorities are served first.
+type Priority int
+
+// Priorities of the calls a query makes. Calls default to Query.
+const (
+	Enrichment Priority = iota // Per-item lookups that add detail to results
+	Query                      // Lists and searches
+	Get                        // Reads of a single record
+	numPriorities
+)
+
+// defaultLatency stands in for the call latency before any call finished.
+const defaultLatency = time.Second
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose API calls queue at priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityOf(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return Query
+}
+
+// Error is returned in place of a call the full queue turned away.
+type Error struct {
+	RetryAfter time.Duration // Suggested delay before retrying
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("GitHub request queue is full, retry after %s", e.RetryAfter)
+}
+
+// Options bounds the calls in flight and waiting.
+type Options struct {
+	Concurrency int `json:"concurrency"` // Calls run at once; 0 means unlimited
+	Depth       int `json:"depth"`       // Calls that may wait for a slot
+}
+
+// Default is the process-wide queue wrapped around every provider client.
+var Default = New()
+
+// Queue admits API calls under its Options.
+type Queue struct {
+	mu      sync.Mutex
+	opts    Options
+	active  int
+	waiting [numPriorities][]chan struct{}
+	latency time.Duration // Moving average of call durations
+}
+
+// New creates an unlimited queue.
+func New() *Queue {
+	return &Queue{}
+}
+
+// Configure applies opts. Calls already running or waiting keep their place.
+func (q *Queue) Configure(opts Options) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.opts = opts
+	for q.opts.Concurrency <= 0 || q.active < q.opts.Concurrency {
+		if !q.grant() {
+			break
+		}
+		q.active++
+	}
+}
+
+// Options returns the current settings.
+func (q *Queue) Options() Options {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.opts
+}
+
+// Wrap returns a transport that admits every request through base via q.
+func (q *Queue) Wrap(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{queue: q, base: base}
+}
+
+type transport struct {
+	queue *Queue
+	base  http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limited, err := t.queue.acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	if !limited {
+		return t.base.RoundTrip(req)
+	}
+	start := time.Now()
+	defer func() { t.queue.release(time.Since(start)) }()
+	return t.base.RoundTrip(req)
+}
+
+// acquire waits for a slot, reporting whether the call holds one and must
+// release it.
+func (q *Queue) acquire(ctx context.Context) (bool, error) {
+	q.mu.Lock()
+	if q.opts.Concurrency <= 0 {
+		q.mu.Unlock()
+		return false, nil
+	}
+	if q.active < q.opts.Concurrency {
+		q.active++
+		q.mu.Unlock()
+		return true, nil
+	}
+	if q.queued() >= q.opts.Depth {
+		err := &Error{RetryAfter: q.retryAfter()}
+		q.mu.Unlock()
+		return false, err
+	}
+	p := priorityOf(ctx)
+	ready := make(chan struct{})
+	q.waiting[p] = append(q.waiting[p], ready)
+	q.mu.Unlock()
+
+	select {
+	case <-ready:
+		return true, nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		for i, ch := range q.waiting[p] {
+			if ch == ready {
+				q.waiting[p] = append(q.waiting[p][:i], q.waiting[p][i+1:]...)
+				return false, ctx.Err()
+			}
+		}
+		// The slot was granted as the context ended; pass it on
+		q.handOff()
+		return false, ctx.Err()
+	}
+}
+
+// release returns a slot after a call that took d.
+func (q *Queue) release(d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.latency == 0 {
+		q.latency = d
+	} else {
+		q.latency = (q.latency*7 + d) / 8
+	}
+	q.handOff()
+}
+
+// handOff gives a freed slot to the next waiting call, or frees it. Callers
+// must hold q.mu.
+func (q *Queue) handOff() {
+	if q.opts.Concurrency > 0 && q.active > q.opts.Concurrency {
+		q.active-- // The queue shrank; this slot is gone
+		return
+	}
+	if !q.grant() {
+		q.active--
+	}
+}
+
+// grant wakes the first waiting call of the highest priority, reporting whether
+// there was one. Callers must hold q.mu.
+func (q *Queue) grant() bool {
+	for p := numPriorities - 1; p >= 0; p-- {
+		if len(q.waiting[p]) > 0 {
+			close(q.waiting[p][0])
+			q.waiting[p] = q.waiting[p][1:]
+			return true
+		}
+	}
+	return false
+}
+
+// queued returns the number of waiting calls. Callers must hold q.mu.
+func (q *Queue) queued() int {
+	n := 0
+	for _, w := range q.waiting {
+		n += len(w)
+	}
+	return n
+}
+
+// retryAfter estimates how long the queue takes to drain, in whole seconds.
+// Callers must hold q.mu.
+func (q *Queue) retryAfter() time.Duration {
+	latency := q.latency
+	if latency == 0 {
+		latency = defaultLatency
+	}
+	rounds := float64(q.queued())/float64(q.opts.Concurrency) + 1
+	seconds := math.Ceil((time.Duration(rounds * float64(latency))).Seconds())
+	return time.Duration(max(seconds, 1)) * time.Second
+}
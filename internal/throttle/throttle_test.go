@@ -0,0 +1,132 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// hold occupies q's only slot until the returned function is called.
+func hold(t *testing.T, q *Queue) func() {
+	t.Helper()
+	limited, err := q.acquire(context.Background())
+	if err != nil || !limited {
+		t.Fatalf("acquire() = %v, %v", limited, err)
+	}
+	return func() { q.release(10 * time.Millisecond) }
+}
+
+// waitQueued waits until n calls wait in q.
+func waitQueued(t *testing.T, q *Queue, n int) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		q.mu.Lock()
+		got := q.queued()
+		q.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d calls never queued", n)
+}
+
+func TestPriorityOrder(t *testing.T) {
+	q := New()
+	q.Configure(Options{Concurrency: 1, Depth: 10})
+	done := hold(t, q)
+
+	var mu sync.Mutex
+	var order []Priority
+	var wg sync.WaitGroup
+	for i, p := range []Priority{Enrichment, Query, Get} {
+		wg.Add(1)
+		go func(p Priority) {
+			defer wg.Done()
+			if _, err := q.acquire(WithPriority(context.Background(), p)); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, p)
+			mu.Unlock()
+			q.release(time.Millisecond)
+		}(p)
+		waitQueued(t, q, i+1)
+	}
+	done()
+	wg.Wait()
+
+	if len(order) != 3 || order[0] != Get || order[1] != Query || order[2] != Enrichment {
+		t.Errorf("served in order %v, want Get, Query, Enrichment", order)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	q := New()
+	q.Configure(Options{Concurrency: 1, Depth: 1})
+	done := hold(t, q)
+	defer done()
+
+	go q.acquire(context.Background())
+	waitQueued(t, q, 1)
+
+	_, err := q.acquire(context.Background())
+	var queueErr *Error
+	if !errors.As(err, &queueErr) || queueErr.RetryAfter < time.Second {
+		t.Fatalf("acquire() on a full queue error = %v", err)
+	}
+}
+
+func TestCanceledWait(t *testing.T) {
+	q := New()
+	q.Configure(Options{Concurrency: 1, Depth: 5})
+	done := hold(t, q)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		_, err := q.acquire(ctx)
+		errc <- err
+	}()
+	waitQueued(t, q, 1)
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("acquire() error = %v, want canceled", err)
+	}
+
+	// The canceled call left the queue, so the slot is free once released
+	done()
+	if limited, err := q.acquire(context.Background()); err != nil || !limited {
+		t.Errorf("acquire() after release = %v, %v", limited, err)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer srv.Close()
+
+	// Unconfigured queues admit everything
+	q := New()
+	client := &http.Client{Transport: q.Wrap(nil)}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	q.Configure(Options{Concurrency: 1, Depth: 0})
+	done := hold(t, q)
+	defer done()
+	_, err := client.Get(srv.URL)
+	var queueErr *Error
+	if !errors.As(err, &queueErr) {
+		t.Errorf("GET with the slot taken error = %v, want a queue error", err)
+	}
+}
@@ -0,0 +1,30 @@
+// Package timefmt normalizes the timestamps of returned records. go-github
+// keeps the offset GitHub sent, so commit dates arrive in their author's zone
+// while most others are UTC, and times read from the clock carry nanoseconds.
+// Normalized timestamps are UTC with whole seconds, so every one marshals as
+// RFC 3339 in the same form.
+package timefmt
+
+import "time"
+
+// MillisSuffix ends the Fields key of a timestamp's epoch milliseconds, which
+// records carry when "epochMillis" is set: "closed_at" is also
+// "closed_at_ms".
+const MillisSuffix = "_ms"
+
+// UTC returns t in UTC, truncated to whole seconds. The zero time stays zero.
+func UTC(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.UTC().Truncate(time.Second)
+}
+
+// SetMillis sets fields[name+MillisSuffix] to t in milliseconds since the Unix
+// epoch, unless t is zero.
+func SetMillis(fields map[string]any, name string, t time.Time) {
+	if t.IsZero() || fields == nil {
+		return
+	}
+	fields[name+MillisSuffix] = t.UnixMilli()
+}
@@ -0,0 +1,27 @@
+package timefmt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestUTC(t *testing.T) {
+	local := time.Date(2024, 3, 1, 12, 30, 0, 500, time.FixedZone("CET", 3600))
+	data, _ := json.Marshal(UTC(local))
+	if string(data) != `"2024-03-01T11:30:00Z"` {
+		t.Errorf("UTC() marshals as %s", data)
+	}
+	if !UTC(time.Time{}).IsZero() {
+		t.Error("UTC() of the zero time is not zero")
+	}
+}
+
+func TestSetMillis(t *testing.T) {
+	fields := map[string]any{}
+	SetMillis(fields, "closed_at", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	SetMillis(fields, "updated_at", time.Time{})
+	if len(fields) != 1 || fields["closed_at_ms"] != int64(1709251200000) {
+		t.Errorf("fields = %v", fields)
+	}
+}
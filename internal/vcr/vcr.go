@@ -0,0 +1,256 @@
+// Package vcr records GitHub API traffic to cassette files and replays it, so the
+// integration scenarios can run deterministically without live credentials.
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Environment variables that select the recorder mode and cassette location.
+const (
+	EnvMode     = "GITHUB_VCR_MODE"
+	EnvCassette = "GITHUB_VCR_CASSETTE"
+)
+
+// Mode controls whether the recorder talks to GitHub.
+type Mode string
+
+const (
+	// ModeOff passes requests straight through without recording.
+	ModeOff Mode = ""
+	// ModeRecord passes requests through and saves every interaction.
+	ModeRecord Mode = "record"
+	// ModeReplay serves interactions from the cassette and never touches the network.
+	ModeReplay Mode = "replay"
+)
+
+// redacted replaces secrets in recorded interactions.
+const redacted = "REDACTED"
+
+// Response headers kept in cassettes; everything else is dropped.
+var keptHeaders = []string{"Content-Type", "Link", "X-Ratelimit-Limit", "X-Ratelimit-Remaining", "X-Ratelimit-Reset"}
+
+// Interaction is a single recorded request and its response.
+type Interaction struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Body    string            `json:"body,omitempty"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Reply   json.RawMessage   `json:"reply,omitempty"` // JSON response body
+	Text    string            `json:"text,omitempty"`  // Non-JSON response body
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+type Recorder struct {
+	mode      Mode
+	path      string
+	secrets   []string
+	transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// New creates a recorder for the cassette at path. Occurrences of secrets are
+// replaced before anything is written. In replay mode the cassette must exist.
+func New(mode Mode, path string, secrets ...string) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path, transport: http.DefaultTransport}
+	for _, s := range secrets {
+		if s != "" {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+
+	switch mode {
+	case ModeOff, ModeRecord:
+	case ModeReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("vcr: reading cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("vcr: decoding cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.interactions))
+	default:
+		return nil, fmt.Errorf("vcr: unknown mode %q (use record or replay)", mode)
+	}
+	return r, nil
+}
+
+// FromEnv creates a recorder from GITHUB_VCR_MODE, using GITHUB_VCR_CASSETTE or
+// defaultPath for the cassette.
+func FromEnv(defaultPath string, secrets ...string) (*Recorder, error) {
+	path := strings.TrimSpace(os.Getenv(EnvCassette))
+	if path == "" {
+		path = defaultPath
+	}
+	return New(Mode(strings.ToLower(strings.TrimSpace(os.Getenv(EnvMode)))), path, secrets...)
+}
+
+// Mode reports the recorder mode.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an HTTP client that routes through the recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	switch r.mode {
+	case ModeReplay:
+		return r.replay(req)
+	case ModeRecord:
+		return r.record(req, body)
+	default:
+		return r.transport.RoundTrip(req)
+	}
+}
+
+// Save writes recorded interactions to the cassette. It is a no-op outside record mode.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(reply))
+
+	in := Interaction{
+		Method:  req.Method,
+		URL:     r.redact(req.URL.String()),
+		Body:    r.redact(string(body)),
+		Status:  resp.StatusCode,
+		Headers: map[string]string{},
+	}
+	for _, h := range keptHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			in.Headers[h] = r.redact(v)
+		}
+	}
+	if len(reply) > 0 {
+		clean := []byte(r.redact(string(reply)))
+		if json.Valid(clean) {
+			in.Reply = clean
+		} else {
+			in.Text = string(clean)
+		}
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, in)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replay serves the first unused interaction with the same method and URL. When
+// nothing matches exactly, an interaction for the same path is used, which keeps
+// scenarios with time-based query parameters replayable.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	key := r.redact(req.URL.String())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, in := range r.interactions {
+		if !r.used[i] && in.Method == req.Method && in.URL == key {
+			match = i
+			break
+		}
+	}
+	if match < 0 {
+		for i, in := range r.interactions {
+			if !r.used[i] && in.Method == req.Method && pathOf(in.URL) == req.URL.Path {
+				match = i
+				break
+			}
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("vcr: no recorded interaction for %s %s", req.Method, key)
+	}
+	r.used[match] = true
+
+	in := r.interactions[match]
+	reply := []byte(in.Reply)
+	if in.Text != "" {
+		reply = []byte(in.Text)
+	}
+	resp := &http.Response{
+		Status:     fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+		StatusCode: in.Status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       io.NopCloser(bytes.NewReader(reply)),
+		Request:    req,
+	}
+	for k, v := range in.Headers {
+		resp.Header.Set(k, v)
+	}
+	resp.ContentLength = int64(len(reply))
+	return resp, nil
+}
+
+func (r *Recorder) redact(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+// pathOf returns the path component of a recorded URL.
+func pathOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
@@ -0,0 +1,87 @@
+package vcr
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	const token = "ghp_secret123"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Write([]byte(`{"path": "` + r.URL.Path + `", "echo": "` + token + `"}`))
+	}))
+	defer server.Close()
+
+	cassette := filepath.Join(t.TempDir(), "cassettes", "test.json")
+
+	rec, err := New(ModeRecord, cassette, token)
+	if err != nil {
+		t.Fatalf("New(record) error = %v", err)
+	}
+	for _, path := range []string{"/repos/acme/api/issues?since=1", "/repos/acme/api/issues/1"} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := rec.Client().Do(req)
+		if err != nil {
+			t.Fatalf("record %s: %v", path, err)
+		}
+		resp.Body.Close()
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(cassette)
+	if strings.Contains(string(data), token) {
+		t.Errorf("cassette contains the token: %s", data)
+	}
+	if strings.Contains(string(data), "session=abc") {
+		t.Errorf("cassette contains dropped headers: %s", data)
+	}
+
+	server.Close()
+
+	replay, err := New(ModeReplay, cassette, token)
+	if err != nil {
+		t.Fatalf("New(replay) error = %v", err)
+	}
+
+	// Exact match
+	resp, err := replay.Client().Get(server.URL + "/repos/acme/api/issues/1")
+	if err != nil {
+		t.Fatalf("replay error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `"/repos/acme/api/issues/1"`) {
+		t.Errorf("unexpected replay: %d %s", resp.StatusCode, body)
+	}
+	if resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q", resp.Header.Get("Content-Type"))
+	}
+
+	// Path match with a different query
+	if _, err := replay.Client().Get(server.URL + "/repos/acme/api/issues?since=2"); err != nil {
+		t.Errorf("path fallback error = %v", err)
+	}
+
+	// Interactions are used once
+	if _, err := replay.Client().Get(server.URL + "/repos/acme/api/issues/1"); err == nil {
+		t.Error("expected error once the interaction is used up")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	if _, err := New(ModeReplay, filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing cassette")
+	}
+	if _, err := New("rewind", "x.json"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
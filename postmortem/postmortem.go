@@ -0,0 +1,355 @@
+// Package postmortem drafts a postmortem document from an incident issue: its
+// timeline of comments, the deployments linked to it, and the people who took
+// part. The document is committed to a docs repository through the change
+// provider, or proposed there as a pull request for the team to fill in.
+package postmortem
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/change"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/ticket"
+)
+
+// DefaultPath is where documents are written when the config gives no path.
+const DefaultPath = "postmortems/{{ date }}-{{ id }}.md"
+
+// placeholderPattern matches {{ name }} placeholders in path templates.
+var placeholderPattern = regexp.MustCompile(`{{\s*([\w.-]+)\s*}}`)
+
+// markerPattern matches the HTML comment markers the adapter leaves in comments.
+var markerPattern = regexp.MustCompile(`<!--.*?-->`)
+
+// Tickets is the subset of the ticket provider Generate uses.
+type Tickets interface {
+	Get(ctx context.Context, id string) (schema.Ticket, error)
+	Comments(ctx context.Context, id string) ([]ticket.Comment, error)
+}
+
+// Changes is the subset of the change provider, configured for the docs
+// repository, Generate uses.
+type Changes interface {
+	WriteFile(ctx context.Context, input change.WriteInput) (change.Commit, error)
+	CreatePullRequest(ctx context.Context, input change.PullRequestInput) (change.PullRequest, error)
+}
+
+// Config holds the configuration for postmortem documents.
+type Config struct {
+	Path        string   `json:"path"`        // Path template of documents, with {{ id }}, {{ date }}, and {{ slug }}
+	PullRequest bool     `json:"pullRequest"` // Open a pull request instead of committing to the default branch
+	Base        string   `json:"base"`        // Branch pull requests merge into; defaults to the default branch
+	Labels      []string `json:"labels"`      // Labels added to pull requests
+	Reviewers   []string `json:"reviewers"`   // Logins asked to review pull requests
+}
+
+// Generator drafts postmortems from incident issues into a docs repository.
+type Generator struct {
+	tickets Tickets
+	changes Changes
+	config  Config
+}
+
+// Input selects the incident to draft a postmortem for.
+type Input struct {
+	Ticket      string         `json:"ticket"`                // Issue number of the incident
+	Path        string         `json:"path,omitempty"`        // Overrides the configured path template
+	PullRequest *bool          `json:"pullRequest,omitempty"` // Overrides the configured pullRequest
+	Metadata    map[string]any `json:"metadata,omitempty"`
+}
+
+// Result is the drafted document and where it was written. Commit is set when
+// the document was committed directly, PullRequest when it was proposed.
+type Result struct {
+	Ticket      string              `json:"ticket"`
+	Path        string              `json:"path"`
+	Document    string              `json:"document"`
+	Commit      *change.Commit      `json:"commit,omitempty"`
+	PullRequest *change.PullRequest `json:"pullRequest,omitempty"`
+}
+
+// New creates a generator from the "postmortem" config object. changes writes
+// to the docs repository.
+func New(cfg map[string]any, tickets Tickets, changes Changes) (*Generator, error) {
+	if tickets == nil {
+		return nil, fmt.Errorf("ticket provider is required")
+	}
+	if changes == nil {
+		return nil, fmt.Errorf("change provider is required")
+	}
+
+	config := Config{
+		Path:        ghconfig.String(cfg, "path"),
+		PullRequest: ghconfig.Bool(cfg, "pullRequest"),
+		Base:        ghconfig.String(cfg, "base"),
+		Labels:      ghconfig.StringSlice(cfg, "labels"),
+		Reviewers:   ghconfig.StringSlice(cfg, "reviewers"),
+	}
+	if config.Path == "" {
+		config.Path = DefaultPath
+	}
+
+	return &Generator{
+		tickets: tickets,
+		changes: changes,
+		config:  config,
+	}, nil
+}
+
+// Generate drafts the postmortem of an incident issue and commits it to the
+// docs repository, or opens a pull request with it on a postmortem/ branch.
+// Committing a document that already exists replaces it. Dry runs follow the
+// change provider: with metadata dryRun, or under its readOnly, the write is
+// logged and the document still returned.
+func (g *Generator) Generate(ctx context.Context, input Input) (Result, error) {
+	if strings.TrimSpace(input.Ticket) == "" {
+		return Result{}, &orcherr.OpsOrchError{Code: "bad_request", Message: "ticket is required"}
+	}
+	incident, err := g.tickets.Get(ctx, input.Ticket)
+	if err != nil {
+		return Result{}, err
+	}
+	comments, err := g.tickets.Comments(ctx, input.Ticket)
+	if err != nil {
+		return Result{}, err
+	}
+
+	template := g.config.Path
+	if input.Path != "" {
+		template = input.Path
+	}
+	path, err := renderPath(template, incident)
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Ticket: input.Ticket, Path: path, Document: Document(incident, comments)}
+
+	message := "Add postmortem for " + incidentName(incident)
+	pullRequest := g.config.PullRequest
+	if input.PullRequest != nil {
+		pullRequest = *input.PullRequest
+	}
+	if !pullRequest {
+		commit, err := g.changes.WriteFile(ctx, change.WriteInput{
+			Path:     path,
+			Content:  result.Document,
+			Message:  message,
+			Metadata: input.Metadata,
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		result.Commit = &commit
+		return result, nil
+	}
+
+	pr, err := g.changes.CreatePullRequest(ctx, change.PullRequestInput{
+		Title:     "Postmortem: " + incident.Title,
+		Body:      fmt.Sprintf("Draft postmortem for %s, generated from the incident's timeline. Fill in the root cause and action items before merging.", incidentLink(incident)),
+		Branch:    "postmortem/" + slug(incident.ID),
+		Base:      g.config.Base,
+		Files:     []change.FileEdit{{Path: path, Content: result.Document}},
+		Message:   message,
+		Labels:    g.config.Labels,
+		Reviewers: g.config.Reviewers,
+		Metadata:  input.Metadata,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	result.PullRequest = &pr
+	return result, nil
+}
+
+// Document renders the postmortem of an incident as Markdown. Comments that
+// link a deployment are listed under Deployments; the others make up the
+// timeline, between the incident opening and closing. Sections the timeline
+// cannot fill are left for the team to complete.
+func Document(incident schema.Ticket, comments []ticket.Comment) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Postmortem: %s\n\n", incident.Title)
+
+	closedAt, closed := closedAt(incident)
+	b.WriteString("| | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Incident | %s |\n", incidentLink(incident))
+	fmt.Fprintf(&b, "| Status | %s |\n", incident.Status)
+	if severity, _ := incident.Fields["severity"].(string); severity != "" {
+		fmt.Fprintf(&b, "| Severity | %s |\n", severity)
+	}
+	fmt.Fprintf(&b, "| Opened | %s |\n", formatTime(incident.CreatedAt))
+	if closed {
+		fmt.Fprintf(&b, "| Closed | %s |\n", formatTime(closedAt))
+		fmt.Fprintf(&b, "| Duration | %s |\n", closedAt.Sub(incident.CreatedAt).Round(time.Minute))
+	}
+
+	b.WriteString("\n## Summary\n\n")
+	if summary := strings.TrimSpace(markerPattern.ReplaceAllString(incident.Description, "")); summary != "" {
+		b.WriteString(summary + "\n")
+	} else {
+		b.WriteString("_To be completed._\n")
+	}
+
+	var deployments []string
+	b.WriteString("\n## Timeline\n\n")
+	fmt.Fprintf(&b, "- **%s** Incident opened%s\n", formatTime(incident.CreatedAt), by(incident.Reporter))
+	for _, c := range comments {
+		if _, text, ok := ticket.LinkedDeployment(c); ok {
+			deployments = append(deployments, text)
+			continue
+		}
+		text := strings.Join(strings.Fields(markerPattern.ReplaceAllString(c.Body, "")), " ")
+		if text == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "- **%s** %s: %s\n", formatTime(c.CreatedAt), mention(c.Author), text)
+	}
+	if closed {
+		fmt.Fprintf(&b, "- **%s** Incident closed%s\n", formatTime(closedAt), by(stringField(incident.Fields, "closed_by")))
+	}
+
+	b.WriteString("\n## Deployments\n\n")
+	if len(deployments) == 0 {
+		b.WriteString("No deployments were linked to the incident.\n")
+	}
+	for _, d := range deployments {
+		fmt.Fprintf(&b, "- %s\n", strings.TrimPrefix(d, "Deployed in "))
+	}
+
+	b.WriteString("\n## Participants\n\n")
+	for _, login := range participants(incident, comments) {
+		fmt.Fprintf(&b, "- %s\n", mention(login))
+	}
+
+	b.WriteString("\n## Root Cause\n\n_To be completed._\n")
+	b.WriteString("\n## Action Items\n\n- [ ] _To be completed._\n")
+	return b.String()
+}
+
+// participants returns the reporter, assignees, and commenters of an incident,
+// sorted and without bots.
+func participants(incident schema.Ticket, comments []ticket.Comment) []string {
+	seen := map[string]bool{}
+	add := func(login string) {
+		if login != "" && !strings.HasSuffix(login, "[bot]") {
+			seen[login] = true
+		}
+	}
+	add(incident.Reporter)
+	for _, login := range incident.Assignees {
+		add(login)
+	}
+	for _, c := range comments {
+		if _, _, ok := ticket.LinkedDeployment(c); !ok {
+			add(c.Author)
+		}
+	}
+	logins := make([]string, 0, len(seen))
+	for login := range seen {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+	return logins
+}
+
+// renderPath fills the {{ id }}, {{ date }}, and {{ slug }} placeholders of a
+// path template: the issue number, the day the incident opened, and the
+// title made path-safe.
+func renderPath(template string, incident schema.Ticket) (string, error) {
+	values := map[string]string{
+		"id":   slug(incident.ID),
+		"date": incident.CreatedAt.UTC().Format(time.DateOnly),
+		"slug": slug(incident.Title),
+	}
+	var missing string
+	path := placeholderPattern.ReplaceAllStringFunc(template, func(m string) string {
+		name := placeholderPattern.FindStringSubmatch(m)[1]
+		value, ok := values[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("unknown placeholder %q in postmortem path (expected id, date, or slug)", missing),
+		}
+	}
+	return path, nil
+}
+
+// slug lower-cases s and replaces every run of characters other than letters
+// and digits with a hyphen.
+func slug(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// closedAt returns when the incident issue was closed, as the ticket provider
+// reports it in fields.closed_at.
+func closedAt(incident schema.Ticket) (time.Time, bool) {
+	switch v := incident.Fields["closed_at"].(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// incidentName returns how the incident is referred to, such as #42.
+func incidentName(incident schema.Ticket) string {
+	if strings.Contains(incident.ID, "#") {
+		return incident.ID
+	}
+	return "#" + incident.ID
+}
+
+// incidentLink returns the incident's name linked to its issue.
+func incidentLink(incident schema.Ticket) string {
+	if incident.URL == "" {
+		return incidentName(incident)
+	}
+	return fmt.Sprintf("[%s](%s)", incidentName(incident), incident.URL)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
+
+func mention(login string) string {
+	if login == "" {
+		return "someone"
+	}
+	return "@" + login
+}
+
+func by(login string) string {
+	if login == "" {
+		return ""
+	}
+	return " by " + mention(login)
+}
+
+func stringField(fields map[string]any, key string) string {
+	s, _ := fields[key].(string)
+	return s
+}
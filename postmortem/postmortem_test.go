@@ -0,0 +1,146 @@
+package postmortem
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/change"
+	"github.com/opsorch/opsorch-github-adapter/ticket"
+)
+
+var opened = time.Date(2024, 3, 1, 14, 2, 0, 0, time.UTC)
+
+type stubTickets struct{}
+
+func (stubTickets) Get(_ context.Context, id string) (schema.Ticket, error) {
+	if id != "42" {
+		return schema.Ticket{}, &orcherr.OpsOrchError{Code: "not_found", Message: "issue not found"}
+	}
+	return schema.Ticket{
+		ID:          "42",
+		Title:       "Checkout errors",
+		Description: "500s on /pay.\n<!-- opsorch-sync:state -->",
+		Status:      "closed",
+		Reporter:    "alice",
+		Assignees:   []string{"bob"},
+		URL:         "https://github.com/acme/api/issues/42",
+		CreatedAt:   opened,
+		Fields: map[string]any{
+			"severity":  "sev1",
+			"closed_at": opened.Add(95 * time.Minute),
+			"closed_by": "bob",
+		},
+	}, nil
+}
+
+func (stubTickets) Comments(context.Context, string) ([]ticket.Comment, error) {
+	return []ticket.Comment{
+		{Author: "bob", Body: "Rolling back\ncheckout.", CreatedAt: opened.Add(10 * time.Minute)},
+		{Author: "deploy-bot[bot]", Body: "<!-- opsorch-link:deployment:1234 -->\nDeployed in [1234](https://github.com/acme/api/actions/runs/1234) - status: success", CreatedAt: opened.Add(40 * time.Minute)},
+		{Author: "carol", Body: "<!-- opsorch-sync:timeline:7 -->\nError rate back to normal.", CreatedAt: opened.Add(90 * time.Minute)},
+	}, nil
+}
+
+type stubChanges struct {
+	writes []change.WriteInput
+	prs    []change.PullRequestInput
+}
+
+func (s *stubChanges) WriteFile(_ context.Context, input change.WriteInput) (change.Commit, error) {
+	s.writes = append(s.writes, input)
+	return change.Commit{Path: input.Path, Action: change.ActionCreate}, nil
+}
+
+func (s *stubChanges) CreatePullRequest(_ context.Context, input change.PullRequestInput) (change.PullRequest, error) {
+	s.prs = append(s.prs, input)
+	return change.PullRequest{Number: 7, Branch: input.Branch}, nil
+}
+
+func TestDocument(t *testing.T) {
+	incident, _ := stubTickets{}.Get(context.Background(), "42")
+	comments, _ := stubTickets{}.Comments(context.Background(), "42")
+	doc := Document(incident, comments)
+
+	for _, want := range []string{
+		"# Postmortem: Checkout errors\n",
+		"| Incident | [#42](https://github.com/acme/api/issues/42) |\n",
+		"| Severity | sev1 |\n",
+		"| Duration | 1h35m0s |\n",
+		"## Summary\n\n500s on /pay.\n",
+		"- **2024-03-01 14:02 UTC** Incident opened by @alice\n",
+		"- **2024-03-01 14:12 UTC** @bob: Rolling back checkout.\n",
+		"- **2024-03-01 15:32 UTC** @carol: Error rate back to normal.\n",
+		"- **2024-03-01 15:37 UTC** Incident closed by @bob\n",
+		"## Deployments\n\n- [1234](https://github.com/acme/api/actions/runs/1234) - status: success\n",
+		"## Participants\n\n- @alice\n- @bob\n- @carol\n\n",
+		"## Root Cause\n",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document lacks %q:\n%s", want, doc)
+		}
+	}
+	if strings.Contains(doc, "<!--") || strings.Contains(doc, "deploy-bot") {
+		t.Errorf("document keeps markers or bots:\n%s", doc)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	ctx := context.Background()
+
+	changes := &stubChanges{}
+	g, err := New(map[string]any{}, stubTickets{}, changes)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	result, err := g.Generate(ctx, Input{Ticket: "42"})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if result.Path != "postmortems/2024-03-01-42.md" || result.Commit == nil || result.PullRequest != nil {
+		t.Errorf("result = %+v", result)
+	}
+	if len(changes.writes) != 1 || changes.writes[0].Content != result.Document || changes.writes[0].Message != "Add postmortem for #42" {
+		t.Errorf("writes = %+v", changes.writes)
+	}
+
+	changes = &stubChanges{}
+	g, _ = New(map[string]any{"pullRequest": true, "path": "incidents/{{ slug }}.md", "labels": "postmortem", "base": "docs"}, stubTickets{}, changes)
+	result, err = g.Generate(ctx, Input{Ticket: "42"})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if result.Path != "incidents/checkout-errors.md" || result.PullRequest == nil || len(changes.writes) != 0 {
+		t.Errorf("result = %+v", result)
+	}
+	if pr := changes.prs[0]; pr.Branch != "postmortem/42" || pr.Base != "docs" || pr.Labels[0] != "postmortem" || pr.Files[0].Path != result.Path || pr.Title != "Postmortem: Checkout errors" {
+		t.Errorf("pull request = %+v", pr)
+	}
+
+	// The request overrides the config
+	changes = &stubChanges{}
+	g, _ = New(map[string]any{"pullRequest": true}, stubTickets{}, changes)
+	direct := false
+	if _, err := g.Generate(ctx, Input{Ticket: "42", PullRequest: &direct}); err != nil || len(changes.writes) != 1 {
+		t.Errorf("Generate() = %v, writes = %+v", err, changes.writes)
+	}
+
+	var opsErr *orcherr.OpsOrchError
+	for _, input := range []Input{{}, {Ticket: "42", Path: "{{ team }}.md"}, {Ticket: "9"}} {
+		if _, err := g.Generate(ctx, input); !errors.As(err, &opsErr) {
+			t.Errorf("Generate(%+v) error = %v", input, err)
+		}
+	}
+}
+
+func TestSlug(t *testing.T) {
+	for in, want := range map[string]string{"Checkout errors!": "checkout-errors", "acme/api#42": "acme-api-42", "  --x": "x"} {
+		if got := slug(in); got != want {
+			t.Errorf("slug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
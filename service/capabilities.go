@@ -0,0 +1,14 @@
+package service
+
+import "github.com/opsorch/opsorch-github-adapter/capability"
+
+// Capabilities describes what the provider supports. Services are updated by
+// changing their tags.
+func (p *Provider) Capabilities() capability.Capabilities {
+	return capability.Capabilities{
+		Provider:         "service",
+		SupportsUpdate:   true,
+		DryRun:           p.config.ReadOnly,
+		SupportedFilters: []string{"ids", "name", "tags", "limit", "scope.service"},
+	}
+}
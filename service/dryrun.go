@@ -0,0 +1,9 @@
+package service
+
+import "github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+
+// isDryRun reports whether a write should be simulated, either because the
+// provider is configured read-only or because the caller asked for a dry run.
+func (p *Provider) isDryRun(metadata map[string]any) bool {
+	return p.config.ReadOnly || ghconfig.Bool(metadata, "dryRun")
+}
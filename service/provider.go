@@ -0,0 +1,264 @@
+// Package service presents an organization's repositories as OpsOrch services,
+// tagged with the metadata GitHub keeps on them natively: repository topics and
+// the organization's custom properties. OpsOrch reads the catalog through the
+// service.Provider interface, and SetTags manages the same metadata.
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-core/service"
+	"github.com/opsorch/opsorch-github-adapter/audit"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/gherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/servicetags"
+)
+
+// Provider implements the service.Provider interface for the repositories of a
+// GitHub organization.
+type Provider struct {
+	api    ghapi.Services
+	config Config
+}
+
+// Config holds the configuration for the GitHub service provider.
+type Config struct {
+	Token        string     `json:"token"`        // GitHub personal access token
+	Organization string     `json:"organization"` // GitHub organization whose repositories are the services
+	Topic        string     `json:"topic"`        // Only list repositories with this topic
+	ReadOnly     bool       `json:"readOnly"`     // Simulate writes instead of calling GitHub
+	Audit        *audit.Log `json:"-"`            // Record of writes to GitHub, from "auditLog" and "auditFunc"
+}
+
+// New creates a new GitHub service provider.
+func New(cfg map[string]any) (service.Provider, error) {
+	// Build the API services: GitHub authenticated with the token (falling back to
+	// GITHUB_TOKEN), or local fixtures when mode is "fixtures"
+	api, err := ghconfig.Services(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := NewWithServices(cfg, api)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewWithServices creates a GitHub service provider backed by the given API
+// implementations instead of a token-authenticated client. The Repositories
+// service is required; without Properties, services are tagged with their
+// topics only.
+func NewWithServices(cfg map[string]any, api ghapi.Services) (*Provider, error) {
+	if api.Repositories == nil {
+		return nil, fmt.Errorf("repositories service is required")
+	}
+
+	var config Config
+	config.Token = ghconfig.Token(cfg)
+
+	// Parse organization, falling back to the Actions repository owner
+	config.Organization = ghconfig.Organization(cfg)
+	if config.Organization == "" {
+		return nil, fmt.Errorf("organization is required")
+	}
+
+	// Parse the topic filter (optional); GitHub stores topics lower-cased
+	config.Topic = strings.ToLower(ghconfig.String(cfg, "topic"))
+
+	// Parse read-only mode (optional)
+	config.ReadOnly = ghconfig.Bool(cfg, "readOnly")
+
+	// Open the audit log (optional)
+	var err error
+	if config.Audit, err = audit.Parse(cfg); err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		api:    api,
+		config: config,
+	}, nil
+}
+
+// Query returns the organization's unarchived repositories matching the given
+// filters as services. IDs and the service scope match repository names; tags
+// match topic and custom property tags exactly.
+func (p *Provider) Query(ctx context.Context, query schema.ServiceQuery) ([]schema.Service, error) {
+	repos, err := p.repositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	properties := p.organizationProperties(ctx)
+
+	result := []schema.Service{}
+	for _, repo := range repos {
+		svc := p.convertRepository(repo, properties[repo.GetName()])
+		if !matches(svc, query) {
+			continue
+		}
+		result = append(result, svc)
+		if query.Limit > 0 && len(result) == query.Limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// Get returns the service of a repository of the organization by name.
+func (p *Provider) Get(ctx context.Context, id string) (schema.Service, error) {
+	repo, _, err := p.api.Repositories.Get(ctx, p.config.Organization, id)
+	if err != nil {
+		return schema.Service{}, p.wrapError(err)
+	}
+	properties, err := p.repositoryProperties(ctx, repo.GetName())
+	if err != nil {
+		return schema.Service{}, err
+	}
+	return p.convertRepository(repo, properties), nil
+}
+
+// repositories lists the organization's unarchived repositories, keeping only
+// those with the configured topic.
+func (p *Provider) repositories(ctx context.Context) ([]*github.Repository, error) {
+	opts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	var repos []*github.Repository
+	for {
+		page, resp, err := p.api.Repositories.ListByOrg(ctx, p.config.Organization, opts)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		for _, repo := range page {
+			if repo.GetArchived() || (p.config.Topic != "" && !slices.Contains(repo.Topics, p.config.Topic)) {
+				continue
+			}
+			repos = append(repos, repo)
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return repos, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// organizationProperties returns the custom property values of the
+// organization's repositories by repository name. Properties are best effort:
+// a token that cannot read them leaves services tagged with topics alone, and
+// the failure is logged.
+func (p *Provider) organizationProperties(ctx context.Context) map[string][]*ghapi.PropertyValue {
+	if p.api.Properties == nil {
+		return nil
+	}
+	opts := &github.ListOptions{PerPage: 100}
+	properties := map[string][]*ghapi.PropertyValue{}
+	for {
+		page, resp, err := p.api.Properties.ListOrganizationPropertyValues(ctx, p.config.Organization, opts)
+		if err != nil {
+			log.Printf("[service] custom properties of %s unavailable: %v", p.config.Organization, p.wrapError(err))
+			return nil
+		}
+		for _, repo := range page {
+			properties[repo.RepositoryName] = repo.Properties
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return properties
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// repositoryProperties returns the custom property values of one repository.
+func (p *Provider) repositoryProperties(ctx context.Context, repo string) ([]*ghapi.PropertyValue, error) {
+	if p.api.Properties == nil {
+		return nil, nil
+	}
+	properties, _, err := p.api.Properties.ListRepositoryPropertyValues(ctx, p.config.Organization, repo)
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+	return properties, nil
+}
+
+// convertRepository converts a repository and its custom property values to a
+// service. Properties are kept in metadata with their raw values too, so
+// multi-select properties keep their separate values.
+func (p *Provider) convertRepository(repo *github.Repository, properties []*ghapi.PropertyValue) schema.Service {
+	values := make(map[string]any, len(properties))
+	for _, property := range properties {
+		if property.Value != nil {
+			values[property.Name] = property.Value
+		}
+	}
+	topics := repo.Topics
+	if topics == nil {
+		topics = []string{}
+	}
+	return schema.Service{
+		ID:   repo.GetName(),
+		Name: repo.GetName(),
+		URL:  repo.GetHTMLURL(),
+		Tags: servicetags.Tags(repo.Topics, properties),
+		Metadata: map[string]any{
+			"full_name":      repo.GetFullName(),
+			"description":    repo.GetDescription(),
+			"default_branch": repo.GetDefaultBranch(),
+			"visibility":     repo.GetVisibility(),
+			"language":       repo.GetLanguage(),
+			"archived":       repo.GetArchived(),
+			"node_id":        repo.GetNodeID(),
+			"topics":         topics,
+			"properties":     values,
+		},
+	}
+}
+
+// matches reports whether a service passes the query's filters.
+func matches(svc schema.Service, query schema.ServiceQuery) bool {
+	if len(query.IDs) > 0 && !slices.ContainsFunc(query.IDs, func(id string) bool { return strings.EqualFold(id, svc.ID) }) {
+		return false
+	}
+	if query.Scope.Service != "" && !strings.EqualFold(query.Scope.Service, svc.ID) {
+		return false
+	}
+	if query.Name != "" && !strings.Contains(strings.ToLower(svc.Name), strings.ToLower(query.Name)) {
+		return false
+	}
+	for key, value := range query.Tags {
+		if svc.Tags[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// audit records a write to a repository of the organization in the audit log.
+func (p *Provider) audit(operation, repo string, input any, url string, err error) {
+	entry := audit.Entry{
+		Operation:  operation,
+		Repository: p.config.Organization + "/" + repo,
+		Target:     repo,
+		Input:      input,
+		URL:        url,
+	}
+	if err != nil {
+		entry.Error = p.wrapError(err).Error()
+	}
+	p.config.Audit.Record(entry)
+}
+
+// wrapError wraps GitHub API errors into OpsOrch errors.
+func (p *Provider) wrapError(err error) error {
+	return gherr.Wrap(err, "organization or repository")
+}
+
+func init() {
+	service.RegisterProvider("github", New)
+}
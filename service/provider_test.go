@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	coreservice "github.com/opsorch/opsorch-core/service"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+// newFakeProvider returns a provider wired to a fake GitHub server whose acme
+// organization has the repositories api (topics payments and tier-1, owner
+// property team-payments), web, and an archived legacy.
+func newFakeProvider(t *testing.T, cfg map[string]any) (*Provider, *fakegithub.Server) {
+	t.Helper()
+	srv := fakegithub.New(t)
+	api := map[string]any{"name": "api", "full_name": "acme/api", "html_url": "https://github.com/acme/api", "topics": []string{"payments", "tier-1"}, "default_branch": "main"}
+
+	srv.Handle(http.MethodGet, "/orgs/acme/repos", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			api,
+			{"name": "web", "full_name": "acme/web", "topics": []string{"frontend"}},
+			{"name": "legacy", "full_name": "acme/legacy", "topics": []string{"payments"}, "archived": true},
+		})
+	})
+	srv.Handle(http.MethodGet, "/orgs/acme/properties/values", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"repository_name": "api", "properties": []map[string]any{{"property_name": "owner", "value": "team-payments"}, {"property_name": "regions", "value": []string{"eu", "us"}}}},
+			{"repository_name": "web", "properties": []map[string]any{{"property_name": "owner", "value": nil}}},
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, api)
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/properties/values", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"property_name": "owner", "value": "team-payments"}})
+	})
+	srv.Handle(http.MethodPut, "/repos/acme/api/topics", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Names []string `json:"names"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		fakegithub.WriteJSON(w, http.StatusOK, body)
+	})
+	srv.Handle(http.MethodPatch, "/orgs/acme/properties/values", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	config := map[string]any{"organization": "acme"}
+	for k, v := range cfg {
+		config[k] = v
+	}
+	p, err := NewWithServices(config, ghapi.FromClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("NewWithServices() error = %v", err)
+	}
+	return p, srv
+}
+
+// writes returns the bodies of the requests that wrote to GitHub.
+func writes(srv *fakegithub.Server) []map[string]any {
+	var out []map[string]any
+	for _, r := range srv.Requests() {
+		if r.Method == http.MethodGet {
+			continue
+		}
+		body := map[string]any{"path": r.Path}
+		json.Unmarshal(r.Body, &body)
+		out = append(out, body)
+	}
+	return out
+}
+
+func hasCode(err error, code string) bool {
+	var opsErr *orcherr.OpsOrchError
+	return errors.As(err, &opsErr) && opsErr.Code == code
+}
+
+func TestNewWithServices(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY_OWNER", "")
+	t.Setenv("GITHUB_REPOSITORY", "")
+	srv := fakegithub.New(t)
+	if _, err := NewWithServices(map[string]any{}, ghapi.FromClient(srv.Client())); err == nil {
+		t.Error("NewWithServices() without organization succeeded")
+	}
+	if _, err := NewWithServices(map[string]any{"organization": "acme"}, ghapi.Services{}); err == nil {
+		t.Error("NewWithServices() without repositories succeeded")
+	}
+}
+
+func TestProviderRegistration(t *testing.T) {
+	if constructor, ok := coreservice.LookupProvider("github"); !ok || constructor == nil {
+		t.Errorf("GitHub service provider not registered")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+
+	p, _ := newFakeProvider(t, nil)
+	services, err := p.Query(ctx, schema.ServiceQuery{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(services) != 2 || services[0].ID != "api" || services[1].ID != "web" {
+		t.Fatalf("Query() = %+v, want api and web", services)
+	}
+	want := map[string]string{"topic:payments": "true", "topic:tier-1": "true", "owner": "team-payments", "regions": "eu,us"}
+	if !reflect.DeepEqual(services[0].Tags, want) || services[0].URL != "https://github.com/acme/api" {
+		t.Errorf("api = %+v", services[0])
+	}
+	if regions := services[0].Metadata["properties"].(map[string]any)["regions"]; !reflect.DeepEqual(regions, []any{"eu", "us"}) {
+		t.Errorf("regions = %v", regions)
+	}
+	if !reflect.DeepEqual(services[1].Tags, map[string]string{"topic:frontend": "true"}) {
+		t.Errorf("web tags = %v", services[1].Tags)
+	}
+
+	tests := []struct {
+		name  string
+		query schema.ServiceQuery
+		want  []string
+	}{
+		{"tags", schema.ServiceQuery{Tags: map[string]string{"owner": "team-payments"}}, []string{"api"}},
+		{"topic tag", schema.ServiceQuery{Tags: map[string]string{"topic:frontend": "true"}}, []string{"web"}},
+		{"ids", schema.ServiceQuery{IDs: []string{"WEB"}}, []string{"web"}},
+		{"name", schema.ServiceQuery{Name: "ap"}, []string{"api"}},
+		{"scope", schema.ServiceQuery{Scope: schema.QueryScope{Service: "api"}}, []string{"api"}},
+		{"limit", schema.ServiceQuery{Limit: 1}, []string{"api"}},
+	}
+	for _, tt := range tests {
+		services, err := p.Query(ctx, tt.query)
+		var ids []string
+		for _, s := range services {
+			ids = append(ids, s.ID)
+		}
+		if err != nil || !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("%s: Query() = %v, %v, want %v", tt.name, ids, err, tt.want)
+		}
+	}
+
+	// The topic filter applies before the query's
+	p, _ = newFakeProvider(t, map[string]any{"topic": "Frontend"})
+	if services, err := p.Query(ctx, schema.ServiceQuery{}); err != nil || len(services) != 1 || services[0].ID != "web" {
+		t.Errorf("topic Query() = %+v, %v", services, err)
+	}
+
+	// Custom properties the token cannot read leave topic tags
+	p, srv := newFakeProvider(t, nil)
+	srv.Error(http.MethodGet, "/orgs/acme/properties/values", http.StatusForbidden, "Resource not accessible by integration")
+	services, err = p.Query(ctx, schema.ServiceQuery{IDs: []string{"api"}})
+	if err != nil || len(services) != 1 || services[0].Tags["owner"] != "" || services[0].Tags["topic:payments"] != "true" {
+		t.Errorf("Query() without properties = %+v, %v", services, err)
+	}
+}
+
+func TestSetTags(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("writes", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		svc, err := p.SetTags(ctx, "api", TagsInput{
+			AddTopics:    []string{"Tier-0"},
+			RemoveTopics: []string{"tier-1"},
+			Properties:   map[string]any{"owner": "team-payments", "tier": "0"},
+		})
+		if err != nil || svc.ID != "api" {
+			t.Fatalf("SetTags() = %+v, %v", svc, err)
+		}
+		w := writes(srv)
+		if len(w) != 2 {
+			t.Fatalf("writes = %v", w)
+		}
+		if !reflect.DeepEqual(w[0]["names"], []any{"payments", "tier-0"}) {
+			t.Errorf("topics = %v", w[0])
+		}
+		// Only the property that changed is written
+		want := []any{map[string]any{"property_name": "tier", "value": "0"}}
+		if !reflect.DeepEqual(w[1]["repository_names"], []any{"api"}) || !reflect.DeepEqual(w[1]["properties"], want) {
+			t.Errorf("properties = %v", w[1])
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		if _, err := p.SetTags(ctx, "api", TagsInput{AddTopics: []string{"payments"}, Properties: map[string]any{"owner": "team-payments"}}); err != nil {
+			t.Fatalf("SetTags() error = %v", err)
+		}
+		if w := writes(srv); len(w) != 0 {
+			t.Errorf("writes = %v", w)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		p, srv := newFakeProvider(t, map[string]any{"readOnly": true})
+		if _, err := p.SetTags(ctx, "api", TagsInput{AddTopics: []string{"tier-0"}}); err != nil {
+			t.Fatalf("SetTags() error = %v", err)
+		}
+		if w := writes(srv); len(w) != 0 {
+			t.Errorf("writes = %v", w)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		p, srv := newFakeProvider(t, nil)
+		for _, input := range []TagsInput{
+			{AddTopics: []string{"no spaces"}},
+			{Properties: map[string]any{"tier": 0}},
+			{Properties: map[string]any{"regions": []any{"eu", 1}}},
+		} {
+			if _, err := p.SetTags(ctx, "api", input); !hasCode(err, "bad_request") {
+				t.Errorf("SetTags(%+v) error = %v, want bad_request", input, err)
+			}
+		}
+		if w := writes(srv); len(w) != 0 {
+			t.Errorf("writes = %v", w)
+		}
+	})
+}
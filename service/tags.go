@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+// topicPattern is what GitHub accepts as a repository topic.
+var topicPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// TagsInput changes the tags of a service. Topics are added and removed rather
+// than replaced, so concurrent automations managing different topics do not
+// undo each other. Properties not named are left as they are.
+type TagsInput struct {
+	AddTopics    []string       `json:"addTopics,omitempty"`
+	RemoveTopics []string       `json:"removeTopics,omitempty"`
+	Properties   map[string]any `json:"properties,omitempty"` // Custom property values: a string, a list of strings for multi-select properties, or null to unset
+	Metadata     map[string]any `json:"metadata,omitempty"`
+}
+
+// SetTags changes the topics and custom property values of a repository of
+// the organization and returns its service as updated. Custom properties must
+// be defined for the organization first. Nothing is written when the input
+// leaves both as they are.
+func (p *Provider) SetTags(ctx context.Context, id string, input TagsInput) (schema.Service, error) {
+	input.AddTopics, input.RemoveTopics = lowerTopics(input.AddTopics), lowerTopics(input.RemoveTopics)
+	if err := checkTags(input); err != nil {
+		return schema.Service{}, err
+	}
+	if len(input.Properties) > 0 && p.api.Properties == nil {
+		return schema.Service{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "custom properties are not available for this provider",
+		}
+	}
+	current, err := p.Get(ctx, id)
+	if err != nil {
+		return schema.Service{}, err
+	}
+	repo := current.ID
+
+	// Only changed values are written
+	topics := current.Metadata["topics"].([]string)
+	newTopics := changeTopics(topics, input.AddTopics, input.RemoveTopics)
+	setTopics := !slices.Equal(topics, newTopics)
+	values := current.Metadata["properties"].(map[string]any)
+	var changed []*ghapi.PropertyValue
+	for _, name := range sortedKeys(input.Properties) {
+		if fmt.Sprint(values[name]) != fmt.Sprint(input.Properties[name]) {
+			changed = append(changed, &ghapi.PropertyValue{Name: name, Value: input.Properties[name]})
+		}
+	}
+
+	if p.isDryRun(input.Metadata) {
+		if setTopics {
+			log.Printf("[dry-run] PUT /repos/%s/%s/topics names=%v", p.config.Organization, repo, newTopics)
+		}
+		for _, property := range changed {
+			log.Printf("[dry-run] PATCH /orgs/%s/properties/values repository=%s %s=%v", p.config.Organization, repo, property.Name, property.Value)
+		}
+		return current, nil
+	}
+
+	if setTopics {
+		_, _, err := p.api.Repositories.ReplaceAllTopics(ctx, p.config.Organization, repo, newTopics)
+		p.audit("service.setTopics", repo, map[string]any{"topics": newTopics, "previous": topics}, current.URL, err)
+		if err != nil {
+			return schema.Service{}, p.wrapError(err)
+		}
+	}
+	if len(changed) > 0 {
+		_, err := p.api.Properties.SetRepositoryPropertyValues(ctx, p.config.Organization, repo, changed)
+		p.audit("service.setProperties", repo, map[string]any{"properties": changed}, current.URL, err)
+		if err != nil {
+			return schema.Service{}, p.wrapError(err)
+		}
+	}
+	if !setTopics && len(changed) == 0 {
+		return current, nil
+	}
+	return p.Get(ctx, repo)
+}
+
+// checkTags validates topic names and property values before anything is read.
+func checkTags(input TagsInput) error {
+	for _, topic := range append(slices.Clone(input.AddTopics), input.RemoveTopics...) {
+		if !topicPattern.MatchString(topic) {
+			return &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: fmt.Sprintf("invalid topic %q: topics are lower-case letters, digits, and hyphens, at most 50 characters", topic),
+			}
+		}
+	}
+	for name, value := range input.Properties {
+		if name == "" {
+			return &orcherr.OpsOrchError{Code: "bad_request", Message: "property name is required"}
+		}
+		switch value := value.(type) {
+		case nil, string:
+		case []any:
+			for _, item := range value {
+				if _, ok := item.(string); !ok {
+					return &orcherr.OpsOrchError{
+						Code:    "bad_request",
+						Message: fmt.Sprintf("property %s: multi-select values must be strings", name),
+					}
+				}
+			}
+		default:
+			return &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: fmt.Sprintf("property %s must be a string, a list of strings, or null", name),
+			}
+		}
+	}
+	return nil
+}
+
+// changeTopics returns topics with add added and remove removed, sorted.
+func changeTopics(topics, add, remove []string) []string {
+	set := map[string]bool{}
+	for _, topic := range topics {
+		set[topic] = true
+	}
+	for _, topic := range add {
+		set[topic] = true
+	}
+	for _, topic := range remove {
+		delete(set, topic)
+	}
+	result := make([]string, 0, len(set))
+	for topic := range set {
+		result = append(result, topic)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lowerTopics returns topics lower-cased, as GitHub stores them.
+func lowerTopics(topics []string) []string {
+	lowered := make([]string, len(topics))
+	for i, topic := range topics {
+		lowered[i] = strings.ToLower(strings.TrimSpace(topic))
+	}
+	return lowered
+}
@@ -0,0 +1,44 @@
+package team
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+// reportCalls reports the API calls srv received per benchmark iteration.
+func reportCalls(b *testing.B, srv *fakegithub.Server) {
+	b.ReportMetric(float64(len(srv.Requests()))/float64(b.N), "calls/op")
+}
+
+func BenchmarkQuery(b *testing.B) {
+	p, srv := newFakeProvider(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Query(context.Background(), schema.TeamQuery{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportCalls(b, srv)
+}
+
+func BenchmarkMembers(b *testing.B) {
+	p, srv := newFakeProvider(b)
+	// ghost has no profile, which is logged on every iteration
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Members(context.Background(), "sre"); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportCalls(b, srv)
+}
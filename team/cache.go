@@ -0,0 +1,58 @@
+package team
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/cache"
+	"github.com/opsorch/opsorch-github-adapter/internal/lastgood"
+)
+
+// cacheKey identifies a team in the shared response cache. Teams are cached under
+// both their slug and numeric ID since Get accepts either.
+func (p *Provider) cacheKey(id string) string {
+	return "team/" + p.config.Organization + "/" + id
+}
+
+// cachedTeam returns the cached team for id when caching is enabled.
+func (p *Provider) cachedTeam(id string) (schema.Team, bool) {
+	if p.config.CacheTTL <= 0 {
+		return schema.Team{}, false
+	}
+	if v, ok := cache.Default.Get(p.cacheKey(id)); ok {
+		return v.(schema.Team), true
+	}
+	return schema.Team{}, false
+}
+
+// Prime stores a team in the response cache so later Get calls are served without
+// an API request. It is a no-op unless cacheTTL is configured.
+func (p *Provider) Prime(team schema.Team) {
+	if team.ID == "" {
+		return
+	}
+	cache.Default.Set(p.cacheKey(team.ID), team, p.config.CacheTTL)
+	if githubID, ok := team.Metadata["github_id"]; ok {
+		cache.Default.Set(p.cacheKey(fmt.Sprint(githubID)), team, p.config.CacheTTL)
+	}
+}
+
+// markStale flags a last-known-good team served during an outage.
+func markStale(team *schema.Team, savedAt time.Time) {
+	team.Metadata = lastgood.Mark(team.Metadata, savedAt)
+}
+
+// markStaleTeams flags every team of a last-known-good query result.
+func markStaleTeams(teams *[]schema.Team, savedAt time.Time) {
+	for i := range *teams {
+		markStale(&(*teams)[i], savedAt)
+	}
+}
+
+// markStaleMembers flags every member of a last-known-good member list.
+func markStaleMembers(members *[]schema.TeamMember, savedAt time.Time) {
+	for i := range *members {
+		(*members)[i].Metadata = lastgood.Mark((*members)[i].Metadata, savedAt)
+	}
+}
@@ -0,0 +1,12 @@
+package team
+
+import "github.com/opsorch/opsorch-github-adapter/capability"
+
+// Capabilities describes what the provider supports. Team queries list every
+// team and take no limit.
+func (p *Provider) Capabilities() capability.Capabilities {
+	return capability.Capabilities{
+		Provider:         "team",
+		SupportedFilters: []string{"name", "tags"},
+	}
+}
@@ -0,0 +1,88 @@
+package team
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/miniyaml"
+)
+
+// descriptionKey matches the key of a key=value pair in a team description.
+var descriptionKey = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+
+// parseDescription reads structured metadata from a team description. A
+// description with a line holding only "---" carries a YAML mapping after it
+// (up to an optional closing "---"); otherwise every whitespace-separated
+// key=value word is a pair, such as "Payments team tier=1 oncall=pagerduty:PXYZ".
+// It returns nil when the description holds no metadata.
+func parseDescription(description string) map[string]any {
+	description = strings.ReplaceAll(description, "\r\n", "\n")
+
+	if block, ok := descriptionBlock(description); ok {
+		doc, err := miniyaml.Parse([]byte(block))
+		if err != nil {
+			log.Printf("[team] ignoring invalid YAML in team description: %v", err)
+			return nil
+		}
+		fields, _ := doc.(map[string]any)
+		if len(fields) == 0 {
+			return nil
+		}
+		return fields
+	}
+
+	fields := map[string]any{}
+	for _, word := range strings.Fields(description) {
+		key, value, found := strings.Cut(word, "=")
+		if !found || value == "" || !descriptionKey.MatchString(key) {
+			continue
+		}
+		fields[key] = value
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// descriptionBlock returns the YAML block of a description, if it has one.
+func descriptionBlock(description string) (string, bool) {
+	lines := strings.Split(description, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "---" {
+			continue
+		}
+		block := lines[i+1:]
+		for j, rest := range block {
+			if strings.TrimSpace(rest) == "---" {
+				block = block[:j]
+				break
+			}
+		}
+		return strings.Join(block, "\n"), true
+	}
+	return "", false
+}
+
+// applyDescription adds the metadata in a team's description to the team. Scalar
+// values become tags, except where they would replace a tag the adapter sets;
+// every value, nested ones included, is kept in metadata.description_fields.
+func applyDescription(team *schema.Team, description string) {
+	fields := parseDescription(description)
+	if fields == nil {
+		return
+	}
+	for key, value := range fields {
+		if _, reserved := team.Tags[key]; reserved {
+			continue
+		}
+		switch value.(type) {
+		case string, bool:
+			team.Tags[key] = fmt.Sprint(value)
+		}
+	}
+	team.Metadata["description_fields"] = fields
+}
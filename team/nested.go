@@ -0,0 +1,163 @@
+package team
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+)
+
+// Bounds on the hierarchy walked for nestedTeams, so a pathological hierarchy
+// cannot turn one Members call into thousands of requests.
+const (
+	maxTeamDepth   = 10
+	maxNestedTeams = 100
+)
+
+// teamNode is one team in a hierarchy, with the logins GitHub lists for it.
+// GitHub lists a team's members together with those of its child teams.
+type teamNode struct {
+	ref      teamRef
+	name     string
+	members  map[string]bool
+	children []*teamNode
+}
+
+// teamTree is a team and its descendants, in breadth-first order.
+type teamTree struct {
+	nodes []*teamNode
+}
+
+// walkTeams lists the members of a team's descendants. rootMembers are the
+// members already listed for the root. Each team is visited once however often
+// it appears, and the walk stops at maxTeamDepth levels or maxNestedTeams teams.
+func (p *Provider) walkTeams(ctx context.Context, root teamRef, rootName string, rootMembers []*github.User) (*teamTree, error) {
+	node := &teamNode{ref: root, name: rootName, members: logins(rootMembers)}
+	tree := &teamTree{nodes: []*teamNode{node}}
+	visited := map[string]bool{teamKey(root): true}
+
+	level := []*teamNode{node}
+	for depth := 1; len(level) > 0; depth++ {
+		var next []*teamNode
+		for _, parent := range level {
+			if !budget.Call(ctx) {
+				log.Printf("[team] nested teams under %s truncated by the API call budget", rootName)
+				return tree, nil
+			}
+			children, err := p.listChildTeams(ctx, parent.ref)
+			if err != nil {
+				return nil, p.wrapError(err)
+			}
+			for _, child := range children {
+				ref := teamRef{slug: child.GetSlug()}
+				if ref.slug == "" || visited[teamKey(ref)] {
+					continue
+				}
+				if depth > maxTeamDepth || len(tree.nodes) >= maxNestedTeams {
+					log.Printf("[team] nested teams under %s truncated at %d levels and %d teams", rootName, maxTeamDepth, maxNestedTeams)
+					return tree, nil
+				}
+				visited[teamKey(ref)] = true
+
+				if !budget.Call(ctx) {
+					log.Printf("[team] nested teams under %s truncated by the API call budget", rootName)
+					return tree, nil
+				}
+				members, err := p.listTeamMembers(ctx, ref, &github.TeamListTeamMembersOptions{
+					ListOptions: github.ListOptions{PerPage: 100},
+				})
+				if err != nil {
+					return nil, p.wrapError(err)
+				}
+				childNode := &teamNode{ref: ref, name: ref.slug, members: logins(members)}
+				parent.children = append(parent.children, childNode)
+				tree.nodes = append(tree.nodes, childNode)
+				next = append(next, childNode)
+			}
+		}
+		level = next
+	}
+	return tree, nil
+}
+
+// via returns the teams login belongs to directly: those listing it where no
+// child team does. A login listed nowhere belongs to the root.
+func (t *teamTree) via(login string) []*teamNode {
+	var direct []*teamNode
+	for _, node := range t.nodes {
+		if !node.members[login] {
+			continue
+		}
+		inherited := false
+		for _, child := range node.children {
+			if child.members[login] {
+				inherited = true
+				break
+			}
+		}
+		if !inherited {
+			direct = append(direct, node)
+		}
+	}
+	if len(direct) == 0 {
+		return t.nodes[:1]
+	}
+	return direct
+}
+
+// highestRole returns a user's highest role across the teams, "maintainer" or
+// "member", and whether every team was checked before the enrichment budget
+// ran out.
+func (p *Provider) highestRole(ctx context.Context, nodes []*teamNode, login string) (string, bool) {
+	for _, node := range nodes {
+		if !budget.Enrich(ctx) {
+			return "member", false
+		}
+		membership, err := p.teamMembership(ctx, node.ref, login)
+		if err == nil && membership.GetRole() == "maintainer" {
+			return "maintainer", true
+		}
+	}
+	return "member", true
+}
+
+// listChildTeams lists one page of a team's child teams.
+func (p *Provider) listChildTeams(ctx context.Context, ref teamRef) ([]*github.Team, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	var teams []*github.Team
+	var err error
+	if ref.slug != "" {
+		teams, _, err = p.api.Teams.ListChildTeamsByParentSlug(ctx, p.config.Organization, ref.slug, opts)
+	} else {
+		teams, _, err = p.api.Teams.ListChildTeamsByParentID(ctx, ref.orgID, ref.id, opts)
+	}
+	return teams, err
+}
+
+// teamKey identifies a team in a walk.
+func teamKey(ref teamRef) string {
+	if ref.slug != "" {
+		return ref.slug
+	}
+	return strconv.FormatInt(ref.id, 10)
+}
+
+// logins returns the set of the users' logins.
+func logins(users []*github.User) map[string]bool {
+	set := map[string]bool{}
+	for _, user := range users {
+		set[user.GetLogin()] = true
+	}
+	return set
+}
+
+// teamNames returns the names of the teams.
+func teamNames(nodes []*teamNode) []string {
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i] = node.name
+	}
+	return names
+}
@@ -3,65 +3,159 @@ package team
 import (
 	"context"
 	"fmt"
+	"log"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-github/v57/github"
 	"github.com/opsorch/opsorch-core/orcherr"
 	"github.com/opsorch/opsorch-core/schema"
 	"github.com/opsorch/opsorch-core/team"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/botpolicy"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/fieldset"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/gherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/identity"
+	"github.com/opsorch/opsorch-github-adapter/internal/instance"
+	"github.com/opsorch/opsorch-github-adapter/internal/lastgood"
+	"github.com/opsorch/opsorch-github-adapter/internal/nodeid"
+	"github.com/opsorch/opsorch-github-adapter/internal/throttle"
 )
 
+// memberConcurrency bounds the members Members enriches at once.
+const memberConcurrency = 8
+
 // Provider implements the team.Provider interface for GitHub Teams.
 type Provider struct {
-	client *github.Client
+	api    ghapi.Services
 	config Config
+
+	// orgID caches the organization's numeric ID, which the ID-based team
+	// endpoints need and which never changes for a given organization
+	orgMu sync.Mutex
+	orgID int64
 }
 
 // Config holds the configuration for the GitHub team provider.
 type Config struct {
-	Token        string `json:"token"`        // GitHub personal access token
-	Organization string `json:"organization"` // GitHub organization name
+	Token                 string           `json:"token"`                 // GitHub personal access token
+	Organization          string           `json:"organization"`          // GitHub organization name
+	RawAPIAllowlist       []string         `json:"rawAPIAllowlist"`       // Path patterns permitted for raw GET passthrough
+	CacheTTL              time.Duration    `json:"cacheTTL"`              // How long Get results stay in the response cache (0 disables)
+	BotPolicy             botpolicy.Policy `json:"botPolicy"`             // Which members are bots, and whether they are left out
+	Identities            *identity.Map    `json:"-"`                     // Canonical identities by login, from "identities" and "identitiesURL"
+	InstanceLabels        instance.Labels  `json:"instanceLabels"`        // Labels of this adapter instance added to every team's Tags and Metadata["instance_labels"]
+	Budget                budget.Limits    `json:"-"`                     // Per-query API call caps, from "maxAPICallsPerQuery" and "maxEnrichmentCalls"
+	LastGood              *lastgood.Store  `json:"-"`                     // Last-known-good results served during outages, from "staleCacheDir"
+	MemberStatus          bool             `json:"memberStatus"`          // Add organization membership, 2FA, and suspension status to members
+	NestedTeams           bool             `json:"nestedTeams"`           // Attribute members to the child teams they belong to, with their highest role
+	FailOnEnrichmentError bool             `json:"failOnEnrichmentError"` // Fail Members when a member's profile cannot be read, instead of degrading the member
 }
 
 // New creates a new GitHub team provider.
 func New(cfg map[string]any) (team.Provider, error) {
-	var config Config
+	// Build the API services: GitHub authenticated with the token (falling back to
+	// GITHUB_TOKEN), or local fixtures when mode is "fixtures"
+	api, err := ghconfig.Services(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	// Parse token
-	if token, ok := cfg["token"].(string); ok {
-		config.Token = token
-	} else {
-		return nil, fmt.Errorf("token is required")
+	p, err := NewWithServices(cfg, api)
+	if err != nil {
+		return nil, err
 	}
+	return p, nil
+}
 
-	// Parse organization
-	if org, ok := cfg["organization"].(string); ok {
-		config.Organization = org
-	} else {
+// NewWithServices creates a GitHub team provider backed by the given API
+// implementations instead of a token-authenticated client. The Teams,
+// Organizations and Users services are required; raw passthrough is
+// unavailable without a Requester.
+func NewWithServices(cfg map[string]any, api ghapi.Services) (*Provider, error) {
+	if api.Teams == nil || api.Organizations == nil || api.Users == nil {
+		return nil, fmt.Errorf("teams, organizations and users services are required")
+	}
+
+	var config Config
+	config.Token = ghconfig.Token(cfg)
+	if ghconfig.JobToken(config.Token) {
+		// The job token is scoped to the workflow's repository
+		log.Printf("[team] the GitHub Actions job token cannot read organization teams; set token to one with read:org or the members permission")
+	}
+
+	// Parse organization, falling back to the Actions repository owner
+	config.Organization = ghconfig.Organization(cfg)
+	if config.Organization == "" {
 		return nil, fmt.Errorf("organization is required")
 	}
 
-	// Create GitHub client
-	client := github.NewTokenClient(context.Background(), config.Token)
+	// Parse raw API allowlist (optional)
+	config.RawAPIAllowlist = ghconfig.StringSlice(cfg, "rawAPIAllowlist")
+
+	// Parse response cache TTL (optional)
+	config.CacheTTL = ghconfig.Duration(cfg, "cacheTTL", 0)
+
+	// Parse member status lookups (optional)
+	config.MemberStatus = ghconfig.Bool(cfg, "memberStatus")
+
+	// Parse nested team attribution (optional)
+	config.NestedTeams = ghconfig.Bool(cfg, "nestedTeams")
+
+	// Parse profile failure handling (optional)
+	config.FailOnEnrichmentError = ghconfig.Bool(cfg, "failOnEnrichmentError")
+
+	// Parse bot policy (optional)
+	var err error
+	if config.BotPolicy, err = botpolicy.Parse(cfg); err != nil {
+		return nil, err
+	}
+
+	// Parse identity mapping (optional)
+	if config.Identities, err = identity.Parse(cfg); err != nil {
+		return nil, err
+	}
+
+	// Parse API call budgets (optional)
+	config.Budget = budget.Parse(cfg)
+
+	// Parse instance labels (optional)
+	if config.InstanceLabels, err = instance.Parse(cfg); err != nil {
+		return nil, err
+	}
+
+	// Open the last-known-good store (optional)
+	if config.LastGood, err = lastgood.Parse(cfg, "team/"+config.Organization); err != nil {
+		return nil, err
+	}
 
 	return &Provider{
-		client: client,
+		api:    api,
 		config: config,
 	}, nil
 }
 
 // Query returns teams matching the given filters.
 func (p *Provider) Query(ctx context.Context, query schema.TeamQuery) ([]schema.Team, error) {
+	teams, err := p.query(ctx, query)
+	return lastgood.Serve(p.config.LastGood, lastgood.Key("query", query), teams, err, markStaleTeams)
+}
+
+func (p *Provider) query(ctx context.Context, query schema.TeamQuery) ([]schema.Team, error) {
 	opts := &github.ListOptions{
 		PerPage: 100, // GitHub's max per page
 	}
 
-	teams, _, err := p.client.Teams.ListTeams(ctx, p.config.Organization, opts)
+	teams, _, err := p.api.Teams.ListTeams(ctx, p.config.Organization, opts)
 	if err != nil {
 		return nil, p.wrapError(err)
 	}
 
+	want := fieldset.Parse(query.Metadata)
 	var result []schema.Team
 	for _, team := range teams {
 		normalizedTeam := p.convertTeamToSchema(team)
@@ -85,6 +179,7 @@ func (p *Provider) Query(ctx context.Context, query schema.TeamQuery) ([]schema.
 			}
 		}
 
+		want.Team(&normalizedTeam)
 		result = append(result, normalizedTeam)
 	}
 
@@ -93,40 +188,66 @@ func (p *Provider) Query(ctx context.Context, query schema.TeamQuery) ([]schema.
 
 // Get returns a single team by its ID.
 func (p *Provider) Get(ctx context.Context, id string) (schema.Team, error) {
+	team, err := p.get(throttle.WithPriority(ctx, throttle.Get), id)
+	return lastgood.Serve(p.config.LastGood, "get/"+id, team, err, markStale)
+}
+
+func (p *Provider) get(ctx context.Context, id string) (schema.Team, error) {
+	// Node IDs, as found in webhook payloads and GraphQL results, resolve to a slug
+	if nodeid.Is(id) {
+		slug, err := p.resolveNodeID(ctx, id)
+		if err != nil {
+			return schema.Team{}, err
+		}
+		id = slug
+	}
+
+	if cached, ok := p.cachedTeam(id); ok {
+		return cached, nil
+	}
+
 	teamID, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
 		// Try by slug if ID parsing fails
-		team, _, err := p.client.Teams.GetTeamBySlug(ctx, p.config.Organization, id)
+		team, _, err := p.api.Teams.GetTeamBySlug(ctx, p.config.Organization, id)
 		if err != nil {
 			return schema.Team{}, p.wrapError(err)
 		}
-		return p.convertTeamToSchema(team), nil
+		normalizedTeam := p.convertTeamToSchema(team)
+		p.Prime(normalizedTeam)
+		return normalizedTeam, nil
 	}
 
-	// Get organization ID first
-	org, _, err := p.client.Organizations.Get(ctx, p.config.Organization)
+	orgID, err := p.organizationID(ctx)
 	if err != nil {
-		return schema.Team{}, p.wrapError(err)
+		return schema.Team{}, err
 	}
 
-	team, _, err := p.client.Teams.GetTeamByID(ctx, org.GetID(), teamID)
+	team, _, err := p.api.Teams.GetTeamByID(ctx, orgID, teamID)
 	if err != nil {
 		return schema.Team{}, p.wrapError(err)
 	}
 
-	return p.convertTeamToSchema(team), nil
+	normalizedTeam := p.convertTeamToSchema(team)
+	p.Prime(normalizedTeam)
+	return normalizedTeam, nil
 }
 
 // Members returns the members of a team.
+//
+// Profiles, roles, and account status take a few calls per member. Members
+// left without some of them when the API call budget runs out carry
+// metadata.truncated.
 func (p *Provider) Members(ctx context.Context, teamID string) ([]schema.TeamMember, error) {
-	id, err := strconv.ParseInt(teamID, 10, 64)
+	members, err := p.members(ctx, teamID)
+	return lastgood.Serve(p.config.LastGood, "members/"+teamID, members, err, markStaleMembers)
+}
+
+func (p *Provider) members(ctx context.Context, teamID string) ([]schema.TeamMember, error) {
+	ctx = budget.Start(ctx, p.config.Budget)
+	ref, err := p.resolveTeam(ctx, teamID)
 	if err != nil {
-		// Try by slug if ID parsing fails
-		team, _, err := p.client.Teams.GetTeamBySlug(ctx, p.config.Organization, teamID)
-		if err != nil {
-			return nil, p.wrapError(err)
-		}
-		id = team.GetID()
+		return nil, err
 	}
 
 	opts := &github.TeamListTeamMembersOptions{
@@ -135,73 +256,267 @@ func (p *Provider) Members(ctx context.Context, teamID string) ([]schema.TeamMem
 		},
 	}
 
-	// Get organization ID first
-	org, _, err := p.client.Organizations.Get(ctx, p.config.Organization)
+	members, err := p.listTeamMembers(ctx, ref, opts)
 	if err != nil {
 		return nil, p.wrapError(err)
 	}
 
-	members, _, err := p.client.Teams.ListTeamMembersByID(ctx, org.GetID(), id, opts)
-	if err != nil {
-		return nil, p.wrapError(err)
+	// GitHub lists child team members with the team's own; with nestedTeams each
+	// is attributed to the teams they belong to directly
+	rootName := teamID
+	if ref.slug != "" {
+		rootName = ref.slug
+	}
+	tree := &teamTree{nodes: []*teamNode{{ref: ref, name: rootName}}}
+	if p.config.NestedTeams {
+		if tree, err = p.walkTeams(ctx, ref, rootName, members); err != nil {
+			return nil, err
+		}
 	}
 
-	var result []schema.TeamMember
+	// Members are enriched concurrently, each degrading to basic info on its own
+	// unless failOnEnrichmentError fails the call
+	var candidates []*github.User
+	seen := map[string]bool{}
 	for _, member := range members {
-		// Get detailed user info to get email and name
-		user, _, err := p.client.Users.Get(ctx, member.GetLogin())
-		if err != nil {
-			// If we can't get detailed info, use basic info
-			result = append(result, schema.TeamMember{
-				ID:     member.GetLogin(),
-				Name:   member.GetLogin(),
-				Handle: member.GetLogin(),
-				Role:   "member", // Default role
-				Metadata: map[string]any{
-					"github_id":  member.GetID(),
-					"avatar_url": member.GetAvatarURL(),
-					"html_url":   member.GetHTMLURL(),
-					"site_admin": member.GetSiteAdmin(),
-					"type":       member.GetType(),
-				},
-			})
+		if seen[member.GetLogin()] {
 			continue
 		}
+		seen[member.GetLogin()] = true
 
-		// Get team membership to determine role
-		membership, _, err := p.client.Teams.GetTeamMembershipByID(ctx, org.GetID(), id, member.GetLogin())
-		role := "member"
-		if err == nil && membership != nil {
-			role = membership.GetRole() // "member" or "maintainer"
+		// Bots are left out before the per-member lookups when the policy excludes them
+		if p.config.BotPolicy.Exclude && p.config.BotPolicy.IsBot(member.GetLogin(), member.GetType()) {
+			continue
 		}
+		candidates = append(candidates, member)
+	}
 
-		result = append(result, schema.TeamMember{
-			ID:     member.GetLogin(),
-			Name:   user.GetName(),
-			Email:  user.GetEmail(),
-			Handle: member.GetLogin(),
-			Role:   p.normalizeRole(role),
-			Metadata: map[string]any{
-				"github_id":    member.GetID(),
-				"avatar_url":   member.GetAvatarURL(),
-				"html_url":     member.GetHTMLURL(),
-				"site_admin":   member.GetSiteAdmin(),
-				"type":         member.GetType(),
-				"company":      user.GetCompany(),
-				"location":     user.GetLocation(),
-				"bio":          user.GetBio(),
-				"blog":         user.GetBlog(),
-				"twitter":      user.GetTwitterUsername(),
-				"public_repos": user.GetPublicRepos(),
-				"followers":    user.GetFollowers(),
-				"following":    user.GetFollowing(),
-			},
-		})
+	result := make([]schema.TeamMember, len(candidates))
+	profiles := make([]*github.User, len(candidates))
+	errs := make([]error, len(candidates))
+	forEach(len(candidates), func(i int) {
+		result[i], profiles[i], errs[i] = p.enrichMember(throttle.WithPriority(ctx, throttle.Enrichment), candidates[i], tree)
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+	}
+	users := map[string]*github.User{}
+	for i, user := range profiles {
+		if user != nil {
+			users[candidates[i].GetLogin()] = user
+		}
 	}
 
+	for i := range result {
+		p.applyIdentity(&result[i])
+		if p.config.NestedTeams {
+			result[i].Metadata["via_teams"] = teamNames(tree.via(result[i].Handle))
+		}
+	}
+	if p.config.MemberStatus {
+		p.addMemberStatus(ctx, result, users)
+	}
 	return result, nil
 }
 
+// enrichMember converts a team member with their profile and highest role,
+// returning the profile as well. Every member carries the same metadata keys;
+// one whose profile cannot be read, or who is past the enrichment budget, gets
+// empty profile values, the default role, and a nil profile. A failed read is
+// marked enrichment_failed, or returned with failOnEnrichmentError.
+func (p *Provider) enrichMember(ctx context.Context, member *github.User, tree *teamTree) (schema.TeamMember, *github.User, error) {
+	login := member.GetLogin()
+
+	// Get detailed user info to get email and name
+	enrich := budget.Enrich(ctx)
+	var user *github.User
+	if enrich {
+		var err error
+		if user, _, err = p.api.Users.Get(ctx, login); err != nil {
+			if p.config.FailOnEnrichmentError {
+				return schema.TeamMember{}, nil, err
+			}
+			log.Printf("[team] profile unavailable for %s: %v", login, err)
+			user = nil
+		}
+	}
+
+	// The profile getters return empty values for a nil user
+	enriched := schema.TeamMember{
+		ID:     login,
+		Name:   login,
+		Handle: login,
+		Role:   "member", // Default role
+		Metadata: map[string]any{
+			"github_id":    member.GetID(),
+			"avatar_url":   member.GetAvatarURL(),
+			"html_url":     member.GetHTMLURL(),
+			"site_admin":   member.GetSiteAdmin(),
+			"type":         member.GetType(),
+			"company":      user.GetCompany(),
+			"location":     user.GetLocation(),
+			"bio":          user.GetBio(),
+			"blog":         user.GetBlog(),
+			"twitter":      user.GetTwitterUsername(),
+			"public_repos": user.GetPublicRepos(),
+			"followers":    user.GetFollowers(),
+			"following":    user.GetFollowing(),
+			"bot":          p.config.BotPolicy.IsBot(login, member.GetType()),
+		},
+	}
+	switch {
+	case !enrich:
+		enriched.Metadata["truncated"] = true
+	case user == nil:
+		enriched.Metadata["enrichment_failed"] = true
+	default:
+		enriched.Name = user.GetName()
+		enriched.Email = user.GetEmail()
+
+		// Get team membership to determine role, the highest across the teams
+		// the member belongs to
+		role, complete := p.highestRole(ctx, tree.via(login), login)
+		enriched.Role = p.normalizeRole(role)
+		if !complete {
+			enriched.Metadata["truncated"] = true
+		}
+	}
+	return enriched, user, nil
+}
+
+// forEach calls fn for 0..n-1 with at most memberConcurrency calls at once and
+// waits for them all.
+func forEach(n int, fn func(i int)) {
+	sem := make(chan struct{}, memberConcurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// resolveNodeID returns the slug of the team with the given node ID, which must
+// belong to the configured organization.
+func (p *Provider) resolveNodeID(ctx context.Context, id string) (string, error) {
+	node, err := nodeid.Resolve(ctx, p.api.GraphQL, id, p.wrapError)
+	if err != nil {
+		return "", err
+	}
+	if err := nodeid.Expect(id, node, nodeid.TypeTeam); err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(node.Owner, p.config.Organization) {
+		return "", &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("team %s belongs to organization %s, not %s", id, node.Owner, p.config.Organization),
+		}
+	}
+	return node.Slug, nil
+}
+
+// teamRef addresses a team by slug or, for a numeric team ID, by organization
+// and team ID.
+type teamRef struct {
+	slug  string
+	orgID int64
+	id    int64
+}
+
+// resolveTeam addresses the team a caller named. Slugs are used as given, since
+// the slug endpoints need no other lookups; numeric IDs need the organization
+// ID, and node IDs are resolved to slugs.
+func (p *Provider) resolveTeam(ctx context.Context, teamID string) (teamRef, error) {
+	if nodeid.Is(teamID) {
+		slug, err := p.resolveNodeID(ctx, teamID)
+		if err != nil {
+			return teamRef{}, err
+		}
+		return teamRef{slug: slug}, nil
+	}
+
+	id, err := strconv.ParseInt(teamID, 10, 64)
+	if err != nil {
+		return teamRef{slug: teamID}, nil
+	}
+	orgID, err := p.organizationID(ctx)
+	if err != nil {
+		return teamRef{}, err
+	}
+	return teamRef{orgID: orgID, id: id}, nil
+}
+
+// listTeamMembers lists one page of a team's members.
+func (p *Provider) listTeamMembers(ctx context.Context, ref teamRef, opts *github.TeamListTeamMembersOptions) ([]*github.User, error) {
+	var members []*github.User
+	var err error
+	if ref.slug != "" {
+		members, _, err = p.api.Teams.ListTeamMembersBySlug(ctx, p.config.Organization, ref.slug, opts)
+	} else {
+		members, _, err = p.api.Teams.ListTeamMembersByID(ctx, ref.orgID, ref.id, opts)
+	}
+	return members, err
+}
+
+// teamMembership returns a user's membership in a team.
+func (p *Provider) teamMembership(ctx context.Context, ref teamRef, login string) (*github.Membership, error) {
+	var membership *github.Membership
+	var err error
+	if ref.slug != "" {
+		membership, _, err = p.api.Teams.GetTeamMembershipBySlug(ctx, p.config.Organization, ref.slug, login)
+	} else {
+		membership, _, err = p.api.Teams.GetTeamMembershipByID(ctx, ref.orgID, ref.id, login)
+	}
+	return membership, err
+}
+
+// organizationID returns the configured organization's numeric ID, fetching it
+// on first use. Failed lookups are not cached.
+func (p *Provider) organizationID(ctx context.Context) (int64, error) {
+	p.orgMu.Lock()
+	defer p.orgMu.Unlock()
+
+	if p.orgID != 0 {
+		return p.orgID, nil
+	}
+	org, _, err := p.api.Organizations.Get(ctx, p.config.Organization)
+	if err != nil {
+		return 0, p.wrapError(err)
+	}
+	p.orgID = org.GetID()
+	return p.orgID, nil
+}
+
+// applyIdentity attaches the member's canonical identity, whose email replaces
+// the one GitHub reports.
+func (p *Provider) applyIdentity(member *schema.TeamMember) {
+	id, ok := p.config.Identities.Lookup(member.Handle)
+	if !ok {
+		return
+	}
+	member.Metadata["identity"] = id
+	if id.Email != "" {
+		member.Email = id.Email
+	}
+	if member.Name == "" || member.Name == member.Handle {
+		if id.Name != "" {
+			member.Name = id.Name
+		}
+	}
+}
+
+// ConvertTeam converts a GitHub Team using this provider's configuration.
+func (p *Provider) ConvertTeam(team *github.Team) schema.Team {
+	return p.convertTeamToSchema(team)
+}
+
 // convertTeamToSchema converts a GitHub Team to a normalized Team.
 func (p *Provider) convertTeamToSchema(team *github.Team) schema.Team {
 	// Use team ID as primary identifier, with slug as fallback
@@ -244,9 +559,20 @@ func (p *Provider) convertTeamToSchema(team *github.Team) schema.Team {
 	// Add organization info to tags
 	normalizedTeam.Tags["organization"] = p.config.Organization
 
+	// Add routing metadata from the description
+	applyDescription(&normalizedTeam, team.GetDescription())
+
+	p.stampInstance(&normalizedTeam)
 	return normalizedTeam
 }
 
+// stampInstance adds the instance labels to a team's tags, after those from its
+// description so they win, and to its metadata.
+func (p *Provider) stampInstance(team *schema.Team) {
+	p.config.InstanceLabels.Tag(team.Tags)
+	p.config.InstanceLabels.Stamp(team.Metadata)
+}
+
 // normalizeRole converts GitHub team roles to standard roles.
 func (p *Provider) normalizeRole(role string) string {
 	switch strings.ToLower(role) {
@@ -261,40 +587,7 @@ func (p *Provider) normalizeRole(role string) string {
 
 // wrapError wraps GitHub API errors into OpsOrch errors.
 func (p *Provider) wrapError(err error) error {
-	if ghErr, ok := err.(*github.ErrorResponse); ok {
-		switch ghErr.Response.StatusCode {
-		case 401:
-			return &orcherr.OpsOrchError{
-				Code:    "unauthorized",
-				Message: "GitHub API authentication failed",
-			}
-		case 403:
-			return &orcherr.OpsOrchError{
-				Code:    "forbidden",
-				Message: "GitHub API access forbidden - check token permissions",
-			}
-		case 404:
-			return &orcherr.OpsOrchError{
-				Code:    "not_found",
-				Message: "GitHub organization or team not found",
-			}
-		case 422:
-			return &orcherr.OpsOrchError{
-				Code:    "bad_request",
-				Message: fmt.Sprintf("GitHub API validation error: %s", ghErr.Message),
-			}
-		default:
-			return &orcherr.OpsOrchError{
-				Code:    "provider_error",
-				Message: fmt.Sprintf("GitHub API error: %s", ghErr.Message),
-			}
-		}
-	}
-
-	return &orcherr.OpsOrchError{
-		Code:    "provider_error",
-		Message: fmt.Sprintf("GitHub API error: %v", err),
-	}
+	return gherr.Wrap(err, "organization or team")
 }
 
 func init() {
@@ -0,0 +1,253 @@
+package team
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+// Snapshot is the organization's whole team graph — teams, their hierarchy, and
+// their members with roles — read in bulk for periodic sync.
+type Snapshot struct {
+	Organization string         `json:"organization"`
+	GeneratedAt  time.Time      `json:"generatedAt"`
+	Teams        []SnapshotTeam `json:"teams"`
+}
+
+// SnapshotTeam is a team with its direct members. Members of child teams are
+// listed under the child, so a team's full membership includes its descendants'.
+type SnapshotTeam struct {
+	schema.Team
+	Members []schema.TeamMember `json:"members"`
+}
+
+// snapshotTeamsQuery pages through the organization's teams, with the first page
+// of each team's direct members.
+const snapshotTeamsQuery = `query($org: String!, $cursor: String) {
+  organization(login: $org) {
+    teams(first: 100, after: $cursor) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        databaseId slug name description privacy url
+        parentTeam { databaseId slug }
+        members(first: 100, membership: IMMEDIATE) {
+          totalCount
+          pageInfo { hasNextPage endCursor }
+          edges { role node { databaseId login name email url } }
+        }
+      }
+    }
+  }
+}`
+
+// snapshotMembersQuery reads further pages of one team's direct members.
+const snapshotMembersQuery = `query($org: String!, $slug: String!, $cursor: String) {
+  organization(login: $org) {
+    team(slug: $slug) {
+      members(first: 100, after: $cursor, membership: IMMEDIATE) {
+        pageInfo { hasNextPage endCursor }
+        edges { role node { databaseId login name email url } }
+      }
+    }
+  }
+}`
+
+type gqlPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+type gqlMembers struct {
+	TotalCount int         `json:"totalCount"`
+	PageInfo   gqlPageInfo `json:"pageInfo"`
+	Edges      []struct {
+		Role string `json:"role"`
+		Node struct {
+			DatabaseID int64  `json:"databaseId"`
+			Login      string `json:"login"`
+			Name       string `json:"name"`
+			Email      string `json:"email"`
+			URL        string `json:"url"`
+		} `json:"node"`
+	} `json:"edges"`
+}
+
+type gqlTeam struct {
+	DatabaseID  int64  `json:"databaseId"`
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Privacy     string `json:"privacy"`
+	URL         string `json:"url"`
+	ParentTeam  *struct {
+		DatabaseID int64  `json:"databaseId"`
+		Slug       string `json:"slug"`
+	} `json:"parentTeam"`
+	Members gqlMembers `json:"members"`
+}
+
+// Snapshot exports every team in the organization with its parent and direct
+// members in a handful of GraphQL calls, instead of the one-per-team and
+// one-per-member REST calls Query and Members make. Team and member shapes match
+// Query and Members, except that members carry only the fields GraphQL returns.
+func (p *Provider) Snapshot(ctx context.Context) (Snapshot, error) {
+	if p.api.GraphQL == nil {
+		return Snapshot{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "team snapshots are not available for this provider",
+		}
+	}
+
+	snapshot := Snapshot{Organization: p.config.Organization, GeneratedAt: time.Now().UTC(), Teams: []SnapshotTeam{}}
+	vars := map[string]any{"org": p.config.Organization, "cursor": nil}
+	for {
+		var data struct {
+			Organization *struct {
+				Teams struct {
+					PageInfo gqlPageInfo `json:"pageInfo"`
+					Nodes    []gqlTeam   `json:"nodes"`
+				} `json:"teams"`
+			} `json:"organization"`
+		}
+		if _, err := p.api.GraphQL.Query(ctx, snapshotTeamsQuery, vars, &data); err != nil {
+			return Snapshot{}, p.wrapGraphQLError(err)
+		}
+		if data.Organization == nil {
+			return Snapshot{}, &orcherr.OpsOrchError{Code: "not_found", Message: "GitHub organization or team not found"}
+		}
+
+		for _, t := range data.Organization.Teams.Nodes {
+			members, err := p.snapshotMembers(ctx, t)
+			if err != nil {
+				return Snapshot{}, err
+			}
+			snapshot.Teams = append(snapshot.Teams, SnapshotTeam{Team: p.convertSnapshotTeam(t), Members: members})
+		}
+
+		page := data.Organization.Teams.PageInfo
+		if !page.HasNextPage {
+			return snapshot, nil
+		}
+		vars["cursor"] = page.EndCursor
+	}
+}
+
+// snapshotMembers converts a team's direct members, reading the pages beyond
+// the first that came with the team.
+func (p *Provider) snapshotMembers(ctx context.Context, t gqlTeam) ([]schema.TeamMember, error) {
+	members := []schema.TeamMember{}
+	page := t.Members
+	for {
+		for _, edge := range page.Edges {
+			n := edge.Node
+			// GraphQL team members are always users, so only the login rules apply
+			isBot := p.config.BotPolicy.IsBot(n.Login, "")
+			if isBot && p.config.BotPolicy.Exclude {
+				continue
+			}
+			name := n.Name
+			if name == "" {
+				name = n.Login
+			}
+			members = append(members, schema.TeamMember{
+				ID:     n.Login,
+				Name:   name,
+				Email:  n.Email,
+				Handle: n.Login,
+				Role:   p.normalizeRole(edge.Role),
+				Metadata: map[string]any{
+					"github_id": n.DatabaseID,
+					"html_url":  n.URL,
+					"bot":       isBot,
+				},
+			})
+			p.applyIdentity(&members[len(members)-1])
+		}
+		if !page.PageInfo.HasNextPage {
+			return members, nil
+		}
+
+		var data struct {
+			Organization struct {
+				Team struct {
+					Members gqlMembers `json:"members"`
+				} `json:"team"`
+			} `json:"organization"`
+		}
+		vars := map[string]any{"org": p.config.Organization, "slug": t.Slug, "cursor": page.PageInfo.EndCursor}
+		if _, err := p.api.GraphQL.Query(ctx, snapshotMembersQuery, vars, &data); err != nil {
+			return nil, p.wrapGraphQLError(err)
+		}
+		page = data.Organization.Team.Members
+	}
+}
+
+// convertSnapshotTeam converts a GraphQL team to the normalized Team Query returns.
+func (p *Provider) convertSnapshotTeam(t gqlTeam) schema.Team {
+	// GraphQL calls visible teams what REST calls closed
+	privacy := strings.ToLower(t.Privacy)
+	if privacy == "visible" {
+		privacy = "closed"
+	}
+
+	team := schema.Team{
+		ID:   t.Slug,
+		Name: t.Name,
+		URL:  t.URL,
+		Tags: map[string]string{
+			"provider":     "github",
+			"privacy":      privacy,
+			"organization": p.config.Organization,
+		},
+		Metadata: map[string]any{
+			"github_id":     t.DatabaseID,
+			"slug":          t.Slug,
+			"description":   t.Description,
+			"privacy":       privacy,
+			"html_url":      t.URL,
+			"members_count": t.Members.TotalCount,
+		},
+	}
+	if team.ID == "" {
+		team.ID = strconv.FormatInt(t.DatabaseID, 10)
+	}
+	if parent := t.ParentTeam; parent != nil {
+		team.Parent = parent.Slug
+		if team.Parent == "" {
+			team.Parent = strconv.FormatInt(parent.DatabaseID, 10)
+		}
+	}
+	applyDescription(&team, t.Description)
+	p.stampInstance(&team)
+	return team
+}
+
+// wrapGraphQLError maps GraphQL error types to OpsOrch errors, deferring HTTP
+// errors to wrapError.
+func (p *Provider) wrapGraphQLError(err error) error {
+	var gqlErr *ghapi.GraphQLError
+	if !errors.As(err, &gqlErr) {
+		return p.wrapError(err)
+	}
+
+	code := "provider_error"
+	for _, entry := range gqlErr.Errors {
+		switch entry.Type {
+		case "NOT_FOUND":
+			code = "not_found"
+		case "FORBIDDEN", "INSUFFICIENT_SCOPES":
+			code = "forbidden"
+		}
+	}
+	return &orcherr.OpsOrchError{
+		Code:    code,
+		Message: "GitHub GraphQL error: " + err.Error(),
+		Err:     err,
+	}
+}
@@ -0,0 +1,93 @@
+package team
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/throttle"
+)
+
+// addMemberStatus adds account status to member metadata, for leaving
+// deactivated accounts out of escalation chains:
+//
+//	org_membership_state  "active", "pending", or "none" when not in the organization
+//	two_factor_enabled    whether 2FA is on; only set when the token may list 2FA status
+//	suspended             whether the account is suspended (GitHub Enterprise Server)
+//	deprovisioned         true when the account is suspended or has left the organization
+//
+// users holds the profiles Members fetched, by login. Status that cannot be read
+// is left out rather than failing the call; members the enrichment budget
+// leaves without status are marked truncated.
+func (p *Provider) addMemberStatus(ctx context.Context, members []schema.TeamMember, users map[string]*github.User) {
+	twoFactorDisabled, err := p.twoFactorDisabled(ctx)
+	if err != nil {
+		log.Printf("[team] 2FA status unavailable for organization %s: %v", p.config.Organization, err)
+	}
+
+	ctx = throttle.WithPriority(ctx, throttle.Enrichment)
+	forEach(len(members), func(i int) {
+		member := members[i]
+		login := member.Handle
+		deprovisioned := false
+
+		if !budget.Enrich(ctx) {
+			member.Metadata["truncated"] = true
+			return
+		}
+		membership, resp, err := p.api.Organizations.GetOrgMembership(ctx, login, p.config.Organization)
+		switch {
+		case err == nil:
+			member.Metadata["org_membership_state"] = membership.GetState()
+		case resp != nil && resp.StatusCode == http.StatusNotFound:
+			member.Metadata["org_membership_state"] = "none"
+			deprovisioned = true
+		default:
+			log.Printf("[team] organization membership unavailable for %s: %v", login, err)
+		}
+
+		if twoFactorDisabled != nil {
+			member.Metadata["two_factor_enabled"] = !twoFactorDisabled[login]
+		}
+
+		if user, ok := users[login]; ok {
+			suspended := user.SuspendedAt != nil
+			member.Metadata["suspended"] = suspended
+			deprovisioned = deprovisioned || suspended
+		}
+
+		member.Metadata["deprovisioned"] = deprovisioned
+	})
+}
+
+// twoFactorDisabled returns the logins of organization members without 2FA.
+// Only organization owners may filter on 2FA status.
+func (p *Provider) twoFactorDisabled(ctx context.Context) (map[string]bool, error) {
+	opts := &github.ListMembersOptions{
+		Filter: "2fa_disabled",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	logins := map[string]bool{}
+	for {
+		if !budget.Call(ctx) {
+			return nil, budget.ErrExhausted
+		}
+		users, resp, err := p.api.Organizations.ListMembers(ctx, p.config.Organization, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			logins[user.GetLogin()] = true
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return logins, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
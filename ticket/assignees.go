@@ -0,0 +1,79 @@
+package ticket
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// InvalidAssigneesError lists logins that cannot be assigned issues in the
+// repository. It is wrapped in a bad_request OpsOrchError.
+type InvalidAssigneesError struct {
+	Logins []string
+}
+
+func (e *InvalidAssigneesError) Error() string {
+	return "invalid assignees: " + strings.Join(e.Logins, ", ")
+}
+
+// isBestEffortAssignees reports whether unassignable logins should be dropped
+// instead of failing the write.
+func (p *Provider) isBestEffortAssignees(metadata map[string]any) bool {
+	return p.config.BestEffortAssignees || ghconfig.Bool(metadata, "bestEffortAssignees")
+}
+
+// checkAssignees verifies that every assignee on req can be assigned in the
+// repository (push access or org membership, as GitHub decides). Invalid logins
+// fail the call, or in best-effort mode are removed from req and returned.
+func (p *Provider) checkAssignees(ctx context.Context, req *github.IssueRequest, metadata map[string]any) ([]string, error) {
+	if req.Assignees == nil || len(*req.Assignees) == 0 {
+		return nil, nil
+	}
+
+	var valid, invalid []string
+	for _, login := range *req.Assignees {
+		ok, _, err := p.api.Issues.IsAssignee(ctx, p.config.Owner, p.config.Repo, login)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		if ok {
+			valid = append(valid, login)
+		} else {
+			invalid = append(invalid, login)
+		}
+	}
+	if len(invalid) == 0 {
+		return nil, nil
+	}
+
+	if !p.isBestEffortAssignees(metadata) {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("assignees cannot be assigned in %s/%s", p.config.Owner, p.config.Repo),
+			Err:     &InvalidAssigneesError{Logins: invalid},
+		}
+	}
+
+	log.Printf("[assignees] dropping %s: cannot be assigned in %s/%s", strings.Join(invalid, ", "), p.config.Owner, p.config.Repo)
+	// With nothing left, leave the current assignees alone rather than clearing them
+	req.Assignees = nil
+	if len(valid) > 0 {
+		req.Assignees = &valid
+	}
+	return invalid, nil
+}
+
+// withDroppedAssignees records logins removed in best-effort mode on the result,
+// so callers can surface a warning.
+func withDroppedAssignees(ticket schema.Ticket, dropped []string) schema.Ticket {
+	if len(dropped) > 0 && ticket.Fields != nil {
+		ticket.Fields["dropped_assignees"] = dropped
+	}
+	return ticket
+}
@@ -0,0 +1,222 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+// Attachment is a file, such as a log snippet, to host alongside a ticket. The
+// Issues API has no attachment upload, so attachments are stored in a secret gist
+// and linked from the issue or comment body.
+type Attachment struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// AttachmentLink is the hosted location of an uploaded attachment.
+type AttachmentLink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// parseAttachments reads the "attachments" metadata list.
+func parseAttachments(metadata map[string]any) ([]Attachment, error) {
+	raw, ok := metadata["attachments"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	// Round-trip through JSON so decoded payloads ([]any of maps) and typed
+	// attachments passed in-process are handled the same way
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, attachmentError("attachments: %v", err)
+	}
+	var attachments []Attachment
+	if err := json.Unmarshal(data, &attachments); err != nil {
+		return nil, attachmentError("attachments must be a list of {name, content}")
+	}
+
+	seen := map[string]bool{}
+	for i, a := range attachments {
+		if strings.TrimSpace(a.Name) == "" || strings.ContainsAny(a.Name, "/\\") {
+			return nil, attachmentError("attachments[%d]: a file name without slashes is required", i)
+		}
+		if a.Content == "" {
+			return nil, attachmentError("attachments[%d]: content is required", i)
+		}
+		if seen[a.Name] {
+			return nil, attachmentError("attachments[%d]: duplicate name %s", i, a.Name)
+		}
+		seen[a.Name] = true
+	}
+	return attachments, nil
+}
+
+func attachmentError(format string, args ...any) error {
+	return &orcherr.OpsOrchError{
+		Code:    "bad_request",
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// UploadAttachments stores attachments as files of one secret gist and returns a
+// link to each. description labels the gist. Secret gists can be read by anyone
+// with the link, so attachments are refused for private repositories unless
+// privateAttachments is set.
+func (p *Provider) UploadAttachments(ctx context.Context, description string, attachments []Attachment) ([]AttachmentLink, error) {
+	links, _, err := p.uploadAttachments(ctx, description, attachments)
+	return links, err
+}
+
+// uploadAttachments is UploadAttachments, also returning the gist's ID.
+func (p *Provider) uploadAttachments(ctx context.Context, description string, attachments []Attachment) ([]AttachmentLink, string, error) {
+	if len(attachments) == 0 {
+		return nil, "", nil
+	}
+	if p.api.Gists == nil {
+		return nil, "", &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "attachments are not available for this provider",
+		}
+	}
+	if err := p.checkAttachmentVisibility(ctx); err != nil {
+		return nil, "", err
+	}
+
+	files := make(map[github.GistFilename]github.GistFile, len(attachments))
+	for _, a := range attachments {
+		files[github.GistFilename(a.Name)] = github.GistFile{Content: github.String(a.Content)}
+	}
+	gist, _, err := p.api.Gists.Create(ctx, &github.Gist{
+		Description: github.String(description),
+		Public:      github.Bool(false),
+		Files:       files,
+	})
+	names := make([]string, len(attachments))
+	for i, a := range attachments {
+		names[i] = a.Name
+	}
+	p.audit("ticket.attach", "", map[string]any{"description": description, "files": names}, gist.GetHTMLURL(), err)
+	if err != nil {
+		return nil, "", p.wrapError(err)
+	}
+
+	links := make([]AttachmentLink, len(attachments))
+	for i, a := range attachments {
+		links[i] = AttachmentLink{Name: a.Name, URL: gist.GetHTMLURL() + "#" + gistFileAnchor(a.Name)}
+	}
+	return links, gist.GetID(), nil
+}
+
+// checkAttachmentVisibility refuses attachments for a private repository, whose
+// files would be readable by anyone holding a gist link, unless
+// privateAttachments accepts that; then it only warns.
+func (p *Provider) checkAttachmentVisibility(ctx context.Context) error {
+	if p.api.Repositories == nil {
+		return nil
+	}
+	repository, _, err := p.api.Repositories.Get(ctx, p.config.Owner, p.config.Repo)
+	if err != nil {
+		return p.wrapError(err)
+	}
+	if !repository.GetPrivate() {
+		return nil
+	}
+	if p.config.PrivateAttachments {
+		log.Printf("[attachments] %s/%s is private; its attachments are readable by anyone with the gist link", p.config.Owner, p.config.Repo)
+		return nil
+	}
+	return &orcherr.OpsOrchError{
+		Code:    "bad_request",
+		Message: fmt.Sprintf("%s/%s is private, and attachments are stored in secret gists anyone with the link can read (set privateAttachments to allow)", p.config.Owner, p.config.Repo),
+	}
+}
+
+// maxGistURL is the length reserved for each attachment link's gist URL when a
+// body is checked before the gist exists; GitHub's are about 60 characters.
+const maxGistURL = 128
+
+// attachBody uploads the attachments named in metadata and appends links to body.
+// check validates the body the write will send, with room for the links, before
+// anything is uploaded. The returned function deletes the gist, for a write
+// that fails after the upload. In dry-run mode nothing is uploaded and the names
+// are listed instead.
+func (p *Provider) attachBody(ctx context.Context, description, body string, metadata map[string]any, check func(body string) error) (string, func(), error) {
+	discard := func() {}
+	attachments, err := parseAttachments(metadata)
+	if err != nil || len(attachments) == 0 {
+		return body, discard, err
+	}
+
+	reserved := make([]AttachmentLink, len(attachments))
+	for i, a := range attachments {
+		reserved[i] = AttachmentLink{Name: a.Name, URL: strings.Repeat("x", maxGistURL) + "#" + gistFileAnchor(a.Name)}
+	}
+	if err := check(appendAttachmentLinks(body, reserved)); err != nil {
+		return "", discard, err
+	}
+
+	if p.isDryRun(metadata) {
+		log.Printf("[dry-run] POST /gists files=%d", len(attachments))
+		links := make([]AttachmentLink, len(attachments))
+		for i, a := range attachments {
+			links[i] = AttachmentLink{Name: a.Name}
+		}
+		return appendAttachmentLinks(body, links), discard, nil
+	}
+
+	links, gistID, err := p.uploadAttachments(ctx, description, attachments)
+	if err != nil {
+		return "", discard, err
+	}
+	discard = func() {
+		// The write has failed already, so the request's context may be done
+		_, err := p.api.Gists.Delete(context.WithoutCancel(ctx), gistID)
+		p.audit("gist.delete", gistID, map[string]any{"description": description}, "", err)
+		if err != nil {
+			log.Printf("[attachments] deleting gist %s after a failed write: %v", gistID, err)
+		}
+	}
+	return appendAttachmentLinks(body, links), discard, nil
+}
+
+// appendAttachmentLinks adds an Attachments section listing links to body.
+func appendAttachmentLinks(body string, links []AttachmentLink) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(body, "\n"))
+	if b.Len() > 0 {
+		b.WriteString("\n\n")
+	}
+	b.WriteString("### Attachments\n")
+	for _, l := range links {
+		if l.URL == "" {
+			fmt.Fprintf(&b, "\n- %s", l.Name)
+		} else {
+			fmt.Fprintf(&b, "\n- [%s](%s)", l.Name, l.URL)
+		}
+	}
+	return b.String()
+}
+
+// gistFileAnchor returns the fragment GitHub uses for a file on a gist page:
+// "file-" followed by the lower-cased name with other characters as dashes.
+func gistFileAnchor(name string) string {
+	var b strings.Builder
+	b.WriteString("file-")
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
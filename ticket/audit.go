@@ -0,0 +1,28 @@
+package ticket
+
+import (
+	"fmt"
+
+	"github.com/opsorch/opsorch-github-adapter/audit"
+)
+
+// audit records a write to the provider's repository in the audit log. target
+// is the issue number written, and url the GitHub URL of the result.
+func (p *Provider) audit(operation, target string, input any, url string, err error) {
+	entry := audit.Entry{
+		Operation:  operation,
+		Repository: p.config.Owner + "/" + p.config.Repo,
+		Target:     target,
+		Input:      input,
+		URL:        url,
+	}
+	if err != nil {
+		entry.Error = p.wrapError(err).Error()
+	}
+	p.config.Audit.Record(entry)
+}
+
+// issueURL returns the web URL of an issue in the provider's repository.
+func (p *Provider) issueURL(number int) string {
+	return fmt.Sprintf("https://github.com/%s/%s/issues/%d", p.config.Owner, p.config.Repo, number)
+}
@@ -0,0 +1,81 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+	"github.com/opsorch/opsorch-github-adapter/internal/fieldset"
+)
+
+// reportCalls reports the API calls srv received per benchmark iteration.
+func reportCalls(b *testing.B, srv *fakegithub.Server) {
+	b.ReportMetric(float64(len(srv.Requests()))/float64(b.N), "calls/op")
+}
+
+func BenchmarkConvertIssue(b *testing.B) {
+	p, _ := newFakeProvider(b)
+	var issue github.Issue
+	if err := json.Unmarshal(fakegithub.Fixture("issue.json"), &issue); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.convertIssueToTicket(&issue)
+	}
+}
+
+func BenchmarkConvertIssueLean(b *testing.B) {
+	p, _ := newFakeProvider(b)
+	var issue github.Issue
+	if err := json.Unmarshal(fakegithub.Fixture("issue.json"), &issue); err != nil {
+		b.Fatal(err)
+	}
+	want := fieldset.Parse(map[string]any{"fields": []string{"labels"}})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.convertIssue(&issue, want)
+	}
+}
+
+func BenchmarkQuery(b *testing.B) {
+	p, srv := newFakeProvider(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Query(context.Background(), schema.TicketQuery{Limit: 50}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportCalls(b, srv)
+}
+
+func BenchmarkQueryPaged(b *testing.B) {
+	p, srv := newFakeProvider(b)
+	srv.PageSize = 1
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Query(context.Background(), schema.TicketQuery{Limit: 3}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportCalls(b, srv)
+}
+
+func BenchmarkGet(b *testing.B) {
+	p, srv := newFakeProvider(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Get(context.Background(), "1"); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportCalls(b, srv)
+}
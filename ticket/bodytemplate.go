@@ -0,0 +1,114 @@
+package ticket
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// bodyData is what a body template renders.
+type bodyData struct {
+	Kind        string
+	Title       string
+	Description string
+	Fields      map[string]any
+	Metadata    map[string]any
+}
+
+// bodyFuncs are the functions body templates may call besides Go's builtins.
+var bodyFuncs = template.FuncMap{
+	// default returns value, or fallback when value is missing or empty
+	"default": func(fallback, value any) any {
+		if value == nil || value == "" {
+			return fallback
+		}
+		return value
+	},
+	// join joins a list, as it arrives through the plugins, with sep
+	"join": func(sep string, value any) string {
+		return strings.Join(ghconfig.StringSlice(map[string]any{"v": value}, "v"), sep)
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// parseBodyTemplates reads and compiles the "bodyTemplates" config object of Go
+// templates by ticket kind.
+func parseBodyTemplates(cfg map[string]any) (map[string]*template.Template, error) {
+	raw, ok := cfg["bodyTemplates"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	sources, ok := raw.(map[string]any)
+	if !ok {
+		if typed, isTyped := raw.(map[string]string); isTyped {
+			sources = make(map[string]any, len(typed))
+			for kind, source := range typed {
+				sources[kind] = source
+			}
+		} else {
+			return nil, fmt.Errorf("bodyTemplates must be an object of templates by kind")
+		}
+	}
+
+	templates := make(map[string]*template.Template, len(sources))
+	for kind, value := range sources {
+		source, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("bodyTemplates.%s must be a string", kind)
+		}
+		tmpl, err := template.New(kind).Funcs(bodyFuncs).Option("missingkey=error").Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("bodyTemplates.%s: %w", kind, err)
+		}
+		templates[strings.ToLower(kind)] = tmpl
+	}
+	return templates, nil
+}
+
+// renderBody renders the body template of kind with the create request's title,
+// description, fields, and metadata. A field a template names but the request
+// lacks fails the create, so templates use index or default for optional ones.
+func (p *Provider) renderBody(kind string, input schema.CreateTicketInput) (string, error) {
+	tmpl, ok := p.config.BodyTemplates[strings.ToLower(kind)]
+	if !ok {
+		kinds := make([]string, 0, len(p.config.BodyTemplates))
+		for k := range p.config.BodyTemplates {
+			kinds = append(kinds, k)
+		}
+		sort.Strings(kinds)
+		return "", &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("no body template for kind %q (configured: %s)", kind, strings.Join(kinds, ", ")),
+		}
+	}
+
+	data := bodyData{
+		Kind:        strings.ToLower(kind),
+		Title:       strings.TrimSpace(input.Title),
+		Description: input.Description,
+		Fields:      input.Fields,
+		Metadata:    input.Metadata,
+	}
+	if data.Fields == nil {
+		data.Fields = map[string]any{}
+	}
+	if data.Metadata == nil {
+		data.Metadata = map[string]any{}
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("rendering %s body: %v", data.Kind, err),
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,87 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/limits"
+)
+
+func TestCreateBodyTemplate(t *testing.T) {
+	ctx := context.Background()
+	templates, err := parseBodyTemplates(map[string]any{"bodyTemplates": map[string]any{
+		"Incident": "## {{ .Title }}\n\nImpact: {{ .Fields.impact }}\nServices: {{ .Fields.services | join \", \" }}\nOwner: {{ index .Fields \"owner\" | default \"unassigned\" }}\nSource: {{ .Metadata.source }}\n\n{{ .Description }}",
+		"task":     "{{ .Description }}",
+	}})
+	if err != nil {
+		t.Fatalf("parseBodyTemplates() error = %v", err)
+	}
+
+	p, srv := newFakeProvider(t)
+	p.config.BodyTemplates = templates
+	_, err = p.Create(ctx, schema.CreateTicketInput{
+		Title:       "Checkout errors",
+		Description: "500s on /pay",
+		Fields:      map[string]any{"impact": "EU customers", "services": []any{"checkout", "web"}},
+		Metadata:    map[string]any{"kind": "incident", "source": "pagerduty"},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	var body map[string]any
+	for _, r := range srv.Requests() {
+		if r.Method == http.MethodPost && r.Path == "/repos/acme/api/issues" {
+			json.Unmarshal(r.Body, &body)
+		}
+	}
+	want := "## Checkout errors\n\nImpact: EU customers\nServices: checkout, web\nOwner: unassigned\nSource: pagerduty\n\n500s on /pay"
+	if body["body"] != want {
+		t.Errorf("body = %q, want %q", body["body"], want)
+	}
+
+	for name, input := range map[string]schema.CreateTicketInput{
+		"unknown kind":  {Title: "x", Metadata: map[string]any{"kind": "postmortem"}},
+		"missing field": {Title: "x", Metadata: map[string]any{"kind": "incident", "source": "api"}},
+		"with template": {Title: "x", Metadata: map[string]any{"kind": "task", "template": "bug"}},
+	} {
+		if _, err := p.Create(ctx, input); !hasCode(err, "bad_request") {
+			t.Errorf("%s: Create() error = %v, want bad_request", name, err)
+		}
+	}
+}
+
+func TestCreateBodyTemplateLimits(t *testing.T) {
+	templates, err := parseBodyTemplates(map[string]any{"bodyTemplates": map[string]any{
+		"incident": "{{ .Description }}\n\n{{ .Description }}",
+	}})
+	if err != nil {
+		t.Fatalf("parseBodyTemplates() error = %v", err)
+	}
+	p, srv := newFakeProvider(t)
+	p.config.BodyTemplates = templates
+
+	// The description fits, but the rendered body does not
+	description := strings.Repeat("x", limits.MaxBody/2+1)
+	_, err = p.Create(context.Background(), schema.CreateTicketInput{Title: "Checkout errors", Description: description, Metadata: map[string]any{"kind": "incident"}})
+	if !hasCode(err, "bad_request") || !strings.Contains(err.Error(), "body is") {
+		t.Errorf("Create() error = %v, want bad_request for the body", err)
+	}
+	if len(srv.Requests()) != 0 {
+		t.Errorf("Create() made %d requests", len(srv.Requests()))
+	}
+}
+
+func TestParseBodyTemplates(t *testing.T) {
+	if templates, err := parseBodyTemplates(map[string]any{}); templates != nil || err != nil {
+		t.Errorf("parseBodyTemplates() without templates = %v, %v", templates, err)
+	}
+	for _, raw := range []any{"{{ .Title }}", map[string]any{"task": 1}, map[string]any{"task": "{{ .Title "}} {
+		if _, err := parseBodyTemplates(map[string]any{"bodyTemplates": raw}); err == nil {
+			t.Errorf("parseBodyTemplates(%v) succeeded", raw)
+		}
+	}
+}
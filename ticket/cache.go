@@ -0,0 +1,152 @@
+package ticket
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/cache"
+	"github.com/opsorch/opsorch-github-adapter/internal/lastgood"
+	"github.com/opsorch/opsorch-github-adapter/internal/timefmt"
+)
+
+// cacheScopes numbers providers, so each caches the tickets it shapes apart from
+// providers configured differently, such as those of other profiles.
+var cacheScopes atomic.Uint64
+
+// recordKey identifies a ticket across providers. Watch callers listen on it and
+// Invalidate drops every provider's copy under it.
+func (p *Provider) recordKey(id string) string {
+	return "ticket/" + p.config.Owner + "/" + p.config.Repo + "/" + id
+}
+
+// cacheKey identifies this provider's copy of a ticket in the shared response cache.
+func (p *Provider) cacheKey(id string) string {
+	return p.recordKey(id) + "/" + strconv.FormatUint(p.cacheScope, 10)
+}
+
+// cachedTicket returns the cached ticket for id when caching is enabled.
+func (p *Provider) cachedTicket(id string) (schema.Ticket, bool) {
+	if p.config.CacheTTL <= 0 {
+		return schema.Ticket{}, false
+	}
+	if v, ok := cache.Default.Get(p.cacheKey(id)); ok {
+		return cloneTicket(v.(schema.Ticket)), true
+	}
+	return schema.Ticket{}, false
+}
+
+// Prime stores a copy of a ticket in the response cache so later Get calls are
+// served without an API request. It is a no-op unless cacheTTL is configured.
+// Only Get primes, once it has added the rendered description, linked
+// deployments, and resolution; Publish keeps those copies up to date.
+func (p *Provider) Prime(ticket schema.Ticket) {
+	if ticket.ID == "" {
+		return
+	}
+	cache.Default.Set(p.cacheKey(ticket.ID), cloneTicket(ticket), p.config.CacheTTL)
+}
+
+// Publish merges a changed ticket into every provider's cached copy and hands it
+// to Watch callers. Writes and webhook deliveries publish instead of priming, as
+// their tickets lack what Get adds. A copy the change cannot be merged into, as
+// mergeTicket decides, is dropped and fetched again by the next Get.
+func (p *Provider) Publish(ticket schema.Ticket) {
+	if ticket.ID == "" {
+		return
+	}
+	cache.Default.UpdatePrefix(p.recordKey(ticket.ID)+"/", func(v any) (any, bool) {
+		return mergeTicket(v.(schema.Ticket), ticket)
+	})
+	cache.Default.Notify(p.recordKey(ticket.ID), cloneTicket(ticket))
+}
+
+// closedFields are the Fields entries only a closed ticket has, from conversion
+// and from Get's resolution lookup.
+var closedFields = []string{
+	"closed_at", "closed_at" + timefmt.MillisSuffix, "close_duration_seconds", "closed_by",
+	"closing_commit", "closing_pull_request", "closing_pull_request_url", "deployed_in", "deployed_in_url",
+}
+
+// routedFields are the Fields entries routing rules derive from the title and labels.
+var routedFields = []string{"team", "service", "severity"}
+
+// mergeTicket brings a cached ticket up to date with a changed one: its status,
+// labels, update time, and any linked deployments it carries. ok is false when
+// the merge is ambiguous: the change is older than the copy, closes the issue,
+// whose resolution Get looks up, or touches the title, description, or
+// assignees, which the copy may have shaped or rendered differently, or its
+// labels while the copy carries routed values.
+func mergeTicket(cached, changed schema.Ticket) (schema.Ticket, bool) {
+	if changed.UpdatedAt.Before(cached.UpdatedAt) ||
+		changed.Title != cached.Title ||
+		changed.Description != cached.Description ||
+		!slices.Equal(changed.Assignees, cached.Assignees) {
+		return cached, false
+	}
+	_, wasClosed := cached.Fields["closed_at"]
+	_, closed := changed.Fields["closed_at"]
+	if closed && !wasClosed {
+		return cached, false
+	}
+	fields := cache.Clone(changed.Fields)
+	labels, hasLabels := fields["labels"]
+	if !reflect.DeepEqual(labels, cached.Fields["labels"]) && slices.ContainsFunc(routedFields, func(f string) bool {
+		_, ok := cached.Fields[f]
+		return ok
+	}) {
+		return cached, false
+	}
+
+	merged := cloneTicket(cached)
+	if merged.Fields == nil {
+		merged.Fields = map[string]any{}
+	}
+	merged.Status = changed.Status
+	merged.UpdatedAt = changed.UpdatedAt
+	if _, ok := merged.Fields["updated_at"+timefmt.MillisSuffix]; ok {
+		timefmt.SetMillis(merged.Fields, "updated_at", changed.UpdatedAt)
+	}
+	if hasLabels {
+		merged.Fields["labels"] = labels
+	} else {
+		delete(merged.Fields, "labels")
+	}
+	if deployments, ok := fields["deployments"]; ok {
+		merged.Fields["deployments"] = deployments
+	}
+	if wasClosed && !closed {
+		for _, f := range closedFields {
+			delete(merged.Fields, f)
+		}
+	}
+	return merged, true
+}
+
+// cloneTicket copies the Fields and Metadata of a ticket, which the cache and
+// its callers must not share.
+func cloneTicket(ticket schema.Ticket) schema.Ticket {
+	ticket.Fields = cache.Clone(ticket.Fields)
+	ticket.Metadata = cache.Clone(ticket.Metadata)
+	return ticket
+}
+
+// Invalidate drops every provider's copy of a ticket from the response cache.
+func (p *Provider) Invalidate(id string) {
+	cache.Default.DeletePrefix(p.recordKey(id) + "/")
+}
+
+// markStale flags a last-known-good ticket served during an outage.
+func markStale(ticket *schema.Ticket, savedAt time.Time) {
+	ticket.Metadata = lastgood.Mark(ticket.Metadata, savedAt)
+}
+
+// markStalePage flags every ticket of a last-known-good page.
+func markStalePage(page *Page, savedAt time.Time) {
+	for i := range page.Tickets {
+		markStale(&page.Tickets[i], savedAt)
+	}
+}
@@ -0,0 +1,27 @@
+package ticket
+
+import (
+	"github.com/opsorch/opsorch-github-adapter/capability"
+	"github.com/opsorch/opsorch-github-adapter/internal/paging"
+)
+
+// Capabilities describes what the provider supports as configured. With
+// readOnly, creates and updates are simulated.
+func (p *Provider) Capabilities() capability.Capabilities {
+	return capability.Capabilities{
+		Provider:         "ticket",
+		SupportsCreate:   true,
+		SupportsUpdate:   true,
+		SupportsComments: true,
+		SupportsWatch:    true,
+		SupportsPaging:   true,
+		DryRun:           p.config.ReadOnly,
+		MaxLimit:         paging.MaxResults,
+		SupportedFilters: []string{
+			"statuses", "scope.team", "scope.service", "query",
+			"metadata.labels", "metadata.org", "metadata.sort", "metadata.direction",
+			"metadata.savedQuery", "metadata.fields", "metadata.pageToken",
+			"metadata.owner", "metadata.repo", "metadata.repository",
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package ticket
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/limits"
+	"github.com/opsorch/opsorch-github-adapter/internal/timefmt"
+)
+
+// Comment is a normalized issue comment.
+type Comment struct {
+	ID        string    `json:"id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	URL       string    `json:"url,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Comments returns all comments on a ticket, oldest first.
+func (p *Provider) Comments(ctx context.Context, id string) ([]Comment, error) {
+	target, issueNumber, err := p.target(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return target.comments(ctx, issueNumber)
+}
+
+func (p *Provider) comments(ctx context.Context, issueNumber int) ([]Comment, error) {
+	opts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var comments []Comment
+	for {
+		page, resp, err := p.api.Issues.ListComments(ctx, p.config.Owner, p.config.Repo, issueNumber, opts)
+		if err != nil {
+			return nil, p.wrapError(err)
+		}
+		for _, c := range page {
+			comments = append(comments, convertComment(c))
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return comments, nil
+}
+
+// AddComment posts a comment on a ticket. Files in metadata "attachments" are
+// uploaded to a gist and linked at the end of the comment. Under readOnly or
+// metadata dryRun the comment is logged and returned without being posted.
+func (p *Provider) AddComment(ctx context.Context, id, body string, metadata map[string]any) (Comment, error) {
+	target, issueNumber, err := p.target(ctx, id)
+	if err != nil {
+		return Comment{}, err
+	}
+	return target.addComment(ctx, issueNumber, body, metadata)
+}
+
+func (p *Provider) addComment(ctx context.Context, issueNumber int, body string, metadata map[string]any) (Comment, error) {
+	var err error
+	if strings.TrimSpace(body) == "" {
+		return Comment{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "comment body is required",
+		}
+	}
+
+	// Host attachments in a gist and link them from the comment
+	gistDescription := fmt.Sprintf("Attachments for %s/%s#%d", p.config.Owner, p.config.Repo, issueNumber)
+	var discardAttachments func()
+	body, discardAttachments, err = p.attachBody(ctx, gistDescription, body, metadata, func(body string) error {
+		return limits.Issue("", body, nil)
+	})
+	if err != nil {
+		return Comment{}, err
+	}
+
+	if p.isDryRun(metadata) {
+		log.Printf("[dry-run] POST /repos/%s/%s/issues/%d/comments", p.config.Owner, p.config.Repo, issueNumber)
+		return Comment{Body: body, CreatedAt: time.Now().UTC()}, nil
+	}
+
+	comment, _, err := p.api.Issues.CreateComment(ctx, p.config.Owner, p.config.Repo, issueNumber, &github.IssueComment{Body: &body})
+	p.audit("ticket.comment", strconv.Itoa(issueNumber), map[string]any{"body": body}, comment.GetHTMLURL(), err)
+	if err != nil {
+		discardAttachments()
+		return Comment{}, p.wrapError(err)
+	}
+	return convertComment(comment), nil
+}
+
+// convertComment converts a GitHub issue comment to a normalized Comment.
+func convertComment(c *github.IssueComment) Comment {
+	return Comment{
+		ID:        strconv.FormatInt(c.GetID(), 10),
+		Author:    c.GetUser().GetLogin(),
+		Body:      c.GetBody(),
+		URL:       c.GetHTMLURL(),
+		CreatedAt: timefmt.UTC(c.GetCreatedAt().Time),
+	}
+}
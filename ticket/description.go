@@ -0,0 +1,102 @@
+package ticket
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Values accepted for the "descriptionFormat" config key.
+const (
+	DescriptionMarkdown = "markdown"
+	DescriptionPlain    = "plain"
+)
+
+var (
+	htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+	imagePattern       = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkPattern        = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	htmlTagPattern     = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	fencePattern       = regexp.MustCompile("^\\s*(```|~~~)")
+	headingPattern     = regexp.MustCompile(`^\s{0,3}#{1,6}\s+`)
+	quotePattern       = regexp.MustCompile(`^\s{0,3}(>\s?)+`)
+	bulletPattern      = regexp.MustCompile(`^(\s*)[*+]\s+`)
+	taskPattern        = regexp.MustCompile(`^(\s*-\s+)\[[ xX]\]\s+`)
+	rulePattern        = regexp.MustCompile(`^\s{0,3}([-*_]\s*){3,}$`)
+	tableRulePattern   = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	emphasisPattern    = regexp.MustCompile(`(\*\*|__|~~)(\S(?:.*?\S)?)(\*\*|__|~~)`)
+	italicPattern      = regexp.MustCompile(`(^|[\s(])[*_](\S(?:[^*_]*?\S)?)[*_]($|[\s).,;:!?])`)
+	inlineCodePattern  = regexp.MustCompile("`([^`]*)`")
+	blankLinesPattern  = regexp.MustCompile(`\n{3,}`)
+)
+
+// markdownToPlain strips Markdown and inline HTML from an issue body, keeping link
+// and image text, code contents, and list structure.
+func markdownToPlain(body string) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	body = htmlCommentPattern.ReplaceAllString(body, "")
+
+	var lines []string
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			lines = append(lines, line)
+			continue
+		}
+		if rulePattern.MatchString(line) || tableRulePattern.MatchString(line) {
+			continue
+		}
+
+		line = headingPattern.ReplaceAllString(line, "")
+		line = quotePattern.ReplaceAllString(line, "")
+		line = bulletPattern.ReplaceAllString(line, "$1- ")
+		line = taskPattern.ReplaceAllString(line, "$1")
+		line = imagePattern.ReplaceAllString(line, "$1")
+		line = linkPattern.ReplaceAllString(line, "$1")
+		line = htmlTagPattern.ReplaceAllString(line, "")
+		line = inlineCodePattern.ReplaceAllString(line, "$1")
+		line = emphasisPattern.ReplaceAllString(line, "$2")
+		line = italicPattern.ReplaceAllString(line, "$1$2$3")
+		lines = append(lines, strings.TrimRightFunc(line, unicode.IsSpace))
+	}
+
+	text := html.UnescapeString(strings.Join(lines, "\n"))
+	text = blankLinesPattern.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// truncate shortens s to at most max runes, preferring to cut at a word boundary,
+// and reports whether it did.
+func truncate(s string, max int) (string, bool) {
+	runes := []rune(s)
+	if max <= 0 || len(runes) <= max {
+		return s, false
+	}
+
+	// Leave room for the ellipsis
+	cut := max - 1
+	if cut <= 0 {
+		return string(runes[:max]), true
+	}
+	// Back up to whitespace unless that would drop more than a fifth of the text
+	for i := cut; i > cut*4/5; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace) + "…", true
+}
+
+// formatDescription applies the configured description format and length limit.
+func (p *Provider) formatDescription(body string) (string, bool) {
+	if p.config.DescriptionFormat == DescriptionPlain {
+		body = markdownToPlain(body)
+	}
+	return truncate(body, p.config.DescriptionMaxLength)
+}
@@ -0,0 +1,88 @@
+package ticket
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+func TestMarkdownToPlain(t *testing.T) {
+	body := "<!-- Please fill in the template -->\n" +
+		"## Summary\r\n" +
+		"Checkout **fails** for _some_ users, see [the dashboard](https://grafana/d/1) &amp; `api_errors_total`.\n\n\n\n" +
+		"> Quoted from support\n\n" +
+		"* step one\n" +
+		"- [x] status page updated\n\n" +
+		"| Region | Errors |\n|---|---:|\n| eu | 512 |\n\n" +
+		"---\n" +
+		"![screenshot](https://user-images/1.png)\n" +
+		"<img width=\"400\" src=\"https://user-images/2.png\">\n" +
+		"```\npanic: nil map\n  **not bold**\n```\n" +
+		"snake_case_name stays"
+
+	want := "Summary\n" +
+		"Checkout fails for some users, see the dashboard & api_errors_total.\n\n" +
+		"Quoted from support\n\n" +
+		"- step one\n" +
+		"- status page updated\n\n" +
+		"| Region | Errors |\n| eu | 512 |\n\n" +
+		"screenshot\n\n" +
+		"panic: nil map\n  **not bold**\n" +
+		"snake_case_name stays"
+	if got := markdownToPlain(body); got != want {
+		t.Errorf("markdownToPlain() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+		cut  bool
+	}{
+		{"short", 0, "short", false},
+		{"short", 10, "short", false},
+		{"the quick brown fox jumps", 20, "the quick brown fox…", true},
+		{"abcdefghijklmnopqrstuvwxyz", 10, "abcdefghi…", true},
+		{"日本語のテキストです", 5, "日本語の…", true},
+		{"ab", 1, "a", true},
+	}
+	for _, tt := range tests {
+		got, cut := truncate(tt.in, tt.max)
+		if got != tt.want || cut != tt.cut {
+			t.Errorf("truncate(%q, %d) = %q, %v, want %q, %v", tt.in, tt.max, got, cut, tt.want, tt.cut)
+		}
+	}
+}
+
+func TestDescriptionOptions(t *testing.T) {
+	issue := &github.Issue{Number: github.Int(1), Body: github.String("## Impact\n**All** EU customers cannot check out")}
+
+	p, err := NewWithServices(map[string]any{
+		"repository":           "acme/api",
+		"descriptionFormat":    "plain",
+		"descriptionMaxLength": float64(24),
+	}, ghapi.Services{Issues: &stubIssues{}})
+	if err != nil {
+		t.Fatalf("NewWithServices() error = %v", err)
+	}
+	got := p.ConvertIssue(issue)
+	if got.Description != "Impact\nAll EU customers…" || got.Fields["description_truncated"] != true {
+		t.Errorf("description = %q, fields = %v", got.Description, got.Fields)
+	}
+
+	p, err = NewWithServices(map[string]any{"repository": "acme/api"}, ghapi.Services{Issues: &stubIssues{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = p.ConvertIssue(issue)
+	if got.Description != issue.GetBody() || got.Fields["description_truncated"] != nil {
+		t.Errorf("default description = %q, fields = %v", got.Description, got.Fields)
+	}
+
+	if _, err := NewWithServices(map[string]any{"repository": "acme/api", "descriptionFormat": "html"}, ghapi.Services{Issues: &stubIssues{}}); err == nil {
+		t.Error("expected error for unknown descriptionFormat")
+	}
+}
@@ -0,0 +1,81 @@
+package ticket
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// isDryRun reports whether a write should be simulated, either because the provider
+// is configured read-only or because the caller asked for a dry run.
+func (p *Provider) isDryRun(metadata map[string]any) bool {
+	return p.config.ReadOnly || ghconfig.Bool(metadata, "dryRun")
+}
+
+// simulateCreate logs the issue that would have been created and returns it as a ticket
+// without calling GitHub.
+func (p *Provider) simulateCreate(req *github.IssueRequest) schema.Ticket {
+	log.Printf("[dry-run] POST /repos/%s/%s/issues title=%q", p.config.Owner, p.config.Repo, req.GetTitle())
+
+	issue := &github.Issue{
+		Title: req.Title,
+		Body:  req.Body,
+		State: github.String(p.config.DefaultState),
+	}
+	applyIssueRequest(issue, req)
+
+	ticket := p.convertIssueToTicket(issue)
+	ticket.ID = ""
+	ticket.Fields["dry_run"] = true
+	return ticket
+}
+
+// simulateUpdate fetches the current issue and applies the requested edit locally,
+// returning the would-be result without calling the edit endpoint.
+func (p *Provider) simulateUpdate(ctx context.Context, number int, req *github.IssueRequest, clearMilestone bool) (schema.Ticket, error) {
+	log.Printf("[dry-run] PATCH /repos/%s/%s/issues/%d", p.config.Owner, p.config.Repo, number)
+
+	issue, _, err := p.api.Issues.Get(ctx, p.config.Owner, p.config.Repo, number)
+	if err != nil {
+		return schema.Ticket{}, p.wrapError(err)
+	}
+	if req.Title != nil {
+		issue.Title = req.Title
+	}
+	if req.Body != nil {
+		issue.Body = req.Body
+	}
+	if req.State != nil {
+		issue.State = req.State
+	}
+	applyIssueRequest(issue, req)
+	if req.Milestone != nil {
+		issue.Milestone = &github.Milestone{Number: req.Milestone}
+	}
+	if clearMilestone {
+		issue.Milestone = nil
+	}
+
+	ticket := p.convertIssueToTicket(issue)
+	ticket.Fields["dry_run"] = true
+	return ticket, nil
+}
+
+// applyIssueRequest copies the assignee and label parts of a request onto an issue.
+func applyIssueRequest(issue *github.Issue, req *github.IssueRequest) {
+	if req.Assignees != nil {
+		issue.Assignees = make([]*github.User, 0, len(*req.Assignees))
+		for _, login := range *req.Assignees {
+			issue.Assignees = append(issue.Assignees, &github.User{Login: github.String(login)})
+		}
+	}
+	if req.Labels != nil {
+		issue.Labels = make([]*github.Label, 0, len(*req.Labels))
+		for _, name := range *req.Labels {
+			issue.Labels = append(issue.Labels, &github.Label{Name: github.String(name)})
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package ticket
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+// Gist is a scratchpad stored as a GitHub gist, such as incident notes or a
+// shared debug log, kept out of the issue tracker.
+type Gist struct {
+	ID          string     `json:"id"`
+	URL         string     `json:"url"`
+	Description string     `json:"description"`
+	Public      bool       `json:"public"`
+	Files       []GistFile `json:"files"` // Sorted by name
+	CreatedAt   time.Time  `json:"createdAt,omitempty"`
+	UpdatedAt   time.Time  `json:"updatedAt,omitempty"`
+}
+
+// GistFile is one file of a gist. GitHub returns at most one megabyte of a
+// file's content; Truncated is set when there was more, and RawURL serves it all.
+type GistFile struct {
+	Name      string `json:"name"`
+	Content   string `json:"content"`
+	Language  string `json:"language,omitempty"`
+	Size      int    `json:"size"`
+	Truncated bool   `json:"truncated,omitempty"`
+	RawURL    string `json:"rawUrl,omitempty"`
+}
+
+// GistInput is the content of a gist to create or update. Files maps file
+// names to their content; on update, files not named are left as they are.
+// Metadata "dryRun" simulates the write, as for tickets.
+type GistInput struct {
+	Description string            `json:"description,omitempty"`
+	Public      bool              `json:"public,omitempty"` // Only read on create; GitHub cannot change it later
+	Files       map[string]string `json:"files"`
+	Metadata    map[string]any    `json:"metadata,omitempty"`
+}
+
+// CreateGist stores the files as a new gist, secret unless Public is set.
+func (p *Provider) CreateGist(ctx context.Context, input GistInput) (Gist, error) {
+	if err := p.checkGists(); err != nil {
+		return Gist{}, err
+	}
+	if len(input.Files) == 0 {
+		return Gist{}, gistError("at least one file is required")
+	}
+	files, err := gistFiles(input.Files)
+	if err != nil {
+		return Gist{}, err
+	}
+	if p.isDryRun(input.Metadata) {
+		log.Printf("[dry-run] POST /gists files=%d", len(files))
+		return simulateGist("", input), nil
+	}
+
+	gist, _, err := p.api.Gists.Create(ctx, &github.Gist{
+		Description: github.String(input.Description),
+		Public:      github.Bool(input.Public),
+		Files:       files,
+	})
+	p.audit("gist.create", gist.GetID(), gistAuditInput(input), gist.GetHTMLURL(), err)
+	if err != nil {
+		return Gist{}, p.wrapError(err)
+	}
+	return convertGist(gist), nil
+}
+
+// UpdateGist replaces the content of the named files of a gist, adding those
+// it lacks, and its description when one is given.
+func (p *Provider) UpdateGist(ctx context.Context, id string, input GistInput) (Gist, error) {
+	if err := p.checkGists(); err != nil {
+		return Gist{}, err
+	}
+	if err := checkGistID(id); err != nil {
+		return Gist{}, err
+	}
+	if len(input.Files) == 0 && input.Description == "" {
+		return Gist{}, gistError("files or a description is required")
+	}
+	files, err := gistFiles(input.Files)
+	if err != nil {
+		return Gist{}, err
+	}
+	if p.isDryRun(input.Metadata) {
+		log.Printf("[dry-run] PATCH /gists/%s files=%d", id, len(files))
+		return simulateGist(id, input), nil
+	}
+
+	edit := &github.Gist{Files: files}
+	if input.Description != "" {
+		edit.Description = github.String(input.Description)
+	}
+	gist, _, err := p.api.Gists.Edit(ctx, id, edit)
+	p.audit("gist.update", id, gistAuditInput(input), gist.GetHTMLURL(), err)
+	if err != nil {
+		return Gist{}, p.wrapError(err)
+	}
+	return convertGist(gist), nil
+}
+
+// GetGist returns a gist with the content of its files.
+func (p *Provider) GetGist(ctx context.Context, id string) (Gist, error) {
+	if err := p.checkGists(); err != nil {
+		return Gist{}, err
+	}
+	if err := checkGistID(id); err != nil {
+		return Gist{}, err
+	}
+	gist, _, err := p.api.Gists.Get(ctx, id)
+	if err != nil {
+		return Gist{}, p.wrapError(err)
+	}
+	return convertGist(gist), nil
+}
+
+func (p *Provider) checkGists() error {
+	if p.api.Gists == nil {
+		return &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "gists are not available for this provider",
+		}
+	}
+	return nil
+}
+
+// checkGistID rejects IDs that would address another API path.
+func checkGistID(id string) error {
+	if id == "" || strings.ContainsAny(id, "/?#") {
+		return gistError("a gist ID is required")
+	}
+	return nil
+}
+
+// gistFiles validates file names and content as for attachments.
+func gistFiles(contents map[string]string) (map[github.GistFilename]github.GistFile, error) {
+	files := make(map[github.GistFilename]github.GistFile, len(contents))
+	for name, content := range contents {
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, "/\\") {
+			return nil, gistError("files: a file name without slashes is required")
+		}
+		if content == "" {
+			return nil, gistError("files[%s]: content is required", name)
+		}
+		files[github.GistFilename(name)] = github.GistFile{Content: github.String(content)}
+	}
+	return files, nil
+}
+
+func gistError(format string, args ...any) error {
+	return &orcherr.OpsOrchError{
+		Code:    "bad_request",
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// gistAuditInput is what the audit log records of a gist write: the
+// description and file names, not the content.
+func gistAuditInput(input GistInput) map[string]any {
+	names := make([]string, 0, len(input.Files))
+	for name := range input.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return map[string]any{"description": input.Description, "public": input.Public, "files": names}
+}
+
+// convertGist converts a GitHub gist, sorting its files by name.
+func convertGist(gist *github.Gist) Gist {
+	g := Gist{
+		ID:          gist.GetID(),
+		URL:         gist.GetHTMLURL(),
+		Description: gist.GetDescription(),
+		Public:      gist.GetPublic(),
+		Files:       make([]GistFile, 0, len(gist.Files)),
+		CreatedAt:   gist.GetCreatedAt().Time,
+		UpdatedAt:   gist.GetUpdatedAt().Time,
+	}
+	for name, file := range gist.Files {
+		f := GistFile{
+			Name:     string(name),
+			Content:  file.GetContent(),
+			Language: file.GetLanguage(),
+			Size:     file.GetSize(),
+			RawURL:   file.GetRawURL(),
+		}
+		if file.Filename != nil {
+			f.Name = file.GetFilename()
+		}
+		// go-github drops GitHub's truncated flag, so compare the content
+		// returned with the file's size; very large files come without any
+		f.Truncated = len(f.Content) < f.Size
+		g.Files = append(g.Files, f)
+	}
+	sort.Slice(g.Files, func(i, j int) bool { return g.Files[i].Name < g.Files[j].Name })
+	return g
+}
+
+// simulateGist returns the gist a dry-run write would have produced.
+func simulateGist(id string, input GistInput) Gist {
+	g := Gist{ID: id, Description: input.Description, Public: input.Public, Files: make([]GistFile, 0, len(input.Files))}
+	for name, content := range input.Files {
+		g.Files = append(g.Files, GistFile{Name: name, Content: content, Size: len(content)})
+	}
+	sort.Slice(g.Files, func(i, j int) bool { return g.Files[i].Name < g.Files[j].Name })
+	return g
+}
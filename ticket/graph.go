@@ -0,0 +1,271 @@
+package ticket
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// Edge types of a RelatedGraph.
+const (
+	EdgeMentions = "mentions"
+	EdgeCloses   = "closes"
+)
+
+// maxRelatedLookups caps how many referenced issues Related fetches for details.
+const maxRelatedLookups = 25
+
+var (
+	// referencePattern matches #N, owner/repo#N and github.com issue and pull
+	// request URLs. The leading group keeps matches out of other URLs and words.
+	referencePattern = regexp.MustCompile(`(^|[^\w/#.:-])(https://github\.com/([\w.-]+)/([\w.-]+)/(?:issues|pull)/(\d+)|(?:([\w.-]+)/([\w.-]+))?#(\d+))\b`)
+	// closingPattern matches a closing keyword directly before a reference.
+	closingPattern = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+$`)
+)
+
+// RelatedGraph is the set of issues and pull requests that reference, or are
+// referenced by, a ticket. Nodes in the ticket's repository use the issue number
+// as ID, like tickets do; others use owner/repo#N.
+type RelatedGraph struct {
+	Root  string      `json:"root"`
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is an issue or pull request in a RelatedGraph. Title, state and URL are
+// empty when the item could not be fetched.
+type GraphNode struct {
+	ID          string `json:"id"`
+	Repository  string `json:"repository"`
+	Number      int    `json:"number"`
+	Title       string `json:"title,omitempty"`
+	State       string `json:"state,omitempty"`
+	URL         string `json:"url,omitempty"`
+	PullRequest bool   `json:"pullRequest"`
+}
+
+// GraphEdge points from the referencing item to the referenced one.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+// reference is an issue reference found in Markdown text.
+type reference struct {
+	Owner  string
+	Repo   string
+	Number int
+	Closes bool
+}
+
+// parseReferences returns the issue references in text. Short #N references
+// resolve to owner/repo.
+func parseReferences(text, owner, repo string) []reference {
+	var refs []reference
+	for _, m := range referencePattern.FindAllStringSubmatchIndex(text, -1) {
+		group := func(i int) string {
+			if m[2*i] < 0 {
+				return ""
+			}
+			return text[m[2*i]:m[2*i+1]]
+		}
+
+		ref := reference{Owner: owner, Repo: repo}
+		number := group(8)
+		if group(3) != "" {
+			ref.Owner, ref.Repo, number = group(3), group(4), group(5)
+		} else if group(6) != "" {
+			ref.Owner, ref.Repo = group(6), group(7)
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil || n == 0 {
+			continue
+		}
+		ref.Number = n
+		ref.Closes = closingPattern.MatchString(text[:m[4]])
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// relatedGraph accumulates nodes and edges without duplicates.
+type relatedGraph struct {
+	RelatedGraph
+	nodes map[string]int
+	edges map[[2]string]int
+}
+
+// node adds an item if it is not in the graph yet and returns its index.
+func (g *relatedGraph) node(id, fullName string, number int) int {
+	if i, ok := g.nodes[id]; ok {
+		return i
+	}
+	g.nodes[id] = len(g.Nodes)
+	g.Nodes = append(g.Nodes, GraphNode{ID: id, Repository: fullName, Number: number})
+	return len(g.Nodes) - 1
+}
+
+// edge adds an edge, upgrading an existing mention to a close.
+func (g *relatedGraph) edge(from, to, typ string) {
+	key := [2]string{from, to}
+	if i, ok := g.edges[key]; ok {
+		if typ == EdgeCloses {
+			g.Edges[i].Type = EdgeCloses
+		}
+		return
+	}
+	g.edges[key] = len(g.Edges)
+	g.Edges = append(g.Edges, GraphEdge{From: from, To: to, Type: typ})
+}
+
+// Related returns the cross-reference graph around a ticket: items the issue body
+// or its comments mention, and items whose cross-reference events appear on the
+// issue timeline. References preceded by a closing keyword such as "fixes" become
+// closes edges. Closing keywords count in issue and pull request bodies only, as
+// on GitHub.
+func (p *Provider) Related(ctx context.Context, id string) (RelatedGraph, error) {
+	target, issueNumber, err := p.target(ctx, id)
+	if err != nil {
+		return RelatedGraph{}, err
+	}
+	// IDs in the graph are this provider's, wherever the root issue lives
+	owner, repo := target.config.Owner, target.config.Repo
+	id = p.ticketID(owner, repo, issueNumber)
+
+	root, _, err := p.api.Issues.Get(ctx, owner, repo, issueNumber)
+	if err != nil {
+		return RelatedGraph{}, p.wrapError(err)
+	}
+
+	g := &relatedGraph{
+		RelatedGraph: RelatedGraph{
+			Root:  id,
+			Nodes: []GraphNode{{ID: id, Repository: owner + "/" + repo, Number: issueNumber}},
+			Edges: []GraphEdge{},
+		},
+		nodes: map[string]int{id: 0},
+		edges: map[[2]string]int{},
+	}
+	describe(&g.Nodes[0], root)
+
+	// Outgoing: references in the body and comments
+	var outgoing []reference
+	outgoing = append(outgoing, parseReferences(root.GetBody(), owner, repo)...)
+	comments, err := target.comments(ctx, issueNumber)
+	if err != nil {
+		return RelatedGraph{}, err
+	}
+	for _, c := range comments {
+		for _, ref := range parseReferences(c.Body, owner, repo) {
+			ref.Closes = false
+			outgoing = append(outgoing, ref)
+		}
+	}
+	var lookups []reference
+	for _, ref := range outgoing {
+		target := p.ticketID(ref.Owner, ref.Repo, ref.Number)
+		if target == id {
+			continue
+		}
+		if _, seen := g.nodes[target]; !seen {
+			lookups = append(lookups, ref)
+		}
+		g.node(target, ref.Owner+"/"+ref.Repo, ref.Number)
+		g.edge(id, target, edgeType(ref.Closes))
+	}
+
+	// Incoming: cross-reference events on the timeline carry the source item
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		events, resp, err := p.api.Issues.ListIssueTimeline(ctx, owner, repo, issueNumber, opts)
+		if err != nil {
+			return RelatedGraph{}, p.wrapError(err)
+		}
+		for _, e := range events {
+			source := e.GetSource().GetIssue()
+			if e.GetEvent() != "cross-referenced" || source == nil {
+				continue
+			}
+			sourceOwner, sourceRepo := issueRepository(source)
+			if sourceOwner == "" {
+				continue
+			}
+			from := p.ticketID(sourceOwner, sourceRepo, source.GetNumber())
+			if from == id {
+				continue
+			}
+			i := g.node(from, sourceOwner+"/"+sourceRepo, source.GetNumber())
+			describe(&g.Nodes[i], source)
+
+			g.edge(from, id, edgeType(closesIssue(source, owner, repo, issueNumber)))
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	// Fill in referenced items the timeline did not describe. Items that cannot be
+	// fetched, such as ones in private repositories, keep only their reference.
+	fetched := 0
+	for _, ref := range lookups {
+		node := &g.Nodes[g.nodes[p.ticketID(ref.Owner, ref.Repo, ref.Number)]]
+		if node.URL != "" {
+			continue
+		}
+		if fetched == maxRelatedLookups {
+			break
+		}
+		fetched++
+		issue, _, err := p.api.Issues.Get(ctx, ref.Owner, ref.Repo, ref.Number)
+		if err != nil {
+			continue
+		}
+		describe(node, issue)
+	}
+
+	return g.RelatedGraph, nil
+}
+
+// describe copies an issue's details onto its graph node.
+func describe(node *GraphNode, issue *github.Issue) {
+	node.Title = issue.GetTitle()
+	node.State = issue.GetState()
+	node.URL = issue.GetHTMLURL()
+	node.PullRequest = issue.IsPullRequest()
+}
+
+// ticketID returns the ID of an issue: the bare number in the configured
+// repository, owner/repo#N elsewhere.
+func (p *Provider) ticketID(owner, repo string, number int) string {
+	if strings.EqualFold(owner, p.config.Owner) && strings.EqualFold(repo, p.config.Repo) {
+		return strconv.Itoa(number)
+	}
+	return fmt.Sprintf("%s/%s#%d", owner, repo, number)
+}
+
+// issueRepository returns the owner and name of the repository an issue belongs
+// to, from its repository object or its API URL.
+func issueRepository(issue *github.Issue) (string, string) {
+	if owner, repo, ok := strings.Cut(issue.GetRepository().GetFullName(), "/"); ok {
+		return owner, repo
+	}
+	_, path, ok := strings.Cut(issue.GetRepositoryURL(), "/repos/")
+	if !ok {
+		return "", ""
+	}
+	owner, repo, _ := strings.Cut(path, "/")
+	return owner, repo
+}
+
+func edgeType(closes bool) string {
+	if closes {
+		return EdgeCloses
+	}
+	return EdgeMentions
+}
@@ -0,0 +1,87 @@
+package ticket
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+func TestParseReferences(t *testing.T) {
+	text := "Fixes #12, see other/lib#3 and https://github.com/acme/web/pull/7.\n" +
+		"Resolves: acme/api#5; not https://example.com/page#4 or abc#9 or #0"
+
+	want := []reference{
+		{Owner: "acme", Repo: "api", Number: 12, Closes: true},
+		{Owner: "other", Repo: "lib", Number: 3},
+		{Owner: "acme", Repo: "web", Number: 7},
+		{Owner: "acme", Repo: "api", Number: 5, Closes: true},
+	}
+	if got := parseReferences(text, "acme", "api"); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseReferences() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestRelated(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api/issues/5", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+			"number": 5, "title": "Checkout down", "state": "open",
+			"body": "Probably caused by #1, tracked upstream in other/lib#9.",
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/issues/5/comments", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"id": 1, "body": "Fixes #1 too? Also #5 itself."}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/issues/5/timeline", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"event": "labeled"},
+			{"event": "cross-referenced", "source": map[string]any{"type": "issue", "issue": map[string]any{
+				"number": 8, "title": "Roll back checkout", "state": "closed",
+				"html_url":       "https://github.com/acme/api/pull/8",
+				"body":           "Closes #5",
+				"repository_url": "https://api.github.com/repos/acme/api",
+				"pull_request":   map[string]any{"url": "https://api.github.com/repos/acme/api/pulls/8"},
+			}}},
+			{"event": "cross-referenced", "source": map[string]any{"type": "issue", "issue": map[string]any{
+				"number": 2, "title": "Same on mobile", "state": "open",
+				"body":       "Looks like acme/api#5",
+				"repository": map[string]any{"full_name": "acme/mobile"},
+			}}},
+		})
+	})
+
+	got, err := p.Related(context.Background(), "5")
+	if err != nil {
+		t.Fatalf("Related() error = %v", err)
+	}
+
+	wantNodes := []GraphNode{
+		{ID: "5", Repository: "acme/api", Number: 5, Title: "Checkout down", State: "open"},
+		{ID: "1", Repository: "acme/api", Number: 1, Title: "Database latency spike", State: "open", URL: "https://github.com/acme/api/issues/1"},
+		{ID: "other/lib#9", Repository: "other/lib", Number: 9},
+		{ID: "8", Repository: "acme/api", Number: 8, Title: "Roll back checkout", State: "closed", URL: "https://github.com/acme/api/pull/8", PullRequest: true},
+		{ID: "acme/mobile#2", Repository: "acme/mobile", Number: 2, Title: "Same on mobile", State: "open"},
+	}
+	wantEdges := []GraphEdge{
+		{From: "5", To: "1", Type: EdgeMentions},
+		{From: "5", To: "other/lib#9", Type: EdgeMentions},
+		{From: "8", To: "5", Type: EdgeCloses},
+		{From: "acme/mobile#2", To: "5", Type: EdgeMentions},
+	}
+	if got.Root != "5" || !reflect.DeepEqual(got.Nodes, wantNodes) {
+		t.Errorf("nodes =\n%+v\nwant\n%+v", got.Nodes, wantNodes)
+	}
+	if !reflect.DeepEqual(got.Edges, wantEdges) {
+		t.Errorf("edges =\n%+v\nwant\n%+v", got.Edges, wantEdges)
+	}
+
+	if _, err := p.Related(context.Background(), "abc"); !hasCode(err, "bad_request") {
+		t.Errorf("Related() with invalid id error = %v, want bad_request", err)
+	}
+	if _, err := p.Related(context.Background(), "99"); !hasCode(err, "not_found") {
+		t.Errorf("Related() with unknown issue error = %v, want not_found", err)
+	}
+}
@@ -0,0 +1,174 @@
+package ticket
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// deploymentLinkMarker tags the comment LinkDeployment posts so repeated links are
+// idempotent.
+const deploymentLinkMarker = "<!-- opsorch-link:deployment:%s -->"
+
+// LinkResult holds both sides of a deployment/ticket link, each referencing the other.
+type LinkResult struct {
+	Ticket     schema.Ticket     `json:"ticket"`
+	Deployment schema.Deployment `json:"deployment"`
+	Commented  bool              `json:"commented"`
+}
+
+// LinkDeployment cross-links a deployment and a ticket: it comments on the issue with
+// the deployment's run URL, adds the deployedInLabel, and returns the deployment with
+// the issue number added to Fields["linked_tickets"] and the ticket with every
+// deployment linked to it in Fields["deployments"], as Get reports them. Linking the
+// same pair twice does not post a second comment.
+func (p *Provider) LinkDeployment(ctx context.Context, id string, deployment schema.Deployment) (LinkResult, error) {
+	if deployment.ID == "" {
+		return LinkResult{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "deployment ID is required",
+		}
+	}
+	target, issueNumber, err := p.target(ctx, id)
+	if err != nil {
+		return LinkResult{}, err
+	}
+	result, err := target.linkDeployment(ctx, issueNumber, deployment)
+	if err != nil {
+		return LinkResult{}, err
+	}
+	result.Ticket = p.qualify(target, result.Ticket)
+	if result.Deployment.Fields == nil {
+		result.Deployment.Fields = map[string]any{}
+	}
+	tickets := ghconfig.StringSlice(result.Deployment.Fields, "linked_tickets")
+	if !slices.Contains(tickets, result.Ticket.ID) {
+		tickets = append(tickets, result.Ticket.ID)
+	}
+	result.Deployment.Fields["linked_tickets"] = tickets
+	return result, nil
+}
+
+func (p *Provider) linkDeployment(ctx context.Context, issueNumber int, deployment schema.Deployment) (LinkResult, error) {
+	comments, err := p.comments(ctx, issueNumber)
+	if err != nil {
+		return LinkResult{}, err
+	}
+	deployments := linkedDeployments(comments)
+
+	result := LinkResult{}
+	if !slices.Contains(deployments, deployment.ID) {
+		marker := fmt.Sprintf(deploymentLinkMarker, deployment.ID)
+		if _, err := p.addComment(ctx, issueNumber, marker+"\n"+deploymentLinkComment(deployment), nil); err != nil {
+			return LinkResult{}, err
+		}
+		deployments = append(deployments, deployment.ID)
+		result.Commented = true
+	}
+
+	if err := p.addLabels(ctx, issueNumber, p.config.DeployedInLabel); err != nil {
+		return LinkResult{}, err
+	}
+
+	_, ticket, err := p.fetchIssue(ctx, issueNumber)
+	if err != nil {
+		return LinkResult{}, err
+	}
+	ticket.Fields["deployments"] = deployments
+	p.Publish(ticket)
+
+	result.Ticket = ticket
+	result.Deployment = deployment
+	return result, nil
+}
+
+// addDeployments sets fields["deployments"] to the deployments LinkDeployment
+// linked to an issue, read from its marker comments. Only issues carrying the
+// deployedInLabel have their comments read. The lookup is best effort; a
+// failure is logged and leaves the field unset.
+func (p *Provider) addDeployments(ctx context.Context, issue *github.Issue, fields map[string]any) {
+	if issue.GetComments() == 0 || !slices.ContainsFunc(issue.Labels, func(l *github.Label) bool {
+		return strings.EqualFold(l.GetName(), p.config.DeployedInLabel)
+	}) {
+		return
+	}
+	comments, err := p.comments(ctx, issue.GetNumber())
+	if err != nil {
+		log.Printf("[link] %s/%s#%d: comments: %v", p.config.Owner, p.config.Repo, issue.GetNumber(), err)
+		return
+	}
+	if deployments := linkedDeployments(comments); len(deployments) > 0 {
+		fields["deployments"] = deployments
+	}
+}
+
+// linkedDeployments returns the deployment IDs linked by marker comments, oldest
+// link first.
+func linkedDeployments(comments []Comment) []string {
+	var ids []string
+	for _, c := range comments {
+		if id, _, ok := LinkedDeployment(c); ok && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// LinkedDeployment returns the deployment ID a LinkDeployment comment links and
+// the comment's text without its marker. ok is false for other comments.
+func LinkedDeployment(c Comment) (id, text string, ok bool) {
+	prefix, _, _ := strings.Cut(deploymentLinkMarker, "%s")
+	rest, found := strings.CutPrefix(c.Body, prefix)
+	if !found {
+		return "", "", false
+	}
+	id, text, found = strings.Cut(rest, " -->")
+	if !found || id == "" {
+		return "", "", false
+	}
+	return id, strings.TrimSpace(text), true
+}
+
+// addLabels adds labels to an issue without replacing its existing ones.
+func (p *Provider) addLabels(ctx context.Context, issueNumber int, labels ...string) error {
+	if p.isDryRun(nil) {
+		log.Printf("[dry-run] POST /repos/%s/%s/issues/%d/labels %v", p.config.Owner, p.config.Repo, issueNumber, labels)
+		return nil
+	}
+	_, _, err := p.api.Issues.AddLabelsToIssue(ctx, p.config.Owner, p.config.Repo, issueNumber, labels)
+	p.audit("ticket.label", strconv.Itoa(issueNumber), map[string]any{"labels": labels}, p.issueURL(issueNumber), err)
+	if err != nil {
+		return p.wrapError(err)
+	}
+	return nil
+}
+
+// deploymentLinkComment renders the human-readable part of the link comment.
+func deploymentLinkComment(d schema.Deployment) string {
+	var b strings.Builder
+	b.WriteString("Deployed in ")
+	if d.URL != "" {
+		fmt.Fprintf(&b, "[%s](%s)", d.ID, d.URL)
+	} else {
+		b.WriteString(d.ID)
+	}
+	if d.Version != "" {
+		fmt.Fprintf(&b, " (version `%s`", d.Version)
+		if d.Environment != "" {
+			fmt.Fprintf(&b, " to %s", d.Environment)
+		}
+		b.WriteString(")")
+	}
+	if d.Status != "" {
+		fmt.Fprintf(&b, " - status: %s", d.Status)
+	}
+	return b.String()
+}
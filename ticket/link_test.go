@@ -0,0 +1,93 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+func TestLinkDeployment(t *testing.T) {
+	var posted []string
+	var labels []string
+	comments := []map[string]any{{"id": 9, "body": "<!-- opsorch-link:deployment:111 -->\nDeployed in 111"}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/acme/api/issues/7/comments", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var body struct{ Body string }
+			json.NewDecoder(r.Body).Decode(&body)
+			posted = append(posted, body.Body)
+			comments = append(comments, map[string]any{"id": len(comments) + 9, "body": body.Body})
+			w.Write([]byte(`{"id": 1}`))
+			return
+		}
+		json.NewEncoder(w).Encode(comments)
+	})
+	mux.HandleFunc("/repos/acme/api/issues/7/labels", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&labels)
+		w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("/repos/acme/api/issues/7", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `{"number": 7, "state": "open", "comments": %d, "labels": [{"name": "deployed-in"}]}`, len(comments))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	p := &Provider{api: ghapi.FromClient(client), config: Config{Owner: "acme", Repo: "api", DeployedInLabel: "deployed-in"}}
+
+	d := schema.Deployment{ID: "222", URL: "https://github.com/acme/api/actions/runs/222", Version: "abc1234", Environment: "production", Status: "success"}
+	result, err := p.LinkDeployment(context.Background(), "7", d)
+	if err != nil {
+		t.Fatalf("LinkDeployment() error = %v", err)
+	}
+	if !result.Commented || len(posted) != 1 || !strings.Contains(posted[0], "opsorch-link:deployment:222") || !strings.Contains(posted[0], d.URL) {
+		t.Errorf("unexpected comment: %v", posted)
+	}
+	if len(labels) != 1 || labels[0] != "deployed-in" {
+		t.Errorf("labels = %v", labels)
+	}
+	if tickets := result.Deployment.Fields["linked_tickets"].([]string); len(tickets) != 1 || tickets[0] != "7" {
+		t.Errorf("linked_tickets = %v", tickets)
+	}
+	// The new link is added to the earlier one, and Get reads both back
+	if deployments := result.Ticket.Fields["deployments"].([]string); len(deployments) != 2 || deployments[0] != "111" || deployments[1] != "222" {
+		t.Errorf("deployments = %v", deployments)
+	}
+	got, err := p.Get(context.Background(), "7")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if deployments, _ := got.Fields["deployments"].([]string); len(deployments) != 2 || deployments[0] != "111" || deployments[1] != "222" {
+		t.Errorf("Get() deployments = %v", got.Fields["deployments"])
+	}
+
+	// Already linked: no second comment
+	posted = nil
+	result, err = p.LinkDeployment(context.Background(), "7", schema.Deployment{ID: "111"})
+	if err != nil {
+		t.Fatalf("LinkDeployment() error = %v", err)
+	}
+	if result.Commented || len(posted) != 0 {
+		t.Errorf("relinking posted a comment: %v", posted)
+	}
+}
+
+func TestLinkedDeployment(t *testing.T) {
+	body := fmt.Sprintf(deploymentLinkMarker, "1234") + "\n" + deploymentLinkComment(schema.Deployment{ID: "1234", Status: "success"})
+	if id, text, ok := LinkedDeployment(Comment{Body: body}); !ok || id != "1234" || text != "Deployed in 1234 - status: success" {
+		t.Errorf("LinkedDeployment() = %q, %q, %v", id, text, ok)
+	}
+	if _, _, ok := LinkedDeployment(Comment{Body: "Deployed in 1234"}); ok {
+		t.Error("LinkedDeployment() matched a plain comment")
+	}
+}
@@ -5,51 +5,110 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/google/go-github/v57/github"
 	"github.com/opsorch/opsorch-core/orcherr"
 	"github.com/opsorch/opsorch-core/schema"
 	"github.com/opsorch/opsorch-core/ticket"
+	"github.com/opsorch/opsorch-github-adapter/audit"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/botpolicy"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/fieldset"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/gherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/identity"
+	"github.com/opsorch/opsorch-github-adapter/internal/instance"
+	"github.com/opsorch/opsorch-github-adapter/internal/lastgood"
+	"github.com/opsorch/opsorch-github-adapter/internal/limits"
+	"github.com/opsorch/opsorch-github-adapter/internal/override"
+	"github.com/opsorch/opsorch-github-adapter/internal/paging"
+	"github.com/opsorch/opsorch-github-adapter/internal/throttle"
+	"github.com/opsorch/opsorch-github-adapter/internal/timefmt"
 )
 
 // Provider implements the ticket.Provider interface for GitHub Issues.
 type Provider struct {
-	client *github.Client
-	config Config
+	api        ghapi.Services
+	config     Config
+	cacheScope uint64 // Separates this provider's response cache entries, see cacheKey
 }
 
 // Config holds the configuration for the GitHub ticket provider.
 type Config struct {
-	Token        string `json:"token"`        // GitHub personal access token
-	Owner        string `json:"owner"`        // Repository owner (user or organization)
-	Repo         string `json:"repo"`         // Repository name
-	DefaultState string `json:"defaultState"` // Default state for new issues (open/closed)
+	Token                 string                        `json:"token"`                 // GitHub personal access token
+	Owner                 string                        `json:"owner"`                 // Repository owner (user or organization)
+	Repo                  string                        `json:"repo"`                  // Repository name
+	DefaultState          string                        `json:"defaultState"`          // Default state for new issues (open/closed)
+	ReadOnly              bool                          `json:"readOnly"`              // Simulate writes instead of calling GitHub
+	RawAPIAllowlist       []string                      `json:"rawAPIAllowlist"`       // Path patterns permitted for raw GET passthrough
+	RepositoryAllowlist   override.Allowlist            `json:"repositoryAllowlist"`   // owner/repo patterns queries and creates may target through metadata
+	OrganizationAllowlist override.Organizations        `json:"organizationAllowlist"` // Organization patterns org-scope queries may name in metadata "org"
+	CacheTTL              time.Duration                 `json:"cacheTTL"`              // How long Get results stay in the response cache (0 disables)
+	DeployedInLabel       string                        `json:"deployedInLabel"`       // Label added to issues linked to a deployment
+	RoutingRules          []RoutingRule                 `json:"routingRules"`          // Label/title rules assigning team, service, and severity
+	StalePolicy           *StalePolicy                  `json:"stalePolicy"`           // Which issues SweepStale finds stale and what it does to them
+	BodyTemplates         map[string]*template.Template `json:"-"`                     // Go templates rendering Create bodies by metadata kind, from "bodyTemplates"
+	BestEffortAssignees   bool                          `json:"bestEffortAssignees"`   // Drop unassignable logins instead of failing writes
+	PrivateAttachments    bool                          `json:"privateAttachments"`    // Allow attachments for a private repository, though anyone with a gist link can read them
+	DescriptionFormat     string                        `json:"descriptionFormat"`     // "markdown" (default) or "plain" to strip Markdown from descriptions
+	DescriptionMaxLength  int                           `json:"descriptionMaxLength"`  // Truncate descriptions to this many characters (0 disables)
+	Render                string                        `json:"render"`                // "html" to add GitHub's HTML rendering of issue bodies as fields.description_html
+	Budget                budget.Limits                 `json:"-"`                     // Per-query API call caps, from "maxAPICallsPerQuery" and "maxEnrichmentCalls"
+	QueryScope            string                        `json:"queryScope"`            // "repo" (default) or "org" to search every repository in Organization
+	Organization          string                        `json:"organization"`          // Organization searched by org-scope queries (defaults to Owner)
+	Queries               map[string]schema.TicketQuery `json:"queries"`               // Named query presets selected with metadata "savedQuery"
+	Services              map[string]string             `json:"services"`              // Repository (owner/repo) queried for each service scope, by lower-cased service name
+	BotPolicy             botpolicy.Policy              `json:"botPolicy"`             // Which assignees and reporters are bots, and whether bot assignees are left out
+	Identities            *identity.Map                 `json:"-"`                     // Canonical identities by login, from "identities" and "identitiesURL"
+	InstanceLabels        instance.Labels               `json:"instanceLabels"`        // Labels of this adapter instance added to every ticket's Fields["instance_labels"]
+	EpochMillis           bool                          `json:"epochMillis"`           // Also add each timestamp to Fields in epoch milliseconds, as "created_at_ms" and so on
+	LastGood              *lastgood.Store               `json:"-"`                     // Last-known-good results served during outages, from "staleCacheDir"
+	Audit                 *audit.Log                    `json:"-"`                     // Record of writes to GitHub, from "auditLog" and "auditFunc"
 }
 
 // New creates a new GitHub ticket provider.
 func New(cfg map[string]any) (ticket.Provider, error) {
-	var config Config
+	// Build the API services: GitHub authenticated with the token (falling back to
+	// GITHUB_TOKEN), or local fixtures when mode is "fixtures"
+	api, err := ghconfig.Services(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	// Parse token
-	if token, ok := cfg["token"].(string); ok {
-		config.Token = token
-	} else {
-		return nil, fmt.Errorf("token is required")
+	p, err := NewWithServices(cfg, api)
+	if err != nil {
+		return nil, err
 	}
+	return p, nil
+}
 
-	// Parse owner
-	if owner, ok := cfg["owner"].(string); ok {
-		config.Owner = owner
-	} else {
-		return nil, fmt.Errorf("owner is required")
+// NewWithServices creates a GitHub ticket provider backed by the given API
+// implementations instead of a token-authenticated client. Only the Issues
+// service is required; raw passthrough is unavailable without a Requester.
+func NewWithServices(cfg map[string]any, api ghapi.Services) (*Provider, error) {
+	if api.Issues == nil {
+		return nil, fmt.Errorf("issues service is required")
 	}
 
-	// Parse repo
-	if repo, ok := cfg["repo"].(string); ok {
-		config.Repo = repo
-	} else {
+	var config Config
+	config.Token = ghconfig.Token(cfg)
+
+	// Parse owner/repo, accepting the "repository" shorthand and GITHUB_REPOSITORY
+	owner, repo, err := ghconfig.Repository(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if owner == "" {
+		return nil, fmt.Errorf("owner is required")
+	}
+	if repo == "" {
 		return nil, fmt.Errorf("repo is required")
 	}
+	config.Owner = owner
+	config.Repo = repo
 
 	// Parse default state (optional)
 	if state, ok := cfg["defaultState"].(string); ok {
@@ -58,27 +117,209 @@ func New(cfg map[string]any) (ticket.Provider, error) {
 		config.DefaultState = "open"
 	}
 
-	// Create GitHub client
-	client := github.NewTokenClient(context.Background(), config.Token)
+	// Parse read-only flag (optional)
+	config.ReadOnly = ghconfig.Bool(cfg, "readOnly")
+
+	// Parse best-effort assignee flag (optional)
+	config.BestEffortAssignees = ghconfig.Bool(cfg, "bestEffortAssignees")
+	config.PrivateAttachments = ghconfig.Bool(cfg, "privateAttachments")
+
+	// Parse raw API allowlist (optional)
+	config.RawAPIAllowlist = ghconfig.StringSlice(cfg, "rawAPIAllowlist")
+
+	// Parse repository override allowlist (optional)
+	if config.RepositoryAllowlist, err = override.Parse(cfg); err != nil {
+		return nil, err
+	}
+	if config.OrganizationAllowlist, err = override.ParseOrganizations(cfg); err != nil {
+		return nil, err
+	}
+
+	// Parse response cache TTL (optional)
+	config.CacheTTL = ghconfig.Duration(cfg, "cacheTTL", 0)
+
+	// Parse deployed-in label (optional)
+	config.DeployedInLabel = ghconfig.String(cfg, "deployedInLabel")
+	if config.DeployedInLabel == "" {
+		config.DeployedInLabel = "deployed-in"
+	}
+
+	// Parse description format and length limit (optional)
+	config.DescriptionFormat = strings.ToLower(ghconfig.String(cfg, "descriptionFormat"))
+	switch config.DescriptionFormat {
+	case "":
+		config.DescriptionFormat = DescriptionMarkdown
+	case DescriptionMarkdown, DescriptionPlain:
+	default:
+		return nil, fmt.Errorf("unknown descriptionFormat %q (expected %s or %s)", config.DescriptionFormat, DescriptionMarkdown, DescriptionPlain)
+	}
+	config.DescriptionMaxLength = ghconfig.Int(cfg, "descriptionMaxLength", 0)
+	config.Render = strings.ToLower(ghconfig.String(cfg, "render"))
+	switch config.Render {
+	case "":
+	case RenderHTML:
+		if api.Markdown == nil {
+			return nil, fmt.Errorf("render %q requires the Markdown API", config.Render)
+		}
+	default:
+		return nil, fmt.Errorf("unknown render %q (expected %s)", config.Render, RenderHTML)
+	}
+
+	// Parse query scope and the organization it searches (optional)
+	config.QueryScope = strings.ToLower(ghconfig.String(cfg, "queryScope"))
+	switch config.QueryScope {
+	case "":
+		config.QueryScope = QueryScopeRepo
+	case QueryScopeRepo, QueryScopeOrg:
+	default:
+		return nil, fmt.Errorf("unknown queryScope %q (expected %s or %s)", config.QueryScope, QueryScopeRepo, QueryScopeOrg)
+	}
+	config.Organization = ghconfig.String(cfg, "organization")
+	if config.Organization == "" {
+		config.Organization = owner
+	}
+
+	// Parse saved query presets (optional)
+	if config.Queries, err = parseSavedQueries(cfg); err != nil {
+		return nil, err
+	}
+
+	// Parse service repositories (optional)
+	if config.Services, err = parseServices(cfg, owner); err != nil {
+		return nil, err
+	}
+
+	// Parse API call budgets (optional)
+	config.Budget = budget.Parse(cfg)
+
+	// Parse epoch millisecond timestamps (optional)
+	config.EpochMillis = ghconfig.Bool(cfg, "epochMillis")
+
+	// Parse instance labels (optional)
+	if config.InstanceLabels, err = instance.Parse(cfg); err != nil {
+		return nil, err
+	}
+
+	// Parse routing rules (optional)
+	if config.RoutingRules, err = parseRoutingRules(cfg); err != nil {
+		return nil, err
+	}
+
+	// Parse the stale policy (optional)
+	if config.StalePolicy, err = parseStalePolicy(cfg); err != nil {
+		return nil, err
+	}
+
+	// Parse body templates by ticket kind (optional)
+	if config.BodyTemplates, err = parseBodyTemplates(cfg); err != nil {
+		return nil, err
+	}
+
+	// Parse bot policy (optional)
+	if config.BotPolicy, err = botpolicy.Parse(cfg); err != nil {
+		return nil, err
+	}
+
+	// Parse identity mapping (optional)
+	if config.Identities, err = identity.Parse(cfg); err != nil {
+		return nil, err
+	}
+
+	// Open the last-known-good store (optional)
+	if config.LastGood, err = lastgood.Parse(cfg, "ticket/"+owner+"/"+repo); err != nil {
+		return nil, err
+	}
+
+	// Open the audit log (optional)
+	if config.Audit, err = audit.Parse(cfg); err != nil {
+		return nil, err
+	}
 
 	return &Provider{
-		client: client,
-		config: config,
+		api:        api,
+		config:     config,
+		cacheScope: cacheScopes.Add(1),
 	}, nil
 }
 
-// Query returns tickets (GitHub Issues) matching the given filters.
+// Page is one page of query results. NextPageToken, when set, is passed back
+// in the query's metadata "pageToken" to read the results that follow.
+// TotalCount is the size of the whole result set when GitHub reports it or the
+// query read every result, and nil when it is unknown. Truncated is set when
+// the API call budget ran out, leaving the page short or missing enrichments.
+type Page struct {
+	Tickets       []schema.Ticket `json:"tickets"`
+	NextPageToken string          `json:"nextPageToken,omitempty"`
+	HasMore       bool            `json:"hasMore"`
+	TotalCount    *int            `json:"totalCount,omitempty"`
+	Truncated     bool            `json:"truncated,omitempty"`
+}
+
+// Query returns tickets (GitHub Issues) matching the given filters. With a
+// limit, pages are read until that many tickets are found, because pull
+// requests are dropped after listing; without one, a single page is returned.
 func (p *Provider) Query(ctx context.Context, query schema.TicketQuery) ([]schema.Ticket, error) {
+	page, err := p.QueryPage(ctx, query)
+	return page.Tickets, err
+}
+
+// QueryPage is Query that also returns a continuation token, so large result
+// sets can be read one query at a time. A query resumes from metadata
+// "pageToken"; the token is only meaningful for the query that produced it.
+func (p *Provider) QueryPage(ctx context.Context, query schema.TicketQuery) (Page, error) {
+	ctx = budget.Start(ctx, p.config.Budget)
+	page, err := p.queryPage(ctx, query)
+	page.Truncated = budget.Truncated(ctx)
+	p.projectPage(&page, query)
+	return lastgood.Serve(p.config.LastGood, lastgood.Key("query", query), page, err, markStalePage)
+}
+
+// projectPage leaves out the top-level values of a page's records that the
+// query's "fields", or those of its saved query, do not name.
+func (p *Provider) projectPage(page *Page, query schema.TicketQuery) {
+	query, err := p.applySavedQuery(query)
+	if err != nil {
+		return
+	}
+	want := fieldset.Parse(query.Metadata)
+	for i := range page.Tickets {
+		want.Ticket(&page.Tickets[i])
+	}
+}
+
+func (p *Provider) queryPage(ctx context.Context, query schema.TicketQuery) (Page, error) {
+	query, err := p.applySavedQuery(query)
+	if err != nil {
+		return Page{}, err
+	}
+
+	order, err := parseOrdering(query.Metadata)
+	if err != nil {
+		return Page{}, err
+	}
+	want := fieldset.Parse(query.Metadata)
+
+	org, err := p.queryOrganization(query)
+	if err != nil {
+		return Page{}, err
+	}
+	if org != "" {
+		return p.searchOrganization(ctx, org, query, order, want)
+	}
+
 	opts := &github.IssueListByRepoOptions{
 		ListOptions: github.ListOptions{
 			PerPage: 100, // GitHub's max per page
 		},
 	}
 
-	// Apply limit
-	if query.Limit > 0 && query.Limit < 100 {
-		opts.PerPage = query.Limit
-	}
+	// The limit is not applied to the page size: pull requests listed among the
+	// issues are skipped below, so full pages are fetched and the walk trims to
+	// the limit, reading more pages if it is not filled
+
+	// Apply sort order
+	opts.Sort = order.Sort
+	opts.Direction = order.Direction
 
 	// Apply status filter
 	if len(query.Statuses) > 0 {
@@ -99,82 +340,263 @@ func (p *Provider) Query(ctx context.Context, query schema.TicketQuery) ([]schem
 	}
 
 	// Apply labels from metadata
-	if labels, ok := query.Metadata["labels"].([]string); ok {
+	if labels := ghconfig.StringSlice(query.Metadata, "labels"); labels != nil {
 		opts.Labels = labels
 	}
 
-	issues, _, err := p.client.Issues.ListByRepo(ctx, p.config.Owner, p.config.Repo, opts)
+	// A service scope naming a configured service selects its repository
+	owner, repo, isService := p.serviceRepository(query)
+	if !isService {
+		owner, repo = p.config.Owner, p.config.Repo
+	}
+
+	// A repository named in metadata wins over the service scope
+	targetOwner, targetRepo, overridden, err := p.config.RepositoryAllowlist.Repository(query.Metadata, p.config.Owner, p.config.Repo)
 	if err != nil {
-		return nil, p.wrapError(err)
+		return Page{}, err
+	}
+	if overridden {
+		owner, repo, isService = targetOwner, targetRepo, false
 	}
 
-	tickets := make([]schema.Ticket, 0, len(issues))
-	for _, issue := range issues {
+	tickets := make([]schema.Ticket, 0, paging.Capacity(query.Limit, opts.PerPage, nil))
+	token := paging.FromMetadata(query.Metadata)
+	list := func() ([]*github.Issue, *github.Response, error) {
+		if !budget.Call(ctx) {
+			return nil, nil, budget.ErrExhausted
+		}
+		issues, resp, err := p.api.Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, nil, p.wrapError(err)
+		}
+		return issues, resp, nil
+	}
+	next, err := paging.Walk(token, query.Limit, &opts.ListOptions, list, func(issue *github.Issue) (bool, error) {
 		// Skip pull requests (GitHub API includes them in issues)
 		if issue.PullRequestLinks != nil {
-			continue
+			return false, nil
 		}
 
-		ticket := p.convertIssueToTicket(issue)
+		var ticket schema.Ticket
+		if isService || overridden {
+			ticket = p.convertIssueIn(issue, owner, repo, want)
+		} else {
+			ticket = p.convertIssue(issue, want)
+		}
+		if want.Has("description_html") {
+			p.renderHTML(ctx, issue.GetBody(), owner, repo, ticket.Fields)
+		}
 		tickets = append(tickets, ticket)
+		return true, nil
+	})
+	if err != nil {
+		return Page{}, err
 	}
 
-	return tickets, nil
+	if isService && want.Has("service") {
+		setService(tickets, query.Scope.Service)
+	}
+	// The issues endpoint lists pull requests too, so its page links do not
+	// count tickets
+	return Page{
+		Tickets:       tickets,
+		NextPageToken: next,
+		HasMore:       next != "",
+		TotalCount:    paging.Total(token, next, len(tickets)),
+	}, nil
 }
 
-// Get returns a single ticket by its ID.
+// Get returns a single ticket by its ID. IDs of issues in other repositories,
+// as returned by org-scope queries, have the form owner/repo#N.
 func (p *Provider) Get(ctx context.Context, id string) (schema.Ticket, error) {
-	issueNumber, err := strconv.Atoi(id)
+	ticket, err := p.get(throttle.WithPriority(ctx, throttle.Get), id)
+	return lastgood.Serve(p.config.LastGood, "get/"+id, ticket, err, markStale)
+}
+
+func (p *Provider) get(ctx context.Context, id string) (schema.Ticket, error) {
+	target, issueNumber, err := p.target(ctx, id)
 	if err != nil {
-		return schema.Ticket{}, &orcherr.OpsOrchError{
-			Code:    "bad_request",
-			Message: fmt.Sprintf("invalid issue number: %s", id),
-		}
+		return schema.Ticket{}, err
+	}
+	if target != p {
+		return p.getIn(ctx, target.config.Owner, target.config.Repo, issueNumber)
 	}
+	id = strconv.Itoa(issueNumber)
 
-	issue, _, err := p.client.Issues.Get(ctx, p.config.Owner, p.config.Repo, issueNumber)
+	if cached, ok := p.cachedTicket(id); ok {
+		return cached, nil
+	}
+
+	issue, ticket, err := p.fetchIssue(ctx, issueNumber)
 	if err != nil {
-		return schema.Ticket{}, p.wrapError(err)
+		return ticket, err
+	}
+	p.renderHTML(ctx, issue.GetBody(), p.config.Owner, p.config.Repo, ticket.Fields)
+	p.addDeployments(ctx, issue, ticket.Fields)
+	if ticket.Status == "closed" {
+		p.resolve(ctx, p.config.Owner, p.config.Repo, issueNumber, ticket.Fields)
 	}
+	p.Prime(ticket)
+	return ticket, nil
+}
 
-	return p.convertIssueToTicket(issue), nil
+// fetch retrieves an issue from the API, bypassing the cache and publishing the
+// ticket to Watch callers.
+func (p *Provider) fetch(ctx context.Context, issueNumber int) (schema.Ticket, error) {
+	_, ticket, err := p.fetchIssue(ctx, issueNumber)
+	if err == nil {
+		p.Publish(ticket)
+	}
+	return ticket, err
 }
 
-// Create creates a new ticket (GitHub Issue).
+// fetchIssue retrieves and converts an issue, leaving it to the caller to
+// prime the cache once the ticket is complete.
+func (p *Provider) fetchIssue(ctx context.Context, issueNumber int) (*github.Issue, schema.Ticket, error) {
+	issue, _, err := p.api.Issues.Get(ctx, p.config.Owner, p.config.Repo, issueNumber)
+	if err != nil {
+		return nil, schema.Ticket{}, p.wrapError(err)
+	}
+
+	return issue, p.convertIssueToTicket(issue), nil
+}
+
+// Create creates a new ticket (GitHub Issue). Metadata "owner" and "repo", or
+// "repository", create it in another repository the repositoryAllowlist permits;
+// its ID is then of the form owner/repo#N.
 func (p *Provider) Create(ctx context.Context, input schema.CreateTicketInput) (schema.Ticket, error) {
+	owner, repo, overridden, err := p.config.RepositoryAllowlist.Repository(input.Metadata, p.config.Owner, p.config.Repo)
+	if err != nil {
+		return schema.Ticket{}, err
+	}
+	if !overridden {
+		return p.create(ctx, input)
+	}
+
+	// Templates, assignee checks, and dry runs all apply to the target repository
+	target := p.in(owner, repo)
+	ticket, err := target.create(ctx, input)
+	if err != nil {
+		return schema.Ticket{}, err
+	}
+	return p.qualify(target, ticket), nil
+}
+
+// in returns a copy of the provider configured for owner/repo.
+func (p *Provider) in(owner, repo string) *Provider {
+	clone := *p
+	clone.config.Owner, clone.config.Repo = owner, repo
+	return &clone
+}
+
+func (p *Provider) create(ctx context.Context, input schema.CreateTicketInput) (schema.Ticket, error) {
+	template := ghconfig.String(input.Metadata, "template")
+	if strings.TrimSpace(input.Title) == "" && template == "" {
+		return schema.Ticket{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "title is required",
+		}
+	}
+
 	issueRequest := &github.IssueRequest{
 		Title: &input.Title,
 		Body:  &input.Description,
 	}
 
-	// Set assignees from fields if provided
-	if assignees, ok := input.Fields["assignees"].([]string); ok && len(assignees) > 0 {
+	// Set assignees from fields if provided; lists arrive as []any through the
+	// plugins, and a comma-separated string works too
+	if assignees := ghconfig.StringSlice(input.Fields, "assignees"); len(assignees) > 0 {
 		issueRequest.Assignees = &assignees
 	}
 
 	// Set labels from metadata
-	if labels, ok := input.Metadata["labels"].([]string); ok {
+	if labels := ghconfig.StringSlice(input.Metadata, "labels"); labels != nil {
 		issueRequest.Labels = &labels
 	}
 
-	issue, _, err := p.client.Issues.Create(ctx, p.config.Owner, p.config.Repo, issueRequest)
+	// Render the body from the configured template for the ticket's kind
+	if kind := ghconfig.String(input.Metadata, "kind"); kind != "" {
+		if template != "" {
+			return schema.Ticket{}, &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: "metadata kind and template cannot both be set",
+			}
+		}
+		body, err := p.renderBody(kind, input)
+		if err != nil {
+			return schema.Ticket{}, err
+		}
+		issueRequest.Body = &body
+	}
+
+	// Render the body (and title, labels, assignees) from a repo issue template
+	if template != "" {
+		if err := p.applyTemplate(ctx, template, strings.TrimSpace(input.Title), input.Description, input.Fields, issueRequest); err != nil {
+			return schema.Ticket{}, err
+		}
+		if issueRequest.GetTitle() == "" {
+			return schema.Ticket{}, &orcherr.OpsOrchError{
+				Code:    "bad_request",
+				Message: "title is required",
+			}
+		}
+	}
+
+	dropped, err := p.checkAssignees(ctx, issueRequest, input.Metadata)
+	if err != nil {
+		return schema.Ticket{}, err
+	}
+
+	// Host attachments in a gist and link them from the body, once the request
+	// is known to be valid
+	gistDescription := fmt.Sprintf("Attachments for %s/%s: %s", p.config.Owner, p.config.Repo, issueRequest.GetTitle())
+	body, discardAttachments, err := p.attachBody(ctx, gistDescription, issueRequest.GetBody(), input.Metadata, func(body string) error {
+		withLinks := *issueRequest
+		withLinks.Body = &body
+		return checkLimits(&withLinks)
+	})
+	if err != nil {
+		return schema.Ticket{}, err
+	}
+	issueRequest.Body = &body
+
+	if err := checkLimits(issueRequest); err != nil {
+		return schema.Ticket{}, err
+	}
+
+	if p.isDryRun(input.Metadata) {
+		return withDroppedAssignees(p.simulateCreate(issueRequest), dropped), nil
+	}
+
+	issue, _, err := p.api.Issues.Create(ctx, p.config.Owner, p.config.Repo, issueRequest)
 	if err != nil {
+		p.audit("ticket.create", "", issueRequest, "", err)
+		discardAttachments()
 		return schema.Ticket{}, p.wrapError(err)
 	}
+	p.audit("ticket.create", strconv.Itoa(issue.GetNumber()), issueRequest, issue.GetHTMLURL(), nil)
 
-	return p.convertIssueToTicket(issue), nil
+	ticket := p.convertIssueToTicket(issue)
+	p.Publish(ticket)
+	return withDroppedAssignees(ticket, dropped), nil
 }
 
-// Update updates an existing ticket.
+// Update updates an existing ticket, which may live in another repository the
+// provider can reach when id is of the form owner/repo#N or a node ID.
 func (p *Provider) Update(ctx context.Context, id string, input schema.UpdateTicketInput) (schema.Ticket, error) {
-	issueNumber, err := strconv.Atoi(id)
+	target, issueNumber, err := p.target(ctx, id)
 	if err != nil {
-		return schema.Ticket{}, &orcherr.OpsOrchError{
-			Code:    "bad_request",
-			Message: fmt.Sprintf("invalid issue number: %s", id),
-		}
+		return schema.Ticket{}, err
+	}
+	ticket, err := target.update(ctx, issueNumber, input)
+	if err != nil {
+		return schema.Ticket{}, err
 	}
+	return p.qualify(target, ticket), nil
+}
 
+func (p *Provider) update(ctx context.Context, issueNumber int, input schema.UpdateTicketInput) (schema.Ticket, error) {
+	id := strconv.Itoa(issueNumber)
 	issueRequest := &github.IssueRequest{}
 
 	// Update title if provided
@@ -182,8 +604,8 @@ func (p *Provider) Update(ctx context.Context, id string, input schema.UpdateTic
 		issueRequest.Title = input.Title
 	}
 
-	// Update description if provided
-	if input.Description != nil && *input.Description != "" {
+	// Update description if provided; an empty description clears the body
+	if input.Description != nil {
 		issueRequest.Body = input.Description
 	}
 
@@ -199,65 +621,268 @@ func (p *Provider) Update(ctx context.Context, id string, input schema.UpdateTic
 		}
 	}
 
-	// Update assignees if provided
-	if input.Assignees != nil && len(*input.Assignees) > 0 {
+	// Update assignees if provided; an empty list unassigns everyone
+	if input.Assignees != nil {
 		issueRequest.Assignees = input.Assignees
 	}
 
-	issue, _, err := p.client.Issues.Edit(ctx, p.config.Owner, p.config.Repo, issueNumber, issueRequest)
+	// Replace labels if provided in metadata; null or an empty list removes them all
+	if _, ok := input.Metadata["labels"]; ok {
+		labels := ghconfig.StringSlice(input.Metadata, "labels")
+		if labels == nil {
+			labels = []string{}
+		}
+		issueRequest.Labels = &labels
+	}
+
+	// Set the milestone by number or title, or clear it with null or ""
+	clearMilestone, err := p.parseMilestone(ctx, input.Fields, issueRequest)
+	if err != nil {
+		return schema.Ticket{}, err
+	}
+
+	dropped, err := p.checkAssignees(ctx, issueRequest, input.Metadata)
+	if err != nil {
+		return schema.Ticket{}, err
+	}
+
+	if err := checkLimits(issueRequest); err != nil {
+		return schema.Ticket{}, err
+	}
+
+	if p.isDryRun(input.Metadata) {
+		ticket, err := p.simulateUpdate(ctx, issueNumber, issueRequest, clearMilestone)
+		return withDroppedAssignees(ticket, dropped), err
+	}
+
+	issue, _, err := p.api.Issues.Edit(ctx, p.config.Owner, p.config.Repo, issueNumber, issueRequest)
+	p.audit("ticket.update", id, issueRequest, issue.GetHTMLURL(), err)
 	if err != nil {
 		return schema.Ticket{}, p.wrapError(err)
 	}
 
-	return p.convertIssueToTicket(issue), nil
+	// An IssueRequest cannot send the null that removes a milestone
+	if clearMilestone {
+		issue, _, err = p.api.Issues.RemoveMilestone(ctx, p.config.Owner, p.config.Repo, issueNumber)
+		p.audit("ticket.update", id, map[string]any{"milestone": nil}, issue.GetHTMLURL(), err)
+		if err != nil {
+			return schema.Ticket{}, p.wrapError(err)
+		}
+	}
+
+	ticket := p.convertIssueToTicket(issue)
+	p.Publish(ticket)
+	return withDroppedAssignees(ticket, dropped), nil
+}
+
+// checkLimits validates an issue request against GitHub's limits once the kind
+// and repository templates, template labels, and attachment links have shaped
+// it, since each can grow what the caller sent.
+func checkLimits(req *github.IssueRequest) error {
+	var labels []string
+	if req.Labels != nil {
+		labels = *req.Labels
+	}
+	return limits.Issue(req.GetTitle(), req.GetBody(), labels)
+}
+
+// ConvertIssue converts a GitHub Issue using this provider's configuration.
+func (p *Provider) ConvertIssue(issue *github.Issue) schema.Ticket {
+	return p.convertIssueToTicket(issue)
 }
 
+// ticketFieldsHint sizes a ticket's Fields for the entries most issues have,
+// so filling it rarely grows the map.
+const ticketFieldsHint = 8
+
 // convertIssueToTicket converts a GitHub Issue to a normalized Ticket.
 func (p *Provider) convertIssueToTicket(issue *github.Issue) schema.Ticket {
+	return p.convertIssue(issue, nil)
+}
+
+// convertIssue converts a GitHub Issue, building only the Fields entries in
+// want. Queries pass the set from their "fields" metadata, so large result sets
+// skip the lookups and allocations behind entries nobody reads.
+func (p *Provider) convertIssue(issue *github.Issue, want fieldset.Set) schema.Ticket {
+	description, truncated := p.formatDescription(issue.GetBody())
 	ticket := schema.Ticket{
 		ID:          strconv.Itoa(issue.GetNumber()),
 		Title:       issue.GetTitle(),
-		Description: issue.GetBody(),
+		Description: description,
 		Status:      p.normalizeStatus(issue.GetState()),
 		URL:         issue.GetHTMLURL(),
-		CreatedAt:   issue.GetCreatedAt().Time,
-		UpdatedAt:   issue.GetUpdatedAt().Time,
-		Fields: map[string]any{
-			"url": issue.GetHTMLURL(),
-		},
+		CreatedAt:   timefmt.UTC(issue.GetCreatedAt().Time),
+		UpdatedAt:   timefmt.UTC(issue.GetUpdatedAt().Time),
+		Fields:      make(map[string]any, want.Size(ticketFieldsHint)),
+	}
+	if want.Has("url") {
+		ticket.Fields["url"] = issue.GetHTMLURL()
+	}
+
+	// Node IDs stay valid across repository renames and transfers, and Get accepts them
+	if nodeID := issue.GetNodeID(); nodeID != "" && want.Has("node_id") {
+		ticket.Fields["node_id"] = nodeID
+	}
+	if repoNodeID := issue.GetRepository().GetNodeID(); repoNodeID != "" && want.Has("repository_node_id") {
+		ticket.Fields["repository_node_id"] = repoNodeID
 	}
 
-	// Add assignees
-	if len(issue.Assignees) > 0 {
-		assignees := make([]string, len(issue.Assignees))
-		for i, assignee := range issue.Assignees {
-			assignees[i] = assignee.GetLogin()
+	// Add assignees, tagging bots and leaving them out when the policy excludes them
+	var bots []string
+	for _, assignee := range issue.Assignees {
+		if p.config.BotPolicy.IsBot(assignee.GetLogin(), assignee.GetType()) {
+			bots = append(bots, assignee.GetLogin())
+			if p.config.BotPolicy.Exclude {
+				continue
+			}
+		}
+		ticket.Assignees = append(ticket.Assignees, assignee.GetLogin())
+	}
+	if len(bots) > 0 && want.Has("bot_assignees") {
+		ticket.Fields["bot_assignees"] = bots
+	}
+	if want.Has("assignee_identities") {
+		var identities map[string]identity.Identity
+		for _, login := range ticket.Assignees {
+			if id, ok := p.config.Identities.Lookup(login); ok {
+				if identities == nil {
+					identities = map[string]identity.Identity{}
+				}
+				identities[login] = id
+			}
+		}
+		if len(identities) > 0 {
+			ticket.Fields["assignee_identities"] = identities
 		}
-		ticket.Assignees = assignees
+	}
+
+	if truncated && want.Has("description_truncated") {
+		ticket.Fields["description_truncated"] = true
 	}
 
 	// Add reporter
 	if user := issue.GetUser(); user != nil {
 		ticket.Reporter = user.GetLogin()
+		if want.Has("reporter_bot") && p.config.BotPolicy.IsBot(user.GetLogin(), user.GetType()) {
+			ticket.Fields["reporter_bot"] = true
+		}
+		if want.Has("reporter_identity") {
+			if id, ok := p.config.Identities.Lookup(user.GetLogin()); ok {
+				ticket.Fields["reporter_identity"] = id
+			}
+		}
 	}
 
-	// Add labels
-	if len(issue.Labels) > 0 {
-		labels := make([]string, len(issue.Labels))
+	// Add labels, which routing reads too
+	routed := len(p.config.RoutingRules) > 0 && want.Any("team", "service", "severity")
+	var labels []string
+	if len(issue.Labels) > 0 && (routed || want.Has("labels")) {
+		labels = make([]string, len(issue.Labels))
 		for i, label := range issue.Labels {
 			labels[i] = label.GetName()
 		}
+	}
+	if len(labels) > 0 && want.Has("labels") {
 		ticket.Fields["labels"] = labels
 	}
 
+	// Pre-route the ticket using the configured label/title rules
+	if routed {
+		p.route(ticket.Fields, ticket.Title, labels)
+	}
+
 	// Add milestone
-	if milestone := issue.GetMilestone(); milestone != nil {
+	if milestone := issue.GetMilestone(); milestone != nil && want.Has("milestone") {
 		ticket.Fields["milestone"] = milestone.GetTitle()
 	}
 
+	// Add close time and duration for MTTR reporting
+	if issue.GetState() == "closed" && issue.ClosedAt != nil {
+		if want.Has("closed_at") {
+			ticket.Fields["closed_at"] = timefmt.UTC(issue.GetClosedAt().Time)
+		}
+		if want.Has("close_duration_seconds") {
+			ticket.Fields["close_duration_seconds"] = int64(issue.GetClosedAt().Sub(issue.GetCreatedAt().Time).Seconds())
+		}
+		if login := issue.GetClosedBy().GetLogin(); login != "" && want.Has("closed_by") {
+			ticket.Fields["closed_by"] = login
+		}
+	}
+
+	if p.config.EpochMillis {
+		timefmt.SetMillis(ticket.Fields, "created_at", ticket.CreatedAt)
+		timefmt.SetMillis(ticket.Fields, "updated_at", ticket.UpdatedAt)
+		if issue.GetState() == "closed" {
+			timefmt.SetMillis(ticket.Fields, "closed_at", issue.GetClosedAt().Time)
+		}
+	}
+
+	// Routing and timestamps set entries as a group; drop the ones not asked for
+	want.Prune(ticket.Fields)
+	if want.Has(instance.Field) {
+		p.config.InstanceLabels.Stamp(ticket.Fields)
+	}
 	return ticket
 }
 
+// parseMilestone sets req's milestone from fields["milestone"], a milestone
+// number or title. It reports whether the milestone should be removed instead,
+// which a null or empty value asks for; an absent key leaves the milestone alone.
+func (p *Provider) parseMilestone(ctx context.Context, fields map[string]any, req *github.IssueRequest) (bool, error) {
+	value, ok := fields["milestone"]
+	if !ok {
+		return false, nil
+	}
+	if value == nil {
+		return true, nil
+	}
+	if s, isString := value.(string); isString && strings.TrimSpace(s) == "" {
+		return true, nil
+	}
+
+	number := ghconfig.Int(fields, "milestone", 0)
+	if title, isString := value.(string); isString && number == 0 {
+		// Tickets carry the milestone title, so a ticket's fields can be sent back as is
+		found, err := p.milestoneByTitle(ctx, strings.TrimSpace(title))
+		if err != nil {
+			return false, err
+		}
+		number = found
+	}
+	if number <= 0 {
+		return false, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("invalid milestone: %v (expected a milestone number or title)", value),
+		}
+	}
+	req.Milestone = &number
+	return false, nil
+}
+
+// milestoneByTitle returns the number of the repository milestone with the given
+// title, open or closed, or 0 when there is none.
+func (p *Provider) milestoneByTitle(ctx context.Context, title string) (int, error) {
+	opts := &github.MilestoneListOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		milestones, resp, err := p.api.Issues.ListMilestones(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return 0, p.wrapError(err)
+		}
+		for _, m := range milestones {
+			if strings.EqualFold(m.GetTitle(), title) {
+				return m.GetNumber(), nil
+			}
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return 0, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
 // normalizeStatus converts GitHub issue state to normalized status.
 func (p *Provider) normalizeStatus(state string) string {
 	switch strings.ToLower(state) {
@@ -272,40 +897,7 @@ func (p *Provider) normalizeStatus(state string) string {
 
 // wrapError wraps GitHub API errors into OpsOrch errors.
 func (p *Provider) wrapError(err error) error {
-	if ghErr, ok := err.(*github.ErrorResponse); ok {
-		switch ghErr.Response.StatusCode {
-		case 401:
-			return &orcherr.OpsOrchError{
-				Code:    "unauthorized",
-				Message: "GitHub API authentication failed",
-			}
-		case 403:
-			return &orcherr.OpsOrchError{
-				Code:    "forbidden",
-				Message: "GitHub API access forbidden",
-			}
-		case 404:
-			return &orcherr.OpsOrchError{
-				Code:    "not_found",
-				Message: "GitHub repository or issue not found",
-			}
-		case 422:
-			return &orcherr.OpsOrchError{
-				Code:    "bad_request",
-				Message: fmt.Sprintf("GitHub API validation error: %s", ghErr.Message),
-			}
-		default:
-			return &orcherr.OpsOrchError{
-				Code:    "provider_error",
-				Message: fmt.Sprintf("GitHub API error: %s", ghErr.Message),
-			}
-		}
-	}
-
-	return &orcherr.OpsOrchError{
-		Code:    "provider_error",
-		Message: fmt.Sprintf("GitHub API error: %v", err),
-	}
+	return gherr.Wrap(err, "repository or issue")
 }
 
 func init() {
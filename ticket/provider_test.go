@@ -1,11 +1,40 @@
 package ticket
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
 	"github.com/opsorch/opsorch-core/ticket"
+	"github.com/opsorch/opsorch-github-adapter/audit"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/botpolicy"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+	"github.com/opsorch/opsorch-github-adapter/internal/identity"
+	"github.com/opsorch/opsorch-github-adapter/internal/instance"
+	"github.com/opsorch/opsorch-github-adapter/internal/lastgood"
+	"github.com/opsorch/opsorch-github-adapter/internal/override"
 )
 
+// newFakeProvider returns a provider wired to a fake GitHub server.
+func newFakeProvider(t testing.TB) (*Provider, *fakegithub.Server) {
+	srv := fakegithub.New(t)
+	return &Provider{
+		api:    ghapi.FromClient(srv.Client()),
+		config: Config{Owner: fakegithub.Owner, Repo: fakegithub.Repo, DefaultState: "open"},
+	}, srv
+}
+
 func TestNew(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -45,8 +74,28 @@ func TestNew(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "repository shorthand",
+			config: map[string]any{
+				"token":      "ghp_test_token",
+				"repository": "testorg/testrepo",
+			},
+			wantErr: false,
+		},
+		{
+			name: "malformed repository shorthand",
+			config: map[string]any{
+				"token":      "ghp_test_token",
+				"repository": "testrepo",
+			},
+			wantErr: true,
+		},
 	}
 
+	// Keep Actions environment from leaking into the config under test
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_REPOSITORY", "")
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			provider, err := New(tt.config)
@@ -61,6 +110,20 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewEnvFallback(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_env_token")
+	t.Setenv("GITHUB_REPOSITORY", "envorg/envrepo")
+
+	p, err := New(map[string]any{"repo": "override"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	cfg := p.(*Provider).config
+	if cfg.Token != "ghp_env_token" || cfg.Owner != "envorg" || cfg.Repo != "override" {
+		t.Errorf("unexpected config from env fallback: %+v", cfg)
+	}
+}
+
 func TestProviderRegistration(t *testing.T) {
 	// Test that the provider is registered
 	constructor, ok := ticket.LookupProvider("github")
@@ -95,3 +158,1266 @@ func TestNormalizeStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateDryRun(t *testing.T) {
+	p := &Provider{config: Config{Owner: "testorg", Repo: "testrepo", DefaultState: "open", ReadOnly: true}}
+
+	result, err := p.Create(context.Background(), schema.CreateTicketInput{
+		Title:       "Outage",
+		Description: "Details",
+		Metadata:    map[string]any{"labels": []string{"sev1"}},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if result.Title != "Outage" || result.Status != "open" {
+		t.Errorf("unexpected simulated ticket: %+v", result)
+	}
+	if result.Fields["dry_run"] != true {
+		t.Errorf("expected dry_run field, got %v", result.Fields["dry_run"])
+	}
+
+	if _, err := p.Create(context.Background(), schema.CreateTicketInput{}); err == nil {
+		t.Errorf("expected validation error for empty title")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	p, srv := newFakeProvider(t)
+
+	tickets, err := p.Query(context.Background(), schema.TicketQuery{
+		Statuses: []string{"open"},
+		Scope:    schema.QueryScope{Team: "alice"},
+		Limit:    10,
+		Metadata: map[string]any{"labels": []string{"bug", "sev1"}},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+
+	// The pull request in the fixture is skipped
+	if len(tickets) != 2 {
+		t.Fatalf("got %d tickets, want 2", len(tickets))
+	}
+	first := tickets[0]
+	if first.ID != "1" || first.Status != "open" || first.Reporter != "reporter" {
+		t.Errorf("unexpected ticket: %+v", first)
+	}
+	if !reflect.DeepEqual(first.Assignees, []string{"alice", "bob"}) {
+		t.Errorf("assignees = %v", first.Assignees)
+	}
+	if !reflect.DeepEqual(first.Fields["labels"], []string{"bug", "sev1"}) || first.Fields["milestone"] != "Q1" {
+		t.Errorf("fields = %v", first.Fields)
+	}
+	if tickets[1].Status != "closed" {
+		t.Errorf("second ticket status = %q", tickets[1].Status)
+	}
+
+	q := srv.Requests()[0].Query
+	if q.Get("state") != "open" || q.Get("assignee") != "alice" || q.Get("labels") != "bug,sev1" || q.Get("per_page") != "100" {
+		t.Errorf("unexpected query parameters: %v", q)
+	}
+}
+
+func TestQueryFillsLimitAcrossPages(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.PageSize = 1
+
+	// The third issue is a pull request, so a limit of 3 runs out of pages with 2
+	tickets, err := p.Query(context.Background(), schema.TicketQuery{Limit: 3})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(tickets) != 2 || tickets[0].ID != "1" || tickets[1].ID != "2" {
+		t.Fatalf("tickets = %+v", tickets)
+	}
+	if got := len(srv.Requests()); got != 3 {
+		t.Errorf("made %d requests, want 3", got)
+	}
+
+	tickets, _ = p.Query(context.Background(), schema.TicketQuery{Limit: 1})
+	if len(tickets) != 1 || len(srv.Requests()) != 4 {
+		t.Errorf("limit 1 returned %d tickets after %d requests", len(tickets), len(srv.Requests()))
+	}
+
+	// Limits beyond GitHub's page size are filled from later pages
+	srv.PageSize = 0
+	tickets, _ = p.Query(context.Background(), schema.TicketQuery{Limit: 250})
+	reqs := srv.Requests()
+	if len(tickets) != 2 || reqs[len(reqs)-1].Query.Get("per_page") != "100" {
+		t.Errorf("limit 250 returned %d tickets with query %v", len(tickets), reqs[len(reqs)-1].Query)
+	}
+}
+
+func TestQuerySkipsPullRequestPage(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	pr := map[string]any{"pull_request": map[string]any{"url": "https://api.github.com/repos/acme/api/pulls/1"}}
+	items := []map[string]any{pr, pr, pr, {"number": 4, "state": "open"}, {"number": 5, "state": "open"}}
+	srv.Handle(http.MethodGet, "/repos/acme/api/issues", func(w http.ResponseWriter, r *http.Request) {
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		fakegithub.WriteJSON(w, http.StatusOK, items[:min(perPage, len(items))])
+	})
+
+	// A page sized to the limit would hold only pull requests
+	tickets, err := p.Query(context.Background(), schema.TicketQuery{Limit: 2})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(tickets) != 2 || tickets[0].ID != "4" || tickets[1].ID != "5" {
+		t.Errorf("tickets = %+v, want issues 4 and 5", tickets)
+	}
+	if reqs := srv.Requests(); len(reqs) != 1 || reqs[0].Query.Get("per_page") != "100" {
+		t.Errorf("requests = %+v, want one full page", reqs)
+	}
+}
+
+func TestQueryPageToken(t *testing.T) {
+	p, _ := newFakeProvider(t)
+
+	first, err := p.QueryPage(context.Background(), schema.TicketQuery{Limit: 1})
+	if err != nil {
+		t.Fatalf("QueryPage() error = %v", err)
+	}
+	if len(first.Tickets) != 1 || first.Tickets[0].ID != "1" || first.NextPageToken == "" || !first.HasMore || first.TotalCount != nil {
+		t.Fatalf("first page = %+v", first)
+	}
+
+	// The pull request after issue 2 is skipped and ends the results
+	second, err := p.QueryPage(context.Background(), schema.TicketQuery{Limit: 5, Metadata: map[string]any{"pageToken": first.NextPageToken}})
+	if err != nil {
+		t.Fatalf("QueryPage() error = %v", err)
+	}
+	if len(second.Tickets) != 1 || second.Tickets[0].ID != "2" || second.NextPageToken != "" || second.HasMore {
+		t.Errorf("second page = %+v", second)
+	}
+
+	all, err := p.QueryPage(context.Background(), schema.TicketQuery{})
+	if err != nil || all.TotalCount == nil || *all.TotalCount != 2 {
+		t.Errorf("complete QueryPage() = %+v, %v, want a total of 2", all, err)
+	}
+
+	if _, err := p.Query(context.Background(), schema.TicketQuery{Metadata: map[string]any{"pageToken": "bogus"}}); !hasCode(err, "bad_request") {
+		t.Errorf("Query() with a bad token error = %v, want bad_request", err)
+	}
+}
+
+func TestQueryPageBudget(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.PageSize = 1
+	p.config.Budget = budget.Limits{Calls: 1}
+
+	page, err := p.QueryPage(context.Background(), schema.TicketQuery{Limit: 2})
+	if err != nil {
+		t.Fatalf("QueryPage() error = %v", err)
+	}
+	if len(page.Tickets) != 1 || !page.Truncated || page.NextPageToken == "" || page.TotalCount != nil {
+		t.Fatalf("budgeted page = %+v", page)
+	}
+	if got := len(srv.Requests()); got != 1 {
+		t.Errorf("made %d requests, want 1", got)
+	}
+
+	// The token picks up where the budget ran out
+	page, err = p.QueryPage(context.Background(), schema.TicketQuery{Limit: 1, Metadata: map[string]any{"pageToken": page.NextPageToken}})
+	if err != nil || len(page.Tickets) != 1 || page.Tickets[0].ID != "2" || page.Truncated {
+		t.Errorf("resumed page = %+v, %v", page, err)
+	}
+}
+
+func TestQueryFields(t *testing.T) {
+	p, _ := newFakeProvider(t)
+	p.config.RoutingRules = []RoutingRule{{Label: "bug", Team: "sre", Severity: "sev2"}}
+
+	tickets, err := p.Query(context.Background(), schema.TicketQuery{
+		Metadata: map[string]any{"fields": []string{"labels", "team"}},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(tickets) == 0 {
+		t.Fatal("no tickets")
+	}
+	// Only the requested entries are built; routing's severity is dropped
+	want := map[string]any{"labels": []string{"bug", "sev1"}, "team": "sre"}
+	if !reflect.DeepEqual(tickets[0].Fields, want) {
+		t.Errorf("fields = %v, want %v", tickets[0].Fields, want)
+	}
+	if tickets[0].ID != "1" || tickets[0].Title == "" || tickets[0].Reporter == "" {
+		t.Errorf("top-level fields missing: %+v", tickets[0])
+	}
+
+	// Without "fields" every entry is built
+	tickets, err = p.Query(context.Background(), schema.TicketQuery{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if tickets[0].Fields["url"] == nil || tickets[0].Fields["milestone"] != "Q1" || tickets[0].Fields["severity"] != "sev2" {
+		t.Errorf("fields = %v", tickets[0].Fields)
+	}
+
+	// Naming top-level values leaves out the others
+	tickets, err = p.Query(context.Background(), schema.TicketQuery{
+		Metadata: map[string]any{"fields": []string{"id", "status", "url"}},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	got := tickets[0]
+	if got.ID != "1" || got.Status == "" || got.URL == "" || got.Title != "" || got.Reporter != "" || !got.CreatedAt.IsZero() {
+		t.Errorf("projected ticket = %+v", got)
+	}
+	if !reflect.DeepEqual(got.Fields, map[string]any{"url": got.URL}) {
+		t.Errorf("projected fields = %v", got.Fields)
+	}
+}
+
+func TestInstanceLabels(t *testing.T) {
+	p, _ := newFakeProvider(t)
+	p.config.InstanceLabels = instance.Labels{"region": "eu-west-1", "tenant": "acme"}
+
+	got, err := p.Get(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Fields[instance.Field], map[string]string{"region": "eu-west-1", "tenant": "acme"}) {
+		t.Errorf("fields = %v", got.Fields)
+	}
+
+	// Projected queries only carry the labels when asked for
+	tickets, _ := p.Query(context.Background(), schema.TicketQuery{Metadata: map[string]any{"fields": "labels"}})
+	if len(tickets) == 0 || tickets[0].Fields[instance.Field] != nil {
+		t.Errorf("projected tickets = %+v", tickets)
+	}
+}
+
+func TestEpochMillis(t *testing.T) {
+	p, _ := newFakeProvider(t)
+	p.config.EpochMillis = true
+
+	closedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	got := p.convertIssue(&github.Issue{
+		Number:    github.Int(1),
+		State:     github.String("closed"),
+		CreatedAt: &github.Timestamp{Time: closedAt.Add(-time.Hour)},
+		ClosedAt:  &github.Timestamp{Time: closedAt},
+	}, nil)
+	if got.CreatedAt.Location() != time.UTC || got.Fields["closed_at"] != closedAt.UTC() {
+		t.Errorf("timestamps not in UTC: %v, %v", got.CreatedAt, got.Fields["closed_at"])
+	}
+	if got.Fields["created_at_ms"] != closedAt.Add(-time.Hour).UnixMilli() || got.Fields["closed_at_ms"] != closedAt.UnixMilli() {
+		t.Errorf("fields = %v", got.Fields)
+	}
+	if _, ok := got.Fields["updated_at_ms"]; ok {
+		t.Errorf("zero updated_at has millis: %v", got.Fields)
+	}
+}
+
+func TestGetServesLastGoodDuringOutage(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	store, err := lastgood.Parse(map[string]any{"staleCacheDir": t.TempDir()}, "ticket/acme/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.config.LastGood = store
+
+	fresh, err := p.Get(context.Background(), "1")
+	if err != nil || fresh.Metadata["stale"] != nil {
+		t.Fatalf("Get() = %+v, %v", fresh, err)
+	}
+	if _, err := p.Query(context.Background(), schema.TicketQuery{Limit: 1}); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+
+	srv.Error(http.MethodGet, "/repos/acme/api/issues/1", http.StatusBadGateway, "Server Error")
+	srv.Error(http.MethodGet, "/repos/acme/api/issues", http.StatusBadGateway, "Server Error")
+	stale, err := p.Get(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Get() during an outage error = %v", err)
+	}
+	if stale.Title != fresh.Title || stale.Metadata["stale"] != true || stale.Metadata["stale_at"] == nil {
+		t.Errorf("Get() during an outage = %+v", stale)
+	}
+	tickets, err := p.Query(context.Background(), schema.TicketQuery{Limit: 1})
+	if err != nil || len(tickets) != 1 || tickets[0].Metadata["stale"] != true {
+		t.Errorf("Query() during an outage = %+v, %v", tickets, err)
+	}
+
+	// Reads never made before still fail
+	if _, err := p.Query(context.Background(), schema.TicketQuery{Limit: 2}); !hasCode(err, "provider_error") {
+		t.Errorf("unseen Query() during an outage error = %v", err)
+	}
+}
+
+func TestGet(t *testing.T) {
+	p, _ := newFakeProvider(t)
+
+	got, err := p.Get(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Title != "Database latency spike" || got.URL != "https://github.com/acme/api/issues/1" {
+		t.Errorf("unexpected ticket: %+v", got)
+	}
+
+	if _, err := p.Get(context.Background(), "abc"); !hasCode(err, "bad_request") {
+		t.Errorf("Get(abc) error = %v, want bad_request", err)
+	}
+	if _, err := p.Get(context.Background(), "99"); !hasCode(err, "not_found") {
+		t.Errorf("Get(99) error = %v, want not_found", err)
+	}
+}
+
+func TestGetCachesCopy(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	p.config.CacheTTL = time.Minute
+	p.config.Render = RenderHTML
+	t.Cleanup(func() { p.Invalidate("1") })
+	srv.Handle(http.MethodPost, "/markdown", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("<p>rendered</p>"))
+	})
+
+	first, err := p.Get(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, ok := first.Fields["description_html"]; !ok {
+		t.Fatalf("Get() fields = %v, want the rendered description", first.Fields)
+	}
+	first.Fields["description_html"] = "changed by the caller"
+
+	// The cached ticket holds the rendering and keeps it when callers change theirs
+	second, err := p.Get(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("second Get() error = %v", err)
+	}
+	if second.Fields["description_html"] == "changed by the caller" {
+		t.Error("changing a returned ticket changed the cached one")
+	}
+	reads := 0
+	for _, r := range srv.Requests() {
+		if r.Path == "/repos/acme/api/issues/1" {
+			reads++
+		}
+	}
+	if reads != 1 {
+		t.Errorf("issue read %d times, want once", reads)
+	}
+}
+
+func TestUpdateThenGetEnriches(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	p.config.CacheTTL = time.Minute
+	p.config.Render = RenderHTML
+	t.Cleanup(func() { p.Invalidate("1") })
+	srv.Handle(http.MethodPost, "/markdown", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("<p>rendered</p>"))
+	})
+
+	if _, err := p.Get(context.Background(), "1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	title := "Database latency spike (mitigated)"
+	updated, err := p.Update(context.Background(), "1", schema.UpdateTicketInput{Title: &title})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, ok := updated.Fields["description_html"]; ok {
+		t.Fatalf("Update() fields = %v, want no rendering", updated.Fields)
+	}
+
+	// A new title cannot be merged into the cached ticket, so Update dropped it
+	// rather than caching its bare result
+	got, err := p.Get(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Get() after Update error = %v", err)
+	}
+	if got.Fields["description_html"] != "<p>rendered</p>" {
+		t.Errorf("Get() after Update fields = %v, want the rendered description", got.Fields)
+	}
+}
+
+func TestPublishMergesCachedTicket(t *testing.T) {
+	p, _ := newFakeProvider(t)
+	p.config.CacheTTL = time.Minute
+	t.Cleanup(func() { p.Invalidate("1") })
+	now := time.Now().UTC()
+	p.Prime(schema.Ticket{ID: "1", Title: "Outage", Status: "open", UpdatedAt: now, Fields: map[string]any{"description_html": "<p>down</p>", "labels": []string{"bug"}}})
+
+	p.Publish(schema.Ticket{ID: "1", Title: "Outage", Status: "open", UpdatedAt: now.Add(time.Minute), Fields: map[string]any{"labels": []string{"bug", "sev1"}}})
+	got, ok := p.cachedTicket("1")
+	if !ok || !reflect.DeepEqual(got.Fields["labels"], []string{"bug", "sev1"}) || got.Fields["description_html"] != "<p>down</p>" || !got.UpdatedAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("cached ticket after a label change = %+v, %v, want the new labels merged", got, ok)
+	}
+
+	// A delivery older than the cached ticket is ambiguous and drops it
+	p.Publish(schema.Ticket{ID: "1", Title: "Outage", Status: "open", UpdatedAt: now})
+	if _, ok := p.cachedTicket("1"); ok {
+		t.Error("an older change was merged into the cached ticket")
+	}
+}
+
+func TestCacheSeparatesProviders(t *testing.T) {
+	plain, srv := newFakeProvider(t)
+	plain.config.CacheTTL = time.Minute
+	plain.cacheScope = cacheScopes.Add(1)
+	rendering := &Provider{api: plain.api, cacheScope: cacheScopes.Add(1), config: plain.config}
+	rendering.config.Render = RenderHTML
+	t.Cleanup(func() { plain.Invalidate("1") })
+	srv.Handle(http.MethodPost, "/markdown", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("<p>rendered</p>"))
+	})
+
+	if _, err := rendering.Get(context.Background(), "1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got, err := plain.Get(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, ok := got.Fields["description_html"]; ok {
+		t.Errorf("Get() fields = %v, served from another provider's cache", got.Fields)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	p, srv := newFakeProvider(t)
+
+	got, err := p.Create(context.Background(), schema.CreateTicketInput{
+		Title:       "Cache stampede",
+		Description: "Thundering herd after deploy",
+		Fields:      map[string]any{"assignees": []string{"alice"}},
+		Metadata:    map[string]any{"labels": []string{"sev2"}},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if got.ID != "4" || got.Title != "Cache stampede" || got.Status != "open" {
+		t.Errorf("unexpected ticket: %+v", got)
+	}
+	if !reflect.DeepEqual(got.Assignees, []string{"alice"}) || !reflect.DeepEqual(got.Fields["labels"], []string{"sev2"}) {
+		t.Errorf("unexpected assignees/labels: %v %v", got.Assignees, got.Fields["labels"])
+	}
+
+	reqs := srv.Requests()
+	var body map[string]any
+	json.Unmarshal(reqs[len(reqs)-1].Body, &body)
+	if body["body"] != "Thundering herd after deploy" {
+		t.Errorf("request body = %v", body)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	var entries []audit.Entry
+	p.config.Audit, _ = audit.Parse(map[string]any{"auditActor": "opsorch-prod", "token": "ghp_secret", "auditFunc": func(e audit.Entry) {
+		entries = append(entries, e)
+	}})
+
+	if _, err := p.Create(context.Background(), schema.CreateTicketInput{Title: "Database down"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	title := "Database restored"
+	if _, err := p.Update(context.Background(), "1", schema.UpdateTicketInput{Title: &title}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	// Dry runs write nothing and are not recorded
+	if _, err := p.Create(context.Background(), schema.CreateTicketInput{Title: "Rehearsal", Metadata: map[string]any{"dryRun": true}}); err != nil {
+		t.Fatalf("Create() dry run error = %v", err)
+	}
+	srv.Error(http.MethodPost, "/repos/acme/api/issues", http.StatusForbidden, "Resource not accessible by integration")
+	if _, err := p.Create(context.Background(), schema.CreateTicketInput{Title: "Denied"}); err == nil {
+		t.Fatal("Create() succeeded, want forbidden")
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("audit entries = %+v, want 3", entries)
+	}
+	create, update, failed := entries[0], entries[1], entries[2]
+	if create.Operation != "ticket.create" || create.Repository != "acme/api" || create.Target == "" || create.URL == "" {
+		t.Errorf("create entry = %+v", create)
+	}
+	if create.Actor != "opsorch-prod" || create.Token != audit.Fingerprint("ghp_secret") || create.Time.IsZero() {
+		t.Errorf("create entry identity = %q %q %v", create.Actor, create.Token, create.Time)
+	}
+	if req, ok := create.Input.(*github.IssueRequest); !ok || req.GetTitle() != "Database down" {
+		t.Errorf("create entry input = %#v", create.Input)
+	}
+	if update.Operation != "ticket.update" || update.Target != "1" || update.Error != "" {
+		t.Errorf("update entry = %+v", update)
+	}
+	if failed.Operation != "ticket.create" || failed.Target != "" || failed.Error == "" {
+		t.Errorf("failed create entry = %+v", failed)
+	}
+}
+
+func TestRepositoryOverride(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/web/issues", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"number": 7, "title": "Web outage", "state": "open"}})
+	})
+	srv.Handle(http.MethodPost, "/repos/acme/web/issues", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusCreated, map[string]any{"number": 8, "title": "Cache stampede", "state": "open"})
+	})
+
+	// Overrides are refused until an allowlist enables them
+	_, err := p.Query(context.Background(), schema.TicketQuery{Metadata: map[string]any{"repo": "web"}})
+	var oe *orcherr.OpsOrchError
+	if !errors.As(err, &oe) || oe.Code != "forbidden" {
+		t.Fatalf("Query() error = %v, want forbidden", err)
+	}
+
+	p.config.RepositoryAllowlist = override.Allowlist{"acme/web"}
+	tickets, err := p.Query(context.Background(), schema.TicketQuery{Metadata: map[string]any{"repo": "web"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(tickets) != 1 || tickets[0].ID != "acme/web#7" || tickets[0].Fields["repository"] != "acme/web" {
+		t.Errorf("Query() = %+v", tickets)
+	}
+
+	got, err := p.Create(context.Background(), schema.CreateTicketInput{
+		Title:    "Cache stampede",
+		Metadata: map[string]any{"repository": "acme/web"},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if got.ID != "acme/web#8" || got.Fields["repository"] != "acme/web" {
+		t.Errorf("Create() = %+v", got)
+	}
+
+	_, err = p.Create(context.Background(), schema.CreateTicketInput{
+		Title:    "Elsewhere",
+		Metadata: map[string]any{"owner": "other", "repo": "web"},
+	})
+	if !errors.As(err, &oe) || oe.Code != "forbidden" {
+		t.Errorf("Create() outside the allowlist error = %v, want forbidden", err)
+	}
+}
+
+func TestOverrideTicketIDs(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	p.config.RepositoryAllowlist = override.Allowlist{"partner/portal"}
+	issue := map[string]any{"number": 8, "title": "Partner outage", "state": "open"}
+	srv.Handle(http.MethodGet, "/repos/partner/portal/issues/8", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, issue)
+	})
+	srv.Handle(http.MethodPatch, "/repos/partner/portal/issues/8", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"number": 8, "title": "Partner outage", "state": "closed"})
+	})
+	srv.Handle(http.MethodGet, "/repos/partner/portal/issues/8/comments", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{})
+	})
+	srv.Handle(http.MethodPost, "/repos/partner/portal/issues/8/comments", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusCreated, map[string]any{"id": 5, "body": "Deployed in 7001"})
+	})
+	srv.Handle(http.MethodPost, "/repos/partner/portal/issues/8/labels", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []any{})
+	})
+	ctx := context.Background()
+
+	status := "closed"
+	got, err := p.Update(ctx, "partner/portal#8", schema.UpdateTicketInput{Status: &status})
+	if err != nil || got.ID != "partner/portal#8" || got.Status != "closed" || got.Fields["repository"] != "partner/portal" {
+		t.Errorf("Update() = %+v, %v", got, err)
+	}
+	if _, err := p.AddComment(ctx, "partner/portal#8", "Mitigated", nil); err != nil {
+		t.Errorf("AddComment() error = %v", err)
+	}
+	if _, err := p.Comments(ctx, "partner/portal#8"); err != nil {
+		t.Errorf("Comments() error = %v", err)
+	}
+	link, err := p.LinkDeployment(ctx, "partner/portal#8", schema.Deployment{ID: "7001"})
+	if err != nil || link.Ticket.ID != "partner/portal#8" || !reflect.DeepEqual(link.Deployment.Fields["linked_tickets"], []string{"partner/portal#8"}) {
+		t.Errorf("LinkDeployment() = %+v, %v", link, err)
+	}
+
+	// Repositories outside the allowlist are refused before any call
+	before := len(srv.Requests())
+	if _, err := p.Update(ctx, "other/web#1", schema.UpdateTicketInput{Status: &status}); !hasCode(err, "forbidden") {
+		t.Errorf("Update() outside the allowlist error = %v, want forbidden", err)
+	}
+	if _, err := p.AddComment(ctx, "other/web#1", "Hi", nil); !hasCode(err, "forbidden") {
+		t.Errorf("AddComment() outside the allowlist error = %v, want forbidden", err)
+	}
+	if len(srv.Requests()) != before {
+		t.Errorf("forbidden calls made %d requests", len(srv.Requests())-before)
+	}
+}
+
+func TestAssigneeValidation(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	input := schema.CreateTicketInput{
+		Title:  "Cache stampede",
+		Fields: map[string]any{"assignees": []string{"alice", "ghost", "mallory"}},
+	}
+
+	_, err := p.Create(context.Background(), input)
+	var invalid *InvalidAssigneesError
+	if !hasCode(err, "bad_request") || !errors.As(err, &invalid) || !reflect.DeepEqual(invalid.Logins, []string{"ghost", "mallory"}) {
+		t.Fatalf("Create() error = %v, want invalid assignees ghost, mallory", err)
+	}
+	for _, req := range srv.Requests() {
+		if req.Method == http.MethodPost {
+			t.Fatalf("issue created despite invalid assignees")
+		}
+	}
+
+	input.Metadata = map[string]any{"bestEffortAssignees": true}
+	got, err := p.Create(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Create() best effort error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Assignees, []string{"alice"}) || !reflect.DeepEqual(got.Fields["dropped_assignees"], []string{"ghost", "mallory"}) {
+		t.Errorf("assignees = %v, fields = %v", got.Assignees, got.Fields)
+	}
+
+	// Dropping every assignee on update leaves the existing ones untouched
+	p.config.BestEffortAssignees = true
+	ghost := []string{"ghost"}
+	if _, err := p.Update(context.Background(), "1", schema.UpdateTicketInput{Assignees: &ghost}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	reqs := srv.Requests()
+	var body map[string]any
+	json.Unmarshal(reqs[len(reqs)-1].Body, &body)
+	if _, ok := body["assignees"]; ok {
+		t.Errorf("update request = %v, want no assignees", body)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	p, srv := newFakeProvider(t)
+
+	status := "resolved"
+	title := "Database latency spike (mitigated)"
+	got, err := p.Update(context.Background(), "1", schema.UpdateTicketInput{
+		Title:  &title,
+		Status: &status,
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got.Title != title || got.Status != "closed" {
+		t.Errorf("unexpected ticket: %+v", got)
+	}
+
+	var body map[string]any
+	json.Unmarshal(srv.Requests()[0].Body, &body)
+	if body["state"] != "closed" {
+		t.Errorf("request body = %v", body)
+	}
+	if _, ok := body["body"]; ok {
+		t.Errorf("unset description should not be sent: %v", body)
+	}
+}
+
+func TestComments(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.PageSize = 2
+
+	comments, err := p.Comments(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Comments() error = %v", err)
+	}
+	if len(comments) != 3 {
+		t.Fatalf("got %d comments across pages, want 3", len(comments))
+	}
+	if comments[0].Author != "alice" || comments[2].Body != "Latency back to normal" {
+		t.Errorf("unexpected comments: %+v", comments)
+	}
+	if got := len(srv.Requests()); got != 2 {
+		t.Errorf("made %d requests, want 2", got)
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"full_name": "acme/api", "private": false})
+	})
+	srv.Handle(http.MethodPost, "/gists", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusCreated, map[string]any{"id": "abc", "html_url": "https://gist.github.com/abc"})
+	})
+	srv.Handle(http.MethodPost, "/repos/acme/api/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		var c map[string]any
+		json.NewDecoder(r.Body).Decode(&c)
+		c["id"] = 9100
+		fakegithub.WriteJSON(w, http.StatusCreated, c)
+	})
+
+	var attachments []any
+	json.Unmarshal([]byte(`[{"name": "api.log", "content": "panic: nil map"}, {"name": "heap profile.txt", "content": "..."}]`), &attachments)
+
+	if _, err := p.Create(context.Background(), schema.CreateTicketInput{
+		Title:       "Crash loop",
+		Description: "Pods restarting",
+		Metadata:    map[string]any{"attachments": attachments},
+	}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	reqs := srv.Requests()
+	var gist, issue map[string]any
+	json.Unmarshal(reqs[len(reqs)-2].Body, &gist)
+	json.Unmarshal(reqs[len(reqs)-1].Body, &issue)
+	files, _ := gist["files"].(map[string]any)
+	if gist["public"] != false || len(files) != 2 || files["api.log"].(map[string]any)["content"] != "panic: nil map" {
+		t.Errorf("gist request = %v", gist)
+	}
+	wantBody := "Pods restarting\n\n### Attachments\n\n- [api.log](https://gist.github.com/abc#file-api-log)\n- [heap profile.txt](https://gist.github.com/abc#file-heap-profile-txt)"
+	if issue["body"] != wantBody {
+		t.Errorf("issue body = %q", issue["body"])
+	}
+
+	comment, err := p.AddComment(context.Background(), "1", "Heap dump attached", map[string]any{
+		"attachments": []Attachment{{Name: "heap.txt", Content: "..."}},
+	})
+	if err != nil {
+		t.Fatalf("AddComment() error = %v", err)
+	}
+	if comment.Body != "Heap dump attached\n\n### Attachments\n\n- [heap.txt](https://gist.github.com/abc#file-heap-txt)" {
+		t.Errorf("comment body = %q", comment.Body)
+	}
+
+	// Dry runs list the files without uploading them
+	before := len(srv.Requests())
+	got, err := p.AddComment(context.Background(), "1", "Logs", map[string]any{"dryRun": true, "attachments": []Attachment{{Name: "a.log", Content: "x"}}})
+	if err != nil || got.Body != "Logs\n\n### Attachments\n\n- a.log" || len(srv.Requests()) != before {
+		t.Errorf("dry-run AddComment() = %q, %v with %d requests", got.Body, err, len(srv.Requests())-before)
+	}
+
+	for _, bad := range []any{
+		"api.log",
+		[]any{map[string]any{"name": "", "content": "x"}},
+		[]any{map[string]any{"name": "a/b.log", "content": "x"}},
+		[]any{map[string]any{"name": "a.log"}},
+		[]any{map[string]any{"name": "a.log", "content": "x"}, map[string]any{"name": "a.log", "content": "y"}},
+	} {
+		if _, err := p.AddComment(context.Background(), "1", "x", map[string]any{"attachments": bad}); !hasCode(err, "bad_request") {
+			t.Errorf("AddComment() with attachments %v error = %v, want bad_request", bad, err)
+		}
+	}
+}
+
+func TestAttachmentsUploadLast(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	private := true
+	srv.Handle(http.MethodGet, "/repos/acme/api", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"full_name": "acme/api", "private": private})
+	})
+	srv.Handle(http.MethodPost, "/gists", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusCreated, map[string]any{"id": "abc", "html_url": "https://gist.github.com/abc"})
+	})
+	deleted := false
+	srv.Handle(http.MethodDelete, "/gists/abc", func(w http.ResponseWriter, r *http.Request) {
+		deleted = true
+		fakegithub.NoContent(w, r)
+	})
+	srv.Error(http.MethodPost, "/repos/acme/api/issues", http.StatusGone, "Issues are disabled for this repo")
+	uploads := func() int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if r.Method == http.MethodPost && r.Path == "/gists" {
+				n++
+			}
+		}
+		return n
+	}
+	input := schema.CreateTicketInput{
+		Title:    "Crash loop",
+		Metadata: map[string]any{"attachments": []Attachment{{Name: "api.log", Content: "panic: nil map"}}},
+	}
+
+	// Private repositories refuse attachments unless privateAttachments is set
+	if _, err := p.Create(context.Background(), input); !hasCode(err, "bad_request") || uploads() != 0 {
+		t.Errorf("Create() in a private repository error = %v with %d uploads", err, uploads())
+	}
+
+	// A request that fails validation uploads nothing
+	private = false
+	tooLong := input
+	tooLong.Description = strings.Repeat("x", 65536)
+	if _, err := p.Create(context.Background(), tooLong); !hasCode(err, "bad_request") || uploads() != 0 {
+		t.Errorf("Create() of an oversized body error = %v with %d uploads", err, uploads())
+	}
+
+	// The gist of a create GitHub refuses is deleted
+	p.config.PrivateAttachments, private = true, true
+	if _, err := p.Create(context.Background(), input); err == nil || uploads() != 1 || !deleted {
+		t.Errorf("failed Create() error = %v with %d uploads, gist deleted = %v", err, uploads(), deleted)
+	}
+}
+
+func TestGists(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	gist := map[string]any{
+		"id": "abc", "html_url": "https://gist.github.com/abc", "description": "INC-42 scratchpad", "public": false,
+		"files": map[string]any{
+			"notes.md": map[string]any{"filename": "notes.md", "content": "Rolled back", "size": 11, "language": "Markdown"},
+			"big.log":  map[string]any{"filename": "big.log", "content": "first MB", "size": 5000000},
+		},
+	}
+	srv.Handle(http.MethodPost, "/gists", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusCreated, gist)
+	})
+	srv.Handle(http.MethodPatch, "/gists/abc", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, gist)
+	})
+	srv.Handle(http.MethodGet, "/gists/abc", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, gist)
+	})
+
+	created, err := p.CreateGist(context.Background(), GistInput{Description: "INC-42 scratchpad", Files: map[string]string{"notes.md": "Rolled back"}})
+	if err != nil {
+		t.Fatalf("CreateGist() error = %v", err)
+	}
+	if created.ID != "abc" || len(created.Files) != 2 || created.Files[0].Name != "big.log" || !created.Files[0].Truncated || created.Files[1].Truncated {
+		t.Errorf("created = %+v", created)
+	}
+	var body map[string]any
+	json.Unmarshal(srv.Requests()[0].Body, &body)
+	if body["public"] != false || body["description"] != "INC-42 scratchpad" {
+		t.Errorf("create request = %v", body)
+	}
+
+	if _, err := p.UpdateGist(context.Background(), "abc", GistInput{Files: map[string]string{"notes.md": "Rolled back\nRoot cause: config"}}); err != nil {
+		t.Fatalf("UpdateGist() error = %v", err)
+	}
+	body = nil
+	json.Unmarshal(srv.Requests()[1].Body, &body)
+	if _, ok := body["description"]; ok || body["files"].(map[string]any)["notes.md"] == nil {
+		t.Errorf("update request = %v", body)
+	}
+
+	got, err := p.GetGist(context.Background(), "abc")
+	if err != nil || got.Files[1].Content != "Rolled back" || got.Files[1].Language != "Markdown" {
+		t.Errorf("GetGist() = %+v, %v", got, err)
+	}
+
+	// Dry runs write nothing
+	before := len(srv.Requests())
+	dry, err := p.UpdateGist(context.Background(), "abc", GistInput{Files: map[string]string{"a.txt": "x"}, Metadata: map[string]any{"dryRun": true}})
+	if err != nil || dry.ID != "abc" || len(dry.Files) != 1 || len(srv.Requests()) != before {
+		t.Errorf("dry-run UpdateGist() = %+v, %v", dry, err)
+	}
+
+	for name, call := range map[string]func() error{
+		"no files": func() error { _, err := p.CreateGist(context.Background(), GistInput{}); return err },
+		"slash": func() error {
+			_, err := p.CreateGist(context.Background(), GistInput{Files: map[string]string{"a/b": "x"}})
+			return err
+		},
+		"empty": func() error {
+			_, err := p.CreateGist(context.Background(), GistInput{Files: map[string]string{"a": ""}})
+			return err
+		},
+		"no update": func() error { _, err := p.UpdateGist(context.Background(), "abc", GistInput{}); return err },
+		"bad id":    func() error { _, err := p.GetGist(context.Background(), "../user"); return err },
+	} {
+		if err := call(); !hasCode(err, "bad_request") {
+			t.Errorf("%s: error = %v, want bad_request", name, err)
+		}
+	}
+}
+
+func TestErrorWrapping(t *testing.T) {
+	tests := []struct {
+		status int
+		code   string
+	}{
+		{http.StatusUnauthorized, "unauthorized"},
+		{http.StatusForbidden, "forbidden"},
+		{http.StatusNotFound, "not_found"},
+		{http.StatusUnprocessableEntity, "bad_request"},
+		{http.StatusInternalServerError, "provider_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			p, srv := newFakeProvider(t)
+			srv.Error(http.MethodGet, "/repos/acme/api/issues", tt.status, "boom")
+
+			_, err := p.Query(context.Background(), schema.TicketQuery{})
+			if !hasCode(err, tt.code) {
+				t.Errorf("Query() error = %v, want code %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func hasCode(err error, code string) bool {
+	var oe *orcherr.OpsOrchError
+	return errors.As(err, &oe) && oe.Code == code
+}
+
+// stubIssues serves Get from memory; other methods are unimplemented.
+type stubIssues struct {
+	ghapi.IssuesService
+	issues map[int]*github.Issue
+}
+
+func (s *stubIssues) Get(_ context.Context, _, _ string, number int) (*github.Issue, *github.Response, error) {
+	issue, ok := s.issues[number]
+	if !ok {
+		return nil, nil, errors.New("no such issue")
+	}
+	return issue, nil, nil
+}
+
+func TestNewWithServices(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+
+	if _, err := NewWithServices(map[string]any{"owner": "acme", "repo": "api"}, ghapi.Services{}); err == nil {
+		t.Error("expected error without an issues service")
+	}
+
+	issues := &stubIssues{issues: map[int]*github.Issue{
+		5: {Number: github.Int(5), Title: github.String("Injected"), State: github.String("open")},
+	}}
+	p, err := NewWithServices(map[string]any{"owner": "acme", "repo": "api"}, ghapi.Services{Issues: issues})
+	if err != nil {
+		t.Fatalf("NewWithServices() error = %v", err)
+	}
+
+	got, err := p.Get(context.Background(), "5")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Title != "Injected" || got.Status != "open" {
+		t.Errorf("unexpected ticket: %+v", got)
+	}
+
+	// Raw passthrough is unavailable without a Requester
+	p.config.RawAPIAllowlist = []string{"/repos/{owner}/{repo}/*"}
+	if _, err := p.Raw(context.Background(), "/repos/acme/api/pulls"); !hasCode(err, "forbidden") {
+		t.Errorf("Raw() error = %v, want forbidden", err)
+	}
+}
+
+func TestNewFixturesMode(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+
+	p, err := New(map[string]any{"mode": "fixtures", "repository": "opsorch/demo"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tickets, err := p.Query(context.Background(), schema.TicketQuery{Statuses: []string{"open"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(tickets) != 2 {
+		t.Errorf("got %d open tickets from the demo dataset, want 2", len(tickets))
+	}
+
+	if _, err := New(map[string]any{"mode": "replay", "repository": "opsorch/demo"}); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestRoutingRules(t *testing.T) {
+	var rules []any
+	if err := json.Unmarshal([]byte(`[
+		{"label": "sev*", "severity": "sev1"},
+		{"label": "team:payments", "team": "payments", "service": "checkout"},
+		{"title": "\\bcheckout\\b", "team": "storefront", "service": "web"}
+	]`), &rules); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewWithServices(map[string]any{"repository": "acme/api", "routingRules": rules}, ghapi.Services{Issues: &stubIssues{}})
+	if err != nil {
+		t.Fatalf("NewWithServices() error = %v", err)
+	}
+
+	issue := func(title string, labels ...string) *github.Issue {
+		i := &github.Issue{Number: github.Int(1), Title: github.String(title)}
+		for _, l := range labels {
+			i.Labels = append(i.Labels, &github.Label{Name: github.String(l)})
+		}
+		return i
+	}
+
+	tests := []struct {
+		name  string
+		issue *github.Issue
+		want  map[string]any
+	}{
+		{"label rules", issue("Checkout errors", "SEV1", "team:payments"), map[string]any{"severity": "sev1", "team": "payments", "service": "checkout"}},
+		{"title rule", issue("Checkout page is slow"), map[string]any{"team": "storefront", "service": "web"}},
+		{"no match", issue("Checkouts export", "chore"), map[string]any{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := p.ConvertIssue(tt.issue).Fields
+			for _, key := range []string{"team", "service", "severity"} {
+				if fields[key] != tt.want[key] {
+					t.Errorf("fields[%s] = %v, want %v", key, fields[key], tt.want[key])
+				}
+			}
+		})
+	}
+
+	for _, bad := range []string{
+		`[{"team": "payments"}]`,
+		`[{"label": "sev1"}]`,
+		`[{"label": "[", "team": "x"}]`,
+		`[{"title": "(", "team": "x"}]`,
+		`{"label": "sev1"}`,
+	} {
+		var cfg any
+		json.Unmarshal([]byte(bad), &cfg)
+		if _, err := NewWithServices(map[string]any{"repository": "acme/api", "routingRules": cfg}, ghapi.Services{Issues: &stubIssues{}}); err == nil {
+			t.Errorf("expected error for routingRules %s", bad)
+		}
+	}
+}
+
+// FuzzNew checks that arbitrary JSON config never crashes the constructor.
+func FuzzNew(f *testing.F) {
+	f.Add(`{"token":"t","owner":"o","repo":"r"}`)
+	f.Add(`{"token":"t","repository":"o/r","readOnly":"yes","cacheTTL":90,"rawAPIAllowlist":"/a,/b"}`)
+	f.Add(`{"mode":"fixtures","repository":"opsorch/demo","defaultState":7}`)
+
+	f.Fuzz(func(t *testing.T, data string) {
+		var cfg map[string]any
+		if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+			return
+		}
+		p, err := New(cfg)
+		if err != nil {
+			return
+		}
+		gp := p.(*Provider)
+		if gp.config.Owner == "" || gp.config.Repo == "" {
+			t.Errorf("New() accepted config without owner/repo: %s", data)
+		}
+	})
+}
+
+func TestBotPolicy(t *testing.T) {
+	p, _ := newFakeProvider(t)
+	p.config.BotPolicy = botpolicy.Policy{LoginSuffixes: botpolicy.DefaultLoginSuffixes, Deny: []string{"deploy-svc"}}
+	issue := &github.Issue{
+		Number: github.Int(7),
+		User:   &github.User{Login: github.String("github-actions[bot]"), Type: github.String("Bot")},
+		Assignees: []*github.User{
+			{Login: github.String("alice"), Type: github.String("User")},
+			{Login: github.String("renovate"), Type: github.String("Bot")},
+			{Login: github.String("deploy-svc"), Type: github.String("User")},
+		},
+	}
+
+	got := p.convertIssueToTicket(issue)
+	if !reflect.DeepEqual(got.Assignees, []string{"alice", "renovate", "deploy-svc"}) {
+		t.Errorf("tagged Assignees = %v", got.Assignees)
+	}
+	if !reflect.DeepEqual(got.Fields["bot_assignees"], []string{"renovate", "deploy-svc"}) || got.Fields["reporter_bot"] != true {
+		t.Errorf("bot fields = %v", got.Fields)
+	}
+
+	p.config.BotPolicy.Exclude = true
+	got = p.convertIssueToTicket(issue)
+	if !reflect.DeepEqual(got.Assignees, []string{"alice"}) || got.Reporter != "github-actions[bot]" {
+		t.Errorf("excluded Assignees = %v, Reporter = %s", got.Assignees, got.Reporter)
+	}
+}
+
+func TestIdentityFields(t *testing.T) {
+	p, _ := newFakeProvider(t)
+	identities, err := identity.Parse(map[string]any{"identities": map[string]any{
+		"alice": map[string]any{"email": "alice@acme.example", "chatHandle": "@alice"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.config.Identities = identities
+
+	got := p.convertIssueToTicket(&github.Issue{
+		Number:    github.Int(7),
+		User:      &github.User{Login: github.String("alice")},
+		Assignees: []*github.User{{Login: github.String("alice")}, {Login: github.String("bob")}},
+	})
+	want := identity.Identity{Email: "alice@acme.example", ChatHandle: "@alice"}
+	if got.Fields["reporter_identity"] != want {
+		t.Errorf("reporter_identity = %v", got.Fields["reporter_identity"])
+	}
+	if !reflect.DeepEqual(got.Fields["assignee_identities"], map[string]identity.Identity{"alice": want}) {
+		t.Errorf("assignee_identities = %v", got.Fields["assignee_identities"])
+	}
+}
+
+func TestGetByNodeID(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodPost, "/graphql", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"node": map[string]any{
+			"__typename": "Issue", "number": 1, "repository": map[string]any{"name": "api", "owner": map[string]any{"login": "acme"}},
+		}}})
+	})
+
+	got, err := p.Get(context.Background(), "I_kwDOABCD5M5abcde")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.ID != "1" {
+		t.Errorf("Get() ID = %s, want 1", got.ID)
+	}
+
+	got = p.convertIssueToTicket(&github.Issue{
+		Number:     github.Int(7),
+		NodeID:     github.String("I_kwDOABCD5M5abcde"),
+		Repository: &github.Repository{NodeID: github.String("R_kgDOABCD5M")},
+	})
+	if got.Fields["node_id"] != "I_kwDOABCD5M5abcde" || got.Fields["repository_node_id"] != "R_kgDOABCD5M" {
+		t.Errorf("node ID fields = %v", got.Fields)
+	}
+}
+
+func TestValidationErrorDetails(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodPost, "/repos/acme/api/issues", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-GitHub-Request-Id", "C0DE:1234:ABCD")
+		fakegithub.WriteJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"message": "Validation Failed",
+			"errors":  []any{map[string]any{"resource": "Issue", "field": "milestone", "code": "invalid"}},
+		})
+	})
+
+	_, err := p.Create(context.Background(), schema.CreateTicketInput{Title: "Outage"})
+	var oe *orcherr.OpsOrchError
+	if !errors.As(err, &oe) || oe.Code != "bad_request" {
+		t.Fatalf("Create() error = %v, want bad_request", err)
+	}
+	want := "GitHub API validation error: Validation Failed (Issue.milestone: invalid; request ID C0DE:1234:ABCD)"
+	if oe.Message != want {
+		t.Errorf("Message = %q, want %q", oe.Message, want)
+	}
+}
+
+func TestContextErrors(t *testing.T) {
+	p, _ := newFakeProvider(t)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	for code, ctx := range map[string]context.Context{"canceled": canceled, "timeout": expired} {
+		_, err := p.Get(ctx, "1")
+		var oe *orcherr.OpsOrchError
+		if !errors.As(err, &oe) || oe.Code != code {
+			t.Errorf("Get() error = %v, want code %s", err, code)
+		}
+	}
+}
+
+func TestCreateDecodesJSONLists(t *testing.T) {
+	tests := []struct {
+		name      string
+		assignees any
+		labels    any
+	}{
+		{"json lists", []any{"alice"}, []any{"sev2", "incident"}},
+		{"comma-separated", "alice", "sev2, incident"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, srv := newFakeProvider(t)
+			_, err := p.Create(context.Background(), schema.CreateTicketInput{
+				Title:    "Cache stampede",
+				Fields:   map[string]any{"assignees": tt.assignees},
+				Metadata: map[string]any{"labels": tt.labels},
+			})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			reqs := srv.Requests()
+			var body map[string]any
+			json.Unmarshal(reqs[len(reqs)-1].Body, &body)
+			if !reflect.DeepEqual(body["assignees"], []any{"alice"}) || !reflect.DeepEqual(body["labels"], []any{"sev2", "incident"}) {
+				t.Errorf("request body = %v", body)
+			}
+		})
+	}
+}
+
+func TestUpdateClearsFields(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodPatch, "/repos/acme/api/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"number": 1, "title": "Database latency spike", "state": "open"})
+	})
+
+	empty := ""
+	_, err := p.Update(context.Background(), "1", schema.UpdateTicketInput{
+		Description: &empty,
+		Assignees:   &[]string{},
+		Fields:      map[string]any{"milestone": nil},
+		Metadata:    map[string]any{"labels": nil},
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("made %d requests, want edit and milestone removal", len(reqs))
+	}
+	var body map[string]any
+	json.Unmarshal(reqs[0].Body, &body)
+	if body["body"] != "" || !reflect.DeepEqual(body["assignees"], []any{}) || !reflect.DeepEqual(body["labels"], []any{}) {
+		t.Errorf("edit request body = %v", body)
+	}
+	var removal map[string]any
+	json.Unmarshal(reqs[1].Body, &removal)
+	if v, ok := removal["milestone"]; !ok || v != nil {
+		t.Errorf("milestone removal body = %v, want milestone null", removal)
+	}
+}
+
+func TestUpdateMilestone(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api/milestones", func(w http.ResponseWriter, r *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"number": 7, "title": "Q3 reliability"}})
+	})
+	srv.Handle(http.MethodPatch, "/repos/acme/api/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"number": 1, "title": "Database latency spike", "state": "open"})
+	})
+
+	for _, milestone := range []any{3, "3", "q3 reliability"} {
+		if _, err := p.Update(context.Background(), "1", schema.UpdateTicketInput{Fields: map[string]any{"milestone": milestone}}); err != nil {
+			t.Fatalf("Update(milestone %v) error = %v", milestone, err)
+		}
+		reqs := srv.Requests()
+		var body map[string]any
+		json.Unmarshal(reqs[len(reqs)-1].Body, &body)
+		want := float64(3)
+		if milestone == "q3 reliability" {
+			want = 7
+		}
+		if body["milestone"] != want {
+			t.Errorf("milestone %v sent as %v, want %v", milestone, body["milestone"], want)
+		}
+	}
+
+	_, err := p.Update(context.Background(), "1", schema.UpdateTicketInput{Fields: map[string]any{"milestone": "no such milestone"}})
+	var oe *orcherr.OpsOrchError
+	if !errors.As(err, &oe) || oe.Code != "bad_request" {
+		t.Errorf("Update() error = %v, want bad_request", err)
+	}
+}
+
+func TestUpdateDecodesJSONLabels(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	for _, labels := range []any{[]any{"sev1"}, []any{}} {
+		if _, err := p.Update(context.Background(), "1", schema.UpdateTicketInput{Metadata: map[string]any{"labels": labels}}); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		reqs := srv.Requests()
+		var body map[string]any
+		json.Unmarshal(reqs[len(reqs)-1].Body, &body)
+		if !reflect.DeepEqual(body["labels"], labels) {
+			t.Errorf("labels in request = %v, want %v", body["labels"], labels)
+		}
+	}
+}
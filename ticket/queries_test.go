@@ -0,0 +1,59 @@
+package ticket
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+func TestSavedQuery(t *testing.T) {
+	srv := fakegithub.New(t)
+	p, err := NewWithServices(map[string]any{
+		"repository": "acme/api",
+		"queries": map[string]any{
+			"open-sev1": map[string]any{
+				"statuses": []any{"open"},
+				"scope":    map[string]any{"team": "alice"},
+				"limit":    float64(5),
+				"metadata": map[string]any{"labels": []any{"sev1", "incident"}},
+			},
+		},
+	}, ghapi.FromClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("NewWithServices() error = %v", err)
+	}
+
+	if _, err := p.Query(context.Background(), schema.TicketQuery{
+		Limit:    20,
+		Metadata: map[string]any{"savedQuery": "open-sev1"},
+	}); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	q := srv.Requests()[0].Query
+	want := map[string]string{"state": "open", "assignee": "alice", "labels": "sev1,incident"}
+	for k, v := range want {
+		if q.Get(k) != v {
+			t.Errorf("%s = %q, want %q", k, q.Get(k), v)
+		}
+	}
+	if limited, _ := p.applySavedQuery(schema.TicketQuery{Limit: 20, Metadata: map[string]any{"savedQuery": "open-sev1"}}); limited.Limit != 20 {
+		t.Errorf("limit = %d, want the query's 20", limited.Limit)
+	}
+
+	expanded, err := p.applySavedQuery(schema.TicketQuery{Metadata: map[string]any{"savedQuery": "open-sev1", "labels": []string{"sev2"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMetadata := map[string]any{"labels": []string{"sev2"}}
+	if !reflect.DeepEqual(expanded.Metadata, wantMetadata) || expanded.Limit != 5 {
+		t.Errorf("expanded = %+v", expanded)
+	}
+
+	if _, err := p.Query(context.Background(), schema.TicketQuery{Metadata: map[string]any{"savedQuery": "missing"}}); !hasCode(err, "bad_request") {
+		t.Errorf("Query() with unknown preset error = %v, want bad_request", err)
+	}
+}
@@ -0,0 +1,32 @@
+package ticket
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/throttle"
+)
+
+// RenderHTML is the "render" config value that adds GitHub's HTML rendering of
+// each issue body to ticket fields.
+const RenderHTML = "html"
+
+// renderHTML sets fields["description_html"] to body as GitHub renders it, with
+// issue references and mentions resolved against owner/repo, when render is
+// "html". The rendering takes the raw body, so descriptionFormat and
+// descriptionMaxLength do not apply to it. A failure is logged and leaves the
+// field out, since the Markdown description is still there, as does running out
+// of enrichment budget.
+func (p *Provider) renderHTML(ctx context.Context, body, owner, repo string, fields map[string]any) {
+	if p.config.Render != RenderHTML || body == "" || !budget.Enrich(ctx) {
+		return
+	}
+	rendered, _, err := p.api.Markdown.Render(throttle.WithPriority(ctx, throttle.Enrichment), body, &github.MarkdownOptions{Mode: "gfm", Context: owner + "/" + repo})
+	if err != nil {
+		log.Printf("[render] %s/%s: %v", owner, repo, err)
+		return
+	}
+	fields["description_html"] = rendered
+}
@@ -0,0 +1,66 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+func TestRenderHTML(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	p.config.Render = RenderHTML
+	p.config.DescriptionFormat = DescriptionPlain
+	srv.Handle(http.MethodPost, "/markdown", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<p>" + req["mode"] + " " + req["context"] + ": " + req["text"] + "</p>"))
+	})
+
+	got, err := p.Get(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	html, _ := got.Fields["description_html"].(string)
+	if html == "" || html[:len("<p>gfm acme/api: ")] != "<p>gfm acme/api: " {
+		t.Errorf("description_html = %q", html)
+	}
+
+	tickets, err := p.Query(context.Background(), schema.TicketQuery{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	for _, ticket := range tickets {
+		if _, ok := ticket.Fields["description_html"]; !ok && ticket.Description != "" {
+			t.Errorf("ticket %s has no description_html", ticket.ID)
+		}
+	}
+
+	// A rendering failure leaves the field out instead of failing the read
+	srv.Error(http.MethodPost, "/markdown", http.StatusInternalServerError, "boom")
+	got, err = p.Get(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, ok := got.Fields["description_html"]; ok {
+		t.Errorf("description_html = %v after a failed render", got.Fields["description_html"])
+	}
+}
+
+func TestRenderConfig(t *testing.T) {
+	if _, err := NewWithServices(map[string]any{"repository": "acme/api", "render": "pdf"}, ghapi.Services{Issues: &stubIssues{}}); err == nil {
+		t.Error("expected error for unknown render")
+	}
+	if _, err := NewWithServices(map[string]any{"repository": "acme/api", "render": "html"}, ghapi.Services{Issues: &stubIssues{}}); err == nil {
+		t.Error("expected error for render html without the Markdown API")
+	}
+	p, err := NewWithServices(map[string]any{"repository": "acme/api", "render": "HTML"}, ghapi.Services{Issues: &stubIssues{}, Markdown: fakegithub.New(t).Client().Markdown})
+	if err != nil || p.config.Render != RenderHTML {
+		t.Errorf("NewWithServices() = %+v, %v", p, err)
+	}
+}
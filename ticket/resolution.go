@@ -0,0 +1,191 @@
+package ticket
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+)
+
+// resolve adds resolution data to the fields of a closed ticket: the commit and
+// pull request that closed it, from the issue timeline, and the deployment
+// that shipped the closing commit. Lookups are best effort; a failure is logged
+// and leaves the fields unset.
+func (p *Provider) resolve(ctx context.Context, owner, repo string, number int, fields map[string]any) {
+	closed, closing, err := p.closingEvents(ctx, owner, repo, number)
+	if err != nil {
+		log.Printf("[resolution] %s/%s#%d: timeline: %v", owner, repo, number, err)
+		return
+	}
+	if closed == nil {
+		return
+	}
+
+	if closing != nil {
+		prOwner, prRepo := issueRepository(closing)
+		fields["closing_pull_request"] = p.ticketID(prOwner, prRepo, closing.GetNumber())
+		fields["closing_pull_request_url"] = closing.GetHTMLURL()
+	}
+
+	sha := closed.GetCommitID()
+	if sha == "" {
+		return
+	}
+	fields["closing_commit"] = sha
+
+	commitOwner, commitRepo := commitRepository(closed, owner, repo)
+	if id, url := p.deployedIn(ctx, commitOwner, commitRepo, sha); id != "" {
+		fields["deployed_in"] = id
+		fields["deployed_in_url"] = url
+	}
+}
+
+// closingEvents reads the issue timeline for the event that last closed the
+// issue and the pull request that closed it, either nil when there is none.
+func (p *Provider) closingEvents(ctx context.Context, owner, repo string, number int) (closed *github.Timeline, closing *github.Issue, err error) {
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		if !budget.Call(ctx) {
+			return nil, nil, budget.ErrExhausted
+		}
+		events, resp, err := p.api.Issues.ListIssueTimeline(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, e := range events {
+			switch e.GetEvent() {
+			case "closed":
+				closed = e
+			case "reopened":
+				closed, closing = nil, nil
+			case "cross-referenced":
+				// A pull request whose body closes this issue is the closing pull
+				// request; the latest one before the close wins
+				if source := e.GetSource().GetIssue(); source.IsPullRequest() && closesIssue(source, owner, repo, number) {
+					closing = source
+				}
+			}
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return closed, closing, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// commitRepository returns the repository of the commit a close event names,
+// which may differ from the issue's, e.g. for a fix in a library.
+func commitRepository(closed *github.Timeline, owner, repo string) (string, string) {
+	if _, path, ok := strings.Cut(closed.GetCommitURL(), "/repos/"); ok {
+		if parts := strings.SplitN(path, "/", 3); len(parts) == 3 {
+			return parts[0], parts[1]
+		}
+	}
+	return owner, repo
+}
+
+// deploymentIDPrefix marks the IDs of Deployments API records, as the
+// deployment provider's Get accepts them.
+const deploymentIDPrefix = "deployment-"
+
+// deployedIn returns the ID and URL of the first successful deployment of
+// commit sha. As in the deployment provider's DeploymentOf, the Deployments API
+// records that environment jobs and deployment workflows create come first; a
+// commit with no records falls back to the successful runs of deployment
+// workflows. It returns "" when there is none or the lookups fail.
+func (p *Provider) deployedIn(ctx context.Context, owner, repo, sha string) (id, url string) {
+	if p.api.Repositories != nil {
+		id, url, found := p.deploymentRecordOf(ctx, owner, repo, sha)
+		if found {
+			return id, url
+		}
+	}
+	if p.api.Actions == nil {
+		return "", ""
+	}
+	runs, _, err := p.api.Actions.ListRepositoryWorkflowRuns(ctx, owner, repo, &github.ListWorkflowRunsOptions{
+		HeadSHA:     sha,
+		Status:      "success",
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		log.Printf("[resolution] %s/%s@%s: workflow runs: %v", owner, repo, sha, err)
+		return "", ""
+	}
+
+	var first *github.WorkflowRun
+	for _, run := range runs.WorkflowRuns {
+		if run.GetConclusion() != "success" || !isDeploymentRun(run) {
+			continue
+		}
+		if first == nil || run.GetUpdatedAt().Before(first.GetUpdatedAt().Time) {
+			first = run
+		}
+	}
+	if first == nil {
+		return "", ""
+	}
+	return strconv.FormatInt(first.GetID(), 10), first.GetHTMLURL()
+}
+
+// deploymentRecordOf returns the Deployments API record of commit sha whose
+// latest status turned successful first. found is false when the commit has no
+// records at all or they cannot be listed, so workflow runs are read instead.
+func (p *Provider) deploymentRecordOf(ctx context.Context, owner, repo, sha string) (id, url string, found bool) {
+	records, _, err := p.api.Repositories.ListDeployments(ctx, owner, repo, &github.DeploymentsListOptions{
+		SHA:         sha,
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		log.Printf("[resolution] %s/%s@%s: deployments: %v", owner, repo, sha, err)
+		return "", "", false
+	}
+
+	var first *github.DeploymentStatus
+	for _, record := range records {
+		statuses, _, err := p.api.Repositories.ListDeploymentStatuses(ctx, owner, repo, record.GetID(), &github.ListOptions{PerPage: 1})
+		if err != nil {
+			log.Printf("[resolution] %s/%s@%s: deployment %d statuses: %v", owner, repo, sha, record.GetID(), err)
+			continue
+		}
+		// GitHub lists statuses newest first
+		if len(statuses) == 0 || statuses[0].GetState() != "success" {
+			continue
+		}
+		if status := statuses[0]; first == nil || status.GetUpdatedAt().Before(first.GetUpdatedAt().Time) {
+			first = status
+			id = deploymentIDPrefix + strconv.FormatInt(record.GetID(), 10)
+		}
+	}
+	if first != nil {
+		url = first.GetLogURL()
+		if url == "" {
+			url = first.GetTargetURL()
+		}
+	}
+	return id, url, len(records) > 0
+}
+
+// isDeploymentRun reports whether run deploys rather than only builds or tests:
+// it was started by a deployment event, or its workflow is named for deploying.
+func isDeploymentRun(run *github.WorkflowRun) bool {
+	if run.GetEvent() == "deployment" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(run.GetName()), "deploy")
+}
+
+// closesIssue reports whether the body of issue closes owner/repo#number with a
+// closing keyword.
+func closesIssue(issue *github.Issue, owner, repo string, number int) bool {
+	sourceOwner, sourceRepo := issueRepository(issue)
+	for _, ref := range parseReferences(issue.GetBody(), sourceOwner, sourceRepo) {
+		if ref.Closes && ref.Number == number && strings.EqualFold(ref.Owner, owner) && strings.EqualFold(ref.Repo, repo) {
+			return true
+		}
+	}
+	return false
+}
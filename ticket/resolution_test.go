@@ -0,0 +1,175 @@
+package ticket
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+func TestGetClosedResolution(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api/issues/9", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+			"number": 9, "title": "Checkout down", "state": "closed",
+			"created_at": "2030-01-01T10:00:00Z",
+			"closed_at":  "2030-01-01T11:30:00Z",
+			"closed_by":  map[string]any{"login": "alice"},
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/issues/9/timeline", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"event": "cross-referenced", "source": map[string]any{"issue": map[string]any{
+				"number": 10, "body": "Related to #9", "repository_url": "https://api.github.com/repos/acme/api",
+				"pull_request": map[string]any{"url": "https://api.github.com/repos/acme/api/pulls/10"},
+			}}},
+			{"event": "cross-referenced", "source": map[string]any{"issue": map[string]any{
+				"number": 11, "body": "Fixes acme/api#9", "html_url": "https://github.com/acme/api/pull/11",
+				"repository_url": "https://api.github.com/repos/acme/api",
+				"pull_request":   map[string]any{"url": "https://api.github.com/repos/acme/api/pulls/11"},
+			}}},
+			{"event": "closed", "commit_id": "abc123", "commit_url": "https://api.github.com/repos/acme/api/commits/abc123"},
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("head_sha") != "abc123" {
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 0, "workflow_runs": []any{}})
+			return
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 2, "workflow_runs": []map[string]any{
+			{"id": 500, "name": "CI", "event": "push", "conclusion": "success", "updated_at": "2030-01-01T11:40:00Z"},
+			{"id": 501, "name": "Deploy", "event": "push", "conclusion": "failure", "updated_at": "2030-01-01T11:45:00Z"},
+			{"id": 502, "name": "Deploy production", "event": "push", "conclusion": "success", "updated_at": "2030-01-01T11:50:00Z",
+				"html_url": "https://github.com/acme/api/actions/runs/502"},
+		}})
+	})
+
+	got, err := p.Get(context.Background(), "9")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	want := map[string]any{
+		"closed_at":                time.Date(2030, 1, 1, 11, 30, 0, 0, time.UTC),
+		"close_duration_seconds":   int64(5400),
+		"closed_by":                "alice",
+		"closing_pull_request":     "11",
+		"closing_pull_request_url": "https://github.com/acme/api/pull/11",
+		"closing_commit":           "abc123",
+		"deployed_in":              "502",
+		"deployed_in_url":          "https://github.com/acme/api/actions/runs/502",
+	}
+	for k, v := range want {
+		if got.Fields[k] != v {
+			t.Errorf("fields[%s] = %v, want %v", k, got.Fields[k], v)
+		}
+	}
+
+	// Open tickets skip the timeline
+	srv.Handle(http.MethodGet, "/repos/acme/api/issues/1/timeline", func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("timeline requested for an open issue")
+	})
+	if _, err := p.Get(context.Background(), "1"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+}
+
+func TestGetClosedResolutionDeployments(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api/issues/9", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+			"number": 9, "state": "closed", "created_at": "2030-01-01T10:00:00Z", "closed_at": "2030-01-01T11:30:00Z",
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/issues/9/timeline", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"event": "closed", "commit_id": "abc123"}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/deployments", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sha") != "abc123" {
+			t.Errorf("deployments sha = %q", r.URL.Query().Get("sha"))
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"id": 702}, {"id": 701}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/deployments/702/statuses", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"state": "success", "updated_at": "2030-01-01T12:30:00Z", "log_url": "https://github.com/acme/api/actions/runs/802/job/1"},
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/deployments/701/statuses", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"state": "success", "updated_at": "2030-01-01T12:00:00Z", "log_url": "https://github.com/acme/api/actions/runs/801/job/1"},
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/actions/runs", func(w http.ResponseWriter, _ *http.Request) {
+		t.Error("workflow runs listed for a commit with deployment records")
+	})
+
+	got, err := p.Get(context.Background(), "9")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Fields["deployed_in"] != "deployment-701" || got.Fields["deployed_in_url"] != "https://github.com/acme/api/actions/runs/801/job/1" {
+		t.Errorf("deployed_in = %v, %v", got.Fields["deployed_in"], got.Fields["deployed_in_url"])
+	}
+}
+
+func TestGetClosedResolutionBestEffort(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/repos/acme/api/issues/9", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+			"number": 9, "state": "closed", "created_at": "2030-01-01T10:00:00Z", "closed_at": "2030-01-01T10:00:30Z",
+		})
+	})
+	srv.Error(http.MethodGet, "/repos/acme/api/issues/9/timeline", http.StatusForbidden, "Resource not accessible by integration")
+
+	got, err := p.Get(context.Background(), "9")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Fields["close_duration_seconds"] != int64(30) || got.Fields["closing_commit"] != nil {
+		t.Errorf("fields = %v", got.Fields)
+	}
+}
+
+func TestResolved(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/search/issues", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 2, "items": []map[string]any{
+			{"number": 9, "html_url": "https://github.com/acme/api/issues/9", "repository_url": "https://api.github.com/repos/acme/api",
+				"created_at": "2030-01-01T10:00:00Z", "closed_at": "2030-01-01T11:30:00Z"},
+			{"number": 12, "repository_url": "https://api.github.com/repos/acme/api", "created_at": "2030-01-02T10:00:00Z", "closed_at": "2030-01-02T12:00:00Z"},
+		}})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/issues/9/timeline", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"event": "cross-referenced", "source": map[string]any{"issue": map[string]any{
+				"number": 11, "body": "Fixes #9", "repository_url": "https://api.github.com/repos/acme/api",
+				"pull_request": map[string]any{"url": "https://api.github.com/repos/acme/api/pulls/11"},
+			}}},
+			{"event": "closed", "commit_id": "abc123", "commit_url": "https://api.github.com/repos/acme/lib/commits/abc123"},
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/api/issues/12/timeline", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"event": "closed"}})
+	})
+
+	since := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	result, err := p.Resolved(context.Background(), ResolvedInput{Since: since, Until: since.AddDate(0, 0, 7)})
+	if err != nil {
+		t.Fatalf("Resolved() error = %v", err)
+	}
+	if len(result.Issues) != 2 {
+		t.Fatalf("issues = %+v", result.Issues)
+	}
+	if r := result.Issues[0]; r.Ticket != "9" || r.PullRequest != "11" || r.Commit != "abc123" || r.CommitRepository != "acme/lib" || !r.OpenedAt.Equal(since.Add(10*time.Hour)) {
+		t.Errorf("issue 9 = %+v", r)
+	}
+	// Closed by hand
+	if r := result.Issues[1]; r.Ticket != "12" || r.Commit != "" || r.PullRequest != "" {
+		t.Errorf("issue 12 = %+v", r)
+	}
+	if q := srv.Requests()[0].Query.Get("q"); q != `is:issue repo:acme/api closed:2030-01-01T00:00:00Z..2030-01-08T00:00:00Z label:"incident"` {
+		t.Errorf("q = %q", q)
+	}
+}
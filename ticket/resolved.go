@@ -0,0 +1,116 @@
+package ticket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+)
+
+// defaultIncidentLabels select the issues Resolved reports on when no labels
+// are given.
+var defaultIncidentLabels = []string{"incident"}
+
+// ResolvedInput selects the closed issues Resolved reports on.
+type ResolvedInput struct {
+	Since  time.Time `json:"since,omitempty"`  // Start of the window issues were closed in; defaults to 30 days before Until
+	Until  time.Time `json:"until,omitempty"`  // End of the window; defaults to now
+	Labels []string  `json:"labels,omitempty"` // Labels an issue must all have; defaults to "incident"
+}
+
+// Resolution is a closed issue and the commit that closed it. Commit is empty
+// when the issue was closed by hand rather than by a commit or merged pull
+// request.
+type Resolution struct {
+	Ticket           string    `json:"ticket"`
+	URL              string    `json:"url"`
+	OpenedAt         time.Time `json:"openedAt"`
+	ClosedAt         time.Time `json:"closedAt"`
+	PullRequest      string    `json:"pullRequest,omitempty"`
+	Commit           string    `json:"commit,omitempty"`
+	CommitRepository string    `json:"commitRepository,omitempty"` // owner/repo the commit lives in
+}
+
+// ResolvedIssues are the issues closed in a window, oldest first. Sampled
+// is set when there were more than the Search API returns; Truncated when the
+// API call budget ran out, leaving later issues without their commit.
+type ResolvedIssues struct {
+	Since     time.Time    `json:"since"`
+	Until     time.Time    `json:"until"`
+	Issues    []Resolution `json:"issues"`
+	Sampled   bool         `json:"sampled,omitempty"`
+	Truncated bool         `json:"truncated,omitempty"`
+}
+
+// Resolved lists the labeled issues closed in a window with the pull request
+// and commit that closed each, read from its timeline.
+func (p *Provider) Resolved(ctx context.Context, input ResolvedInput) (ResolvedIssues, error) {
+	if p.api.Search == nil {
+		return ResolvedIssues{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "resolved issues are not available for this provider",
+		}
+	}
+	since, until, err := statsWindow(input.Since, input.Until)
+	if err != nil {
+		return ResolvedIssues{}, err
+	}
+	labels := input.Labels
+	if len(labels) == 0 {
+		labels = defaultIncidentLabels
+	}
+
+	ctx = budget.Start(ctx, p.config.Budget)
+	result := ResolvedIssues{Since: since, Until: until, Issues: []Resolution{}}
+	scope := p.statsScope()
+	qualifiers := []string{"closed:" + since.Format(time.RFC3339) + ".." + until.Format(time.RFC3339)}
+	for _, label := range labels {
+		qualifiers = append(qualifiers, fmt.Sprintf("label:%q", label))
+	}
+
+	issues, _, sampled, err := p.listIssues(ctx, scope, strings.Join(qualifiers, " "))
+	if err != nil {
+		if errors.Is(err, budget.ErrExhausted) {
+			result.Truncated = true
+			return result, nil
+		}
+		return ResolvedIssues{}, err
+	}
+	result.Sampled = sampled
+
+	for _, issue := range issues {
+		owner, repo := issueRepository(issue)
+		if owner == "" {
+			owner, repo = p.config.Owner, p.config.Repo
+		}
+		r := Resolution{
+			Ticket:   p.ticketID(owner, repo, issue.GetNumber()),
+			URL:      issue.GetHTMLURL(),
+			OpenedAt: issue.GetCreatedAt().UTC(),
+			ClosedAt: issue.GetClosedAt().UTC(),
+		}
+		closed, closing, err := p.closingEvents(ctx, owner, repo, issue.GetNumber())
+		if errors.Is(err, budget.ErrExhausted) {
+			result.Issues = append(result.Issues, r)
+			continue
+		}
+		if err != nil {
+			return ResolvedIssues{}, p.wrapError(err)
+		}
+		if closing != nil {
+			prOwner, prRepo := issueRepository(closing)
+			r.PullRequest = p.ticketID(prOwner, prRepo, closing.GetNumber())
+		}
+		if closed != nil && closed.GetCommitID() != "" {
+			commitOwner, commitRepo := commitRepository(closed, owner, repo)
+			r.Commit, r.CommitRepository = closed.GetCommitID(), commitOwner+"/"+commitRepo
+		}
+		result.Issues = append(result.Issues, r)
+	}
+	result.Truncated = budget.Truncated(ctx)
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package ticket
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// RoutingRule assigns a team, service, and/or severity to issues whose labels or
+// title match. A rule with both patterns requires both to match.
+type RoutingRule struct {
+	Label    string `json:"label,omitempty"`    // Glob matched against each label, case-insensitive
+	Title    string `json:"title,omitempty"`    // Regular expression matched against the title, case-insensitive
+	Team     string `json:"team,omitempty"`     // Sets fields.team
+	Service  string `json:"service,omitempty"`  // Sets fields.service
+	Severity string `json:"severity,omitempty"` // Sets fields.severity
+
+	title *regexp.Regexp
+}
+
+// parseRoutingRules reads and validates the "routingRules" config list.
+func parseRoutingRules(cfg map[string]any) ([]RoutingRule, error) {
+	raw, ok := cfg["routingRules"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	// Round-trip through JSON so decoded config ([]any of maps) and typed rules
+	// passed in-process are handled the same way
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("routingRules: %w", err)
+	}
+	var rules []RoutingRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("routingRules must be a list of rules: %w", err)
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Label == "" && rule.Title == "" {
+			return nil, fmt.Errorf("routingRules[%d]: label or title pattern is required", i)
+		}
+		if rule.Team == "" && rule.Service == "" && rule.Severity == "" {
+			return nil, fmt.Errorf("routingRules[%d]: team, service, or severity is required", i)
+		}
+		if rule.Label != "" {
+			rule.Label = strings.ToLower(rule.Label)
+			if _, err := path.Match(rule.Label, ""); err != nil {
+				return nil, fmt.Errorf("routingRules[%d]: invalid label pattern %q", i, rule.Label)
+			}
+		}
+		if rule.Title != "" {
+			re, err := regexp.Compile("(?i)" + rule.Title)
+			if err != nil {
+				return nil, fmt.Errorf("routingRules[%d]: invalid title pattern: %w", i, err)
+			}
+			rule.title = re
+		}
+	}
+	return rules, nil
+}
+
+// matches reports whether the rule applies to an issue with title and labels.
+func (r RoutingRule) matches(title string, labels []string) bool {
+	if r.title != nil && !r.title.MatchString(title) {
+		return false
+	}
+	if r.Label == "" {
+		return true
+	}
+	for _, label := range labels {
+		if ok, _ := path.Match(r.Label, strings.ToLower(label)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// route applies the routing rules to a ticket's fields. Rules are evaluated in
+// order and each field is taken from the first matching rule that sets it.
+func (p *Provider) route(fields map[string]any, title string, labels []string) {
+	for _, rule := range p.config.RoutingRules {
+		if !rule.matches(title, labels) {
+			continue
+		}
+		for field, value := range map[string]string{"team": rule.Team, "service": rule.Service, "severity": rule.Severity} {
+			if _, set := fields[field]; value != "" && !set {
+				fields[field] = value
+			}
+		}
+	}
+}
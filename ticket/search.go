@@ -0,0 +1,260 @@
+package ticket
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/fieldset"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/nodeid"
+	"github.com/opsorch/opsorch-github-adapter/internal/override"
+	"github.com/opsorch/opsorch-github-adapter/internal/paging"
+)
+
+// Values accepted for the "queryScope" config key.
+const (
+	QueryScopeRepo = "repo"
+	QueryScopeOrg  = "org"
+)
+
+// queryOrganization returns the organization a query searches, or "" for a query
+// of the configured repository. Metadata "org" selects an organization for one
+// query, which must be the configured one or match the organizationAllowlist;
+// otherwise queryScope "org" searches the configured organization.
+func (p *Provider) queryOrganization(query schema.TicketQuery) (string, error) {
+	if org := ghconfig.String(query.Metadata, "org"); org != "" {
+		return org, p.config.OrganizationAllowlist.Check(org, p.config.Organization)
+	}
+	if p.config.QueryScope == QueryScopeOrg {
+		return p.config.Organization, nil
+	}
+	return "", nil
+}
+
+// searchOrganization finds issues in every repository of org with the Search API,
+// in the given order.
+// Tickets outside the configured repository have owner/repo#N IDs and all carry
+// the repository in Fields["repository"].
+func (p *Provider) searchOrganization(ctx context.Context, org string, query schema.TicketQuery, order ordering, want fieldset.Set) (Page, error) {
+	if p.api.Search == nil {
+		return Page{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "organization queries are not available for this provider",
+		}
+	}
+
+	terms := []string{"org:" + org, "is:issue"}
+
+	// Apply status filter; GitHub Issues only support "open" or "closed"
+	state := ""
+	for _, status := range query.Statuses {
+		switch strings.ToLower(status) {
+		case "open", "new", "in_progress":
+			state = "open"
+		case "closed", "resolved", "done":
+			state = "closed"
+		}
+	}
+	if state != "" {
+		terms = append(terms, "is:"+state)
+	}
+
+	// Apply assignee filter from scope
+	if query.Scope.Team != "" {
+		terms = append(terms, "assignee:"+query.Scope.Team)
+	}
+
+	// Apply labels from metadata; each label term must match
+	for _, label := range ghconfig.StringSlice(query.Metadata, "labels") {
+		terms = append(terms, fmt.Sprintf("label:%q", label))
+	}
+
+	// A service scope naming a configured service narrows to its repository
+	serviceOwner, serviceRepo, isService := p.serviceRepository(query)
+	if isService {
+		terms = append(terms, "repo:"+serviceOwner+"/"+serviceRepo)
+	}
+
+	// Free text is passed through, so it may carry further search qualifiers
+	if text := strings.TrimSpace(query.Query); text != "" {
+		terms = append(terms, text)
+	}
+
+	opts := &github.SearchOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100, // GitHub's max per page
+		},
+	}
+	if query.Limit > 0 && query.Limit < 100 {
+		opts.PerPage = query.Limit
+	}
+	opts.Sort = order.Sort
+	opts.Order = order.Direction
+
+	// With a limit, read pages until it is filled
+	var issues []*github.Issue
+	var total *int
+	token := paging.FromMetadata(query.Metadata)
+	search := func() ([]*github.Issue, *github.Response, error) {
+		if !budget.Call(ctx) {
+			return nil, nil, budget.ErrExhausted
+		}
+		result, resp, err := p.api.Search.Issues(ctx, strings.Join(terms, " "), opts)
+		if err != nil {
+			return nil, nil, p.wrapError(err)
+		}
+		if total == nil && result.Total != nil {
+			issues = slices.Grow(issues, paging.Capacity(query.Limit, opts.PerPage, result.Total))
+		}
+		total = result.Total
+		return result.Issues, resp, nil
+	}
+	next, err := paging.Walk(token, query.Limit, &opts.ListOptions, search, func(issue *github.Issue) (bool, error) {
+		if owner, _ := issueRepository(issue); owner == "" {
+			return false, nil
+		}
+		issues = append(issues, issue)
+		return true, nil
+	})
+	if err != nil {
+		return Page{}, err
+	}
+	// Search ranks results from many repositories; sort them so ties are stable
+	order.sortIssues(issues)
+
+	tickets := make([]schema.Ticket, 0, len(issues))
+	for _, issue := range issues {
+		owner, repo := issueRepository(issue)
+		ticket := p.convertIssueIn(issue, owner, repo, want)
+		if want.Has("description_html") {
+			p.renderHTML(ctx, issue.GetBody(), owner, repo, ticket.Fields)
+		}
+		tickets = append(tickets, ticket)
+	}
+	if isService && want.Has("service") {
+		setService(tickets, query.Scope.Service)
+	}
+	// Search reports the total across all pages
+	if total == nil {
+		total = paging.Total(token, next, len(tickets))
+	}
+	return Page{
+		Tickets:       tickets,
+		NextPageToken: next,
+		HasMore:       next != "",
+		TotalCount:    total,
+	}, nil
+}
+
+// target resolves a ticket ID to the provider of the issue's repository and
+// the issue's number. IDs are numbers in the configured repository, owner/repo#N,
+// or node IDs, which are resolved to the repository the issue lives in now, as
+// it may have been renamed or transferred since the ID was recorded.
+// Repositories the provider may not reach are forbidden.
+func (p *Provider) target(ctx context.Context, id string) (*Provider, int, error) {
+	if nodeid.Is(id) {
+		node, err := nodeid.Resolve(ctx, p.api.GraphQL, id, p.wrapError)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := nodeid.Expect(id, node, nodeid.TypeIssue); err != nil {
+			return nil, 0, err
+		}
+		id = fmt.Sprintf("%s/%s#%d", node.Owner, node.Repo, node.Number)
+	}
+
+	if owner, repo, number, ok := splitTicketID(id); ok {
+		if err := p.reach(owner, repo); err != nil {
+			return nil, 0, err
+		}
+		if strings.Contains(p.ticketID(owner, repo, number), "#") {
+			return p.in(owner, repo), number, nil
+		}
+		return p, number, nil
+	}
+
+	number, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, 0, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("invalid issue number: %s", id),
+		}
+	}
+	return p, number, nil
+}
+
+// qualify gives a ticket returned by target its ID in this provider: owner/repo#N,
+// with the repository in Fields["repository"], outside the configured repository.
+func (p *Provider) qualify(target *Provider, ticket schema.Ticket) schema.Ticket {
+	if target == p {
+		return ticket
+	}
+	if number, err := strconv.Atoi(ticket.ID); err == nil {
+		ticket.ID = p.ticketID(target.config.Owner, target.config.Repo, number)
+	}
+	if ticket.Fields == nil {
+		ticket.Fields = map[string]any{}
+	}
+	ticket.Fields["repository"] = target.config.Owner + "/" + target.config.Repo
+	return ticket
+}
+
+// getIn returns an issue from a repository other than the configured one.
+func (p *Provider) getIn(ctx context.Context, owner, repo string, number int) (schema.Ticket, error) {
+	issue, _, err := p.api.Issues.Get(ctx, owner, repo, number)
+	if err != nil {
+		return schema.Ticket{}, p.wrapError(err)
+	}
+	ticket := p.convertIssueIn(issue, owner, repo, nil)
+	p.renderHTML(ctx, issue.GetBody(), owner, repo, ticket.Fields)
+	p.in(owner, repo).addDeployments(ctx, issue, ticket.Fields)
+	if ticket.Status == "closed" {
+		p.resolve(ctx, owner, repo, number, ticket.Fields)
+	}
+	return ticket, nil
+}
+
+// reach returns forbidden unless owner/repo is the configured repository, one
+// the repositoryAllowlist permits, or one of an organization queries may search.
+func (p *Provider) reach(owner, repo string) error {
+	if strings.EqualFold(owner, p.config.Owner) && strings.EqualFold(repo, p.config.Repo) {
+		return nil
+	}
+	return override.Reach(p.config.RepositoryAllowlist, p.config.OrganizationAllowlist, p.config.Organization, owner, repo)
+}
+
+// convertIssueIn converts an issue from the given repository, qualifying its ID
+// when the repository is not the configured one. want selects Fields entries as
+// for convertIssue.
+func (p *Provider) convertIssueIn(issue *github.Issue, owner, repo string, want fieldset.Set) schema.Ticket {
+	ticket := p.convertIssue(issue, want)
+	ticket.ID = p.ticketID(owner, repo, issue.GetNumber())
+	if want.Has("repository") {
+		ticket.Fields["repository"] = owner + "/" + repo
+	}
+	return ticket
+}
+
+// splitTicketID parses an owner/repo#N ticket ID.
+func splitTicketID(id string) (owner, repo string, number int, ok bool) {
+	fullName, n, found := strings.Cut(id, "#")
+	if !found {
+		return "", "", 0, false
+	}
+	owner, repo, found = strings.Cut(fullName, "/")
+	if !found || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", 0, false
+	}
+	number, err := strconv.Atoi(n)
+	if err != nil || number <= 0 {
+		return "", "", 0, false
+	}
+	return owner, repo, number, true
+}
@@ -0,0 +1,117 @@
+package ticket
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+	"github.com/opsorch/opsorch-github-adapter/internal/override"
+)
+
+func TestQueryOrganization(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	p.config.QueryScope = QueryScopeOrg
+	p.config.Organization = "acme"
+	srv.Handle(http.MethodGet, "/search/issues", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+			"total_count": 2,
+			"items": []map[string]any{
+				{"number": 1, "title": "Database latency spike", "state": "open", "repository_url": "https://api.github.com/repos/acme/api"},
+				{"number": 3, "title": "Checkout errors", "state": "open", "repository_url": "https://api.github.com/repos/acme/web", "labels": []map[string]any{{"name": "sev1"}}},
+			},
+		})
+	})
+	srv.Handle(http.MethodGet, "/repos/acme/web/issues/3", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"number": 3, "title": "Checkout errors", "state": "open"})
+	})
+
+	tickets, err := p.Query(context.Background(), schema.TicketQuery{
+		Query:    "checkout",
+		Statuses: []string{"open"},
+		Scope:    schema.QueryScope{Team: "alice"},
+		Limit:    10,
+		Metadata: map[string]any{"labels": []string{"sev1", "needs triage"}},
+	})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(tickets) != 2 || tickets[0].ID != "1" || tickets[1].ID != "acme/web#3" || tickets[1].Fields["repository"] != "acme/web" {
+		t.Fatalf("tickets = %+v", tickets)
+	}
+
+	q := srv.Requests()[0].Query
+	if want := `org:acme is:issue is:open assignee:alice label:"sev1" label:"needs triage" checkout`; q.Get("q") != want {
+		t.Errorf("q = %q, want %q", q.Get("q"), want)
+	}
+	if q.Get("per_page") != "10" || q.Get("sort") != "created" || q.Get("order") != "desc" {
+		t.Errorf("per_page = %q, sort = %q, order = %q", q.Get("per_page"), q.Get("sort"), q.Get("order"))
+	}
+
+	// Search reports the total even when a limit cuts the results short
+	page, err := p.QueryPage(context.Background(), schema.TicketQuery{Limit: 1})
+	if err != nil || page.TotalCount == nil || *page.TotalCount != 2 || !page.HasMore {
+		t.Errorf("limited QueryPage() = %+v, %v", page, err)
+	}
+
+	got, err := p.Get(context.Background(), "acme/web#3")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.ID != "acme/web#3" || got.Title != "Checkout errors" {
+		t.Errorf("Get() = %+v", got)
+	}
+	if got, err := p.Get(context.Background(), "acme/api#1"); err != nil || got.ID != "1" {
+		t.Errorf("Get() of the configured repository = %+v, %v", got, err)
+	}
+	// Repositories outside the organization and the allowlists are not read
+	if _, err := p.Get(context.Background(), "globex/app#3"); !hasCode(err, "forbidden") {
+		t.Errorf("Get() outside the allowlists error = %v, want forbidden", err)
+	}
+	p.config.RepositoryAllowlist = override.Allowlist{"globex/app"}
+	srv.Handle(http.MethodGet, "/repos/globex/app/issues/3", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"number": 3, "title": "Partner outage", "state": "open"})
+	})
+	if got, err := p.Get(context.Background(), "globex/app#3"); err != nil || got.ID != "globex/app#3" {
+		t.Errorf("Get() of an allowlisted repository = %+v, %v", got, err)
+	}
+}
+
+func TestQueryOrganizationFromMetadata(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/search/issues", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 0, "items": []any{}})
+	})
+
+	// Organizations other than the configured one must be allowlisted
+	if _, err := p.Query(context.Background(), schema.TicketQuery{Metadata: map[string]any{"org": "globex"}}); !hasCode(err, "forbidden") {
+		t.Fatalf("Query() outside the organizationAllowlist error = %v, want forbidden", err)
+	}
+	if len(srv.Requests()) != 0 {
+		t.Fatalf("forbidden query made %d requests", len(srv.Requests()))
+	}
+	p.config.OrganizationAllowlist = override.Organizations{"globex"}
+	if _, err := p.Query(context.Background(), schema.TicketQuery{Metadata: map[string]any{"org": "globex"}}); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if q := srv.Requests()[0].Query.Get("q"); q != "org:globex is:issue" {
+		t.Errorf("q = %q", q)
+	}
+
+	noSearch, err := NewWithServices(map[string]any{"repository": "acme/api", "queryScope": "org"}, ghapi.Services{Issues: &stubIssues{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if noSearch.config.Organization != "acme" {
+		t.Errorf("Organization = %q, want owner", noSearch.config.Organization)
+	}
+	if _, err := noSearch.Query(context.Background(), schema.TicketQuery{}); !hasCode(err, "bad_request") {
+		t.Errorf("Query() without a search service error = %v, want bad_request", err)
+	}
+
+	if _, err := NewWithServices(map[string]any{"repository": "acme/api", "queryScope": "enterprise"}, ghapi.Services{Issues: &stubIssues{}}); err == nil {
+		t.Error("expected error for unknown queryScope")
+	}
+}
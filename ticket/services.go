@@ -0,0 +1,69 @@
+package ticket
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+// parseServices reads the "services" config map, which names the repository
+// holding each service's issues as owner/repo or, for a repository of owner,
+// just the name. Keys are matched case-insensitively, so they are lower-cased.
+func parseServices(cfg map[string]any, owner string) (map[string]string, error) {
+	raw, ok := cfg["services"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	// Round-trip through JSON so decoded config and typed maps passed in-process
+	// are handled the same way
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("services: %w", err)
+	}
+	var aliases map[string]string
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("services must map service names to repositories: %w", err)
+	}
+
+	services := make(map[string]string, len(aliases))
+	for name, repository := range aliases {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return nil, fmt.Errorf("services: service name is required")
+		}
+		repository = strings.TrimSpace(repository)
+		if !strings.Contains(repository, "/") {
+			repository = owner + "/" + repository
+		}
+		repoOwner, repo, _ := strings.Cut(repository, "/")
+		if repoOwner == "" || repo == "" || strings.Contains(repo, "/") {
+			return nil, fmt.Errorf("services[%s]: invalid repository %q (expected owner/repo)", name, repository)
+		}
+		services[name] = repository
+	}
+	return services, nil
+}
+
+// serviceRepository returns the owner and name of the repository configured for
+// the query's service scope, or ok false when the scope names no configured
+// service.
+func (p *Provider) serviceRepository(query schema.TicketQuery) (owner, repo string, ok bool) {
+	repository, found := p.config.Services[strings.ToLower(strings.TrimSpace(query.Scope.Service))]
+	if !found {
+		return "", "", false
+	}
+	owner, repo, _ = strings.Cut(repository, "/")
+	return owner, repo, true
+}
+
+// setService records the query's service on tickets that routing left without one.
+func setService(tickets []schema.Ticket, service string) {
+	for _, t := range tickets {
+		if _, set := t.Fields["service"]; !set {
+			t.Fields["service"] = service
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package ticket
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+func TestParseServices(t *testing.T) {
+	p, err := NewWithServices(map[string]any{
+		"repository": "acme/api",
+		"services":   map[string]any{"Checkout": "web", "billing": "globex/invoices"},
+	}, ghapi.Services{Issues: &stubIssues{}})
+	if err != nil {
+		t.Fatalf("NewWithServices() error = %v", err)
+	}
+	if p.config.Services["checkout"] != "acme/web" || p.config.Services["billing"] != "globex/invoices" {
+		t.Errorf("Services = %v", p.config.Services)
+	}
+
+	for _, services := range []any{
+		map[string]any{"checkout": "acme/web/issues"},
+		map[string]any{"checkout": ""},
+		map[string]any{" ": "web"},
+		[]any{"web"},
+	} {
+		if _, err := NewWithServices(map[string]any{"repository": "acme/api", "services": services}, ghapi.Services{Issues: &stubIssues{}}); err == nil {
+			t.Errorf("expected error for services %v", services)
+		}
+	}
+}
+
+func TestQueryServiceScope(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	p.config.Services = map[string]string{"checkout": "acme/web"}
+	srv.Handle(http.MethodGet, "/repos/acme/web/issues", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{{"number": 3, "title": "Checkout errors", "state": "open"}})
+	})
+
+	tickets, err := p.Query(context.Background(), schema.TicketQuery{Scope: schema.QueryScope{Service: "Checkout"}})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(tickets) != 1 || tickets[0].ID != "acme/web#3" || tickets[0].Fields["repository"] != "acme/web" || tickets[0].Fields["service"] != "Checkout" {
+		t.Fatalf("tickets = %+v", tickets)
+	}
+
+	// Services without a configured repository keep querying the configured one
+	if _, err := p.Query(context.Background(), schema.TicketQuery{Scope: schema.QueryScope{Service: "search"}}); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	reqs := srv.Requests()
+	if reqs[0].Path != "/repos/acme/web/issues" || reqs[len(reqs)-1].Path != "/repos/acme/api/issues" {
+		t.Errorf("requested %s then %s", reqs[0].Path, reqs[len(reqs)-1].Path)
+	}
+}
+
+func TestQueryOrganizationServiceScope(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	p.config.QueryScope = QueryScopeOrg
+	p.config.Organization = "acme"
+	p.config.Services = map[string]string{"checkout": "acme/web"}
+	srv.Handle(http.MethodGet, "/search/issues", func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 0, "items": []any{}})
+	})
+
+	if _, err := p.Query(context.Background(), schema.TicketQuery{Scope: schema.QueryScope{Service: "checkout"}}); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if q := srv.Requests()[0].Query.Get("q"); q != "org:acme is:issue repo:acme/web" {
+		t.Errorf("q = %q", q)
+	}
+}
@@ -0,0 +1,91 @@
+package ticket
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// Values accepted for the "sort" query metadata key.
+const (
+	SortCreated  = "created"
+	SortUpdated  = "updated"
+	SortComments = "comments"
+)
+
+// ordering is the sort requested by query metadata "sort" and "direction". The
+// zero value is GitHub's default, newest created first.
+type ordering struct {
+	Sort      string
+	Direction string
+}
+
+// parseOrdering reads the "sort" and "direction" query metadata keys.
+func parseOrdering(metadata map[string]any) (ordering, error) {
+	o := ordering{
+		Sort:      strings.ToLower(ghconfig.String(metadata, "sort")),
+		Direction: strings.ToLower(ghconfig.String(metadata, "direction")),
+	}
+	switch o.Sort {
+	case "":
+		o.Sort = SortCreated
+	case SortCreated, SortUpdated, SortComments:
+	default:
+		return o, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("unknown sort %q (expected %s, %s, or %s)", o.Sort, SortCreated, SortUpdated, SortComments),
+		}
+	}
+	switch o.Direction {
+	case "":
+		o.Direction = "desc"
+	case "asc", "desc":
+	default:
+		return o, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: fmt.Sprintf("unknown direction %q (expected asc or desc)", o.Direction),
+		}
+	}
+	return o, nil
+}
+
+// sortIssues orders issues client-side, for results merged from several
+// repositories. Ties are broken by repository and then issue number.
+func (o ordering) sortIssues(issues []*github.Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		var c int
+		switch o.Sort {
+		case SortUpdated:
+			c = a.GetUpdatedAt().Compare(b.GetUpdatedAt().Time)
+		case SortComments:
+			c = compareInts(a.GetComments(), b.GetComments())
+		default:
+			c = a.GetCreatedAt().Compare(b.GetCreatedAt().Time)
+		}
+		if c == 0 {
+			if c = strings.Compare(a.GetRepositoryURL(), b.GetRepositoryURL()); c != 0 {
+				return c < 0
+			}
+			c = compareInts(a.GetNumber(), b.GetNumber())
+		}
+		if o.Direction == "asc" {
+			return c < 0
+		}
+		return c > 0
+	})
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
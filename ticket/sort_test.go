@@ -0,0 +1,70 @@
+package ticket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/schema"
+)
+
+func TestSortIssues(t *testing.T) {
+	at := func(hour int) *github.Timestamp {
+		return &github.Timestamp{Time: time.Date(2030, 1, 1, hour, 0, 0, 0, time.UTC)}
+	}
+	issue := func(repo string, number, comments, updated int) *github.Issue {
+		return &github.Issue{
+			Number:        github.Int(number),
+			Comments:      github.Int(comments),
+			UpdatedAt:     at(updated),
+			RepositoryURL: github.String("https://api.github.com/repos/acme/" + repo),
+		}
+	}
+	issues := []*github.Issue{issue("web", 3, 0, 9), issue("api", 7, 5, 11), issue("api", 2, 1, 9), issue("lib", 1, 5, 10)}
+
+	order := func(o ordering) []int {
+		o.sortIssues(issues)
+		numbers := make([]int, len(issues))
+		for i, issue := range issues {
+			numbers[i] = issue.GetNumber()
+		}
+		return numbers
+	}
+
+	tests := []struct {
+		ordering ordering
+		want     []int
+	}{
+		{ordering{Sort: SortUpdated, Direction: "desc"}, []int{7, 1, 2, 3}},
+		{ordering{Sort: SortUpdated, Direction: "asc"}, []int{2, 3, 1, 7}},
+		{ordering{Sort: SortComments, Direction: "desc"}, []int{7, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		got := order(tt.ordering)
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%+v: order = %v, want %v", tt.ordering, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestQuerySort(t *testing.T) {
+	p, srv := newFakeProvider(t)
+
+	if _, err := p.Query(context.Background(), schema.TicketQuery{Metadata: map[string]any{"sort": "Updated", "direction": "asc"}}); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	q := srv.Requests()[0].Query
+	if q.Get("sort") != "updated" || q.Get("direction") != "asc" {
+		t.Errorf("sort = %q, direction = %q", q.Get("sort"), q.Get("direction"))
+	}
+
+	for _, metadata := range []map[string]any{{"sort": "reactions"}, {"direction": "up"}} {
+		if _, err := p.Query(context.Background(), schema.TicketQuery{Metadata: metadata}); !hasCode(err, "bad_request") {
+			t.Errorf("Query(%v) error = %v, want bad_request", metadata, err)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+)
+
+// defaultStaleLimit caps the issues one sweep acts on when the policy sets no
+// limit.
+const defaultStaleLimit = 50
+
+// Actions a stale sweep applies to an issue, in the order they are applied.
+const (
+	StaleActionComment = "comment"
+	StaleActionLabel   = "label"
+	StaleActionClose   = "close"
+)
+
+// StalePolicy says which open issues are stale and what a sweep does to them.
+type StalePolicy struct {
+	Days         int      `json:"days"`                   // Issues not updated for this many days are stale
+	Labels       []string `json:"labels,omitempty"`       // Only issues with all of these labels
+	ExemptLabels []string `json:"exemptLabels,omitempty"` // Issues with any of these labels are never stale
+	Comment      string   `json:"comment,omitempty"`      // Comment posted on stale issues
+	Label        string   `json:"label,omitempty"`        // Label added to stale issues
+	Close        bool     `json:"close,omitempty"`        // Close stale issues as not planned
+	Limit        int      `json:"limit,omitempty"`        // Most issues one sweep acts on (default 50)
+}
+
+// SweepInput is a stale sweep request. Policy replaces the configured
+// "stalePolicy" for this sweep.
+type SweepInput struct {
+	Policy   *StalePolicy   `json:"policy,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// SweepResult lists the stale issues a sweep found and what it did to each.
+type SweepResult struct {
+	Policy    StalePolicy  `json:"policy"`
+	Cutoff    time.Time    `json:"cutoff"`
+	Issues    []SweptIssue `json:"issues"`
+	DryRun    bool         `json:"dryRun,omitempty"`
+	Truncated bool         `json:"truncated,omitempty"` // More issues are stale than the policy's limit
+}
+
+// SweptIssue is one stale issue and the actions applied to it. Error is set
+// when an action failed; the actions before it were applied.
+type SweptIssue struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Actions   []string  `json:"actions"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// parseStalePolicy reads and validates the "stalePolicy" config object.
+func parseStalePolicy(cfg map[string]any) (*StalePolicy, error) {
+	raw, ok := cfg["stalePolicy"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	// Round-trip through JSON so decoded config and a typed policy passed
+	// in-process are handled the same way
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("stalePolicy: %w", err)
+	}
+	var policy StalePolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("stalePolicy must be an object: %w", err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("stalePolicy: %w", err)
+	}
+	return &policy, nil
+}
+
+// validate checks that the policy selects issues and acts on them.
+func (s StalePolicy) validate() error {
+	if s.Days <= 0 {
+		return fmt.Errorf("days must be positive")
+	}
+	if strings.TrimSpace(s.Comment) == "" && s.Label == "" && !s.Close {
+		return fmt.Errorf("comment, label, or close is required")
+	}
+	if s.Limit < 0 {
+		return fmt.Errorf("limit must not be negative")
+	}
+	return nil
+}
+
+// SweepStale applies the stale policy to the open issues of the repository that
+// have not been updated for the policy's days, oldest first. Pull requests are
+// left alone, as are issues that already carry the policy's label when it does
+// not close them, so repeated sweeps do not comment twice. Commenting and
+// labelling update an issue, which makes a two-phase flow possible: one policy
+// marks issues, and a second with more days and the marker label closes them.
+// A failed action is recorded on its issue and the sweep moves on. Under
+// readOnly or metadata dryRun the actions are logged and returned as planned.
+func (p *Provider) SweepStale(ctx context.Context, input SweepInput) (SweepResult, error) {
+	policy := p.config.StalePolicy
+	if input.Policy != nil {
+		if err := input.Policy.validate(); err != nil {
+			return SweepResult{}, &orcherr.OpsOrchError{Code: "bad_request", Message: "invalid stale policy: " + err.Error()}
+		}
+		policy = input.Policy
+	}
+	if policy == nil {
+		return SweepResult{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "no stale policy: set stalePolicy in the config or policy in the request",
+		}
+	}
+	limit := policy.Limit
+	if limit == 0 {
+		limit = defaultStaleLimit
+	}
+
+	result := SweepResult{
+		Policy: *policy,
+		Cutoff: time.Now().UTC().AddDate(0, 0, -policy.Days),
+		Issues: []SweptIssue{},
+		DryRun: p.isDryRun(input.Metadata),
+	}
+	stale, truncated, err := p.staleIssues(ctx, *policy, result.Cutoff, limit)
+	if err != nil {
+		return SweepResult{}, err
+	}
+	result.Truncated = truncated
+
+	for _, issue := range stale {
+		swept := SweptIssue{
+			ID:        strconv.Itoa(issue.GetNumber()),
+			Title:     issue.GetTitle(),
+			URL:       issue.GetHTMLURL(),
+			UpdatedAt: issue.GetUpdatedAt().Time,
+			Actions:   []string{},
+		}
+		for _, action := range policy.actions() {
+			if err := p.applyStaleAction(ctx, issue.GetNumber(), action, *policy, result.DryRun); err != nil {
+				swept.Error = err.Error()
+				break
+			}
+			swept.Actions = append(swept.Actions, action)
+		}
+		result.Issues = append(result.Issues, swept)
+	}
+	return result, nil
+}
+
+// staleIssues lists the open issues last updated before cutoff that the policy
+// acts on, up to limit, and reports whether more were left.
+func (p *Provider) staleIssues(ctx context.Context, policy StalePolicy, cutoff time.Time, limit int) ([]*github.Issue, bool, error) {
+	opts := &github.IssueListByRepoOptions{
+		State:       "open",
+		Labels:      policy.Labels,
+		Sort:        "updated",
+		Direction:   "asc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var stale []*github.Issue
+	for {
+		issues, resp, err := p.api.Issues.ListByRepo(ctx, p.config.Owner, p.config.Repo, opts)
+		if err != nil {
+			return nil, false, p.wrapError(err)
+		}
+		for _, issue := range issues {
+			// Issues come least recently updated first, so the first fresh one ends
+			// the sweep
+			if !issue.GetUpdatedAt().Time.Before(cutoff) {
+				return stale, false, nil
+			}
+			if issue.IsPullRequest() || !policy.appliesTo(issue) {
+				continue
+			}
+			if len(stale) == limit {
+				return stale, true, nil
+			}
+			stale = append(stale, issue)
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return stale, false, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// appliesTo reports whether a stale issue is neither exempt nor already marked
+// by a policy that does not close it.
+func (s StalePolicy) appliesTo(issue *github.Issue) bool {
+	for _, label := range issue.Labels {
+		name := label.GetName()
+		if slices.ContainsFunc(s.ExemptLabels, func(exempt string) bool { return strings.EqualFold(exempt, name) }) {
+			return false
+		}
+		if !s.Close && s.Label != "" && strings.EqualFold(s.Label, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// actions returns the actions the policy applies, in order.
+func (s StalePolicy) actions() []string {
+	var actions []string
+	if strings.TrimSpace(s.Comment) != "" {
+		actions = append(actions, StaleActionComment)
+	}
+	if s.Label != "" {
+		actions = append(actions, StaleActionLabel)
+	}
+	if s.Close {
+		actions = append(actions, StaleActionClose)
+	}
+	return actions
+}
+
+// applyStaleAction applies one action of the policy to an issue.
+func (p *Provider) applyStaleAction(ctx context.Context, number int, action string, policy StalePolicy, dryRun bool) error {
+	id := strconv.Itoa(number)
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d", p.config.Owner, p.config.Repo, number)
+
+	var err error
+	switch action {
+	case StaleActionComment:
+		if dryRun {
+			log.Printf("[dry-run] POST %s/comments (stale)", path)
+			return nil
+		}
+		var comment *github.IssueComment
+		comment, _, err = p.api.Issues.CreateComment(ctx, p.config.Owner, p.config.Repo, number, &github.IssueComment{Body: github.String(policy.Comment)})
+		p.audit("ticket.comment", id, map[string]any{"body": policy.Comment}, comment.GetHTMLURL(), err)
+	case StaleActionLabel:
+		if dryRun {
+			log.Printf("[dry-run] POST %s/labels [%s] (stale)", path, policy.Label)
+			return nil
+		}
+		_, _, err = p.api.Issues.AddLabelsToIssue(ctx, p.config.Owner, p.config.Repo, number, []string{policy.Label})
+		p.audit("ticket.label", id, map[string]any{"labels": []string{policy.Label}}, p.issueURL(number), err)
+	case StaleActionClose:
+		if dryRun {
+			log.Printf("[dry-run] PATCH %s state=closed (stale)", path)
+			return nil
+		}
+		req := &github.IssueRequest{State: github.String("closed"), StateReason: github.String("not_planned")}
+		_, _, err = p.api.Issues.Edit(ctx, p.config.Owner, p.config.Repo, number, req)
+		p.audit("ticket.update", id, req, p.issueURL(number), err)
+	}
+	if err != nil {
+		return p.wrapError(err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package ticket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+// handleStaleIssues serves open issues least recently updated first: issues
+// 1 and 2 untouched for 60 days, 3 a pull request, 4 exempt as pinned, and 5
+// updated yesterday.
+func handleStaleIssues(t *testing.T, srv *fakegithub.Server) {
+	old := time.Now().AddDate(0, 0, -60).UTC().Format(time.RFC3339)
+	srv.Handle(http.MethodGet, "/repos/"+fakegithub.Owner+"/"+fakegithub.Repo+"/issues", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("sort") != "updated" || q.Get("direction") != "asc" || q.Get("state") != "open" {
+			t.Errorf("query = %v", q)
+		}
+		fakegithub.WriteJSON(w, http.StatusOK, []map[string]any{
+			{"number": 1, "title": "old", "updated_at": old},
+			{"number": 2, "title": "marked", "updated_at": old, "labels": []map[string]any{{"name": "Stale"}}},
+			{"number": 3, "title": "pr", "updated_at": old, "pull_request": map[string]any{"url": "x"}},
+			{"number": 4, "title": "pinned", "updated_at": old, "labels": []map[string]any{{"name": "pinned"}}},
+			{"number": 5, "title": "fresh", "updated_at": time.Now().AddDate(0, 0, -1).UTC().Format(time.RFC3339)},
+		})
+	})
+	for _, n := range []string{"1", "2"} {
+		base := "/repos/" + fakegithub.Owner + "/" + fakegithub.Repo + "/issues/" + n
+		srv.Handle(http.MethodPost, base+"/comments", func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusCreated, map[string]any{"id": 1})
+		})
+		srv.Handle(http.MethodPost, base+"/labels", func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusOK, []any{})
+		})
+		srv.Handle(http.MethodPatch, base, func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"number": 1})
+		})
+	}
+}
+
+func TestSweepStale(t *testing.T) {
+	ctx := context.Background()
+	mark := &StalePolicy{Days: 30, ExemptLabels: []string{"Pinned"}, Comment: "Closing soon.", Label: "stale"}
+
+	t.Run("marks", func(t *testing.T) {
+		p, srv := newFakeProvider(t)
+		handleStaleIssues(t, srv)
+		result, err := p.SweepStale(ctx, SweepInput{Policy: mark})
+		if err != nil {
+			t.Fatalf("SweepStale() error = %v", err)
+		}
+		// The issue already marked stale is skipped
+		if len(result.Issues) != 1 || result.Issues[0].ID != "1" || !reflect.DeepEqual(result.Issues[0].Actions, []string{"comment", "label"}) || result.Truncated {
+			t.Fatalf("result = %+v", result)
+		}
+		var paths []string
+		for _, r := range srv.Requests() {
+			if r.Method != http.MethodGet {
+				paths = append(paths, r.Method+" "+r.Path)
+			}
+		}
+		want := []string{"POST /repos/acme/api/issues/1/comments", "POST /repos/acme/api/issues/1/labels"}
+		if !reflect.DeepEqual(paths, want) {
+			t.Errorf("writes = %v, want %v", paths, want)
+		}
+	})
+
+	t.Run("closes", func(t *testing.T) {
+		p, srv := newFakeProvider(t)
+		p.config.StalePolicy = &StalePolicy{Days: 30, Labels: []string{"stale"}, ExemptLabels: []string{"pinned"}, Close: true, Limit: 1}
+		handleStaleIssues(t, srv)
+		result, err := p.SweepStale(ctx, SweepInput{})
+		if err != nil {
+			t.Fatalf("SweepStale() error = %v", err)
+		}
+		if len(result.Issues) != 1 || result.Issues[0].ID != "1" || !result.Truncated {
+			t.Fatalf("result = %+v", result)
+		}
+		for _, r := range srv.Requests() {
+			if r.Method == http.MethodGet && r.Query.Get("labels") != "stale" {
+				t.Errorf("labels = %q", r.Query.Get("labels"))
+			}
+			if r.Method == http.MethodPatch {
+				var body map[string]any
+				json.Unmarshal(r.Body, &body)
+				if body["state"] != "closed" || body["state_reason"] != "not_planned" {
+					t.Errorf("close = %v", body)
+				}
+			}
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		p, srv := newFakeProvider(t)
+		handleStaleIssues(t, srv)
+		result, err := p.SweepStale(ctx, SweepInput{Policy: mark, Metadata: map[string]any{"dryRun": true}})
+		if err != nil || !result.DryRun || len(result.Issues) != 1 || len(result.Issues[0].Actions) != 2 {
+			t.Fatalf("SweepStale() = %+v, %v", result, err)
+		}
+		for _, r := range srv.Requests() {
+			if r.Method != http.MethodGet {
+				t.Errorf("dry run wrote %s %s", r.Method, r.Path)
+			}
+		}
+	})
+
+	t.Run("action error", func(t *testing.T) {
+		p, srv := newFakeProvider(t)
+		handleStaleIssues(t, srv)
+		srv.Error(http.MethodPost, "/repos/acme/api/issues/1/labels", http.StatusForbidden, "Resource not accessible by integration")
+		result, err := p.SweepStale(ctx, SweepInput{Policy: mark})
+		if err != nil {
+			t.Fatalf("SweepStale() error = %v", err)
+		}
+		if issue := result.Issues[0]; !reflect.DeepEqual(issue.Actions, []string{"comment"}) || issue.Error == "" {
+			t.Errorf("issue = %+v", issue)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		p, _ := newFakeProvider(t)
+		for _, input := range []SweepInput{
+			{},
+			{Policy: &StalePolicy{Comment: "x"}},
+			{Policy: &StalePolicy{Days: 30}},
+		} {
+			if _, err := p.SweepStale(ctx, input); !hasCode(err, "bad_request") {
+				t.Errorf("SweepStale(%+v) error = %v, want bad_request", input, err)
+			}
+		}
+	})
+}
+
+func TestParseStalePolicy(t *testing.T) {
+	policy, err := parseStalePolicy(map[string]any{"stalePolicy": map[string]any{"days": 90, "label": "stale"}})
+	if err != nil || policy.Days != 90 || policy.Label != "stale" {
+		t.Errorf("parseStalePolicy() = %+v, %v", policy, err)
+	}
+	if policy, err := parseStalePolicy(map[string]any{}); policy != nil || err != nil {
+		t.Errorf("parseStalePolicy() without policy = %+v, %v", policy, err)
+	}
+	for _, raw := range []any{"90d", map[string]any{"days": 90}, map[string]any{"days": 0, "close": true}} {
+		if _, err := parseStalePolicy(map[string]any{"stalePolicy": raw}); err == nil {
+			t.Errorf("parseStalePolicy(%v) succeeded", raw)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package ticket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/budget"
+	"github.com/opsorch/opsorch-github-adapter/internal/paging"
+)
+
+// defaultStatsWindow is the window Stats covers when Since is unset.
+const defaultStatsWindow = 30 * 24 * time.Hour
+
+// StatsInput selects the time window Stats reports on.
+type StatsInput struct {
+	Since  time.Time `json:"since,omitempty"`  // Start of the window; defaults to 30 days before Until
+	Until  time.Time `json:"until,omitempty"`  // End of the window; defaults to now
+	Labels []string  `json:"labels,omitempty"` // Labels whose open issues are counted in OpenByLabel
+}
+
+// DayCount is the number of issues for one UTC day, as YYYY-MM-DD.
+type DayCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// Stats summarizes the issues of the repository, or of the organization under
+// queryScope "org", over a window. Open counts are as of now. Per-day counts
+// and the median time to close come from listing the window's issues; Sampled
+// is set when there were more than the Search API returns, leaving them partial.
+type Stats struct {
+	Since                    time.Time      `json:"since"`
+	Until                    time.Time      `json:"until"`
+	Open                     int            `json:"open"`
+	OpenByLabel              map[string]int `json:"openByLabel,omitempty"`
+	Created                  int            `json:"created"`
+	Closed                   int            `json:"closed"`
+	CreatedPerDay            []DayCount     `json:"createdPerDay"`
+	ClosedPerDay             []DayCount     `json:"closedPerDay"`
+	MedianTimeToCloseSeconds *int64         `json:"medianTimeToCloseSeconds,omitempty"` // Of issues closed in the window; nil when none were
+	Sampled                  bool           `json:"sampled,omitempty"`
+	Truncated                bool           `json:"truncated,omitempty"` // The API call budget ran out, leaving some figures unset
+}
+
+// Stats computes issue counts and durations over a window without returning the
+// issues themselves. Totals are Search API counts, one call each; per-day counts
+// and the median time to close read the window's created and closed issues.
+func (p *Provider) Stats(ctx context.Context, input StatsInput) (Stats, error) {
+	if p.api.Search == nil {
+		return Stats{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "ticket stats are not available for this provider",
+		}
+	}
+	since, until, err := statsWindow(input.Since, input.Until)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	ctx = budget.Start(ctx, p.config.Budget)
+	stats := Stats{Since: since, Until: until}
+	scope := p.statsScope()
+	window := since.Format(time.RFC3339) + ".." + until.Format(time.RFC3339)
+
+	if stats.Open, err = p.countIssues(ctx, scope, "is:open"); err != nil {
+		return p.statsResult(ctx, stats, err)
+	}
+	for _, label := range input.Labels {
+		if stats.OpenByLabel == nil {
+			stats.OpenByLabel = make(map[string]int, len(input.Labels))
+		}
+		if stats.OpenByLabel[label], err = p.countIssues(ctx, scope, "is:open", fmt.Sprintf("label:%q", label)); err != nil {
+			return p.statsResult(ctx, stats, err)
+		}
+	}
+
+	created, total, sampled, err := p.listIssues(ctx, scope, "created:"+window)
+	if err != nil {
+		return p.statsResult(ctx, stats, err)
+	}
+	stats.Created, stats.Sampled = total, sampled
+	stats.CreatedPerDay = perDay(since, until, created, (*github.Issue).GetCreatedAt)
+
+	closed, total, sampled, err := p.listIssues(ctx, scope, "closed:"+window)
+	if err != nil {
+		return p.statsResult(ctx, stats, err)
+	}
+	stats.Closed, stats.Sampled = total, stats.Sampled || sampled
+	stats.ClosedPerDay = perDay(since, until, closed, (*github.Issue).GetClosedAt)
+	stats.MedianTimeToCloseSeconds = medianTimeToClose(closed)
+
+	return p.statsResult(ctx, stats, nil)
+}
+
+// statsWindow applies the defaults to a window's bounds, in UTC: until is now
+// and since 30 days before it.
+func statsWindow(since, until time.Time) (time.Time, time.Time, error) {
+	if until.IsZero() {
+		until = time.Now()
+	}
+	if since.IsZero() {
+		since = until.Add(-defaultStatsWindow)
+	}
+	if !since.Before(until) {
+		return time.Time{}, time.Time{}, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "since must be before until",
+		}
+	}
+	return since.UTC(), until.UTC(), nil
+}
+
+// statsScope is the search qualifier for the issues stats cover: the
+// repository, or the organization under queryScope "org".
+func (p *Provider) statsScope() string {
+	if p.config.QueryScope == QueryScopeOrg {
+		return "org:" + p.config.Organization
+	}
+	return "repo:" + p.config.Owner + "/" + p.config.Repo
+}
+
+// statsResult returns the figures gathered so far when the budget ran out, and
+// err otherwise.
+func (p *Provider) statsResult(ctx context.Context, stats Stats, err error) (Stats, error) {
+	if errors.Is(err, budget.ErrExhausted) {
+		err = nil
+	}
+	stats.Truncated = budget.Truncated(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	return stats, nil
+}
+
+// countIssues returns how many issues match the qualifiers, reading only the
+// search total.
+func (p *Provider) countIssues(ctx context.Context, qualifiers ...string) (int, error) {
+	if !budget.Call(ctx) {
+		return 0, budget.ErrExhausted
+	}
+	terms := append([]string{"is:issue"}, qualifiers...)
+	result, _, err := p.api.Search.Issues(ctx, strings.Join(terms, " "), &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 1}})
+	if err != nil {
+		return 0, p.wrapError(err)
+	}
+	return result.GetTotal(), nil
+}
+
+// listIssues returns the issues matching scope and qualifier, up to
+// paging.MaxResults, with the search total and whether it was more than were
+// listed.
+func (p *Provider) listIssues(ctx context.Context, scope, qualifier string) ([]*github.Issue, int, bool, error) {
+	query := strings.Join([]string{"is:issue", scope, qualifier}, " ")
+	opts := &github.SearchOptions{Sort: "created", Order: "asc", ListOptions: github.ListOptions{PerPage: 100}}
+	var issues []*github.Issue
+	total := 0
+	for page := 0; page < paging.MaxPages; page++ {
+		if !budget.Call(ctx) {
+			return nil, 0, false, budget.ErrExhausted
+		}
+		result, resp, err := p.api.Search.Issues(ctx, query, opts)
+		if err != nil {
+			return nil, 0, false, p.wrapError(err)
+		}
+		issues = append(issues, result.Issues...)
+		total = result.GetTotal()
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return issues, total, len(issues) < total, nil
+}
+
+// perDay counts issues by the UTC day of the time at, with an entry for every
+// day of the window.
+func perDay(since, until time.Time, issues []*github.Issue, at func(*github.Issue) github.Timestamp) []DayCount {
+	counts := map[string]int{}
+	for _, issue := range issues {
+		counts[at(issue).UTC().Format(time.DateOnly)]++
+	}
+	var days []DayCount
+	for day := since.Truncate(24 * time.Hour); day.Before(until); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		days = append(days, DayCount{Date: date, Count: counts[date]})
+	}
+	return days
+}
+
+// medianTimeToClose returns the median seconds from creation to close of the
+// closed issues, or nil when there are none.
+func medianTimeToClose(issues []*github.Issue) *int64 {
+	durations := make([]int64, 0, len(issues))
+	for _, issue := range issues {
+		if issue.ClosedAt == nil || issue.CreatedAt == nil {
+			continue
+		}
+		durations = append(durations, int64(issue.GetClosedAt().Sub(issue.GetCreatedAt().Time).Seconds()))
+	}
+	if len(durations) == 0 {
+		return nil
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	median := durations[len(durations)/2]
+	if len(durations)%2 == 0 {
+		median = (durations[len(durations)/2-1] + median) / 2
+	}
+	return &median
+}
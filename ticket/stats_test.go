@@ -0,0 +1,83 @@
+package ticket
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+func TestStats(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	srv.Handle(http.MethodGet, "/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		switch {
+		case strings.Contains(q, `label:"sev1"`):
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 2, "items": []any{}})
+		case strings.Contains(q, "is:open"):
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{"total_count": 7, "items": []any{}})
+		case strings.Contains(q, "created:"):
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+				"total_count": 3,
+				"items": []map[string]any{
+					{"number": 1, "created_at": "2024-03-01T08:00:00Z"},
+					{"number": 2, "created_at": "2024-03-01T17:00:00Z"},
+					{"number": 3, "created_at": "2024-03-03T09:00:00Z"},
+				},
+			})
+		case strings.Contains(q, "closed:"):
+			fakegithub.WriteJSON(w, http.StatusOK, map[string]any{
+				"total_count": 2,
+				"items": []map[string]any{
+					{"number": 1, "created_at": "2024-03-01T08:00:00Z", "closed_at": "2024-03-01T09:00:00Z"},
+					{"number": 4, "created_at": "2024-02-20T00:00:00Z", "closed_at": "2024-03-02T00:00:00Z"},
+				},
+			})
+		default:
+			t.Errorf("unexpected q = %q", q)
+		}
+	})
+
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	stats, err := p.Stats(context.Background(), StatsInput{Since: since, Until: since.AddDate(0, 0, 3), Labels: []string{"sev1"}})
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if stats.Open != 7 || stats.OpenByLabel["sev1"] != 2 || stats.Created != 3 || stats.Closed != 2 || stats.Sampled {
+		t.Errorf("stats = %+v", stats)
+	}
+	if len(stats.CreatedPerDay) != 3 || stats.CreatedPerDay[0] != (DayCount{Date: "2024-03-01", Count: 2}) || stats.CreatedPerDay[1].Count != 0 || stats.CreatedPerDay[2].Count != 1 {
+		t.Errorf("CreatedPerDay = %+v", stats.CreatedPerDay)
+	}
+	if len(stats.ClosedPerDay) != 3 || stats.ClosedPerDay[0].Count != 1 || stats.ClosedPerDay[1].Count != 1 {
+		t.Errorf("ClosedPerDay = %+v", stats.ClosedPerDay)
+	}
+	// Median of 1h and 11d
+	if want := int64((time.Hour + 11*24*time.Hour).Seconds()) / 2; stats.MedianTimeToCloseSeconds == nil || *stats.MedianTimeToCloseSeconds != want {
+		t.Errorf("MedianTimeToCloseSeconds = %v, want %d", stats.MedianTimeToCloseSeconds, want)
+	}
+
+	requests := srv.Requests()
+	if q := requests[0].Query; q.Get("q") != "is:issue repo:acme/api is:open" || q.Get("per_page") != "1" {
+		t.Errorf("open count query = %v", q)
+	}
+	if q := requests[2].Query.Get("q"); q != "is:issue repo:acme/api created:2024-03-01T00:00:00Z..2024-03-04T00:00:00Z" {
+		t.Errorf("created query = %q", q)
+	}
+
+	if _, err := p.Stats(context.Background(), StatsInput{Since: since, Until: since}); !hasCode(err, "bad_request") {
+		t.Errorf("Stats() with empty window error = %v, want bad_request", err)
+	}
+
+	noSearch, err := NewWithServices(map[string]any{"repository": "acme/api"}, ghapi.Services{Issues: &stubIssues{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := noSearch.Stats(context.Background(), StatsInput{}); !hasCode(err, "bad_request") {
+		t.Errorf("Stats() without a search service error = %v, want bad_request", err)
+	}
+}
@@ -0,0 +1,358 @@
+package ticket
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/orcherr"
+	"github.com/opsorch/opsorch-github-adapter/internal/miniyaml"
+)
+
+// templateDir is where GitHub looks for issue templates and issue forms.
+const templateDir = ".github/ISSUE_TEMPLATE"
+
+// noResponse is what GitHub renders for an issue form field left empty.
+const noResponse = "_No response_"
+
+// placeholderPattern matches {{ name }} placeholders in Markdown templates.
+var placeholderPattern = regexp.MustCompile(`{{\s*([\w.-]+)\s*}}`)
+
+// issueTemplate is a repository issue template (Markdown with front matter) or an
+// issue form (YAML).
+type issueTemplate struct {
+	Name      string
+	Title     string
+	Labels    []string
+	Assignees []string
+
+	body string        // Markdown template body
+	form []formElement // Issue form body
+}
+
+// formElement is one entry of an issue form body.
+type formElement struct {
+	Type    string
+	ID      string
+	Label   string
+	Value   string
+	Options []string
+}
+
+// loadTemplate finds the issue template called name, matching the file name
+// (without extension) first and then the template's display name.
+func (p *Provider) loadTemplate(ctx context.Context, name string) (*issueTemplate, error) {
+	if p.api.Repositories == nil {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "bad_request",
+			Message: "issue templates are not available for this provider",
+		}
+	}
+
+	_, entries, _, err := p.api.Repositories.GetContents(ctx, p.config.Owner, p.config.Repo, templateDir, nil)
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+
+	var candidates []string
+	for _, entry := range entries {
+		file := entry.GetName()
+		ext := strings.ToLower(path.Ext(file))
+		if entry.GetType() != "file" || (ext != ".md" && ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		// config.yml configures the template chooser and is not a template
+		if stem := strings.TrimSuffix(file, path.Ext(file)); strings.EqualFold(stem, "config") {
+			continue
+		} else if strings.EqualFold(stem, name) {
+			return p.fetchTemplate(ctx, entry.GetPath())
+		}
+		candidates = append(candidates, entry.GetPath())
+	}
+
+	for _, candidate := range candidates {
+		tmpl, err := p.fetchTemplate(ctx, candidate)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(tmpl.Name, name) {
+			return tmpl, nil
+		}
+	}
+
+	return nil, &orcherr.OpsOrchError{
+		Code:    "not_found",
+		Message: fmt.Sprintf("issue template not found: %s", name),
+	}
+}
+
+// fetchTemplate downloads and parses the template at filePath.
+func (p *Provider) fetchTemplate(ctx context.Context, filePath string) (*issueTemplate, error) {
+	file, _, _, err := p.api.Repositories.GetContents(ctx, p.config.Owner, p.config.Repo, filePath, nil)
+	if err != nil {
+		return nil, p.wrapError(err)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "provider_error",
+			Message: fmt.Sprintf("reading issue template %s: %v", filePath, err),
+		}
+	}
+
+	var tmpl *issueTemplate
+	if strings.EqualFold(path.Ext(filePath), ".md") {
+		tmpl, err = parseMarkdownTemplate(content)
+	} else {
+		tmpl, err = parseIssueForm(content)
+	}
+	if err != nil {
+		return nil, &orcherr.OpsOrchError{
+			Code:    "provider_error",
+			Message: fmt.Sprintf("invalid issue template %s: %v", filePath, err),
+		}
+	}
+	return tmpl, nil
+}
+
+// parseMarkdownTemplate splits a Markdown template into its front matter and body.
+func parseMarkdownTemplate(content string) (*issueTemplate, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	tmpl := &issueTemplate{body: content}
+
+	if !strings.HasPrefix(content, "---\n") {
+		return tmpl, nil
+	}
+	end := strings.Index(content[4:], "\n---")
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated front matter")
+	}
+	front := content[4 : 4+end]
+	tmpl.body = strings.TrimLeft(strings.TrimPrefix(content[4+end+4:], "\n"), "\n")
+
+	doc, err := miniyaml.Parse([]byte(front))
+	if err != nil {
+		return nil, err
+	}
+	meta, _ := doc.(map[string]any)
+	tmpl.Name, _ = meta["name"].(string)
+	tmpl.Title, _ = meta["title"].(string)
+	tmpl.Labels = yamlList(meta["labels"])
+	tmpl.Assignees = yamlList(meta["assignees"])
+	return tmpl, nil
+}
+
+// parseIssueForm reads the parts of an issue form used to render an issue body.
+func parseIssueForm(content string) (*issueTemplate, error) {
+	doc, err := miniyaml.Parse([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	form, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("issue form must be a mapping")
+	}
+
+	tmpl := &issueTemplate{form: []formElement{}}
+	tmpl.Name, _ = form["name"].(string)
+	tmpl.Title, _ = form["title"].(string)
+	tmpl.Labels = yamlList(form["labels"])
+	tmpl.Assignees = yamlList(form["assignees"])
+
+	body, _ := form["body"].([]any)
+	for _, item := range body {
+		element, _ := item.(map[string]any)
+		attrs, _ := element["attributes"].(map[string]any)
+		e := formElement{Options: yamlList(attrs["options"])}
+		e.Type, _ = element["type"].(string)
+		e.ID, _ = element["id"].(string)
+		e.Label, _ = attrs["label"].(string)
+		e.Value, _ = attrs["value"].(string)
+		// Checkbox options are objects with a label
+		if opts, ok := attrs["options"].([]any); ok && e.Type == "checkboxes" {
+			e.Options = nil
+			for _, opt := range opts {
+				if m, ok := opt.(map[string]any); ok {
+					label, _ := m["label"].(string)
+					e.Options = append(e.Options, label)
+				}
+			}
+		}
+		tmpl.form = append(tmpl.form, e)
+	}
+	return tmpl, nil
+}
+
+// render produces the issue body with values filled in. Markdown templates replace
+// {{ name }} placeholders; issue forms are rendered the way GitHub renders a
+// submitted form, matching values to elements by id and then by label.
+func (t *issueTemplate) render(values map[string]any) string {
+	if t.form == nil {
+		return placeholderPattern.ReplaceAllStringFunc(t.body, func(m string) string {
+			name := placeholderPattern.FindStringSubmatch(m)[1]
+			if v, ok := values[name]; ok {
+				if s := formatValue(v); s != "" {
+					return s
+				}
+			}
+			return noResponse
+		})
+	}
+
+	var sections []string
+	for _, e := range t.form {
+		if e.Type == "markdown" {
+			continue
+		}
+		v, ok := values[e.ID]
+		if !ok || e.ID == "" {
+			v, ok = values[e.Label]
+		}
+
+		var text string
+		if e.Type == "checkboxes" {
+			text = renderCheckboxes(e.Options, v)
+		} else if ok {
+			text = formatValue(v)
+		} else {
+			text = e.Value
+		}
+		if strings.TrimSpace(text) == "" {
+			text = noResponse
+		}
+		sections = append(sections, fmt.Sprintf("### %s\n\n%s", e.Label, strings.TrimRight(text, "\n")))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// renderTitle fills placeholders in the template title. Unlike the body, missing
+// values are dropped so a prefix such as "[Incident]: " stays readable.
+func (t *issueTemplate) renderTitle(values map[string]any) string {
+	return strings.TrimSpace(placeholderPattern.ReplaceAllStringFunc(t.Title, func(m string) string {
+		return formatValue(values[placeholderPattern.FindStringSubmatch(m)[1]])
+	}))
+}
+
+// renderCheckboxes renders checkbox options, ticking those named in v (a list of
+// labels) or all of them when v is true.
+func renderCheckboxes(options []string, v any) string {
+	all, _ := v.(bool)
+	checked := map[string]bool{}
+	for _, label := range yamlList(v) {
+		checked[label] = true
+	}
+	lines := make([]string, len(options))
+	for i, label := range options {
+		mark := " "
+		if all || checked[label] {
+			mark = "X"
+		}
+		lines[i] = fmt.Sprintf("- [%s] %s", mark, label)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatValue renders a field value as text, joining lists with commas.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ", ")
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = formatValue(item)
+		}
+		return strings.Join(parts, ", ")
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = fmt.Sprintf("%s: %s", k, formatValue(v[k]))
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// yamlList accepts a YAML list or a comma-separated string, as GitHub does for
+// template labels and assignees.
+func yamlList(v any) []string {
+	var out []string
+	switch v := v.(type) {
+	case string:
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+	case []string:
+		out = append(out, v...)
+	}
+	return out
+}
+
+// applyTemplate fills issueRequest from the template named in metadata.
+func (p *Provider) applyTemplate(ctx context.Context, name string, title, description string, fields map[string]any, req *github.IssueRequest) error {
+	tmpl, err := p.loadTemplate(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	// Title and description are available to placeholders and form fields too
+	values := map[string]any{"title": title, "description": description}
+	for k, v := range fields {
+		values[k] = v
+	}
+
+	body := tmpl.render(values)
+	req.Body = &body
+	if title == "" {
+		rendered := tmpl.renderTitle(values)
+		req.Title = &rendered
+	}
+	if len(tmpl.Labels) > 0 {
+		labels := mergeUnique(tmpl.Labels, req.GetLabels())
+		req.Labels = &labels
+	}
+	if len(tmpl.Assignees) > 0 {
+		var current []string
+		if req.Assignees != nil {
+			current = *req.Assignees
+		}
+		assignees := mergeUnique(tmpl.Assignees, current)
+		req.Assignees = &assignees
+	}
+	return nil
+}
+
+// mergeUnique returns a followed by the entries of b not already present.
+func mergeUnique(a, b []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range append(append([]string(nil), a...), b...) {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,187 @@
+package ticket
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+const markdownTemplate = `---
+name: Incident
+about: Report a production incident
+title: "[Incident] "
+labels: incident, triage
+assignees: ''
+---
+
+## Summary
+{{ description }}
+
+## Impact
+{{impact}}
+
+Service: {{ service }}
+`
+
+const issueForm = `name: Outage report
+description: Something is down
+title: "[Outage]: "
+labels: ["outage"]
+assignees:
+  - oncall-bot
+body:
+  - type: markdown
+    attributes:
+      value: Thanks for reporting.
+  - type: input
+    id: service
+    attributes:
+      label: Affected service
+  - type: textarea
+    id: timeline
+    attributes:
+      label: Timeline
+      value: |
+        - detected:
+  - type: dropdown
+    id: severity
+    attributes:
+      label: Severity
+      options: [sev1, sev2]
+  - type: textarea
+    attributes:
+      label: Notes
+  - type: checkboxes
+    id: checks
+    attributes:
+      label: Checklist
+      options:
+        - label: Status page updated
+        - label: Customers notified
+`
+
+// serveTemplates registers a template directory listing and its files.
+func serveTemplates(srv *fakegithub.Server, files map[string]string) {
+	base := "/repos/acme/api/contents/.github/ISSUE_TEMPLATE"
+	listing := []map[string]any{{"type": "file", "name": "config.yml", "path": ".github/ISSUE_TEMPLATE/config.yml"}}
+	for name, content := range files {
+		filePath := ".github/ISSUE_TEMPLATE/" + name
+		listing = append(listing, map[string]any{"type": "file", "name": name, "path": filePath})
+		file := map[string]any{
+			"type":     "file",
+			"name":     name,
+			"path":     filePath,
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+		}
+		srv.Handle(http.MethodGet, base+"/"+name, func(w http.ResponseWriter, _ *http.Request) {
+			fakegithub.WriteJSON(w, http.StatusOK, file)
+		})
+	}
+	srv.Handle(http.MethodGet, base, func(w http.ResponseWriter, _ *http.Request) {
+		fakegithub.WriteJSON(w, http.StatusOK, listing)
+	})
+}
+
+func TestRenderMarkdownTemplate(t *testing.T) {
+	tmpl, err := parseMarkdownTemplate(markdownTemplate)
+	if err != nil {
+		t.Fatalf("parseMarkdownTemplate() error = %v", err)
+	}
+	if tmpl.Name != "Incident" || tmpl.Title != "[Incident] " || !reflect.DeepEqual(tmpl.Labels, []string{"incident", "triage"}) || tmpl.Assignees != nil {
+		t.Errorf("unexpected front matter: %+v", tmpl)
+	}
+
+	got := tmpl.render(map[string]any{"description": "Checkout is failing", "service": []any{"checkout", "payments"}})
+	want := "## Summary\nCheckout is failing\n\n## Impact\n_No response_\n\nService: checkout, payments\n"
+	if got != want {
+		t.Errorf("render() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRenderIssueForm(t *testing.T) {
+	tmpl, err := parseIssueForm(issueForm)
+	if err != nil {
+		t.Fatalf("parseIssueForm() error = %v", err)
+	}
+	if tmpl.Name != "Outage report" || !reflect.DeepEqual(tmpl.Assignees, []string{"oncall-bot"}) {
+		t.Errorf("unexpected form: %+v", tmpl)
+	}
+
+	got := tmpl.render(map[string]any{"service": "checkout", "Severity": "sev1", "checks": []string{"Customers notified"}})
+	want := "### Affected service\n\ncheckout\n\n" +
+		"### Timeline\n\n- detected:\n\n" +
+		"### Severity\n\nsev1\n\n" +
+		"### Notes\n\n_No response_\n\n" +
+		"### Checklist\n\n- [ ] Status page updated\n- [X] Customers notified"
+	if got != want {
+		t.Errorf("render() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestCreateWithTemplate(t *testing.T) {
+	p, srv := newFakeProvider(t)
+	serveTemplates(srv, map[string]string{"incident.md": markdownTemplate, "outage.yml": issueForm})
+	srv.Handle(http.MethodGet, "/repos/acme/api/assignees/oncall-bot", fakegithub.NoContent)
+
+	tests := []struct {
+		name       string
+		input      schema.CreateTicketInput
+		wantTitle  string
+		wantLabels []any
+	}{
+		{
+			name: "by file name",
+			input: schema.CreateTicketInput{
+				Title:       "Checkout errors",
+				Description: "500s on /pay",
+				Fields:      map[string]any{"impact": "All EU customers"},
+				Metadata:    map[string]any{"template": "incident", "labels": []string{"sev1"}},
+			},
+			wantTitle:  "Checkout errors",
+			wantLabels: []any{"incident", "triage", "sev1"},
+		},
+		{
+			name: "by display name with template title",
+			input: schema.CreateTicketInput{
+				Fields:   map[string]any{"service": "checkout"},
+				Metadata: map[string]any{"template": "Outage report"},
+			},
+			wantTitle:  "[Outage]:",
+			wantLabels: []any{"outage"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := p.Create(context.Background(), tt.input); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			reqs := srv.Requests()
+			var body map[string]any
+			json.Unmarshal(reqs[len(reqs)-1].Body, &body)
+			if body["title"] != tt.wantTitle || !reflect.DeepEqual(body["labels"], tt.wantLabels) {
+				t.Errorf("request = %v", body)
+			}
+		})
+	}
+
+	if _, err := p.Create(context.Background(), schema.CreateTicketInput{Title: "x", Metadata: map[string]any{"template": "missing"}}); !hasCode(err, "not_found") {
+		t.Errorf("Create() with unknown template error = %v, want not_found", err)
+	}
+
+	noRepos, err := NewWithServices(map[string]any{"repository": "acme/api"}, ghapi.Services{Issues: &stubIssues{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := noRepos.Create(context.Background(), schema.CreateTicketInput{Title: "x", Metadata: map[string]any{"template": "incident"}}); !hasCode(err, "bad_request") {
+		t.Errorf("Create() without a repositories service error = %v, want bad_request", err)
+	}
+}
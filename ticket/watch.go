@@ -0,0 +1,235 @@
+package ticket
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/internal/cache"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/paging"
+)
+
+// WatchOptions controls how Watch follows an issue.
+type WatchOptions struct {
+	Interval    time.Duration // Time between polls (default 30s, minimum 1s)
+	Timeout     time.Duration // Stop watching after this long (default 30m)
+	StopOnClose bool          // Stop as soon as the issue is closed
+}
+
+// Change describes one observed change to a watched issue.
+type Change struct {
+	Type          string        `json:"type"` // comment, labels, or status
+	Ticket        schema.Ticket `json:"ticket"`
+	Comment       *Comment      `json:"comment,omitempty"`
+	AddedLabels   []string      `json:"addedLabels,omitempty"`
+	RemovedLabels []string      `json:"removedLabels,omitempty"`
+	FromStatus    string        `json:"fromStatus,omitempty"`
+	ToStatus      string        `json:"toStatus,omitempty"`
+	ObservedAt    time.Time     `json:"observedAt"`
+}
+
+const (
+	defaultWatchInterval = 30 * time.Second
+	minWatchInterval     = time.Second
+	defaultWatchTimeout  = 30 * time.Minute
+)
+
+// Watch follows an issue for new comments, label changes, and state transitions,
+// calling onChange for each as it is observed, until the timeout elapses (or the issue
+// closes with StopOnClose). It returns every change seen. The issue is polled, and a
+// webhook event for the issue in the same process triggers an immediate
+// check.
+func (p *Provider) Watch(ctx context.Context, id string, opts WatchOptions, onChange func(Change)) ([]Change, error) {
+	target, issueNumber, err := p.target(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := target.watch(ctx, issueNumber, opts, func(c Change) {
+		if onChange != nil {
+			c.Ticket = p.qualify(target, c.Ticket)
+			onChange(c)
+		}
+	})
+	for i := range changes {
+		changes[i].Ticket = p.qualify(target, changes[i].Ticket)
+	}
+	return changes, err
+}
+
+func (p *Provider) watch(ctx context.Context, issueNumber int, opts WatchOptions, onChange func(Change)) ([]Change, error) {
+	id := strconv.Itoa(issueNumber)
+	if opts.Interval <= 0 {
+		opts.Interval = defaultWatchInterval
+	}
+	if opts.Interval < minWatchInterval {
+		opts.Interval = minWatchInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultWatchTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	pushed, stop := cache.Default.Watch(p.recordKey(id))
+	defer stop()
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	last, err := p.fetch(ctx, issueNumber)
+	if err != nil {
+		return nil, err
+	}
+	drain(pushed)
+	commentsSince := time.Now().UTC()
+	seenComments := map[int64]bool{}
+
+	var changes []Change
+	record := func(c Change) {
+		c.ObservedAt = time.Now().UTC()
+		changes = append(changes, c)
+		if onChange != nil {
+			onChange(c)
+		}
+	}
+
+	for {
+		var current schema.Ticket
+		select {
+		case <-ctx.Done():
+			return changes, nil
+		case v := <-pushed:
+			current = v.(schema.Ticket)
+		case <-ticker.C:
+			current, err = p.fetch(ctx, issueNumber)
+			if err != nil {
+				if ctx.Err() != nil {
+					return changes, nil
+				}
+				return changes, err
+			}
+			drain(pushed)
+		}
+
+		for _, c := range diffTickets(last, current) {
+			record(c)
+		}
+		last = current
+
+		comments, err := p.newComments(ctx, issueNumber, commentsSince, seenComments)
+		if err != nil {
+			if ctx.Err() != nil {
+				return changes, nil
+			}
+			return changes, err
+		}
+		for _, comment := range comments {
+			comment := comment
+			record(Change{Type: "comment", Ticket: current, Comment: &comment})
+		}
+
+		if opts.StopOnClose && current.Status == "closed" {
+			return changes, nil
+		}
+	}
+}
+
+// drain discards the notification our own fetch produced when it published the record.
+func drain(ch <-chan any) {
+	select {
+	case <-ch:
+	default:
+	}
+}
+
+// newComments lists comments created since the watch started that have not been
+// seen, across all pages. GitHub's since filters on when a comment was last
+// updated, so it narrows the listing but edits of older comments still come
+// back; those are left out by their creation time.
+func (p *Provider) newComments(ctx context.Context, issueNumber int, since time.Time, seen map[int64]bool) ([]Comment, error) {
+	opts := &github.IssueListCommentsOptions{
+		Since:       &since,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	list := func() ([]*github.IssueComment, *github.Response, error) {
+		ghComments, resp, err := p.api.Issues.ListComments(ctx, p.config.Owner, p.config.Repo, issueNumber, opts)
+		if err != nil {
+			return nil, nil, p.wrapError(err)
+		}
+		return ghComments, resp, nil
+	}
+
+	var comments []Comment
+	visit := func(c *github.IssueComment) (bool, error) {
+		if seen[c.GetID()] || c.GetCreatedAt().Before(since) {
+			return false, nil
+		}
+		seen[c.GetID()] = true
+		comments = append(comments, convertComment(c))
+		return true, nil
+	}
+	// Walk stops every paging.MaxResults comments; resume until the pages run out
+	for token := ""; ; {
+		next, err := paging.Walk(token, paging.MaxResults, &opts.ListOptions, list, visit)
+		if err != nil {
+			return nil, err
+		}
+		if next == "" {
+			return comments, nil
+		}
+		token = next
+	}
+}
+
+// diffTickets reports status and label changes between two snapshots of an issue.
+func diffTickets(before, after schema.Ticket) []Change {
+	var changes []Change
+
+	if before.Status != after.Status {
+		changes = append(changes, Change{
+			Type:       "status",
+			Ticket:     after,
+			FromStatus: before.Status,
+			ToStatus:   after.Status,
+		})
+	}
+
+	beforeLabels := labelSet(before)
+	afterLabels := labelSet(after)
+	var added, removed []string
+	for label := range afterLabels {
+		if !beforeLabels[label] {
+			added = append(added, label)
+		}
+	}
+	for label := range beforeLabels {
+		if !afterLabels[label] {
+			removed = append(removed, label)
+		}
+	}
+	if len(added) > 0 || len(removed) > 0 {
+		sort.Strings(added)
+		sort.Strings(removed)
+		changes = append(changes, Change{
+			Type:          "labels",
+			Ticket:        after,
+			AddedLabels:   added,
+			RemovedLabels: removed,
+		})
+	}
+
+	return changes
+}
+
+func labelSet(t schema.Ticket) map[string]bool {
+	set := map[string]bool{}
+	for _, label := range ghconfig.StringSlice(t.Fields, "labels") {
+		set[label] = true
+	}
+	return set
+}
@@ -0,0 +1,106 @@
+package ticket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+)
+
+func TestDiffTickets(t *testing.T) {
+	before := schema.Ticket{Status: "open", Fields: map[string]any{"labels": []string{"bug", "sev2"}}}
+	after := schema.Ticket{Status: "closed", Fields: map[string]any{"labels": []string{"bug", "sev1"}}}
+
+	changes := diffTickets(before, after)
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2: %+v", len(changes), changes)
+	}
+	if changes[0].Type != "status" || changes[0].FromStatus != "open" || changes[0].ToStatus != "closed" {
+		t.Errorf("unexpected status change: %+v", changes[0])
+	}
+	labels := changes[1]
+	if labels.Type != "labels" || len(labels.AddedLabels) != 1 || labels.AddedLabels[0] != "sev1" ||
+		len(labels.RemovedLabels) != 1 || labels.RemovedLabels[0] != "sev2" {
+		t.Errorf("unexpected label change: %+v", labels)
+	}
+
+	if changes := diffTickets(after, after); len(changes) != 0 {
+		t.Errorf("identical snapshots produced changes: %+v", changes)
+	}
+}
+
+func TestWatchStopOnClose(t *testing.T) {
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/acme/watch/issues/5", func(w http.ResponseWriter, _ *http.Request) {
+		// The issue is closed by the time of the first poll after the watch starts
+		polls++
+		if polls > 1 {
+			w.Write([]byte(`{"number": 5, "state": "closed"}`))
+			return
+		}
+		w.Write([]byte(`{"number": 5, "state": "open"}`))
+	})
+	mux.HandleFunc("/repos/acme/watch/issues/5/comments", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`[{"id": 1, "body": "rolling back", "user": {"login": "octocat"}, "created_at": "2099-01-01T00:00:00Z"}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	p := &Provider{api: ghapi.FromClient(client), config: Config{Owner: "acme", Repo: "watch"}}
+
+	changes, err := p.Watch(context.Background(), "5", WatchOptions{Interval: minWatchInterval, Timeout: 5 * time.Second, StopOnClose: true}, nil)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	if len(changes) != 2 || changes[0].Type != "status" || changes[1].Type != "comment" || changes[1].Comment.Author != "octocat" {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+}
+
+func TestNewComments(t *testing.T) {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/acme/watch/issues/5/comments", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("since") == "" {
+			t.Error("comments listed without since")
+		}
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`[{"id": 3, "body": "third", "created_at": "2030-01-01T10:20:00Z"}]`))
+			return
+		}
+		w.Header().Set("Link", `<`+server.URL+`/repos/acme/watch/issues/5/comments?page=2>; rel="next"`)
+		// Comment 1 predates the watch and was only edited since
+		w.Write([]byte(`[
+			{"id": 1, "body": "edited", "created_at": "2030-01-01T09:00:00Z", "updated_at": "2030-01-01T10:05:00Z"},
+			{"id": 2, "body": "second", "created_at": "2030-01-01T10:10:00Z"}
+		]`))
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	p := &Provider{api: ghapi.FromClient(client), config: Config{Owner: "acme", Repo: "watch"}}
+
+	seen := map[int64]bool{}
+	since := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+	comments, err := p.newComments(context.Background(), 5, since, seen)
+	if err != nil {
+		t.Fatalf("newComments() error = %v", err)
+	}
+	if len(comments) != 2 || comments[0].Body != "second" || comments[1].Body != "third" {
+		t.Errorf("newComments() = %+v", comments)
+	}
+	if again, _ := p.newComments(context.Background(), 5, since, seen); len(again) != 0 {
+		t.Errorf("newComments() repeated seen comments: %+v", again)
+	}
+}
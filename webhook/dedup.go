@@ -0,0 +1,54 @@
+package webhook
+
+import "sync"
+
+// deliveryStore remembers the most recent delivery GUIDs so redeliveries and replays
+// are not emitted twice. It is bounded: once full, the oldest GUID is forgotten.
+type deliveryStore struct {
+	mu    sync.Mutex
+	seen  map[string]struct{}
+	order []string
+	next  int
+}
+
+func newDeliveryStore(capacity int) *deliveryStore {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &deliveryStore{
+		seen:  make(map[string]struct{}, capacity),
+		order: make([]string, 0, capacity),
+	}
+}
+
+// Add records guid and reports whether it was new. Empty GUIDs are never deduplicated.
+func (s *deliveryStore) Add(guid string) bool {
+	if guid == "" {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[guid]; ok {
+		return false
+	}
+
+	if len(s.order) < cap(s.order) {
+		s.order = append(s.order, guid)
+	} else {
+		delete(s.seen, s.order[s.next])
+		s.order[s.next] = guid
+		s.next = (s.next + 1) % len(s.order)
+	}
+	s.seen[guid] = struct{}{}
+	return true
+}
+
+// Seen reports whether guid has already been recorded.
+func (s *deliveryStore) Seen(guid string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.seen[guid]
+	return ok
+}
@@ -0,0 +1,28 @@
+package webhook
+
+import "testing"
+
+func TestDeliveryStore(t *testing.T) {
+	s := newDeliveryStore(2)
+
+	if !s.Add("a") || !s.Add("b") {
+		t.Fatal("first sightings should be new")
+	}
+	if s.Add("a") {
+		t.Error("duplicate GUID reported as new")
+	}
+	if !s.Add("") || !s.Add("") {
+		t.Error("empty GUIDs should never be deduplicated")
+	}
+
+	// Adding a third GUID evicts the oldest
+	if !s.Add("c") {
+		t.Fatal("c should be new")
+	}
+	if s.Seen("a") {
+		t.Error("a should have been evicted")
+	}
+	if !s.Seen("b") || !s.Seen("c") {
+		t.Error("b and c should still be remembered")
+	}
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+)
+
+// hosted is a listener Host started, with the receiver config served at each path.
+type hosted struct {
+	addr    net.Addr
+	mux     *http.ServeMux
+	configs map[string]map[string]any
+}
+
+var (
+	hostsMu sync.Mutex
+	hosts   = map[string]*hosted{}
+)
+
+// Host starts a receiver inside a provider plugin when cfg carries a "webhook"
+// object, so deliveries refresh the response cache the plugin's own providers
+// read. The object holds the receiver settings (addr, path, secret, hookID,
+// dedupCapacity); the rest of cfg, such as token, repository, and cacheTTL,
+// is shared with the providers. The plugin's stdout carries its responses, so
+// events refresh the cache but are not emitted. Each address is listened on once
+// per process, and each path on it serves one receiver: profiles may share an
+// address under different paths, and a second call for a bound address and path
+// returns its address if the config is the same and an error otherwise. Host
+// returns nil without a "webhook" object.
+func Host(cfg map[string]any) (net.Addr, error) {
+	settings, ok := cfg["webhook"].(map[string]any)
+	if !ok {
+		if _, set := cfg["webhook"]; set {
+			return nil, fmt.Errorf("webhook must be an object")
+		}
+		return nil, nil
+	}
+	merged := make(map[string]any, len(cfg)+len(settings))
+	for k, v := range cfg {
+		if k != "webhook" {
+			merged[k] = v
+		}
+	}
+	for k, v := range settings {
+		merged[k] = v
+	}
+
+	addr := ghconfig.String(merged, "addr")
+	if addr == "" {
+		addr = ":8090"
+	}
+	path := ghconfig.String(merged, "path")
+	if path == "" {
+		path = "/webhook"
+	}
+
+	hostsMu.Lock()
+	defer hostsMu.Unlock()
+	host, bound := hosts[addr]
+	if bound {
+		if served, ok := host.configs[path]; ok {
+			if !reflect.DeepEqual(served, merged) {
+				return nil, fmt.Errorf("webhook: %s%s already serves a receiver with another config; give this one its own path", addr, path)
+			}
+			return host.addr, nil
+		}
+	}
+
+	receiver, err := New(merged, func(Event) {})
+	if err != nil {
+		return nil, err
+	}
+	if !bound {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("webhook: %w", err)
+		}
+		host = &hosted{addr: listener.Addr(), mux: http.NewServeMux(), configs: map[string]map[string]any{}}
+		go func() {
+			log.Printf("[webhook] %v", http.Serve(listener, host.mux))
+		}()
+		hosts[addr] = host
+	}
+	host.mux.Handle(path, receiver)
+	host.configs[path] = merged
+
+	log.Printf("[webhook] listening for GitHub webhooks on %s%s", host.addr, path)
+	return host.addr, nil
+}
@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/deployment"
+)
+
+func TestHost(t *testing.T) {
+	if addr, err := Host(map[string]any{"token": "t"}); addr != nil || err != nil {
+		t.Errorf("Host() without webhook = %v, %v", addr, err)
+	}
+	if _, err := Host(map[string]any{"token": "t", "webhook": "on"}); err == nil {
+		t.Error("Host() accepted a non-object webhook")
+	}
+
+	// The plugin's provider config, with the receiver settings under "webhook"
+	cfg := map[string]any{
+		"token":      "t",
+		"mode":       "fixtures",
+		"repository": "opsorch/demo",
+		"cacheTTL":   "5m",
+		"webhook":    map[string]any{"secret": testSecret, "addr": "127.0.0.1:0"},
+	}
+	addr, err := Host(cfg)
+	if err != nil {
+		t.Fatalf("Host() error = %v", err)
+	}
+	if again, err := Host(cfg); err != nil || again != addr {
+		t.Errorf("second Host() = %v, %v, want the running receiver at %v", again, err, addr)
+	}
+
+	// Another profile shares the address under its own path, but not the same path
+	other := map[string]any{
+		"token":      "t",
+		"mode":       "fixtures",
+		"repository": "opsorch/other",
+		"webhook":    map[string]any{"secret": "other-secret", "addr": "127.0.0.1:0"},
+	}
+	if _, err := Host(other); err == nil {
+		t.Error("Host() served another config at a bound address and path")
+	}
+	other["webhook"].(map[string]any)["path"] = "/other"
+	if got, err := Host(other); err != nil || got != addr {
+		t.Errorf("Host() at another path = %v, %v, want the listener at %v", got, err, addr)
+	}
+
+	// The plugin's provider has a running copy of the run cached
+	p, err := deployment.New(cfg)
+	if err != nil {
+		t.Fatalf("deployment.New() error = %v", err)
+	}
+	githubProvider := p.(*deployment.Provider)
+	t.Cleanup(func() { githubProvider.Invalidate("7002") })
+	githubProvider.Prime(schema.Deployment{ID: "7002", Status: "running", Version: "abcdef1", Fields: map[string]any{"jobs": []any{"deploy"}}})
+
+	runBody := `{"action":"completed","workflow_run":{"id":7002,"name":"Deploy","status":"completed","conclusion":"success","head_sha":"abcdef1234"},"repository":{"full_name":"opsorch/demo","name":"demo","owner":{"login":"opsorch"}}}`
+	req := signedRequest("workflow_run", runBody, testSecret)
+	req.RequestURI = ""
+	req.URL.Scheme, req.URL.Host = "http", addr.String()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	// The delivery's status was merged into the cached copy
+	d, err := p.Get(context.Background(), "7002")
+	if err != nil || d.Status != "success" || d.Fields["jobs"] == nil {
+		t.Errorf("Get() = %+v, %v", d, err)
+	}
+
+	// The other profile's receiver checks its own secret
+	req = signedRequest("workflow_run", runBody, "other-secret")
+	req.RequestURI = ""
+	req.URL.Scheme, req.URL.Host, req.URL.Path = "http", addr.String(), "/other"
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("delivery to the other path status = %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+}
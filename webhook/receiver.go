@@ -0,0 +1,398 @@
+// Package webhook receives GitHub webhook deliveries and translates them into
+// normalized OpsOrch ticket, deployment, and team change events.
+package webhook
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/deployment"
+	"github.com/opsorch/opsorch-github-adapter/internal/ghconfig"
+	"github.com/opsorch/opsorch-github-adapter/internal/redact"
+	"github.com/opsorch/opsorch-github-adapter/team"
+	"github.com/opsorch/opsorch-github-adapter/ticket"
+)
+
+// EnvSecret is consulted when the config does not carry a webhook secret.
+const EnvSecret = "GITHUB_WEBHOOK_SECRET"
+
+// Event kinds emitted by the receiver.
+const (
+	KindTicket     = "ticket"
+	KindDeployment = "deployment"
+	KindTeam       = "team"
+)
+
+// Event is a normalized change notification derived from a GitHub webhook delivery.
+type Event struct {
+	ID         string             `json:"id"`                   // X-GitHub-Delivery GUID
+	Kind       string             `json:"kind"`                 // ticket, deployment, or team
+	Action     string             `json:"action,omitempty"`     // GitHub action (opened, completed, added, ...)
+	Source     string             `json:"source"`               // GitHub event name (issues, workflow_run, ...)
+	Repository string             `json:"repository,omitempty"` // owner/name when the event is repository scoped
+	ReceivedAt time.Time          `json:"receivedAt"`
+	Ticket     *schema.Ticket     `json:"ticket,omitempty"`
+	Comment    *Comment           `json:"comment,omitempty"`
+	Deployment *schema.Deployment `json:"deployment,omitempty"`
+	Team       *schema.Team       `json:"team,omitempty"`
+	Member     *schema.TeamMember `json:"member,omitempty"`
+}
+
+// Comment describes an issue comment carried by an issue_comment event.
+type Comment struct {
+	ID        string    `json:"id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	URL       string    `json:"url,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Sink receives every event the receiver emits.
+type Sink func(Event)
+
+// Config holds the configuration for the webhook receiver.
+type Config struct {
+	Secret        string `json:"secret"`        // Webhook secret used to verify X-Hub-Signature-256
+	HookID        int64  `json:"hookID"`        // Hook whose deliveries Replay fetches
+	Owner         string `json:"owner"`         // Repository owner of a repository hook
+	Repo          string `json:"repo"`          // Repository name of a repository hook
+	Organization  string `json:"organization"`  // Organization of an organization hook
+	DedupCapacity int    `json:"dedupCapacity"` // Number of delivery GUIDs remembered for dedup
+}
+
+// defaultDedupCapacity comfortably covers GitHub's redelivery window for busy repositories.
+const defaultDedupCapacity = 10000
+
+// Receiver is an http.Handler that verifies and translates GitHub webhook deliveries.
+type Receiver struct {
+	config     Config
+	provider   map[string]any // base config used to build normalizing providers
+	sink       Sink
+	client     *github.Client
+	deliveries *deliveryStore
+
+	mu          sync.Mutex
+	tickets     map[string]*ticket.Provider
+	deployments map[string]*deployment.Provider
+	teams       map[string]*team.Provider
+}
+
+// New creates a webhook receiver that emits translated events to sink.
+// The config carries the webhook secret plus the provider config (token, optional
+// owner/repo/organization, cacheTTL) used to normalize payloads and refresh the cache.
+func New(cfg map[string]any, sink Sink) (*Receiver, error) {
+	var config Config
+
+	// Parse secret, falling back to GITHUB_WEBHOOK_SECRET
+	config.Secret = ghconfig.String(cfg, "secret")
+	if config.Secret == "" {
+		config.Secret = ghconfig.Env(EnvSecret)
+	}
+	if config.Secret == "" {
+		return nil, fmt.Errorf("secret is required")
+	}
+
+	// Providers are only used for normalization, but still need a token
+	token := ghconfig.Token(cfg)
+	if token == "" {
+		return nil, fmt.Errorf("token is required")
+	}
+
+	// Mask the secret, token, and "redactPatterns" in logs and errors
+	if err := ghconfig.Redaction(cfg); err != nil {
+		return nil, err
+	}
+	redact.Default.AddSecrets(config.Secret)
+
+	// Parse the hook used for replay (optional)
+	config.HookID = int64(ghconfig.Int(cfg, "hookID", 0))
+	if ghconfig.String(cfg, "organization") != "" {
+		config.Organization = ghconfig.String(cfg, "organization")
+	} else {
+		owner, repo, err := ghconfig.Repository(cfg)
+		if err != nil {
+			return nil, err
+		}
+		config.Owner = owner
+		config.Repo = repo
+	}
+
+	config.DedupCapacity = ghconfig.Int(cfg, "dedupCapacity", defaultDedupCapacity)
+
+	if sink == nil {
+		return nil, fmt.Errorf("sink is required")
+	}
+
+	return &Receiver{
+		config:      config,
+		provider:    cfg,
+		sink:        sink,
+		client:      ghconfig.Client(cfg, token),
+		deliveries:  newDeliveryStore(config.DedupCapacity),
+		tickets:     map[string]*ticket.Provider{},
+		deployments: map[string]*deployment.Provider{},
+		teams:       map[string]*team.Provider{},
+	}, nil
+}
+
+// ServeHTTP verifies the delivery signature and emits the translated event, if any.
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	signature := req.Header.Get(github.SHA256SignatureHeader)
+	if signature == "" {
+		http.Error(w, "missing "+github.SHA256SignatureHeader, http.StatusUnauthorized)
+		return
+	}
+
+	payload, err := github.ValidatePayloadFromBody(req.Header.Get("Content-Type"), io.LimitReader(req.Body, maxPayloadBytes), signature, []byte(r.config.Secret))
+	if err != nil {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event, ok, err := r.Translate(github.WebHookType(req), github.DeliveryID(req), payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Redeliveries reuse the original GUID; acknowledge them without re-emitting
+	if !r.deliveries.Add(event.ID) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	r.emit(event)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// emit refreshes the shared response cache from the event's record and hands the
+// event to the sink. Ticket and deployment records lack what Get adds, so they are
+// published, which merges their state into the copies in-process providers cached
+// and hands them to Watch callers; teams are primed. Deleted issues, and issues
+// with a deployment link comment, which changes what Get reads from the
+// comments, are dropped from the cache.
+func (r *Receiver) emit(event Event) {
+	switch {
+	case event.Ticket != nil:
+		if p, err := r.ticketProvider(event.Repository); err == nil {
+			if event.Action != "deleted" {
+				p.Publish(*event.Ticket)
+			}
+			if event.Action == "deleted" || isLinkComment(event.Comment) {
+				p.Invalidate(event.Ticket.ID)
+			}
+		}
+	case event.Deployment != nil:
+		if p, err := r.deploymentProvider(event.Repository); err == nil {
+			p.Publish(*event.Deployment)
+		}
+	case event.Team != nil && event.Team.ID != "":
+		if org := event.Team.Tags["organization"]; org != "" {
+			if p, err := r.teamProvider(org); err == nil {
+				p.Prime(*event.Team)
+			}
+		}
+	}
+
+	r.sink(event)
+}
+
+// isLinkComment reports whether c is a comment ticket.LinkDeployment posted.
+func isLinkComment(c *Comment) bool {
+	if c == nil {
+		return false
+	}
+	_, _, ok := ticket.LinkedDeployment(ticket.Comment{Body: c.Body})
+	return ok
+}
+
+// maxPayloadBytes caps webhook bodies; GitHub itself caps deliveries at 25 MB.
+const maxPayloadBytes = 25 << 20
+
+// Translate converts a raw webhook payload into an Event. It returns ok=false for
+// event types or actions the receiver does not translate (including ping).
+func (r *Receiver) Translate(eventType, deliveryID string, payload []byte) (Event, bool, error) {
+	parsed, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		return Event{}, false, nil
+	}
+
+	event := Event{
+		ID:         deliveryID,
+		Source:     eventType,
+		ReceivedAt: time.Now().UTC(),
+	}
+
+	switch e := parsed.(type) {
+	case *github.IssuesEvent:
+		p, err := r.ticketProvider(e.GetRepo().GetFullName())
+		if err != nil {
+			return Event{}, false, err
+		}
+		ticket := p.ConvertIssue(e.GetIssue())
+		event.Kind = KindTicket
+		event.Action = e.GetAction()
+		event.Repository = e.GetRepo().GetFullName()
+		event.Ticket = &ticket
+
+	case *github.IssueCommentEvent:
+		// Comments on pull requests arrive through the same event type
+		if e.GetIssue().IsPullRequest() {
+			return Event{}, false, nil
+		}
+		p, err := r.ticketProvider(e.GetRepo().GetFullName())
+		if err != nil {
+			return Event{}, false, err
+		}
+		ticket := p.ConvertIssue(e.GetIssue())
+		comment := e.GetComment()
+		event.Kind = KindTicket
+		event.Action = "comment_" + e.GetAction()
+		event.Repository = e.GetRepo().GetFullName()
+		event.Ticket = &ticket
+		event.Comment = &Comment{
+			ID:        strconv.FormatInt(comment.GetID(), 10),
+			Author:    comment.GetUser().GetLogin(),
+			Body:      comment.GetBody(),
+			URL:       comment.GetHTMLURL(),
+			CreatedAt: comment.GetCreatedAt().Time,
+		}
+
+	case *github.WorkflowRunEvent:
+		p, err := r.deploymentProvider(e.GetRepo().GetFullName())
+		if err != nil {
+			return Event{}, false, err
+		}
+		deployment := p.ConvertWorkflowRun(e.GetWorkflowRun())
+		event.Kind = KindDeployment
+		event.Action = e.GetAction()
+		event.Repository = e.GetRepo().GetFullName()
+		event.Deployment = &deployment
+
+	case *github.DeploymentStatusEvent:
+		p, err := r.deploymentProvider(e.GetRepo().GetFullName())
+		if err != nil {
+			return Event{}, false, err
+		}
+		deployment := p.ConvertDeployment(e.GetDeployment(), e.GetDeploymentStatus())
+		event.Kind = KindDeployment
+		event.Action = e.GetDeploymentStatus().GetState()
+		event.Repository = e.GetRepo().GetFullName()
+		event.Deployment = &deployment
+
+	case *github.MembershipEvent:
+		p, err := r.teamProvider(e.GetOrg().GetLogin())
+		if err != nil {
+			return Event{}, false, err
+		}
+		team := p.ConvertTeam(e.GetTeam())
+		member := e.GetMember()
+		event.Kind = KindTeam
+		event.Action = e.GetAction()
+		event.Team = &team
+		event.Member = &schema.TeamMember{
+			ID:     member.GetLogin(),
+			Name:   member.GetLogin(),
+			Handle: member.GetLogin(),
+			Metadata: map[string]any{
+				"github_id": member.GetID(),
+				"html_url":  member.GetHTMLURL(),
+				"type":      member.GetType(),
+			},
+		}
+
+	default:
+		return Event{}, false, nil
+	}
+
+	return event, true, nil
+}
+
+// ticketProvider returns a ticket provider configured for the event's repository.
+func (r *Receiver) ticketProvider(name string) (*ticket.Provider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p, ok := r.tickets[name]; ok {
+		return p, nil
+	}
+	p, err := ticket.New(r.repositoryConfig(name))
+	if err != nil {
+		return nil, err
+	}
+	githubProvider := p.(*ticket.Provider)
+	r.tickets[name] = githubProvider
+	return githubProvider, nil
+}
+
+// deploymentProvider returns a deployment provider configured for the event's repository.
+func (r *Receiver) deploymentProvider(name string) (*deployment.Provider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p, ok := r.deployments[name]; ok {
+		return p, nil
+	}
+	p, err := deployment.New(r.repositoryConfig(name))
+	if err != nil {
+		return nil, err
+	}
+	githubProvider := p.(*deployment.Provider)
+	r.deployments[name] = githubProvider
+	return githubProvider, nil
+}
+
+// teamProvider returns a team provider configured for the event's organization.
+func (r *Receiver) teamProvider(org string) (*team.Provider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p, ok := r.teams[org]; ok {
+		return p, nil
+	}
+	cfg := r.copyConfig()
+	if org != "" {
+		cfg["organization"] = org
+	}
+	p, err := team.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	githubProvider := p.(*team.Provider)
+	r.teams[org] = githubProvider
+	return githubProvider, nil
+}
+
+// repositoryConfig returns the base provider config pointed at the given repository.
+func (r *Receiver) repositoryConfig(fullName string) map[string]any {
+	cfg := r.copyConfig()
+	if fullName != "" {
+		delete(cfg, "owner")
+		delete(cfg, "repo")
+		cfg["repository"] = fullName
+	}
+	return cfg
+}
+
+func (r *Receiver) copyConfig() map[string]any {
+	cfg := make(map[string]any, len(r.provider)+1)
+	for k, v := range r.provider {
+		cfg[k] = v
+	}
+	return cfg
+}
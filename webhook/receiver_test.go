@@ -0,0 +1,240 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opsorch/opsorch-core/schema"
+	"github.com/opsorch/opsorch-github-adapter/deployment"
+	"github.com/opsorch/opsorch-github-adapter/ghapi"
+	"github.com/opsorch/opsorch-github-adapter/internal/fakegithub"
+)
+
+const testSecret = "s3cret"
+
+func newTestReceiver(t *testing.T) (*Receiver, *[]Event) {
+	t.Helper()
+	var events []Event
+	r, err := New(map[string]any{"secret": testSecret, "token": "ghp_test_token"}, func(e Event) {
+		events = append(events, e)
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return r, &events
+}
+
+func signedRequest(eventType, body, secret string) *http.Request {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", eventType)
+	req.Header.Set("X-GitHub-Delivery", "delivery-1")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv(EnvSecret, "")
+
+	sink := func(Event) {}
+	if _, err := New(map[string]any{"token": "t"}, sink); err == nil {
+		t.Error("expected error for missing secret")
+	}
+	if _, err := New(map[string]any{"secret": "s"}, sink); err == nil {
+		t.Error("expected error for missing token")
+	}
+	if _, err := New(map[string]any{"secret": "s", "token": "t"}, nil); err == nil {
+		t.Error("expected error for missing sink")
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	issueBody := `{"action":"opened","issue":{"number":42,"title":"Outage","state":"open","html_url":"https://github.com/acme/api/issues/42"},"repository":{"full_name":"acme/api","name":"api","owner":{"login":"acme"}}}`
+	runBody := `{"action":"completed","workflow_run":{"id":7,"name":"Deploy to Production","status":"completed","conclusion":"failure","head_branch":"main","head_sha":"abcdef1234"},"repository":{"full_name":"acme/api","name":"api","owner":{"login":"acme"}}}`
+	membershipBody := `{"action":"added","scope":"team","member":{"login":"octocat","id":1},"team":{"id":9,"slug":"sre","name":"SRE"},"organization":{"login":"acme"}}`
+
+	t.Run("issue opened", func(t *testing.T) {
+		r, events := newTestReceiver(t)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, signedRequest("issues", issueBody, testSecret))
+
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusAccepted)
+		}
+		if len(*events) != 1 {
+			t.Fatalf("got %d events, want 1", len(*events))
+		}
+		e := (*events)[0]
+		if e.Kind != KindTicket || e.Action != "opened" || e.Ticket == nil || e.Ticket.ID != "42" || e.Repository != "acme/api" {
+			t.Errorf("unexpected event: %+v", e)
+		}
+	})
+
+	t.Run("workflow run completed", func(t *testing.T) {
+		r, events := newTestReceiver(t)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, signedRequest("workflow_run", runBody, testSecret))
+
+		if w.Code != http.StatusAccepted || len(*events) != 1 {
+			t.Fatalf("status = %d, events = %d", w.Code, len(*events))
+		}
+		d := (*events)[0].Deployment
+		if d == nil || d.ID != "7" || d.Status != "failed" || d.Service != "api" {
+			t.Errorf("unexpected deployment: %+v", d)
+		}
+	})
+
+	t.Run("membership added", func(t *testing.T) {
+		r, events := newTestReceiver(t)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, signedRequest("membership", membershipBody, testSecret))
+
+		if w.Code != http.StatusAccepted || len(*events) != 1 {
+			t.Fatalf("status = %d, events = %d", w.Code, len(*events))
+		}
+		e := (*events)[0]
+		if e.Kind != KindTeam || e.Team.ID != "sre" || e.Member.Handle != "octocat" {
+			t.Errorf("unexpected event: %+v", e)
+		}
+	})
+
+	t.Run("redelivery deduplicated", func(t *testing.T) {
+		r, events := newTestReceiver(t)
+		first := httptest.NewRecorder()
+		r.ServeHTTP(first, signedRequest("issues", issueBody, testSecret))
+		second := httptest.NewRecorder()
+		r.ServeHTTP(second, signedRequest("issues", issueBody, testSecret))
+
+		if first.Code != http.StatusAccepted || second.Code != http.StatusOK {
+			t.Errorf("status codes = %d, %d", first.Code, second.Code)
+		}
+		if len(*events) != 1 {
+			t.Errorf("got %d events, want 1", len(*events))
+		}
+	})
+
+	t.Run("bad signature", func(t *testing.T) {
+		r, events := newTestReceiver(t)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, signedRequest("issues", issueBody, "wrong"))
+
+		if w.Code != http.StatusUnauthorized || len(*events) != 0 {
+			t.Errorf("status = %d, events = %d", w.Code, len(*events))
+		}
+	})
+
+	t.Run("ping ignored", func(t *testing.T) {
+		r, events := newTestReceiver(t)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, signedRequest("ping", `{"zen":"Keep it logically awesome."}`, testSecret))
+
+		if w.Code != http.StatusNoContent || len(*events) != 0 {
+			t.Errorf("status = %d, events = %d", w.Code, len(*events))
+		}
+	})
+}
+
+func TestReplay(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/acme/api/hooks/5/deliveries", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`[
+			{"id": 6, "guid": "g4", "event": "issues", "status_code": 200, "delivered_at": "2030-01-01T00:06:00Z"},
+			{"id": 5, "guid": "g5", "event": "issues", "status_code": 202, "delivered_at": "2030-01-01T00:05:00Z"},
+			{"id": 4, "guid": "g4", "event": "issues", "status_code": 502, "delivered_at": "2030-01-01T00:04:00Z"},
+			{"id": 3, "guid": "g3", "event": "issues", "status_code": 502, "delivered_at": "2030-01-01T00:03:00Z"},
+			{"id": 2, "guid": "g2", "event": "issues", "status_code": 0, "delivered_at": "2030-01-01T00:02:00Z"},
+			{"id": 1, "guid": "g1", "event": "issues", "status_code": 502, "delivered_at": "2020-01-01T00:00:00Z"}
+		]`))
+	})
+	for _, id := range []string{"2", "3", "4", "5", "6"} {
+		id := id
+		mux.HandleFunc("/repos/acme/api/hooks/5/deliveries/"+id, func(w http.ResponseWriter, _ *http.Request) {
+			w.Write([]byte(`{"id": ` + id + `, "guid": "g` + id + `", "event": "issues", "request": {"payload": {"action":"closed","issue":{"number":` + id + `,"state":"closed"},"repository":{"full_name":"acme/api"}}}}`))
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var events []Event
+	r, err := New(map[string]any{"secret": testSecret, "token": "t", "repository": "acme/api", "hookID": float64(5)}, func(e Event) {
+		events = append(events, e)
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	r.client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	// g3 was already received live and must not be emitted again. g5 was
+	// answered 2xx, and g4 redelivered with a 2xx answer, before a restart
+	// cleared the remembered GUIDs, so they were handled too.
+	r.deliveries.Add("g3")
+
+	n, err := r.Replay(context.Background(), time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if n != 1 || len(events) != 1 || events[0].ID != "g2" || events[0].Ticket.ID != "2" {
+		t.Errorf("Replay() = %d, events = %+v", n, events)
+	}
+}
+
+func TestEventRefreshesCache(t *testing.T) {
+	runBody := `{"action":"completed","workflow_run":{"id":1001,"name":"Deploy","status":"completed","conclusion":"success","head_sha":"abcdef1234"},"repository":{"full_name":"acme/api","name":"api","owner":{"login":"acme"}}}`
+
+	srv := fakegithub.New(t)
+	p, err := deployment.NewWithServices(map[string]any{"repository": "acme/api", "cacheTTL": "5m"}, ghapi.FromClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("deployment.NewWithServices() error = %v", err)
+	}
+	t.Cleanup(func() { p.Invalidate("1001") })
+	p.Prime(schema.Deployment{ID: "1001", Status: "running", Version: "abcdef1", Fields: map[string]any{"jobs": []any{"build"}}})
+
+	r, err := New(map[string]any{"secret": testSecret, "token": "t", "cacheTTL": "5m"}, func(Event) {})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, signedRequest("workflow_run", runBody, testSecret))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d", w.Code)
+	}
+
+	// The delivery's state was merged into the cached copy, which keeps its jobs
+	d, err := p.Get(context.Background(), "1001")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if d.Status != "success" {
+		t.Errorf("Status = %q, want success", d.Status)
+	}
+	if _, ok := d.Fields["jobs"]; !ok {
+		t.Errorf("Fields = %v, want the cached jobs", d.Fields)
+	}
+	if reqs := srv.Requests(); len(reqs) != 0 {
+		t.Errorf("Get() after the delivery made %d API calls, want 0", len(reqs))
+	}
+
+	// A run of another commit cannot be merged, so the copy is dropped and
+	// Get reads the run again
+	req := signedRequest("workflow_run", strings.Replace(runBody, "abcdef1234", "1234567890", 1), testSecret)
+	req.Header.Set("X-GitHub-Delivery", "delivery-2")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if _, err := p.Get(context.Background(), "1001"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(srv.Requests()) == 0 {
+		t.Error("Get() after an unmergeable delivery was served from the cache")
+	}
+}
@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// Replay fetches deliveries made to the configured hook since the given time through
+// the webhook deliveries API and emits any that were not already processed, oldest
+// first. It returns the number of events emitted. Use it after downtime to catch up
+// on deliveries GitHub attempted while the receiver was unreachable.
+//
+// Only deliveries that never got a 2xx answer are replayed. The receiver answers
+// 2xx once it has emitted an event, and the GUIDs it remembers do not survive a
+// restart, so a delivery answered 2xx, or redelivered with a 2xx answer, was
+// already handled.
+func (r *Receiver) Replay(ctx context.Context, since time.Time) (int, error) {
+	if r.config.HookID == 0 {
+		return 0, fmt.Errorf("hookID is required for replay")
+	}
+	if r.config.Organization == "" && (r.config.Owner == "" || r.config.Repo == "") {
+		return 0, fmt.Errorf("owner/repo or organization is required for replay")
+	}
+
+	var pending []*github.HookDelivery
+	handled := map[string]bool{}
+	opts := &github.ListCursorOptions{PerPage: 100}
+	for {
+		deliveries, resp, err := r.listDeliveries(ctx, opts)
+		if err != nil {
+			return 0, fmt.Errorf("listing hook deliveries: %w", err)
+		}
+
+		done := false
+		for _, d := range deliveries {
+			// Deliveries are listed newest first
+			if d.GetDeliveredAt().Time.Before(since) {
+				done = true
+				break
+			}
+			if code := d.GetStatusCode(); code >= 200 && code < 300 {
+				handled[d.GetGUID()] = true
+				continue
+			}
+			if r.deliveries.Seen(d.GetGUID()) {
+				continue
+			}
+			pending = append(pending, d)
+		}
+
+		if done || resp == nil || resp.Cursor == "" {
+			break
+		}
+		opts.Cursor = resp.Cursor
+	}
+
+	emitted := 0
+	for i := len(pending) - 1; i >= 0; i-- {
+		summary := pending[i]
+		// Redeliveries share a GUID; skip any answered 2xx or already handled in this pass
+		if handled[summary.GetGUID()] || r.deliveries.Seen(summary.GetGUID()) {
+			continue
+		}
+
+		full, err := r.getDelivery(ctx, summary.GetID())
+		if err != nil {
+			return emitted, fmt.Errorf("fetching hook delivery %d: %w", summary.GetID(), err)
+		}
+		if full.Request == nil || full.Request.RawPayload == nil {
+			continue
+		}
+
+		event, ok, err := r.Translate(full.GetEvent(), full.GetGUID(), *full.Request.RawPayload)
+		if err != nil {
+			return emitted, err
+		}
+		if !ok || !r.deliveries.Add(full.GetGUID()) {
+			continue
+		}
+		r.emit(event)
+		emitted++
+	}
+
+	return emitted, nil
+}
+
+func (r *Receiver) listDeliveries(ctx context.Context, opts *github.ListCursorOptions) ([]*github.HookDelivery, *github.Response, error) {
+	if r.config.Organization != "" {
+		return r.client.Organizations.ListHookDeliveries(ctx, r.config.Organization, r.config.HookID, opts)
+	}
+	return r.client.Repositories.ListHookDeliveries(ctx, r.config.Owner, r.config.Repo, r.config.HookID, opts)
+}
+
+func (r *Receiver) getDelivery(ctx context.Context, id int64) (*github.HookDelivery, error) {
+	if r.config.Organization != "" {
+		d, _, err := r.client.Organizations.GetHookDelivery(ctx, r.config.Organization, r.config.HookID, id)
+		return d, err
+	}
+	d, _, err := r.client.Repositories.GetHookDelivery(ctx, r.config.Owner, r.config.Repo, r.config.HookID, id)
+	return d, err
+}